@@ -2,162 +2,522 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
+	"net"
 	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/not7/core/execution"
+	"github.com/not7/core/server"
+	"github.com/not7/core/spec"
 )
 
+// healthCheckTimeout bounds health checks so an unresponsive server fails fast
+const healthCheckTimeout = 5 * time.Second
+
 // NOT7Client is an HTTP client for the NOT7 API
 type NOT7Client struct {
 	baseURL    string
 	httpClient *http.Client
+	timeout    time.Duration // Default per-call timeout (0 = none)
+	retry      RetryPolicy
+	apiKey     string      // Sent as a bearer token when set
+	headers    http.Header // Custom headers added to every request
+
+	// local runs agents in-process instead of over HTTP (embedded mode)
+	local *execution.Manager
 }
 
 // NewClient creates a new NOT7 API client
-func NewClient(baseURL string) *NOT7Client {
+// Cancellation and deadlines are controlled per call through the context
+// An empty baseURL falls back to NOT7_SERVER_URL, then http://localhost:8080;
+// NewAutoClient runs agents in-process instead when neither is set
+// The API key defaults to the NOT7_API_KEY environment variable
+func NewClient(baseURL string, opts ...Option) *NOT7Client {
+	if baseURL == "" {
+		baseURL = os.Getenv("NOT7_SERVER_URL")
+	}
 	if baseURL == "" {
 		baseURL = "http://localhost:8080"
 	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	c := &NOT7Client{
+		baseURL:    baseURL,
+		httpClient: &http.Client{},
+		retry:      DefaultRetryPolicy,
+		apiKey:     os.Getenv("NOT7_API_KEY"),
+		headers:    make(http.Header),
+	}
 
-	return &NOT7Client{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 5 * time.Minute, // Long timeout for sync operations
-		},
+	for _, opt := range opts {
+		opt(c)
 	}
+
+	return c
+}
+
+// RunOptions configures a single agent run
+type RunOptions struct {
+	// Async returns as soon as the execution is queued
+	Async bool
+
+	// Stream enables live streaming of agent reasoning
+	Stream bool
+
+	// BatchAPI sends the run's OpenAI calls through the Batch API, for half
+	// the cost and answers within 24 hours. It requires Async.
+	BatchAPI bool
+
+	// Input is delivered to the agent's first node(s)
+	Input string
+
+	// Vars override the spec's vars (see spec.AgentSpec.Render)
+	Vars map[string]string
+
+	// Tags label the execution, e.g. with an experiment and its variant
+	Tags map[string]string
+
+	// Attachments are files the agent reads with the ReadAttachment tool.
+	// They are uploaded as a multipart body.
+	Attachments []Attachment
+}
+
+// BaseURL returns the server URL the client talks to
+func (c *NOT7Client) BaseURL() string {
+	return c.baseURL
+}
+
+// ViewURL returns the address of an execution's trace viewer page, or ""
+// in embedded mode, which serves no pages
+func (c *NOT7Client) ViewURL(execID string) string {
+	if c.local != nil {
+		return ""
+	}
+	return strings.TrimSuffix(c.baseURL, "/") + "/executions/" + url.PathEscape(execID) + "/view"
 }
 
 // RunAgent executes an agent (sync or async, with optional stream)
-func (c *NOT7Client) RunAgent(agentJSON []byte, async bool, stream bool) (map[string]interface{}, error) {
-	url := c.baseURL + "/api/v1/run?"
+// For async runs the returned execution only carries the ID and initial status
+func (c *NOT7Client) RunAgent(ctx context.Context, agentJSON []byte, opts RunOptions) (*Execution, error) {
+	if c.local != nil {
+		return c.runLocal(ctx, agentJSON, opts)
+	}
 
-	params := []string{}
-	if async {
-		params = append(params, "async=true")
+	params := url.Values{}
+	if opts.Async {
+		params.Set("async", "true")
+	}
+	if opts.Stream {
+		params.Set("stream", "true")
 	}
-	if stream {
-		params = append(params, "stream=true")
+	if opts.BatchAPI {
+		params.Set("batch_api", "true")
 	}
 
+	path := "/api/v1/run"
 	if len(params) > 0 {
-		url += params[0]
-		for i := 1; i < len(params); i++ {
-			url += "&" + params[i]
+		path += "?" + params.Encode()
+	}
+
+	body := agentJSON
+	if opts.Input != "" || len(opts.Vars) > 0 || len(opts.Tags) > 0 {
+		// Wrap the spec so the input, vars and tags travel with it
+		wrapped, err := json.Marshal(map[string]interface{}{
+			"spec":  json.RawMessage(agentJSON),
+			"input": opts.Input,
+			"vars":  opts.Vars,
+			"tags":  opts.Tags,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON specification: %w", err)
 		}
+		body = wrapped
 	}
 
-	resp, err := c.httpClient.Post(url, "application/json", bytes.NewBuffer(agentJSON))
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to server: %w", err)
+	contentType := "application/json"
+	if len(opts.Attachments) > 0 {
+		var err error
+		if body, contentType, err = multipartRunBody(body, opts.Attachments); err != nil {
+			return nil, err
+		}
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	var exec Execution
+	if err := c.doContent(ctx, http.MethodPost, path, contentType, body, &exec); err != nil {
+		return nil, err
+	}
+
+	return &exec, nil
+}
+
+// RunDeployedAgent runs a deployed agent by ID, with opts.Input as the
+// request body. Attachments are not supported on this route.
+func (c *NOT7Client) RunDeployedAgent(ctx context.Context, agentID string, opts RunOptions) (*Execution, error) {
+	if c.local != nil {
+		return nil, fmt.Errorf("embedded mode has no agent registry")
+	}
+	if len(opts.Attachments) > 0 {
+		return nil, fmt.Errorf("attachments are not supported when running a deployed agent")
+	}
+
+	params := url.Values{}
+	if opts.Async {
+		params.Set("async", "true")
+	}
+	if opts.Stream {
+		params.Set("stream", "true")
+	}
+	if opts.BatchAPI {
+		params.Set("batch_api", "true")
+	}
+	for name, value := range opts.Vars {
+		params.Add("var", name+"="+value)
+	}
+	for key, value := range opts.Tags {
+		params.Add("tag", key+"="+value)
 	}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	path := "/api/v1/agents/" + url.PathEscape(agentID) + "/run"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
 	}
 
-	if resp.StatusCode >= 400 {
-		return result, fmt.Errorf("server error: %s", result["error"])
+	var exec Execution
+	if err := c.do(ctx, http.MethodPost, path, []byte(opts.Input), &exec); err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	return &exec, nil
+}
+
+// multipartRunBody builds a multipart run body: the JSON run body in the
+// "spec" field and the attachments as files
+func multipartRunBody(runBody []byte, attachments []Attachment) ([]byte, string, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	if err := mw.WriteField("spec", string(runBody)); err != nil {
+		return nil, "", err
+	}
+	for _, attachment := range attachments {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename=%q`, attachment.Name))
+		if attachment.ContentType != "" {
+			header.Set("Content-Type", attachment.ContentType)
+		}
+		part, err := mw.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := part.Write(attachment.Data); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), mw.FormDataContentType(), nil
 }
 
 // GetExecution gets the full execution details (status + result if available)
-func (c *NOT7Client) GetExecution(execID string) (map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/api/v1/executions/%s", c.baseURL, execID)
+func (c *NOT7Client) GetExecution(ctx context.Context, execID string) (*Execution, error) {
+	if c.local != nil {
+		exec, err := c.getLocal(ctx, execID)
+		if err != nil {
+			return nil, err
+		}
+		return server.NewExecutionResponse(exec), nil
+	}
 
-	resp, err := c.httpClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to server: %w", err)
+	var exec Execution
+	if err := c.do(ctx, http.MethodGet, "/api/v1/executions/"+url.PathEscape(execID), nil, &exec); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	return &exec, nil
+}
+
+// GetStatus gets the lightweight status and progress of an execution
+func (c *NOT7Client) GetStatus(ctx context.Context, execID string) (*ExecutionStatus, error) {
+	if c.local != nil {
+		info, err := c.local.GetInfo(ctx, execID)
+		if err == execution.ErrExecutionNotFound {
+			return nil, &APIError{StatusCode: http.StatusNotFound, Message: "Execution not found"}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return server.NewExecutionStatus(info), nil
+	}
+
+	var status ExecutionStatus
+	if err := c.do(ctx, http.MethodGet, "/api/v1/executions/"+url.PathEscape(execID)+"/status", nil, &status); err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// GetExecutionResult gets the final result of a finished execution
+func (c *NOT7Client) GetExecutionResult(ctx context.Context, execID string) (*Execution, error) {
+	if c.local != nil {
+		exec, err := c.getLocal(ctx, execID)
+		if err != nil {
+			return nil, err
+		}
+		if !exec.Status.IsTerminal() {
+			return nil, &APIError{StatusCode: http.StatusConflict, Message: fmt.Sprintf("Execution is still %s", exec.Status)}
+		}
+		return server.NewExecutionResponse(exec), nil
+	}
+
+	var exec Execution
+	if err := c.do(ctx, http.MethodGet, "/api/v1/executions/"+url.PathEscape(execID)+"/result", nil, &exec); err != nil {
+		return nil, err
+	}
+
+	return &exec, nil
+}
+
+// GetTrace gets the full execution trace: the agent spec annotated with
+// execution metadata, node results and ReAct thinking steps
+func (c *NOT7Client) GetTrace(ctx context.Context, execID string) (*spec.AgentSpec, error) {
+	if c.local != nil {
+		data, err := c.local.GetTrace(ctx, execID)
+		if err == execution.ErrExecutionNotFound {
+			return nil, &APIError{StatusCode: http.StatusNotFound, Message: "Execution not found"}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return parseTrace(data)
+	}
+
+	var trace spec.AgentSpec
+	if err := c.do(ctx, http.MethodGet, "/api/v1/executions/"+url.PathEscape(execID)+"/trace", nil, &trace); err != nil {
+		return nil, err
 	}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	return &trace, nil
+}
+
+// GetNodeResults gets a page of the node results in an execution's trace,
+// without fetching the whole trace. A limit of 0 uses the server's default.
+func (c *NOT7Client) GetNodeResults(ctx context.Context, execID string, offset, limit int) (*NodeResultPage, error) {
+	if c.local != nil {
+		if limit == 0 {
+			limit = server.DefaultNodeResultLimit
+		}
+		page := &NodeResultPage{ID: execID, NodeResults: []spec.NodeResult{}, Limit: limit, Offset: offset}
+		total, err := c.local.EachNodeResult(ctx, execID, offset, limit, func(raw json.RawMessage) error {
+			var result spec.NodeResult
+			if err := json.Unmarshal(raw, &result); err != nil {
+				return err
+			}
+			page.NodeResults = append(page.NodeResults, result)
+			return nil
+		})
+		if err == execution.ErrExecutionNotFound {
+			return nil, &APIError{StatusCode: http.StatusNotFound, Message: "Execution not found"}
+		}
+		if err != nil {
+			return nil, err
+		}
+		page.Count, page.Total = len(page.NodeResults), total
+		return page, nil
 	}
 
-	if resp.StatusCode >= 400 {
-		return result, fmt.Errorf("execution not found or error: %s", result["error"])
+	query := url.Values{"offset": {strconv.Itoa(offset)}}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var page NodeResultPage
+	if err := c.do(ctx, http.MethodGet, "/api/v1/executions/"+url.PathEscape(execID)+"/trace/nodes?"+query.Encode(), nil, &page); err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	return &page, nil
 }
 
-// GetExecutionStatus gets the status of an execution (deprecated, use GetExecution)
-func (c *NOT7Client) GetExecutionStatus(execID string) (map[string]interface{}, error) {
-	return c.GetExecution(execID)
+// GetLogs gets the log output written during an execution
+func (c *NOT7Client) GetLogs(ctx context.Context, execID string) (string, error) {
+	if c.local != nil {
+		logs, err := c.local.GetLogs(ctx, execID)
+		if err == execution.ErrExecutionNotFound {
+			return "", &APIError{StatusCode: http.StatusNotFound, Message: "Execution not found"}
+		}
+		return logs, err
+	}
+
+	var resp server.ExecutionLogsResponse
+	if err := c.do(ctx, http.MethodGet, "/api/v1/executions/"+url.PathEscape(execID)+"/logs", nil, &resp); err != nil {
+		return "", err
+	}
+
+	return resp.Logs, nil
 }
 
-// GetExecutionResult gets the final result of an execution
-func (c *NOT7Client) GetExecutionResult(execID string) (map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/api/v1/executions/%s/result", c.baseURL, execID)
+// GetArtifact gets the content of a file an execution saved as an artifact.
+// The trace's metadata lists an execution's artifacts.
+func (c *NOT7Client) GetArtifact(ctx context.Context, execID, name string) ([]byte, error) {
+	if c.local != nil {
+		data, err := c.local.GetArtifact(ctx, execID, name)
+		switch {
+		case errors.Is(err, execution.ErrExecutionNotFound):
+			return nil, &APIError{StatusCode: http.StatusNotFound, Message: "Execution not found"}
+		case errors.Is(err, execution.ErrArtifactNotFound):
+			return nil, &APIError{StatusCode: http.StatusNotFound, Message: "Artifact not found"}
+		}
+		return data, err
+	}
 
-	resp, err := c.httpClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to server: %w", err)
+	var data []byte
+	if err := c.do(ctx, http.MethodGet, "/api/v1/executions/"+url.PathEscape(execID)+"/artifacts/"+url.PathEscape(name), nil, &data); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	return data, nil
+}
+
+// parseTrace decodes a raw trace document
+func parseTrace(data []byte) (*spec.AgentSpec, error) {
+	var trace spec.AgentSpec
+	if err := json.Unmarshal(data, &trace); err != nil {
+		return nil, fmt.Errorf("failed to parse trace: %w", err)
+	}
+	return &trace, nil
+}
+
+// ListExecutions lists executions matching the filter, newest first
+func (c *NOT7Client) ListExecutions(ctx context.Context, filter ExecutionFilter) (*ExecutionList, error) {
+	if c.local != nil {
+		infos, total, err := c.local.ListExecutions(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		return &ExecutionList{Executions: infos, Count: len(infos), Total: total, Limit: filter.Limit, Offset: filter.Offset}, nil
 	}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	params := url.Values{}
+	if filter.Status != "" {
+		params.Set("status", string(filter.Status))
+	}
+	if filter.AgentID != "" {
+		params.Set("agent_id", filter.AgentID)
+	}
+	if !filter.Since.IsZero() {
+		params.Set("since", filter.Since.Format(time.RFC3339))
+	}
+	if !filter.Until.IsZero() {
+		params.Set("until", filter.Until.Format(time.RFC3339))
+	}
+	for key, value := range filter.Tags {
+		params.Add("tag", key+"="+value)
+	}
+	if filter.Limit > 0 {
+		params.Set("limit", strconv.Itoa(filter.Limit))
+	}
+	if filter.Offset > 0 {
+		params.Set("offset", strconv.Itoa(filter.Offset))
+	}
+
+	path := "/api/v1/executions"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
 	}
 
-	if resp.StatusCode >= 400 {
-		return result, fmt.Errorf("result not available: %s", result["error"])
+	var list ExecutionList
+	if err := c.do(ctx, http.MethodGet, path, nil, &list); err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	return &list, nil
 }
 
 // ListAgents lists all deployed agents
-func (c *NOT7Client) ListAgents() (map[string]interface{}, error) {
-	url := c.baseURL + "/api/v1/agents"
+func (c *NOT7Client) ListAgents(ctx context.Context) ([]AgentInfo, error) {
+	return c.SearchAgents(ctx, AgentFilter{})
+}
 
-	resp, err := c.httpClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to server: %w", err)
+// SearchAgents lists the deployed agents matching filter
+func (c *NOT7Client) SearchAgents(ctx context.Context, filter AgentFilter) ([]AgentInfo, error) {
+	if c.local != nil {
+		// Embedded mode has no agent registry
+		return []AgentInfo{}, nil
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	params := url.Values{}
+	if filter.Query != "" {
+		params.Set("q", filter.Query)
+	}
+	if filter.Owner != "" {
+		params.Set("owner", filter.Owner)
+	}
+	for _, tag := range filter.Tags {
+		params.Add("tag", tag)
+	}
+
+	path := "/api/v1/agents"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
 	}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	var list server.AgentListResponse
+	if err := c.do(ctx, http.MethodGet, path, nil, &list); err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	return list.Agents, nil
+}
+
+// GetUsage returns the usage of every API client in a month, given as
+// YYYY-MM, or in the current month when month is empty
+func (c *NOT7Client) GetUsage(ctx context.Context, month string) (*Usage, error) {
+	if c.local != nil {
+		// Embedded mode has no API clients
+		return &Usage{Clients: []server.ClientUsage{}}, nil
+	}
+
+	path := "/api/v1/usage"
+	if month != "" {
+		path += "?" + url.Values{"month": {month}}.Encode()
+	}
+
+	var usage Usage
+	if err := c.do(ctx, http.MethodGet, path, nil, &usage); err != nil {
+		return nil, err
+	}
+
+	return &usage, nil
 }
 
 // CheckHealth checks if server is healthy
-func (c *NOT7Client) CheckHealth() error {
-	url := c.baseURL + "/health"
+func (c *NOT7Client) CheckHealth(ctx context.Context) error {
+	if c.local != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	req, err := c.newRequest(ctx, http.MethodGet, "/health", "", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
 
-	resp, err := c.httpClient.Get(url)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("server not reachable: %w", err)
 	}
@@ -169,3 +529,152 @@ func (c *NOT7Client) CheckHealth() error {
 
 	return nil
 }
+
+// do sends a request to the API and decodes the JSON response into out,
+// or copies the raw body when out is a *[]byte
+// Responses with status >= 400 are returned as *APIError
+func (c *NOT7Client) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	return c.doContent(ctx, method, path, "application/json", body, out)
+}
+
+// doContent is do for a body of the given content type
+func (c *NOT7Client) doContent(ctx context.Context, method, path, contentType string, body []byte, out interface{}) error {
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline && c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	statusCode, data, err := c.send(ctx, method, path, contentType, body)
+	if err != nil {
+		return err
+	}
+
+	if statusCode >= 400 {
+		return newAPIError(statusCode, data)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if raw, ok := out.(*[]byte); ok {
+		*raw = data
+		return nil
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return nil
+}
+
+// send performs a request, retrying connection errors and transient 5xx
+// responses according to the client's retry policy and shouldRetry
+func (c *NOT7Client) send(ctx context.Context, method, path, contentType string, body []byte) (int, []byte, error) {
+	backoff := c.retry.InitialBackoff
+	if backoff <= 0 {
+		backoff = DefaultRetryPolicy.InitialBackoff
+	}
+	maxBackoff := c.retry.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultRetryPolicy.MaxBackoff
+	}
+
+	for attempt := 0; ; attempt++ {
+		statusCode, data, err := c.sendOnce(ctx, method, path, contentType, body)
+
+		if attempt >= c.retry.MaxRetries || ctx.Err() != nil || !shouldRetry(method, statusCode, err) {
+			return statusCode, data, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return statusCode, data, err
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// sendOnce performs a single HTTP round trip and reads the full response body
+func (c *NOT7Client) sendOnce(ctx context.Context, method, path, contentType string, body []byte) (int, []byte, error) {
+	req, err := c.newRequest(ctx, method, path, contentType, body)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to connect to server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return resp.StatusCode, data, nil
+}
+
+// shouldRetry decides whether a failed attempt is worth repeating.
+// POST starts work on the server, so a POST is only repeated when it provably
+// never got there: the connection could not be made. A 500 from POST /run
+// means the agent itself failed, so only reads retry on it.
+func shouldRetry(method string, statusCode int, err error) bool {
+	idempotent := method != http.MethodPost && method != http.MethodPatch
+
+	if err != nil {
+		return idempotent || isDialError(err)
+	}
+	if !idempotent {
+		return false
+	}
+
+	switch statusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout, http.StatusInternalServerError:
+		return true
+	}
+
+	return false
+}
+
+// isDialError reports whether err happened while connecting, before any of
+// the request was sent
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// newRequest builds a request against the API with auth and custom headers
+// applied. contentType describes body, if there is one.
+func (c *NOT7Client) newRequest(ctx context.Context, method, path, contentType string, body []byte) (*http.Request, error) {
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for key, values := range c.headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	return req, nil
+}
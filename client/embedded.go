@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/not7/core/config"
+	"github.com/not7/core/execution"
+	"github.com/not7/core/server"
+	"github.com/not7/core/spec"
+)
+
+// NewEmbeddedClient creates a client that executes agents in-process through
+// an execution.Manager instead of calling a NOT7 server. cfg is usually
+// loaded with config.LoadConfig; its server section sets the storage paths.
+func NewEmbeddedClient(cfg *config.Config) (*NOT7Client, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("config is required")
+	}
+
+	execDir, logDir := cfg.Server.ExecutionsDir, cfg.Server.LogDir
+	if execDir == "" {
+		execDir = "./executions"
+	}
+	if logDir == "" {
+		logDir = "./logs"
+	}
+
+	storage, err := execution.NewFileSystemStorage(execDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage: %w", err)
+	}
+
+	cached := execution.NewCachedStorage(storage, cfg.Server.CachedExecutions)
+	return NewClientWithManager(execution.NewManager(cached, logDir, cfg)), nil
+}
+
+// NewAutoClient runs agents on a server when a server URL is configured,
+// either as baseURL or through NOT7_SERVER_URL, and in-process otherwise.
+// cfg is only used in embedded mode; opts only apply to server clients.
+func NewAutoClient(baseURL string, cfg *config.Config, opts ...Option) (*NOT7Client, error) {
+	if baseURL == "" && os.Getenv("NOT7_SERVER_URL") == "" {
+		return NewEmbeddedClient(cfg)
+	}
+	return NewClient(baseURL, opts...), nil
+}
+
+// NewClientWithManager creates an embedded client backed by an existing manager
+func NewClientWithManager(mgr *execution.Manager) *NOT7Client {
+	return &NOT7Client{local: mgr}
+}
+
+// IsEmbedded reports whether the client runs agents in-process
+func (c *NOT7Client) IsEmbedded() bool {
+	return c.local != nil
+}
+
+// runLocal executes an agent spec through the embedded manager
+func (c *NOT7Client) runLocal(ctx context.Context, agentJSON []byte, opts RunOptions) (*Execution, error) {
+	agentSpec, err := spec.Parse(agentJSON)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON specification: %w", err)
+	}
+
+	exec, err := c.local.Execute(ctx, agentSpec, execution.Options{Async: opts.Async, Stream: opts.Stream, BatchAPI: opts.BatchAPI, Input: opts.Input, Vars: opts.Vars, Tags: opts.Tags, Attachments: opts.Attachments})
+	if err != nil {
+		return nil, fmt.Errorf("execution failed: %w", err)
+	}
+
+	response := server.NewExecutionResponse(exec)
+	if opts.Async {
+		response.Message = "Execution started in background"
+	}
+	return response, nil
+}
+
+// getLocal loads an execution from the embedded manager
+func (c *NOT7Client) getLocal(ctx context.Context, execID string) (*execution.Execution, error) {
+	exec, err := c.local.GetExecution(ctx, execID)
+	if err == execution.ErrExecutionNotFound {
+		return nil, &APIError{StatusCode: http.StatusNotFound, Message: "Execution not found"}
+	}
+	return exec, err
+}
+
+// streamLocal delivers events from the embedded manager to handler
+func (c *NOT7Client) streamLocal(ctx context.Context, execID string, handler EventHandler) error {
+	events, unsubscribe := c.local.Subscribe(execID)
+	defer unsubscribe()
+
+	exec, err := c.getLocal(ctx, execID)
+	if err != nil {
+		return err
+	}
+	if exec.Status.IsTerminal() {
+		return handler(exec.FinishedEvent())
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, open := <-events:
+			if !open {
+				return nil
+			}
+			if err := handler(event); err != nil {
+				return err
+			}
+			if event.IsTerminal() {
+				return nil
+			}
+		}
+	}
+}
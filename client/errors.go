@@ -0,0 +1,41 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/not7/core/server"
+)
+
+// APIError represents an error response returned by the NOT7 server
+type APIError struct {
+	StatusCode int
+	Message    string
+	RequestID  string // Server-assigned X-Request-ID, for finding the failure in server logs
+}
+
+func (e *APIError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("server error (status %d, request %s): %s", e.StatusCode, e.RequestID, e.Message)
+	}
+	return fmt.Sprintf("server error (status %d): %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether the server responded with 404
+func (e *APIError) IsNotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// newAPIError builds an APIError from a response body, falling back to the status text
+func newAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: statusCode, Message: http.StatusText(statusCode)}
+
+	var errResp server.ErrorResponse
+	if json.Unmarshal(body, &errResp) == nil && errResp.Error != "" {
+		apiErr.Message = errResp.Error
+		apiErr.RequestID = errResp.RequestID
+	}
+
+	return apiErr
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"net/http"
+	"time"
+)
+
+// Option configures a NOT7Client
+type Option func(*NOT7Client)
+
+// RetryPolicy controls automatic retries of failed requests
+type RetryPolicy struct {
+	// MaxRetries is the number of additional attempts after the first (0 = no retries)
+	MaxRetries int
+
+	// InitialBackoff is the delay before the first retry (default: 500ms)
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between retries (default: 10s)
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy retries transient failures a few times with backoff
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:     3,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+}
+
+// WithTimeout sets a default per-call timeout, applied when the caller's
+// context has no deadline of its own. Event streams are not affected.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *NOT7Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetry sets the retry policy for connection errors and 5xx responses.
+// Runs (POST) are only retried when the server could not be reached, so an
+// agent is never started twice.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *NOT7Client) {
+		c.retry = policy
+	}
+}
+
+// WithTransport sets the HTTP transport used for all requests
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *NOT7Client) {
+		c.httpClient.Transport = transport
+	}
+}
+
+// WithHTTPClient replaces the underlying HTTP client entirely
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *NOT7Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAPIKey authenticates every request with the given API key
+// Overrides the NOT7_API_KEY environment variable
+func WithAPIKey(apiKey string) Option {
+	return func(c *NOT7Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// WithHeader adds a custom header to every request
+func WithHeader(key, value string) Option {
+	return func(c *NOT7Client) {
+		c.headers.Set(key, value)
+	}
+}
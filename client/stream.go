@@ -0,0 +1,130 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/not7/core/execution"
+)
+
+// maxEventSize bounds a single SSE event line (node outputs can be large)
+const maxEventSize = 4 * 1024 * 1024
+
+// EventHandler is invoked for each streamed event; returning an error stops the stream
+type EventHandler func(Event) error
+
+// StreamExecution consumes the server's event stream for an execution and
+// invokes handler for every event. It returns nil once the terminal event has
+// been delivered or the server closes the stream.
+func (c *NOT7Client) StreamExecution(ctx context.Context, execID string, handler EventHandler) error {
+	if c.local != nil {
+		return c.streamLocal(ctx, execID, handler)
+	}
+
+	path := "/api/v1/executions/" + url.PathEscape(execID) + "/events"
+
+	req, err := c.newRequest(ctx, http.MethodGet, path, "", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		data, _ := io.ReadAll(resp.Body)
+		return newAPIError(resp.StatusCode, data)
+	}
+
+	return readEvents(resp.Body, handler)
+}
+
+// readEvents parses a text/event-stream body and dispatches decoded events
+func readEvents(r io.Reader, handler EventHandler) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEventSize)
+
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case line == "":
+			// Blank line dispatches the accumulated event
+			if data.Len() == 0 {
+				continue
+			}
+			var event Event
+			if err := json.Unmarshal([]byte(data.String()), &event); err != nil {
+				return fmt.Errorf("failed to parse event: %w", err)
+			}
+			data.Reset()
+
+			if err := handler(event); err != nil {
+				return err
+			}
+			if event.IsTerminal() {
+				return nil
+			}
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		default:
+			// Comments (keep-alives) and the event name line carry nothing we need;
+			// the event type is also present in the JSON payload
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read event stream: %w", err)
+	}
+
+	return nil
+}
+
+// FollowLogs copies the execution log to w as it is written and returns once
+// the execution has finished and the full log has been delivered
+func (c *NOT7Client) FollowLogs(ctx context.Context, execID string, w io.Writer) error {
+	if c.local != nil {
+		err := c.local.FollowLogs(ctx, execID, w)
+		if err == execution.ErrExecutionNotFound {
+			return &APIError{StatusCode: http.StatusNotFound, Message: "Execution not found"}
+		}
+		return err
+	}
+
+	path := "/api/v1/executions/" + url.PathEscape(execID) + "/logs?follow=true"
+
+	req, err := c.newRequest(ctx, http.MethodGet, path, "", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		data, _ := io.ReadAll(resp.Body)
+		return newAPIError(resp.StatusCode, data)
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to read log stream: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"github.com/not7/core/execution"
+	"github.com/not7/core/executor"
+	"github.com/not7/core/server"
+)
+
+// Execution is the typed view of an execution returned by the API
+type Execution = server.ExecutionResponse
+
+// ExecutionStatus is the lightweight status view of an execution
+type ExecutionStatus = server.ExecutionStatus
+
+// NodeResultPage is a page of the node results in an execution's trace
+type NodeResultPage = server.NodeResultPage
+
+// Progress tracks how many nodes of an execution have completed
+type Progress = server.Progress
+
+// AgentInfo describes a deployed agent
+type AgentInfo = server.AgentInfo
+
+// AgentFilter narrows agent listings by text, owner and tags
+type AgentFilter = server.AgentFilter
+
+// Event is a progress event streamed from a running execution
+type Event = executor.Event
+
+// Attachment is a file sent with a run as input
+type Attachment = executor.Attachment
+
+// ExecutionInfo is a lightweight summary of an execution
+type ExecutionInfo = execution.ExecutionInfo
+
+// ExecutionFilter narrows and paginates execution listings
+type ExecutionFilter = execution.ListFilter
+
+// ExecutionList is a page of execution summaries
+type ExecutionList = server.ExecutionListResponse
+
+// Usage is the usage of every API client in one month, with their quotas
+type Usage = server.UsageResponse
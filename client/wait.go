@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/not7/core/execution"
+)
+
+const (
+	defaultPollInterval    = 1 * time.Second
+	defaultMaxPollInterval = 10 * time.Second
+	pollBackoffFactor      = 1.5
+)
+
+// WaitOptions configures WaitForCompletion
+type WaitOptions struct {
+	// PollInterval is the initial delay between status checks (default: 1s)
+	PollInterval time.Duration
+
+	// MaxPollInterval caps the backoff between status checks (default: 10s)
+	MaxPollInterval time.Duration
+
+	// Timeout bounds the total wait (0 = wait until ctx is done)
+	Timeout time.Duration
+
+	// OnProgress is called with every status observed while waiting
+	OnProgress func(*ExecutionStatus)
+}
+
+// WaitForCompletion blocks until an execution reaches a terminal state and
+// returns its final result. Status is polled with exponential backoff.
+func (c *NOT7Client) WaitForCompletion(ctx context.Context, execID string, opts WaitOptions) (*Execution, error) {
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	maxInterval := opts.MaxPollInterval
+	if maxInterval <= 0 {
+		maxInterval = defaultMaxPollInterval
+	}
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	for {
+		status, err := c.GetStatus(ctx, execID)
+		if err != nil {
+			return nil, err
+		}
+
+		if opts.OnProgress != nil {
+			opts.OnProgress(status)
+		}
+
+		if IsTerminalStatus(status.Status) {
+			return c.GetExecutionResult(ctx, execID)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for execution %s: %w", execID, ctx.Err())
+		case <-time.After(interval):
+		}
+
+		interval = time.Duration(float64(interval) * pollBackoffFactor)
+		if interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
+
+// IsTerminalStatus reports whether an execution status is final
+func IsTerminalStatus(status string) bool {
+	return execution.Status(status).IsTerminal()
+}
@@ -1,44 +1,103 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/not7/core/client"
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/spec"
 	"github.com/spf13/cobra"
 )
 
 var agentsCmd = &cobra.Command{
-	Use:   "agents",
+	Use:   "agents [search]",
 	Short: "List deployed agents",
-	Long:  `List all agents that have been deployed to the server`,
-	RunE:  runAgents,
+	Long: `List the agents that have been deployed to the server.
+
+The optional search text is matched against each agent's ID, goal and
+description. --owner and --tag narrow the list further.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runAgents,
 }
 
 func init() {
 	rootCmd.AddCommand(agentsCmd)
+	agentsCmd.Flags().String("owner", "", "Only show agents with this owner")
+	agentsCmd.Flags().StringArray("tag", nil, "Only show agents with this tag (repeatable)")
 }
 
 func runAgents(cmd *cobra.Command, args []string) error {
-	apiClient := client.NewClient("")
+	apiClient, err := newAPIClient()
+	if err != nil {
+		return err
+	}
 
-	if err := apiClient.CheckHealth(); err != nil {
+	if err := apiClient.CheckHealth(cmd.Context()); err != nil {
 		return fmt.Errorf("server not running")
 	}
 
-	result, err := apiClient.ListAgents()
+	filter := client.AgentFilter{}
+	if len(args) > 0 {
+		filter.Query = args[0]
+	}
+	filter.Owner, _ = cmd.Flags().GetString("owner")
+	filter.Tags, _ = cmd.Flags().GetStringArray("tag")
+
+	agents, err := apiClient.SearchAgents(cmd.Context(), filter)
 	if err != nil {
 		return err
 	}
 
-	count := int(result["count"].(float64))
-	fmt.Printf("Deployed Agents: %d\n\n", count)
+	ui.Infof("Deployed Agents: %d\n\n", len(agents))
 
-	if agents, ok := result["agents"].([]interface{}); ok {
-		for _, a := range agents {
-			agent := a.(map[string]interface{})
-			fmt.Printf("• %s - %s\n", agent["id"], agent["goal"])
+	for _, agent := range agents {
+		ui.Printf("• %s - %s\n", agent.ID, agent.Goal)
+		if agent.Owner != "" {
+			ui.Printf("  Owner: %s\n", agent.Owner)
+		}
+		if len(agent.Tags) > 0 {
+			ui.Printf("  Tags: %s\n", strings.Join(agent.Tags, ", "))
+		}
+		ui.Printf("  Updated: %s (revision %d)\n", agent.UpdatedAt, agent.Revision)
+		if len(agent.Parameters) > 0 {
+			ui.Printf("  Inputs:\n")
+			for _, param := range agent.Parameters {
+				ui.Printf("    %s\n", describeParameter(param))
+			}
+		} else if len(agent.Inputs) > 0 {
+			ui.Printf("  Inputs: %s\n", strings.Join(agent.Inputs, ", "))
+		}
+		if c := agent.Canary; c != nil {
+			ui.Printf("  Canary: %g%% of runs, %d/%d runs, %d failed\n", c.Percent, c.Runs, c.MinRuns, c.Failures)
 		}
 	}
 
 	return nil
 }
+
+// describeParameter formats an input parameter as
+// "name (type, required) - description" or "name (type, default: value)"
+func describeParameter(param spec.InputParameter) string {
+	var details []string
+	if param.Type != "" {
+		details = append(details, param.Type)
+	}
+	if param.Required {
+		details = append(details, "required")
+	}
+	if param.Default != nil {
+		value, _ := json.Marshal(param.Default)
+		details = append(details, "default: "+string(value))
+	}
+
+	text := param.Name
+	if len(details) > 0 {
+		text += " (" + strings.Join(details, ", ") + ")"
+	}
+	if param.Description != "" {
+		text += " - " + param.Description
+	}
+	return text
+}
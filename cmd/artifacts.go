@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/not7/core/internal/cli"
+	"github.com/not7/core/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var artifactsOutput string
+
+var artifactsCmd = &cobra.Command{
+	Use:   "artifacts <execution-id> [name]",
+	Short: "List or download execution artifacts",
+	Long: `List the files an execution's nodes and tools saved as artifacts, or with
+a name print that artifact's content. --output writes it to a file instead.
+
+Examples:
+  not7 artifacts exec-1234
+  not7 artifacts exec-1234 report.md
+  not7 artifacts exec-1234 chart.png --output chart.png`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeExecutionIDs,
+	RunE:              runArtifacts,
+}
+
+func init() {
+	artifactsCmd.Flags().StringVarP(&artifactsOutput, "output", "o", "", "File to write the artifact to")
+	rootCmd.AddCommand(artifactsCmd)
+}
+
+func runArtifacts(cmd *cobra.Command, args []string) error {
+	execID := args[0]
+
+	apiClient, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+
+	if err := apiClient.CheckHealth(cmd.Context()); err != nil {
+		return fmt.Errorf("server not running")
+	}
+
+	if len(args) == 1 {
+		if artifactsOutput != "" {
+			return fmt.Errorf("--output needs an artifact name")
+		}
+		trace, err := apiClient.GetTrace(cmd.Context(), execID)
+		if err != nil {
+			return err
+		}
+		if trace.Metadata == nil || len(trace.Metadata.Artifacts) == 0 {
+			ui.Infof("Execution %s has no artifacts\n", execID)
+			return nil
+		}
+		cli.PrintArtifactTable(trace.Metadata.Artifacts)
+		return nil
+	}
+
+	data, err := apiClient.GetArtifact(cmd.Context(), execID, args[1])
+	if err != nil {
+		return err
+	}
+
+	if artifactsOutput == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(artifactsOutput, data, 0644); err != nil {
+		return fmt.Errorf("failed to write artifact: %w", err)
+	}
+	ui.Infof("💾 Artifact saved to: %s\n", artifactsOutput)
+	return nil
+}
@@ -2,148 +2,116 @@ package cmd
 
 import (
 	"fmt"
-	"time"
+	"strings"
 
 	"github.com/not7/core/config"
-	"github.com/not7/core/tools/arcade"
+	"github.com/not7/core/internal/cli"
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/tools"
 	"github.com/spf13/cobra"
 )
 
 var authorizeCmd = &cobra.Command{
-	Use:   "authorize <provider>",
+	Use:   "authorize [provider]",
 	Short: "Authorize tool provider",
-	Long:  `Authorize tool providers like Arcade to access integrated services (Gmail, Slack, etc.)`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runAuthorize,
+	Long: `Authorize tool providers like Arcade to access integrated services (Gmail, Slack, etc.)
+
+  not7 authorize arcade                   Authorize the default toolkit (Gmail)
+  not7 authorize arcade --toolkit slack   Authorize a specific toolkit
+  not7 authorize arcade --status          Show authorization status of all toolkits
+  not7 authorize                          Show status for every configured provider`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"arcade"},
+	RunE:      runAuthorize,
 }
 
 func init() {
 	rootCmd.AddCommand(authorizeCmd)
+	authorizeCmd.Flags().String("toolkit", "", "Toolkit to authorize (e.g., gmail, slack, spotify)")
+	authorizeCmd.Flags().Bool("status", false, "Show authorization status for all toolkits instead of authorizing")
 }
 
 func runAuthorize(cmd *cobra.Command, args []string) error {
-	provider := args[0]
+	toolkit, _ := cmd.Flags().GetString("toolkit")
+	showStatus, _ := cmd.Flags().GetBool("status")
 
-	if provider != "arcade" {
-		return fmt.Errorf("unsupported provider: %s (currently only 'arcade' is supported)", provider)
+	cfg, err := loadToolsConfig()
+	if err != nil {
+		return err
 	}
 
-	// Load config
-	configFile := "not7.conf"
-	if _, err := config.LoadConfig(configFile); err != nil {
-		return fmt.Errorf("failed to load config from %s: %w", configFile, err)
+	// Without a provider, report on every configured provider that needs authorization
+	if len(args) == 0 {
+		if toolkit != "" {
+			return fmt.Errorf("--toolkit requires a provider (e.g., not7 authorize arcade --toolkit %s)", toolkit)
+		}
+		return printAuthorizationStatus(cmd, cfg, configuredAuthProviders(cfg))
 	}
 
-	cfg := config.Get()
-
-	if cfg.Arcade.APIKey == "" {
-		return fmt.Errorf("ARCADE_API_KEY not set in not7.conf")
+	providerName := args[0]
+	if showStatus {
+		return printAuthorizationStatus(cmd, cfg, []string{providerName})
 	}
 
-	if cfg.Arcade.UserID == "" {
-		return fmt.Errorf("ARCADE_USER_ID not set in not7.conf")
+	if toolkit != "" {
+		providerName += "-" + strings.ToLower(toolkit)
 	}
 
-	fmt.Println("🔐 Authorizing Arcade Gmail Tools")
-	fmt.Println()
-
-	// Create Arcade client
-	client := arcade.NewClient(cfg.Arcade.APIKey, cfg.Arcade.UserID)
-
-	// List Gmail tools to pick one for authorization
-	fmt.Println("📋 Fetching Gmail tools...")
-	tools, err := client.ListTools("Gmail")
+	authorizer, err := newAuthorizer(cfg, providerName)
 	if err != nil {
-		return fmt.Errorf("failed to list tools: %w", err)
+		return err
 	}
 
-	if len(tools) == 0 {
-		return fmt.Errorf("no Gmail tools found")
-	}
+	ui.Infof("🔐 Authorizing %s\n\n", providerName)
+	return authorizer.Authorize(cmd.Context())
+}
 
-	// Check if already authorized
-	if tools[0].Requirements.Authorization != nil && tools[0].Requirements.Authorization.Status == "active" {
-		fmt.Printf("✅ Found %d Gmail tools\n", len(tools))
-		fmt.Println()
-		fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-		fmt.Println()
-		fmt.Println("  ✅ Gmail is already authorized!")
-		fmt.Println()
-		fmt.Println("  You can now use Gmail tools in your agents.")
-		fmt.Println()
-		fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-		fmt.Println()
-		return nil
+// configuredAuthProviders returns the providers with credentials that support authorization
+func configuredAuthProviders(cfg *config.Config) []string {
+	var names []string
+	if cfg.Arcade.APIKey != "" && cfg.Arcade.UserID != "" {
+		names = append(names, "arcade")
 	}
+	return names
+}
 
-	// Use the first Gmail tool for authorization
-	// All Gmail tools typically share the same OAuth authorization
-	firstTool := tools[0].FullyQualifiedName
-	fmt.Printf("✅ Found %d Gmail tools\n", len(tools))
-	fmt.Printf("🔧 Using tool: %s\n\n", firstTool)
-
-	// Initiate authorization
-	fmt.Println("🚀 Initiating OAuth authorization...")
-	authResp, err := client.AuthorizeTool(firstTool)
+// newAuthorizer creates a provider and checks that it supports authorization
+func newAuthorizer(cfg *config.Config, providerName string) (tools.Authorizer, error) {
+	provider, err := newToolProvider(cfg, providerName)
 	if err != nil {
-		return fmt.Errorf("failed to initiate authorization: %w", err)
+		return nil, err
 	}
 
-	if authResp.Status == "completed" {
-		fmt.Println("✅ Authorization already completed!")
-		return nil
+	authorizer, ok := provider.(tools.Authorizer)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not require authorization", providerName)
 	}
 
-	if authResp.AuthorizationURL == "" {
-		return fmt.Errorf("no authorization URL received")
+	return authorizer, nil
+}
+
+// printAuthorizationStatus prints a toolkit status table for each provider
+func printAuthorizationStatus(cmd *cobra.Command, cfg *config.Config, providerNames []string) error {
+	if len(providerNames) == 0 {
+		ui.Println("No tool providers requiring authorization are configured")
+		return nil
 	}
 
-	// Print authorization URL
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println()
-	fmt.Println("  Please visit this URL to authorize Gmail access:")
-	fmt.Println()
-	fmt.Printf("  %s\n", authResp.AuthorizationURL)
-	fmt.Println()
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println()
-	fmt.Println("⏳ Waiting for authorization (timeout: 5 minutes)...")
-	fmt.Println()
-
-	// Poll for authorization status with 5-minute timeout
-	timeout := time.After(5 * time.Minute)
-	ticker := time.NewTicker(3 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-timeout:
-			return fmt.Errorf("authorization timeout (5 minutes)")
-		case <-ticker.C:
-			// Check status with long-polling (wait up to 30 seconds for status change)
-			statusResp, err := client.CheckAuthStatus(authResp.AuthorizationID, 30)
-			if err != nil {
-				fmt.Printf("⚠️  Error checking status: %v\n", err)
-				continue
-			}
-
-			if statusResp.Status == "completed" {
-				fmt.Println()
-				fmt.Println("✅ Authorization completed successfully!")
-				fmt.Println()
-				fmt.Println("You can now use Gmail tools in your agents.")
-				return nil
-			}
-
-			if statusResp.Status == "failed" {
-				errorMsg := "unknown error"
-				if statusResp.Error != "" {
-					errorMsg = statusResp.Error
-				}
-				return fmt.Errorf("authorization failed: %s", errorMsg)
-			}
-
-			// Still pending, continue polling
+	for _, providerName := range providerNames {
+		authorizer, err := newAuthorizer(cfg, providerName)
+		if err != nil {
+			return err
 		}
+
+		statuses, err := authorizer.AuthorizationStatus(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		ui.Printf("🔐 %s\n\n", providerName)
+		cli.PrintAuthStatus(statuses)
+		ui.Infoln()
 	}
+
+	return nil
 }
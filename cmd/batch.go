@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/not7/core/client"
+	"github.com/not7/core/internal/cli"
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/spec"
+)
+
+// runBatch runs every *.json spec in dir with at most parallel executions,
+// passing each the same input, vars and tags
+// in flight, then prints an aggregate summary
+func runBatch(ctx context.Context, apiClient *client.NOT7Client, dir string, parallel int, opts client.RunOptions) error {
+	if streamMode || asyncMode || followMode {
+		return fmt.Errorf("--stream, --async and --follow cannot be used when running a directory")
+	}
+	if parallel < 1 {
+		return fmt.Errorf("--parallel must be at least 1")
+	}
+
+	specFiles, err := findSpecFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	ui.Infof("📂 Running %d agents from %s (parallel: %d)\n\n", len(specFiles), dir, parallel)
+
+	results := make([]cli.BatchResult, len(specFiles))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+
+	for i, specFile := range specFiles {
+		wg.Add(1)
+		go func(i int, specFile string) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := runBatchSpec(ctx, apiClient, specFile, opts)
+			results[i] = result
+
+			switch {
+			case result.Err != nil:
+				ui.Infof("❌ %s: %v\n", specFile, result.Err)
+			case result.Succeeded():
+				ui.Infof("✅ %s: %s\n", specFile, result.Execution.Status)
+			default:
+				ui.Infof("❌ %s: %s\n", specFile, result.Execution.Status)
+			}
+		}(i, specFile)
+	}
+
+	wg.Wait()
+
+	ui.Infoln()
+	cli.PrintBatchSummary(results)
+
+	failed := 0
+	for _, result := range results {
+		if !result.Succeeded() {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d agents failed", failed, len(results))
+	}
+	return nil
+}
+
+// runBatchSpec submits a single spec in the background and waits for it
+func runBatchSpec(ctx context.Context, apiClient *client.NOT7Client, specFile string, opts client.RunOptions) cli.BatchResult {
+	result := cli.BatchResult{Spec: specFile}
+
+	agentJSON, err := os.ReadFile(specFile)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to read spec: %w", err)
+		return result
+	}
+	if agentSpec, err := spec.Parse(agentJSON); err == nil {
+		if agentJSON, err = inlinePrompts(agentSpec, specFile, agentJSON); err != nil {
+			result.Err = err
+			return result
+		}
+	}
+
+	submitted, err := apiClient.RunAgent(ctx, agentJSON, client.RunOptions{Async: true, BatchAPI: opts.BatchAPI, Input: opts.Input, Vars: opts.Vars, Tags: opts.Tags})
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Execution, result.Err = apiClient.WaitForCompletion(ctx, submitted.ID, client.WaitOptions{})
+	return result
+}
+
+// findSpecFiles returns the agent spec files directly inside dir, sorted by name
+func findSpecFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	var specFiles []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			specFiles = append(specFiles, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(specFiles)
+
+	if len(specFiles) == 0 {
+		return nil, fmt.Errorf("no agent specs (*.json) found in %s", dir)
+	}
+
+	return specFiles, nil
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/not7/core/client"
+	"github.com/not7/core/config"
+	"github.com/not7/core/internal/ui"
+)
+
+var (
+	// profileName selects a named server profile from not7.conf (--profile)
+	profileName string
+
+	// serverURL targets a NOT7 server directly (--server)
+	serverURL string
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Server profile from not7.conf (e.g. local, staging, prod)")
+	rootCmd.PersistentFlags().StringVar(&serverURL, "server", "", "NOT7 server URL (overrides the profile URL; env: NOT7_SERVER_URL)")
+}
+
+// configFilePath returns the config file path, honouring NOT7_CONFIG and
+// otherwise searching the working directory, ~/.config/not7 and /etc/not7
+func configFilePath() string {
+	return config.Path()
+}
+
+// newAPIClient builds an API client for the selected server.
+// URL resolution order: --server, --profile, NOT7_PROFILE, NOT7_SERVER_URL,
+// localhost. With both --server and a profile, the profile's API key is kept.
+func newAPIClient() (*client.NOT7Client, error) {
+	name := profileName
+	if name == "" {
+		name = os.Getenv("NOT7_PROFILE")
+	}
+	if name == "" {
+		return newVerboseClient(serverURL), nil
+	}
+
+	cfg, err := config.ReadConfig(configFilePath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles from %s: %w", configFilePath(), err)
+	}
+
+	profile, err := cfg.Profile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []client.Option
+	if profile.APIKey != "" {
+		opts = append(opts, client.WithAPIKey(profile.APIKey))
+	}
+
+	url := profile.ServerURL
+	if serverURL != "" {
+		url = serverURL
+	}
+
+	return newVerboseClient(url, opts...), nil
+}
+
+// newVerboseClient creates an API client and reports its server with --verbose
+func newVerboseClient(baseURL string, opts ...client.Option) *client.NOT7Client {
+	apiClient := client.NewClient(baseURL, opts...)
+	ui.Verbosef("🔗 Server: %s\n", apiClient.BaseURL())
+	return apiClient
+}
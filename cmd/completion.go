@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/not7/core/client"
+	"github.com/not7/core/execution"
+	"github.com/spf13/cobra"
+)
+
+// completionTimeout keeps shell completion responsive when the server is slow or down
+const completionTimeout = 2 * time.Second
+
+// completionLimit caps the number of execution IDs suggested
+const completionLimit = 50
+
+// completeExecutionIDs suggests recent execution IDs from the server
+func completeExecutionIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	executions, err := listCompletionExecutions(cmd.Context())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	suggestions := make([]string, 0, len(executions))
+	for _, exec := range executions {
+		suggestions = append(suggestions, fmt.Sprintf("%s\t%s (%s)", exec.ID, exec.Goal, exec.Status))
+	}
+
+	return suggestions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeAgentIDs suggests deployed agent IDs. Servers without an agent
+// registry fall back to the agent IDs seen in recent executions.
+func completeAgentIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	apiClient, err := newAPIClient()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	ctx, cancel := completionContext(cmd.Context())
+	defer cancel()
+
+	var suggestions []string
+	if agents, err := apiClient.ListAgents(ctx); err == nil && len(agents) > 0 {
+		for _, agent := range agents {
+			suggestions = append(suggestions, fmt.Sprintf("%s\t%s", agent.ID, agent.Goal))
+		}
+		return suggestions, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	executions, err := listCompletionExecutions(cmd.Context())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	seen := make(map[string]bool)
+	for _, exec := range executions {
+		if exec.AgentID == "" || seen[exec.AgentID] {
+			continue
+		}
+		seen[exec.AgentID] = true
+		suggestions = append(suggestions, fmt.Sprintf("%s\t%s", exec.AgentID, exec.Goal))
+	}
+
+	return suggestions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeStatuses suggests execution statuses
+func completeStatuses(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return []string{
+		string(execution.StatusPending),
+		string(execution.StatusRunning),
+		string(execution.StatusSuspended),
+		string(execution.StatusCompleted),
+		string(execution.StatusFailed),
+		string(execution.StatusCancelled),
+	}, cobra.ShellCompDirectiveNoFileComp
+}
+
+// listCompletionExecutions fetches the most recent executions for completion
+func listCompletionExecutions(ctx context.Context) ([]*client.ExecutionInfo, error) {
+	apiClient, err := newAPIClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := completionContext(ctx)
+	defer cancel()
+
+	list, err := apiClient.ListExecutions(ctx, client.ExecutionFilter{Limit: completionLimit})
+	if err != nil {
+		return nil, err
+	}
+
+	return list.Executions, nil
+}
+
+// completionContext bounds a completion request by completionTimeout
+func completionContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithTimeout(ctx, completionTimeout)
+}
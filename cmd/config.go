@@ -0,0 +1,285 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/not7/core/config"
+	"github.com/not7/core/internal/cli"
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/secrets"
+	"github.com/not7/core/spec"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the NOT7 configuration",
+	Long:  `Commands for checking the NOT7 configuration file`,
+}
+
+var configCheckCmd = &cobra.Command{
+	Use:   "check [agent.json|directory...]",
+	Short: "Validate the configuration file",
+	Long: `Check the configuration file for unknown keys, invalid values and
+missing settings.
+
+Without arguments the config is checked for running the server, which
+needs OPENAI_API_KEY. Given agent specs (or directories of specs), it is
+checked against the LLM and tool providers those agents use, so e.g.
+SERP_API_KEY is only required when an agent uses builtin tools.
+
+Exits non-zero when errors are found; warnings alone pass.`,
+	RunE: runConfigCheck,
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Show which configuration file is used",
+	Long: `Print the configuration file NOT7 uses.
+
+NOT7_CONFIG wins when set. Otherwise the first existing file is used from:
+  ./not7.conf, ./not7.yaml, ./not7.yml, ./not7.toml
+  $XDG_CONFIG_HOME/not7/config (default ~/.config/not7/config)
+  /etc/not7/config
+where the config files outside the working directory may also end in
+.yaml, .yml or .toml.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigPath,
+}
+
+var configEncryptCmd = &cobra.Command{
+	Use:   "encrypt [value]",
+	Short: "Encrypt a value for the configuration file",
+	Long: `Encrypt a secret with age and print it as ENC[age:...], ready to paste
+as a config value. Without an argument the value is read from stdin, which
+keeps it out of shell history.
+
+Recipients (age1... public keys) come from --recipient or NOT7_AGE_RECIPIENTS.
+At startup, values are decrypted with the matching identity from
+NOT7_AGE_KEY or NOT7_AGE_KEY_FILE. Generate a key pair with
+'not7 config keygen' or age-keygen.`,
+	Example: `  ./not7 config keygen > not7.key
+  ./not7 config encrypt --recipient age1... sk-my-openai-key
+  echo -n sk-my-openai-key | ./not7 config encrypt --recipient age1...`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runConfigEncrypt,
+}
+
+var configKeygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Generate a key pair for encrypted config values",
+	Long: `Generate an age key pair in the age-keygen file format. Keep the output
+secret and point NOT7_AGE_KEY_FILE at it (or put the AGE-SECRET-KEY line in
+NOT7_AGE_KEY); share the public key for 'not7 config encrypt --recipient'.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigKeygen,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configCheckCmd)
+	configCmd.AddCommand(configPathCmd)
+	configCmd.AddCommand(configEncryptCmd)
+	configCmd.AddCommand(configKeygenCmd)
+
+	configCheckCmd.Flags().Bool("json", false, "Output issues as JSON")
+	configPathCmd.Flags().Bool("all", false, "List every searched location")
+	configEncryptCmd.Flags().StringArray("recipient", nil, "age recipient public key (repeatable; env: NOT7_AGE_RECIPIENTS)")
+}
+
+func runConfigPath(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	path := configFilePath()
+
+	if !all {
+		ui.Println(path)
+		if _, err := os.Stat(path); err != nil {
+			ui.Infoln("(not found)")
+		}
+		return nil
+	}
+
+	if envConfig := os.Getenv("NOT7_CONFIG"); envConfig != "" {
+		ui.Printf("NOT7_CONFIG=%s\n", envConfig)
+	}
+	for _, candidate := range config.SearchPaths() {
+		marker := " "
+		if candidate == path {
+			marker = "*"
+		}
+		status := "missing"
+		if _, err := os.Stat(candidate); err == nil {
+			status = "found"
+		}
+		ui.Printf("%s %s (%s)\n", marker, candidate, status)
+	}
+	return nil
+}
+
+func runConfigCheck(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	configFile := configFilePath()
+
+	cfg, err := config.ReadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config from %s: %w", configFile, err)
+	}
+	configFile = cfg.Source()
+
+	req := config.Requirements{LLMProviders: []string{config.ProviderOpenAI}}
+	if len(args) > 0 {
+		req, err = specRequirements(args)
+		if err != nil {
+			return err
+		}
+	}
+
+	issues := cfg.Validate(req)
+
+	errorCount := 0
+	for _, issue := range issues {
+		if issue.Severity == config.SeverityError {
+			errorCount++
+		}
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"config": configFile,
+			"valid":  errorCount == 0,
+			"issues": issues,
+		}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal issues: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		ui.Infof("🔎 Checking %s\n\n", configFile)
+		cli.PrintConfigIssues(issues)
+		if len(issues) > 0 {
+			ui.Infoln()
+		}
+	}
+
+	if errorCount > 0 {
+		return fmt.Errorf("%d error(s), %d warning(s) in %s", errorCount, len(issues)-errorCount, configFile)
+	}
+	if !jsonOutput {
+		ui.Printf("✅ %s is valid (%d warning(s))\n", configFile, len(issues))
+	}
+	return nil
+}
+
+func runConfigEncrypt(cmd *cobra.Command, args []string) error {
+	recipients, _ := cmd.Flags().GetStringArray("recipient")
+	if len(recipients) == 0 {
+		recipients = strings.FieldsFunc(os.Getenv("NOT7_AGE_RECIPIENTS"), func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		})
+	}
+	if len(recipients) == 0 {
+		return fmt.Errorf("no recipients: pass --recipient age1... or set NOT7_AGE_RECIPIENTS")
+	}
+
+	var value string
+	if len(args) == 1 {
+		value = args[0]
+	} else {
+		data, err := io.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return fmt.Errorf("failed to read value from stdin: %w", err)
+		}
+		value = strings.TrimRight(string(data), "\r\n")
+	}
+	if value == "" {
+		return fmt.Errorf("nothing to encrypt")
+	}
+
+	encrypted, err := secrets.Encrypt(value, recipients)
+	if err != nil {
+		return err
+	}
+	ui.Println(encrypted)
+	return nil
+}
+
+func runConfigKeygen(cmd *cobra.Command, args []string) error {
+	identity, recipient, err := secrets.GenerateKey()
+	if err != nil {
+		return err
+	}
+
+	// Same layout as age-keygen, so the file works with age and sops too
+	fmt.Printf("# created: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Printf("# public key: %s\n", recipient)
+	fmt.Println(identity)
+	fmt.Fprintf(cmd.ErrOrStderr(), "Public key: %s\n", recipient)
+	return nil
+}
+
+// specRequirements collects the LLM and tool providers used by the given
+// spec files and directories of specs. The executor always creates an
+// OpenAI client, so OpenAI is required regardless.
+func specRequirements(paths []string) (config.Requirements, error) {
+	req := config.Requirements{LLMProviders: []string{config.ProviderOpenAI}}
+
+	for _, path := range paths {
+		files := []string{path}
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			found, err := findSpecFiles(path)
+			if err != nil {
+				return req, err
+			}
+			files = found
+		}
+
+		for _, file := range files {
+			agentSpec, err := spec.LoadSpec(file)
+			if err != nil {
+				return req, fmt.Errorf("%s: %w", file, err)
+			}
+			addSpecRequirements(&req, agentSpec)
+		}
+	}
+
+	return req, nil
+}
+
+// addSpecRequirements adds the providers of an agent and its nodes, and the
+// secrets it references, to req
+func addSpecRequirements(req *config.Requirements, agentSpec *spec.AgentSpec) {
+	addConfig := func(cfg *spec.Config) {
+		if cfg == nil {
+			return
+		}
+		if cfg.LLM != nil {
+			req.LLMProviders = append(req.LLMProviders, llmProviderName(cfg.LLM))
+		}
+		if cfg.Tools != nil && cfg.Tools.Provider != "" {
+			req.ToolProviders = append(req.ToolProviders, cfg.Tools.Provider)
+		}
+	}
+
+	addConfig(agentSpec.Config)
+	for _, node := range agentSpec.Nodes {
+		addConfig(node.Config)
+		if node.LLM != nil {
+			req.LLMProviders = append(req.LLMProviders, llmProviderName(node.LLM))
+		}
+	}
+	req.Secrets = append(req.Secrets, agentSpec.SecretNames()...)
+}
+
+// llmProviderName defaults an empty provider to OpenAI
+func llmProviderName(llm *spec.LLMConfig) string {
+	if llm.Provider == "" {
+		return config.ProviderOpenAI
+	}
+	return llm.Provider
+}
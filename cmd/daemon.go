@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/not7/core/config"
+	"github.com/not7/core/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// daemonStartWait is how long serve --daemon watches the new server before
+// reporting it started, so a bad port or config fails the command
+const daemonStartWait = time.Second
+
+var (
+	daemonMode  bool
+	pidFile     string
+	stopTimeout time.Duration
+)
+
+var stopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop a server started with serve --daemon",
+	Long: `Stop the server whose process ID is in the pid file (SERVER_PID_FILE,
+./not7.pid by default). It is asked to shut down and, if still running after
+--timeout, killed.`,
+	Args: cobra.NoArgs,
+	RunE: runStop,
+}
+
+var restartCmd = &cobra.Command{
+	Use:   "restart",
+	Short: "Restart a server started with serve --daemon",
+	Long: `Stop the server in the pid file, if it is running, and start it again in
+the background with the config of the current directory. The config is
+checked first, so a broken config leaves the running server alone.`,
+	Args: cobra.NoArgs,
+	RunE: runRestart,
+}
+
+func init() {
+	serveCmd.Flags().BoolVarP(&daemonMode, "daemon", "d", false, "Run in the background, logging to SERVER_LOG_DIR/server.log")
+	for _, command := range []*cobra.Command{serveCmd, stopCmd, restartCmd} {
+		command.Flags().StringVar(&pidFile, "pid-file", "", "Process ID file (default: SERVER_PID_FILE)")
+	}
+	for _, command := range []*cobra.Command{stopCmd, restartCmd} {
+		command.Flags().DurationVar(&stopTimeout, "timeout", 10*time.Second, "How long to wait for the server to stop before killing it")
+	}
+	rootCmd.AddCommand(stopCmd)
+	rootCmd.AddCommand(restartCmd)
+}
+
+// pidFilePath returns the pid file to use: --pid-file, else the config's
+func pidFilePath(cfg *config.Config) (string, error) {
+	path := pidFile
+	if path == "" {
+		path = cfg.Server.PIDFile
+	}
+	return filepath.Abs(path)
+}
+
+// startDaemon starts the server in a detached child process, which writes
+// the pid file, and returns once it is up
+func startDaemon(cfg *config.Config) error {
+	path, err := pidFilePath(cfg)
+	if err != nil {
+		return err
+	}
+	if pid, err := readPIDFile(path); err == nil && processRunning(pid) {
+		return fmt.Errorf("server is already running (pid %d); stop it with not7 stop", pid)
+	}
+
+	if err := os.MkdirAll(cfg.Server.LogDir, 0755); err != nil {
+		return fmt.Errorf("failed to create logs directory: %w", err)
+	}
+	logPath := filepath.Join(cfg.Server.LogDir, "server.log")
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open server log: %w", err)
+	}
+	defer logFile.Close()
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the not7 executable: %w", err)
+	}
+	child := exec.Command(executable, daemonArgs(os.Args[1:], path)...)
+	child.Stdout = logFile
+	child.Stderr = logFile
+	detach(child)
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("failed to start server: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- child.Wait() }()
+	select {
+	case <-exited:
+		return fmt.Errorf("server exited on start; see %s", logPath)
+	case <-time.After(daemonStartWait):
+	}
+
+	ui.Infof("🚀 Server started in the background (pid %d)\n", child.Process.Pid)
+	ui.Infof("📄 Logs: %s\n", logPath)
+	ui.Infof("🛑 Stop it with: not7 stop\n")
+	return nil
+}
+
+// daemonArgs returns the arguments of the child server: serve and the flags
+// given, without --daemon, with the pid file made explicit
+func daemonArgs(args []string, pidPath string) []string {
+	child := []string{"serve"}
+	skipValue := false
+	for _, arg := range args {
+		switch {
+		case skipValue:
+			skipValue = false
+		case arg == "serve" || arg == "restart":
+		case arg == "--daemon" || arg == "-d" || strings.HasPrefix(arg, "--daemon="):
+		case arg == "--timeout" || arg == "--pid-file":
+			skipValue = true
+		case strings.HasPrefix(arg, "--timeout=") || strings.HasPrefix(arg, "--pid-file="):
+		default:
+			child = append(child, arg)
+		}
+	}
+	return append(child, "--pid-file", pidPath)
+}
+
+// writePIDFile records the current process in path, refusing to replace
+// the pid file of a server that is still running
+func writePIDFile(path string) error {
+	if pid, err := readPIDFile(path); err == nil && pid != os.Getpid() && processRunning(pid) {
+		return fmt.Errorf("server is already running (pid %d)", pid)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create pid file directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write pid file: %w", err)
+	}
+	return nil
+}
+
+// removePIDFile deletes path if it still holds the current process
+func removePIDFile(path string) {
+	if pid, err := readPIDFile(path); err == nil && pid == os.Getpid() {
+		os.Remove(path)
+	}
+}
+
+// readPIDFile returns the process ID in path
+func readPIDFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid pid file %s", path)
+	}
+	return pid, nil
+}
+
+func runStop(cmd *cobra.Command, args []string) error {
+	cfg, err := config.ReadConfig(configFilePath())
+	if err != nil {
+		return fmt.Errorf("failed to load config from %s: %w", configFilePath(), err)
+	}
+	path, err := pidFilePath(cfg)
+	if err != nil {
+		return err
+	}
+	return stopServer(path)
+}
+
+// stopServer stops the server in the pid file at path and removes the file
+func stopServer(path string) error {
+	pid, err := readPIDFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no server is running (no pid file at %s)", path)
+	}
+	if err != nil {
+		return err
+	}
+	if !processRunning(pid) {
+		os.Remove(path)
+		return fmt.Errorf("server (pid %d) is not running; removed the stale pid file", pid)
+	}
+
+	if err := terminate(pid); err != nil {
+		return fmt.Errorf("failed to stop server (pid %d): %w", pid, err)
+	}
+	deadline := time.Now().Add(stopTimeout)
+	for processRunning(pid) {
+		if time.Now().After(deadline) {
+			if err := kill(pid); err != nil {
+				return fmt.Errorf("server (pid %d) did not stop within %s and could not be killed: %w", pid, stopTimeout, err)
+			}
+			ui.Printf("⚠️  Server (pid %d) did not stop within %s and was killed\n", pid, stopTimeout)
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	os.Remove(path)
+
+	ui.Infof("🛑 Server stopped (pid %d)\n", pid)
+	return nil
+}
+
+func runRestart(cmd *cobra.Command, args []string) error {
+	configFile := configFilePath()
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config from %s: %w", configFile, err)
+	}
+	path, err := pidFilePath(cfg)
+	if err != nil {
+		return err
+	}
+
+	if pid, err := readPIDFile(path); err == nil && processRunning(pid) {
+		if err := stopServer(path); err != nil {
+			return err
+		}
+	}
+	return startDaemon(cfg)
+}
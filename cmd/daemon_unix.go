@@ -0,0 +1,43 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// detach starts child in its own session, so it outlives the terminal
+func detach(child *exec.Cmd) {
+	child.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+// processRunning reports whether a process with pid exists
+func processRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// terminate asks the process to shut down
+func terminate(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return process.Signal(syscall.SIGTERM)
+}
+
+// kill ends the process at once
+func kill(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return process.Kill()
+}
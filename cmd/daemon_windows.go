@@ -0,0 +1,45 @@
+//go:build windows
+
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// Process creation flags that detach child from the console
+const (
+	detachedProcess       = 0x00000008
+	createNewProcessGroup = 0x00000200
+)
+
+// detach starts child without a console, so it outlives the terminal
+func detach(child *exec.Cmd) {
+	child.SysProcAttr = &syscall.SysProcAttr{CreationFlags: detachedProcess | createNewProcessGroup, HideWindow: true}
+}
+
+// processRunning reports whether a process with pid exists
+func processRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	process.Release()
+	return true
+}
+
+// terminate ends the process. Windows has no SIGTERM to send to a detached
+// process, so this is the same as kill.
+func terminate(pid int) error {
+	return kill(pid)
+}
+
+// kill ends the process at once
+func kill(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return process.Kill()
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/not7/core/config"
+	"github.com/not7/core/internal/probe"
+	"github.com/not7/core/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose the local NOT7 setup",
+	Long: `Check config file validity, API keys, server reachability, provider
+connectivity (OpenAI, Gemini, SerpAPI, Arcade, the Redis queue) and directory
+permissions`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().Bool("offline", false, "Skip network checks against external providers")
+}
+
+// checkLevel is the outcome of a single diagnostic
+type checkLevel int
+
+const (
+	checkOK checkLevel = iota
+	checkWarn
+	checkFail
+)
+
+// doctorReport collects and prints diagnostic results
+type doctorReport struct {
+	failures int
+	warnings int
+}
+
+// add prints one check result with an optional fix hint
+func (r *doctorReport) add(level checkLevel, name, detail, hint string) {
+	icon := "✅"
+	switch level {
+	case checkWarn:
+		icon = "⚠️ "
+		r.warnings++
+	case checkFail:
+		icon = "❌"
+		r.failures++
+	}
+
+	// Passing checks are noise in quiet mode; problems are always shown
+	line := fmt.Sprintf("%s %s", icon, name)
+	if detail != "" {
+		line += ": " + detail
+	}
+	if level == checkOK {
+		ui.Infoln(line)
+		return
+	}
+
+	ui.Println(line)
+	if hint != "" {
+		ui.Printf("   → %s\n", hint)
+	}
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	offline, _ := cmd.Flags().GetBool("offline")
+	ctx := cmd.Context()
+	report := &doctorReport{}
+	configFile := configFilePath()
+
+	ui.Infoln("🩺 NOT7 Doctor")
+	ui.Infoln()
+
+	// Config file
+	cfg, err := config.ReadConfig(configFile)
+	if err != nil {
+		report.add(checkFail, "Config file", err.Error(),
+			fmt.Sprintf("cp not7.conf.example %s and fill in your keys (see 'not7 config path --all' for other locations)", configFile))
+	} else {
+		report.add(checkOK, "Config file", cfg.Source(), "")
+		for _, issue := range cfg.Warnings() {
+			report.add(checkWarn, issue.String(), "", issue.Hint)
+		}
+	}
+
+	if cfg != nil {
+		checkKeys(report, cfg)
+		checkDirectories(report, cfg)
+		if !offline {
+			checkProviders(ctx, report, cfg)
+		}
+	}
+
+	// Server
+	apiClient, err := newAPIClient()
+	if err != nil {
+		report.add(checkFail, "Server profile", err.Error(), "Check PROFILE_<NAME>_URL entries in not7.conf")
+	} else if err := apiClient.CheckHealth(ctx); err != nil {
+		report.add(checkWarn, "Server", "not reachable", "Start it with: ./not7 serve")
+	} else {
+		report.add(checkOK, "Server", "reachable", "")
+	}
+
+	ui.Infoln()
+	if report.failures > 0 {
+		return fmt.Errorf("%d check(s) failed, %d warning(s)", report.failures, report.warnings)
+	}
+	ui.Printf("All checks passed (%d warning(s))\n", report.warnings)
+	return nil
+}
+
+// checkKeys verifies required and optional API keys are present
+func checkKeys(report *doctorReport, cfg *config.Config) {
+	if cfg.OpenAI.APIKey == "" {
+		report.add(checkFail, "OPENAI_API_KEY", "not set", "Add OPENAI_API_KEY=sk-... to not7.conf")
+	} else {
+		report.add(checkOK, "OPENAI_API_KEY", "set", "")
+	}
+
+	if configured := configuredLLMProviders(cfg); len(configured) > 0 {
+		report.add(checkOK, "Other LLM providers", strings.Join(configured, ", "), "")
+	}
+
+	if cfg.Builtin.SerpAPIKey == "" {
+		report.add(checkWarn, "SERP_API_KEY", "not set (builtin web search disabled)", "Get a key at https://serpapi.com and add SERP_API_KEY to not7.conf")
+	} else {
+		report.add(checkOK, "SERP_API_KEY", "set", "")
+	}
+
+	switch {
+	case cfg.Arcade.APIKey == "" && cfg.Arcade.UserID == "":
+		report.add(checkWarn, "Arcade", "not configured (arcade tools disabled)", "Add ARCADE_API_KEY and ARCADE_USER_ID to not7.conf")
+	case cfg.Arcade.APIKey == "":
+		report.add(checkFail, "ARCADE_API_KEY", "not set but ARCADE_USER_ID is", "Add ARCADE_API_KEY to not7.conf")
+	case cfg.Arcade.UserID == "":
+		report.add(checkFail, "ARCADE_USER_ID", "not set but ARCADE_API_KEY is", "Add ARCADE_USER_ID to not7.conf")
+	default:
+		report.add(checkOK, "Arcade keys", "set", "")
+	}
+}
+
+// configuredLLMProviders lists the non-OpenAI LLM providers with an API key
+func configuredLLMProviders(cfg *config.Config) []string {
+	var names []string
+	for _, name := range config.LLMProviders {
+		if name == config.ProviderOpenAI {
+			continue
+		}
+		if provider, err := cfg.LLMProvider(name); err == nil && provider.APIKey != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// checkDirectories verifies the executions, logs, specs, corpora, memory and sinks directories are writable
+func checkDirectories(report *doctorReport, cfg *config.Config) {
+	dirs := []struct{ name, path, key string }{
+		{"Executions directory", cfg.Server.ExecutionsDir, "SERVER_EXECUTIONS_DIR"},
+		{"Logs directory", cfg.Server.LogDir, "SERVER_LOG_DIR"},
+		{"Specs directory", cfg.Server.SpecsDir, "SERVER_SPECS_DIR"},
+		{"Corpora directory", cfg.Server.CorporaDir, "SERVER_CORPORA_DIR"},
+		{"Memory directory", cfg.Memory.Dir, "MEMORY_DIR"},
+		{"Sinks directory", cfg.Sinks.Dir, "SINKS_DIR"},
+	}
+
+	for _, dir := range dirs {
+		if err := checkWritable(dir.path); err != nil {
+			report.add(checkFail, dir.name, err.Error(),
+				fmt.Sprintf("Fix permissions on %s or point %s elsewhere", dir.path, dir.key))
+			continue
+		}
+		report.add(checkOK, dir.name, dir.path+" (writable)", "")
+	}
+}
+
+// checkWritable creates the directory if needed and writes a probe file
+func checkWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create: %w", err)
+	}
+
+	probe := filepath.Join(dir, ".not7-doctor")
+	if err := os.WriteFile(probe, []byte("ok"), 0644); err != nil {
+		return fmt.Errorf("not writable: %w", err)
+	}
+	return os.Remove(probe)
+}
+
+// checkProviders pings each configured external provider
+func checkProviders(ctx context.Context, report *doctorReport, cfg *config.Config) {
+	for _, result := range probe.Providers(ctx, cfg) {
+		if result.Err != nil {
+			report.add(checkFail, result.Name, result.Err.Error(), result.Hint)
+			continue
+		}
+		report.add(checkOK, result.Name, result.Detail, "")
+	}
+}
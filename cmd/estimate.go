@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/not7/core/executor"
+	"github.com/not7/core/internal/cli"
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/spec"
+	"github.com/spf13/cobra"
+)
+
+var estimateCmd = &cobra.Command{
+	Use:   "estimate <agent.json>",
+	Short: "Estimate token usage and cost",
+	Long: `Estimate the per-node and total token usage and cost of an agent before running it.
+
+Prompts are rendered offline and tokens approximated; ReAct nodes are assumed
+to run every iteration, so the estimate is an upper bound.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runEstimate,
+}
+
+func init() {
+	rootCmd.AddCommand(estimateCmd)
+	estimateCmd.Flags().String("model", "", "Estimate for this model instead of the models in the spec")
+	estimateCmd.Flags().String("input", "", "Sample input for the first node")
+	estimateCmd.Flags().Bool("json", false, "Print the estimate as JSON")
+}
+
+func runEstimate(cmd *cobra.Command, args []string) error {
+	model, _ := cmd.Flags().GetString("model")
+	input, _ := cmd.Flags().GetString("input")
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	agentSpec, err := spec.LoadSpec(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid: %w", err)
+	}
+	if err := agentSpec.ResolvePrompts(promptDirs(args[0])...); err != nil {
+		return fmt.Errorf("invalid: %w", err)
+	}
+	if !asJSON {
+		cli.PrintSpecIssues(agentSpec.Warnings())
+	}
+
+	estimate := executor.EstimateCost(agentSpec, model, input)
+
+	if asJSON {
+		data, err := json.MarshalIndent(estimate, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode estimate: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	ui.Infof("💰 Cost estimate: %s\n\n", agentSpec.Goal)
+	cli.PrintCostEstimate(estimate)
+
+	return nil
+}
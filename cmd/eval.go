@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/not7/core/client"
+	"github.com/not7/core/config"
+	"github.com/not7/core/eval"
+	"github.com/not7/core/internal/cli"
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/llm"
+	"github.com/not7/core/spec"
+	"github.com/spf13/cobra"
+)
+
+var (
+	evalParallel    int
+	evalOutput      string
+	evalGraderModel string
+)
+
+var evalCmd = &cobra.Command{
+	Use:   "eval <suite.yaml>",
+	Short: "Test an agent spec against a suite of cases",
+	Long: `Run an agent spec on each case of a suite and check the outputs with
+assertions: contains, not_contains, regex, json_path (optionally with equals)
+and rubric, which a grader model judges. Cases run on the server; rubrics are
+graded locally with OPENAI_API_KEY.
+
+Prints pass/fail per case with agent and grading costs, and exits non-zero
+when any case fails. --output writes the full report as JSON.
+
+Example suite:
+  spec: sentiment-analyzer.json
+  cases:
+    - name: happy
+      input: "I love this product"
+      assert:
+        - contains: positive
+          ignore_case: true
+        - rubric: "Gives a one-word sentiment label"
+
+Example:
+  not7 eval evals/sentiment.yaml --parallel 4 --output report.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runEval,
+}
+
+func init() {
+	evalCmd.Flags().IntVar(&evalParallel, "parallel", 1, "Maximum cases to run at once")
+	evalCmd.Flags().StringVarP(&evalOutput, "output", "o", "", "File to write the JSON report to")
+	evalCmd.Flags().StringVar(&evalGraderModel, "grader-model", "", "Model that grades rubrics (default: the suite's grader.model, then OPENAI_DEFAULT_MODEL)")
+	rootCmd.AddCommand(evalCmd)
+}
+
+func runEval(cmd *cobra.Command, args []string) error {
+	suite, err := eval.Load(args[0])
+	if err != nil {
+		return err
+	}
+	if evalParallel < 1 {
+		return fmt.Errorf("--parallel must be at least 1")
+	}
+
+	specFile := suite.SpecPath()
+	agentJSON, err := os.ReadFile(specFile)
+	if err != nil {
+		return fmt.Errorf("failed to read spec: %w", err)
+	}
+	agentSpec, err := spec.Parse(agentJSON)
+	if err != nil {
+		return err
+	}
+	if agentJSON, err = inlinePrompts(agentSpec, specFile, agentJSON); err != nil {
+		return err
+	}
+
+	// Fail before running any case when rubrics cannot be graded
+	var grade eval.GradeFunc
+	if suite.HasRubrics() {
+		if grade, err = newEvalGrader(suite); err != nil {
+			return err
+		}
+	}
+
+	apiClient, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	if err := apiClient.CheckHealth(cmd.Context()); err != nil {
+		return fmt.Errorf("server not running. Start server first:\n  Terminal 1: ./not7 serve\n  Terminal 2: ./not7 eval suite.yaml")
+	}
+
+	ui.Infof("🧪 Evaluating %s: %d cases (parallel: %d)\n\n", specFile, len(suite.Cases), evalParallel)
+	report := eval.Run(cmd.Context(), suite, evalParallel, evalRunner(apiClient, agentJSON), grade, cli.PrintEvalCase)
+	report.Spec = specFile
+	cli.PrintEvalSummary(report)
+
+	if evalOutput != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %w", err)
+		}
+		if err := os.WriteFile(evalOutput, data, 0644); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+		ui.Infof("💾 Report saved to: %s\n", evalOutput)
+	}
+
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d cases failed", report.Failed, report.Passed+report.Failed)
+	}
+	return nil
+}
+
+// evalRunner runs each case as a background execution and waits for it
+func evalRunner(apiClient *client.NOT7Client, agentJSON []byte) eval.RunFunc {
+	return func(ctx context.Context, input string, vars map[string]string) (*eval.Output, error) {
+		return runAndWait(ctx, apiClient, agentJSON, client.RunOptions{Input: input, Vars: vars})
+	}
+}
+
+// runAndWait runs agentJSON as a background execution and waits for its result
+func runAndWait(ctx context.Context, apiClient *client.NOT7Client, agentJSON []byte, opts client.RunOptions) (*eval.Output, error) {
+	opts.Async = true
+	submitted, err := apiClient.RunAgent(ctx, agentJSON, opts)
+	if err != nil {
+		return nil, err
+	}
+	result, err := apiClient.WaitForCompletion(ctx, submitted.ID, client.WaitOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	out := &eval.Output{
+		ExecutionID: result.ID,
+		Status:      result.Status,
+		Output:      result.Output,
+		Error:       result.Error,
+		Cost:        result.TotalCost,
+		DurationMs:  result.DurationMs,
+	}
+	if result.Metadata != nil {
+		out.Tokens = result.Metadata.PromptTokens + result.Metadata.CompletionTokens
+	}
+	return out, nil
+}
+
+// newEvalGrader creates the rubric grader from the local config
+func newEvalGrader(suite *eval.Suite) (eval.GradeFunc, error) {
+	model := evalGraderModel
+	if model == "" && suite.Grader != nil {
+		model = suite.Grader.Model
+	}
+	grade, err := newLLMGrader(model)
+	if err != nil {
+		return nil, fmt.Errorf("rubric assertions need a grader: %w", err)
+	}
+	return grade, nil
+}
+
+// newLLMGrader creates a grader that asks model, by default
+// OPENAI_DEFAULT_MODEL, using the local config's OpenAI key
+func newLLMGrader(model string) (eval.GradeFunc, error) {
+	configFile := configFilePath()
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config from %s: %w", configFile, err)
+	}
+	llmClient, err := llm.NewOpenAIClient(cfg.OpenAI)
+	if err != nil {
+		return nil, err
+	}
+
+	if model == "" {
+		model = cfg.OpenAI.DefaultModel
+	}
+	return eval.NewLLMGrader(llmClient, model), nil
+}
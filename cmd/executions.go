@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/not7/core/client"
+	"github.com/not7/core/execution"
+	"github.com/not7/core/internal/cli"
+	"github.com/not7/core/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var executionsCmd = &cobra.Command{
+	Use:   "executions",
+	Short: "List executions",
+	Long:  `List recent agent executions on the server, newest first`,
+	Args:  cobra.NoArgs,
+	RunE:  runExecutions,
+}
+
+func init() {
+	rootCmd.AddCommand(executionsCmd)
+	executionsCmd.Flags().String("status", "", "Only show executions with this status (pending, running, completed, failed, cancelled)")
+	executionsCmd.Flags().String("agent", "", "Only show executions of this agent ID")
+	executionsCmd.Flags().StringArray("tag", nil, "Only show executions tagged key=value (repeatable)")
+	executionsCmd.Flags().Int("limit", 20, "Maximum number of executions to show (0 = all)")
+	executionsCmd.RegisterFlagCompletionFunc("status", completeStatuses)
+	executionsCmd.RegisterFlagCompletionFunc("agent", completeAgentIDs)
+}
+
+func runExecutions(cmd *cobra.Command, args []string) error {
+	status, _ := cmd.Flags().GetString("status")
+	agentID, _ := cmd.Flags().GetString("agent")
+	limit, _ := cmd.Flags().GetInt("limit")
+	tagFlags, _ := cmd.Flags().GetStringArray("tag")
+
+	tags, err := parseKeyValues("--tag", tagFlags)
+	if err != nil {
+		return err
+	}
+
+	apiClient, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+
+	if err := apiClient.CheckHealth(cmd.Context()); err != nil {
+		return fmt.Errorf("server not running")
+	}
+
+	list, err := apiClient.ListExecutions(cmd.Context(), client.ExecutionFilter{
+		Status:  execution.Status(status),
+		AgentID: agentID,
+		Tags:    tags,
+		Limit:   limit,
+	})
+	if err != nil {
+		return err
+	}
+
+	if list.Count == 0 {
+		ui.Println("No executions found")
+		return nil
+	}
+
+	cli.PrintExecutionTable(list.Executions)
+
+	if list.Total > list.Count {
+		ui.Infof("\nShowing %d of %d executions (use --limit to see more)\n", list.Count, list.Total)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/not7/core/client"
+	"github.com/not7/core/eval"
+	"github.com/not7/core/experiment"
+	"github.com/not7/core/internal/cli"
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/spec"
+	"github.com/spf13/cobra"
+)
+
+var (
+	experimentParallel   int
+	experimentOutput     string
+	experimentJudgeModel string
+)
+
+var experimentCmd = &cobra.Command{
+	Use:   "experiment <experiment.yaml>",
+	Short: "Compare prompt and model variants of an agent spec",
+	Long: `Run an agent spec in several variants over the same inputs and compare
+their cost, latency and, with a judge, quality. A variant may change the
+model of every llm and react node, the prompts of given nodes, and vars.
+
+Runs happen on the server and are tagged experiment=<name>,
+variant=<variant> and input=<n>, so they can be listed with
+not7 executions --tag. A judge rubric is graded locally with
+OPENAI_API_KEY; quality is the share of runs the judge passes.
+--output writes the full report as JSON.
+
+Example experiment:
+  name: summary-prompts
+  spec: summarizer.json
+  inputs:
+    - "First article ..."
+    - "Second article ..."
+  variants:
+    - name: baseline
+    - name: mini
+      model: gpt-4o-mini
+    - name: terse
+      prompts:
+        summarize: "Summarize the text in one sentence."
+  judge:
+    rubric: "The summary is accurate and leaves out nothing important"
+
+Example:
+  not7 experiment experiments/summary.yaml --parallel 4 --output report.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExperiment,
+}
+
+func init() {
+	experimentCmd.Flags().IntVar(&experimentParallel, "parallel", 1, "Maximum runs at once")
+	experimentCmd.Flags().StringVarP(&experimentOutput, "output", "o", "", "File to write the JSON report to")
+	experimentCmd.Flags().StringVar(&experimentJudgeModel, "judge-model", "", "Model that judges quality (default: the experiment's judge.model, then OPENAI_DEFAULT_MODEL)")
+	rootCmd.AddCommand(experimentCmd)
+}
+
+func runExperiment(cmd *cobra.Command, args []string) error {
+	x, err := experiment.Load(args[0])
+	if err != nil {
+		return err
+	}
+	if experimentParallel < 1 {
+		return fmt.Errorf("--parallel must be at least 1")
+	}
+
+	specFile := x.SpecPath()
+	agentJSON, err := os.ReadFile(specFile)
+	if err != nil {
+		return fmt.Errorf("failed to read spec: %w", err)
+	}
+
+	// Build every variant's spec up front, so a bad variant fails before any run
+	variantJSON := make(map[string][]byte, len(x.Variants))
+	for i := range x.Variants {
+		agentSpec, err := spec.Parse(agentJSON)
+		if err != nil {
+			return err
+		}
+		if err := agentSpec.ResolvePrompts(promptDirs(specFile)...); err != nil {
+			return fmt.Errorf("invalid spec: %w", err)
+		}
+		if err := x.Variants[i].Apply(agentSpec); err != nil {
+			return err
+		}
+		if variantJSON[x.Variants[i].Name], err = json.Marshal(agentSpec); err != nil {
+			return fmt.Errorf("failed to encode variant %s: %w", x.Variants[i].Name, err)
+		}
+	}
+
+	var judge eval.GradeFunc
+	if x.Judge != nil {
+		model := experimentJudgeModel
+		if model == "" {
+			model = x.Judge.Model
+		}
+		if judge, err = newLLMGrader(model); err != nil {
+			return fmt.Errorf("the judge needs a model: %w", err)
+		}
+	}
+
+	apiClient, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	if err := apiClient.CheckHealth(cmd.Context()); err != nil {
+		return fmt.Errorf("server not running. Start server first:\n  Terminal 1: ./not7 serve\n  Terminal 2: ./not7 experiment experiment.yaml")
+	}
+
+	run := func(ctx context.Context, v *experiment.Variant, input string, vars, tags map[string]string) (*eval.Output, error) {
+		return runAndWait(ctx, apiClient, variantJSON[v.Name], client.RunOptions{Input: input, Vars: vars, Tags: tags})
+	}
+
+	ui.Infof("🧪 Experiment %s: %d variants × %d inputs (parallel: %d)\n\n", specFile, len(x.Variants), len(x.Inputs), experimentParallel)
+	report := experiment.Run(cmd.Context(), x, experimentParallel, run, judge, cli.PrintExperimentRun)
+	report.Spec = specFile
+	ui.Infoln()
+	cli.PrintExperimentReport(report)
+
+	if experimentOutput != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %w", err)
+		}
+		if err := os.WriteFile(experimentOutput, data, 0644); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+		ui.Infof("💾 Report saved to: %s\n", experimentOutput)
+	}
+	return nil
+}
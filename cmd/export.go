@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/not7/core/client"
+	"github.com/not7/core/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <execution-id>",
+	Short: "Export an execution as a report",
+	Long: `Export a finished execution (output, trace and logs) as a Markdown,
+HTML or JSON report for sharing with people who don't run NOT7.
+
+The format defaults to the extension of --output, or Markdown when
+writing to stdout.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeExecutionIDs,
+	RunE:              runExport,
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().String("format", "", "Report format: md, html or json")
+	exportCmd.Flags().StringP("output", "o", "", "Write the report to a file instead of stdout")
+	exportCmd.Flags().Bool("no-logs", false, "Leave execution logs out of the report")
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	execID := args[0]
+	format, _ := cmd.Flags().GetString("format")
+	outputPath, _ := cmd.Flags().GetString("output")
+	noLogs, _ := cmd.Flags().GetBool("no-logs")
+
+	if format == "" {
+		format = cli.ReportFormatFromPath(outputPath)
+	}
+
+	apiClient, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	exec, err := apiClient.GetExecution(ctx, execID)
+	if err != nil {
+		return fmt.Errorf("failed to get execution: %w", err)
+	}
+
+	if !client.IsTerminalStatus(exec.Status) {
+		return fmt.Errorf("execution %s is still %s", execID, exec.Status)
+	}
+
+	report := &cli.Report{Execution: exec}
+
+	if report.Trace, err = apiClient.GetTrace(ctx, execID); err != nil {
+		return fmt.Errorf("failed to get trace: %w", err)
+	}
+
+	if !noLogs {
+		if report.Logs, err = apiClient.GetLogs(ctx, execID); err != nil {
+			return fmt.Errorf("failed to get logs: %w", err)
+		}
+	}
+
+	if outputPath == "" {
+		return cli.WriteReport(os.Stdout, format, report)
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+	defer file.Close()
+
+	if err := cli.WriteReport(file, format, report); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "📄 Report written to %s\n", outputPath)
+	return nil
+}
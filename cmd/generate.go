@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/not7/core/config"
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/llm"
+	"github.com/not7/core/spec"
+	"github.com/not7/core/tools/builtin"
+	"github.com/spf13/cobra"
+)
+
+// maxGenerateAttempts bounds how often a rejected draft is sent back to the
+// model with the validation error
+const maxGenerateAttempts = 3
+
+var (
+	generateOutput string
+	generateModel  string
+	generateForce  bool
+)
+
+var generateCmd = &cobra.Command{
+	Use:   "generate <description>",
+	Short: "Draft an agent spec from a description",
+	Long: `Draft an agent specification from a plain-language description using the
+configured OpenAI model. The draft is validated before it is written; an
+invalid draft is sent back to the model with the error, up to ` + fmt.Sprint(maxGenerateAttempts) + ` times.
+
+The spec is written to <id>.json unless --output is given ('-' for stdout).
+
+Example:
+  not7 generate "monitor HN for Go posts and email me a digest"`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runGenerate,
+}
+
+func init() {
+	generateCmd.Flags().StringVarP(&generateOutput, "output", "o", "", "File to write the spec to ('-' for stdout)")
+	generateCmd.Flags().StringVar(&generateModel, "model", "", "Model used to draft the spec (default: OPENAI_DEFAULT_MODEL)")
+	generateCmd.Flags().BoolVar(&generateForce, "force", false, "Overwrite the output file if it exists")
+	rootCmd.AddCommand(generateCmd)
+}
+
+func runGenerate(cmd *cobra.Command, args []string) error {
+	description := strings.Join(args, " ")
+
+	configFile := configFilePath()
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config from %s: %w", configFile, err)
+	}
+
+	llmClient, err := llm.NewOpenAIClient(cfg.OpenAI)
+	if err != nil {
+		return err
+	}
+
+	model := generateModel
+	if model == "" {
+		model = cfg.OpenAI.DefaultModel
+	}
+
+	toStdout := generateOutput == "-"
+	if !toStdout && generateOutput != "" && !generateForce {
+		// Fail before paying for a draft that cannot be written
+		if _, err := os.Stat(generateOutput); err == nil {
+			return fmt.Errorf("%s already exists (use --force to overwrite)", generateOutput)
+		}
+	}
+	if !toStdout {
+		ui.Infof("✨ Drafting agent with %s: %s\n", model, description)
+	}
+
+	agentSpec, cost, err := draftSpec(cmd.Context(), llmClient, model, description)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(agentSpec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode spec: %w", err)
+	}
+	data = append(data, '\n')
+
+	if toStdout {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	path := generateOutput
+	if path == "" {
+		path = agentSpec.ID + ".json"
+	}
+	if _, err := os.Stat(path); err == nil && !generateForce {
+		return fmt.Errorf("%s already exists (use --force to overwrite or --output to choose another file)", path)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write spec: %w", err)
+	}
+
+	ui.Printf("✅ Wrote %s\n", path)
+	ui.Infof("   Goal: %s\n", agentSpec.Goal)
+	ui.Infof("   Nodes: %d\n", len(agentSpec.Nodes))
+	ui.Infof("   💰 Drafting cost: $%.4f\n\n", cost)
+	ui.Infof("Review the prompts, then run it with: ./not7 run %s\n", path)
+	return nil
+}
+
+// draftSpec asks the model for a spec until one validates, feeding each
+// rejection back as the next input. It returns the spec and the total cost.
+func draftSpec(ctx context.Context, llmClient *llm.OpenAIClient, model, description string) (*spec.AgentSpec, float64, error) {
+	prompt, err := generatePrompt(ctx, model)
+	if err != nil {
+		return nil, 0, err
+	}
+	llmConfig := &spec.LLMConfig{Provider: "openai", Model: model, Temperature: 0.2}
+
+	input := description
+	var cost float64
+	var lastErr error
+	for attempt := 1; attempt <= maxGenerateAttempts; attempt++ {
+		completion, err := llmClient.Execute(ctx, llmConfig, prompt, input)
+		if err != nil {
+			return nil, cost, fmt.Errorf("failed to draft spec: %w", err)
+		}
+		cost += completion.Cost
+
+		draft := extractJSONObject(completion.Content)
+		agentSpec, err := checkDraft(draft)
+		if err == nil {
+			return agentSpec, cost, nil
+		}
+		lastErr = err
+
+		ui.Verbosef("Draft %d rejected: %v\n", attempt, err)
+		input = fmt.Sprintf("%s\n\nYour previous draft was:\n%s\n\nIt was rejected: %v\nReturn a corrected spec.", description, draft, err)
+	}
+
+	return nil, cost, fmt.Errorf("no valid spec after %d attempts: %w", maxGenerateAttempts, lastErr)
+}
+
+// checkDraft parses and validates a drafted spec the way run would
+func checkDraft(draft string) (*spec.AgentSpec, error) {
+	agentSpec, err := spec.Parse([]byte(draft))
+	if err != nil {
+		return nil, err
+	}
+	if err := spec.ValidateSpec(agentSpec); err != nil {
+		return nil, err
+	}
+	if agentSpec.ID == "" || filepath.Base(agentSpec.ID) != agentSpec.ID {
+		return nil, fmt.Errorf("id is required and must be a plain name")
+	}
+
+	// Render a copy so the written spec keeps its ${name} references
+	rendered, _ := spec.Parse([]byte(draft))
+	if err := rendered.Render(nil); err != nil {
+		return nil, err
+	}
+	return agentSpec, nil
+}
+
+// extractJSONObject returns the outermost JSON object in a model response,
+// which may wrap it in a markdown fence or prose
+func extractJSONObject(text string) string {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return strings.TrimSpace(text)
+	}
+	return text[start : end+1]
+}
+
+// generatePrompt builds the system prompt: the spec format, its schema and
+// the builtin tools an agent can use
+func generatePrompt(ctx context.Context, model string) (string, error) {
+	schema, err := spec.Schema()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate schema: %w", err)
+	}
+
+	defs, err := builtin.NewProvider("").ListTools(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to list builtin tools: %w", err)
+	}
+	var toolList strings.Builder
+	for _, def := range defs {
+		fmt.Fprintf(&toolList, "  - %s: %s\n", def.Name, def.Description)
+	}
+
+	return fmt.Sprintf(generatePromptTemplate, spec.CurrentVersion, model, toolList.String(), schema), nil
+}
+
+const generatePromptTemplate = `You design agents for NOT7, a runtime that executes agents declared as JSON.
+Turn the user's description into one agent spec. Reply with the JSON only.
+
+How specs run:
+- Nodes form a graph. Routes connect them, starting at "start" and finishing at "end".
+- An "llm" node sends its "prompt" as the system message and the previous node's output as the user message.
+- A "react" node works towards "react_goal" over up to "max_iterations" steps. With "tools_enabled": true it can call tools; "available_tools" limits which.
+- A "tool" node calls "tool_name" with "tool_arguments"; "{{input}}" in an argument is replaced with the previous node's output.
+- Prompts, goals and tool arguments are templates: "{{input | truncate 200}}", "{{input | json}}", "{{input | upper}}", "{{input | regex_extract \"[0-9]+\"}}" and "{{now}}" shape the input without another node.
+- A "conditional" node has a model classify its input into the labels of its routes, written as {"type": "label", "label": "..."} conditions, and follows the chosen one. Its optional "prompt" explains the labels.
+- A route can branch on a field of a node's JSON output with {"type": "switch", "path": "$.field", "equals": "value"}; a {"type": "default"} route runs when no other conditional route does.
+- An "llm" node whose output must be JSON can set "output": {"format": "json", "schema": {...}}; a non-conforming answer is retried with the errors.
+- A "transform" node computes its output from its input with an "expression" instead of a model call, in a subset of CEL: "data" is the input decoded as JSON, e.g. "data.items.filter(x, x.price > 10).map(x, x.name)", or with a JavaScript "script" body, e.g. "return data.items.filter(x => x.price > 10).map(x => x.name)". Non-string results are output as JSON.
+- A "sink" node appends its JSON input (an object or array of objects) to {"file": "name.csv"} or a ".jsonl" file, and passes it on; use one after a JSON "llm" node when the user wants results collected over runs.
+- Routes without a condition always run. Avoid cycles.
+- Independent research steps can be "parallel": true routes from one node; they meet at a node with "join": {"on_error": "continue"}, whose input lists each branch's status and output.
+
+Rules:
+- Set "version" to %q and a short kebab-case "id".
+- Set config.llm to provider "openai" and model %q.
+- For tools, set config.tools.provider to "builtin". The builtin tools are:
+%s- Only use capabilities these tools provide. When the description needs something they cannot do (such as sending email), produce the content for it as the final output instead, and say so in the goal.
+- When tools read untrusted content such as web pages or emails, set config.tools.injection to "strip".
+- When tools return long content such as web pages, set config.tools.results to {"strategy": "summarize", "max_tokens": 400}.
+- Prefer few nodes with specific, detailed prompts.
+- Values the user will likely change between runs (topics, names, limits) go in "vars" with sensible defaults and are referenced as ${name}.
+- Never write credentials into the spec; reference them in tool arguments as {{secret.NAME}}, e.g. {{secret.JIRA_TOKEN}}, to be defined in the server's config.
+
+The spec must match this JSON Schema:
+%s`
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/not7/core/config"
+	"github.com/not7/core/corpus"
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/llm"
+	"github.com/spf13/cobra"
+)
+
+// defaultEmbeddingModel embeds new corpora unless --model is given
+const defaultEmbeddingModel = "text-embedding-3-small"
+
+// ingestExtensions are the files picked up when a directory is ingested;
+// files named on the command line are ingested whatever their extension
+var ingestExtensions = map[string]bool{
+	".txt": true, ".md": true, ".markdown": true, ".rst": true,
+	".csv": true, ".json": true,
+}
+
+var (
+	ingestChunkSize int
+	ingestOverlap   int
+	ingestModel     string
+)
+
+var ingestCmd = &cobra.Command{
+	Use:   "ingest <corpus> <file|dir>...",
+	Short: "Add documents to a corpus for retrieve nodes",
+	Long: `Split text documents into chunks, embed them with the OpenAI embeddings API
+and add them to a named corpus in SERVER_CORPORA_DIR. Retrieve nodes search
+the corpus and pass the best chunks to the next node.
+
+Directories are walked for .txt, .md, .rst, .csv and .json files.
+Ingesting a document again replaces its chunks.
+
+Example:
+  not7 ingest handbook docs/handbook/
+  not7 ingest handbook faq.md --chunk-size 500`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runIngest,
+}
+
+func init() {
+	ingestCmd.Flags().IntVar(&ingestChunkSize, "chunk-size", corpus.DefaultChunkSize, "Target chunk length in characters")
+	ingestCmd.Flags().IntVar(&ingestOverlap, "overlap", corpus.DefaultOverlap, "Characters each chunk repeats from the one before")
+	ingestCmd.Flags().StringVar(&ingestModel, "model", "", "Embedding model for a new corpus (default "+defaultEmbeddingModel+")")
+	rootCmd.AddCommand(ingestCmd)
+}
+
+func runIngest(cmd *cobra.Command, args []string) error {
+	name, paths := args[0], args[1:]
+
+	configFile := configFilePath()
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config from %s: %w", configFile, err)
+	}
+	llmClient, err := llm.NewOpenAIClient(cfg.OpenAI)
+	if err != nil {
+		return err
+	}
+
+	files, err := ingestFiles(paths)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no documents found (directories are searched for .txt, .md, .rst, .csv and .json files)")
+	}
+
+	store := corpus.NewStore(cfg.Server.CorporaDir)
+	c, err := store.Load(name)
+	switch {
+	case errors.Is(err, corpus.ErrNotFound):
+		c = &corpus.Corpus{Name: name, Model: ingestModel}
+		if c.Model == "" {
+			c.Model = defaultEmbeddingModel
+		}
+	case err != nil:
+		return err
+	case ingestModel != "" && ingestModel != c.Model:
+		// Vectors from different models cannot be compared
+		return fmt.Errorf("corpus %s is embedded with %s; ingest into a new corpus to use %s", name, c.Model, ingestModel)
+	}
+
+	embedder := llmClient.NewEmbedder(c.Model)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		if bytes.IndexByte(data, 0) >= 0 {
+			ui.Infof("⏭️  %s: skipped, not a text file\n", file)
+			continue
+		}
+
+		source := filepath.ToSlash(filepath.Clean(file))
+		n, err := c.Add(cmd.Context(), embedder, source, string(data), ingestChunkSize, ingestOverlap)
+		if err != nil {
+			return err
+		}
+		ui.Infof("📄 %s: %d chunks\n", source, n)
+	}
+
+	if err := store.Save(c); err != nil {
+		return err
+	}
+	ui.Printf("✅ Corpus %s: %d chunks from %d documents (%s)\n", name, len(c.Chunks), len(c.Sources()), c.Model)
+	return nil
+}
+
+// ingestFiles expands paths into the files to ingest, walking directories
+func ingestFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if p != path && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if ingestExtensions[strings.ToLower(filepath.Ext(p))] {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var logsFollow bool
+
+var logsCmd = &cobra.Command{
+	Use:               "logs <execution-id>",
+	Short:             "Show execution logs",
+	Long:              `Print the log written during an agent execution. With --follow, keep streaming new lines until the execution completes.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeExecutionIDs,
+	RunE:              runLogs,
+}
+
+func init() {
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Stream new log lines until the execution completes")
+	rootCmd.AddCommand(logsCmd)
+}
+
+func runLogs(cmd *cobra.Command, args []string) error {
+	execID := args[0]
+
+	apiClient, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+
+	if err := apiClient.CheckHealth(cmd.Context()); err != nil {
+		return fmt.Errorf("server not running")
+	}
+
+	if logsFollow {
+		return apiClient.FollowLogs(cmd.Context(), execID, os.Stdout)
+	}
+
+	logs, err := apiClient.GetLogs(cmd.Context(), execID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(logs)
+	return nil
+}
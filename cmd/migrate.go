@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/spec"
+	"github.com/spf13/cobra"
+)
+
+var migrateWrite bool
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate <agent.json>",
+	Short: "Upgrade a spec to the current schema version",
+	Long: `Upgrade an agent specification written against an older schema version
+to the current one (` + spec.CurrentVersion + `) and print it. Outdated specs are
+migrated automatically when loaded; this command makes the upgrade permanent.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMigrate,
+}
+
+func init() {
+	migrateCmd.Flags().BoolVarP(&migrateWrite, "write", "w", false, "Rewrite the file in place instead of printing it")
+	rootCmd.AddCommand(migrateCmd)
+}
+
+func runMigrate(cmd *cobra.Command, args []string) error {
+	specFile := args[0]
+
+	data, err := os.ReadFile(specFile)
+	if err != nil {
+		return fmt.Errorf("failed to read spec: %w", err)
+	}
+
+	agentSpec, err := spec.Parse(data)
+	if err != nil {
+		return fmt.Errorf("invalid: %w", err)
+	}
+
+	var original struct {
+		Version string `json:"version"`
+	}
+	json.Unmarshal(data, &original)
+
+	if migrateWrite {
+		if original.Version == agentSpec.Version {
+			ui.Infof("✅ %s is already at version %s\n", specFile, agentSpec.Version)
+			return nil
+		}
+		if err := spec.SaveSpec(agentSpec, specFile); err != nil {
+			return err
+		}
+		ui.Infof("✅ Migrated %s to version %s\n", specFile, spec.CurrentVersion)
+		return nil
+	}
+
+	out, err := json.MarshalIndent(agentSpec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode spec: %w", err)
+	}
+	fmt.Println(string(out))
+	return nil
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/not7/core/config"
+	"github.com/not7/core/executor"
+	"github.com/not7/core/internal/cli"
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/spec"
+	"github.com/spf13/cobra"
+)
+
+var (
+	replayFile   string
+	replayOutput string
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay [execution-id]",
+	Short: "Re-run an execution from its recorded responses",
+	Long: `Re-run a past execution locally, answering every LLM call, tool call and
+retrieve node from the responses recorded in its trace instead of calling
+out. No API keys are used and nothing is billed.
+
+Replays check that the current runtime walks a spec the same way it did when
+the execution ran: the command reports the nodes whose output or status
+differs from the recording and exits non-zero when any does, or when the
+replay asks for a response that was never recorded.
+
+With an execution ID the trace is fetched from the server; --file replays
+a trace.json instead. --output writes the replayed trace for not7 trace --file.
+
+Example:
+  not7 replay 7f3c2a1e
+  not7 replay --file executions/7f3c2a1e/trace.json --output replayed.json`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeExecutionIDs,
+	RunE:              runReplay,
+}
+
+func init() {
+	replayCmd.Flags().StringVarP(&replayFile, "file", "f", "", "Local trace JSON file to replay")
+	replayCmd.Flags().StringVarP(&replayOutput, "output", "o", "", "File to write the replayed trace to")
+	rootCmd.AddCommand(replayCmd)
+}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	trace, input, err := loadReplayTrace(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	rec, err := executor.NewRecording(trace)
+	if err != nil {
+		return err
+	}
+	recorded := trace.Metadata.NodeResults
+	trace.Metadata = nil
+
+	// Replays make no calls, so the config only needs to be readable
+	cfg, err := config.ReadConfig(configFilePath())
+	if err != nil {
+		return fmt.Errorf("failed to load config from %s: %w", configFilePath(), err)
+	}
+
+	cli.PrintLiveTraceHeader()
+	ui.Infof("🎯 Goal: %s\n\n", trace.Goal)
+
+	exec, err := executor.NewReplayExecutor(trace, cfg, rec)
+	if err != nil {
+		return fmt.Errorf("failed to create executor: %w", err)
+	}
+
+	output, runErr := exec.Execute(input)
+	metadata := exec.GetMetadata()
+	if runErr == nil {
+		cli.PrintLiveTraceSummary(metadata, output)
+	} else if !errors.Is(runErr, executor.ErrReplayDiverged) {
+		// Only a failure the recording shares can still match it
+		ui.Printf("❌ Replay failed: %v\n\n", runErr)
+	}
+
+	if replayOutput != "" {
+		trace.Metadata = metadata
+		data, err := json.MarshalIndent(trace, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal replayed trace: %w", err)
+		}
+		if err := os.WriteFile(replayOutput, data, 0644); err != nil {
+			return fmt.Errorf("failed to write replayed trace: %w", err)
+		}
+		ui.Infof("Replayed trace written to %s\n", replayOutput)
+	}
+
+	if errors.Is(runErr, executor.ErrReplayDiverged) {
+		return runErr
+	}
+
+	differences := compareNodeResults(recorded, metadata.NodeResults)
+	for _, nodeID := range rec.Unused() {
+		differences = append(differences, fmt.Sprintf("%s: recorded calls were not replayed", nodeID))
+	}
+	if len(differences) > 0 {
+		for _, d := range differences {
+			ui.Printf("  ≠ %s\n", d)
+		}
+		return fmt.Errorf("replay differs from the recording at %d node(s)", len(differences))
+	}
+
+	ui.Printf("✓ Replay matches the recording (%d nodes)\n", len(recorded))
+	return nil
+}
+
+// loadReplayTrace returns the trace and input of the execution to replay
+func loadReplayTrace(cmd *cobra.Command, args []string) (*spec.AgentSpec, string, error) {
+	if len(args) == 1 {
+		if replayFile != "" {
+			return nil, "", fmt.Errorf("--file cannot be combined with an execution ID")
+		}
+
+		apiClient, err := newAPIClient()
+		if err != nil {
+			return nil, "", err
+		}
+		trace, err := apiClient.GetTrace(cmd.Context(), args[0])
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get trace: %w", err)
+		}
+		execution, err := apiClient.GetExecution(cmd.Context(), args[0])
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get execution: %w", err)
+		}
+		return trace, execution.Input, nil
+	}
+
+	if replayFile == "" {
+		return nil, "", fmt.Errorf("an execution ID or --file is required")
+	}
+
+	data, err := os.ReadFile(replayFile)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read trace file: %w", err)
+	}
+
+	// The input is kept in the trace metadata, which spec.Metadata omits
+	var trace spec.AgentSpec
+	var raw struct {
+		Metadata struct {
+			Input string `json:"input"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &trace); err != nil {
+		return nil, "", fmt.Errorf("failed to parse trace: %w", err)
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, "", fmt.Errorf("failed to parse trace: %w", err)
+	}
+	return &trace, raw.Metadata.Input, nil
+}
+
+// compareNodeResults describes each node whose replayed status or output
+// differs from the recording, in the recording's order
+func compareNodeResults(recorded, replayed []spec.NodeResult) []string {
+	byID := make(map[string]spec.NodeResult, len(replayed))
+	for _, r := range replayed {
+		byID[r.NodeID] = r
+	}
+
+	var differences []string
+	for _, want := range recorded {
+		got, ok := byID[want.NodeID]
+		switch {
+		case !ok:
+			differences = append(differences, fmt.Sprintf("%s: did not run", want.NodeID))
+		case got.Status != want.Status:
+			differences = append(differences, fmt.Sprintf("%s: %s, recorded %s", want.NodeID, got.Status, want.Status))
+		case fmt.Sprint(got.Output) != fmt.Sprint(want.Output):
+			differences = append(differences, fmt.Sprintf("%s: output differs", want.NodeID))
+		}
+		delete(byID, want.NodeID)
+	}
+	extra := make([]string, 0, len(byID))
+	for nodeID := range byID {
+		extra = append(extra, nodeID)
+	}
+	sort.Strings(extra)
+	for _, nodeID := range extra {
+		differences = append(differences, fmt.Sprintf("%s: ran but was not recorded", nodeID))
+	}
+	return differences
+}
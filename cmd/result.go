@@ -3,17 +3,17 @@ package cmd
 import (
 	"fmt"
 
-	"github.com/not7/core/client"
 	"github.com/not7/core/internal/cli"
 	"github.com/spf13/cobra"
 )
 
 var resultCmd = &cobra.Command{
-	Use:   "result <execution-id>",
-	Short: "Get execution result",
-	Long:  `Get the result of a completed agent execution`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runResult,
+	Use:               "result <execution-id>",
+	Short:             "Get execution result",
+	Long:              `Get the result of a completed agent execution`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeExecutionIDs,
+	RunE:              runResult,
 }
 
 func init() {
@@ -23,13 +23,16 @@ func init() {
 func runResult(cmd *cobra.Command, args []string) error {
 	execID := args[0]
 
-	apiClient := client.NewClient("")
+	apiClient, err := newAPIClient()
+	if err != nil {
+		return err
+	}
 
-	if err := apiClient.CheckHealth(); err != nil {
+	if err := apiClient.CheckHealth(cmd.Context()); err != nil {
 		return fmt.Errorf("server not running")
 	}
 
-	result, err := apiClient.GetExecutionResult(execID)
+	result, err := apiClient.GetExecutionResult(cmd.Context(), execID)
 	if err != nil {
 		return err
 	}
@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 
+	"github.com/not7/core/config"
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/logger"
 	"github.com/spf13/cobra"
 )
 
@@ -14,17 +19,62 @@ var rootCmd = &cobra.Command{
 
 NOT7 allows you to define AI agents using JSON specifications and execute
 them with built-in chain-of-thought reasoning and tool calling capabilities.`,
+	// Execute prints errors itself; usage is only shown on request
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		configureOutput()
+	},
+}
+
+var (
+	quietMode   bool
+	verboseMode bool
+	plainMode   bool
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVarP(&quietMode, "quiet", "q", false, "Only print essential output (results, IDs, errors)")
+	rootCmd.PersistentFlags().BoolVarP(&verboseMode, "verbose", "v", false, "Print extra detail (full thoughts, tool arguments, server URL) and debug logs")
+	rootCmd.PersistentFlags().BoolVar(&plainMode, "no-emoji", false, "Print plain text without emoji")
+	rootCmd.PersistentFlags().BoolVar(&plainMode, "plain", false, "Alias for --no-emoji")
+	rootCmd.MarkFlagsMutuallyExclusive("quiet", "verbose")
+}
+
+// configureOutput applies the global output flags
+func configureOutput() {
+	switch {
+	case quietMode:
+		ui.SetLevel(ui.LevelQuiet)
+	case verboseMode:
+		ui.SetLevel(ui.LevelVerbose)
+		logger.SetDefaultLevel(logger.DEBUG)
+	}
+	ui.SetPlain(plainMode)
+}
+
+// applyLogSettings sets the log format from the config, and the log level
+// unless --verbose already raised it to debug for this invocation
+func applyLogSettings(cfg *config.Config) {
+	if format, err := logger.ParseFormat(cfg.Log.Format); err == nil {
+		logger.SetDefaultFormat(format)
+	}
+	if verboseMode {
+		return
+	}
+	if level, err := logger.ParseLevel(cfg.Log.Level); err == nil {
+		logger.SetDefaultLevel(level)
+	}
 }
 
 // Execute runs the root command
+// Commands receive a context that is cancelled on Ctrl+C
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
-
-func init() {
-	// Disable default completion command
-	rootCmd.CompletionOptions.DisableDefaultCmd = true
-}
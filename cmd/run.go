@@ -1,63 +1,315 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/not7/core/client"
+	"github.com/not7/core/config"
+	"github.com/not7/core/executor"
 	"github.com/not7/core/internal/cli"
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/registry"
+	"github.com/not7/core/spec"
 	"github.com/spf13/cobra"
 )
 
 var (
 	streamMode bool
 	asyncMode  bool
+	runInput   string
+	inputFile  string
+	runVars    []string
+	runTags    []string
+	runAttach  []string
+	followMode bool
+	parallel   int
+	batchAPI   bool
 )
 
 var runCmd = &cobra.Command{
-	Use:   "run <agent.json>",
+	Use:   "run <agent.json | directory | registry://namespace/name@version | url>",
 	Short: "Execute an agent",
-	Long:  `Execute an agent from a JSON specification file`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runAgent,
+	Long: `Execute an agent from a JSON specification file.
+
+registry://namespace/name@version fetches the spec from the registry at
+REGISTRY_URL, and an http(s) URL fetches it from there. Fetched specs are
+verified against their SHA-256 checksum and cached in REGISTRY_CACHE_DIR.
+
+Given a directory, every *.json spec in it is run with up to --parallel
+executions at a time, followed by a summary of statuses and costs.
+
+--var name=value overrides a default from the spec's "vars" section, which
+is substituted for ${name} in the goal, prompts and tool arguments.
+
+--tag key=value labels the execution; not7 executions --tag lists by it.
+
+--attach file sends a document with the run. Nodes read it with the
+ReadAttachment tool.
+
+--batch-api sends the OpenAI calls of an --async or directory run through
+the Batch API, for half the cost. The execution is suspended while a call
+waits for its batch, which can take up to 24 hours.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAgent,
 }
 
 func init() {
 	rootCmd.AddCommand(runCmd)
 	runCmd.Flags().BoolVar(&streamMode, "stream", false, "Stream live agent reasoning")
 	runCmd.Flags().BoolVar(&asyncMode, "async", false, "Run agent in background")
+	runCmd.Flags().BoolVarP(&followMode, "follow", "f", false, "With --async, follow live progress until completion")
+	runCmd.Flags().StringVar(&runInput, "input", "", "Input passed to the agent's first node")
+	runCmd.Flags().StringVar(&inputFile, "input-file", "", "Read agent input from a file ('-' for stdin)")
+	runCmd.Flags().StringArrayVar(&runVars, "var", nil, "Override a spec variable as name=value (repeatable)")
+	runCmd.Flags().StringArrayVar(&runTags, "tag", nil, "Label the execution with key=value (repeatable)")
+	runCmd.Flags().StringArrayVar(&runAttach, "attach", nil, "Attach a file for the agent to read (repeatable)")
+	runCmd.Flags().BoolVar(&batchAPI, "batch-api", false, "With --async or a directory, send OpenAI calls through the Batch API (half the cost, answers within 24h)")
+	runCmd.Flags().IntVar(&parallel, "parallel", 1, "When running a directory, number of agents to run at once")
+	runCmd.MarkFlagsMutuallyExclusive("input", "input-file")
 }
 
 func runAgent(cmd *cobra.Command, args []string) error {
 	specFile := args[0]
 
 	// Always use API client (server must be running)
-	apiClient := client.NewClient("")
+	apiClient, err := newAPIClient()
+	if err != nil {
+		return err
+	}
 
-	if err := apiClient.CheckHealth(); err != nil {
+	if err := apiClient.CheckHealth(cmd.Context()); err != nil {
 		return fmt.Errorf("server not running. Start server first:\n  Terminal 1: ./not7 serve\n  Terminal 2: ./not7 run agent.json")
 	}
 
-	agentJSON, err := os.ReadFile(specFile)
+	input, err := readRunInput()
+	if err != nil {
+		return err
+	}
+
+	vars, err := parseKeyValues("--var", runVars)
+	if err != nil {
+		return err
+	}
+	tags, err := parseKeyValues("--tag", runTags)
 	if err != nil {
-		return fmt.Errorf("failed to read spec: %w", err)
+		return err
+	}
+	attachments, err := readAttachments(runAttach)
+	if err != nil {
+		return err
 	}
 
-	fmt.Printf("📖 Executing: %s\n", specFile)
+	if info, err := os.Stat(specFile); err == nil && info.IsDir() {
+		return runBatch(cmd.Context(), apiClient, specFile, parallel, client.RunOptions{BatchAPI: batchAPI, Input: input, Vars: vars, Tags: tags, Attachments: attachments})
+	}
+
+	if batchAPI && !asyncMode {
+		return fmt.Errorf("--batch-api requires --async")
+	}
+
+	agentJSON, err := readSpecSource(cmd.Context(), specFile)
+	if err != nil {
+		return err
+	}
+
+	ui.Infof("📖 Executing: %s\n", specFile)
+
+	// The server migrates outdated specs and checks input too; doing it here
+	// shows warnings where the user sees them and fails before submitting
+	if agentSpec, err := spec.Parse(agentJSON); err == nil {
+		cli.PrintSpecIssues(agentSpec.Warnings())
+		withDefaults, err := spec.ApplyInputDefaults(agentSpec, input)
+		if err != nil {
+			return err
+		}
+		if err := spec.ValidateInput(agentSpec, withDefaults); err != nil {
+			return fmt.Errorf("invalid input: %w", err)
+		}
+		if agentJSON, err = inlinePrompts(agentSpec, specFile, agentJSON); err != nil {
+			return err
+		}
+	}
+
+	opts := client.RunOptions{Async: asyncMode, Stream: streamMode, BatchAPI: batchAPI, Input: input, Vars: vars, Tags: tags, Attachments: attachments}
+
+	if (streamMode && !asyncMode) || (asyncMode && followMode) {
+		return runStreaming(cmd.Context(), apiClient, agentJSON, opts)
+	}
 
 	// Execute via API with stream and async options
-	result, err := apiClient.RunAgent(agentJSON, asyncMode, streamMode)
+	result, err := apiClient.RunAgent(cmd.Context(), agentJSON, opts)
 	if err != nil {
 		return err
 	}
 
 	if asyncMode {
-		fmt.Printf("\n✅ Submitted (background)\n")
-		fmt.Printf("📋 Execution ID: %s\n\n", result["execution_id"])
-		fmt.Printf("Check status: ./not7 status %s\n", result["execution_id"])
+		// Quiet mode prints the bare ID so scripts can capture it
+		if ui.IsQuiet() {
+			ui.Println(result.ID)
+			return nil
+		}
+
+		ui.Infof("\n✅ Submitted (background)\n")
+		ui.Infof("📋 Execution ID: %s\n\n", result.ID)
+		ui.Infof("Check status: ./not7 status %s\n", result.ID)
+		printViewURL(apiClient, result.ID)
 	} else {
 		cli.PrintExecutionResult(result)
+		printViewURL(apiClient, result.ID)
 	}
 
 	return nil
 }
+
+// inlinePrompts resolves a spec file's prompt_refs against its directory and
+// the configured prompts directory, so the server receives the prompts
+// rather than paths it may not be able to read. Specs without refs are
+// returned as read.
+func inlinePrompts(agentSpec *spec.AgentSpec, specFile string, agentJSON []byte) ([]byte, error) {
+	if !agentSpec.HasPromptRefs() {
+		return agentJSON, nil
+	}
+	if err := agentSpec.ResolvePrompts(promptDirs(specFile)...); err != nil {
+		return nil, fmt.Errorf("invalid spec: %w", err)
+	}
+	return json.Marshal(agentSpec)
+}
+
+// readSpecSource reads a spec file, or fetches a spec from a registry
+// reference or URL
+func readSpecSource(ctx context.Context, source string) ([]byte, error) {
+	if !registry.IsRemote(source) {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read spec: %w", err)
+		}
+		return data, nil
+	}
+
+	// URLs need no config; they are then fetched without caching
+	var registryCfg config.RegistryConfig
+	if cfg, err := config.ReadConfig(configFilePath()); err == nil {
+		registryCfg = cfg.Registry
+	} else if strings.HasPrefix(source, registry.Scheme) {
+		return nil, fmt.Errorf("failed to read registry settings from %s: %w", configFilePath(), err)
+	}
+
+	ui.Verbosef("Fetching spec: %s\n", source)
+	return registry.NewClient(registryCfg).Fetch(ctx, source)
+}
+
+// promptDirs lists where a spec file's prompt_refs are looked up. A remote
+// spec's refs can only be resolved against the prompts directory.
+func promptDirs(specFile string) []string {
+	var dirs []string
+	if !registry.IsRemote(specFile) {
+		dirs = append(dirs, filepath.Dir(specFile))
+	}
+	if cfg, err := config.ReadConfig(configFilePath()); err == nil {
+		dirs = append(dirs, cfg.Server.PromptsDir)
+	}
+	return dirs
+}
+
+// readRunInput returns the agent input from --input or --input-file
+func readRunInput() (string, error) {
+	if inputFile == "" {
+		return runInput, nil
+	}
+
+	var data []byte
+	var err error
+	if inputFile == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(inputFile)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// readAttachments reads the files given with --attach, each named after its
+// base name
+func readAttachments(paths []string) ([]client.Attachment, error) {
+	var attachments []client.Attachment
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read attachment: %w", err)
+		}
+		name := filepath.Base(path)
+		attachments = append(attachments, client.Attachment{Name: name, ContentType: executor.ArtifactContentType(name, data), Data: data})
+	}
+	return attachments, nil
+}
+
+// parseKeyValues turns repeated name=value flags, such as --var, into a map
+func parseKeyValues(flagName string, flags []string) (map[string]string, error) {
+	if len(flags) == 0 {
+		return nil, nil
+	}
+
+	vars := make(map[string]string, len(flags))
+	for _, flag := range flags {
+		name, value, ok := strings.Cut(flag, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid %s %q: expected name=value", flagName, flag)
+		}
+		vars[name] = value
+	}
+	return vars, nil
+}
+
+// runStreaming submits the agent in the background and renders its events live
+func runStreaming(ctx context.Context, apiClient *client.NOT7Client, agentJSON []byte, opts client.RunOptions) error {
+	opts.Async = true
+	submitted, err := apiClient.RunAgent(ctx, agentJSON, opts)
+	if err != nil {
+		return err
+	}
+
+	ui.Printf("📋 Execution ID: %s\n\n", submitted.ID)
+
+	return followExecution(ctx, apiClient, submitted.ID)
+}
+
+// followExecution live-renders an execution's events and then prints its result
+func followExecution(ctx context.Context, apiClient *client.NOT7Client, execID string) error {
+	err := apiClient.StreamExecution(ctx, execID, func(event client.Event) error {
+		cli.PrintEvent(event)
+		return nil
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			ui.Infof("\n⏸️  Stopped following. The execution continues in the background.\n")
+			ui.Printf("Resume with: ./not7 watch %s\n", execID)
+			return nil
+		}
+		return fmt.Errorf("stream interrupted: %w", err)
+	}
+
+	result, err := apiClient.GetExecutionResult(ctx, execID)
+	if err != nil {
+		return err
+	}
+
+	cli.PrintExecutionResult(result)
+	printViewURL(apiClient, execID)
+	return nil
+}
+
+// printViewURL prints where an execution's trace can be viewed in a browser
+func printViewURL(apiClient *client.NOT7Client, execID string) {
+	if link := apiClient.ViewURL(execID); link != "" {
+		ui.Infof("🔍 Trace: %s\n", link)
+	}
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/spec"
+	"github.com/spf13/cobra"
+)
+
+var schemaOutput string
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of agent specs",
+	Long: `Print the JSON Schema of NOT7 agent specifications, for editor completion
+and validation in CI. Reference it from a spec with:
+
+  "$schema": "` + spec.SchemaID + `"`,
+	Args: cobra.NoArgs,
+	RunE: runSchema,
+}
+
+func init() {
+	schemaCmd.Flags().StringVarP(&schemaOutput, "output", "o", "", "Write the schema to a file instead of stdout")
+	rootCmd.AddCommand(schemaCmd)
+}
+
+func runSchema(cmd *cobra.Command, args []string) error {
+	schema, err := spec.Schema()
+	if err != nil {
+		return fmt.Errorf("failed to generate schema: %w", err)
+	}
+	schema = append(schema, '\n')
+
+	if schemaOutput != "" {
+		if err := os.WriteFile(schemaOutput, schema, 0644); err != nil {
+			return fmt.Errorf("failed to write schema: %w", err)
+		}
+		ui.Infof("✅ Schema written to %s\n", schemaOutput)
+		return nil
+	}
+
+	_, err = os.Stdout.Write(schema)
+	return err
+}
@@ -1,44 +1,174 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/not7/core/config"
+	"github.com/not7/core/internal/cli"
+	"github.com/not7/core/internal/probe"
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/internal/vcr"
+	"github.com/not7/core/notify"
+	"github.com/not7/core/reporting"
 	"github.com/not7/core/server"
+	"github.com/not7/core/tracing"
 	"github.com/spf13/cobra"
 )
 
 var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Start the NOT7 agent server",
-	Long:  `Start the NOT7 server to accept and execute agent requests via HTTP API`,
-	RunE:  runServe,
+	Long: `Start the NOT7 server to accept and execute agent requests via HTTP API.
+
+With --daemon the server runs in the background: it logs to
+SERVER_LOG_DIR/server.log and writes its process ID to SERVER_PID_FILE, and
+not7 stop and not7 restart manage it.
+
+With --check-providers the server first checks the keys of the configured
+LLM and tool providers with a cheap request each, and does not start when
+one fails. --check-providers=warn reports failures and starts anyway. The
+results are shown at /health.
+
+Set NOT7_VCR_CASSETTE to a fixture file to record the server's LLM and tool
+calls to it, or to replay them from it without calling out. NOT7_VCR_MODE
+picks auto (replay when the file exists, the default), record or replay.`,
+	RunE: runServe,
 }
 
+// providerCheckMode is the --check-providers mode: "", "fail" or "warn"
+var providerCheckMode string
+
 func init() {
 	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().StringVar(&providerCheckMode, "check-providers", "", "Check provider keys at startup; fail stops the server on a failed check, warn starts it anyway")
+	serveCmd.Flags().Lookup("check-providers").NoOptDefVal = "fail"
 }
 
 func runServe(cmd *cobra.Command, args []string) error {
 	// Load config
-	configFile := "not7.conf"
-	if envConfig := os.Getenv("NOT7_CONFIG"); envConfig != "" {
-		configFile = envConfig
+	configFile := configFilePath()
+
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config from %s: %w\n\nPlease copy not7.conf.example to not7.conf and update with your API key:\n  cp not7.conf.example not7.conf\n  # Then edit not7.conf with your OpenAI API key\n\nor set the keys as environment variables instead, e.g. OPENAI_API_KEY=sk-...", configFile, err)
 	}
 
-	if _, err := config.LoadConfig(configFile); err != nil {
-		return fmt.Errorf("failed to load config from %s: %w\n\nPlease copy not7.conf.example to not7.conf and update with your API key:\n  cp not7.conf.example not7.conf\n  # Then edit not7.conf with your OpenAI API key", configFile, err)
+	cli.PrintConfigIssues(cfg.Warnings())
+	applyLogSettings(cfg)
+
+	var providers []probe.Result
+	if providerCheckMode != "" {
+		if providers, err = startupChecks(cmd.Context(), cfg); err != nil {
+			return err
+		}
 	}
 
-	// Start server
-	cfg := config.Get()
+	if daemonMode {
+		return startDaemon(cfg)
+	}
+	if pidFile != "" {
+		path, err := pidFilePath(cfg)
+		if err != nil {
+			return err
+		}
+		if err := writePIDFile(path); err != nil {
+			return err
+		}
+		defer removePIDFile(path)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			ui.Printf("⚠️  Failed to flush traces: %v\n", err)
+		}
+	}()
+
+	if err := reporting.Setup(cfg.Reporting); err != nil {
+		return fmt.Errorf("failed to set up error reporting: %w", err)
+	}
+	if err := notify.Setup(cfg.Notify); err != nil {
+		return fmt.Errorf("failed to set up notifications: %w", err)
+	}
 
-	srv := server.NewServer(cfg.Server.Port, cfg.Server.ExecutionsDir, cfg.Server.LogDir)
+	if cassette := os.Getenv("NOT7_VCR_CASSETTE"); cassette != "" {
+		mode, err := vcr.ParseMode(os.Getenv("NOT7_VCR_MODE"))
+		if err != nil {
+			return err
+		}
+		recorder, err := vcr.New(cassette, mode, nil)
+		if err != nil {
+			return err
+		}
+		defer vcr.Install(recorder)()
 
-	if err := srv.Start(); err != nil {
+		action := "Replaying"
+		if recorder.Mode() == vcr.ModeRecord {
+			action = "Recording"
+		}
+		ui.Printf("📼 %s HTTP interactions: %s\n", action, cassette)
+		if recorder.Mode() == vcr.ModeReplay {
+			defer func() {
+				if unused := recorder.Unused(); unused > 0 {
+					ui.Printf("⚠️  %d recorded interactions were not replayed\n", unused)
+				}
+			}()
+		}
+	}
+
+	// Start server; it runs until it fails or is told to stop
+	srv, err := server.NewServer(cfg)
+	if err != nil {
+		return err
+	}
+	if providerCheckMode != "" {
+		srv.SetProviderChecks(providers)
+	}
+	ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGTERM)
+	defer stop()
+
+	failed := make(chan error, 1)
+	go func() { failed <- srv.Start() }()
+	select {
+	case err := <-failed:
 		return fmt.Errorf("server error: %w", err)
+	case <-ctx.Done():
+		ui.Infof("\n👋 Server stopped\n")
+		return nil
+	}
+}
+
+// startupChecks checks the configured providers and prints the results. In
+// fail mode a failed check is an error.
+func startupChecks(ctx context.Context, cfg *config.Config) ([]probe.Result, error) {
+	if providerCheckMode != "fail" && providerCheckMode != "warn" {
+		return nil, fmt.Errorf("invalid --check-providers: %s (expected fail or warn)", providerCheckMode)
+	}
+
+	ui.Infof("🔌 Checking providers...\n")
+	results := probe.Providers(ctx, cfg)
+	for _, result := range results {
+		if result.Err != nil {
+			ui.Printf("   ❌ %s: %v\n", result.Name, result.Err)
+			ui.Printf("      → %s\n", result.Hint)
+			continue
+		}
+		ui.Infof("   ✅ %s: %s\n", result.Name, result.Detail)
+	}
+	if len(results) == 0 {
+		ui.Infof("   No providers configured\n")
 	}
 
-	return nil
+	failed := probe.Failed(results)
+	if len(failed) > 0 && providerCheckMode == "fail" {
+		return nil, fmt.Errorf("%d provider check(s) failed; fix the config or start with --check-providers=warn", len(failed))
+	}
+	return results, nil
 }
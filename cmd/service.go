@@ -0,0 +1,427 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/not7/core/config"
+	"github.com/not7/core/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serviceName    string
+	serviceUser    string
+	serviceWorkDir string
+	serviceEnv     []string
+	servicePrint   bool
+	serviceConfig  string
+)
+
+var serviceCmd = &cobra.Command{
+	Use:   "service",
+	Short: "Install the server as a system service",
+	Long: `Install or remove the NOT7 server as a system service that starts at
+boot and restarts when it exits: a systemd unit on Linux, a launchd job on
+macOS and a Windows service on Windows.`,
+}
+
+var serviceInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Generate and install the service for this platform",
+	Long: `Generate the service definition for this platform and install it. The
+service runs this not7 executable as "not7 serve" in the working directory,
+with NOT7_CONFIG set to the config file found from it.
+
+On Linux the unit is written to /etc/systemd/system/<name>.service, which
+needs root; it runs as --user, by default the user who invoked sudo. On macOS
+the job is a LaunchDaemon when run as root, otherwise a LaunchAgent of the
+current user. On Windows, run from an administrator prompt.
+
+Use --print to see the definition without installing it.`,
+	Example: `  sudo not7 service install
+  sudo not7 service install --user not7 --workdir /srv/not7 --env LOG_FORMAT=json
+  not7 service install --print`,
+	Args: cobra.NoArgs,
+	RunE: runServiceInstall,
+}
+
+var serviceUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Stop and remove the installed service",
+	Args:  cobra.NoArgs,
+	RunE:  runServiceUninstall,
+}
+
+// serviceRunCmd is what an installed Windows service runs; the service
+// manager gives it neither a working directory nor an environment
+var serviceRunCmd = &cobra.Command{
+	Use:    "run",
+	Short:  "Run the server as an installed service",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE:   runServiceRun,
+}
+
+func init() {
+	serviceCmd.PersistentFlags().StringVar(&serviceName, "name", "not7", "Service name")
+	serviceInstallCmd.Flags().StringVar(&serviceUser, "user", "", "User the service runs as (default: the user who invoked sudo, or the current user)")
+	for _, command := range []*cobra.Command{serviceInstallCmd, serviceRunCmd} {
+		command.Flags().StringVar(&serviceWorkDir, "workdir", "", "Working directory of the server (default: the current directory)")
+		command.Flags().StringArrayVar(&serviceEnv, "env", nil, "Set an environment variable as KEY=VALUE (repeatable)")
+	}
+	serviceInstallCmd.Flags().BoolVar(&servicePrint, "print", false, "Print the service definition instead of installing it")
+	serviceRunCmd.Flags().StringVar(&serviceConfig, "config", "", "Config file of the server")
+
+	serviceCmd.AddCommand(serviceInstallCmd)
+	serviceCmd.AddCommand(serviceUninstallCmd)
+	serviceCmd.AddCommand(serviceRunCmd)
+	rootCmd.AddCommand(serviceCmd)
+}
+
+// serviceDefinition is what every platform's service is generated from
+type serviceDefinition struct {
+	Name       string
+	Executable string
+	WorkDir    string
+	ConfigPath string
+	User       string
+	LogDir     string
+	Env        []string // KEY=VALUE, NOT7_CONFIG first
+}
+
+// newServiceDefinition resolves the paths, user and environment of the
+// service from the flags and the current directory
+func newServiceDefinition() (*serviceDefinition, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the not7 executable: %w", err)
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return nil, fmt.Errorf("failed to find the not7 executable: %w", err)
+	}
+
+	workDir := serviceWorkDir
+	if workDir == "" {
+		workDir = "."
+	}
+	if workDir, err = filepath.Abs(workDir); err != nil {
+		return nil, err
+	}
+
+	// The config is looked up as the server would from its directory
+	configPath := os.Getenv("NOT7_CONFIG")
+	if configPath == "" {
+		current, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Chdir(workDir); err != nil {
+			return nil, fmt.Errorf("working directory: %w", err)
+		}
+		configPath, err = filepath.Abs(config.DefaultPath())
+		os.Chdir(current)
+		if err != nil {
+			return nil, err
+		}
+	} else if !filepath.IsAbs(configPath) {
+		configPath = filepath.Join(workDir, configPath)
+	}
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config from %s: %w", configPath, err)
+	}
+
+	logDir := cfg.Server.LogDir
+	if !filepath.IsAbs(logDir) {
+		logDir = filepath.Join(workDir, logDir)
+	}
+
+	env := []string{"NOT7_CONFIG=" + configPath}
+	for _, pair := range serviceEnv {
+		if key, _, ok := strings.Cut(pair, "="); !ok || key == "" {
+			return nil, fmt.Errorf("invalid --env %q (expected KEY=VALUE)", pair)
+		}
+		env = append(env, pair)
+	}
+
+	return &serviceDefinition{
+		Name:       serviceName,
+		Executable: executable,
+		WorkDir:    workDir,
+		ConfigPath: configPath,
+		User:       serviceUserName(),
+		LogDir:     logDir,
+		Env:        env,
+	}, nil
+}
+
+// serviceUserName returns --user, else the user who invoked sudo, else the
+// current user
+func serviceUserName() string {
+	if serviceUser != "" {
+		return serviceUser
+	}
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" {
+		return sudoUser
+	}
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return ""
+}
+
+func runServiceInstall(cmd *cobra.Command, args []string) error {
+	def, err := newServiceDefinition()
+	if err != nil {
+		return err
+	}
+
+	switch runtime.GOOS {
+	case "linux":
+		return installSystemd(def)
+	case "darwin":
+		return installLaunchd(def)
+	case "windows":
+		return installWindowsService(def)
+	default:
+		return fmt.Errorf("service install is not supported on %s", runtime.GOOS)
+	}
+}
+
+func runServiceUninstall(cmd *cobra.Command, args []string) error {
+	switch runtime.GOOS {
+	case "linux":
+		return uninstallSystemd(serviceName)
+	case "darwin":
+		return uninstallLaunchd(serviceName)
+	case "windows":
+		return uninstallWindowsService(serviceName)
+	default:
+		return fmt.Errorf("service uninstall is not supported on %s", runtime.GOOS)
+	}
+}
+
+func runServiceRun(cmd *cobra.Command, args []string) error {
+	if serviceWorkDir != "" {
+		if err := os.Chdir(serviceWorkDir); err != nil {
+			return fmt.Errorf("working directory: %w", err)
+		}
+	}
+	if serviceConfig != "" {
+		os.Setenv("NOT7_CONFIG", serviceConfig)
+	}
+	for _, pair := range serviceEnv {
+		if key, value, ok := strings.Cut(pair, "="); ok {
+			os.Setenv(key, value)
+		}
+	}
+	return runAsService(cmd.Context(), serviceName, func(ctx context.Context) error {
+		cmd.SetContext(ctx)
+		return runServe(cmd, nil)
+	})
+}
+
+// systemdUnitPath returns where the systemd unit of a service is installed
+func systemdUnitPath(name string) string {
+	return filepath.Join("/etc/systemd/system", name+".service")
+}
+
+// systemdUnit renders the systemd unit of def
+func systemdUnit(def *serviceDefinition) string {
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=NOT7 agent server\n")
+	b.WriteString("After=network-online.target\n")
+	b.WriteString("Wants=network-online.target\n\n")
+	b.WriteString("[Service]\n")
+	b.WriteString("Type=simple\n")
+	if def.User != "" && def.User != "root" {
+		fmt.Fprintf(&b, "User=%s\n", def.User)
+	}
+	fmt.Fprintf(&b, "WorkingDirectory=%s\n", systemdQuote(def.WorkDir))
+	for _, pair := range def.Env {
+		fmt.Fprintf(&b, "Environment=%s\n", systemdQuote(pair))
+	}
+	fmt.Fprintf(&b, "ExecStart=%s serve\n", systemdQuote(def.Executable))
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=5\n\n")
+	b.WriteString("[Install]\n")
+	b.WriteString("WantedBy=multi-user.target\n")
+	return b.String()
+}
+
+// systemdQuote quotes a unit file value when it holds spaces or quotes
+func systemdQuote(value string) string {
+	if !strings.ContainsAny(value, " \t\"\\") {
+		return value
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+func installSystemd(def *serviceDefinition) error {
+	unit := systemdUnit(def)
+	if servicePrint {
+		fmt.Print(unit)
+		return nil
+	}
+
+	path := systemdUnitPath(def.Name)
+	if err := os.WriteFile(path, []byte(unit), 0644); err != nil {
+		if os.IsPermission(err) {
+			return fmt.Errorf("writing %s needs root: run with sudo, or use --print", path)
+		}
+		return fmt.Errorf("failed to write unit: %w", err)
+	}
+	if err := runCommand("systemctl", "daemon-reload"); err != nil {
+		return err
+	}
+	if err := runCommand("systemctl", "enable", "--now", def.Name); err != nil {
+		return err
+	}
+
+	ui.Infof("✅ Installed %s and started the %s service\n", path, def.Name)
+	ui.Infof("   Status: systemctl status %s\n", def.Name)
+	ui.Infof("   Logs:   journalctl -u %s -f\n", def.Name)
+	return nil
+}
+
+func uninstallSystemd(name string) error {
+	path := systemdUnitPath(name)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("service %s is not installed (no %s)", name, path)
+	}
+	if err := runCommand("systemctl", "disable", "--now", name); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove unit: %w", err)
+	}
+	if err := runCommand("systemctl", "daemon-reload"); err != nil {
+		return err
+	}
+	ui.Infof("🗑️  Removed the %s service\n", name)
+	return nil
+}
+
+// launchdLabel returns the launchd label of a service
+func launchdLabel(name string) string {
+	return "ai.not7." + name
+}
+
+// launchdPlistPath returns where the launchd job of a service is installed:
+// a LaunchDaemon for root, otherwise a LaunchAgent of the current user
+func launchdPlistPath(name string) (string, error) {
+	if os.Geteuid() == 0 {
+		return filepath.Join("/Library/LaunchDaemons", launchdLabel(name)+".plist"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", launchdLabel(name)+".plist"), nil
+}
+
+// launchdPlist renders the launchd job of def. UserName only applies to
+// LaunchDaemons.
+func launchdPlist(def *serviceDefinition, daemon bool) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	b.WriteString(`<plist version="1.0">` + "\n<dict>\n")
+	plistString(&b, "Label", launchdLabel(def.Name))
+	b.WriteString("  <key>ProgramArguments</key>\n  <array>\n")
+	fmt.Fprintf(&b, "    <string>%s</string>\n    <string>serve</string>\n", xmlEscape(def.Executable))
+	b.WriteString("  </array>\n")
+	plistString(&b, "WorkingDirectory", def.WorkDir)
+	if daemon && def.User != "" && def.User != "root" {
+		plistString(&b, "UserName", def.User)
+	}
+	b.WriteString("  <key>EnvironmentVariables</key>\n  <dict>\n")
+	for _, pair := range def.Env {
+		key, value, _ := strings.Cut(pair, "=")
+		fmt.Fprintf(&b, "    <key>%s</key>\n    <string>%s</string>\n", xmlEscape(key), xmlEscape(value))
+	}
+	b.WriteString("  </dict>\n")
+	b.WriteString("  <key>RunAtLoad</key>\n  <true/>\n")
+	b.WriteString("  <key>KeepAlive</key>\n  <dict>\n    <key>SuccessfulExit</key>\n    <false/>\n  </dict>\n")
+	plistString(&b, "StandardOutPath", filepath.Join(def.LogDir, "server.log"))
+	plistString(&b, "StandardErrorPath", filepath.Join(def.LogDir, "server.log"))
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+func plistString(b *strings.Builder, key, value string) {
+	fmt.Fprintf(b, "  <key>%s</key>\n  <string>%s</string>\n", key, xmlEscape(value))
+}
+
+func xmlEscape(value string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
+
+func installLaunchd(def *serviceDefinition) error {
+	path, err := launchdPlistPath(def.Name)
+	if err != nil {
+		return err
+	}
+	plist := launchdPlist(def, os.Geteuid() == 0)
+	if servicePrint {
+		fmt.Print(plist)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.MkdirAll(def.LogDir, 0755); err != nil {
+		return fmt.Errorf("failed to create logs directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(plist), 0644); err != nil {
+		return fmt.Errorf("failed to write job: %w", err)
+	}
+	if err := runCommand("launchctl", "load", "-w", path); err != nil {
+		return err
+	}
+
+	ui.Infof("✅ Installed %s and started the %s service\n", path, def.Name)
+	ui.Infof("   Status: launchctl list %s\n", launchdLabel(def.Name))
+	ui.Infof("   Logs:   %s\n", filepath.Join(def.LogDir, "server.log"))
+	return nil
+}
+
+func uninstallLaunchd(name string) error {
+	path, err := launchdPlistPath(name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("service %s is not installed (no %s)", name, path)
+	}
+	if err := runCommand("launchctl", "unload", "-w", path); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove job: %w", err)
+	}
+	ui.Infof("🗑️  Removed the %s service\n", name)
+	return nil
+}
+
+// runCommand runs a service manager command, failing with its output
+func runCommand(name string, args ...string) error {
+	output, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w\n%s", name, strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
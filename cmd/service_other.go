@@ -0,0 +1,22 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+)
+
+// runAsService runs serve until ctx is done; only Windows services talk to
+// their service manager
+func runAsService(ctx context.Context, name string, serve func(context.Context) error) error {
+	return serve(ctx)
+}
+
+func installWindowsService(def *serviceDefinition) error {
+	return fmt.Errorf("Windows services can only be installed on Windows")
+}
+
+func uninstallWindowsService(name string) error {
+	return fmt.Errorf("Windows services can only be removed on Windows")
+}
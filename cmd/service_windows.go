@@ -0,0 +1,141 @@
+//go:build windows
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/not7/core/internal/ui"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// windowsServiceArgs returns the arguments the service manager starts the
+// executable with. Services start in the system directory with the system
+// environment, so everything is passed to the hidden service run command.
+func windowsServiceArgs(def *serviceDefinition) []string {
+	args := []string{"service", "run", "--name", def.Name, "--workdir", def.WorkDir, "--config", def.ConfigPath}
+	for _, pair := range def.Env[1:] { // NOT7_CONFIG is passed as --config
+		args = append(args, "--env", pair)
+	}
+	return args
+}
+
+func installWindowsService(def *serviceDefinition) error {
+	args := windowsServiceArgs(def)
+	if servicePrint {
+		fmt.Printf("Service:    %s\nExecutable: %s\nArguments:  %s\nStart:      automatic, as LocalSystem\n", def.Name, def.Executable, strings.Join(args, " "))
+		return nil
+	}
+	if serviceUser != "" {
+		return fmt.Errorf("--user is not supported on Windows; change the service's account in services.msc")
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service manager (run as administrator): %w", err)
+	}
+	defer m.Disconnect()
+
+	if s, err := m.OpenService(def.Name); err == nil {
+		s.Close()
+		return fmt.Errorf("service %s is already installed; remove it with not7 service uninstall", def.Name)
+	}
+	s, err := m.CreateService(def.Name, def.Executable, mgr.Config{
+		DisplayName: "NOT7 agent server",
+		Description: "Runs NOT7 agents over HTTP",
+		StartType:   mgr.StartAutomatic,
+	}, args...)
+	if err != nil {
+		return fmt.Errorf("failed to create service: %w", err)
+	}
+	defer s.Close()
+
+	if err := s.SetRecoveryActions([]mgr.RecoveryAction{{Type: mgr.ServiceRestart, Delay: 5 * time.Second}}, 86400); err != nil {
+		ui.Printf("⚠️  Failed to set the service to restart on failure: %v\n", err)
+	}
+	if err := s.Start(); err != nil {
+		return fmt.Errorf("service installed but failed to start: %w", err)
+	}
+
+	ui.Infof("✅ Installed and started the %s service\n", def.Name)
+	ui.Infof("   Status: sc query %s\n", def.Name)
+	return nil
+}
+
+func uninstallWindowsService(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service manager (run as administrator): %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed", name)
+	}
+	defer s.Close()
+
+	if status, err := s.Query(); err == nil && status.State != svc.Stopped {
+		if _, err := s.Control(svc.Stop); err != nil {
+			return fmt.Errorf("failed to stop service: %w", err)
+		}
+	}
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("failed to remove service: %w", err)
+	}
+	ui.Infof("🗑️  Removed the %s service\n", name)
+	return nil
+}
+
+// runAsService runs serve under the service manager when started by it,
+// stopping it when the service is stopped; otherwise until ctx is done
+func runAsService(ctx context.Context, name string, serve func(context.Context) error) error {
+	isService, err := svc.IsWindowsService()
+	if err != nil || !isService {
+		return serve(ctx)
+	}
+	handler := &windowsService{ctx: ctx, serve: serve}
+	if err := svc.Run(name, handler); err != nil {
+		return err
+	}
+	return handler.err
+}
+
+// windowsService answers the service manager while the server runs
+type windowsService struct {
+	ctx   context.Context
+	serve func(context.Context) error
+	err   error
+}
+
+func (s *windowsService) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- s.serve(ctx) }()
+
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case s.err = <-done:
+			if s.err != nil {
+				return false, 1
+			}
+			return false, 0
+		case request := <-requests:
+			switch request.Cmd {
+			case svc.Interrogate:
+				status <- request.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				cancel()
+				s.err = <-done
+				return false, 0
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/not7/core/executor"
+	"github.com/not7/core/internal/cli"
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/llm"
+	"github.com/not7/core/spec"
+	"github.com/spf13/cobra"
+)
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate [execution-id] --model <model>",
+	Short: "Re-price a past execution under other models",
+	Long: `Recompute what a past execution would have cost under other models, from
+the token counts recorded in its trace. Nothing is run.
+
+Each --model adds a column; --input-cost and --output-cost (USD per 1K
+tokens) override the built-in pricing of a single model. Only llm and react
+nodes are re-priced. A cheaper model may also answer in fewer or more tokens,
+so treat the result as a guide.
+
+With an execution ID the trace is fetched from the server; --file reads a
+trace.json instead.
+
+Example:
+  not7 simulate exec-1792122521836100341 --model gpt-4o-mini --model gpt-4o
+  not7 simulate --file trace.json --model my-model --input-cost 0.0002 --output-cost 0.0008`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeExecutionIDs,
+	RunE:              runSimulate,
+}
+
+func init() {
+	rootCmd.AddCommand(simulateCmd)
+	simulateCmd.Flags().StringSlice("model", nil, "Model to price the execution under (repeatable)")
+	simulateCmd.Flags().Float64("input-cost", 0, "USD per 1K prompt tokens, overriding the model's pricing")
+	simulateCmd.Flags().Float64("output-cost", 0, "USD per 1K completion tokens, overriding the model's pricing")
+	simulateCmd.Flags().StringP("file", "f", "", "Local trace JSON file to simulate")
+	simulateCmd.Flags().Bool("json", false, "Print the simulation as JSON")
+	simulateCmd.MarkFlagRequired("model")
+}
+
+func runSimulate(cmd *cobra.Command, args []string) error {
+	modelNames, _ := cmd.Flags().GetStringSlice("model")
+	filePath, _ := cmd.Flags().GetString("file")
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	models := make([]executor.SimulatedModel, len(modelNames))
+	for i, name := range modelNames {
+		models[i] = executor.SimulatedModel{Model: name, Pricing: llm.PricingFor(name)}
+	}
+
+	if cmd.Flags().Changed("input-cost") || cmd.Flags().Changed("output-cost") {
+		if len(models) != 1 {
+			return fmt.Errorf("--input-cost and --output-cost need exactly one --model")
+		}
+		if cmd.Flags().Changed("input-cost") {
+			models[0].Pricing.InputPer1K, _ = cmd.Flags().GetFloat64("input-cost")
+		}
+		if cmd.Flags().Changed("output-cost") {
+			models[0].Pricing.OutputPer1K, _ = cmd.Flags().GetFloat64("output-cost")
+		}
+		if models[0].Pricing.InputPer1K < 0 || models[0].Pricing.OutputPer1K < 0 {
+			return fmt.Errorf("--input-cost and --output-cost must not be negative")
+		}
+	}
+
+	var trace *spec.AgentSpec
+	switch {
+	case len(args) == 1 && filePath != "":
+		return fmt.Errorf("--file cannot be combined with an execution ID")
+	case len(args) == 1:
+		apiClient, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+		if trace, err = apiClient.GetTrace(cmd.Context(), args[0]); err != nil {
+			return fmt.Errorf("failed to get trace: %w", err)
+		}
+	case filePath != "":
+		var err error
+		if trace, err = readTraceFile(filePath); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("an execution ID or --file is required")
+	}
+
+	if trace.Metadata == nil || len(trace.Metadata.NodeResults) == 0 {
+		return fmt.Errorf("trace has no node results to simulate")
+	}
+
+	simulation := executor.SimulateCost(trace, models)
+
+	if asJSON {
+		data, err := json.MarshalIndent(simulation, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode simulation: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	ui.Infof("💰 Cost simulation: %s\n\n", trace.Goal)
+	cli.PrintCostSimulation(simulation)
+	return nil
+}
@@ -3,16 +3,17 @@ package cmd
 import (
 	"fmt"
 
-	"github.com/not7/core/client"
+	"github.com/not7/core/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 var statusCmd = &cobra.Command{
-	Use:   "status <execution-id>",
-	Short: "Check execution status",
-	Long:  `Check the status of a running or completed agent execution`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runStatus,
+	Use:               "status <execution-id>",
+	Short:             "Check execution status",
+	Long:              `Check the status of a running or completed agent execution`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeExecutionIDs,
+	RunE:              runStatus,
 }
 
 func init() {
@@ -22,24 +23,27 @@ func init() {
 func runStatus(cmd *cobra.Command, args []string) error {
 	execID := args[0]
 
-	apiClient := client.NewClient("")
+	apiClient, err := newAPIClient()
+	if err != nil {
+		return err
+	}
 
-	if err := apiClient.CheckHealth(); err != nil {
+	if err := apiClient.CheckHealth(cmd.Context()); err != nil {
 		return fmt.Errorf("server not running")
 	}
 
-	status, err := apiClient.GetExecutionStatus(execID)
+	status, err := apiClient.GetStatus(cmd.Context(), execID)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("Execution: %s\n", execID)
-	fmt.Printf("Status: %s\n", status["status"])
-	fmt.Printf("Goal: %s\n", status["goal"])
+	ui.Printf("Execution: %s\n", execID)
+	ui.Printf("Status: %s\n", status.Status)
+	ui.Printf("Goal: %s\n", status.Goal)
 
-	if progress, ok := status["progress"].(map[string]interface{}); ok {
-		fmt.Printf("Progress: %v/%v nodes\n",
-			progress["completed_nodes"], progress["total_nodes"])
+	if status.Progress != nil {
+		ui.Printf("Progress: %d/%d nodes\n",
+			status.Progress.CompletedNodes, status.Progress.TotalNodes)
 	}
 
 	return nil
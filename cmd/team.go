@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/not7/core/client"
+	"github.com/not7/core/config"
+	"github.com/not7/core/internal/cli"
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/team"
+	"github.com/spf13/cobra"
+)
+
+var (
+	teamInput string
+	teamTrace string
+)
+
+var teamCmd = &cobra.Command{
+	Use:   "team <team.json>",
+	Short: "Run agents as a team of cooperating roles",
+	Long: `Run several agent specs as roles (e.g. planner, researcher, critic) that
+exchange messages through a shared mailbox. The start role receives --input
+(or the team's goal); each reply goes to the roles named on its first line
+("TO: critic") or to the role's next, until a role replies "TO: end".
+
+Every turn is an ordinary execution on the server. The whole conversation,
+with the execution behind each message, is written to a combined trace
+(<team>.trace.json unless --trace is given).
+
+Example:
+  not7 team examples/team/team.json --input "EV battery recycling"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTeam,
+}
+
+func init() {
+	teamCmd.Flags().StringVar(&teamInput, "input", "", "First message to the start role (default: the team's goal)")
+	teamCmd.Flags().StringVar(&teamTrace, "trace", "", "File to write the combined trace to")
+	rootCmd.AddCommand(teamCmd)
+}
+
+func runTeam(cmd *cobra.Command, args []string) error {
+	teamFile := args[0]
+
+	var promptDirs []string
+	if cfg, err := config.ReadConfig(configFilePath()); err == nil {
+		promptDirs = append(promptDirs, cfg.Server.PromptsDir)
+	}
+	t, err := team.Load(teamFile, promptDirs...)
+	if err != nil {
+		return err
+	}
+
+	apiClient, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	if err := apiClient.CheckHealth(cmd.Context()); err != nil {
+		return fmt.Errorf("server not running. Start server first:\n  Terminal 1: ./not7 serve\n  Terminal 2: ./not7 team team.json")
+	}
+
+	ui.Infof("👥 Team: %s (%d roles)\n\n", t.Goal, len(t.Roles))
+
+	trace, runErr := team.Run(cmd.Context(), t, teamInput, teamRunner(apiClient), cli.PrintTeamMessage)
+
+	traceFile := teamTrace
+	if traceFile == "" {
+		traceFile = strings.TrimSuffix(filepath.Base(teamFile), filepath.Ext(teamFile)) + ".trace.json"
+	}
+	if err := writeTeamTrace(trace, traceFile); err != nil {
+		return err
+	}
+
+	cli.PrintTeamResult(trace)
+	ui.Infof("\n💾 Trace saved to: %s\n", traceFile)
+	return runErr
+}
+
+// teamRunner runs each turn as a synchronous execution through apiClient
+func teamRunner(apiClient *client.NOT7Client) team.RunFunc {
+	return func(ctx context.Context, role *team.Role, input string) (*team.Reply, error) {
+		agentJSON, err := json.Marshal(role.Agent())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode spec: %w", err)
+		}
+
+		result, err := apiClient.RunAgent(ctx, agentJSON, client.RunOptions{Input: input})
+		if err != nil {
+			return nil, err
+		}
+		if result.Error != "" {
+			return nil, fmt.Errorf("execution %s failed: %s", result.ID, result.Error)
+		}
+
+		reply := &team.Reply{ExecutionID: result.ID, Output: result.Output, Cost: result.TotalCost}
+		if result.Metadata != nil {
+			reply.Tokens = result.Metadata.PromptTokens + result.Metadata.CompletionTokens
+		}
+		return reply, nil
+	}
+}
+
+func writeTeamTrace(trace *team.Trace, path string) error {
+	data, err := json.MarshalIndent(trace, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode trace: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write trace: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/not7/core/config"
+	"github.com/not7/core/internal/cli"
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/tools"
+	"github.com/not7/core/tools/arcade"
+	"github.com/not7/core/tools/builtin"
+	"github.com/spf13/cobra"
+)
+
+var toolsCmd = &cobra.Command{
+	Use:   "tools",
+	Short: "Inspect and test tools",
+	Long:  `List the tools available to agents and run them outside an agent`,
+}
+
+var toolsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available tools",
+	Long: `Show available tools with descriptions and parameters.
+
+Without --provider, every configured provider is listed. Arcade toolkits
+other than Gmail are selected with arcade-{toolkit} (e.g., arcade-slack).`,
+	Args: cobra.NoArgs,
+	RunE: runToolsList,
+}
+
+var toolsRunCmd = &cobra.Command{
+	Use:   "run <name>",
+	Short: "Run a single tool",
+	Long: `Execute a tool directly, for testing it outside an agent.
+
+Arguments are passed as --arg key=value. Values that parse as JSON
+(numbers, booleans, arrays, objects) are sent as such, anything else
+is sent as a string.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runToolsRun,
+}
+
+func init() {
+	rootCmd.AddCommand(toolsCmd)
+	toolsCmd.AddCommand(toolsListCmd)
+	toolsCmd.AddCommand(toolsRunCmd)
+
+	toolsListCmd.Flags().String("provider", "", "Tool provider: builtin, arcade, arcade-{toolkit} or mcp")
+	toolsListCmd.Flags().Bool("json", false, "Output tool definitions as JSON")
+
+	toolsRunCmd.Flags().String("provider", "builtin", "Tool provider: builtin, arcade, arcade-{toolkit} or mcp")
+	toolsRunCmd.Flags().StringArray("arg", nil, "Tool argument as key=value (repeatable)")
+}
+
+func runToolsList(cmd *cobra.Command, args []string) error {
+	providerName, _ := cmd.Flags().GetString("provider")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	cfg, err := loadToolsConfig()
+	if err != nil {
+		return err
+	}
+
+	// Default to every provider that has credentials configured
+	providerNames := []string{providerName}
+	if providerName == "" {
+		providerNames = nil
+		if cfg.Builtin.SerpAPIKey != "" {
+			providerNames = append(providerNames, "builtin")
+		}
+		if cfg.Arcade.APIKey != "" && cfg.Arcade.UserID != "" {
+			providerNames = append(providerNames, "arcade")
+		}
+		if len(providerNames) == 0 {
+			return fmt.Errorf("no tool providers configured (set SERP_API_KEY or ARCADE_API_KEY/ARCADE_USER_ID in not7.conf)")
+		}
+	}
+
+	var defs []tools.ToolDefinition
+	for _, name := range providerNames {
+		provider, err := newToolProvider(cfg, name)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Second)
+		providerDefs, err := provider.ListTools(ctx)
+		cancel()
+		provider.Close()
+		if err != nil {
+			return fmt.Errorf("failed to list %s tools: %w", name, err)
+		}
+
+		defs = append(defs, providerDefs...)
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(defs, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode tools: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	cli.PrintToolList(defs)
+	return nil
+}
+
+func runToolsRun(cmd *cobra.Command, args []string) error {
+	toolName := args[0]
+	providerName, _ := cmd.Flags().GetString("provider")
+	rawArgs, _ := cmd.Flags().GetStringArray("arg")
+
+	arguments, err := parseToolArgs(rawArgs)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadToolsConfig()
+	if err != nil {
+		return err
+	}
+
+	provider, err := newToolProvider(cfg, providerName)
+	if err != nil {
+		return err
+	}
+
+	// Arcade tools may need an OAuth grant before they can run
+	if arcadeProvider, ok := provider.(*arcade.Provider); ok {
+		if err := arcadeProvider.CheckAndHandleAuthorization(cmd.Context()); err != nil {
+			return fmt.Errorf("arcade authorization failed: %w", err)
+		}
+	}
+
+	// Registering through the manager resolves tool names and
+	// reports unknown tools the same way agents see them
+	toolMgr := tools.NewManager("")
+	if err := toolMgr.RegisterProvider(provider); err != nil {
+		return fmt.Errorf("failed to register %s provider: %w", providerName, err)
+	}
+	defer toolMgr.Close()
+
+	ui.Infof("🔧 Running %s (%s)\n\n", toolName, providerName)
+
+	start := time.Now()
+	result, err := toolMgr.ExecuteTool(cmd.Context(), toolName, arguments)
+	if err != nil {
+		return err
+	}
+
+	cli.PrintToolResult(result, time.Since(start))
+
+	if !result.Success {
+		return fmt.Errorf("tool %s failed", toolName)
+	}
+	return nil
+}
+
+// loadToolsConfig loads not7.conf for tool provider credentials
+func loadToolsConfig() (*config.Config, error) {
+	configFile := configFilePath()
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config from %s: %w", configFile, err)
+	}
+	return cfg, nil
+}
+
+// newToolProvider creates and initializes a tool provider by name
+func newToolProvider(cfg *config.Config, name string) (tools.ToolProvider, error) {
+	if name == "builtin" {
+		if cfg.Builtin.SerpAPIKey == "" {
+			return nil, fmt.Errorf("builtin provider requires SERP_API_KEY in not7.conf")
+		}
+
+		provider := builtin.NewProvider(cfg.Builtin.SerpAPIKey)
+		if err := provider.Initialize(map[string]string{"serp_api_key": cfg.Builtin.SerpAPIKey}); err != nil {
+			return nil, fmt.Errorf("failed to initialize builtin provider: %w", err)
+		}
+		return provider, nil
+	}
+
+	if toolkit, ok := arcade.ToolkitFromProvider(name); ok {
+		if cfg.Arcade.APIKey == "" {
+			return nil, fmt.Errorf("ARCADE_API_KEY not configured in not7.conf")
+		}
+		if cfg.Arcade.UserID == "" {
+			return nil, fmt.Errorf("ARCADE_USER_ID not configured in not7.conf")
+		}
+
+		provider := arcade.NewProvider(cfg.Arcade.APIKey, cfg.Arcade.UserID, toolkit)
+		if err := provider.Initialize(map[string]string{
+			"arcade_api_key": cfg.Arcade.APIKey,
+			"arcade_user_id": cfg.Arcade.UserID,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to initialize arcade provider: %w", err)
+		}
+		return provider, nil
+	}
+
+	if name == "mcp" {
+		return nil, fmt.Errorf("mcp tool provider is not supported yet")
+	}
+
+	return nil, fmt.Errorf("unsupported tool provider: %s", name)
+}
+
+// parseToolArgs converts key=value pairs into tool arguments
+func parseToolArgs(rawArgs []string) (map[string]interface{}, error) {
+	arguments := make(map[string]interface{}, len(rawArgs))
+	for _, raw := range rawArgs {
+		key, value, found := strings.Cut(raw, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid --arg %q (expected key=value)", raw)
+		}
+
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(value), &parsed); err == nil {
+			arguments[key] = parsed
+		} else {
+			arguments[key] = value
+		}
+	}
+	return arguments, nil
+}
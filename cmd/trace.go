@@ -14,22 +14,55 @@ import (
 )
 
 var traceCmd = &cobra.Command{
-	Use:   "trace [agent-id]",
+	Use:   "trace [execution-id]",
 	Short: "View detailed ReAct execution trace",
-	Long:  `Display the chain of thought and tool calls from the last execution of an agent`,
-	Args:  cobra.MaximumNArgs(1),
-	RunE:  runTrace,
+	Long: `Display the chain of thought and tool calls of an execution.
+
+With an execution ID the trace is fetched from the server. Without one,
+the most recent *-trace.json in ./logs is shown, or the file given by --file.
+
+With --interactive the trace is shown one node or ReAct iteration at a
+time. Arrow keys move between steps (left/right) and nodes (up/down), e
+expands tool calls and long texts, d shows how the answer changed since
+the previous iteration, and q quits.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeExecutionIDs,
+	RunE:              runTrace,
 }
 
 func init() {
 	rootCmd.AddCommand(traceCmd)
-	traceCmd.Flags().StringP("file", "f", "", "Specific agent JSON file to view trace for")
-	traceCmd.Flags().BoolP("full", "F", false, "Show full thoughts (not truncated)")
+	traceCmd.Flags().StringP("file", "f", "", "Local trace JSON file to view")
+	traceCmd.Flags().BoolP("full", "F", false, "Show full thoughts (not truncated) and the logs of every node")
+	traceCmd.Flags().BoolP("interactive", "i", false, "Step through nodes and ReAct iterations with the keyboard")
 }
 
 func runTrace(cmd *cobra.Command, args []string) error {
 	filePath, _ := cmd.Flags().GetString("file")
 	showFull, _ := cmd.Flags().GetBool("full")
+	interactive, _ := cmd.Flags().GetBool("interactive")
+
+	if len(args) == 1 {
+		if filePath != "" {
+			return fmt.Errorf("--file cannot be combined with an execution ID")
+		}
+
+		apiClient, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+
+		trace, err := apiClient.GetTrace(cmd.Context(), args[0])
+		if err != nil {
+			return fmt.Errorf("failed to get trace: %w", err)
+		}
+
+		return displayTrace(trace, showFull, interactive)
+	}
+
+	if filePath != "" {
+		return displayTraceFile(filePath, showFull, interactive)
+	}
 
 	// Find most recent log file
 	logsDir := "./logs"
@@ -57,24 +90,37 @@ func runTrace(cmd *cobra.Command, args []string) error {
 		return infoI.ModTime().After(infoJ.ModTime())
 	})
 
-	traceFile := jsonFiles[0]
-	if filePath != "" {
-		traceFile = filePath
+	return displayTraceFile(jsonFiles[0], showFull, interactive)
+}
+
+// displayTraceFile renders a trace stored on the local filesystem
+func displayTraceFile(traceFile string, showFull, interactive bool) error {
+	agentSpec, err := readTraceFile(traceFile)
+	if err != nil {
+		return err
+	}
+	return displayTrace(agentSpec, showFull, interactive)
+}
+
+// displayTrace prints a trace, or steps through it with --interactive
+func displayTrace(agentSpec *spec.AgentSpec, showFull, interactive bool) error {
+	if interactive {
+		return cli.StepTrace(agentSpec, showFull)
 	}
+	cli.DisplayTrace(agentSpec, showFull)
+	return nil
+}
 
-	// Read trace file
+// readTraceFile parses a trace.json: the agent spec with its execution metadata
+func readTraceFile(traceFile string) (*spec.AgentSpec, error) {
 	data, err := os.ReadFile(traceFile)
 	if err != nil {
-		return fmt.Errorf("failed to read trace file: %w", err)
+		return nil, fmt.Errorf("failed to read trace file: %w", err)
 	}
 
 	var agentSpec spec.AgentSpec
 	if err := json.Unmarshal(data, &agentSpec); err != nil {
-		return fmt.Errorf("failed to parse trace: %w", err)
+		return nil, fmt.Errorf("failed to parse trace: %w", err)
 	}
-
-	// Display trace
-	cli.DisplayTrace(&agentSpec, showFull)
-
-	return nil
+	return &agentSpec, nil
 }
@@ -3,6 +3,8 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/not7/core/internal/cli"
+	"github.com/not7/core/internal/ui"
 	"github.com/not7/core/spec"
 	"github.com/spf13/cobra"
 )
@@ -10,28 +12,51 @@ import (
 var validateCmd = &cobra.Command{
 	Use:   "validate <agent.json>",
 	Short: "Validate agent specification",
-	Long:  `Validate an agent JSON specification file (offline validation)`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runValidate,
+	Long: `Validate an agent JSON specification file (offline validation).
+
+--lint also warns about constructs that are valid but probably mistakes:
+blank prompts, tools enabled without a tool provider, unusual temperatures
+and a missing route to end. Warnings never fail validation.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runValidate,
 }
 
+var validateLint bool
+
 func init() {
+	validateCmd.Flags().BoolVar(&validateLint, "lint", false, "Also warn about likely mistakes in the spec")
 	rootCmd.AddCommand(validateCmd)
 }
 
 func runValidate(cmd *cobra.Command, args []string) error {
 	specFile := args[0]
 
-	fmt.Printf("Validating: %s\n", specFile)
+	ui.Infof("Validating: %s\n", specFile)
 
 	agentSpec, err := spec.LoadSpec(specFile)
 	if err != nil {
 		return fmt.Errorf("invalid: %w", err)
 	}
 
-	fmt.Println("✅ Valid!")
-	fmt.Printf("   Goal: %s\n", agentSpec.Goal)
-	fmt.Printf("   Nodes: %d\n", len(agentSpec.Nodes))
+	if err := agentSpec.ResolvePrompts(promptDirs(specFile)...); err != nil {
+		return fmt.Errorf("invalid: %w", err)
+	}
+
+	// Render with the defaults so undefined ${name} references are caught
+	if err := agentSpec.Render(nil); err != nil {
+		return fmt.Errorf("invalid: %w", err)
+	}
+
+	// Errors already failed LoadSpec, so only warnings remain
+	issues := append(agentSpec.Warnings(), spec.CheckGraph(agentSpec)...)
+	if validateLint {
+		issues = append(issues, spec.Lint(agentSpec)...)
+	}
+	cli.PrintSpecIssues(issues)
+
+	ui.Println("✅ Valid!")
+	ui.Infof("   Goal: %s\n", agentSpec.Goal)
+	ui.Infof("   Nodes: %d\n", len(agentSpec.Nodes))
 
 	return nil
 }
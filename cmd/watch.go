@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/not7/core/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var watchCmd = &cobra.Command{
+	Use:               "watch <execution-id>",
+	Short:             "Follow a running execution",
+	Long:              `Live-render node progress and ReAct iterations of an execution until it completes`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeExecutionIDs,
+	RunE:              runWatch,
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	execID := args[0]
+
+	apiClient, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+
+	if err := apiClient.CheckHealth(cmd.Context()); err != nil {
+		return fmt.Errorf("server not running")
+	}
+
+	ui.Infof("👀 Watching: %s\n\n", execID)
+
+	return followExecution(cmd.Context(), apiClient, execID)
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"github.com/not7/core/config"
+	"github.com/not7/core/execution"
+	"github.com/not7/core/internal/cli"
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/notify"
+	"github.com/not7/core/queue"
+	"github.com/not7/core/reporting"
+	"github.com/not7/core/tracing"
+	"github.com/spf13/cobra"
+)
+
+var workerCount int
+
+var workerCmd = &cobra.Command{
+	Use:   "worker",
+	Short: "Run queued executions",
+	Long: `Take async executions off the Redis queue (QUEUE_BACKEND=redis) and run
+them, so agent workloads can scale past one server. Point the workers and the
+server at the same queue and executions directory, and set QUEUE_WORKERS=0
+on the server to keep it to accepting runs.
+
+On Ctrl+C or SIGTERM the worker stops taking executions and exits once the
+running ones finish.
+
+Examples:
+  not7 worker
+  not7 worker --workers 16`,
+	Args: cobra.NoArgs,
+	RunE: runWorker,
+}
+
+func init() {
+	workerCmd.Flags().IntVarP(&workerCount, "workers", "w", 0, "Executions to run at once (default: QUEUE_WORKERS)")
+	rootCmd.AddCommand(workerCmd)
+}
+
+func runWorker(cmd *cobra.Command, args []string) error {
+	configFile := configFilePath()
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config from %s: %w", configFile, err)
+	}
+	cli.PrintConfigIssues(cfg.Warnings())
+	applyLogSettings(cfg)
+
+	if cfg.Queue.Backend != queue.BackendRedis {
+		return fmt.Errorf("not7 worker needs a shared queue: set QUEUE_BACKEND=redis and QUEUE_URL in %s", configFile)
+	}
+	workers := cfg.Queue.Workers
+	if cmd.Flags().Changed("workers") {
+		workers = workerCount
+	}
+	if workers < 1 {
+		return fmt.Errorf("--workers must be at least 1")
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			ui.Printf("⚠️  Failed to flush traces: %v\n", err)
+		}
+	}()
+
+	if err := reporting.Setup(cfg.Reporting); err != nil {
+		return fmt.Errorf("failed to set up error reporting: %w", err)
+	}
+	if err := notify.Setup(cfg.Notify); err != nil {
+		return fmt.Errorf("failed to set up notifications: %w", err)
+	}
+
+	storage, err := execution.NewFileSystemStorage(cfg.Server.ExecutionsDir)
+	if err != nil {
+		return fmt.Errorf("failed to create storage: %w", err)
+	}
+	q, err := queue.Open(cfg.Queue)
+	if err != nil {
+		return fmt.Errorf("failed to open execution queue: %w", err)
+	}
+	defer q.Close()
+
+	mgr := execution.NewManager(storage, cfg.Server.LogDir, cfg)
+	mgr.SetQueue(q)
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGTERM)
+	defer stop()
+
+	ui.Infof("👷 Worker started: %s, %d workers\n", queue.Describe(cfg.Queue), workers)
+	ui.Infof("📁 Executions: %s\n", cfg.Server.ExecutionsDir)
+	ui.Infof("📁 Logs: %s\n", cfg.Server.LogDir)
+
+	mgr.Consume(ctx, workers)
+
+	ui.Infoln("👋 Worker stopped")
+	return nil
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -10,25 +12,114 @@ import (
 
 // Config represents the NOT7 configuration
 type Config struct {
-	OpenAI  OpenAIConfig
-	Server  ServerConfig
-	Builtin BuiltinConfig
-	Arcade  ArcadeConfig
+	OpenAI    OpenAIConfig
+	Anthropic LLMProviderConfig
+	Gemini    LLMProviderConfig
+	Ollama    LLMProviderConfig
+	Azure     LLMProviderConfig
+	Server    ServerConfig
+	Log       LogConfig
+	Tracing   TracingConfig
+	Reporting ReportingConfig
+	Notify    NotifyConfig
+	Builtin   BuiltinConfig
+	Arcade    ArcadeConfig
+	Memory    MemoryConfig
+	Queue     QueueConfig
+	Registry  RegistryConfig
+	Sinks     SinksConfig
+	Policy    PolicyConfig
+	Hooks     HooksConfig
+	Secrets   map[string]string // named secrets for {{secret.NAME}}, by upper-case name
+	Profiles  map[string]ProfileConfig
+	Clients   map[string]ClientConfig // API keys the server accepts, by lower-case client name
+
+	path       string  // file the config was read from
+	fromEnv    bool    // no file; read from the environment alone
+	structured bool    // YAML/TOML rather than KEY=value
+	warnings   []Issue // problems that did not stop parsing
 }
 
+// LLM provider names accepted by Config.LLMProvider
+const (
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+	ProviderGemini    = "gemini"
+	ProviderOllama    = "ollama"
+	ProviderAzure     = "azure"
+)
+
+// LLMProviders lists the supported LLM provider names
+var LLMProviders = []string{ProviderOpenAI, ProviderAnthropic, ProviderGemini, ProviderOllama, ProviderAzure}
+
 // OpenAIConfig holds OpenAI-specific configuration
 type OpenAIConfig struct {
 	APIKey             string
+	BaseURL            string
 	DefaultModel       string
 	DefaultTemperature float64
 	DefaultMaxTokens   int
 }
 
+// LLMProviderConfig holds connection settings for an LLM provider. For
+// Azure, BaseURL is the resource endpoint and Deployment names the model
+// deployment; APIVersion is only used by Azure.
+type LLMProviderConfig struct {
+	APIKey       string
+	BaseURL      string
+	DefaultModel string
+	APIVersion   string
+	Deployment   string
+}
+
 // ServerConfig holds server-specific configuration
 type ServerConfig struct {
-	Port          int
-	ExecutionsDir string
-	LogDir        string
+	Port             int
+	ExecutionsDir    string
+	LogDir           string
+	SpecsDir         string // deployed agent specs
+	PromptsDir       string // prompt files referenced by prompt_ref
+	CorporaDir       string // documents ingested for retrieve nodes
+	PIDFile          string // written by serve --daemon, read by not7 stop
+	CachedExecutions int    // executions kept in memory for repeated reads; 0 disables
+	ReloadSeconds    int    // how often changed <id>.json files in SpecsDir are redeployed; 0 disables
+}
+
+// LogConfig holds execution log settings
+type LogConfig struct {
+	Level  string // debug, info or error
+	Format string // text or json
+}
+
+// TracingConfig holds OpenTelemetry trace export settings. Tracing is off
+// unless Endpoint (or the standard OTEL_EXPORTER_OTLP_ENDPOINT variable) is set.
+type TracingConfig struct {
+	Endpoint    string  // OTLP/HTTP collector URL, e.g. http://localhost:4318
+	ServiceName string  // service.name resource attribute
+	SampleRatio float64 // fraction of executions traced (0-1)
+}
+
+// ReportingConfig holds error reporting settings. Panics, failed executions
+// and provider errors are sent to every configured destination.
+type ReportingConfig struct {
+	SentryDSN     string // Sentry project DSN
+	WebhookURL    string // receives each report as a JSON POST
+	WebhookSecret string // signs webhook deliveries; unsigned when empty
+}
+
+// NotifyConfig holds the channels execution outcomes are announced on. An
+// agent's spec can narrow the channels, outcomes and email recipients.
+type NotifyConfig struct {
+	On                string // comma-separated outcomes announced: success and/or failure
+	BaseURL           string // server URL the announcements link to
+	SlackWebhookURL   string // Slack incoming webhook
+	DiscordWebhookURL string // Discord channel webhook
+	EmailTo           string // comma-separated email recipients
+	SMTPHost          string
+	SMTPPort          int // 465 for implicit TLS; STARTTLS is used when offered
+	SMTPUsername      string
+	SMTPPassword      string
+	SMTPFrom          string
 }
 
 // BuiltinConfig holds built-in tool provider settings
@@ -42,31 +133,237 @@ type ArcadeConfig struct {
 	UserID string
 }
 
-var globalConfig *Config
+// MemoryConfig holds the long-term memory behind the Remember and Recall
+// tools. Memories are embedded with the OpenAI embeddings API.
+type MemoryConfig struct {
+	Dir            string // one <agent-id>.json file per agent
+	EmbeddingModel string
+}
+
+// QueueConfig holds the queue async executions wait in for a worker. With no
+// backend they run in the accepting server's process, as goroutines.
+type QueueConfig struct {
+	Backend string // "", memory or redis
+	URL     string // redis://[user:password@]host:port/db
+	Name    string // prefix of the backend's keys
+	Workers int    // executions the server runs from the queue at once (0 = none)
+
+	// LeaseSeconds is how long a worker holds a queued execution without
+	// renewing its lease before the execution is given to another worker
+	LeaseSeconds int
+}
 
-// LoadConfig loads configuration from a simple key-value file
+// RegistryConfig holds the remote spec registry that registry:// references
+// are fetched from
+type RegistryConfig struct {
+	URL      string // base URL; specs are at <URL>/<namespace>/<name>/<version>.json
+	APIKey   string // sent as a bearer token, for private registries
+	CacheDir string // fetched specs, by reference and by checksum
+}
+
+// SinksConfig holds where sink nodes append their records
+type SinksConfig struct {
+	Dir               string // CSV and JSONL files of file sinks
+	DatabaseURL       string // postgres:// URL or SQLite file of table sinks
+	GoogleCredentials string // service account key file of sheet sinks
+}
+
+// PolicyConfig holds the rules that constrain what agents may do. They are
+// checked when an execution is submitted and again while it runs. Lists are
+// comma-separated; model and tool patterns may use * wildcards, and a domain
+// also covers its subdomains. A name must match the allow list when one is
+// set, and must not match the deny list.
+type PolicyConfig struct {
+	AllowModels    string
+	DenyModels     string
+	AllowTools     string
+	DenyTools      string
+	AllowProviders string // LLM providers, e.g. openai or ollama
+	DenyProviders  string
+	AllowDomains   string // hosts WebFetch may fetch from
+	DenyDomains    string
+	MaxCost        float64 // USD an execution may spend on LLM calls; 0 for no limit
+}
+
+// HooksConfig holds the external commands run before and after every node.
+// Each reads the node's input, output and metadata as JSON on stdin, and
+// may replace the input or output, or fail the node by exiting non-zero.
+type HooksConfig struct {
+	BeforeNode     string // command line, split on spaces; empty for none
+	AfterNode      string
+	TimeoutSeconds int // how long a hook command may run
+}
+
+// ProfileConfig holds client connection settings for a named server profile
+type ProfileConfig struct {
+	ServerURL string
+	APIKey    string
+}
+
+// ClientConfig holds an API key the server accepts and the monthly quotas
+// of the executions started with it. A zero quota is unlimited.
+type ClientConfig struct {
+	APIKey            string
+	MonthlyExecutions int
+	MonthlyTokens     int     // prompt and completion tokens
+	MonthlyCost       float64 // USD
+}
+
+// LoadConfig loads configuration from a key-value, YAML or TOML file and
+// validates it for running agents. The result is passed explicitly to the
+// server, execution manager and executor; there is no global config.
 func LoadConfig(filepath string) (*Config, error) {
-	file, err := os.Open(filepath)
+	cfg, err := ReadConfig(filepath)
 	if err != nil {
+		return nil, err
+	}
+
+	// The server always needs OpenAI; warnings are left to the caller
+	var errs []string
+	for _, issue := range cfg.Validate(Requirements{LLMProviders: []string{ProviderOpenAI}}) {
+		if issue.Severity == SeverityError {
+			errs = append(errs, issue.String())
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid config: %s", strings.Join(errs, "; "))
+	}
+
+	return cfg, nil
+}
+
+// ReadConfig parses a configuration file without validating server-only
+// requirements. Client commands use it to read profiles on machines that
+// have no OpenAI key.
+//
+// The format is chosen by extension: .yaml/.yml and .toml use nested
+// sections, anything else the flat KEY=value format. Values may be secret
+// references such as env://NAME or vault://path#key; see package secrets.
+//
+// Environment variables named like flat keys override the file. When the
+// default not7.conf does not exist but such variables are set, the config
+// is read from the environment alone.
+func ReadConfig(filepath string) (*Config, error) {
+	cfg := defaultConfig()
+
+	data, err := os.ReadFile(filepath)
+	switch {
+	case err == nil:
+		cfg.path = filepath
+		cfg.structured = isStructuredConfig(filepath)
+		if cfg.structured {
+			err = parseStructuredConfig(cfg, filepath, data)
+		} else {
+			err = parseFlatConfig(cfg, data)
+		}
+		if err != nil {
+			return nil, err
+		}
+	case errors.Is(err, os.ErrNotExist) && filepath == DefaultPaths[0] && envConfigured():
+		cfg.fromEnv = true
+	default:
 		return nil, fmt.Errorf("failed to open config file: %w", err)
 	}
-	defer file.Close()
 
-	cfg := &Config{
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+	if err := resolveSecrets(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Source describes where the config was read from: its file, or
+// "environment" when no file exists
+func (c *Config) Source() string {
+	if c.fromEnv {
+		return "environment"
+	}
+	return c.path
+}
+
+// Default returns the configuration of an empty file: provider base URLs,
+// default models and server settings, with no API keys. Programs that
+// embed NOT7 start from it and set the fields they need.
+func Default() *Config {
+	return defaultConfig()
+}
+
+// defaultConfig returns the configuration used for keys a file leaves out
+func defaultConfig() *Config {
+	return &Config{
 		// Set defaults
 		OpenAI: OpenAIConfig{
+			BaseURL:            "https://api.openai.com/v1",
 			DefaultModel:       "gpt-4",
 			DefaultTemperature: 0.7,
 			DefaultMaxTokens:   2000,
 		},
+		Anthropic: LLMProviderConfig{
+			BaseURL:      "https://api.anthropic.com/v1",
+			DefaultModel: "claude-3-5-sonnet-latest",
+		},
+		Gemini: LLMProviderConfig{
+			BaseURL:      "https://generativelanguage.googleapis.com/v1beta",
+			DefaultModel: "gemini-1.5-pro",
+		},
+		Ollama: LLMProviderConfig{
+			BaseURL:      "http://localhost:11434",
+			DefaultModel: "llama3",
+		},
+		Azure: LLMProviderConfig{
+			APIVersion: "2024-02-01",
+		},
 		Server: ServerConfig{
-			Port:          8080,
-			ExecutionsDir: "./executions",
-			LogDir:        "./logs",
+			Port:             8080,
+			ExecutionsDir:    "./executions",
+			LogDir:           "./logs",
+			SpecsDir:         "./specs",
+			PromptsDir:       "./prompts",
+			CorporaDir:       "./corpora",
+			PIDFile:          "./not7.pid",
+			CachedExecutions: 256,
+			ReloadSeconds:    5,
+		},
+		Log: LogConfig{Level: "info", Format: "text"},
+		Memory: MemoryConfig{
+			Dir:            "./memory",
+			EmbeddingModel: "text-embedding-3-small",
+		},
+		Tracing: TracingConfig{
+			ServiceName: "not7",
+			SampleRatio: 1,
+		},
+		Queue: QueueConfig{
+			Name:         "not7",
+			Workers:      4,
+			LeaseSeconds: 30,
+		},
+		Notify: NotifyConfig{
+			On:       "failure",
+			SMTPPort: 587,
 		},
+		Registry: RegistryConfig{
+			CacheDir: "./registry",
+		},
+		Sinks: SinksConfig{
+			Dir: "./datasets",
+		},
+		Hooks: HooksConfig{
+			TimeoutSeconds: 10,
+		},
+		Secrets: map[string]string{},
+		Profiles: map[string]ProfileConfig{
+			"local": {ServerURL: "http://localhost:8080"},
+		},
+		Clients: map[string]ClientConfig{},
 	}
+}
 
-	scanner := bufio.NewScanner(file)
+// parseFlatConfig parses the KEY=value format into cfg
+func parseFlatConfig(cfg *Config, data []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
 	lineNum := 0
 
 	for scanner.Scan() {
@@ -81,29 +378,27 @@ func LoadConfig(filepath string) (*Config, error) {
 		// Parse KEY=value (standard .env format)
 		parts := strings.SplitN(line, "=", 2)
 		if len(parts) != 2 {
-			return nil, fmt.Errorf("line %d: invalid format (expected: KEY=value)", lineNum)
+			return fmt.Errorf("line %d: invalid format (expected: KEY=value)", lineNum)
 		}
 
 		key := strings.TrimSpace(parts[0])
 		value := strings.TrimSpace(parts[1])
 
-		// Set config values based on key
+		// Set config values based on key; unknown keys are only warnings
 		if err := setConfigValue(cfg, key, value); err != nil {
-			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			if errors.Is(err, errUnknownKey) {
+				cfg.warnings = append(cfg.warnings, unknownFlatKey(key, lineNum))
+				continue
+			}
+			return fmt.Errorf("line %d: %w", lineNum, err)
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading config file: %w", err)
-	}
-
-	// Validate required fields
-	if cfg.OpenAI.APIKey == "" {
-		return nil, fmt.Errorf("OpenAI.api_key is required in config")
+		return fmt.Errorf("error reading config file: %w", err)
 	}
 
-	globalConfig = cfg
-	return cfg, nil
+	return nil
 }
 
 // setConfigValue sets a configuration value based on key
@@ -112,6 +407,8 @@ func setConfigValue(cfg *Config, key, value string) error {
 	// OpenAI settings
 	case "OPENAI_API_KEY":
 		cfg.OpenAI.APIKey = value
+	case "OPENAI_BASE_URL":
+		cfg.OpenAI.BaseURL = value
 	case "OPENAI_DEFAULT_MODEL":
 		cfg.OpenAI.DefaultModel = value
 	case "OPENAI_DEFAULT_TEMPERATURE":
@@ -127,6 +424,40 @@ func setConfigValue(cfg *Config, key, value string) error {
 		}
 		cfg.OpenAI.DefaultMaxTokens = tokens
 
+	// Anthropic settings
+	case "ANTHROPIC_API_KEY":
+		cfg.Anthropic.APIKey = value
+	case "ANTHROPIC_BASE_URL":
+		cfg.Anthropic.BaseURL = value
+	case "ANTHROPIC_DEFAULT_MODEL":
+		cfg.Anthropic.DefaultModel = value
+
+	// Gemini settings
+	case "GEMINI_API_KEY":
+		cfg.Gemini.APIKey = value
+	case "GEMINI_BASE_URL":
+		cfg.Gemini.BaseURL = value
+	case "GEMINI_DEFAULT_MODEL":
+		cfg.Gemini.DefaultModel = value
+
+	// Ollama settings
+	case "OLLAMA_BASE_URL":
+		cfg.Ollama.BaseURL = value
+	case "OLLAMA_DEFAULT_MODEL":
+		cfg.Ollama.DefaultModel = value
+
+	// Azure OpenAI settings
+	case "AZURE_OPENAI_API_KEY":
+		cfg.Azure.APIKey = value
+	case "AZURE_OPENAI_ENDPOINT":
+		cfg.Azure.BaseURL = value
+	case "AZURE_OPENAI_API_VERSION":
+		cfg.Azure.APIVersion = value
+	case "AZURE_OPENAI_DEPLOYMENT":
+		cfg.Azure.Deployment = value
+	case "AZURE_OPENAI_DEFAULT_MODEL":
+		cfg.Azure.DefaultModel = value
+
 	// Server settings
 	case "SERVER_PORT":
 		port, err := strconv.Atoi(value)
@@ -138,6 +469,78 @@ func setConfigValue(cfg *Config, key, value string) error {
 		cfg.Server.ExecutionsDir = value
 	case "SERVER_LOG_DIR":
 		cfg.Server.LogDir = value
+	case "SERVER_SPECS_DIR":
+		cfg.Server.SpecsDir = value
+	case "SERVER_PROMPTS_DIR":
+		cfg.Server.PromptsDir = value
+	case "SERVER_CORPORA_DIR":
+		cfg.Server.CorporaDir = value
+	case "SERVER_PID_FILE":
+		cfg.Server.PIDFile = value
+	case "SERVER_CACHED_EXECUTIONS":
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid cached executions value: %s", value)
+		}
+		cfg.Server.CachedExecutions = size
+	case "SERVER_RELOAD_SECONDS":
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid reload seconds value: %s", value)
+		}
+		cfg.Server.ReloadSeconds = seconds
+
+	// Log settings
+	case "LOG_LEVEL":
+		cfg.Log.Level = value
+	case "LOG_FORMAT":
+		cfg.Log.Format = value
+
+	// Tracing settings
+	case "TRACING_OTLP_ENDPOINT":
+		cfg.Tracing.Endpoint = value
+	case "TRACING_SERVICE_NAME":
+		cfg.Tracing.ServiceName = value
+	case "TRACING_SAMPLE_RATIO":
+		ratio, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid sample ratio value: %s", value)
+		}
+		cfg.Tracing.SampleRatio = ratio
+
+	// Error reporting settings
+	case "SENTRY_DSN":
+		cfg.Reporting.SentryDSN = value
+	case "ERROR_WEBHOOK_URL":
+		cfg.Reporting.WebhookURL = value
+	case "ERROR_WEBHOOK_SECRET":
+		cfg.Reporting.WebhookSecret = value
+
+	// Notification settings
+	case "NOTIFY_ON":
+		cfg.Notify.On = value
+	case "NOTIFY_BASE_URL":
+		cfg.Notify.BaseURL = value
+	case "NOTIFY_SLACK_WEBHOOK_URL":
+		cfg.Notify.SlackWebhookURL = value
+	case "NOTIFY_DISCORD_WEBHOOK_URL":
+		cfg.Notify.DiscordWebhookURL = value
+	case "NOTIFY_EMAIL_TO":
+		cfg.Notify.EmailTo = value
+	case "NOTIFY_SMTP_HOST":
+		cfg.Notify.SMTPHost = value
+	case "NOTIFY_SMTP_PORT":
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid port value: %s", value)
+		}
+		cfg.Notify.SMTPPort = port
+	case "NOTIFY_SMTP_USERNAME":
+		cfg.Notify.SMTPUsername = value
+	case "NOTIFY_SMTP_PASSWORD":
+		cfg.Notify.SMTPPassword = value
+	case "NOTIFY_SMTP_FROM":
+		cfg.Notify.SMTPFrom = value
 
 	// Builtin tool settings
 	case "SERP_API_KEY":
@@ -149,17 +552,219 @@ func setConfigValue(cfg *Config, key, value string) error {
 	case "ARCADE_USER_ID":
 		cfg.Arcade.UserID = value
 
+	// Memory tool settings
+	case "MEMORY_DIR":
+		cfg.Memory.Dir = value
+	case "MEMORY_EMBEDDING_MODEL":
+		cfg.Memory.EmbeddingModel = value
+
+	// Queue settings
+	case "QUEUE_BACKEND":
+		cfg.Queue.Backend = value
+	case "QUEUE_URL":
+		cfg.Queue.URL = value
+	case "QUEUE_NAME":
+		cfg.Queue.Name = value
+	case "QUEUE_WORKERS":
+		workers, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid workers value: %s", value)
+		}
+		cfg.Queue.Workers = workers
+	case "QUEUE_LEASE_SECONDS":
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid lease value: %s", value)
+		}
+		cfg.Queue.LeaseSeconds = seconds
+
+	// Registry settings
+	case "REGISTRY_URL":
+		cfg.Registry.URL = value
+	case "REGISTRY_API_KEY":
+		cfg.Registry.APIKey = value
+	case "REGISTRY_CACHE_DIR":
+		cfg.Registry.CacheDir = value
+
+	// Sink settings
+	case "SINKS_DIR":
+		cfg.Sinks.Dir = value
+	case "SINKS_DATABASE_URL":
+		cfg.Sinks.DatabaseURL = value
+	case "SINKS_GOOGLE_CREDENTIALS":
+		cfg.Sinks.GoogleCredentials = value
+
+	// Policy settings
+	case "POLICY_ALLOW_MODELS":
+		cfg.Policy.AllowModels = value
+	case "POLICY_DENY_MODELS":
+		cfg.Policy.DenyModels = value
+	case "POLICY_ALLOW_TOOLS":
+		cfg.Policy.AllowTools = value
+	case "POLICY_DENY_TOOLS":
+		cfg.Policy.DenyTools = value
+	case "POLICY_ALLOW_PROVIDERS":
+		cfg.Policy.AllowProviders = value
+	case "POLICY_DENY_PROVIDERS":
+		cfg.Policy.DenyProviders = value
+	case "POLICY_ALLOW_DOMAINS":
+		cfg.Policy.AllowDomains = value
+	case "POLICY_DENY_DOMAINS":
+		cfg.Policy.DenyDomains = value
+	case "POLICY_MAX_COST":
+		cost, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid max cost value: %s", value)
+		}
+		cfg.Policy.MaxCost = cost
+
+	// Hook settings
+	case "HOOKS_BEFORE_NODE":
+		cfg.Hooks.BeforeNode = value
+	case "HOOKS_AFTER_NODE":
+		cfg.Hooks.AfterNode = value
+	case "HOOKS_TIMEOUT_SECONDS":
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid hook timeout value: %s", value)
+		}
+		cfg.Hooks.TimeoutSeconds = seconds
+
 	default:
-		return fmt.Errorf("unknown config key: %s", key)
+		if strings.HasPrefix(key, "PROFILE_") {
+			return setProfileValue(cfg, key, value)
+		}
+		if strings.HasPrefix(key, "CLIENT_") {
+			return setClientValue(cfg, key, value)
+		}
+		if name, ok := strings.CutPrefix(key, "SECRET_"); ok {
+			if name == "" {
+				return fmt.Errorf("secret name is missing in key: %s", key)
+			}
+			cfg.Secrets[strings.ToUpper(name)] = value
+			return nil
+		}
+		return fmt.Errorf("%w: %s", errUnknownKey, key)
+	}
+
+	return nil
+}
+
+// setProfileValue handles PROFILE_<NAME>_URL and PROFILE_<NAME>_API_KEY keys
+func setProfileValue(cfg *Config, key, value string) error {
+	name, field := profileKey(key)
+	if field == "" {
+		return fmt.Errorf("%w: %s", errUnknownKey, key)
+	}
+	if name == "" {
+		return fmt.Errorf("profile name is missing in key: %s", key)
+	}
+
+	name = strings.ToLower(name)
+	profile := cfg.Profiles[name]
+	if field == "url" {
+		profile.ServerURL = value
+	} else {
+		profile.APIKey = value
+	}
+	cfg.Profiles[name] = profile
+
+	return nil
+}
+
+// profileKey splits a PROFILE_<NAME>_URL or PROFILE_<NAME>_API_KEY key into
+// the profile name and its field, "url" or "api_key". The field is empty
+// for other keys.
+func profileKey(key string) (name, field string) {
+	rest := strings.TrimPrefix(key, "PROFILE_")
+	switch {
+	case strings.HasSuffix(rest, "_URL"):
+		return strings.TrimSuffix(rest, "_URL"), "url"
+	case strings.HasSuffix(rest, "_API_KEY"):
+		return strings.TrimSuffix(rest, "_API_KEY"), "api_key"
+	}
+	return "", ""
+}
+
+// setClientValue handles CLIENT_<NAME>_API_KEY and the
+// CLIENT_<NAME>_MONTHLY_EXECUTIONS, _MONTHLY_TOKENS and _MONTHLY_COST keys
+func setClientValue(cfg *Config, key, value string) error {
+	name, field := clientKey(key)
+	if field == "" {
+		return fmt.Errorf("%w: %s", errUnknownKey, key)
+	}
+	if name == "" {
+		return fmt.Errorf("client name is missing in key: %s", key)
 	}
 
+	name = strings.ToLower(name)
+	client := cfg.Clients[name]
+	switch field {
+	case "api_key":
+		client.APIKey = value
+	case "monthly_executions":
+		executions, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid monthly executions value: %s", value)
+		}
+		client.MonthlyExecutions = executions
+	case "monthly_tokens":
+		tokens, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid monthly tokens value: %s", value)
+		}
+		client.MonthlyTokens = tokens
+	case "monthly_cost":
+		cost, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid monthly cost value: %s", value)
+		}
+		client.MonthlyCost = cost
+	}
+	cfg.Clients[name] = client
+
 	return nil
 }
 
-// Get returns the global configuration
-func Get() *Config {
-	if globalConfig == nil {
-		panic("config not loaded - call LoadConfig first")
+// clientKey splits a CLIENT_<NAME>_<FIELD> key into the client name and
+// its field, one of clientFields. The field is empty for other keys.
+func clientKey(key string) (name, field string) {
+	rest := strings.TrimPrefix(key, "CLIENT_")
+	for _, candidate := range clientFields {
+		if suffix := "_" + strings.ToUpper(candidate); strings.HasSuffix(rest, suffix) {
+			return strings.TrimSuffix(rest, suffix), candidate
+		}
+	}
+	return "", ""
+}
+
+// LLMProvider returns the connection settings of the named LLM provider.
+// OpenAI's settings are returned without the temperature and token defaults.
+func (c *Config) LLMProvider(name string) (LLMProviderConfig, error) {
+	switch strings.ToLower(name) {
+	case ProviderOpenAI, "":
+		return LLMProviderConfig{
+			APIKey:       c.OpenAI.APIKey,
+			BaseURL:      c.OpenAI.BaseURL,
+			DefaultModel: c.OpenAI.DefaultModel,
+		}, nil
+	case ProviderAnthropic:
+		return c.Anthropic, nil
+	case ProviderGemini:
+		return c.Gemini, nil
+	case ProviderOllama:
+		return c.Ollama, nil
+	case ProviderAzure:
+		return c.Azure, nil
+	}
+	return LLMProviderConfig{}, fmt.Errorf("unknown LLM provider: %s (supported: %s)", name, strings.Join(LLMProviders, ", "))
+}
+
+// Profile returns the named client profile
+func (c *Config) Profile(name string) (ProfileConfig, error) {
+	profile, ok := c.Profiles[strings.ToLower(name)]
+	if !ok || profile.ServerURL == "" {
+		return ProfileConfig{}, fmt.Errorf("unknown profile: %s (define %s in %s)", name, c.profileKey(strings.ToLower(name), "url"), c.fileName())
 	}
-	return globalConfig
+	return profile, nil
 }
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// DefaultPaths lists the config files looked up in the working directory,
+// in order of preference
+var DefaultPaths = []string{"not7.conf", "not7.yaml", "not7.yml", "not7.toml"}
+
+// dirConfigNames are the file names looked up in the per-user and system
+// config directories; "config" uses the flat KEY=value format
+var dirConfigNames = []string{"config", "config.yaml", "config.yml", "config.toml"}
+
+// systemConfigDir holds the config of NOT7 running as a system service
+const systemConfigDir = "/etc/not7"
+
+// Path returns the config file to use: NOT7_CONFIG when set, otherwise the
+// first existing file of SearchPaths, otherwise not7.conf
+func Path() string {
+	if envConfig := os.Getenv("NOT7_CONFIG"); envConfig != "" {
+		return envConfig
+	}
+	return DefaultPath()
+}
+
+// DefaultPath returns the first existing file of SearchPaths, or not7.conf
+// when none exists
+func DefaultPath() string {
+	for _, path := range SearchPaths() {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return DefaultPaths[0]
+}
+
+// SearchPaths returns the config file locations in lookup order:
+//
+//	./not7.conf, ./not7.yaml, ./not7.yml, ./not7.toml
+//	$XDG_CONFIG_HOME/not7/config[.yaml|.yml|.toml] (default ~/.config)
+//	/etc/not7/config[.yaml|.yml|.toml]
+func SearchPaths() []string {
+	paths := append([]string(nil), DefaultPaths...)
+
+	var dirs []string
+	if userDir := userConfigDir(); userDir != "" {
+		dirs = append(dirs, filepath.Join(userDir, "not7"))
+	}
+	dirs = append(dirs, systemConfigDir)
+
+	for _, dir := range dirs {
+		for _, name := range dirConfigNames {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+	}
+
+	return paths
+}
+
+// userConfigDir returns $XDG_CONFIG_HOME, or ~/.config when it is unset
+func userConfigDir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config")
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// applyEnv overrides cfg with the environment variables named like flat
+// keys, e.g. OPENAI_API_KEY or SERVER_PORT, so a container can be
+// configured with docker run -e alone. Empty variables are ignored.
+func applyEnv(cfg *Config) error {
+	for _, entry := range os.Environ() {
+		key, value, _ := strings.Cut(entry, "=")
+		if value == "" || !isEnvKey(key) {
+			continue
+		}
+		if err := setConfigValue(cfg, key, value); err != nil {
+			return fmt.Errorf("environment variable %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// FromEnv returns the default configuration overridden by the environment
+// variables named like flat keys, with secret references resolved. Programs
+// that embed NOT7 use it when they keep no config file.
+func FromEnv() (*Config, error) {
+	cfg := defaultConfig()
+	cfg.fromEnv = true
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+	if err := resolveSecrets(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// envConfigured reports whether any environment variable sets a config key
+func envConfigured() bool {
+	for _, entry := range os.Environ() {
+		key, value, _ := strings.Cut(entry, "=")
+		if value != "" && isEnvKey(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEnvKey reports whether an environment variable name is a flat config
+// key. Prefixed keys must have a name and a known field, so unrelated
+// variables such as CLIENT_ID or CLIENT_SECRET are left alone.
+func isEnvKey(key string) bool {
+	if _, ok := keyNames[key]; ok {
+		return true
+	}
+	switch {
+	case strings.HasPrefix(key, "PROFILE_"):
+		name, field := profileKey(key)
+		return name != "" && field != ""
+	case strings.HasPrefix(key, "CLIENT_"):
+		name, field := clientKey(key)
+		return name != "" && field != ""
+	case strings.HasPrefix(key, "SECRET_"):
+		return key != "SECRET_"
+	}
+	return false
+}
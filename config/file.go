@@ -0,0 +1,419 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// fileConfig is the nested layout of not7.yaml and not7.toml:
+//
+//	llm:
+//	  openai: {api_key, base_url, default_model, default_temperature, default_max_tokens}
+//	  anthropic: {api_key, base_url, default_model}
+//	  gemini: {api_key, base_url, default_model}
+//	  ollama: {base_url, default_model}
+//	  azure: {api_key, endpoint, api_version, deployment, default_model}
+//	tools:
+//	  builtin: {serp_api_key}
+//	  arcade: {api_key, user_id}
+//	  memory: {dir, embedding_model}
+//	server: {port, executions_dir, log_dir, specs_dir, prompts_dir, corpora_dir, pid_file,
+//	         cached_executions, reload_seconds}
+//	log: {level, format}
+//	tracing: {otlp_endpoint, service_name, sample_ratio}
+//	reporting: {sentry_dsn, webhook_url, webhook_secret}
+//	notify: {on, base_url, slack_webhook_url, discord_webhook_url, email_to,
+//	         smtp_host, smtp_port, smtp_username, smtp_password, smtp_from}
+//	queue: {backend, url, name, workers, lease_seconds}
+//	registry: {url, api_key, cache_dir}
+//	sinks: {dir, database_url, google_credentials}
+//	policy: {allow_models, deny_models, allow_tools, deny_tools, allow_providers,
+//	         deny_providers, allow_domains, deny_domains, max_cost}
+//	hooks: {before_node, after_node, timeout_seconds}
+//	secrets:
+//	  <NAME>: value or secret reference
+//	profiles:
+//	  <name>: {url, api_key}
+//	clients:
+//	  <name>: {api_key, monthly_executions, monthly_tokens, monthly_cost}
+type fileConfig struct {
+	LLM       fileLLMConfig                `yaml:"llm" toml:"llm"`
+	Tools     fileToolsConfig              `yaml:"tools" toml:"tools"`
+	Server    fileServerConfig             `yaml:"server" toml:"server"`
+	Log       fileLogConfig                `yaml:"log" toml:"log"`
+	Tracing   fileTracingConfig            `yaml:"tracing" toml:"tracing"`
+	Reporting fileReportingConfig          `yaml:"reporting" toml:"reporting"`
+	Notify    fileNotifyConfig             `yaml:"notify" toml:"notify"`
+	Queue     fileQueueConfig              `yaml:"queue" toml:"queue"`
+	Registry  fileRegistryConfig           `yaml:"registry" toml:"registry"`
+	Sinks     fileSinksConfig              `yaml:"sinks" toml:"sinks"`
+	Policy    filePolicyConfig             `yaml:"policy" toml:"policy"`
+	Hooks     fileHooksConfig              `yaml:"hooks" toml:"hooks"`
+	Secrets   map[string]string            `yaml:"secrets" toml:"secrets"`
+	Profiles  map[string]fileProfileConfig `yaml:"profiles" toml:"profiles"`
+	Clients   map[string]fileClientConfig  `yaml:"clients" toml:"clients"`
+}
+
+type fileLLMConfig struct {
+	OpenAI    fileOpenAIConfig   `yaml:"openai" toml:"openai"`
+	Anthropic fileProviderConfig `yaml:"anthropic" toml:"anthropic"`
+	Gemini    fileProviderConfig `yaml:"gemini" toml:"gemini"`
+	Ollama    fileOllamaConfig   `yaml:"ollama" toml:"ollama"`
+	Azure     fileAzureConfig    `yaml:"azure" toml:"azure"`
+}
+
+type fileOpenAIConfig struct {
+	APIKey             string  `yaml:"api_key" toml:"api_key"`
+	BaseURL            string  `yaml:"base_url" toml:"base_url"`
+	DefaultModel       string  `yaml:"default_model" toml:"default_model"`
+	DefaultTemperature float64 `yaml:"default_temperature" toml:"default_temperature"`
+	DefaultMaxTokens   int     `yaml:"default_max_tokens" toml:"default_max_tokens"`
+}
+
+type fileProviderConfig struct {
+	APIKey       string `yaml:"api_key" toml:"api_key"`
+	BaseURL      string `yaml:"base_url" toml:"base_url"`
+	DefaultModel string `yaml:"default_model" toml:"default_model"`
+}
+
+type fileOllamaConfig struct {
+	BaseURL      string `yaml:"base_url" toml:"base_url"`
+	DefaultModel string `yaml:"default_model" toml:"default_model"`
+}
+
+type fileAzureConfig struct {
+	APIKey       string `yaml:"api_key" toml:"api_key"`
+	Endpoint     string `yaml:"endpoint" toml:"endpoint"`
+	APIVersion   string `yaml:"api_version" toml:"api_version"`
+	Deployment   string `yaml:"deployment" toml:"deployment"`
+	DefaultModel string `yaml:"default_model" toml:"default_model"`
+}
+
+type fileToolsConfig struct {
+	Builtin fileBuiltinConfig `yaml:"builtin" toml:"builtin"`
+	Arcade  fileArcadeConfig  `yaml:"arcade" toml:"arcade"`
+	Memory  fileMemoryConfig  `yaml:"memory" toml:"memory"`
+}
+
+type fileBuiltinConfig struct {
+	SerpAPIKey string `yaml:"serp_api_key" toml:"serp_api_key"`
+}
+
+type fileMemoryConfig struct {
+	Dir            string `yaml:"dir" toml:"dir"`
+	EmbeddingModel string `yaml:"embedding_model" toml:"embedding_model"`
+}
+
+type fileArcadeConfig struct {
+	APIKey string `yaml:"api_key" toml:"api_key"`
+	UserID string `yaml:"user_id" toml:"user_id"`
+}
+
+type fileServerConfig struct {
+	Port             int    `yaml:"port" toml:"port"`
+	ExecutionsDir    string `yaml:"executions_dir" toml:"executions_dir"`
+	LogDir           string `yaml:"log_dir" toml:"log_dir"`
+	SpecsDir         string `yaml:"specs_dir" toml:"specs_dir"`
+	PromptsDir       string `yaml:"prompts_dir" toml:"prompts_dir"`
+	CorporaDir       string `yaml:"corpora_dir" toml:"corpora_dir"`
+	PIDFile          string `yaml:"pid_file" toml:"pid_file"`
+	CachedExecutions int    `yaml:"cached_executions" toml:"cached_executions"`
+	ReloadSeconds    int    `yaml:"reload_seconds" toml:"reload_seconds"`
+}
+
+type fileLogConfig struct {
+	Level  string `yaml:"level" toml:"level"`
+	Format string `yaml:"format" toml:"format"`
+}
+
+type fileTracingConfig struct {
+	Endpoint    string  `yaml:"otlp_endpoint" toml:"otlp_endpoint"`
+	ServiceName string  `yaml:"service_name" toml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio"`
+}
+
+type fileReportingConfig struct {
+	SentryDSN     string `yaml:"sentry_dsn" toml:"sentry_dsn"`
+	WebhookURL    string `yaml:"webhook_url" toml:"webhook_url"`
+	WebhookSecret string `yaml:"webhook_secret" toml:"webhook_secret"`
+}
+
+type fileNotifyConfig struct {
+	On                string `yaml:"on" toml:"on"`
+	BaseURL           string `yaml:"base_url" toml:"base_url"`
+	SlackWebhookURL   string `yaml:"slack_webhook_url" toml:"slack_webhook_url"`
+	DiscordWebhookURL string `yaml:"discord_webhook_url" toml:"discord_webhook_url"`
+	EmailTo           string `yaml:"email_to" toml:"email_to"`
+	SMTPHost          string `yaml:"smtp_host" toml:"smtp_host"`
+	SMTPPort          int    `yaml:"smtp_port" toml:"smtp_port"`
+	SMTPUsername      string `yaml:"smtp_username" toml:"smtp_username"`
+	SMTPPassword      string `yaml:"smtp_password" toml:"smtp_password"`
+	SMTPFrom          string `yaml:"smtp_from" toml:"smtp_from"`
+}
+
+type fileQueueConfig struct {
+	Backend      string `yaml:"backend" toml:"backend"`
+	URL          string `yaml:"url" toml:"url"`
+	Name         string `yaml:"name" toml:"name"`
+	Workers      int    `yaml:"workers" toml:"workers"`
+	LeaseSeconds int    `yaml:"lease_seconds" toml:"lease_seconds"`
+}
+
+type fileRegistryConfig struct {
+	URL      string `yaml:"url" toml:"url"`
+	APIKey   string `yaml:"api_key" toml:"api_key"`
+	CacheDir string `yaml:"cache_dir" toml:"cache_dir"`
+}
+
+type fileSinksConfig struct {
+	Dir               string `yaml:"dir" toml:"dir"`
+	DatabaseURL       string `yaml:"database_url" toml:"database_url"`
+	GoogleCredentials string `yaml:"google_credentials" toml:"google_credentials"`
+}
+
+type filePolicyConfig struct {
+	AllowModels    string  `yaml:"allow_models" toml:"allow_models"`
+	DenyModels     string  `yaml:"deny_models" toml:"deny_models"`
+	AllowTools     string  `yaml:"allow_tools" toml:"allow_tools"`
+	DenyTools      string  `yaml:"deny_tools" toml:"deny_tools"`
+	AllowProviders string  `yaml:"allow_providers" toml:"allow_providers"`
+	DenyProviders  string  `yaml:"deny_providers" toml:"deny_providers"`
+	AllowDomains   string  `yaml:"allow_domains" toml:"allow_domains"`
+	DenyDomains    string  `yaml:"deny_domains" toml:"deny_domains"`
+	MaxCost        float64 `yaml:"max_cost" toml:"max_cost"`
+}
+
+type fileHooksConfig struct {
+	BeforeNode     string `yaml:"before_node" toml:"before_node"`
+	AfterNode      string `yaml:"after_node" toml:"after_node"`
+	TimeoutSeconds int    `yaml:"timeout_seconds" toml:"timeout_seconds"`
+}
+
+type fileProfileConfig struct {
+	URL    string `yaml:"url" toml:"url"`
+	APIKey string `yaml:"api_key" toml:"api_key"`
+}
+
+type fileClientConfig struct {
+	APIKey            string  `yaml:"api_key" toml:"api_key"`
+	MonthlyExecutions int     `yaml:"monthly_executions" toml:"monthly_executions"`
+	MonthlyTokens     int     `yaml:"monthly_tokens" toml:"monthly_tokens"`
+	MonthlyCost       float64 `yaml:"monthly_cost" toml:"monthly_cost"`
+}
+
+// isStructuredConfig reports whether path is a YAML or TOML config file
+func isStructuredConfig(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".toml":
+		return true
+	}
+	return false
+}
+
+// parseStructuredConfig decodes a YAML or TOML file on top of cfg's defaults.
+// Unknown keys are recorded as warnings, as in the flat format.
+func parseStructuredConfig(cfg *Config, path string, data []byte) error {
+	file := newFileConfig(cfg)
+	raw := make(map[string]interface{})
+
+	if strings.ToLower(filepath.Ext(path)) == ".toml" {
+		if _, err := toml.Decode(string(data), &file); err != nil {
+			return fmt.Errorf("invalid TOML: %w", err)
+		}
+		if _, err := toml.Decode(string(data), &raw); err != nil {
+			return fmt.Errorf("invalid TOML: %w", err)
+		}
+	} else {
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return fmt.Errorf("invalid YAML: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("invalid YAML: %w", err)
+		}
+	}
+
+	cfg.warnings = append(cfg.warnings, unknownNestedKeys(raw)...)
+	file.apply(cfg)
+	return nil
+}
+
+// newFileConfig mirrors cfg so that decoding only overrides keys present in the file
+func newFileConfig(cfg *Config) fileConfig {
+	file := fileConfig{
+		LLM: fileLLMConfig{
+			OpenAI: fileOpenAIConfig{
+				APIKey:             cfg.OpenAI.APIKey,
+				BaseURL:            cfg.OpenAI.BaseURL,
+				DefaultModel:       cfg.OpenAI.DefaultModel,
+				DefaultTemperature: cfg.OpenAI.DefaultTemperature,
+				DefaultMaxTokens:   cfg.OpenAI.DefaultMaxTokens,
+			},
+			Anthropic: fileProviderConfig{
+				APIKey:       cfg.Anthropic.APIKey,
+				BaseURL:      cfg.Anthropic.BaseURL,
+				DefaultModel: cfg.Anthropic.DefaultModel,
+			},
+			Gemini: fileProviderConfig{
+				APIKey:       cfg.Gemini.APIKey,
+				BaseURL:      cfg.Gemini.BaseURL,
+				DefaultModel: cfg.Gemini.DefaultModel,
+			},
+			Ollama: fileOllamaConfig{
+				BaseURL:      cfg.Ollama.BaseURL,
+				DefaultModel: cfg.Ollama.DefaultModel,
+			},
+			Azure: fileAzureConfig{
+				APIKey:       cfg.Azure.APIKey,
+				Endpoint:     cfg.Azure.BaseURL,
+				APIVersion:   cfg.Azure.APIVersion,
+				Deployment:   cfg.Azure.Deployment,
+				DefaultModel: cfg.Azure.DefaultModel,
+			},
+		},
+		Tools: fileToolsConfig{
+			Builtin: fileBuiltinConfig{SerpAPIKey: cfg.Builtin.SerpAPIKey},
+			Arcade:  fileArcadeConfig{APIKey: cfg.Arcade.APIKey, UserID: cfg.Arcade.UserID},
+			Memory:  fileMemoryConfig{Dir: cfg.Memory.Dir, EmbeddingModel: cfg.Memory.EmbeddingModel},
+		},
+		Server: fileServerConfig{
+			Port:             cfg.Server.Port,
+			ExecutionsDir:    cfg.Server.ExecutionsDir,
+			LogDir:           cfg.Server.LogDir,
+			SpecsDir:         cfg.Server.SpecsDir,
+			PromptsDir:       cfg.Server.PromptsDir,
+			CorporaDir:       cfg.Server.CorporaDir,
+			PIDFile:          cfg.Server.PIDFile,
+			CachedExecutions: cfg.Server.CachedExecutions,
+			ReloadSeconds:    cfg.Server.ReloadSeconds,
+		},
+		Log: fileLogConfig{Level: cfg.Log.Level, Format: cfg.Log.Format},
+		Tracing: fileTracingConfig{
+			Endpoint:    cfg.Tracing.Endpoint,
+			ServiceName: cfg.Tracing.ServiceName,
+			SampleRatio: cfg.Tracing.SampleRatio,
+		},
+		Reporting: fileReportingConfig{
+			SentryDSN:     cfg.Reporting.SentryDSN,
+			WebhookURL:    cfg.Reporting.WebhookURL,
+			WebhookSecret: cfg.Reporting.WebhookSecret,
+		},
+		Notify: fileNotifyConfig(cfg.Notify),
+		Queue: fileQueueConfig{
+			Backend:      cfg.Queue.Backend,
+			URL:          cfg.Queue.URL,
+			Name:         cfg.Queue.Name,
+			Workers:      cfg.Queue.Workers,
+			LeaseSeconds: cfg.Queue.LeaseSeconds,
+		},
+		Registry: fileRegistryConfig{
+			URL:      cfg.Registry.URL,
+			APIKey:   cfg.Registry.APIKey,
+			CacheDir: cfg.Registry.CacheDir,
+		},
+		Sinks:    fileSinksConfig(cfg.Sinks),
+		Policy:   filePolicyConfig(cfg.Policy),
+		Hooks:    fileHooksConfig(cfg.Hooks),
+		Secrets:  make(map[string]string, len(cfg.Secrets)),
+		Profiles: make(map[string]fileProfileConfig, len(cfg.Profiles)),
+		Clients:  make(map[string]fileClientConfig, len(cfg.Clients)),
+	}
+
+	for name, value := range cfg.Secrets {
+		file.Secrets[name] = value
+	}
+	for name, profile := range cfg.Profiles {
+		file.Profiles[name] = fileProfileConfig{URL: profile.ServerURL, APIKey: profile.APIKey}
+	}
+	for name, client := range cfg.Clients {
+		file.Clients[name] = fileClientConfig(client)
+	}
+
+	return file
+}
+
+// apply copies the decoded file values into cfg
+func (f fileConfig) apply(cfg *Config) {
+	cfg.OpenAI = OpenAIConfig{
+		APIKey:             f.LLM.OpenAI.APIKey,
+		BaseURL:            f.LLM.OpenAI.BaseURL,
+		DefaultModel:       f.LLM.OpenAI.DefaultModel,
+		DefaultTemperature: f.LLM.OpenAI.DefaultTemperature,
+		DefaultMaxTokens:   f.LLM.OpenAI.DefaultMaxTokens,
+	}
+	cfg.Anthropic = LLMProviderConfig{
+		APIKey:       f.LLM.Anthropic.APIKey,
+		BaseURL:      f.LLM.Anthropic.BaseURL,
+		DefaultModel: f.LLM.Anthropic.DefaultModel,
+	}
+	cfg.Gemini = LLMProviderConfig{
+		APIKey:       f.LLM.Gemini.APIKey,
+		BaseURL:      f.LLM.Gemini.BaseURL,
+		DefaultModel: f.LLM.Gemini.DefaultModel,
+	}
+	cfg.Ollama = LLMProviderConfig{
+		BaseURL:      f.LLM.Ollama.BaseURL,
+		DefaultModel: f.LLM.Ollama.DefaultModel,
+	}
+	cfg.Azure = LLMProviderConfig{
+		APIKey:       f.LLM.Azure.APIKey,
+		BaseURL:      f.LLM.Azure.Endpoint,
+		APIVersion:   f.LLM.Azure.APIVersion,
+		Deployment:   f.LLM.Azure.Deployment,
+		DefaultModel: f.LLM.Azure.DefaultModel,
+	}
+	cfg.Builtin = BuiltinConfig{SerpAPIKey: f.Tools.Builtin.SerpAPIKey}
+	cfg.Arcade = ArcadeConfig{APIKey: f.Tools.Arcade.APIKey, UserID: f.Tools.Arcade.UserID}
+	cfg.Memory = MemoryConfig{Dir: f.Tools.Memory.Dir, EmbeddingModel: f.Tools.Memory.EmbeddingModel}
+	cfg.Server = ServerConfig{
+		Port:             f.Server.Port,
+		ExecutionsDir:    f.Server.ExecutionsDir,
+		LogDir:           f.Server.LogDir,
+		SpecsDir:         f.Server.SpecsDir,
+		PromptsDir:       f.Server.PromptsDir,
+		CorporaDir:       f.Server.CorporaDir,
+		PIDFile:          f.Server.PIDFile,
+		CachedExecutions: f.Server.CachedExecutions,
+		ReloadSeconds:    f.Server.ReloadSeconds,
+	}
+	cfg.Log = LogConfig{Level: f.Log.Level, Format: f.Log.Format}
+	cfg.Tracing = TracingConfig{
+		Endpoint:    f.Tracing.Endpoint,
+		ServiceName: f.Tracing.ServiceName,
+		SampleRatio: f.Tracing.SampleRatio,
+	}
+	cfg.Reporting = ReportingConfig{
+		SentryDSN:     f.Reporting.SentryDSN,
+		WebhookURL:    f.Reporting.WebhookURL,
+		WebhookSecret: f.Reporting.WebhookSecret,
+	}
+	cfg.Notify = NotifyConfig(f.Notify)
+	cfg.Queue = QueueConfig{
+		Backend:      f.Queue.Backend,
+		URL:          f.Queue.URL,
+		Name:         f.Queue.Name,
+		Workers:      f.Queue.Workers,
+		LeaseSeconds: f.Queue.LeaseSeconds,
+	}
+	cfg.Registry = RegistryConfig{
+		URL:      f.Registry.URL,
+		APIKey:   f.Registry.APIKey,
+		CacheDir: f.Registry.CacheDir,
+	}
+	cfg.Sinks = SinksConfig(f.Sinks)
+	cfg.Policy = PolicyConfig(f.Policy)
+	cfg.Hooks = HooksConfig(f.Hooks)
+
+	for name, value := range f.Secrets {
+		cfg.Secrets[strings.ToUpper(name)] = value
+	}
+	for name, profile := range f.Profiles {
+		cfg.Profiles[strings.ToLower(name)] = ProfileConfig{ServerURL: profile.URL, APIKey: profile.APIKey}
+	}
+	for name, client := range f.Clients {
+		cfg.Clients[strings.ToLower(name)] = ClientConfig(client)
+	}
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/not7/core/secrets"
+)
+
+// secretResolveTimeout bounds resolving all secret references of a config file
+const secretResolveTimeout = 30 * time.Second
+
+// resolveSecrets decrypts every ENC[age:...] string value and replaces every
+// secret reference (env://, file://, vault://, aws-sm://, gcp-sm://) with the
+// secret it points to, so config files can be committed without credentials.
+// Named secrets are left as they are, for Secret to resolve when used.
+func resolveSecrets(cfg *Config) error {
+	ctx, cancel := context.WithTimeout(context.Background(), secretResolveTimeout)
+	defer cancel()
+
+	return resolveSecretFields(ctx, reflect.ValueOf(cfg).Elem(), "")
+}
+
+// resolveSecretFields walks the string fields of v, including struct values
+// of maps such as Profiles. path names the field in error messages.
+func resolveSecretFields(ctx context.Context, v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.String:
+		switch {
+		case secrets.IsEncrypted(v.String()):
+			value, err := secrets.Decrypt(v.String())
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			v.SetString(value)
+		case secrets.IsReference(v.String()):
+			value, err := secrets.Resolve(ctx, v.String())
+			if err != nil {
+				return fmt.Errorf("%s: failed to resolve secret: %w", path, err)
+			}
+			v.SetString(value)
+		}
+
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() || (path == "" && field.Name == "Secrets") {
+				continue
+			}
+			if err := resolveSecretFields(ctx, v.Field(i), joinFieldPath(path, field.Name)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			// Map values are not addressable, so resolve a copy and store it back
+			value := reflect.New(iter.Value().Type()).Elem()
+			value.Set(iter.Value())
+			if err := resolveSecretFields(ctx, value, joinFieldPath(path, fmt.Sprint(iter.Key()))); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), value)
+		}
+	}
+
+	return nil
+}
+
+// Secret returns the value of a named secret, decrypting it or resolving
+// its reference first. Names are not case-sensitive.
+func (c *Config) Secret(ctx context.Context, name string) (string, error) {
+	if err := c.CheckSecret(name); err != nil {
+		return "", err
+	}
+	value := c.Secrets[strings.ToUpper(name)]
+	switch {
+	case secrets.IsEncrypted(value):
+		decrypted, err := secrets.Decrypt(value)
+		if err != nil {
+			return "", fmt.Errorf("secret %s: %w", name, err)
+		}
+		return decrypted, nil
+	case secrets.IsReference(value):
+		resolved, err := secrets.Resolve(ctx, value)
+		if err != nil {
+			return "", fmt.Errorf("secret %s: failed to resolve secret: %w", name, err)
+		}
+		return resolved, nil
+	}
+	return value, nil
+}
+
+// CheckSecret reports an error naming the key to set when a named secret
+// is not defined. The secret itself is not resolved.
+func (c *Config) CheckSecret(name string) error {
+	if _, ok := c.Secrets[strings.ToUpper(name)]; !ok {
+		return fmt.Errorf("unknown secret: %s (define %s in %s)", name, c.secretKey(name), c.fileName())
+	}
+	return nil
+}
+
+func joinFieldPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
@@ -0,0 +1,718 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/not7/core/logger"
+)
+
+// errUnknownKey marks a key the config format does not define
+var errUnknownKey = errors.New("unknown config key")
+
+// Severity classifies a config issue
+type Severity string
+
+const (
+	// SeverityError makes the config unusable for the checked features
+	SeverityError Severity = "error"
+	// SeverityWarning is reported but does not stop the config from loading
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a single problem found in a config file
+type Issue struct {
+	Severity Severity `json:"severity"`
+	Key      string   `json:"key,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Message  string   `json:"message"`
+	Hint     string   `json:"hint,omitempty"`
+}
+
+// String formats the issue as "line N: KEY: message"
+func (i Issue) String() string {
+	var b strings.Builder
+	if i.Line > 0 {
+		fmt.Fprintf(&b, "line %d: ", i.Line)
+	}
+	if i.Key != "" {
+		b.WriteString(i.Key + ": ")
+	}
+	b.WriteString(i.Message)
+	return b.String()
+}
+
+// Requirements lists the features a config is checked against. Provider
+// names are those used in agent specs (e.g. "openai", "builtin", "arcade-slack").
+type Requirements struct {
+	LLMProviders  []string
+	ToolProviders []string
+	Secrets       []string // names referenced as {{secret.NAME}}
+}
+
+// keyNames maps each flat config key to its YAML/TOML path
+var keyNames = map[string]string{
+	"OPENAI_API_KEY":             "llm.openai.api_key",
+	"OPENAI_BASE_URL":            "llm.openai.base_url",
+	"OPENAI_DEFAULT_MODEL":       "llm.openai.default_model",
+	"OPENAI_DEFAULT_TEMPERATURE": "llm.openai.default_temperature",
+	"OPENAI_DEFAULT_MAX_TOKENS":  "llm.openai.default_max_tokens",
+	"ANTHROPIC_API_KEY":          "llm.anthropic.api_key",
+	"ANTHROPIC_BASE_URL":         "llm.anthropic.base_url",
+	"ANTHROPIC_DEFAULT_MODEL":    "llm.anthropic.default_model",
+	"GEMINI_API_KEY":             "llm.gemini.api_key",
+	"GEMINI_BASE_URL":            "llm.gemini.base_url",
+	"GEMINI_DEFAULT_MODEL":       "llm.gemini.default_model",
+	"OLLAMA_BASE_URL":            "llm.ollama.base_url",
+	"OLLAMA_DEFAULT_MODEL":       "llm.ollama.default_model",
+	"AZURE_OPENAI_API_KEY":       "llm.azure.api_key",
+	"AZURE_OPENAI_ENDPOINT":      "llm.azure.endpoint",
+	"AZURE_OPENAI_API_VERSION":   "llm.azure.api_version",
+	"AZURE_OPENAI_DEPLOYMENT":    "llm.azure.deployment",
+	"AZURE_OPENAI_DEFAULT_MODEL": "llm.azure.default_model",
+	"SERVER_PORT":                "server.port",
+	"SERVER_EXECUTIONS_DIR":      "server.executions_dir",
+	"SERVER_LOG_DIR":             "server.log_dir",
+	"SERVER_SPECS_DIR":           "server.specs_dir",
+	"SERVER_PROMPTS_DIR":         "server.prompts_dir",
+	"SERVER_CORPORA_DIR":         "server.corpora_dir",
+	"SERVER_PID_FILE":            "server.pid_file",
+	"SERVER_CACHED_EXECUTIONS":   "server.cached_executions",
+	"SERVER_RELOAD_SECONDS":      "server.reload_seconds",
+	"LOG_LEVEL":                  "log.level",
+	"LOG_FORMAT":                 "log.format",
+	"TRACING_OTLP_ENDPOINT":      "tracing.otlp_endpoint",
+	"TRACING_SERVICE_NAME":       "tracing.service_name",
+	"TRACING_SAMPLE_RATIO":       "tracing.sample_ratio",
+	"SENTRY_DSN":                 "reporting.sentry_dsn",
+	"ERROR_WEBHOOK_URL":          "reporting.webhook_url",
+	"ERROR_WEBHOOK_SECRET":       "reporting.webhook_secret",
+	"NOTIFY_ON":                  "notify.on",
+	"NOTIFY_BASE_URL":            "notify.base_url",
+	"NOTIFY_SLACK_WEBHOOK_URL":   "notify.slack_webhook_url",
+	"NOTIFY_DISCORD_WEBHOOK_URL": "notify.discord_webhook_url",
+	"NOTIFY_EMAIL_TO":            "notify.email_to",
+	"NOTIFY_SMTP_HOST":           "notify.smtp_host",
+	"NOTIFY_SMTP_PORT":           "notify.smtp_port",
+	"NOTIFY_SMTP_USERNAME":       "notify.smtp_username",
+	"NOTIFY_SMTP_PASSWORD":       "notify.smtp_password",
+	"NOTIFY_SMTP_FROM":           "notify.smtp_from",
+	"SERP_API_KEY":               "tools.builtin.serp_api_key",
+	"ARCADE_API_KEY":             "tools.arcade.api_key",
+	"ARCADE_USER_ID":             "tools.arcade.user_id",
+	"MEMORY_DIR":                 "tools.memory.dir",
+	"MEMORY_EMBEDDING_MODEL":     "tools.memory.embedding_model",
+	"QUEUE_BACKEND":              "queue.backend",
+	"QUEUE_URL":                  "queue.url",
+	"QUEUE_NAME":                 "queue.name",
+	"QUEUE_WORKERS":              "queue.workers",
+	"QUEUE_LEASE_SECONDS":        "queue.lease_seconds",
+	"REGISTRY_URL":               "registry.url",
+	"REGISTRY_API_KEY":           "registry.api_key",
+	"REGISTRY_CACHE_DIR":         "registry.cache_dir",
+	"SINKS_DIR":                  "sinks.dir",
+	"SINKS_DATABASE_URL":         "sinks.database_url",
+	"SINKS_GOOGLE_CREDENTIALS":   "sinks.google_credentials",
+	"POLICY_ALLOW_MODELS":        "policy.allow_models",
+	"POLICY_DENY_MODELS":         "policy.deny_models",
+	"POLICY_ALLOW_TOOLS":         "policy.allow_tools",
+	"POLICY_DENY_TOOLS":          "policy.deny_tools",
+	"POLICY_ALLOW_PROVIDERS":     "policy.allow_providers",
+	"POLICY_DENY_PROVIDERS":      "policy.deny_providers",
+	"POLICY_ALLOW_DOMAINS":       "policy.allow_domains",
+	"POLICY_DENY_DOMAINS":        "policy.deny_domains",
+	"POLICY_MAX_COST":            "policy.max_cost",
+	"HOOKS_BEFORE_NODE":          "hooks.before_node",
+	"HOOKS_AFTER_NODE":           "hooks.after_node",
+	"HOOKS_TIMEOUT_SECONDS":      "hooks.timeout_seconds",
+}
+
+// profileFields are the per-profile keys, as PROFILE_<NAME>_<FIELD> or
+// profiles.<name>.<field>
+var profileFields = []string{"url", "api_key"}
+
+// clientFields are the per-client keys, as CLIENT_<NAME>_<FIELD> or
+// clients.<name>.<field>
+var clientFields = []string{"api_key", "monthly_executions", "monthly_tokens", "monthly_cost"}
+
+// Warnings returns the problems found while parsing that did not stop the
+// config from loading, such as unknown keys
+func (c *Config) Warnings() []Issue {
+	return c.warnings
+}
+
+// Validate checks value ranges and the keys required by the given features.
+// The parse warnings are included, so an empty result means a clean config.
+func (c *Config) Validate(req Requirements) []Issue {
+	issues := append([]Issue(nil), c.warnings...)
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		issues = append(issues, c.invalid("SERVER_PORT", fmt.Sprintf("port %d is out of range (1-65535)", c.Server.Port)))
+	}
+	if c.Server.CachedExecutions < 0 {
+		issues = append(issues, c.invalid("SERVER_CACHED_EXECUTIONS", "must not be negative"))
+	}
+	if c.Server.ReloadSeconds < 0 {
+		issues = append(issues, c.invalid("SERVER_RELOAD_SECONDS", "must not be negative"))
+	}
+	if c.OpenAI.DefaultTemperature < 0 || c.OpenAI.DefaultTemperature > 2 {
+		issues = append(issues, c.invalid("OPENAI_DEFAULT_TEMPERATURE", fmt.Sprintf("temperature %g is out of range (0-2)", c.OpenAI.DefaultTemperature)))
+	}
+	if c.OpenAI.DefaultMaxTokens < 1 {
+		issues = append(issues, c.invalid("OPENAI_DEFAULT_MAX_TOKENS", "must be a positive number"))
+	}
+	if _, err := logger.ParseLevel(c.Log.Level); err != nil {
+		issues = append(issues, c.invalid("LOG_LEVEL", err.Error()))
+	}
+	if _, err := logger.ParseFormat(c.Log.Format); err != nil {
+		issues = append(issues, c.invalid("LOG_FORMAT", err.Error()))
+	}
+	switch c.Queue.Backend {
+	case "", "memory":
+	case "redis":
+		if c.Queue.URL == "" {
+			issues = append(issues, c.missing("QUEUE_URL", "the redis queue backend"))
+		}
+	default:
+		issues = append(issues, c.invalid("QUEUE_BACKEND", fmt.Sprintf("unknown backend %q (expected memory or redis)", c.Queue.Backend)))
+	}
+	if c.Queue.Workers < 0 {
+		issues = append(issues, c.invalid("QUEUE_WORKERS", "must not be negative"))
+	}
+	if c.Queue.LeaseSeconds < 3 {
+		issues = append(issues, c.invalid("QUEUE_LEASE_SECONDS", "must be at least 3"))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		issues = append(issues, c.invalid("TRACING_SAMPLE_RATIO", fmt.Sprintf("sample ratio %g is out of range (0-1)", c.Tracing.SampleRatio)))
+	}
+
+	if c.Policy.MaxCost < 0 {
+		issues = append(issues, c.invalid("POLICY_MAX_COST", "must not be negative"))
+	}
+	providers := map[string]string{
+		"POLICY_ALLOW_PROVIDERS": c.Policy.AllowProviders,
+		"POLICY_DENY_PROVIDERS":  c.Policy.DenyProviders,
+	}
+	for _, key := range sortedKeys(providers) {
+		for _, name := range strings.Split(providers[key], ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name != "" && !slices.Contains(LLMProviders, name) {
+				issues = append(issues, c.invalid(key, fmt.Sprintf("unknown LLM provider %q (expected %s)", name, strings.Join(LLMProviders, ", "))))
+			}
+		}
+	}
+	domains := map[string]string{
+		"POLICY_ALLOW_DOMAINS": c.Policy.AllowDomains,
+		"POLICY_DENY_DOMAINS":  c.Policy.DenyDomains,
+	}
+	for _, key := range sortedKeys(domains) {
+		for _, domain := range strings.Split(domains[key], ",") {
+			if strings.ContainsAny(domain, "/:*") {
+				issues = append(issues, c.invalid(key, fmt.Sprintf("%q is not a domain (expected a host name such as example.com)", strings.TrimSpace(domain))))
+			}
+		}
+	}
+
+	urls := map[string]string{
+		"OPENAI_BASE_URL":            c.OpenAI.BaseURL,
+		"ANTHROPIC_BASE_URL":         c.Anthropic.BaseURL,
+		"GEMINI_BASE_URL":            c.Gemini.BaseURL,
+		"OLLAMA_BASE_URL":            c.Ollama.BaseURL,
+		"AZURE_OPENAI_ENDPOINT":      c.Azure.BaseURL,
+		"TRACING_OTLP_ENDPOINT":      c.Tracing.Endpoint,
+		"SENTRY_DSN":                 c.Reporting.SentryDSN,
+		"ERROR_WEBHOOK_URL":          c.Reporting.WebhookURL,
+		"NOTIFY_BASE_URL":            c.Notify.BaseURL,
+		"NOTIFY_SLACK_WEBHOOK_URL":   c.Notify.SlackWebhookURL,
+		"NOTIFY_DISCORD_WEBHOOK_URL": c.Notify.DiscordWebhookURL,
+		"REGISTRY_URL":               c.Registry.URL,
+	}
+	for _, key := range sortedKeys(urls) {
+		if err := checkURL(urls[key]); err != nil {
+			issues = append(issues, c.invalid(key, err.Error()))
+		}
+	}
+	for _, name := range sortedKeys(c.Profiles) {
+		if err := checkURL(c.Profiles[name].ServerURL); err != nil {
+			issues = append(issues, c.invalid(c.profileKey(name, "url"), err.Error()))
+		}
+	}
+
+	clientKeys := make(map[string]string, len(c.Clients))
+	for _, name := range sortedKeys(c.Clients) {
+		client := c.Clients[name]
+		switch other, taken := clientKeys[client.APIKey]; {
+		case client.APIKey == "":
+			key := c.clientKey(name, "api_key")
+			issues = append(issues, Issue{
+				Severity: SeverityError,
+				Key:      key,
+				Message:  fmt.Sprintf("required by client %s", name),
+				Hint:     fmt.Sprintf("Set %s in %s", key, c.fileName()),
+			})
+		case taken:
+			issues = append(issues, Issue{Severity: SeverityError, Key: c.clientKey(name, "api_key"), Message: "same API key as client " + other})
+		default:
+			clientKeys[client.APIKey] = name
+		}
+		if client.MonthlyExecutions < 0 {
+			issues = append(issues, Issue{Severity: SeverityError, Key: c.clientKey(name, "monthly_executions"), Message: "must not be negative"})
+		}
+		if client.MonthlyTokens < 0 {
+			issues = append(issues, Issue{Severity: SeverityError, Key: c.clientKey(name, "monthly_tokens"), Message: "must not be negative"})
+		}
+		if client.MonthlyCost < 0 {
+			issues = append(issues, Issue{Severity: SeverityError, Key: c.clientKey(name, "monthly_cost"), Message: "must not be negative"})
+		}
+	}
+
+	if c.Hooks.TimeoutSeconds < 1 {
+		issues = append(issues, c.invalid("HOOKS_TIMEOUT_SECONDS", "must be at least 1"))
+	}
+	hooks := map[string]string{
+		"HOOKS_BEFORE_NODE": c.Hooks.BeforeNode,
+		"HOOKS_AFTER_NODE":  c.Hooks.AfterNode,
+	}
+	for _, key := range sortedKeys(hooks) {
+		if fields := strings.Fields(hooks[key]); len(fields) > 0 {
+			if _, err := exec.LookPath(fields[0]); err != nil {
+				issues = append(issues, Issue{
+					Severity: SeverityWarning,
+					Key:      c.keyName(key),
+					Message:  fmt.Sprintf("command %q not found, so every node would fail", fields[0]),
+				})
+			}
+		}
+	}
+
+	if c.Reporting.WebhookURL != "" && c.Reporting.WebhookSecret == "" {
+		issues = append(issues, Issue{
+			Severity: SeverityWarning,
+			Key:      c.keyName("ERROR_WEBHOOK_SECRET"),
+			Message:  "not set, so error webhook deliveries are unsigned",
+			Hint:     fmt.Sprintf("Set %s in %s to a random string shared with the receiver", c.keyName("ERROR_WEBHOOK_SECRET"), c.fileName()),
+		})
+	}
+
+	for _, outcome := range strings.Split(c.Notify.On, ",") {
+		switch strings.TrimSpace(outcome) {
+		case "", "success", "failure":
+		default:
+			issues = append(issues, c.invalid("NOTIFY_ON", fmt.Sprintf("unknown outcome %q (expected success and/or failure)", outcome)))
+		}
+	}
+	if c.Notify.EmailTo != "" {
+		if c.Notify.SMTPHost == "" {
+			issues = append(issues, c.missing("NOTIFY_SMTP_HOST", "email notifications"))
+		}
+		if c.Notify.SMTPFrom == "" {
+			issues = append(issues, c.missing("NOTIFY_SMTP_FROM", "email notifications"))
+		}
+	}
+	if c.Notify.SMTPPort < 1 || c.Notify.SMTPPort > 65535 {
+		issues = append(issues, c.invalid("NOTIFY_SMTP_PORT", fmt.Sprintf("port %d is out of range (1-65535)", c.Notify.SMTPPort)))
+	}
+
+	// A half-configured Arcade account is a mistake even when unused
+	if (c.Arcade.APIKey == "") != (c.Arcade.UserID == "") {
+		missing := "ARCADE_API_KEY"
+		if c.Arcade.UserID == "" {
+			missing = "ARCADE_USER_ID"
+		}
+		issues = append(issues, c.missing(missing, "the Arcade tool provider"))
+	}
+
+	for _, provider := range uniqueNames(req.LLMProviders) {
+		issues = append(issues, c.checkLLMProvider(provider)...)
+	}
+	for _, provider := range uniqueNames(req.ToolProviders) {
+		issues = append(issues, c.checkToolProvider(provider)...)
+	}
+	for _, name := range uniqueNames(req.Secrets) {
+		if c.CheckSecret(name) != nil {
+			key := c.secretKey(name)
+			issues = append(issues, Issue{
+				Severity: SeverityError,
+				Key:      key,
+				Message:  fmt.Sprintf("required by {{secret.%s}}", strings.ToUpper(name)),
+				Hint:     fmt.Sprintf("Set %s in %s", key, c.fileName()),
+			})
+		}
+	}
+
+	return dedupeIssues(issues)
+}
+
+// checkLLMProvider reports the keys an LLM provider needs but lacks
+func (c *Config) checkLLMProvider(name string) []Issue {
+	feature := fmt.Sprintf("the %s LLM provider", name)
+	var issues []Issue
+
+	switch name {
+	case ProviderOpenAI:
+		if c.OpenAI.APIKey == "" {
+			issues = append(issues, c.missing("OPENAI_API_KEY", feature))
+		}
+	case ProviderAnthropic:
+		if c.Anthropic.APIKey == "" {
+			issues = append(issues, c.missing("ANTHROPIC_API_KEY", feature))
+		}
+	case ProviderGemini:
+		if c.Gemini.APIKey == "" {
+			issues = append(issues, c.missing("GEMINI_API_KEY", feature))
+		}
+	case ProviderOllama:
+		if c.Ollama.BaseURL == "" {
+			issues = append(issues, c.missing("OLLAMA_BASE_URL", feature))
+		}
+	case ProviderAzure:
+		if c.Azure.APIKey == "" {
+			issues = append(issues, c.missing("AZURE_OPENAI_API_KEY", feature))
+		}
+		if c.Azure.BaseURL == "" {
+			issues = append(issues, c.missing("AZURE_OPENAI_ENDPOINT", feature))
+		}
+		if c.Azure.Deployment == "" {
+			issues = append(issues, c.missing("AZURE_OPENAI_DEPLOYMENT", feature))
+		}
+	default:
+		issues = append(issues, Issue{
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("unknown LLM provider %q", name),
+			Hint:     "Supported providers: " + strings.Join(LLMProviders, ", "),
+		})
+	}
+
+	return issues
+}
+
+// checkToolProvider reports the keys a tool provider needs but lacks
+func (c *Config) checkToolProvider(name string) []Issue {
+	switch {
+	case name == "builtin":
+		if c.Builtin.SerpAPIKey == "" {
+			return []Issue{c.missing("SERP_API_KEY", "the builtin tool provider")}
+		}
+	case name == "memory":
+		if c.OpenAI.APIKey == "" {
+			return []Issue{c.missing("OPENAI_API_KEY", "the memory tool provider")}
+		}
+	case name == "arcade" || strings.HasPrefix(name, "arcade-"):
+		var issues []Issue
+		if c.Arcade.APIKey == "" {
+			issues = append(issues, c.missing("ARCADE_API_KEY", "the "+name+" tool provider"))
+		}
+		if c.Arcade.UserID == "" {
+			issues = append(issues, c.missing("ARCADE_USER_ID", "the "+name+" tool provider"))
+		}
+		return issues
+	default:
+		return []Issue{{
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("tool provider %q needs no config or is not supported", name),
+		}}
+	}
+	return nil
+}
+
+// missing builds the issue for a required key that is not set
+func (c *Config) missing(key, feature string) Issue {
+	name := c.keyName(key)
+	return Issue{
+		Severity: SeverityError,
+		Key:      name,
+		Message:  "required by " + feature,
+		Hint:     fmt.Sprintf("Set %s in %s", name, c.fileName()),
+	}
+}
+
+// invalid builds the issue for a key whose value is out of range
+func (c *Config) invalid(key, message string) Issue {
+	return Issue{Severity: SeverityError, Key: c.keyName(key), Message: message}
+}
+
+// keyName returns the name of a flat key in the config's own format
+func (c *Config) keyName(key string) string {
+	if c.structured {
+		if nested, ok := keyNames[key]; ok {
+			return nested
+		}
+	}
+	return key
+}
+
+// profileKey returns the name of a profile field in the config's own format
+func (c *Config) profileKey(name, field string) string {
+	if c.structured {
+		return "profiles." + name + "." + field
+	}
+	return "PROFILE_" + strings.ToUpper(name) + "_" + strings.ToUpper(field)
+}
+
+// clientKey returns the name of a client field in the config's own format
+func (c *Config) clientKey(name, field string) string {
+	if c.structured {
+		return "clients." + name + "." + field
+	}
+	return "CLIENT_" + strings.ToUpper(name) + "_" + strings.ToUpper(field)
+}
+
+// secretKey returns the name of a named secret in the config's own format
+func (c *Config) secretKey(name string) string {
+	if c.structured {
+		return "secrets." + strings.ToUpper(name)
+	}
+	return "SECRET_" + strings.ToUpper(name)
+}
+
+// fileName is used in hints
+func (c *Config) fileName() string {
+	if c.fromEnv {
+		return "the environment"
+	}
+	if c.path != "" {
+		return c.path
+	}
+	return DefaultPaths[0]
+}
+
+// unknownFlatKey builds the warning for an unknown KEY=value line
+func unknownFlatKey(key string, line int) Issue {
+	candidates := make([]string, 0, len(keyNames)+len(clientFields))
+	for flat := range keyNames {
+		candidates = append(candidates, flat)
+	}
+	for prefix, fields := range map[string][]string{"PROFILE_": profileFields, "CLIENT_": clientFields} {
+		if rest, ok := strings.CutPrefix(key, prefix); ok {
+			// Compare against the fields of the same profile or client,
+			// whichever underscore its name ends at
+			for i := strings.Index(rest, "_"); i > 0; i = nextIndex(rest, "_", i) {
+				for _, field := range fields {
+					candidates = append(candidates, prefix+rest[:i]+"_"+strings.ToUpper(field))
+				}
+			}
+		}
+	}
+	return unknownKey(key, line, suggestKey(key, candidates))
+}
+
+// nextIndex returns the index of the next sep in s after i, or -1
+func nextIndex(s, sep string, i int) int {
+	if j := strings.Index(s[i+1:], sep); j >= 0 {
+		return i + 1 + j
+	}
+	return -1
+}
+
+// unknownNestedKeys reports the dotted paths in a decoded YAML/TOML document
+// that the nested format does not define
+func unknownNestedKeys(raw map[string]interface{}) []Issue {
+	known := make(map[string]bool, len(keyNames))
+	for _, nested := range keyNames {
+		known[nested] = true
+	}
+	for _, field := range profileFields {
+		known["profiles.*."+field] = true
+	}
+	for _, field := range clientFields {
+		known["clients.*."+field] = true
+	}
+	known["secrets.*"] = true
+
+	var issues []Issue
+	walkNestedKeys(raw, "", "", known, &issues)
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Key < issues[j].Key })
+	return issues
+}
+
+// walkNestedKeys descends into sections that contain known keys and records
+// the first unknown path of each branch. pattern is path with profile,
+// client and secret names replaced by "*".
+func walkNestedKeys(node map[string]interface{}, path, pattern string, known map[string]bool, issues *[]Issue) {
+	for name, value := range node {
+		childPath := joinFieldPath(path, name)
+		childPattern := joinFieldPath(pattern, name)
+		if pattern == "profiles" || pattern == "clients" || pattern == "secrets" {
+			childPattern = pattern + ".*"
+		}
+
+		if known[childPattern] {
+			continue
+		}
+		if child, ok := value.(map[string]interface{}); ok && isKnownSection(childPattern, known) {
+			walkNestedKeys(child, childPath, childPattern, known, issues)
+			continue
+		}
+
+		// Sections are candidates too, for typos such as llm.anthropc
+		candidates := make([]string, 0, len(known))
+		for key := range known {
+			parts := strings.Split(key, ".")
+			for i := range parts {
+				candidates = append(candidates, strings.Join(parts[:i+1], "."))
+			}
+		}
+		suggestion := suggestKey(childPattern, candidates)
+		if section, _, _ := strings.Cut(pattern, "."); section == "profiles" || section == "clients" {
+			// Put the profile's or client's own name back into the suggestion
+			if parts := strings.Split(childPath, "."); len(parts) > 1 {
+				suggestion = strings.Replace(suggestion, "*", parts[1], 1)
+			}
+		}
+		*issues = append(*issues, unknownKey(childPath, 0, suggestion))
+	}
+}
+
+// isKnownSection reports whether some known key lies below pattern
+func isKnownSection(pattern string, known map[string]bool) bool {
+	for key := range known {
+		if strings.HasPrefix(key, pattern+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// unknownKey builds the warning for an unknown key with an optional suggestion
+func unknownKey(key string, line int, suggestion string) Issue {
+	issue := Issue{
+		Severity: SeverityWarning,
+		Key:      key,
+		Line:     line,
+		Message:  "unknown key, ignored",
+	}
+	if suggestion != "" {
+		issue.Hint = fmt.Sprintf("Did you mean %s?", suggestion)
+	}
+	return issue
+}
+
+// suggestKey returns the candidate most likely meant by key: a close typo,
+// or else the closest candidate containing all of key's words (so
+// OPENAI_MODEL suggests OPENAI_DEFAULT_MODEL). It returns "" when neither fits.
+func suggestKey(key string, candidates []string) string {
+	lower := strings.ToLower(key)
+	maxDistance := len(key) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+
+	typo, typoDistance := "", maxDistance+1
+	partial, partialDistance := "", -1
+	for _, candidate := range candidates {
+		distance := levenshtein(lower, strings.ToLower(candidate))
+		if distance < typoDistance || (distance == typoDistance && candidate < typo) {
+			typo, typoDistance = candidate, distance
+		}
+		if containsWords(strings.ToLower(candidate), lower) &&
+			(partialDistance < 0 || distance < partialDistance || (distance == partialDistance && candidate < partial)) {
+			partial, partialDistance = candidate, distance
+		}
+	}
+
+	if typo != "" {
+		return typo
+	}
+	return partial
+}
+
+// containsWords reports whether every "_"/"." separated word of key is a
+// word of candidate. Single words are too vague to suggest anything.
+func containsWords(candidate, key string) bool {
+	split := func(r rune) bool { return r == '_' || r == '.' }
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(candidate, split) {
+		words[word] = true
+	}
+
+	keyWords := strings.FieldsFunc(key, split)
+	for _, word := range keyWords {
+		if !words[word] {
+			return false
+		}
+	}
+	return len(keyWords) > 1
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+// checkURL accepts empty values and absolute http(s) URLs
+func checkURL(value string) error {
+	if value == "" {
+		return nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an http(s) URL", value)
+	}
+	return nil
+}
+
+// uniqueNames lowercases names and drops duplicates and empty entries
+func uniqueNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	var unique []string
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		unique = append(unique, name)
+	}
+	return unique
+}
+
+// dedupeIssues keeps one issue per key, e.g. ARCADE_API_KEY required by
+// two arcade toolkits
+func dedupeIssues(issues []Issue) []Issue {
+	seen := make(map[string]bool, len(issues))
+	unique := issues[:0]
+	for _, issue := range issues {
+		id := issue.Message
+		if issue.Key != "" {
+			id = string(issue.Severity) + "|" + issue.Key
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, issue)
+	}
+	return unique
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,201 @@
+// Package corpus keeps ingested documents as embedded chunks for retrieve
+// nodes. Each corpus is one <dir>/<name>.json file holding its chunks and
+// the embedding model they were embedded with.
+package corpus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/not7/core/llm"
+)
+
+const (
+	// DefaultChunkSize is the target chunk length in characters
+	DefaultChunkSize = 1000
+	// DefaultOverlap is the characters a chunk repeats from the one before
+	DefaultOverlap = 200
+	// DefaultTopK is the number of chunks a retrieve node returns by default
+	DefaultTopK = 4
+
+	// embedBatchSize bounds the texts sent in one embeddings request
+	embedBatchSize = 100
+)
+
+// ErrNotFound is returned for a corpus that was never ingested
+var ErrNotFound = errors.New("corpus not found")
+
+// namePattern restricts corpus names to names that are safe as file names
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Corpus is a named set of embedded chunks
+type Corpus struct {
+	Name      string    `json:"name"`
+	Model     string    `json:"model"` // Embedding model of every chunk
+	UpdatedAt time.Time `json:"updated_at"`
+	Chunks    []Chunk   `json:"chunks"`
+}
+
+// Chunk is one piece of an ingested document
+type Chunk struct {
+	Source    string    `json:"source"` // Document the chunk came from
+	Index     int       `json:"index"`  // Position of the chunk in its document
+	Text      string    `json:"text"`
+	Embedding []float64 `json:"embedding"`
+}
+
+// Match is a retrieved chunk with its similarity to the query (-1 to 1)
+type Match struct {
+	Chunk
+	Score float64
+}
+
+// Store keeps corpora in a directory
+type Store struct {
+	dir string
+}
+
+// NewStore returns a store keeping corpora in dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Load reads a corpus, returning ErrNotFound when it does not exist
+func (s *Store) Load(name string) (*Corpus, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s (ingest it with: not7 ingest %s <files>)", ErrNotFound, name, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read corpus: %w", err)
+	}
+
+	var c Corpus
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse corpus %s: %w", name, err)
+	}
+	return &c, nil
+}
+
+// Save writes a corpus, creating the directory if needed
+func (s *Store) Save(c *Corpus) error {
+	path, err := s.path(c.Name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create corpora directory: %w", err)
+	}
+
+	c.UpdatedAt = time.Now().UTC()
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal corpus: %w", err)
+	}
+
+	// Write atomically: write to temp file, then rename
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write corpus: %w", err)
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to rename corpus file: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) path(name string) (string, error) {
+	if !namePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid corpus name %q (use letters, digits, '.', '_' and '-')", name)
+	}
+	return filepath.Join(s.dir, name+".json"), nil
+}
+
+// Add splits text into chunks, embeds them and stores them under source,
+// replacing the chunks of an earlier ingestion of the same source. It
+// returns the number of chunks added.
+func (c *Corpus) Add(ctx context.Context, embedder llm.Embedder, source, text string, size, overlap int) (int, error) {
+	texts := Split(text, size, overlap)
+
+	chunks := make([]Chunk, 0, len(texts))
+	for start := 0; start < len(texts); start += embedBatchSize {
+		end := start + embedBatchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+		vectors, err := embedder.Embed(ctx, texts[start:end])
+		if err != nil {
+			return 0, fmt.Errorf("failed to embed %s: %w", source, err)
+		}
+		for i, v := range vectors {
+			chunks = append(chunks, Chunk{Source: source, Index: start + i, Text: texts[start+i], Embedding: v})
+		}
+	}
+
+	kept := c.Chunks[:0]
+	for _, chunk := range c.Chunks {
+		if chunk.Source != source {
+			kept = append(kept, chunk)
+		}
+	}
+	c.Chunks = append(kept, chunks...)
+	return len(chunks), nil
+}
+
+// Sources returns the documents in the corpus, sorted
+func (c *Corpus) Sources() []string {
+	seen := make(map[string]bool)
+	var sources []string
+	for _, chunk := range c.Chunks {
+		if !seen[chunk.Source] {
+			seen[chunk.Source] = true
+			sources = append(sources, chunk.Source)
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// Search returns the k chunks most similar to query, most similar first.
+// embedder must use the corpus's Model.
+func (c *Corpus) Search(ctx context.Context, embedder llm.Embedder, query string, k int) ([]Match, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("query is empty")
+	}
+	if len(c.Chunks) == 0 {
+		return nil, nil
+	}
+	if k <= 0 {
+		k = DefaultTopK
+	}
+
+	vectors, err := embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	matches := make([]Match, len(c.Chunks))
+	for i, chunk := range c.Chunks {
+		matches[i] = Match{Chunk: chunk, Score: llm.CosineSimilarity(vectors[0], chunk.Embedding)}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
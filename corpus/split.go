@@ -0,0 +1,103 @@
+package corpus
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Split cuts text into chunks of about size characters. Paragraphs are kept
+// whole where they fit; longer ones are cut between words. Each chunk after
+// the first starts with up to overlap characters from the end of the one
+// before, so a sentence split across chunks is still found.
+func Split(text string, size, overlap int) []string {
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+
+	var pieces []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		pieces = append(pieces, splitWords(paragraph, size-overlap)...)
+	}
+
+	var chunks []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+	}
+	for _, piece := range pieces {
+		if current.Len() > 0 && utf8.RuneCountInString(current.String())+2+utf8.RuneCountInString(piece) > size-overlap {
+			flush()
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n\n")
+		}
+		current.WriteString(piece)
+	}
+	flush()
+
+	if overlap == 0 {
+		return chunks
+	}
+	for i := len(chunks) - 1; i > 0; i-- {
+		if tail := tailWords(chunks[i-1], overlap); tail != "" {
+			chunks[i] = tail + " " + chunks[i]
+		}
+	}
+	return chunks
+}
+
+// splitWords cuts text between words into pieces of at most size
+// characters; a single longer word becomes a piece of its own
+func splitWords(text string, size int) []string {
+	if utf8.RuneCountInString(text) <= size {
+		return []string{text}
+	}
+
+	var pieces []string
+	var current strings.Builder
+	n := 0
+	for _, word := range strings.Fields(text) {
+		w := utf8.RuneCountInString(word)
+		if n > 0 && n+1+w > size {
+			pieces = append(pieces, current.String())
+			current.Reset()
+			n = 0
+		}
+		if n > 0 {
+			current.WriteByte(' ')
+			n++
+		}
+		current.WriteString(word)
+		n += w
+	}
+	if n > 0 {
+		pieces = append(pieces, current.String())
+	}
+	return pieces
+}
+
+// tailWords returns the whole words at the end of text that fit in n characters
+func tailWords(text string, n int) string {
+	words := strings.Fields(text)
+	length := 0
+	i := len(words)
+	for i > 0 {
+		w := utf8.RuneCountInString(words[i-1])
+		if length+w+1 > n {
+			break
+		}
+		length += w + 1
+		i--
+	}
+	return strings.Join(words[i:], " ")
+}
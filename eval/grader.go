@@ -0,0 +1,57 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/not7/core/llm"
+	"github.com/not7/core/spec"
+)
+
+const graderPrompt = `You grade the output of an AI agent against a rubric. Judge only what the rubric asks for.
+
+Reply with a JSON object and nothing else:
+{"pass": true or false, "reason": "one sentence explaining the verdict"}`
+
+// NewLLMGrader returns a GradeFunc that asks model, through client, whether
+// an output meets a rubric
+func NewLLMGrader(client *llm.OpenAIClient, model string) GradeFunc {
+	return func(ctx context.Context, rubric, input, output string) (*Verdict, error) {
+		message := fmt.Sprintf("Rubric:\n%s\n\nAgent input:\n%s\n\nAgent output:\n%s", rubric, input, output)
+		completion, err := client.Execute(ctx, &spec.LLMConfig{Provider: "openai", Model: model}, graderPrompt, message)
+		if err != nil {
+			return nil, err
+		}
+
+		verdict, err := parseVerdict(completion.Content)
+		if err != nil {
+			return nil, err
+		}
+		verdict.Cost = completion.Cost
+		return verdict, nil
+	}
+}
+
+// parseVerdict reads the grader's JSON reply, tolerating text or a code
+// fence around it
+func parseVerdict(content string) (*Verdict, error) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("grader did not reply with JSON: %s", truncate(content, 80))
+	}
+
+	var reply struct {
+		Pass   *bool  `json:"pass"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(content[start:end+1]), &reply); err != nil {
+		return nil, fmt.Errorf("grader reply is not valid JSON: %w", err)
+	}
+	if reply.Pass == nil {
+		return nil, fmt.Errorf("grader reply has no pass field")
+	}
+	return &Verdict{Pass: *reply.Pass, Reason: reply.Reason}, nil
+}
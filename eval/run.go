@@ -0,0 +1,224 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Output is the result of one agent run
+type Output struct {
+	ExecutionID string
+	Status      string // completed or failed
+	Output      string
+	Error       string
+	Cost        float64
+	Tokens      int
+	DurationMs  int64 // Execution time reported by the runtime
+}
+
+// RunFunc runs the suite's spec on input with vars
+type RunFunc func(ctx context.Context, input string, vars map[string]string) (*Output, error)
+
+// Verdict is a grader's judgement of an output against a rubric
+type Verdict struct {
+	Pass   bool
+	Reason string
+	Cost   float64
+}
+
+// GradeFunc judges output, produced for input, against rubric
+type GradeFunc func(ctx context.Context, rubric, input, output string) (*Verdict, error)
+
+// AssertionResult is the outcome of one assertion
+type AssertionResult struct {
+	Assertion string `json:"assertion"`
+	Passed    bool   `json:"passed"`
+	Message   string `json:"message,omitempty"` // Why it failed, or the grader's reason
+}
+
+// CaseResult is the outcome of one case
+type CaseResult struct {
+	Name        string            `json:"name"`
+	Input       string            `json:"input,omitempty"`
+	ExecutionID string            `json:"execution_id,omitempty"`
+	Output      string            `json:"output,omitempty"`
+	Passed      bool              `json:"passed"`
+	Error       string            `json:"error,omitempty"` // The run failed, so no assertions were checked
+	Cost        float64           `json:"cost"`            // Agent run cost
+	GradingCost float64           `json:"grading_cost,omitempty"`
+	Tokens      int               `json:"tokens,omitempty"`
+	DurationMs  int64             `json:"duration_ms"`
+	Assertions  []AssertionResult `json:"assertions,omitempty"`
+}
+
+// Report is the outcome of a suite run
+type Report struct {
+	Suite       string       `json:"suite,omitempty"`
+	Spec        string       `json:"spec"`
+	Passed      int          `json:"passed"`
+	Failed      int          `json:"failed"`
+	Cost        float64      `json:"cost"`         // Agent runs
+	GradingCost float64      `json:"grading_cost"` // Rubric grading
+	StartedAt   time.Time    `json:"started_at"`
+	EndedAt     time.Time    `json:"ended_at"`
+	Cases       []CaseResult `json:"cases"`
+}
+
+// Run runs every case with at most parallel in flight and checks its
+// assertions. grade may be nil for suites without rubrics. onCase, if set,
+// sees each case as it finishes. Cases keep the suite's order in the report.
+func Run(ctx context.Context, s *Suite, parallel int, run RunFunc, grade GradeFunc, onCase func(CaseResult)) *Report {
+	if parallel < 1 {
+		parallel = 1
+	}
+	report := &Report{Suite: s.Name, Spec: s.Spec, StartedAt: time.Now(), Cases: make([]CaseResult, len(s.Cases))}
+
+	var mu sync.Mutex
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i := range s.Cases {
+		// Take a slot before starting, so cases start in the suite's order
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result := runCase(ctx, s, &s.Cases[i], run, grade)
+			mu.Lock()
+			report.Cases[i] = result
+			if onCase != nil {
+				onCase(result)
+			}
+			mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+
+	for _, c := range report.Cases {
+		if c.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+		report.Cost += c.Cost
+		report.GradingCost += c.GradingCost
+	}
+	report.EndedAt = time.Now()
+	return report
+}
+
+func runCase(ctx context.Context, s *Suite, c *Case, run RunFunc, grade GradeFunc) (result CaseResult) {
+	result = CaseResult{Name: c.Name, Input: c.Input}
+	start := time.Now()
+	defer func() { result.DurationMs = time.Since(start).Milliseconds() }()
+
+	out, err := run(ctx, c.Input, s.vars(c))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.ExecutionID = out.ExecutionID
+	result.Output = out.Output
+	result.Cost = out.Cost
+	result.Tokens = out.Tokens
+	if out.Status != "completed" {
+		result.Error = fmt.Sprintf("execution %s: %s", out.Status, out.Error)
+		return result
+	}
+
+	result.Passed = true
+	for i := range c.Assert {
+		a := &c.Assert[i]
+		check := a.check(ctx, c.Input, out.Output, grade)
+		result.GradingCost += check.cost
+		result.Assertions = append(result.Assertions, AssertionResult{Assertion: a.String(), Passed: check.passed, Message: check.message})
+		if !check.passed {
+			result.Passed = false
+		}
+	}
+	return result
+}
+
+type checkResult struct {
+	passed  bool
+	message string
+	cost    float64
+}
+
+// check evaluates the assertion against output
+func (a *Assertion) check(ctx context.Context, input, output string, grade GradeFunc) checkResult {
+	contains := func(s, sub string) bool {
+		if a.IgnoreCase {
+			return strings.Contains(strings.ToLower(s), strings.ToLower(sub))
+		}
+		return strings.Contains(s, sub)
+	}
+
+	switch {
+	case a.Contains != "":
+		if contains(output, a.Contains) {
+			return checkResult{passed: true}
+		}
+		return checkResult{message: "output does not contain it"}
+
+	case a.NotContains != "":
+		if !contains(output, a.NotContains) {
+			return checkResult{passed: true}
+		}
+		return checkResult{message: "output contains it"}
+
+	case a.Regex != "":
+		if a.regex.MatchString(output) {
+			return checkResult{passed: true}
+		}
+		return checkResult{message: "output does not match"}
+
+	case a.JSONPath != "":
+		var doc interface{}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &doc); err != nil {
+			return checkResult{message: fmt.Sprintf("output is not JSON: %v", err)}
+		}
+		value, found := a.path.Lookup(doc)
+		if !found {
+			return checkResult{message: "no value at path"}
+		}
+		if a.Equals == nil {
+			return checkResult{passed: true}
+		}
+		if jsonEqual(value, a.Equals) {
+			return checkResult{passed: true}
+		}
+		got, _ := json.Marshal(value)
+		return checkResult{message: fmt.Sprintf("got %s", got)}
+
+	default:
+		if grade == nil {
+			return checkResult{message: "no grader configured"}
+		}
+		verdict, err := grade(ctx, a.Rubric, input, output)
+		if err != nil {
+			return checkResult{message: fmt.Sprintf("grading failed: %v", err)}
+		}
+		return checkResult{passed: verdict.Pass, message: verdict.Reason, cost: verdict.Cost}
+	}
+}
+
+// jsonEqual compares a decoded JSON value with an expected value from the
+// suite, which YAML may have decoded with different Go types (e.g. int)
+func jsonEqual(value, expected interface{}) bool {
+	data, err := json.Marshal(expected)
+	if err != nil {
+		return false
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(value, normalized)
+}
@@ -0,0 +1,199 @@
+// Package eval runs an agent spec against a suite of test cases and checks
+// each output with assertions, so prompt changes can be regression-tested.
+package eval
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/not7/core/internal/jsonpath"
+	"gopkg.in/yaml.v3"
+)
+
+// Suite is a set of test cases for one agent spec
+type Suite struct {
+	Name   string            `yaml:"name"`
+	Spec   string            `yaml:"spec"`   // Agent spec file, relative to the suite file
+	Vars   map[string]string `yaml:"vars"`   // Variables for every case
+	Grader *GraderConfig     `yaml:"grader"` // Model for rubric assertions
+	Cases  []Case            `yaml:"cases"`
+
+	dir string
+}
+
+// GraderConfig configures the model that judges rubric assertions
+type GraderConfig struct {
+	Model string `yaml:"model"` // Default: OPENAI_DEFAULT_MODEL
+}
+
+// Case is one input and the assertions its output must satisfy
+type Case struct {
+	Name   string            `yaml:"name"`
+	Input  string            `yaml:"input"`
+	Vars   map[string]string `yaml:"vars"` // Override the suite's vars
+	Assert []Assertion       `yaml:"assert"`
+}
+
+// Assertion checks an output. Exactly one of Contains, NotContains, Regex,
+// JSONPath and Rubric is set.
+type Assertion struct {
+	Contains    string      `yaml:"contains"`
+	NotContains string      `yaml:"not_contains"`
+	Regex       string      `yaml:"regex"`
+	JSONPath    string      `yaml:"json_path"` // Output must be JSON with a value at the path
+	Equals      interface{} `yaml:"equals"`    // Expected value at json_path
+	Rubric      string      `yaml:"rubric"`    // Criteria a grader model judges the output by
+
+	IgnoreCase bool `yaml:"ignore_case"` // For contains and not_contains
+
+	regex *regexp.Regexp
+	path  *jsonpath.Path
+}
+
+// Load reads a suite file (YAML or JSON) and validates it
+func Load(path string) (*Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read suite: %w", err)
+	}
+
+	var s Suite
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("failed to parse suite: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid suite: %w", err)
+	}
+	s.dir = filepath.Dir(path)
+	return &s, nil
+}
+
+// Validate checks a suite and compiles its regexes and JSON paths
+func (s *Suite) Validate() error {
+	if s.Spec == "" {
+		return fmt.Errorf("spec is required")
+	}
+	if len(s.Cases) == 0 {
+		return fmt.Errorf("at least one case is required")
+	}
+
+	names := make(map[string]bool)
+	for i := range s.Cases {
+		c := &s.Cases[i]
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("case-%d", i+1)
+		}
+		if names[c.Name] {
+			return fmt.Errorf("duplicate case: %s", c.Name)
+		}
+		names[c.Name] = true
+
+		if len(c.Assert) == 0 {
+			return fmt.Errorf("case %s: at least one assertion is required", c.Name)
+		}
+		for j := range c.Assert {
+			if err := c.Assert[j].compile(); err != nil {
+				return fmt.Errorf("case %s: assertion %d: %w", c.Name, j+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+// SpecPath returns the spec file, resolved against the suite's directory
+func (s *Suite) SpecPath() string {
+	if filepath.IsAbs(s.Spec) || s.dir == "" {
+		return s.Spec
+	}
+	return filepath.Join(s.dir, s.Spec)
+}
+
+// HasRubrics reports whether any assertion needs a grader model
+func (s *Suite) HasRubrics() bool {
+	for _, c := range s.Cases {
+		for _, a := range c.Assert {
+			if a.Rubric != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// vars merges the suite's vars with a case's
+func (s *Suite) vars(c *Case) map[string]string {
+	if len(s.Vars) == 0 && len(c.Vars) == 0 {
+		return nil
+	}
+	vars := make(map[string]string, len(s.Vars)+len(c.Vars))
+	for k, v := range s.Vars {
+		vars[k] = v
+	}
+	for k, v := range c.Vars {
+		vars[k] = v
+	}
+	return vars
+}
+
+func (a *Assertion) compile() error {
+	set := 0
+	for _, field := range []string{a.Contains, a.NotContains, a.Regex, a.JSONPath, a.Rubric} {
+		if field != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("set exactly one of contains, not_contains, regex, json_path and rubric")
+	}
+	if a.Equals != nil && a.JSONPath == "" {
+		return fmt.Errorf("equals is only used with json_path")
+	}
+	if a.IgnoreCase && a.Contains == "" && a.NotContains == "" {
+		return fmt.Errorf("ignore_case is only used with contains and not_contains")
+	}
+
+	var err error
+	if a.Regex != "" {
+		if a.regex, err = regexp.Compile(a.Regex); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	}
+	if a.JSONPath != "" {
+		if a.path, err = jsonpath.Compile(a.JSONPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String describes the assertion in reports
+func (a *Assertion) String() string {
+	switch {
+	case a.Contains != "":
+		return fmt.Sprintf("contains %q", a.Contains)
+	case a.NotContains != "":
+		return fmt.Sprintf("not_contains %q", a.NotContains)
+	case a.Regex != "":
+		return fmt.Sprintf("regex %s", a.Regex)
+	case a.JSONPath != "" && a.Equals != nil:
+		return fmt.Sprintf("json_path %s == %v", a.JSONPath, a.Equals)
+	case a.JSONPath != "":
+		return fmt.Sprintf("json_path %s", a.JSONPath)
+	default:
+		return fmt.Sprintf("rubric %q", truncate(a.Rubric, 60))
+	}
+}
+
+func truncate(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) <= max {
+		return s
+	}
+	return s[:max-3] + "..."
+}
@@ -0,0 +1,195 @@
+package execution
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// unfinishedCacheTTL is how long the cached state of an unfinished
+// execution is trusted. Other processes sharing the executions directory,
+// such as queue workers, save executions without going through this cache.
+const unfinishedCacheTTL = time.Second
+
+// CachedStorage keeps recently read executions and their summaries in
+// memory in front of another Storage, dropping the least recently used
+// beyond its size. Saving or deleting an execution through it drops the
+// execution's entry; finished executions are otherwise kept until evicted.
+type CachedStorage struct {
+	Storage
+
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element // values are *cacheEntry
+	order   *list.List               // most recently used first
+	version uint64                   // bumped by every invalidation
+}
+
+// cacheEntry holds what has been read of one execution
+type cacheEntry struct {
+	id          string
+	exec        *Execution
+	execExpires time.Time // zero when the execution had finished
+	info        *ExecutionInfo
+	infoExpires time.Time
+}
+
+// NewCachedStorage returns storage with a cache of size executions in front
+// of it, or storage itself when size is not positive
+func NewCachedStorage(storage Storage, size int) Storage {
+	if size <= 0 {
+		return storage
+	}
+	return &CachedStorage{
+		Storage: storage,
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Save persists an execution and drops its cached state
+func (c *CachedStorage) Save(ctx context.Context, exec *Execution) error {
+	defer c.invalidate(exec.ID)
+	return c.Storage.Save(ctx, exec)
+}
+
+// SaveOutput writes the final output and drops the cached execution
+func (c *CachedStorage) SaveOutput(ctx context.Context, id string, output string) error {
+	defer c.invalidate(id)
+	return c.Storage.SaveOutput(ctx, id, output)
+}
+
+// SaveTrace writes the trace executions are loaded from and drops the
+// cached execution
+func (c *CachedStorage) SaveTrace(ctx context.Context, id string, trace interface{}) error {
+	defer c.invalidate(id)
+	return c.Storage.SaveTrace(ctx, id, trace)
+}
+
+// Delete removes an execution and its cached state
+func (c *CachedStorage) Delete(ctx context.Context, id string) error {
+	defer c.invalidate(id)
+	return c.Storage.Delete(ctx, id)
+}
+
+// Load returns a copy of the cached execution, reading it on a miss
+func (c *CachedStorage) Load(ctx context.Context, id string) (*Execution, error) {
+	c.mu.Lock()
+	if entry := c.lookup(id); entry != nil && entry.exec != nil && fresh(entry.execExpires) {
+		exec := copyExecution(entry.exec)
+		c.mu.Unlock()
+		return exec, nil
+	}
+	version := c.version
+	c.mu.Unlock()
+
+	exec, err := c.Storage.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry := c.store(id, version); entry != nil {
+		entry.exec = copyExecution(exec)
+		entry.execExpires = expiry(exec.Status)
+	}
+	return exec, nil
+}
+
+// LoadInfo returns a copy of the cached summary, reading it on a miss
+func (c *CachedStorage) LoadInfo(ctx context.Context, id string) (*ExecutionInfo, error) {
+	c.mu.Lock()
+	if entry := c.lookup(id); entry != nil && entry.info != nil && fresh(entry.infoExpires) {
+		info := *entry.info
+		c.mu.Unlock()
+		return &info, nil
+	}
+	version := c.version
+	c.mu.Unlock()
+
+	info, err := c.Storage.LoadInfo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry := c.store(id, version); entry != nil {
+		cached := *info
+		entry.info = &cached
+		entry.infoExpires = expiry(info.Status)
+	}
+	return info, nil
+}
+
+// lookup returns the entry of an execution, marking it recently used
+func (c *CachedStorage) lookup(id string) *cacheEntry {
+	element, ok := c.entries[id]
+	if !ok {
+		return nil
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*cacheEntry)
+}
+
+// store returns the entry to fill with what was read of an execution,
+// adding one and evicting the least recently used if needed. It returns
+// nil when an invalidation happened since version, as the read may predate
+// it.
+func (c *CachedStorage) store(id string, version uint64) *cacheEntry {
+	if c.version != version {
+		return nil
+	}
+	if entry := c.lookup(id); entry != nil {
+		return entry
+	}
+
+	entry := &cacheEntry{id: id}
+	c.entries[id] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).id)
+	}
+	return entry
+}
+
+// invalidate drops the entry of an execution
+func (c *CachedStorage) invalidate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.version++
+	if element, ok := c.entries[id]; ok {
+		c.order.Remove(element)
+		delete(c.entries, id)
+	}
+}
+
+// expiry returns when state read with the given status goes stale: never
+// for finished executions, shortly for the others
+func expiry(status Status) time.Time {
+	if status.IsTerminal() {
+		return time.Time{}
+	}
+	return time.Now().Add(unfinishedCacheTTL)
+}
+
+// fresh reports whether state with the given expiry is still good
+func fresh(expires time.Time) bool {
+	return expires.IsZero() || time.Now().Before(expires)
+}
+
+// copyExecution copies an execution and its result, so callers changing
+// them do not change the cache
+func copyExecution(exec *Execution) *Execution {
+	copied := *exec
+	if exec.Result != nil {
+		result := *exec.Result
+		copied.Result = &result
+	}
+	return &copied
+}
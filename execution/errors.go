@@ -1,11 +1,18 @@
 package execution
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/not7/core/policy"
+)
 
 var (
 	// ErrExecutionNotFound is returned when an execution ID doesn't exist
 	ErrExecutionNotFound = errors.New("execution not found")
 
+	// ErrArtifactNotFound is returned when an execution has no artifact of that name
+	ErrArtifactNotFound = errors.New("artifact not found")
+
 	// ErrExecutionAlreadyRunning is returned when trying to start an already-running execution
 	ErrExecutionAlreadyRunning = errors.New("execution already running")
 
@@ -15,6 +22,12 @@ var (
 	// ErrInvalidSpec is returned when the agent specification is invalid
 	ErrInvalidSpec = errors.New("invalid agent specification")
 
+	// ErrInvalidInput is returned when run input does not match the spec's input_schema
+	ErrInvalidInput = errors.New("invalid agent input")
+
+	// ErrPolicyViolation is wrapped by errors for agents that do something the configured policy denies
+	ErrPolicyViolation = policy.ErrDenied
+
 	// ErrStorageUnavailable is returned when storage operations fail
 	ErrStorageUnavailable = errors.New("storage unavailable")
 )
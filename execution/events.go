@@ -0,0 +1,117 @@
+package execution
+
+import (
+	"sync"
+	"time"
+
+	"github.com/not7/core/executor"
+)
+
+const (
+	// eventBufferSize is the per-subscriber buffer; slow subscribers drop events beyond it
+	eventBufferSize = 64
+
+	// eventHistorySize caps how many past events are replayed to late subscribers
+	eventHistorySize = 256
+)
+
+// eventBroker fans out execution events to subscribers keyed by execution ID
+type eventBroker struct {
+	mu      sync.Mutex
+	subs    map[string]map[chan executor.Event]struct{}
+	history map[string][]executor.Event // Events of in-flight executions, for replay
+}
+
+// newEventBroker creates an empty broker
+func newEventBroker() *eventBroker {
+	return &eventBroker{
+		subs:    make(map[string]map[chan executor.Event]struct{}),
+		history: make(map[string][]executor.Event),
+	}
+}
+
+// subscribe registers a listener for an execution's events
+// Events already emitted by an in-flight execution are replayed first
+// The returned function unsubscribes and closes the channel
+func (b *eventBroker) subscribe(execID string) (<-chan executor.Event, func()) {
+	b.mu.Lock()
+	past := b.history[execID]
+	ch := make(chan executor.Event, eventBufferSize+len(past))
+	for _, event := range past {
+		ch <- event
+	}
+
+	if b.subs[execID] == nil {
+		b.subs[execID] = make(map[chan executor.Event]struct{})
+	}
+	b.subs[execID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if _, ok := b.subs[execID][ch]; ok {
+				delete(b.subs[execID], ch)
+				close(ch)
+			}
+			if len(b.subs[execID]) == 0 {
+				delete(b.subs, execID)
+			}
+		})
+	}
+}
+
+// publish delivers an event to every subscriber of its execution
+// Terminal events close all subscriptions for that execution
+func (b *eventBroker) publish(event executor.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !event.IsTerminal() {
+		past := append(b.history[event.ExecutionID], event)
+		if len(past) > eventHistorySize {
+			past = past[len(past)-eventHistorySize:]
+		}
+		b.history[event.ExecutionID] = past
+	}
+
+	for ch := range b.subs[event.ExecutionID] {
+		select {
+		case ch <- event:
+		default:
+			// Subscriber is not keeping up; drop rather than block the executor
+		}
+	}
+
+	if event.IsTerminal() {
+		for ch := range b.subs[event.ExecutionID] {
+			close(ch)
+		}
+		delete(b.subs, event.ExecutionID)
+		delete(b.history, event.ExecutionID)
+	}
+}
+
+// FinishedEvent builds the terminal event describing a finished execution
+func (e *Execution) FinishedEvent() executor.Event {
+	event := executor.Event{
+		Type:        executor.EventExecutionCompleted,
+		ExecutionID: e.ID,
+		RequestID:   e.RequestID,
+		Timestamp:   time.Now(),
+	}
+	if e.EndedAt != nil {
+		event.Timestamp = *e.EndedAt
+	}
+	if e.Result != nil {
+		event.Cost = e.Result.TotalCost
+		event.DurationMs = e.Result.DurationMs
+		event.Error = e.Result.Error
+	}
+	if e.Status != StatusCompleted {
+		event.Type = executor.EventExecutionFailed
+	}
+	return event
+}
@@ -0,0 +1,106 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// logPollInterval is how often FollowLogs checks the log file for new output
+const logPollInterval = 250 * time.Millisecond
+
+// GetLogs returns the execution log written by the agent logger. Returns an
+// empty string when the execution exists but has no log file yet.
+func (m *Manager) GetLogs(ctx context.Context, id string) (string, error) {
+	if _, err := m.GetExecution(ctx, id); err != nil {
+		return "", err
+	}
+
+	path, err := m.logPath(id)
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read log file: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// FollowLogs copies the execution log to w as it is written, like tail -f.
+// It returns once the execution is no longer running and the log has been
+// copied in full, or when ctx is cancelled.
+func (m *Manager) FollowLogs(ctx context.Context, id string, w io.Writer) error {
+	if _, err := m.GetExecution(ctx, id); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(logPollInterval)
+	defer ticker.Stop()
+
+	var offset int64
+	for {
+		// Check before reading: the logger is closed before the execution
+		// leaves the active set, so the read below sees the final line
+		_, running := m.activeExecutions.Load(id)
+		if !running {
+			// Queued here and running on a worker
+			_, running = m.queued.Load(id)
+		}
+
+		n, err := m.copyLogs(id, offset, w)
+		offset += n
+		if err != nil {
+			return err
+		}
+		if !running {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// copyLogs writes the log file contents from offset onwards to w
+func (m *Manager) copyLogs(id string, offset int64, w io.Writer) (int64, error) {
+	path, err := m.logPath(id)
+	if err != nil || path == "" {
+		return 0, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read log file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to read log file: %w", err)
+	}
+	return io.Copy(w, f)
+}
+
+// logPath returns the newest log file of an execution, or "" if none exists.
+// Log files are named agent-{timestamp}-{executionID}.log
+func (m *Manager) logPath(id string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(m.logDir, "agent-*-"+id+".log"))
+	if err != nil {
+		return "", fmt.Errorf("failed to find log file: %w", err)
+	}
+	if len(matches) == 0 {
+		return "", nil
+	}
+
+	sort.Strings(matches)
+	return matches[len(matches)-1], nil
+}
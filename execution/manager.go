@@ -2,32 +2,70 @@ package execution
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"sync"
 	"time"
 
+	"github.com/not7/core/config"
 	"github.com/not7/core/executor"
+	"github.com/not7/core/llm"
 	"github.com/not7/core/logger"
+	"github.com/not7/core/queue"
 	"github.com/not7/core/spec"
+	"github.com/not7/core/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/not7/core/execution")
+
 // Manager orchestrates agent executions with thread-safe operations
 type Manager struct {
 	storage Storage
 	logDir  string
+	cfg     *config.Config
 
 	// Track active executions for concurrent safety
 	activeExecutions sync.Map // map[string]*Execution
 
+	// Fan out progress events to live subscribers
+	events *eventBroker
+
+	// Token and cost totals of finished executions
+	metrics *usageMetrics
+
+	// Async executions wait here for a worker when set
+	queue queue.Queue
+
+	// Executions this manager queued and that have not finished, with
+	// their OnFinish callbacks (possibly nil)
+	queued sync.Map // map[string]func(*Execution)
+
+	// Queued executions this manager runs for another process, whose
+	// events are relayed back to it
+	relayed sync.Map // map[string]bool
+
+	// Sends the calls of BatchAPI executions, created on first use
+	batcher     *llm.Batcher
+	batcherErr  error
+	batcherOnce sync.Once
+
 	// Protect state mutations
 	mu sync.RWMutex
 }
 
-// NewManager creates a new execution manager
-func NewManager(storage Storage, logDir string) *Manager {
+// NewManager creates a new execution manager. cfg supplies the LLM and
+// tool provider settings of every execution it runs.
+func NewManager(storage Storage, logDir string, cfg *config.Config) *Manager {
 	return &Manager{
 		storage: storage,
 		logDir:  logDir,
+		cfg:     cfg,
+		events:  newEventBroker(),
+		metrics: newUsageMetrics(),
 	}
 }
 
@@ -35,30 +73,69 @@ func NewManager(storage Storage, logDir string) *Manager {
 // For async execution, it returns immediately with execution ID
 // For sync execution, it blocks until completion
 func (m *Manager) Execute(ctx context.Context, agentSpec *spec.AgentSpec, opts Options) (*Execution, error) {
-	// Validate spec
+	// Load referenced prompts and render variables, then validate the result
+	if err := agentSpec.ResolvePrompts(m.promptsDir()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+	}
+	if err := agentSpec.Render(opts.Vars); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+	}
 	if err := spec.ValidateSpec(agentSpec); err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrInvalidSpec, err)
 	}
+	input, err := spec.ApplyInputDefaults(agentSpec, opts.Input)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	opts.Input = input
+	if err := spec.ValidateInput(agentSpec, opts.Input); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	if err := validateAttachments(opts.Attachments); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	if opts.BatchAPI && !opts.Async {
+		return nil, fmt.Errorf("%w: Batch API executions must be async", ErrInvalidInput)
+	}
+	if err := executor.CheckPolicy(agentSpec, m.cfg, opts.Input); err != nil {
+		return nil, err
+	}
 
 	// Generate unique execution ID
 	execID := m.generateExecutionID(agentSpec)
 
 	// Create execution instance
 	exec := NewExecution(execID, agentSpec)
+	exec.Input = opts.Input
+	exec.Tags = opts.Tags
+	exec.RequestID = opts.RequestID
+	if exec.RequestID == "" {
+		exec.RequestID = tracing.RequestIDFromContext(ctx)
+	}
+	if exec.RequestID == "" {
+		exec.RequestID = tracing.NewRequestID()
+	}
+	ctx = tracing.WithRequestID(ctx, exec.RequestID)
 
 	// Save initial state
 	if err := m.storage.Save(ctx, exec); err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrStorageUnavailable, err)
 	}
 
+	// With a queue, async executions stay pending until a worker takes them
+	if opts.Async && m.queue != nil {
+		return exec, m.enqueue(ctx, exec, opts)
+	}
+
 	// Track as active
 	if _, loaded := m.activeExecutions.LoadOrStore(execID, exec); loaded {
 		return nil, ErrExecutionAlreadyRunning
 	}
 
 	if opts.Async {
-		// Execute asynchronously
-		go m.executeAsync(context.Background(), exec, opts)
+		// Execute asynchronously, detached from the caller's cancellation
+		// but keeping its request ID and trace context
+		go m.executeAsync(context.WithoutCancel(ctx), exec, opts)
 		return exec, nil
 	}
 
@@ -69,46 +146,124 @@ func (m *Manager) Execute(ctx context.Context, agentSpec *spec.AgentSpec, opts O
 // executeSync performs synchronous execution with optional trace streaming
 func (m *Manager) executeSync(ctx context.Context, exec *Execution, opts Options) (*Execution, error) {
 	defer m.activeExecutions.Delete(exec.ID)
+	if opts.OnFinish != nil {
+		defer opts.OnFinish(exec)
+	}
 
 	// Mark as started
 	exec.MarkStarted()
 	if err := m.storage.Save(ctx, exec); err != nil {
+		m.publishFinished(exec, err)
 		return nil, err
 	}
+	m.publish(executor.Event{Type: executor.EventExecutionStarted, ExecutionID: exec.ID, RequestID: exec.RequestID, Message: exec.Spec.Goal, Timestamp: time.Now()})
 
 	// Create logger for this execution
 	log, err := logger.NewFileLogger(m.logDir, exec.ID)
 	if err != nil {
 		exec.MarkFailed(fmt.Errorf("failed to create logger: %w", err))
 		m.storage.Save(ctx, exec)
+		m.publishFinished(exec, err)
 		return exec, err
 	}
 	defer log.Close()
+	log.SetRequestID(exec.RequestID)
+	log = log.With("execution_id", exec.ID)
 
 	log.Info("Starting execution: %s", exec.Spec.Goal)
 	log.Info("Execution ID: %s", exec.ID)
 
 	// Create and configure executor
-	execEngine, err := executor.NewExecutorWithLogger(exec.Spec, log)
+	execEngine, err := executor.NewExecutorWithLogger(exec.Spec, m.cfg, log)
 	if err != nil {
 		exec.MarkFailed(fmt.Errorf("failed to create executor: %w", err))
 		m.storage.Save(ctx, exec)
+		m.publishFinished(exec, err)
 		return exec, err
 	}
 
+	if opts.BatchAPI {
+		batcher, err := m.batchClient()
+		if err != nil {
+			exec.MarkFailed(err)
+			m.storage.Save(ctx, exec)
+			m.publishFinished(exec, err)
+			return exec, err
+		}
+		execEngine.SetBatcher(batcher)
+	}
+
+	// Forward executor progress to event subscribers, and keep the stored
+	// status in step while calls wait for a batch
+	execEngine.SetEventHandler(func(event executor.Event) {
+		event.ExecutionID = exec.ID
+		event.RequestID = exec.RequestID
+		switch event.Type {
+		case executor.EventNodeSuspended:
+			exec.MarkSuspended()
+			m.storage.Save(ctx, exec)
+		case executor.EventNodeResumed:
+			exec.MarkResumed()
+			m.storage.Save(ctx, exec)
+		}
+		m.publish(event)
+	})
+
+	// Store the files sent with the run and hand them to its nodes
+	for _, attachment := range opts.Attachments {
+		if err := m.storage.SaveAttachment(ctx, exec.ID, attachment.Name, attachment.Data); err != nil {
+			log.Error("Failed to save attachment %s: %v", attachment.Name, err)
+		}
+	}
+	if err := execEngine.SetAttachments(opts.Attachments); err != nil {
+		exec.MarkFailed(err)
+		m.storage.Save(ctx, exec)
+		m.publishFinished(exec, err)
+		return exec, err
+	}
+
+	// Keep the files nodes and tools produce with the execution
+	execEngine.SetArtifactSaver(func(ctx context.Context, name string, data []byte) error {
+		return m.storage.SaveArtifact(ctx, exec.ID, name, data)
+	})
+
+	// Trace the run as the root of its node, LLM and tool spans
+	spanCtx, span := tracer.Start(ctx, "execution", trace.WithAttributes(
+		tracing.ExecutionIDKey.String(exec.ID),
+		tracing.RequestIDKey.String(exec.RequestID),
+		tracing.AgentIDKey.String(exec.Spec.ID),
+		tracing.AgentGoalKey.String(exec.Spec.Goal),
+	))
+
 	// Execute with timeout if specified
-	execCtx := ctx
+	execCtx := spanCtx
 	if opts.Timeout > 0 {
 		var cancel context.CancelFunc
-		execCtx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		execCtx, cancel = context.WithTimeout(spanCtx, opts.Timeout)
 		defer cancel()
 	}
 
 	// Execute agent
 	startTime := time.Now()
-	output, execErr := m.runWithContext(execCtx, execEngine)
+	output, execErr := m.runWithContext(execCtx, execEngine, exec.Input)
 	duration := time.Since(startTime)
 
+	// The worker that took over a queued execution saves its result
+	if errors.Is(context.Cause(execCtx), queue.ErrLeaseLost) {
+		tracing.End(span, execErr)
+		log.Error("Execution stopped: lease lost to another worker")
+		return exec, execErr
+	}
+	if !errors.Is(execErr, ErrExecutionCancelled) {
+		metadata := execEngine.GetMetadata()
+		span.SetAttributes(
+			tracing.CostKey.Float64(metadata.TotalCost),
+			tracing.PromptTokensKey.Int(metadata.PromptTokens),
+			tracing.CompletionTokensKey.Int(metadata.CompletionTokens),
+		)
+	}
+	tracing.End(span, execErr)
+
 	// Build result
 	result := &Result{
 		Output:     output,
@@ -119,6 +274,15 @@ func (m *Manager) executeSync(ctx context.Context, exec *Execution, opts Options
 		result.Error = execErr.Error()
 		exec.MarkFailed(execErr)
 		log.Error("Execution failed: %v", execErr)
+
+		// Keep the usage of the nodes that ran. A cancelled executor may
+		// still be running, so its metadata is not safe to read.
+		if !errors.Is(execErr, ErrExecutionCancelled) {
+			metadata := execEngine.GetMetadata()
+			exec.Result.Metadata = metadata
+			exec.Result.TotalCost = metadata.TotalCost
+			exec.Result.DurationMs = result.DurationMs
+		}
 	} else {
 		// Get metadata from executor
 		metadata := execEngine.GetMetadata()
@@ -126,7 +290,12 @@ func (m *Manager) executeSync(ctx context.Context, exec *Execution, opts Options
 		result.TotalCost = metadata.TotalCost
 
 		exec.MarkCompleted(result)
-		log.Info("Execution completed: duration=%dms, cost=$%.4f", result.DurationMs, result.TotalCost)
+		log.LogAttrs(logger.INFO, "Execution completed",
+			slog.Int64("duration_ms", result.DurationMs),
+			slog.Float64("cost_usd", result.TotalCost),
+			slog.Int("prompt_tokens", metadata.PromptTokens),
+			slog.Int("completion_tokens", metadata.CompletionTokens),
+		)
 	}
 
 	// Save final state
@@ -146,20 +315,88 @@ func (m *Manager) executeSync(ctx context.Context, exec *Execution, opts Options
 		log.Error("Failed to save trace: %v", err)
 	}
 
+	m.publishFinished(exec, execErr)
+
+	// Cancellation is requested by the caller, so only real failures are reported
+	if execErr != nil && !errors.Is(execErr, ErrExecutionCancelled) {
+		if err := reportFailure(ctx, exec, execErr); err != nil {
+			log.Error("Failed to report execution failure: %v", err)
+		}
+	}
+	if !errors.Is(execErr, ErrExecutionCancelled) {
+		if err := announce(ctx, exec); err != nil {
+			log.Error("Failed to send notifications: %v", err)
+		}
+	}
+
 	return exec, execErr
 }
 
+// batchClient returns the Batcher shared by Batch API executions, so calls
+// from executions running at the same time share batches
+func (m *Manager) batchClient() (*llm.Batcher, error) {
+	m.batcherOnce.Do(func() {
+		client, err := llm.NewOpenAIClient(m.cfg.OpenAI)
+		if err != nil {
+			m.batcherErr = fmt.Errorf("failed to create LLM client: %w", err)
+			return
+		}
+		m.batcher = client.NewBatcher()
+	})
+	return m.batcher, m.batcherErr
+}
+
+// validateAttachments checks that attachment names are usable as file names
+// and unique
+func validateAttachments(attachments []executor.Attachment) error {
+	seen := make(map[string]bool, len(attachments))
+	for _, attachment := range attachments {
+		if err := spec.ValidateArtifactName(attachment.Name); err != nil {
+			return fmt.Errorf("attachment: %w", err)
+		}
+		if seen[attachment.Name] {
+			return fmt.Errorf("duplicate attachment %s", attachment.Name)
+		}
+		seen[attachment.Name] = true
+	}
+	return nil
+}
+
+// publishFinished records a finished execution in the metrics and emits
+// its terminal event
+func (m *Manager) publishFinished(exec *Execution, err error) {
+	m.metrics.record(exec)
+
+	event := exec.FinishedEvent()
+	if err != nil {
+		event.Type = executor.EventExecutionFailed
+		event.Error = err.Error()
+	}
+	m.publish(event)
+}
+
+// Metrics returns the execution counts and LLM usage of this manager
+func (m *Manager) Metrics() Metrics {
+	return m.metrics.snapshot()
+}
+
+// Subscribe returns a channel of progress events for an execution
+// The channel is closed after the terminal event or when the returned cancel function is called
+func (m *Manager) Subscribe(execID string) (<-chan executor.Event, func()) {
+	return m.events.subscribe(execID)
+}
+
 // executeAsync performs asynchronous execution in a goroutine
 func (m *Manager) executeAsync(ctx context.Context, exec *Execution, opts Options) {
 	defer m.activeExecutions.Delete(exec.ID)
 
 	// Execute synchronously within the goroutine
-	// We use a background context since the caller has already returned
+	// The context is detached from the caller, which has already returned
 	m.executeSync(ctx, exec, opts)
 }
 
 // runWithContext executes the agent with context support
-func (m *Manager) runWithContext(ctx context.Context, exec *executor.Executor) (string, error) {
+func (m *Manager) runWithContext(ctx context.Context, exec *executor.Executor, input string) (string, error) {
 	// Create a channel to receive the result
 	type execResult struct {
 		output string
@@ -169,7 +406,15 @@ func (m *Manager) runWithContext(ctx context.Context, exec *executor.Executor) (
 
 	// Run executor in goroutine
 	go func() {
-		output, err := exec.Execute("")
+		// Nodes recover their own panics; this catches the rest, which
+		// would otherwise take down the server
+		defer func() {
+			if r := recover(); r != nil {
+				resultCh <- execResult{err: executor.NewPanicError(r)}
+			}
+		}()
+
+		output, err := exec.ExecuteContext(ctx, input)
 		resultCh <- execResult{output: output, err: err}
 	}()
 
@@ -193,9 +438,52 @@ func (m *Manager) GetExecution(ctx context.Context, id string) (*Execution, erro
 	return m.storage.Load(ctx, id)
 }
 
-// ListExecutions returns all executions
-func (m *Manager) ListExecutions(ctx context.Context) ([]*ExecutionInfo, error) {
-	return m.storage.List(ctx)
+// GetInfo returns the summary of an execution without loading its trace
+func (m *Manager) GetInfo(ctx context.Context, id string) (*ExecutionInfo, error) {
+	if exec, ok := m.activeExecutions.Load(id); ok {
+		return exec.(*Execution).Info(), nil
+	}
+	return m.storage.LoadInfo(ctx, id)
+}
+
+// GetTrace returns the raw trace document of an execution
+func (m *Manager) GetTrace(ctx context.Context, id string) ([]byte, error) {
+	return m.storage.LoadTrace(ctx, id)
+}
+
+// EachNodeResult calls fn with the stored node results of an execution from
+// offset on, at most limit of them (0 for no limit), and returns how many
+// the trace holds in all
+func (m *Manager) EachNodeResult(ctx context.Context, id string, offset, limit int, fn func(json.RawMessage) error) (int, error) {
+	total := 0
+	err := m.storage.EachNodeResult(ctx, id, func(raw json.RawMessage) error {
+		total++
+		if total <= offset || (limit > 0 && total > offset+limit) {
+			return nil
+		}
+		return fn(raw)
+	})
+	return total, err
+}
+
+// GetArtifact returns the content of an execution's artifact
+func (m *Manager) GetArtifact(ctx context.Context, id, name string) ([]byte, error) {
+	if _, err := m.GetExecution(ctx, id); err != nil {
+		return nil, err
+	}
+	return m.storage.LoadArtifact(ctx, id, name)
+}
+
+// ListExecutions returns executions matching the filter (newest first)
+// along with the total number of matches before pagination
+func (m *Manager) ListExecutions(ctx context.Context, filter ListFilter) ([]*ExecutionInfo, int, error) {
+	infos, err := m.storage.List(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	page, total := filter.Apply(infos)
+	return page, total, nil
 }
 
 // DeleteExecution removes an execution
@@ -210,11 +498,11 @@ func (m *Manager) DeleteExecution(ctx context.Context, id string) error {
 
 // GetStatus returns the current status of an execution
 func (m *Manager) GetStatus(ctx context.Context, id string) (Status, error) {
-	exec, err := m.GetExecution(ctx, id)
+	info, err := m.GetInfo(ctx, id)
 	if err != nil {
 		return "", err
 	}
-	return exec.Status, nil
+	return info.Status, nil
 }
 
 // generateExecutionID creates a unique execution ID
@@ -227,3 +515,11 @@ func (m *Manager) generateExecutionID(agentSpec *spec.AgentSpec) string {
 
 	return fmt.Sprintf("exec-%d", timestamp)
 }
+
+// promptsDir returns the directory prompt_ref paths are resolved against
+func (m *Manager) promptsDir() string {
+	if m.cfg == nil {
+		return ""
+	}
+	return m.cfg.Server.PromptsDir
+}
@@ -0,0 +1,88 @@
+package execution
+
+import (
+	"sort"
+	"sync"
+)
+
+// anonymousAgentID labels usage of specs without an id
+const anonymousAgentID = "anonymous"
+
+// AgentUsage totals the LLM usage of one agent's executions
+type AgentUsage struct {
+	AgentID          string  `json:"agent_id"`
+	Executions       int64   `json:"executions"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// Metrics is a snapshot of the usage aggregated by a Manager since it started
+type Metrics struct {
+	Executions map[Status]int64 `json:"executions"` // Finished executions by final status
+	Agents     []AgentUsage     `json:"agents"`     // Sorted by agent ID
+}
+
+// usageMetrics aggregates finished executions
+type usageMetrics struct {
+	mu         sync.Mutex
+	executions map[Status]int64
+	agents     map[string]*AgentUsage
+}
+
+// newUsageMetrics creates empty metrics
+func newUsageMetrics() *usageMetrics {
+	return &usageMetrics{
+		executions: make(map[Status]int64),
+		agents:     make(map[string]*AgentUsage),
+	}
+}
+
+// record adds a finished execution. Token counts come from the result
+// metadata, which failed executions carry too when their nodes ran.
+func (u *usageMetrics) record(exec *Execution) {
+	agentID := exec.Spec.ID
+	if agentID == "" {
+		agentID = anonymousAgentID
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.executions[exec.Status]++
+
+	agent := u.agents[agentID]
+	if agent == nil {
+		agent = &AgentUsage{AgentID: agentID}
+		u.agents[agentID] = agent
+	}
+	agent.Executions++
+
+	if exec.Result != nil && exec.Result.Metadata != nil {
+		agent.PromptTokens += int64(exec.Result.Metadata.PromptTokens)
+		agent.CompletionTokens += int64(exec.Result.Metadata.CompletionTokens)
+		agent.Cost += exec.Result.Metadata.TotalCost
+	}
+}
+
+// snapshot copies the current totals
+func (u *usageMetrics) snapshot() Metrics {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	metrics := Metrics{
+		Executions: make(map[Status]int64, len(u.executions)),
+		Agents:     make([]AgentUsage, 0, len(u.agents)),
+	}
+	for status, count := range u.executions {
+		metrics.Executions[status] = count
+	}
+	for _, agent := range u.agents {
+		metrics.Agents = append(metrics.Agents, *agent)
+	}
+	sort.Slice(metrics.Agents, func(i, j int) bool {
+		return metrics.Agents[i].AgentID < metrics.Agents[j].AgentID
+	})
+
+	return metrics
+}
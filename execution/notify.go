@@ -0,0 +1,28 @@
+package execution
+
+import (
+	"context"
+
+	"github.com/not7/core/notify"
+)
+
+// announce sends the outcome of a finished execution to the configured
+// notification channels, as narrowed by its spec
+func announce(ctx context.Context, exec *Execution) error {
+	if !notify.Enabled() {
+		return nil
+	}
+	summary := notify.Summary{
+		ExecutionID: exec.ID,
+		AgentID:     exec.Spec.ID,
+		Goal:        exec.Spec.Goal,
+		Status:      string(exec.Status),
+	}
+	if exec.Result != nil {
+		summary.Cost = exec.Result.TotalCost
+		summary.DurationMs = exec.Result.DurationMs
+		summary.Output = exec.Result.Output
+		summary.Error = exec.Result.Error
+	}
+	return notify.Send(ctx, summary, exec.Spec.Notifications)
+}
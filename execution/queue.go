@@ -0,0 +1,240 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/not7/core/executor"
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/queue"
+	"github.com/not7/core/tracing"
+)
+
+const (
+	// queueRetryDelay is how long a consumer waits after a failed dequeue
+	queueRetryDelay = 2 * time.Second
+
+	// queuedCheckInterval is how often queued executions are looked up in
+	// storage, in case the event relay missed their end
+	queuedCheckInterval = 30 * time.Second
+
+	// relayTimeout bounds relaying one event
+	relayTimeout = 5 * time.Second
+)
+
+// SetQueue makes async executions wait in q until a consumer runs them,
+// instead of starting them in this process. Sync executions are unaffected.
+// When other processes may run them, their events are relayed back through
+// q, so subscribers, metrics and OnFinish callbacks here still see them.
+func (m *Manager) SetQueue(q queue.Queue) {
+	m.queue = q
+	if relay, ok := q.(queue.Relay); ok {
+		go m.relayEvents(relay)
+	}
+}
+
+// enqueue hands a pending execution to the queue
+func (m *Manager) enqueue(ctx context.Context, exec *Execution, opts Options) error {
+	job := &queue.Job{
+		ExecutionID: exec.ID,
+		RequestID:   exec.RequestID,
+		Spec:        exec.Spec,
+		Input:       exec.Input,
+		Tags:        exec.Tags,
+		Attachments: opts.Attachments,
+		Timeout:     opts.Timeout,
+		BatchAPI:    opts.BatchAPI,
+		CreatedAt:   exec.CreatedAt,
+	}
+
+	m.queued.Store(exec.ID, opts.OnFinish)
+	if err := m.queue.Enqueue(ctx, job); err != nil {
+		m.queued.Delete(exec.ID)
+		exec.MarkFailed(err)
+		m.storage.Save(ctx, exec)
+		return fmt.Errorf("%w: %v", ErrStorageUnavailable, err)
+	}
+	return nil
+}
+
+// Consume runs queued executions, at most workers at a time, until ctx is
+// done or the queue is closed. It returns once the running executions have
+// finished.
+func (m *Manager) Consume(ctx context.Context, workers int) {
+	if m.queue == nil || workers < 1 {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.consumeLoop(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// consumeLoop takes one job at a time off the queue and runs it
+func (m *Manager) consumeLoop(ctx context.Context) {
+	for {
+		job, err := m.queue.Dequeue(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, queue.ErrClosed) {
+				return
+			}
+			ui.Printf("⚠️  Queue: %v\n", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(queueRetryDelay):
+			}
+			continue
+		}
+
+		// A job that was taken runs to completion even during shutdown
+		runCtx := context.WithoutCancel(ctx)
+		m.runJob(runCtx, job)
+		if err := m.queue.Ack(runCtx, job); err != nil {
+			ui.Printf("⚠️  Queue: %v\n", err)
+		}
+	}
+}
+
+// runJob runs a queued execution as an async run would have
+func (m *Manager) runJob(ctx context.Context, job *queue.Job) {
+	exec := NewExecution(job.ExecutionID, job.Spec)
+	exec.RequestID = job.RequestID
+	exec.Input = job.Input
+	exec.Tags = job.Tags
+	exec.CreatedAt = job.CreatedAt
+	ctx = tracing.WithRequestID(ctx, exec.RequestID)
+
+	if _, loaded := m.activeExecutions.LoadOrStore(exec.ID, exec); loaded {
+		return
+	}
+
+	// Executions queued here finish here; the events of the others are
+	// relayed to the process that queued them
+	onFinish, local := m.queued.LoadAndDelete(exec.ID)
+	if _, ok := m.queue.(queue.Relay); ok && !local {
+		m.relayed.Store(exec.ID, true)
+		defer m.relayed.Delete(exec.ID)
+	}
+
+	// A job whose workers keep dying, e.g. because it exhausts their
+	// memory, is failed rather than handed to yet another worker
+	if job.Attempts >= queue.MaxAttempts {
+		defer m.activeExecutions.Delete(exec.ID)
+		err := fmt.Errorf("abandoned after %d workers stopped while running it", job.Attempts)
+		exec.MarkFailed(err)
+		if saveErr := m.storage.Save(ctx, exec); saveErr != nil {
+			ui.Printf("⚠️  Queue: failed to save %s: %v\n", exec.ID, saveErr)
+		}
+		m.publishFinished(exec, err)
+		return
+	}
+
+	// Stop when the lease lapses; another worker may be running it by then
+	if lost := job.Lost(); lost != nil {
+		var cancel context.CancelCauseFunc
+		ctx, cancel = context.WithCancelCause(ctx)
+		defer cancel(nil)
+		go func() {
+			select {
+			case <-lost:
+				cancel(queue.ErrLeaseLost)
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	opts := Options{
+		Async:       true,
+		Timeout:     job.Timeout,
+		Attachments: job.Attachments,
+		BatchAPI:    job.BatchAPI,
+	}
+	if local {
+		opts.OnFinish = onFinish.(func(*Execution))
+	}
+
+	m.executeSync(ctx, exec, opts)
+}
+
+// publish delivers an event to this process's subscribers and relays the
+// events of executions run for another process back to it
+func (m *Manager) publish(event executor.Event) {
+	m.events.publish(event)
+
+	if _, ok := m.relayed.Load(event.ExecutionID); !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), relayTimeout)
+	defer cancel()
+	if err := m.queue.(queue.Relay).Publish(ctx, event); err != nil {
+		ui.Printf("⚠️  Queue: %v\n", err)
+	}
+}
+
+// relayEvents delivers the events other processes relay for executions this
+// manager queued, and finishes those executions when they end. It also
+// checks storage periodically, so an end the relay missed is still seen.
+// It returns when the queue is closed.
+func (m *Manager) relayEvents(relay queue.Relay) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		ticker := time.NewTicker(queuedCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			m.queued.Range(func(id, _ interface{}) bool {
+				exec, err := m.storage.Load(ctx, id.(string))
+				if err == nil && exec.Status.IsTerminal() && m.finishQueued(exec) {
+					m.events.publish(exec.FinishedEvent())
+				}
+				return true
+			})
+		}
+	}()
+
+	relay.Subscribe(ctx, func(event executor.Event) {
+		if _, ok := m.queued.Load(event.ExecutionID); !ok {
+			return
+		}
+		if event.IsTerminal() {
+			// The worker saves the execution before publishing its end
+			exec, err := m.storage.Load(ctx, event.ExecutionID)
+			if err != nil {
+				ui.Printf("⚠️  Queue: failed to load %s: %v\n", event.ExecutionID, err)
+				return
+			}
+			m.finishQueued(exec)
+		}
+		m.events.publish(event)
+	})
+}
+
+// finishQueued counts a queued execution another process ran in the metrics
+// and calls its OnFinish. It reports false when it was already finished.
+func (m *Manager) finishQueued(exec *Execution) bool {
+	onFinish, ok := m.queued.LoadAndDelete(exec.ID)
+	if !ok {
+		return false
+	}
+	m.metrics.record(exec)
+	if onFinish := onFinish.(func(*Execution)); onFinish != nil {
+		onFinish(exec)
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+package execution
+
+import (
+	"context"
+	"errors"
+
+	"github.com/not7/core/executor"
+	"github.com/not7/core/llm"
+	"github.com/not7/core/reporting"
+)
+
+// reportFailure sends a failed execution to the configured error reporting
+// destinations, classified as a panic, an LLM provider error or a plain failure
+func reportFailure(ctx context.Context, exec *Execution, err error) error {
+	report := reporting.Report{
+		Kind:        reporting.KindExecutionFailed,
+		Message:     err.Error(),
+		ExecutionID: exec.ID,
+		RequestID:   exec.RequestID,
+		AgentID:     exec.Spec.ID,
+	}
+
+	var nodeErr *executor.NodeError
+	if errors.As(err, &nodeErr) {
+		report.NodeID = nodeErr.NodeID
+	}
+
+	var panicErr *executor.PanicError
+	var providerErr *llm.ProviderError
+	switch {
+	case errors.As(err, &panicErr):
+		report.Kind = reporting.KindPanic
+		report.Stack = string(panicErr.Stack)
+	case errors.As(err, &providerErr):
+		report.Kind = reporting.KindProviderError
+		report.Provider = providerErr.Provider
+		report.StatusCode = providerErr.StatusCode
+	}
+
+	return reporting.Send(ctx, report)
+}
@@ -30,6 +30,26 @@ type Storage interface {
 	// SaveTrace writes the full execution trace
 	SaveTrace(ctx context.Context, id string, trace interface{}) error
 
+	// LoadTrace returns the raw trace document (agent spec + execution metadata)
+	LoadTrace(ctx context.Context, id string) ([]byte, error)
+
+	// LoadInfo returns an execution's summary from its index entry, without
+	// reading the trace
+	LoadInfo(ctx context.Context, id string) (*ExecutionInfo, error)
+
+	// EachNodeResult calls fn with each node result of the trace, in order,
+	// decoding one at a time so large traces are never loaded whole
+	EachNodeResult(ctx context.Context, id string, fn func(json.RawMessage) error) error
+
+	// SaveArtifact stores a named file produced by the execution
+	SaveArtifact(ctx context.Context, id, name string, data []byte) error
+
+	// LoadArtifact returns a stored artifact's content
+	LoadArtifact(ctx context.Context, id, name string) ([]byte, error)
+
+	// SaveAttachment stores a named file sent with the execution's run
+	SaveAttachment(ctx context.Context, id, name string, data []byte) error
+
 	// Delete removes an execution from storage
 	Delete(ctx context.Context, id string) error
 }
@@ -52,7 +72,8 @@ func NewFileSystemStorage(basePath string) (*FileSystemStorage, error) {
 	}, nil
 }
 
-// Save persists an execution atomically to trace.json
+// Save persists an execution atomically to trace.json, and its summary to
+// info.json, the index entry that status reads and listings use
 func (s *FileSystemStorage) Save(ctx context.Context, exec *Execution) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -72,19 +93,32 @@ func (s *FileSystemStorage) Save(ctx context.Context, exec *Execution) error {
 		return fmt.Errorf("failed to marshal execution: %w", err)
 	}
 
-	// Write atomically: write to temp file, then rename
-	traceFile := filepath.Join(execDir, "trace.json")
-	tempFile := traceFile + ".tmp"
+	if err := writeAtomic(filepath.Join(execDir, "trace.json"), data); err != nil {
+		return fmt.Errorf("failed to commit trace file: %w", err)
+	}
 
-	if err := os.WriteFile(tempFile, data, 0644); err != nil {
-		return fmt.Errorf("failed to write temp file: %w", err)
+	info, err := json.Marshal(exec.Info())
+	if err != nil {
+		return fmt.Errorf("failed to marshal execution info: %w", err)
+	}
+	if err := writeAtomic(filepath.Join(execDir, "info.json"), info); err != nil {
+		return fmt.Errorf("failed to commit execution info: %w", err)
 	}
 
-	if err := os.Rename(tempFile, traceFile); err != nil {
+	return nil
+}
+
+// writeAtomic writes a file through a temp file and a rename, so readers
+// never see it half written
+func writeAtomic(path string, data []byte) error {
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tempFile, path); err != nil {
 		os.Remove(tempFile) // Cleanup temp file on failure
-		return fmt.Errorf("failed to commit trace file: %w", err)
+		return err
 	}
-
 	return nil
 }
 
@@ -115,6 +149,13 @@ func (s *FileSystemStorage) Load(ctx context.Context, id string) (*Execution, er
 		return nil, fmt.Errorf("failed to parse trace data: %w", err)
 	}
 
+	// The output is kept beside the trace, see SaveOutput
+	if exec.Result != nil {
+		if output, err := os.ReadFile(filepath.Join(s.executionDir(id), "output.txt")); err == nil {
+			exec.Result.Output = string(output)
+		}
+	}
+
 	return exec, nil
 }
 
@@ -135,14 +176,13 @@ func (s *FileSystemStorage) List(ctx context.Context) ([]*ExecutionInfo, error)
 			continue
 		}
 
-		// Load execution
-		exec, err := s.loadWithoutLock(entry.Name())
+		info, err := s.loadInfoWithoutLock(entry.Name())
 		if err != nil {
 			// Skip invalid executions
 			continue
 		}
 
-		infos = append(infos, exec.Info())
+		infos = append(infos, info)
 	}
 
 	// Sort by creation time (newest first)
@@ -175,6 +215,160 @@ func (s *FileSystemStorage) SaveTrace(ctx context.Context, id string, trace inte
 	return nil
 }
 
+// LoadTrace returns the raw contents of trace.json
+func (s *FileSystemStorage) LoadTrace(ctx context.Context, id string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, err := os.ReadFile(filepath.Join(s.executionDir(id), "trace.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrExecutionNotFound
+		}
+		return nil, fmt.Errorf("failed to read trace file: %w", err)
+	}
+
+	return data, nil
+}
+
+// LoadInfo returns an execution's summary from info.json
+func (s *FileSystemStorage) LoadInfo(ctx context.Context, id string) (*ExecutionInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	info, err := s.loadInfoWithoutLock(id)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrExecutionNotFound
+		}
+		return nil, fmt.Errorf("failed to read execution info: %w", err)
+	}
+	return info, nil
+}
+
+// EachNodeResult streams the node results of trace.json
+func (s *FileSystemStorage) EachNodeResult(ctx context.Context, id string, fn func(json.RawMessage) error) error {
+	// Save replaces the file rather than rewriting it, so the open file
+	// stays whole after the lock is released
+	s.mu.RLock()
+	f, err := os.Open(filepath.Join(s.executionDir(id), "trace.json"))
+	s.mu.RUnlock()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ErrExecutionNotFound
+		}
+		return fmt.Errorf("failed to read trace file: %w", err)
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	for _, key := range []string{"metadata", "node_results"} {
+		found, err := seekKey(dec, key)
+		if err != nil {
+			return fmt.Errorf("failed to parse trace: %w", err)
+		}
+		if !found {
+			return nil
+		}
+	}
+	if tok, err := dec.Token(); err != nil {
+		return fmt.Errorf("failed to parse trace: %w", err)
+	} else if tok != json.Delim('[') {
+		return nil
+	}
+
+	for dec.More() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("failed to parse trace: %w", err)
+		}
+		if err := fn(raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seekKey reads the start of an object and skips its members up to the one
+// named key, leaving dec before that member's value. It reports whether the
+// object has the member.
+func seekKey(dec *json.Decoder, key string) (bool, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return false, err
+	}
+	if tok != json.Delim('{') {
+		return false, nil
+	}
+	for dec.More() {
+		name, err := dec.Token()
+		if err != nil {
+			return false, err
+		}
+		if name == key {
+			return true, nil
+		}
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// SaveArtifact writes an artifact to the execution's artifacts directory
+func (s *FileSystemStorage) SaveArtifact(ctx context.Context, id, name string, data []byte) error {
+	return s.saveFile(id, "artifacts", name, data)
+}
+
+// SaveAttachment writes an attachment to the execution's attachments directory
+func (s *FileSystemStorage) SaveAttachment(ctx context.Context, id, name string, data []byte) error {
+	return s.saveFile(id, "attachments", name, data)
+}
+
+// saveFile writes a named file to a subdirectory of the execution's directory
+func (s *FileSystemStorage) saveFile(id, subdir, name string, data []byte) error {
+	if err := spec.ValidateArtifactName(name); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir := filepath.Join(s.executionDir(id), subdir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s directory: %w", subdir, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// LoadArtifact reads an artifact from the execution's artifacts directory
+func (s *FileSystemStorage) LoadArtifact(ctx context.Context, id, name string) ([]byte, error) {
+	if spec.ValidateArtifactName(name) != nil {
+		return nil, ErrArtifactNotFound
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, err := os.ReadFile(filepath.Join(s.executionDir(id), "artifacts", name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrArtifactNotFound
+		}
+		return nil, fmt.Errorf("failed to read artifact: %w", err)
+	}
+
+	return data, nil
+}
+
 // Delete removes an execution and all its files
 func (s *FileSystemStorage) Delete(ctx context.Context, id string) error {
 	s.mu.Lock()
@@ -216,6 +410,28 @@ func (s *FileSystemStorage) loadWithoutLock(id string) (*Execution, error) {
 	return exec, nil
 }
 
+// loadInfoWithoutLock reads an execution's info.json. Executions saved
+// before there was one are summarized from their trace.
+func (s *FileSystemStorage) loadInfoWithoutLock(id string) (*ExecutionInfo, error) {
+	data, err := os.ReadFile(filepath.Join(s.executionDir(id), "info.json"))
+	if os.IsNotExist(err) {
+		exec, err := s.loadWithoutLock(id)
+		if err != nil {
+			return nil, err
+		}
+		return exec.Info(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var info ExecutionInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
 // buildTraceData constructs the enhanced trace structure with agent spec + execution metadata
 func (s *FileSystemStorage) buildTraceData(exec *Execution) map[string]interface{} {
 	trace := make(map[string]interface{})
@@ -233,6 +449,12 @@ func (s *FileSystemStorage) buildTraceData(exec *Execution) map[string]interface
 		"created_at":   exec.CreatedAt,
 	}
 
+	if exec.Input != "" {
+		metadata["input"] = exec.Input
+	}
+	if len(exec.Tags) > 0 {
+		metadata["tags"] = exec.Tags
+	}
 	if exec.StartedAt != nil {
 		metadata["started_at"] = exec.StartedAt
 	}
@@ -250,6 +472,17 @@ func (s *FileSystemStorage) buildTraceData(exec *Execution) map[string]interface
 			metadata["executed_at"] = exec.Result.Metadata.ExecutedAt
 			metadata["execution_time_ms"] = exec.Result.Metadata.ExecutionTimeMs
 			metadata["node_results"] = exec.Result.Metadata.NodeResults
+			metadata["prompt_tokens"] = exec.Result.Metadata.PromptTokens
+			metadata["completion_tokens"] = exec.Result.Metadata.CompletionTokens
+			if len(exec.Result.Metadata.OutputViolations) > 0 {
+				metadata["output_violations"] = exec.Result.Metadata.OutputViolations
+			}
+			if len(exec.Result.Metadata.Attachments) > 0 {
+				metadata["attachments"] = exec.Result.Metadata.Attachments
+			}
+			if len(exec.Result.Metadata.Artifacts) > 0 {
+				metadata["artifacts"] = exec.Result.Metadata.Artifacts
+			}
 		}
 	}
 
@@ -283,6 +516,16 @@ func (s *FileSystemStorage) parseTraceData(traceData map[string]interface{}, id
 		endedAt = &t
 	}
 
+	input, _ := metadata["input"].(string)
+
+	var tags map[string]string
+	if rawTags, ok := metadata["tags"].(map[string]interface{}); ok {
+		tags = make(map[string]string, len(rawTags))
+		for key, value := range rawTags {
+			tags[key], _ = value.(string)
+		}
+	}
+
 	// Parse result if present
 	var result *Result
 	if durationMs, ok := metadata["duration_ms"].(float64); ok {
@@ -295,6 +538,22 @@ func (s *FileSystemStorage) parseTraceData(traceData map[string]interface{}, id
 		if errorStr, ok := metadata["error"].(string); ok {
 			result.Error = errorStr
 		}
+		if promptTokens, ok := metadata["prompt_tokens"].(float64); ok {
+			result.Metadata = &spec.Metadata{PromptTokens: int(promptTokens)}
+			if completionTokens, ok := metadata["completion_tokens"].(float64); ok {
+				result.Metadata.CompletionTokens = int(completionTokens)
+			}
+		}
+		if violations, ok := metadata["output_violations"].([]interface{}); ok {
+			if result.Metadata == nil {
+				result.Metadata = &spec.Metadata{}
+			}
+			for _, v := range violations {
+				if violation, ok := v.(string); ok {
+					result.Metadata.OutputViolations = append(result.Metadata.OutputViolations, violation)
+				}
+			}
+		}
 	}
 
 	// Parse agent spec (all fields except metadata)
@@ -313,6 +572,8 @@ func (s *FileSystemStorage) parseTraceData(traceData map[string]interface{}, id
 		ID:        id,
 		Spec:      &agentSpec,
 		Status:    status,
+		Input:     input,
+		Tags:      tags,
 		Result:    result,
 		CreatedAt: createdAt,
 		StartedAt: startedAt,
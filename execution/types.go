@@ -3,14 +3,18 @@ package execution
 import (
 	"time"
 
+	"github.com/not7/core/executor"
 	"github.com/not7/core/spec"
 )
 
 // Execution represents a single agent execution instance
 type Execution struct {
 	ID        string           `json:"id"`
+	RequestID string           `json:"request_id,omitempty"` // Correlation ID of the API request that started it
 	Spec      *spec.AgentSpec  `json:"spec"`
 	Status    Status           `json:"status"`
+	Input     string           `json:"input,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"` // Labels set by the caller, e.g. an experiment and its variant
 	Result    *Result          `json:"result,omitempty"`
 	CreatedAt time.Time        `json:"created_at"`
 	StartedAt *time.Time       `json:"started_at,omitempty"`
@@ -23,11 +27,17 @@ type Status string
 const (
 	StatusPending   Status = "pending"
 	StatusRunning   Status = "running"
+	StatusSuspended Status = "suspended" // Waiting for a Batch API answer
 	StatusCompleted Status = "completed"
 	StatusFailed    Status = "failed"
 	StatusCancelled Status = "cancelled"
 )
 
+// IsTerminal reports whether the status is final (no further transitions)
+func (s Status) IsTerminal() bool {
+	return s == StatusCompleted || s == StatusFailed || s == StatusCancelled
+}
+
 // Result contains the output and metadata from an execution
 type Result struct {
 	Output       string             `json:"output"`
@@ -47,16 +57,104 @@ type Options struct {
 
 	// Timeout sets the maximum execution duration (0 = no timeout)
 	Timeout time.Duration
+
+	// Input is delivered to the agent's first node(s)
+	Input string
+
+	// Vars override the spec's vars before ${name} references are rendered
+	Vars map[string]string
+
+	// Tags label the execution; listings can be filtered by them
+	Tags map[string]string
+
+	// Attachments are files sent with the run, stored with the execution
+	// and read by nodes with the ReadAttachment tool
+	Attachments []executor.Attachment
+
+	// BatchAPI sends the execution's OpenAI calls through the Batch API,
+	// at half the cost. The execution is suspended while a call waits,
+	// which may take up to 24 hours, so it must be async.
+	BatchAPI bool
+
+	// RequestID correlates the execution's logs, events and spans with the
+	// request that started it. One is generated when empty.
+	RequestID string
+
+	// OnFinish, if set, is called with the execution once it has finished,
+	// whether it completed, failed or was cancelled
+	OnFinish func(*Execution)
 }
 
 // ExecutionInfo is a lightweight summary of an execution
 type ExecutionInfo struct {
-	ID        string    `json:"id"`
-	Goal      string    `json:"goal"`
-	Status    Status    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
-	DurationMs int64    `json:"duration_ms,omitempty"`
-	TotalCost float64   `json:"total_cost,omitempty"`
+	ID             string            `json:"id"`
+	RequestID      string            `json:"request_id,omitempty"`
+	AgentID        string            `json:"agent_id,omitempty"`
+	Goal           string            `json:"goal"`
+	Status         Status            `json:"status"`
+	CreatedAt      time.Time         `json:"created_at"`
+	DurationMs     int64             `json:"duration_ms,omitempty"`
+	TotalCost      float64           `json:"total_cost,omitempty"`
+	Tags           map[string]string `json:"tags,omitempty"`
+	StartedAt      *time.Time        `json:"started_at,omitempty"`
+	EndedAt        *time.Time        `json:"ended_at,omitempty"`
+	TotalNodes     int               `json:"total_nodes,omitempty"`
+	CompletedNodes int               `json:"completed_nodes,omitempty"`
+}
+
+// ListFilter narrows and paginates execution listings
+// Zero values mean "no constraint"
+type ListFilter struct {
+	Status  Status
+	AgentID string
+	Since   time.Time // Created at or after
+	Until   time.Time // Created before
+	Tags    map[string]string // Every tag must be set to the given value
+	Limit   int
+	Offset  int
+}
+
+// Matches reports whether an execution summary satisfies the filter criteria
+func (f ListFilter) Matches(info *ExecutionInfo) bool {
+	if f.Status != "" && info.Status != f.Status {
+		return false
+	}
+	if f.AgentID != "" && info.AgentID != f.AgentID {
+		return false
+	}
+	if !f.Since.IsZero() && info.CreatedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !info.CreatedAt.Before(f.Until) {
+		return false
+	}
+	for key, value := range f.Tags {
+		if info.Tags[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply filters and paginates a listing, returning the page and the total match count
+func (f ListFilter) Apply(infos []*ExecutionInfo) ([]*ExecutionInfo, int) {
+	matched := make([]*ExecutionInfo, 0, len(infos))
+	for _, info := range infos {
+		if f.Matches(info) {
+			matched = append(matched, info)
+		}
+	}
+
+	total := len(matched)
+	if f.Offset >= total {
+		return []*ExecutionInfo{}, total
+	}
+	matched = matched[f.Offset:]
+	if f.Limit > 0 && f.Limit < len(matched) {
+		matched = matched[:f.Limit]
+	}
+
+	return matched, total
 }
 
 // NewExecution creates a new execution instance
@@ -76,6 +174,16 @@ func (e *Execution) MarkStarted() {
 	e.Status = StatusRunning
 }
 
+// MarkSuspended marks a running execution as waiting for a batch
+func (e *Execution) MarkSuspended() {
+	e.Status = StatusSuspended
+}
+
+// MarkResumed marks a suspended execution as running again
+func (e *Execution) MarkResumed() {
+	e.Status = StatusRunning
+}
+
 // MarkCompleted transitions execution to completed state with result
 func (e *Execution) MarkCompleted(result *Result) {
 	now := time.Now()
@@ -97,15 +205,24 @@ func (e *Execution) MarkFailed(err error) {
 // Info returns a lightweight summary
 func (e *Execution) Info() *ExecutionInfo {
 	info := &ExecutionInfo{
-		ID:        e.ID,
-		Goal:      e.Spec.Goal,
-		Status:    e.Status,
-		CreatedAt: e.CreatedAt,
+		ID:         e.ID,
+		RequestID:  e.RequestID,
+		AgentID:    e.Spec.ID,
+		Goal:       e.Spec.Goal,
+		Status:     e.Status,
+		CreatedAt:  e.CreatedAt,
+		Tags:       e.Tags,
+		StartedAt:  e.StartedAt,
+		EndedAt:    e.EndedAt,
+		TotalNodes: len(e.Spec.Nodes),
 	}
 
 	if e.Result != nil {
 		info.DurationMs = e.Result.DurationMs
 		info.TotalCost = e.Result.TotalCost
+		if e.Result.Metadata != nil {
+			info.CompletedNodes = len(e.Result.Metadata.NodeResults)
+		}
 	}
 
 	return info
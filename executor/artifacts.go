@@ -0,0 +1,152 @@
+package executor
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/spec"
+	"github.com/not7/core/tools"
+)
+
+// artifactProviderName is the provider of the SaveArtifact tool, which every
+// tool manager gets
+const artifactProviderName = "artifacts"
+
+// ArtifactSaver stores the content of a named artifact
+type ArtifactSaver func(ctx context.Context, name string, data []byte) error
+
+// SetArtifactSaver sets where artifacts are stored. Without one, artifacts
+// are listed in the metadata but their content is dropped.
+func (e *Executor) SetArtifactSaver(saver ArtifactSaver) {
+	e.saveArtifactData = saver
+}
+
+// ArtifactContentType returns the media type of an artifact, from its name's
+// extension or else sniffed from its content
+func ArtifactContentType(name string, data []byte) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(name)); contentType != "" {
+		return contentType
+	}
+	return http.DetectContentType(data)
+}
+
+// saveArtifact stores an artifact of nodeID and lists it in the metadata
+func (e *Executor) saveArtifact(ctx context.Context, nodeID, toolName string, artifact tools.Artifact) error {
+	if err := spec.ValidateArtifactName(artifact.Name); err != nil {
+		return err
+	}
+	contentType := artifact.ContentType
+	if contentType == "" {
+		contentType = ArtifactContentType(artifact.Name, artifact.Data)
+	}
+
+	if e.saveArtifactData == nil {
+		e.logger.Debug("Artifact %s not stored: no artifact storage", artifact.Name)
+	} else if err := e.saveArtifactData(ctx, artifact.Name, artifact.Data); err != nil {
+		return fmt.Errorf("failed to save artifact %s: %w", artifact.Name, err)
+	}
+
+	e.spec.Metadata.Artifacts = append(e.spec.Metadata.Artifacts, spec.Artifact{
+		Name:        artifact.Name,
+		NodeID:      nodeID,
+		ToolName:    toolName,
+		ContentType: contentType,
+		Size:        len(artifact.Data),
+		CreatedAt:   time.Now().Format(time.RFC3339),
+	})
+	e.logger.Info("Artifact saved: %s (%s, %d bytes)", artifact.Name, contentType, len(artifact.Data))
+	if e.useCLI {
+		ui.Infof("   📎 Artifact saved: %s\n", artifact.Name)
+	}
+	return nil
+}
+
+// saveToolArtifacts stores the artifacts a tool returned
+func (e *Executor) saveToolArtifacts(ctx context.Context, nodeID, toolName string, result *tools.ToolResult) error {
+	for _, artifact := range result.Artifacts {
+		if err := e.saveArtifact(ctx, nodeID, toolName, artifact); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// artifactProvider offers the SaveArtifact tool. It only packages the
+// content; the executor saves the artifacts of every tool result.
+type artifactProvider struct{}
+
+func (p *artifactProvider) Initialize(config map[string]string) error {
+	return nil
+}
+
+func (p *artifactProvider) ListTools(ctx context.Context) ([]tools.ToolDefinition, error) {
+	return []tools.ToolDefinition{
+		{
+			Name:        "SaveArtifact",
+			Description: "Save content as a named file (e.g. report.md, data.csv) that is kept with the execution. Returns the name it was saved under.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "File name with extension, using letters, digits, '.', '_' and '-'",
+					},
+					"content": map[string]interface{}{
+						"type":        "string",
+						"description": "The file content",
+					},
+					"base64": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Whether content is base64-encoded binary data (default: false)",
+					},
+				},
+				"required": []string{"name", "content"},
+			},
+			Provider: artifactProviderName,
+		},
+	}, nil
+}
+
+func (p *artifactProvider) ExecuteTool(ctx context.Context, toolName string, arguments map[string]interface{}) (*tools.ToolResult, error) {
+	if toolName != "SaveArtifact" {
+		return &tools.ToolResult{Success: false, Error: fmt.Sprintf("unknown tool: %s", toolName)}, nil
+	}
+
+	name, _ := arguments["name"].(string)
+	if err := spec.ValidateArtifactName(name); err != nil {
+		return &tools.ToolResult{Success: false, Error: err.Error()}, nil
+	}
+	content, ok := arguments["content"].(string)
+	if !ok {
+		return &tools.ToolResult{Success: false, Error: "content parameter is required"}, nil
+	}
+
+	data := []byte(content)
+	if encoded, _ := arguments["base64"].(bool); encoded {
+		decoded, err := base64.StdEncoding.DecodeString(content)
+		if err != nil {
+			return &tools.ToolResult{Success: false, Error: fmt.Sprintf("content is not valid base64: %v", err)}, nil
+		}
+		data = decoded
+	}
+
+	return &tools.ToolResult{
+		Success:   true,
+		Output:    fmt.Sprintf("Saved artifact %s (%d bytes)", name, len(data)),
+		Artifacts: []tools.Artifact{{Name: name, Data: data}},
+	}, nil
+}
+
+func (p *artifactProvider) GetProviderName() string {
+	return artifactProviderName
+}
+
+func (p *artifactProvider) Close() error {
+	return nil
+}
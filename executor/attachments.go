@@ -0,0 +1,143 @@
+package executor
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/not7/core/spec"
+	"github.com/not7/core/tools"
+)
+
+// attachmentProviderName is the provider of the ReadAttachment tool, which
+// every tool manager gets when the run has attachments
+const attachmentProviderName = "attachments"
+
+// maxAttachmentRead is the most characters ReadAttachment returns at once
+const maxAttachmentRead = 20000
+
+// Attachment is a file sent with a run as input
+type Attachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data"`
+}
+
+// SetAttachments gives the run's nodes the files sent with it, through the
+// ReadAttachment tool. react nodes with tools_enabled can read them even
+// when the spec configures no tool provider.
+func (e *Executor) SetAttachments(attachments []Attachment) error {
+	e.attachments = attachments
+	if len(attachments) == 0 || e.replay != nil {
+		return nil
+	}
+
+	// Tool managers made before now, like the agent's default one, get the
+	// tool too; later ones get it in getOrCreateToolManager
+	for provider, toolMgr := range e.toolManagers {
+		if err := toolMgr.RegisterProvider(&attachmentProvider{attachments: attachments}); err != nil {
+			return fmt.Errorf("failed to register attachment provider for %s: %w", provider, err)
+		}
+	}
+	return nil
+}
+
+// attachmentInfo summarizes the run's attachments for the metadata
+func (e *Executor) attachmentInfo() []spec.Attachment {
+	var infos []spec.Attachment
+	for _, a := range e.attachments {
+		infos = append(infos, spec.Attachment{Name: a.Name, ContentType: a.ContentType, Size: len(a.Data)})
+	}
+	return infos
+}
+
+// attachmentProvider offers the ReadAttachment tool over a run's attachments
+type attachmentProvider struct {
+	attachments []Attachment
+}
+
+func (p *attachmentProvider) Initialize(config map[string]string) error {
+	return nil
+}
+
+func (p *attachmentProvider) ListTools(ctx context.Context) ([]tools.ToolDefinition, error) {
+	names := make([]string, len(p.attachments))
+	for i, a := range p.attachments {
+		names[i] = fmt.Sprintf("%s (%s, %d bytes)", a.Name, a.ContentType, len(a.Data))
+	}
+
+	return []tools.ToolDefinition{
+		{
+			Name: "ReadAttachment",
+			Description: fmt.Sprintf("Read a document attached to this run. Returns up to %d characters from offset; read again from a later offset for more. Binary files are returned base64-encoded. Attached: %s.",
+				maxAttachmentRead, strings.Join(names, ", ")),
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Name of the attachment",
+					},
+					"offset": map[string]interface{}{
+						"type":        "integer",
+						"description": "Character to start reading from (default: 0)",
+					},
+				},
+				"required": []string{"name"},
+			},
+			Provider: attachmentProviderName,
+		},
+	}, nil
+}
+
+func (p *attachmentProvider) ExecuteTool(ctx context.Context, toolName string, arguments map[string]interface{}) (*tools.ToolResult, error) {
+	if toolName != "ReadAttachment" {
+		return &tools.ToolResult{Success: false, Error: fmt.Sprintf("unknown tool: %s", toolName)}, nil
+	}
+
+	name, _ := arguments["name"].(string)
+	var attachment *Attachment
+	for i := range p.attachments {
+		if p.attachments[i].Name == name {
+			attachment = &p.attachments[i]
+			break
+		}
+	}
+	if attachment == nil {
+		return &tools.ToolResult{Success: false, Error: fmt.Sprintf("no attachment named %q", name)}, nil
+	}
+
+	content := string(attachment.Data)
+	if !utf8.Valid(attachment.Data) {
+		content = base64.StdEncoding.EncodeToString(attachment.Data)
+	}
+	text := []rune(content)
+
+	offset := 0
+	if value, ok := arguments["offset"].(float64); ok && value > 0 {
+		offset = int(value)
+	}
+	if offset > len(text) {
+		offset = len(text)
+	}
+	end := offset + maxAttachmentRead
+	if end > len(text) {
+		end = len(text)
+	}
+
+	output := string(text[offset:end])
+	if end < len(text) {
+		output += fmt.Sprintf("\n\n[%d more characters; read from offset %d]", len(text)-end, end)
+	}
+	return &tools.ToolResult{Success: true, Output: output}, nil
+}
+
+func (p *attachmentProvider) GetProviderName() string {
+	return attachmentProviderName
+}
+
+func (p *attachmentProvider) Close() error {
+	return nil
+}
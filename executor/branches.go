@@ -0,0 +1,128 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/spec"
+)
+
+// Branch statuses, as passed to join nodes
+const (
+	branchSucceeded = "success"
+	branchFailed    = "failed"
+)
+
+// branchOutcome is how one parallel branch ended
+type branchOutcome struct {
+	Node   string `json:"node"`             // First node of the branch
+	Status string `json:"status"`           // "success" or "failed"
+	Output string `json:"output,omitempty"` // Output of the branch's last node
+	Error  string `json:"error,omitempty"`
+}
+
+// joinInput is the input of a join node: the outcome of each branch, in
+// route order
+type joinInput struct {
+	Branches  []branchOutcome `json:"branches"`
+	Succeeded int             `json:"succeeded"`
+	Failed    int             `json:"failed"`
+}
+
+// splitBranches separates the nodes taken from a node into those reached
+// over parallel routes, which start branches, and the others
+func (e *Executor) splitBranches(from string, nodeIDs []string) (branches, sequential []string) {
+	parallel := make(map[string]bool)
+	for _, route := range e.spec.Routes {
+		if route.From == from && route.Parallel {
+			parallel[route.To] = true
+		}
+	}
+	for _, nodeID := range nodeIDs {
+		if parallel[nodeID] {
+			branches = append(branches, nodeID)
+		} else {
+			sequential = append(sequential, nodeID)
+		}
+	}
+	return branches, sequential
+}
+
+// runBranches runs the branches starting at a node's parallel targets, then
+// their join node with the outcome of each, and follows the join node's
+// routes up to stopAt. Branches run one after another. The join node's
+// policy decides whether a failed branch fails the execution; without a
+// join node, it does, and the output of the last branch carries on.
+func (e *Executor) runBranches(ctx context.Context, from string, starts []string, input, stopAt string) (string, error) {
+	joinID, err := e.spec.JoinOf(from)
+	if err != nil {
+		return "", fmt.Errorf("node %s: %w", from, err)
+	}
+	if joinID == stopAt {
+		// The enclosing branches meet there too; they run it
+		joinID = ""
+	}
+	var join *spec.JoinConfig
+	if node := e.nodeMap[joinID]; node != nil {
+		join = node.Join
+	}
+	policy := join.Policy()
+
+	e.logger.Info("Running %d parallel branches from %s (on error: %s)", len(starts), from, policy)
+
+	var joined joinInput
+	output := input
+	for _, start := range starts {
+		branchStop := joinID
+		if branchStop == "" {
+			branchStop = stopAt
+		}
+		branchOutput, err := e.runBranch(ctx, start, input, branchStop)
+		if err != nil {
+			if policy == spec.JoinFailFast || ctx.Err() != nil {
+				return "", err
+			}
+			e.logger.Error("Branch %s failed, continuing with the other branches: %v", start, err)
+			if e.useCLI {
+				ui.Infof("⚠️  Branch %s failed, continuing with the other branches\n", start)
+			}
+			joined.Branches = append(joined.Branches, branchOutcome{Node: start, Status: branchFailed, Error: err.Error()})
+			joined.Failed++
+			continue
+		}
+		joined.Branches = append(joined.Branches, branchOutcome{Node: start, Status: branchSucceeded, Output: branchOutput})
+		joined.Succeeded++
+		output = branchOutput
+	}
+
+	if policy == spec.JoinRequireN && joined.Succeeded < join.Require {
+		return "", fmt.Errorf("%d of %d parallel branches from %s succeeded, %s needs %d", joined.Succeeded, len(starts), from, joinID, join.Require)
+	}
+	if joinID == "" {
+		return output, nil
+	}
+
+	data, err := json.Marshal(joined)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the branches of %s: %w", from, err)
+	}
+	output, err = e.executeNode(ctx, joinID, string(data))
+	if err != nil {
+		return "", err
+	}
+	return e.followRoutesUntil(ctx, joinID, output, stopAt)
+}
+
+// runBranch runs a branch from its first node until it reaches stopAt
+func (e *Executor) runBranch(ctx context.Context, nodeID, input, stopAt string) (string, error) {
+	if nodeID == "end" || nodeID == stopAt {
+		return input, nil
+	}
+	output, err := e.executeNode(ctx, nodeID, input)
+	if err != nil {
+		return "", err
+	}
+	return e.followRoutesUntil(ctx, nodeID, output, stopAt)
+}
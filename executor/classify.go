@@ -0,0 +1,127 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/not7/core/internal/tmpl"
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/spec"
+)
+
+// classifyMaxTokens bounds the classifier's reply, which should be one label
+const classifyMaxTokens = 20
+
+// executeConditionalNode asks a model to classify the input into one of the
+// labels of the node's label routes and records the choice, which
+// routeTaken then follows. The input passes through unchanged, so the chosen
+// branch sees what the conditional node saw.
+func (e *Executor) executeConditionalNode(ctx context.Context, node *spec.Node, input string) (string, usage, error) {
+	labels := spec.RouteLabels(e.spec, node.ID)
+	if len(labels) == 0 {
+		return "", usage{}, fmt.Errorf("conditional node has no label routes")
+	}
+
+	// Classification needs little from the model
+	llmConfig, err := e.llmConfigFor(node)
+	if err != nil {
+		return "", usage{}, err
+	}
+	if llmConfig.MaxTokens == 0 {
+		llmConfig.MaxTokens = classifyMaxTokens
+	}
+
+	instructions, err := tmpl.Render(node.Prompt, input)
+	if err != nil {
+		return "", usage{}, fmt.Errorf("prompt: %w", err)
+	}
+
+	completion, err := e.complete(ctx, llmConfig, buildClassifyPrompt(labels, instructions), input)
+	if err != nil {
+		return "", usage{}, err
+	}
+	var used usage
+	used.addCompletion(completion)
+
+	label, ok := matchLabel(completion.Content, labels)
+	if !ok {
+		return "", used, fmt.Errorf("classified the input as %q, which is not one of %s", strings.TrimSpace(completion.Content), strings.Join(labels, ", "))
+	}
+	e.labels[node.ID] = label
+
+	e.logger.Info("Classified input as %s", label)
+	if e.useCLI {
+		ui.Infof("   🏷️  Classified as: %s\n", label)
+	}
+	return input, used, nil
+}
+
+// buildClassifyPrompt builds the classifier's system prompt
+func buildClassifyPrompt(labels []string, instructions string) string {
+	var b strings.Builder
+	b.WriteString("Classify the user's message into exactly one of these labels:\n")
+	for _, label := range labels {
+		fmt.Fprintf(&b, "- %s\n", label)
+	}
+	if instructions = strings.TrimSpace(instructions); instructions != "" {
+		fmt.Fprintf(&b, "\n%s\n", instructions)
+	}
+	b.WriteString("\nReply with the label only.")
+	return b.String()
+}
+
+// matchLabel finds the label a classifier reply names. Case, surrounding
+// punctuation and a "label:" style prefix are ignored; otherwise the reply
+// must mention exactly one of the labels.
+func matchLabel(reply string, labels []string) (string, bool) {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.TrimFunc(s, func(r rune) bool {
+			return unicode.IsSpace(r) || unicode.IsPunct(r)
+		}))
+	}
+
+	answer := normalize(reply)
+	if _, after, ok := strings.Cut(answer, ":"); ok {
+		answer = normalize(after)
+	}
+	for _, label := range labels {
+		if normalize(label) == answer {
+			return label, true
+		}
+	}
+
+	var found []string
+	for _, label := range labels {
+		if containsWord(strings.ToLower(reply), normalize(label)) {
+			found = append(found, label)
+		}
+	}
+	if len(found) == 1 {
+		return found[0], true
+	}
+	return "", false
+}
+
+// containsWord reports whether word occurs in s between non-word characters
+func containsWord(s, word string) bool {
+	if word == "" {
+		return false
+	}
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' }
+	for offset := 0; ; {
+		i := strings.Index(s[offset:], word)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(word)
+		before, _ := utf8.DecodeLastRuneInString(s[:start])
+		after, _ := utf8.DecodeRuneInString(s[end:])
+		if !isWord(before) && !isWord(after) {
+			return true
+		}
+		offset = start + len(word)
+	}
+}
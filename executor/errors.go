@@ -0,0 +1,50 @@
+package executor
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// NodeError is returned when a node fails. The message is that of the
+// underlying error; use errors.As to find which node failed.
+type NodeError struct {
+	NodeID   string
+	NodeType string
+	Err      error
+}
+
+func (e *NodeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
+
+// BlockedError is returned when a node's input or output breaks its
+// moderation policy. The node is recorded with the "blocked" status.
+type BlockedError struct {
+	Stage  string // spec.ModerateInput or spec.ModerateOutput
+	Reason string
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("%s blocked by moderation: %s", e.Stage, e.Reason)
+}
+
+// PanicError is a recovered panic, returned as an ordinary error so a bad
+// node fails its execution instead of the whole process
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// NewPanicError captures the recovered value and the current stack. It must
+// be called from the deferred function that recovered.
+func NewPanicError(value interface{}) *PanicError {
+	return &PanicError{Value: value, Stack: debug.Stack()}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
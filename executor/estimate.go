@@ -0,0 +1,167 @@
+package executor
+
+import (
+	"github.com/not7/core/llm"
+	"github.com/not7/core/spec"
+)
+
+const (
+	// defaultEstimatedOutputTokens is assumed per call when max_tokens is unset
+	defaultEstimatedOutputTokens = 500
+
+	// defaultEstimateModel is used when neither the spec nor the caller names a model
+	defaultEstimateModel = "gpt-4"
+
+	// defaultMaxIterations mirrors the ReAct iteration default
+	defaultMaxIterations = 5
+)
+
+// NodeEstimate is the projected token usage and cost of a single node
+type NodeEstimate struct {
+	NodeID           string  `json:"node_id"`
+	NodeType         string  `json:"node_type"`
+	Model            string  `json:"model,omitempty"`
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// CostEstimate is the projected token usage and cost of a whole agent
+type CostEstimate struct {
+	Nodes            []NodeEstimate `json:"nodes"`
+	PromptTokens     int            `json:"prompt_tokens"`
+	CompletionTokens int            `json:"completion_tokens"`
+	TotalCost        float64        `json:"total_cost"`
+}
+
+// EstimateCost projects the worst-case token usage and cost of running an
+// agent without calling any LLM. Prompts are rendered the same way the
+// executor renders them; ReAct nodes are assumed to use every iteration and
+// each node's input is assumed to be the previous node's full output.
+// A non-empty model overrides the models configured in the spec.
+func EstimateCost(agentSpec *spec.AgentSpec, model, input string) *CostEstimate {
+	return estimateCost(agentSpec, input, func(node *spec.Node) *spec.LLMConfig {
+		llmConfig, _ := specLLMConfig(agentSpec, node)
+		if model != "" {
+			llmConfig.Model = model
+		}
+		if llmConfig.Model == "" {
+			llmConfig.Model = defaultEstimateModel
+		}
+		return &llmConfig
+	})
+}
+
+// estimateCost projects the usage and cost of an agent, pricing each node
+// with the model llmConfigFor returns. Nodes it returns nil for are not
+// priced.
+func estimateCost(agentSpec *spec.AgentSpec, input string, llmConfigFor func(node *spec.Node) *spec.LLMConfig) *CostEstimate {
+	estimate := &CostEstimate{}
+	upstreamTokens := llm.CountTokens(input)
+
+	for _, node := range orderedNodes(agentSpec) {
+		nodeEstimate := estimateNode(agentSpec, node, llmConfigFor(node), upstreamTokens)
+
+		estimate.Nodes = append(estimate.Nodes, nodeEstimate)
+		estimate.PromptTokens += nodeEstimate.PromptTokens
+		estimate.CompletionTokens += nodeEstimate.CompletionTokens
+		estimate.TotalCost += nodeEstimate.Cost
+
+		if nodeEstimate.Calls > 0 {
+			upstreamTokens = nodeEstimate.CompletionTokens / nodeEstimate.Calls
+		}
+	}
+
+	return estimate
+}
+
+// estimateNode projects the usage of one node, run with llmConfig, given
+// the size of its input
+func estimateNode(agentSpec *spec.AgentSpec, node *spec.Node, llmConfig *spec.LLMConfig, inputTokens int) NodeEstimate {
+	nodeEstimate := NodeEstimate{
+		NodeID:   node.ID,
+		NodeType: node.Type,
+	}
+
+	outputTokens := defaultEstimatedOutputTokens
+	if llmConfig != nil && llmConfig.MaxTokens > 0 {
+		outputTokens = llmConfig.MaxTokens
+	}
+
+	switch node.Type {
+	case "llm":
+		nodeEstimate.Calls = 1
+		nodeEstimate.PromptTokens = llm.CountTokens(node.Prompt) + inputTokens
+		nodeEstimate.CompletionTokens = outputTokens
+	case "conditional":
+		labels := spec.RouteLabels(agentSpec, node.ID)
+		nodeEstimate.Calls = 1
+		nodeEstimate.PromptTokens = llm.CountTokens(buildClassifyPrompt(labels, node.Prompt)) + inputTokens
+		nodeEstimate.CompletionTokens = classifyMaxTokens
+	case "react":
+		iterations := node.MaxIterations
+		if iterations == 0 {
+			iterations = defaultMaxIterations
+		}
+		systemTokens := llm.CountTokens(buildReActSystemPrompt(node.ReActGoal, node.ThinkingPrompt))
+		goalTokens := llm.CountTokens(node.ReActGoal)
+
+		nodeEstimate.Calls = iterations
+		for i := 1; i <= iterations; i++ {
+			// Tool-enabled nodes replay prior turns, so context grows each iteration
+			contextTokens := goalTokens
+			if node.ToolsEnabled {
+				contextTokens += (i - 1) * outputTokens
+			}
+			nodeEstimate.PromptTokens += systemTokens + contextTokens
+		}
+		nodeEstimate.CompletionTokens = iterations * outputTokens
+	default:
+		// Tool and other non-LLM nodes make no model calls
+		return nodeEstimate
+	}
+
+	if llmConfig != nil {
+		nodeEstimate.Model = llmConfig.Model
+		nodeEstimate.Cost = llm.CostForTokens(llmConfig.Model, nodeEstimate.PromptTokens, nodeEstimate.CompletionTokens)
+	}
+	return nodeEstimate
+}
+
+// orderedNodes returns nodes in route order from "start", followed by any
+// nodes that are not reachable through routes
+func orderedNodes(agentSpec *spec.AgentSpec) []*spec.Node {
+	nodeMap := make(map[string]*spec.Node)
+	for i := range agentSpec.Nodes {
+		nodeMap[agentSpec.Nodes[i].ID] = &agentSpec.Nodes[i]
+	}
+
+	var ordered []*spec.Node
+	visited := make(map[string]bool)
+	queue := []string{"start"}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, route := range agentSpec.Routes {
+			if route.From != current || visited[route.To] {
+				continue
+			}
+			visited[route.To] = true
+			if node, ok := nodeMap[route.To]; ok {
+				ordered = append(ordered, node)
+				queue = append(queue, route.To)
+			}
+		}
+	}
+
+	for i := range agentSpec.Nodes {
+		if !visited[agentSpec.Nodes[i].ID] {
+			ordered = append(ordered, &agentSpec.Nodes[i])
+		}
+	}
+
+	return ordered
+}
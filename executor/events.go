@@ -0,0 +1,58 @@
+package executor
+
+import "time"
+
+// EventType identifies the kind of progress event emitted during execution
+type EventType string
+
+const (
+	EventExecutionStarted   EventType = "execution_started"
+	EventExecutionCompleted EventType = "execution_completed"
+	EventExecutionFailed    EventType = "execution_failed"
+	EventNodeStarted        EventType = "node_started"
+	EventNodeCompleted      EventType = "node_completed"
+	EventNodeFailed         EventType = "node_failed"
+	EventReActIteration     EventType = "react_iteration"
+	EventToolCall           EventType = "tool_call"
+	EventNodeSuspended      EventType = "node_suspended" // A call waits for a batch
+	EventNodeResumed        EventType = "node_resumed"   // The batch answered
+)
+
+// Event is a progress notification emitted while an agent runs
+type Event struct {
+	Type        EventType `json:"type"`
+	ExecutionID string    `json:"execution_id,omitempty"`
+	RequestID   string    `json:"request_id,omitempty"`
+	NodeID      string    `json:"node_id,omitempty"`
+	NodeType    string    `json:"node_type,omitempty"`
+	Iteration   int       `json:"iteration,omitempty"`
+	ToolName    string    `json:"tool_name,omitempty"`
+	Message     string    `json:"message,omitempty"`
+	Output      string    `json:"output,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Cost        float64   `json:"cost,omitempty"`
+	DurationMs  int64     `json:"duration_ms,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// IsTerminal reports whether the event marks the end of an execution
+func (e Event) IsTerminal() bool {
+	return e.Type == EventExecutionCompleted || e.Type == EventExecutionFailed
+}
+
+// EventHandler receives progress events from an executor
+type EventHandler func(Event)
+
+// SetEventHandler registers a handler that receives progress events
+func (e *Executor) SetEventHandler(handler EventHandler) {
+	e.onEvent = handler
+}
+
+// emit timestamps an event and forwards it to the registered handler
+func (e *Executor) emit(event Event) {
+	if e.onEvent == nil {
+		return
+	}
+	event.Timestamp = time.Now()
+	e.onEvent(event)
+}
@@ -2,19 +2,30 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/not7/core/config"
+	"github.com/not7/core/internal/redact"
+	"github.com/not7/core/internal/tmpl"
+	"github.com/not7/core/internal/ui"
 	"github.com/not7/core/llm"
 	"github.com/not7/core/logger"
+	"github.com/not7/core/policy"
+	"github.com/not7/core/memory"
 	"github.com/not7/core/spec"
 	"github.com/not7/core/tools"
 	"github.com/not7/core/tools/arcade"
 	"github.com/not7/core/tools/builtin"
+	"github.com/not7/core/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/not7/core/executor")
+
 // Logger interface for logging
 type Logger interface {
 	Info(format string, args ...interface{})
@@ -26,50 +37,149 @@ type Logger interface {
 type Executor struct {
 	spec         *spec.AgentSpec
 	llmClient    *llm.OpenAIClient
+	completer    completer                   // Answers OpenAI calls: llmClient, or the recording when replaying
+	clients      map[string]completer        // Clients of the other LLM providers, created on first use
+	clientsMu    sync.Mutex
+	batcher      *llm.Batcher                // Set to send OpenAI calls through the Batch API
+	redactor     *redact.Redactor            // Placeholders of the personal data kept from providers
+	redactions   map[string]*redact.Rules    // Redaction rules by node ID; nodes without any are absent
+	replay       *Recording                  // Set when replaying a recorded execution
 	nodeMap      map[string]*spec.Node
 	results      map[string]*spec.NodeResult
+	labels       map[string]string           // Labels chosen by conditional nodes, by node ID
+	attempts     map[string][]spec.OutputAttempt // Answers checked against node output contracts, by node ID
 	logger       Logger
+	nodeLogs     *nodeLogCapture           // Copies log lines into the running node's result
 	useCLI       bool                        // Flag to determine if we should print to stdout
 	toolManagers map[string]*tools.Manager // Pool of tool managers by provider
-	cfg          *config.Config              // Global config for tool initialization
+	cfg          *config.Config              // LLM defaults and tool provider credentials
+	onEvent      EventHandler                // Optional progress event listener
+	saveArtifactData ArtifactSaver           // Stores artifact content; nil drops it
+	attachments  []Attachment                // Files sent with the run, read with ReadAttachment
+	fullResults  *resultStore                // Shortened tool results, read with ReadMore
+	secrets      *secretValues               // Named secrets resolved for tool calls
+	input        string                      // Run input, visible to route conditions
+	policy       *policy.Policy              // Operator rules on calls; nil allows everything
+	spent        float64                     // Cost of the LLM calls so far, checked against the policy
+	hooks        []namedHook                 // Called around every node, registered ones first
+}
+
+// completer runs LLM completions
+type completer interface {
+	Execute(ctx context.Context, config *spec.LLMConfig, prompt string, input string) (*llm.Completion, error)
+}
+
+// usage accumulates the cost and token counts of LLM calls
+type usage struct {
+	cost             float64
+	promptTokens     int
+	completionTokens int
+	model            string // Model of the last call
+}
+
+// addCompletion counts one LLM call
+func (u *usage) addCompletion(c *llm.Completion) {
+	u.cost += c.Cost
+	u.promptTokens += c.Usage.PromptTokens
+	u.completionTokens += c.Usage.CompletionTokens
+	u.model = c.Model
+}
+
+// add counts the calls of another usage
+func (u *usage) add(other usage) {
+	u.cost += other.cost
+	u.promptTokens += other.promptTokens
+	u.completionTokens += other.completionTokens
+	if other.model != "" {
+		u.model = other.model
+	}
 }
 
-// NewExecutor creates a new executor for CLI mode (prints to stdout)
-func NewExecutor(agentSpec *spec.AgentSpec) (*Executor, error) {
-	return newExecutor(agentSpec, logger.NewConsoleLogger(), true)
+// NewExecutor creates a new executor that prints nothing and discards its
+// log; node log lines are still kept in the node results
+func NewExecutor(agentSpec *spec.AgentSpec, cfg *config.Config) (*Executor, error) {
+	return newExecutor(agentSpec, cfg, logger.NewDiscardLogger(), false, nil)
+}
+
+// NewCLIExecutor creates a new executor for CLI mode (prints to stdout)
+func NewCLIExecutor(agentSpec *spec.AgentSpec, cfg *config.Config) (*Executor, error) {
+	return newExecutor(agentSpec, cfg, logger.NewConsoleLogger(), true, nil)
 }
 
 // NewExecutorWithLogger creates a new executor with a custom logger (for server mode)
-func NewExecutorWithLogger(agentSpec *spec.AgentSpec, log Logger) (*Executor, error) {
-	return newExecutor(agentSpec, log, false)
+func NewExecutorWithLogger(agentSpec *spec.AgentSpec, cfg *config.Config, log Logger) (*Executor, error) {
+	return newExecutor(agentSpec, cfg, log, false, nil)
+}
+
+// NewReplayExecutor creates a CLI-mode executor that answers LLM calls, tool
+// calls and retrieve nodes from rec instead of calling out, so no API keys
+// are needed
+func NewReplayExecutor(agentSpec *spec.AgentSpec, cfg *config.Config, rec *Recording) (*Executor, error) {
+	return newExecutor(agentSpec, cfg, logger.NewConsoleLogger(), true, rec)
 }
 
 // newExecutor is the internal constructor
-func newExecutor(agentSpec *spec.AgentSpec, log Logger, useCLI bool) (*Executor, error) {
-	llmClient, err := llm.NewOpenAIClient()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create LLM client: %w", err)
+func newExecutor(agentSpec *spec.AgentSpec, cfg *config.Config, log Logger, useCLI bool, rec *Recording) (*Executor, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("config is required")
+	}
+
+	var llmClient *llm.OpenAIClient
+	var openAI completer = rec
+	if rec == nil {
+		client, err := llm.NewOpenAIClient(cfg.OpenAI)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create LLM client: %w", err)
+		}
+		llmClient, openAI = client, client
 	}
 
 	// Build node map for quick lookup
 	nodeMap := make(map[string]*spec.Node)
+	redactions := make(map[string]*redact.Rules)
 	for i := range agentSpec.Nodes {
-		nodeMap[agentSpec.Nodes[i].ID] = &agentSpec.Nodes[i]
-	}
+		node := &agentSpec.Nodes[i]
+		nodeMap[node.ID] = node
 
-	// Get global config
-	cfg := config.Get()
+		rules, err := spec.RedactionFor(agentSpec, node).Rules()
+		if err != nil {
+			return nil, fmt.Errorf("node %s: invalid redaction: %w", node.ID, err)
+		}
+		if rules != nil {
+			redactions[node.ID] = rules
+		}
+	}
 
 	// Create executor with tool manager pool
+	nodeLogs := &nodeLogCapture{next: log}
 	executor := &Executor{
 		spec:         agentSpec,
 		llmClient:    llmClient,
+		completer:    openAI,
+		clients:      make(map[string]completer),
+		replay:       rec,
 		nodeMap:      nodeMap,
 		results:      make(map[string]*spec.NodeResult),
-		logger:       log,
+		labels:       make(map[string]string),
+		attempts:     make(map[string][]spec.OutputAttempt),
+		redactor:     redact.NewRedactor(),
+		redactions:   redactions,
+		logger:       nodeLogs,
+		nodeLogs:     nodeLogs,
 		useCLI:       useCLI,
 		toolManagers: make(map[string]*tools.Manager),
 		cfg:          cfg,
+		fullResults:  &resultStore{},
+		secrets:      &secretValues{values: make(map[string]string)},
+	}
+	if rec == nil {
+		// Replays make no calls, so only live runs are held to the policy
+		// and call hooks
+		executor.policy = policy.New(cfg.Policy)
+		executor.hooks = nodeHooks(cfg.Hooks)
+		if err := executor.checkSecrets(); err != nil {
+			return nil, err
+		}
 	}
 
 	// Initialize default tool manager if agent-level tools are configured
@@ -96,7 +206,12 @@ func (e *Executor) getOrCreateToolManager(provider string) (*tools.Manager, erro
 	toolMgr := tools.NewManager("")
 
 	// Initialize based on provider type
-	if provider == "builtin" {
+	if e.replay != nil {
+		// Replays answer every provider's tools from the recording
+		if err := toolMgr.RegisterProvider(&replayProvider{recording: e.replay}); err != nil {
+			return nil, fmt.Errorf("failed to register replay provider: %w", err)
+		}
+	} else if provider == "builtin" {
 		if e.cfg.Builtin.SerpAPIKey == "" {
 			return nil, fmt.Errorf("builtin provider requires SERP_API_KEY in not7.conf")
 		}
@@ -114,8 +229,23 @@ func (e *Executor) getOrCreateToolManager(provider string) (*tools.Manager, erro
 			return nil, fmt.Errorf("failed to register builtin provider: %w", err)
 		}
 
+		// Builtin agents with an ID also get the Remember and Recall tools
+		if e.spec.ID == "" || e.cfg.Memory.Dir == "" {
+			e.logger.Debug("Memory tools disabled: agent has no id or MEMORY_DIR is empty")
+		} else if err := e.registerMemoryProvider(toolMgr); err != nil {
+			e.logger.Error("Memory tools unavailable: %v", err)
+		}
+
 		e.logger.Info("Builtin tool provider initialized with %d tools", len(toolMgr.ListTools()))
-	} else if provider == "arcade" || (len(provider) > 7 && provider[:7] == "arcade-") {
+	} else if provider == memory.ProviderName {
+		if err := e.registerMemoryProvider(toolMgr); err != nil {
+			return nil, err
+		}
+
+		e.logger.Info("Memory tool provider initialized with %d tools", len(toolMgr.ListTools()))
+	} else if provider == attachmentProviderName {
+		// Only ReadAttachment, for nodes of specs without a tool provider
+	} else if toolkit, ok := arcade.ToolkitFromProvider(provider); ok {
 		// Arcade provider (supports arcade-{toolkit} pattern)
 		if e.cfg.Arcade.APIKey == "" {
 			return nil, fmt.Errorf("ARCADE_API_KEY not configured in not7.conf")
@@ -124,16 +254,6 @@ func (e *Executor) getOrCreateToolManager(provider string) (*tools.Manager, erro
 			return nil, fmt.Errorf("ARCADE_USER_ID not configured in not7.conf")
 		}
 
-		// Extract toolkit name from provider (e.g., "arcade-spotify" → "Spotify")
-		toolkit := "Gmail" // Default for backward compatibility with "arcade"
-		if len(provider) > 7 && provider[:7] == "arcade-" {
-			toolkit = provider[7:]
-			// Capitalize first letter for API compatibility
-			if len(toolkit) > 0 {
-				toolkit = strings.ToUpper(toolkit[:1]) + toolkit[1:]
-			}
-		}
-
 		arcadeProvider := arcade.NewProvider(e.cfg.Arcade.APIKey, e.cfg.Arcade.UserID, toolkit)
 		providerConfig := map[string]string{
 			"arcade_api_key": e.cfg.Arcade.APIKey,
@@ -161,11 +281,51 @@ func (e *Executor) getOrCreateToolManager(provider string) (*tools.Manager, erro
 		return nil, fmt.Errorf("unsupported tool provider: %s", provider)
 	}
 
+	// Every tool manager can save artifacts, read shortened tool results and
+	// read the run's attachments. Replays answer these tools from the
+	// recording like any other.
+	if e.replay == nil {
+		if err := toolMgr.RegisterProvider(&artifactProvider{}); err != nil {
+			return nil, fmt.Errorf("failed to register artifact provider: %w", err)
+		}
+		if err := toolMgr.RegisterProvider(&resultsProvider{store: e.fullResults}); err != nil {
+			return nil, fmt.Errorf("failed to register results provider: %w", err)
+		}
+		if len(e.attachments) > 0 {
+			if err := toolMgr.RegisterProvider(&attachmentProvider{attachments: e.attachments}); err != nil {
+				return nil, fmt.Errorf("failed to register attachment provider: %w", err)
+			}
+		}
+	}
+
 	// Store in pool
 	e.toolManagers[provider] = toolMgr
 	return toolMgr, nil
 }
 
+// registerMemoryProvider adds the agent's Remember and Recall tools to toolMgr
+func (e *Executor) registerMemoryProvider(toolMgr *tools.Manager) error {
+	if e.spec.ID == "" {
+		return fmt.Errorf("memory tools require the agent to have an id")
+	}
+	if e.cfg.Memory.Dir == "" {
+		return fmt.Errorf("memory tools require MEMORY_DIR in not7.conf")
+	}
+
+	store, err := memory.NewStore(e.cfg.Memory.Dir, e.llmClient.NewEmbedder(e.cfg.Memory.EmbeddingModel))
+	if err != nil {
+		return err
+	}
+	memoryProvider := memory.NewProvider(store, e.spec.ID)
+	if err := memoryProvider.Initialize(nil); err != nil {
+		return fmt.Errorf("failed to initialize memory provider: %w", err)
+	}
+	if err := toolMgr.RegisterProvider(memoryProvider); err != nil {
+		return fmt.Errorf("failed to register memory provider: %w", err)
+	}
+	return nil
+}
+
 // getToolManagerForNode resolves and returns the appropriate tool manager for a node
 func (e *Executor) getToolManagerForNode(node *spec.Node) (*tools.Manager, error) {
 	// Check node-level config first (highest priority)
@@ -181,12 +341,22 @@ func (e *Executor) getToolManagerForNode(node *spec.Node) (*tools.Manager, error
 		return e.getOrCreateToolManager(provider)
 	}
 
-	// No tools configured
+	// No tools configured, but the run's attachments can still be read
+	if len(e.attachments) > 0 && e.replay == nil {
+		return e.getOrCreateToolManager(attachmentProviderName)
+	}
 	return nil, nil
 }
 
 // Execute runs the agent
 func (e *Executor) Execute(input string) (string, error) {
+	return e.ExecuteContext(context.Background(), input)
+}
+
+// ExecuteContext runs the agent under ctx. Cancelling ctx aborts in-flight
+// LLM and tool calls, and node spans are recorded as children of the span
+// in ctx (the execution span when run by an execution.Manager).
+func (e *Executor) ExecuteContext(ctx context.Context, input string) (string, error) {
 	startTime := time.Now()
 
 	// Log start
@@ -195,8 +365,8 @@ func (e *Executor) Execute(input string) (string, error) {
 
 	// Print to stdout if CLI mode
 	if e.useCLI {
-		fmt.Printf("🚀 Starting agent: %s\n", e.spec.Goal)
-		fmt.Printf("📋 Version: %s\n\n", e.spec.Version)
+		ui.Infof("🚀 Starting agent: %s\n", e.spec.Goal)
+		ui.Infof("📋 Version: %s\n\n", e.spec.Version)
 	}
 
 	// Initialize metadata
@@ -205,127 +375,185 @@ func (e *Executor) Execute(input string) (string, error) {
 	}
 	e.spec.Metadata.ExecutedAt = time.Now().Format(time.RFC3339)
 	e.spec.Metadata.Status = "running"
+	e.spec.Metadata.Attachments = e.attachmentInfo()
 
-	// Find starting nodes (routes from "start")
-	startingNodes := e.findNodesFrom("start")
+	// Check the agent against the policy, then find starting nodes (routes from "start")
+	e.input = input
+	if err := e.checkPolicy(input); err != nil {
+		e.finishMetadata("failed", startTime)
+		e.logger.Error("Policy check failed: %v", err)
+		return "", err
+	}
+	startingNodes, err := e.nextNodes("start", input)
+	if err != nil {
+		e.finishMetadata("failed", startTime)
+		e.logger.Error("Routing failed: %v", err)
+		return "", fmt.Errorf("routing failed: %w", err)
+	}
 	if len(startingNodes) == 0 {
 		return "", fmt.Errorf("no routes from 'start' found")
 	}
 
-	// Execute starting nodes
+	// Execute starting nodes, parallel branches first
 	currentOutput := input
+	branches, startingNodes := e.splitBranches("start", startingNodes)
+	if len(branches) > 0 {
+		output, err := e.runBranches(ctx, "start", branches, currentOutput, "")
+		if err != nil {
+			e.finishMetadata("failed", startTime)
+			e.logger.Error("Execution failed: %v", err)
+			return "", fmt.Errorf("execution failed: %w", err)
+		}
+		currentOutput = output
+	}
 	for _, nodeID := range startingNodes {
-		output, err := e.executeNode(nodeID, currentOutput)
+		output, err := e.executeNode(ctx, nodeID, currentOutput)
 		if err != nil {
-			e.spec.Metadata.Status = "failed"
+			e.finishMetadata("failed", startTime)
 			e.logger.Error("Execution failed at node %s: %v", nodeID, err)
 			return "", fmt.Errorf("execution failed at node %s: %w", nodeID, err)
 		}
 		currentOutput = output
 
 		// Follow routes from this node
-		nextOutput, err := e.followRoutes(nodeID, currentOutput)
+		nextOutput, err := e.followRoutes(ctx, nodeID, currentOutput)
 		if err != nil {
-			e.spec.Metadata.Status = "failed"
+			e.finishMetadata("failed", startTime)
 			e.logger.Error("Routing failed: %v", err)
 			return "", fmt.Errorf("routing failed: %w", err)
 		}
 		currentOutput = nextOutput
 	}
 
-	// Update metadata
-	e.spec.Metadata.ExecutionTimeMs = time.Since(startTime).Milliseconds()
-	e.spec.Metadata.Status = "success"
-
-	// Calculate total cost
-	totalCost := 0.0
-	for _, result := range e.results {
-		totalCost += result.Cost
+	// Hold the final output to the spec's output contract, if any
+	currentOutput, e.spec.Metadata.OutputViolations = applyOutputContract(e.spec.Output, currentOutput)
+	for _, violation := range e.spec.Metadata.OutputViolations {
+		e.logger.Error("Output contract violation: %s", violation)
+		if e.useCLI {
+			ui.Infof("⚠️  Output contract violation: %s\n", violation)
+		}
 	}
-	e.spec.Metadata.TotalCost = totalCost
 
-	// Convert results map to slice
-	var nodeResults []spec.NodeResult
-	for _, result := range e.results {
-		nodeResults = append(nodeResults, *result)
+	// Shape the output for delivery with the spec's post-processors, if any
+	currentOutput, problems := postprocess(e.spec.Postprocess, currentOutput)
+	for _, problem := range problems {
+		e.logger.Error("Post-processing skipped a step: %s", problem)
+		if e.useCLI {
+			ui.Infof("⚠️  Post-processing skipped a step: %s\n", problem)
+		}
 	}
-	e.spec.Metadata.NodeResults = nodeResults
+
+	e.finishMetadata("success", startTime)
+	totalCost := e.spec.Metadata.TotalCost
 
 	// Log completion
 	e.logger.Info("Execution completed successfully in %dms", e.spec.Metadata.ExecutionTimeMs)
-	e.logger.Info("Total cost: $%.4f", totalCost)
+	e.logger.Info("Total cost: $%.4f (%d prompt + %d completion tokens)", totalCost, e.spec.Metadata.PromptTokens, e.spec.Metadata.CompletionTokens)
 
 	// Print to stdout if CLI mode
 	if e.useCLI {
-		fmt.Printf("\n✅ Execution completed in %dms\n", e.spec.Metadata.ExecutionTimeMs)
-		fmt.Printf("💰 Total cost: $%.4f\n\n", totalCost)
+		ui.Infof("\n✅ Execution completed in %dms\n", e.spec.Metadata.ExecutionTimeMs)
+		ui.Infof("💰 Total cost: $%.4f\n\n", totalCost)
 	}
 
 	return currentOutput, nil
 }
 
+// finishMetadata records the final status, duration, cost and token usage.
+// Failed runs are totalled too, since their completed nodes were paid for.
+func (e *Executor) finishMetadata(status string, startTime time.Time) {
+	e.spec.Metadata.ExecutionTimeMs = time.Since(startTime).Milliseconds()
+	e.spec.Metadata.Status = status
+
+	e.spec.Metadata.TotalCost = 0
+	e.spec.Metadata.PromptTokens, e.spec.Metadata.CompletionTokens = 0, 0
+	nodeResults := make([]spec.NodeResult, 0, len(e.results))
+	for _, result := range e.results {
+		e.spec.Metadata.TotalCost += result.Cost
+		e.spec.Metadata.PromptTokens += result.PromptTokens
+		e.spec.Metadata.CompletionTokens += result.CompletionTokens
+		nodeResults = append(nodeResults, *result)
+	}
+	e.spec.Metadata.NodeResults = nodeResults
+}
+
 // executeNode executes a single node
-func (e *Executor) executeNode(nodeID string, input string) (string, error) {
+func (e *Executor) executeNode(ctx context.Context, nodeID string, input string) (string, error) {
 	node := e.nodeMap[nodeID]
 	if node == nil {
 		return "", fmt.Errorf("node not found: %s", nodeID)
 	}
 
+	ctx, span := tracer.Start(ctx, "node "+nodeID, trace.WithAttributes(
+		tracing.NodeIDKey.String(nodeID),
+		tracing.NodeTypeKey.String(node.Type),
+	))
+
+	// Keep this node's log lines with its result
+	e.nodeLogs.start()
+
 	// Log node execution
 	e.logger.Info("Executing node: %s (%s)", node.Name, node.Type)
+	e.logger.Debug("Node %s input: %s", nodeID, input)
 
 	// Print to stdout if CLI mode
 	if e.useCLI {
-		fmt.Printf("⚙️  Executing node: %s (%s)\n", node.Name, node.Type)
+		ui.Infof("⚙️  Executing node: %s (%s)\n", node.Name, node.Type)
 	}
 
+	e.emit(Event{Type: EventNodeStarted, NodeID: nodeID, NodeType: node.Type})
+
 	startTime := time.Now()
 
 	result := &spec.NodeResult{
-		NodeID: nodeID,
-		Input:  input,
-		Status: "running",
+		NodeID:      nodeID,
+		Annotations: node.Annotations,
+		Input:       input,
+		Status:      "running",
 	}
+	defer func() {
+		result.Logs, result.LogsDropped = e.nodeLogs.stop()
+	}()
 
+	// Hooks may rewrite the input, fail the node or rewrite its output
+	call := e.newNodeCall(ctx, node, input)
 	var output string
-	var cost float64
-	var err error
+	var used usage
 	var reactTrace *spec.ReActTrace
-
-	switch node.Type {
-	case "llm":
-		output, cost, err = e.executeLLMNode(node, input)
-	case "react":
-		// Check if tools are enabled for this node
-		if node.ToolsEnabled {
-			// Resolve tool manager for this node
-			toolMgr, toolErr := e.getToolManagerForNode(node)
-			if toolErr != nil {
-				err = fmt.Errorf("failed to get tool manager: %w", toolErr)
-			} else if toolMgr != nil && toolMgr.HasTools() {
-				output, cost, reactTrace, err = e.executeReActNodeWithTools(node, input, toolMgr)
-			} else {
-				output, cost, reactTrace, err = e.executeReActNode(node, input)
-			}
-		} else {
-			output, cost, reactTrace, err = e.executeReActNode(node, input)
-		}
-	case "tool":
-		output, cost, err = e.executeToolNode(node, input)
-	default:
-		err = fmt.Errorf("unsupported node type: %s", node.Type)
+	err := e.beforeNode(ctx, call)
+	if err == nil {
+		result.Input = call.Input
+		output, used, reactTrace, err = e.runNode(ctx, node, call.Input)
+		output, err = e.afterNode(ctx, call, output, used, err, startTime)
 	}
+	cost := used.cost
 	result.Cost = cost
+	result.PromptTokens = used.promptTokens
+	result.CompletionTokens = used.completionTokens
+	result.Model = used.model
+	span.SetAttributes(
+		tracing.CostKey.Float64(cost),
+		tracing.PromptTokensKey.Int(used.promptTokens),
+		tracing.CompletionTokensKey.Int(used.completionTokens),
+	)
+	tracing.End(span, err)
 
 	result.ExecutionTimeMs = time.Since(startTime).Milliseconds()
 	result.ReActTrace = reactTrace
+	result.Classification = e.labels[nodeID]
+	result.OutputAttempts = e.attempts[nodeID]
 
 	if err != nil {
 		result.Status = "failed"
+		var blocked *BlockedError
+		if errors.As(err, &blocked) {
+			result.Status = "blocked"
+		}
 		result.Error = err.Error()
 		e.results[nodeID] = result
 		e.logger.Error("Node %s failed: %v", nodeID, err)
-		return "", err
+		e.emit(Event{Type: EventNodeFailed, NodeID: nodeID, NodeType: node.Type, Error: err.Error(), Cost: cost, DurationMs: result.ExecutionTimeMs})
+		return "", &NodeError{NodeID: nodeID, NodeType: node.Type, Err: err}
 	}
 
 	result.Status = "success"
@@ -333,116 +561,214 @@ func (e *Executor) executeNode(nodeID string, input string) (string, error) {
 	e.results[nodeID] = result
 
 	// Log completion
-	e.logger.Info("Node %s completed in %dms (cost: $%.4f)", nodeID, result.ExecutionTimeMs, cost)
+	e.logger.Info("Node %s completed in %dms (cost: $%.4f, tokens: %d prompt + %d completion)", nodeID, result.ExecutionTimeMs, cost, used.promptTokens, used.completionTokens)
+	e.logger.Debug("Node %s output: %s", nodeID, output)
+	e.emit(Event{Type: EventNodeCompleted, NodeID: nodeID, NodeType: node.Type, Output: output, Cost: cost, DurationMs: result.ExecutionTimeMs})
 
 	// Print to stdout if CLI mode
 	if e.useCLI {
-		fmt.Printf("   ✓ Completed in %dms (cost: $%.4f)\n", result.ExecutionTimeMs, cost)
+		ui.Infof("   ✓ Completed in %dms (cost: $%.4f)\n", result.ExecutionTimeMs, cost)
 	}
 
 	return output, nil
 }
 
+// runNode dispatches a node to its implementation. A panic in the node is
+// recovered and returned as a *PanicError.
+func (e *Executor) runNode(ctx context.Context, node *spec.Node, input string) (output string, used usage, reactTrace *spec.ReActTrace, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = NewPanicError(r)
+		}
+	}()
+
+	ctx = context.WithValue(ctx, nodeIDKey{}, node.ID)
+	if err := e.moderate(ctx, node, spec.ModerateInput, input); err != nil {
+		return "", usage{}, nil, err
+	}
+	if e.replay != nil {
+		defer func() {
+			// A node that failed in the recording fails with the same error
+			if err != nil {
+				if recorded := e.replay.nodeError(node.ID); recorded != nil {
+					err = recorded
+				}
+			}
+		}()
+	}
+
+	switch node.Type {
+	case "llm":
+		output, used, err = e.executeLLMNode(ctx, node, input)
+	case "react":
+		// Check if tools are enabled for this node
+		if node.ToolsEnabled {
+			// Resolve tool manager for this node
+			toolMgr, toolErr := e.getToolManagerForNode(node)
+			if toolErr != nil {
+				err = fmt.Errorf("failed to get tool manager: %w", toolErr)
+			} else if toolMgr != nil && toolMgr.HasTools() {
+				output, used, reactTrace, err = e.executeReActNodeWithTools(ctx, node, input, toolMgr)
+			} else {
+				output, used, reactTrace, err = e.executeReActNode(ctx, node, input)
+			}
+		} else {
+			output, used, reactTrace, err = e.executeReActNode(ctx, node, input)
+		}
+	case "tool":
+		output, err = e.executeToolNode(ctx, node, input)
+	case "retrieve":
+		output, used, err = e.executeRetrieveNode(ctx, node, input)
+	case "conditional":
+		output, used, err = e.executeConditionalNode(ctx, node, input)
+	case "transform":
+		output, err = e.executeTransformNode(ctx, node, input)
+	case "sink":
+		output, err = e.executeSinkNode(ctx, node, input)
+	default:
+		if handler, ok := lookupNodeType(node.Type); ok {
+			output, err = e.executeCustomNode(ctx, node, handler, input)
+		} else {
+			err = fmt.Errorf("unsupported node type: %s", node.Type)
+		}
+	}
+
+	if err == nil {
+		err = e.moderate(ctx, node, spec.ModerateOutput, output)
+	}
+	if err == nil && node.Artifact != "" {
+		err = e.saveArtifact(ctx, node.ID, "", tools.Artifact{Name: node.Artifact, Data: []byte(output)})
+	}
+	return output, used, reactTrace, err
+}
+
 // executeToolNode executes an explicit tool node
-func (e *Executor) executeToolNode(node *spec.Node, input string) (string, float64, error) {
+func (e *Executor) executeToolNode(ctx context.Context, node *spec.Node, input string) (string, error) {
 	// Resolve tool manager for this node
 	toolMgr, err := e.getToolManagerForNode(node)
 	if err != nil {
-		return "", 0, fmt.Errorf("failed to get tool manager: %w", err)
+		return "", fmt.Errorf("failed to get tool manager: %w", err)
 	}
 	if toolMgr == nil {
-		return "", 0, fmt.Errorf("tool manager not initialized - tools not configured")
+		return "", fmt.Errorf("tool manager not initialized - tools not configured")
 	}
 
 	if node.ToolName == "" {
-		return "", 0, fmt.Errorf("tool_name is required for tool nodes")
+		return "", fmt.Errorf("tool_name is required for tool nodes")
 	}
 
 	e.logger.Info("Executing tool: %s", node.ToolName)
 
-	// Prepare arguments
-	args := node.ToolArguments
-	if args == nil {
-		args = make(map[string]interface{})
-	}
-
-	// Support {{input}} placeholder in arguments
-	for key, val := range args {
-		if strVal, ok := val.(string); ok && strVal == "{{input}}" {
-			args[key] = input
+	// Prepare arguments, rendering their templates against the input
+	args := make(map[string]interface{})
+	if node.ToolArguments != nil {
+		rendered, err := tmpl.RenderValue(node.ToolArguments, input)
+		if err != nil {
+			return "", fmt.Errorf("tool_arguments.%w", err)
 		}
+		args = rendered.(map[string]interface{})
 	}
 
-	// Execute tool
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	e.logger.Debug("Tool %s arguments: %v", node.ToolName, args)
+
+	// Secrets are filled in last, so only the tool sees their values
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
+	args, err = e.resolveSecretArgs(ctx, node.ToolArguments, args)
+	if err != nil {
+		return "", fmt.Errorf("tool_arguments: %w", err)
+	}
+	if err := e.checkToolCall(node.ToolName, args); err != nil {
+		return "", err
+	}
 
-	result, err := toolMgr.ExecuteTool(ctx, node.ToolName, args)
+	// Execute tool
+	result, err := toolMgr.ExecuteTool(e.policyContext(ctx), node.ToolName, args)
 	if err != nil {
-		return "", 0, fmt.Errorf("tool execution failed: %w", err)
+		return "", fmt.Errorf("tool execution failed: %s", e.scrubSecrets(err.Error()))
 	}
 
 	if !result.Success {
-		return "", 0, fmt.Errorf("tool returned error: %s", result.Error)
+		return "", fmt.Errorf("tool returned error: %s", e.scrubSecrets(result.Error))
+	}
+	if err := e.saveToolArtifacts(ctx, node.ID, node.ToolName, result); err != nil {
+		return "", err
 	}
 
 	// Convert output to string
-	output := fmt.Sprintf("%v", result.Output)
-	return output, 0, nil
+	output := e.scrubSecrets(fmt.Sprintf("%v", result.Output))
+	return output, nil
 }
 
 // executeLLMNode executes an LLM node
-func (e *Executor) executeLLMNode(node *spec.Node, input string) (string, float64, error) {
-	// Determine LLM config (node-specific or global)
-	llmConfig := node.LLM
-	if llmConfig == nil && e.spec.Config != nil {
-		llmConfig = e.spec.Config.LLM
-	}
-	if llmConfig == nil {
-		return "", 0, fmt.Errorf("no LLM configuration found")
+func (e *Executor) executeLLMNode(ctx context.Context, node *spec.Node, input string) (string, usage, error) {
+	llmConfig, err := e.llmConfigFor(node)
+	if err != nil {
+		return "", usage{}, err
 	}
 
-	// Set defaults
-	if llmConfig.Model == "" {
-		llmConfig.Model = "gpt-3.5-turbo"
-	}
-	if llmConfig.Temperature == 0 {
-		llmConfig.Temperature = 0.7
+	prompt, err := tmpl.Render(node.Prompt, input)
+	if err != nil {
+		return "", usage{}, fmt.Errorf("prompt: %w", err)
 	}
 
 	// Execute
-	output, cost, err := e.llmClient.Execute(llmConfig, node.Prompt, input)
+	completion, err := e.complete(ctx, llmConfig, prompt, input)
 	if err != nil {
-		return "", 0, err
+		return "", usage{}, err
 	}
 
-	return output, cost, nil
+	var used usage
+	used.addCompletion(completion)
+	if node.Output != nil {
+		output, err := e.enforceNodeContract(ctx, node, llmConfig, prompt, input, completion, &used)
+		return output, used, err
+	}
+	return completion.Content, used, nil
 }
 
 // followRoutes follows routes from a node
-func (e *Executor) followRoutes(fromNodeID string, input string) (string, error) {
-	nextNodes := e.findNodesFrom(fromNodeID)
+func (e *Executor) followRoutes(ctx context.Context, fromNodeID string, input string) (string, error) {
+	return e.followRoutesUntil(ctx, fromNodeID, input, "")
+}
+
+// followRoutesUntil follows routes from a node, stopping before stopAt, the
+// join node of the branch being run (if any)
+func (e *Executor) followRoutesUntil(ctx context.Context, fromNodeID string, input string, stopAt string) (string, error) {
+	nextNodes, err := e.nextNodes(fromNodeID, input)
+	if err != nil {
+		return "", err
+	}
 	if len(nextNodes) == 0 {
 		// No more routes, we're done
 		return input, nil
 	}
 
-	// For now, just follow the first route (simple linear execution)
-	// TODO: Handle parallel execution, conditionals, etc.
+	// Taken parallel routes run first, as branches
 	currentOutput := input
+	branches, nextNodes := e.splitBranches(fromNodeID, nextNodes)
+	if len(branches) > 0 {
+		output, err := e.runBranches(ctx, fromNodeID, branches, currentOutput, stopAt)
+		if err != nil {
+			return "", err
+		}
+		currentOutput = output
+	}
+
+	// Other taken routes run one after another
 	for _, nodeID := range nextNodes {
-		if nodeID == "end" {
+		if nodeID == "end" || nodeID == stopAt {
 			return currentOutput, nil
 		}
 
-		output, err := e.executeNode(nodeID, currentOutput)
+		output, err := e.executeNode(ctx, nodeID, currentOutput)
 		if err != nil {
 			return "", err
 		}
 		currentOutput = output
 
 		// Recursively follow routes
-		nextOutput, err := e.followRoutes(nodeID, currentOutput)
+		nextOutput, err := e.followRoutesUntil(ctx, nodeID, currentOutput, stopAt)
 		if err != nil {
 			return "", err
 		}
@@ -452,17 +778,6 @@ func (e *Executor) followRoutes(fromNodeID string, input string) (string, error)
 	return currentOutput, nil
 }
 
-// findNodesFrom finds nodes that are routed from the given node
-func (e *Executor) findNodesFrom(fromNodeID string) []string {
-	var nodes []string
-	for _, route := range e.spec.Routes {
-		if route.From == fromNodeID {
-			nodes = append(nodes, route.To)
-		}
-	}
-	return nodes
-}
-
 // GetMetadata returns execution metadata
 func (e *Executor) GetMetadata() *spec.Metadata {
 	return e.spec.Metadata
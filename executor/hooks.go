@@ -0,0 +1,271 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/not7/core/config"
+	"github.com/not7/core/spec"
+	"github.com/not7/core/tracing"
+)
+
+// HookStage is when a hook is called relative to its node
+type HookStage string
+
+const (
+	HookBeforeNode HookStage = "before_node"
+	HookAfterNode  HookStage = "after_node"
+)
+
+// NodeHook is called around every node an executor runs, for validation,
+// enrichment or notifications.
+//
+// BeforeNode may change the call's Input, which the node then runs on, or
+// return an error to fail the node without running it. AfterNode may change
+// the Output of a node that succeeded, or return an error to fail it. After
+// a failed node, Error is set and an error from AfterNode is only logged.
+type NodeHook interface {
+	BeforeNode(ctx context.Context, call *NodeCall) error
+	AfterNode(ctx context.Context, call *NodeCall) error
+}
+
+// NodeHookFuncs adapts a pair of functions to the NodeHook interface. Either
+// may be nil.
+type NodeHookFuncs struct {
+	Before func(ctx context.Context, call *NodeCall) error
+	After  func(ctx context.Context, call *NodeCall) error
+}
+
+// BeforeNode calls f.Before, if set
+func (f NodeHookFuncs) BeforeNode(ctx context.Context, call *NodeCall) error {
+	if f.Before == nil {
+		return nil
+	}
+	return f.Before(ctx, call)
+}
+
+// AfterNode calls f.After, if set
+func (f NodeHookFuncs) AfterNode(ctx context.Context, call *NodeCall) error {
+	if f.After == nil {
+		return nil
+	}
+	return f.After(ctx, call)
+}
+
+// NodeCall is what hooks see of a node run. The fields below Output are set
+// for AfterNode only.
+type NodeCall struct {
+	Stage       HookStage         `json:"stage"`
+	AgentID     string            `json:"agent_id,omitempty"`
+	RequestID   string            `json:"request_id,omitempty"`
+	NodeID      string            `json:"node_id"`
+	NodeName    string            `json:"node_name,omitempty"`
+	NodeType    string            `json:"node_type"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Input       string            `json:"input"`
+	Output      string            `json:"output,omitempty"`
+
+	Error            string  `json:"error,omitempty"` // Why the node failed
+	Model            string  `json:"model,omitempty"`
+	Cost             float64 `json:"cost,omitempty"`
+	PromptTokens     int     `json:"prompt_tokens,omitempty"`
+	CompletionTokens int     `json:"completion_tokens,omitempty"`
+	DurationMs       int64   `json:"duration_ms,omitempty"`
+}
+
+// namedHook is a hook with the name it is reported by
+type namedHook struct {
+	name string
+	hook NodeHook
+}
+
+var (
+	hooks   []namedHook
+	hooksMu sync.RWMutex
+)
+
+// RegisterHook adds a hook that executors created afterwards call around
+// every node, in the order hooks were registered. Registering a name again
+// replaces its hook in place.
+func RegisterHook(name string, hook NodeHook) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	for i := range hooks {
+		if hooks[i].name == name {
+			hooks[i].hook = hook
+			return
+		}
+	}
+	hooks = append(hooks, namedHook{name: name, hook: hook})
+}
+
+// nodeHooks returns the registered hooks followed by the command hooks of
+// cfg
+func nodeHooks(cfg config.HooksConfig) []namedHook {
+	hooksMu.RLock()
+	all := append([]namedHook(nil), hooks...)
+	hooksMu.RUnlock()
+
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if args := strings.Fields(cfg.BeforeNode); len(args) > 0 {
+		all = append(all, namedHook{name: args[0], hook: &commandHook{stage: HookBeforeNode, args: args, timeout: timeout}})
+	}
+	if args := strings.Fields(cfg.AfterNode); len(args) > 0 {
+		all = append(all, namedHook{name: args[0], hook: &commandHook{stage: HookAfterNode, args: args, timeout: timeout}})
+	}
+	return all
+}
+
+// newNodeCall describes a node about to run on input
+func (e *Executor) newNodeCall(ctx context.Context, node *spec.Node, input string) *NodeCall {
+	return &NodeCall{
+		AgentID:     e.spec.ID,
+		RequestID:   tracing.RequestIDFromContext(ctx),
+		NodeID:      node.ID,
+		NodeName:    node.Name,
+		NodeType:    node.Type,
+		Annotations: node.Annotations,
+		Input:       input,
+	}
+}
+
+// beforeNode calls the BeforeNode hooks, stopping at the first that fails
+func (e *Executor) beforeNode(ctx context.Context, call *NodeCall) error {
+	call.Stage = HookBeforeNode
+	for _, h := range e.hooks {
+		if err := runHook(ctx, h, call); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// afterNode tells the AfterNode hooks how the node went and returns the
+// output and error it ends with
+func (e *Executor) afterNode(ctx context.Context, call *NodeCall, output string, used usage, nodeErr error, startTime time.Time) (string, error) {
+	if len(e.hooks) == 0 {
+		return output, nodeErr
+	}
+
+	call.Stage = HookAfterNode
+	call.Output = output
+	call.Model = used.model
+	call.Cost = used.cost
+	call.PromptTokens = used.promptTokens
+	call.CompletionTokens = used.completionTokens
+	call.DurationMs = time.Since(startTime).Milliseconds()
+	if nodeErr != nil {
+		call.Output, call.Error = "", nodeErr.Error()
+	}
+
+	for _, h := range e.hooks {
+		if err := runHook(ctx, h, call); err != nil {
+			if nodeErr != nil {
+				e.logger.Error("Node %s: %v", call.NodeID, err)
+				continue
+			}
+			return "", err
+		}
+	}
+	if nodeErr != nil {
+		return "", nodeErr
+	}
+	return call.Output, nil
+}
+
+// runHook calls a hook for the call's stage. A panic in the hook is
+// recovered and returned as a *PanicError.
+func runHook(ctx context.Context, h namedHook, call *NodeCall) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = NewPanicError(r)
+		}
+		if err != nil {
+			err = fmt.Errorf("%s hook %s: %w", call.Stage, h.name, err)
+		}
+	}()
+
+	if call.Stage == HookBeforeNode {
+		return h.hook.BeforeNode(ctx, call)
+	}
+	return h.hook.AfterNode(ctx, call)
+}
+
+// commandHook runs an external command at one stage. The command reads the
+// NodeCall as JSON on stdin, and a non-zero exit fails the node with its
+// stderr as the reason. It may print a JSON object whose "input" (before the
+// node) or "output" (after it) replaces the node's.
+type commandHook struct {
+	stage   HookStage
+	args    []string
+	timeout time.Duration
+}
+
+// commandReply is what a hook command may print to stdout
+type commandReply struct {
+	Input  *string `json:"input"`
+	Output *string `json:"output"`
+}
+
+// BeforeNode runs the command if it is a before_node hook
+func (h *commandHook) BeforeNode(ctx context.Context, call *NodeCall) error {
+	if h.stage != HookBeforeNode {
+		return nil
+	}
+	reply, err := h.run(ctx, call)
+	if err == nil && reply.Input != nil {
+		call.Input = *reply.Input
+	}
+	return err
+}
+
+// AfterNode runs the command if it is an after_node hook. Commands are told
+// of failed nodes too, but cannot replace their output.
+func (h *commandHook) AfterNode(ctx context.Context, call *NodeCall) error {
+	if h.stage != HookAfterNode {
+		return nil
+	}
+	reply, err := h.run(ctx, call)
+	if err == nil && reply.Output != nil && call.Error == "" {
+		call.Output = *reply.Output
+	}
+	return err
+}
+
+// run starts the command with the call on stdin and parses its reply
+func (h *commandHook) run(ctx context.Context, call *NodeCall) (commandReply, error) {
+	var reply commandReply
+	payload, err := json.Marshal(call)
+	if err != nil {
+		return reply, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, h.args[0], h.args[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return reply, fmt.Errorf("timed out after %s", h.timeout)
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return reply, fmt.Errorf("%w: %s", err, message)
+		}
+		return reply, err
+	}
+
+	if out := bytes.TrimSpace(stdout.Bytes()); len(out) > 0 {
+		if err := json.Unmarshal(out, &reply); err != nil {
+			return reply, fmt.Errorf("invalid reply (expected a JSON object): %w", err)
+		}
+	}
+	return reply, nil
+}
@@ -0,0 +1,233 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/not7/core/config"
+	"github.com/not7/core/llm"
+	"github.com/not7/core/policy"
+	"github.com/not7/core/spec"
+)
+
+// llmConfigFor resolves the LLM settings a node runs with. Each field,
+// fallbacks included, comes from the first of the node's llm, its
+// config.llm and the agent's config.llm that sets it. The provider defaults
+// to OpenAI, the model to the provider's default model and the temperature
+// to the configured default. The result is a copy, so resolving never
+// changes the spec or the config.
+func (e *Executor) llmConfigFor(node *spec.Node) (*spec.LLMConfig, error) {
+	resolved, found := specLLMConfig(e.spec, node)
+	if !found {
+		return nil, fmt.Errorf("no LLM configuration found")
+	}
+
+	resolved.Provider = strings.ToLower(resolved.Provider)
+	if resolved.Provider == "" {
+		resolved.Provider = config.ProviderOpenAI
+	}
+	if resolved.Model == "" {
+		provider, err := e.cfg.LLMProvider(resolved.Provider)
+		if err != nil {
+			return nil, err
+		}
+		resolved.Model = provider.DefaultModel
+	}
+	if resolved.Model == "" {
+		return nil, fmt.Errorf("no model set and %s has no default model", resolved.Provider)
+	}
+	if resolved.Temperature == 0 {
+		resolved.Temperature = e.cfg.OpenAI.DefaultTemperature
+	}
+	return &resolved, nil
+}
+
+// specLLMConfig merges the LLM settings the spec gives a node, as
+// llmConfigFor does before applying the config's defaults. found is false
+// when no layer is set.
+func specLLMConfig(agentSpec *spec.AgentSpec, node *spec.Node) (resolved spec.LLMConfig, found bool) {
+	layers := []*spec.LLMConfig{node.LLM}
+	if node.Config != nil {
+		layers = append(layers, node.Config.LLM)
+	}
+	if agentSpec.Config != nil {
+		layers = append(layers, agentSpec.Config.LLM)
+	}
+
+	for _, layer := range layers {
+		if layer == nil {
+			continue
+		}
+		found = true
+		if resolved.Provider == "" {
+			resolved.Provider = layer.Provider
+		}
+		if resolved.Model == "" {
+			resolved.Model = layer.Model
+		}
+		if resolved.Temperature == 0 {
+			resolved.Temperature = layer.Temperature
+		}
+		if resolved.MaxTokens == 0 {
+			resolved.MaxTokens = layer.MaxTokens
+		}
+		if resolved.Fallbacks == nil {
+			resolved.Fallbacks = append([]spec.LLMConfig(nil), layer.Fallbacks...)
+		}
+	}
+	return resolved, found
+}
+
+// complete runs an LLM call on llmConfig's model. When the call fails, it
+// is retried on each of the fallbacks in turn, and the last error is
+// returned if they all fail. Replays answer as the recorded run did.
+func (e *Executor) complete(ctx context.Context, llmConfig *spec.LLMConfig, prompt, input string) (*llm.Completion, error) {
+	completion, err := e.completeOn(ctx, llmConfig, prompt, input)
+	if err == nil || e.replay != nil || errors.Is(err, policy.ErrDenied) {
+		return completion, err
+	}
+
+	failed := llmConfig
+	for i := range llmConfig.Fallbacks {
+		if ctx.Err() != nil {
+			break
+		}
+		fallback := fallbackConfig(llmConfig, &llmConfig.Fallbacks[i])
+		if fallback.Model == "" {
+			if provider, lookupErr := e.cfg.LLMProvider(fallback.Provider); lookupErr == nil {
+				fallback.Model = provider.DefaultModel
+			}
+		}
+		e.logger.Info("%s/%s failed (%v), falling back to %s/%s", failed.Provider, failed.Model, err, fallback.Provider, fallback.Model)
+
+		completion, err = e.completeOn(ctx, fallback, prompt, input)
+		if err == nil || errors.Is(err, policy.ErrDenied) {
+			return completion, err
+		}
+		failed = fallback
+	}
+	return nil, err
+}
+
+// fallbackConfig returns the settings of a fallback, taking the provider,
+// temperature and token limit of primary where the fallback leaves them out.
+// A fallback that changes the provider does not inherit the model.
+func fallbackConfig(primary, fallback *spec.LLMConfig) *spec.LLMConfig {
+	resolved := spec.LLMConfig{
+		Provider:    strings.ToLower(fallback.Provider),
+		Model:       fallback.Model,
+		Temperature: fallback.Temperature,
+		MaxTokens:   fallback.MaxTokens,
+	}
+	if resolved.Provider == "" {
+		resolved.Provider = primary.Provider
+	}
+	if resolved.Temperature == 0 {
+		resolved.Temperature = primary.Temperature
+	}
+	if resolved.MaxTokens == 0 {
+		resolved.MaxTokens = primary.MaxTokens
+	}
+	return &resolved
+}
+
+// completeOn runs an LLM call on the client of llmConfig's provider, with
+// the running node's personal data redacted unless the provider is local.
+// The completion records the model asked for when the provider names none.
+// Calls the policy denies are not made, nor any call once the execution
+// has cost more than the policy allows.
+func (e *Executor) completeOn(ctx context.Context, llmConfig *spec.LLMConfig, prompt, input string) (*llm.Completion, error) {
+	if err := e.policy.CheckModel(llmConfig.Provider, llmConfig.Model); err != nil {
+		return nil, err
+	}
+	if err := e.policy.CheckCost(e.spent); err != nil {
+		return nil, err
+	}
+	if llmConfig.Provider != config.ProviderOllama {
+		prompt, input = e.redactCall(ctx, prompt, input)
+	}
+
+	var completion *llm.Completion
+	var err error
+	if e.batcher != nil && e.replay == nil && llmConfig.Provider == config.ProviderOpenAI {
+		completion, err = e.completeInBatch(ctx, llmConfig, prompt, input)
+	} else {
+		var c completer
+		if c, err = e.completerFor(llmConfig.Provider); err == nil {
+			completion, err = c.Execute(ctx, llmConfig, prompt, input)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	completion.Content = e.redactor.Restore(completion.Content)
+	if completion.Model == "" {
+		completion.Model = llmConfig.Model
+	}
+	e.spent += completion.Cost
+	return completion, nil
+}
+
+// redactCall replaces the personal data in an LLM call's prompt and input
+// with placeholders, following the running node's redaction config
+func (e *Executor) redactCall(ctx context.Context, prompt, input string) (string, string) {
+	nodeID, _ := ctx.Value(nodeIDKey{}).(string)
+	rules, ok := e.redactions[nodeID]
+	if !ok {
+		return prompt, input
+	}
+	prompt, inPrompt := e.redactor.Redact(rules, prompt)
+	input, inInput := e.redactor.Redact(rules, input)
+	if n := inPrompt + inInput; n > 0 {
+		e.logger.Debug("Redacted %d values from the LLM call of node %s", n, nodeID)
+	}
+	return prompt, input
+}
+
+// completerFor returns the client of the named provider, creating it on
+// first use. Replays answer for every provider.
+func (e *Executor) completerFor(provider string) (completer, error) {
+	if e.replay != nil || provider == "" || provider == config.ProviderOpenAI {
+		return e.completer, nil
+	}
+
+	e.clientsMu.Lock()
+	defer e.clientsMu.Unlock()
+	if c, ok := e.clients[provider]; ok {
+		return c, nil
+	}
+	providerCfg, err := e.cfg.LLMProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+	client, err := llm.NewProviderClient(provider, providerCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LLM client: %w", err)
+	}
+	e.clients[provider] = client
+	return client, nil
+}
+
+// SetBatcher sends the run's OpenAI calls through the Batch API. A node is
+// suspended while its call waits for the batch, which may take hours.
+func (e *Executor) SetBatcher(batcher *llm.Batcher) {
+	e.batcher = batcher
+}
+
+// completeInBatch runs an LLM call as part of a batch, emitting the node's
+// suspension and resumption around it
+func (e *Executor) completeInBatch(ctx context.Context, llmConfig *spec.LLMConfig, prompt, input string) (*llm.Completion, error) {
+	nodeID, _ := ctx.Value(nodeIDKey{}).(string)
+	e.logger.Info("Node %s suspended until the batch with its %s call finishes", nodeID, llmConfig.Model)
+	e.emit(Event{Type: EventNodeSuspended, NodeID: nodeID, Message: "waiting for batch"})
+
+	start := time.Now()
+	completion, err := e.batcher.Execute(ctx, llmConfig, prompt, input)
+	e.logger.Info("Node %s resumed after %s", nodeID, time.Since(start).Round(time.Second))
+	e.emit(Event{Type: EventNodeResumed, NodeID: nodeID, DurationMs: time.Since(start).Milliseconds()})
+	return completion, err
+}
@@ -0,0 +1,70 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/not7/core/spec"
+)
+
+// moderate checks text against the node's moderation policy at stage and
+// returns a *BlockedError when the policy rejects it. Local rules are
+// checked first; the moderation endpoint is not called when replaying.
+func (e *Executor) moderate(ctx context.Context, node *spec.Node, stage, text string) error {
+	policy := spec.ModerationFor(e.spec, node)
+	if policy == nil || !policy.Checks(stage) || strings.TrimSpace(text) == "" {
+		return nil
+	}
+
+	patterns, err := policy.BlockedPatterns()
+	if err != nil {
+		return fmt.Errorf("moderation: %w", err)
+	}
+	for _, pattern := range patterns {
+		if pattern.MatchString(text) {
+			return e.blocked(node, stage, fmt.Sprintf("matches blocked pattern %q", pattern))
+		}
+	}
+
+	if policy.Provider == "" || e.llmClient == nil {
+		return nil
+	}
+	moderation, err := e.llmClient.Moderate(ctx, text)
+	if err != nil {
+		return fmt.Errorf("moderation: %w", err)
+	}
+	if !moderation.Flagged {
+		return nil
+	}
+	categories := moderation.Categories
+	if len(policy.Categories) > 0 {
+		categories = intersect(categories, policy.Categories)
+		if len(categories) == 0 {
+			e.logger.Debug("Node %s %s flagged for %s, which the policy allows", node.ID, stage, strings.Join(moderation.Categories, ", "))
+			return nil
+		}
+	}
+	return e.blocked(node, stage, "flagged for "+strings.Join(categories, ", "))
+}
+
+// blocked logs a moderation block and returns its error
+func (e *Executor) blocked(node *spec.Node, stage, reason string) error {
+	e.logger.Error("Node %s %s blocked by moderation: %s", node.ID, stage, reason)
+	return &BlockedError{Stage: stage, Reason: reason}
+}
+
+// intersect returns the values of a that are also in b, in a's order
+func intersect(a, b []string) []string {
+	in := make(map[string]bool, len(b))
+	for _, value := range b {
+		in[value] = true
+	}
+	var both []string
+	for _, value := range a {
+		if in[value] {
+			both = append(both, value)
+		}
+	}
+	return both
+}
@@ -0,0 +1,73 @@
+package executor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/not7/core/logger"
+)
+
+// maxNodeLogLines bounds the lines kept per node; older lines are dropped first
+const maxNodeLogLines = 200
+
+// maxNodeLogLineLength truncates long lines, such as debug dumps of node input
+const maxNodeLogLineLength = 1000
+
+// nodeLogCapture forwards log calls to the execution logger and, while a
+// node runs, keeps a copy of them for its NodeResult. Nodes run one at a
+// time, so a single buffer is enough.
+type nodeLogCapture struct {
+	next    Logger
+	active  bool
+	lines   []string
+	dropped int
+}
+
+// start begins capturing lines for a new node
+func (c *nodeLogCapture) start() {
+	c.active = true
+	c.lines = nil
+	c.dropped = 0
+}
+
+// stop ends the capture and returns the lines kept and the number dropped
+func (c *nodeLogCapture) stop() ([]string, int) {
+	c.active = false
+	return c.lines, c.dropped
+}
+
+func (c *nodeLogCapture) Info(format string, args ...interface{}) {
+	c.next.Info(format, args...)
+	c.capture(logger.INFO, format, args)
+}
+
+func (c *nodeLogCapture) Error(format string, args ...interface{}) {
+	c.next.Error(format, args...)
+	c.capture(logger.ERROR, format, args)
+}
+
+func (c *nodeLogCapture) Debug(format string, args ...interface{}) {
+	c.next.Debug(format, args...)
+	c.capture(logger.DEBUG, format, args)
+}
+
+// capture records a line at the levels the execution logger writes
+func (c *nodeLogCapture) capture(level logger.Level, format string, args []interface{}) {
+	if !c.active {
+		return
+	}
+	if l, ok := c.next.(interface{ Enabled(logger.Level) bool }); ok && !l.Enabled(level) {
+		return
+	}
+
+	message := fmt.Sprintf(format, args...)
+	if len(message) > maxNodeLogLineLength {
+		message = message[:maxNodeLogLineLength] + "... [truncated]"
+	}
+
+	if len(c.lines) == maxNodeLogLines {
+		c.lines = c.lines[1:]
+		c.dropped++
+	}
+	c.lines = append(c.lines, fmt.Sprintf("[%s] [%s] %s", time.Now().Format("15:04:05"), level, message))
+}
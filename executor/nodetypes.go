@@ -0,0 +1,88 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/not7/core/internal/tmpl"
+	"github.com/not7/core/spec"
+)
+
+// builtinNodeTypes are the node types the executor implements itself
+var builtinNodeTypes = []string{"llm", "react", "tool", "retrieve", "conditional", "transform", "sink"}
+
+// NodeRun is what the handler of a registered node type runs on
+type NodeRun struct {
+	Node   *spec.Node
+	Input  string
+	Params map[string]interface{} // The node's params, rendered against the input
+	Logger Logger                 // Lines logged here are kept with the node's result
+}
+
+// NodeHandler implements a registered node type. Its output is the node's
+// output, passed along the node's routes like that of any other node.
+type NodeHandler func(ctx context.Context, run *NodeRun) (string, error)
+
+var (
+	nodeTypes   = map[string]NodeHandler{}
+	nodeTypesMu sync.RWMutex
+)
+
+// RegisterNodeType adds or replaces the handler of a node type, so specs
+// run by executors in this process may use nodes of that type. The nodes
+// are traced, routed and hooked like built-in ones. Built-in types cannot
+// be replaced.
+func RegisterNodeType(name string, handler NodeHandler) error {
+	if name == "" {
+		return fmt.Errorf("node type name is required")
+	}
+	if slices.Contains(builtinNodeTypes, name) {
+		return fmt.Errorf("node type %q is built in", name)
+	}
+	if handler == nil {
+		return fmt.Errorf("handler is required for node type %q", name)
+	}
+	nodeTypesMu.Lock()
+	defer nodeTypesMu.Unlock()
+	nodeTypes[name] = handler
+	return nil
+}
+
+// NodeTypes returns the registered node types, sorted. Built-in types are
+// not included.
+func NodeTypes() []string {
+	nodeTypesMu.RLock()
+	defer nodeTypesMu.RUnlock()
+
+	names := make([]string, 0, len(nodeTypes))
+	for name := range nodeTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupNodeType finds the handler registered for a node type
+func lookupNodeType(name string) (NodeHandler, bool) {
+	nodeTypesMu.RLock()
+	defer nodeTypesMu.RUnlock()
+	handler, ok := nodeTypes[name]
+	return handler, ok
+}
+
+// executeCustomNode runs a node of a registered type
+func (e *Executor) executeCustomNode(ctx context.Context, node *spec.Node, handler NodeHandler, input string) (string, error) {
+	params := make(map[string]interface{})
+	if node.Params != nil {
+		rendered, err := tmpl.RenderValue(node.Params, input)
+		if err != nil {
+			return "", fmt.Errorf("params.%w", err)
+		}
+		params = rendered.(map[string]interface{})
+	}
+
+	return handler(ctx, &NodeRun{Node: node, Input: input, Params: params, Logger: e.logger})
+}
@@ -0,0 +1,195 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/not7/core/internal/jsonschema"
+	"github.com/not7/core/llm"
+	"github.com/not7/core/spec"
+)
+
+// applyOutputContract coerces the final output towards the spec's output
+// contract and returns it with the ways it still falls short. Models often
+// wrap JSON in prose or a markdown fence, so that wrapping is removed; the
+// output is otherwise left as produced.
+func applyOutputContract(contract *spec.OutputContract, output string) (string, []string) {
+	if contract == nil {
+		return output, nil
+	}
+	if strings.TrimSpace(output) == "" {
+		return output, []string{"output is empty"}
+	}
+
+	switch contract.Format {
+	case spec.OutputJSON:
+		return coerceJSON(contract.Schema, output)
+	case spec.OutputMarkdown:
+		if body, lang, ok := unfence(output); ok && (lang == "" || lang == "markdown" || lang == "md") {
+			return body, nil
+		}
+		return output, nil
+	default:
+		return output, nil
+	}
+}
+
+// enforceNodeContract holds an llm node's answer to the node's output
+// contract. A rejected answer is sent back to the model with the violations
+// until it complies or the contract's retries run out, which fails the node.
+// When an answer was rejected, every attempt is kept for the trace.
+func (e *Executor) enforceNodeContract(ctx context.Context, node *spec.Node, llmConfig *spec.LLMConfig, prompt, input string, completion *llm.Completion, used *usage) (string, error) {
+	var attempts []spec.OutputAttempt
+	retries := node.Output.OutputRetries()
+	for attempt := 1; ; attempt++ {
+		output, violations := applyOutputContract(node.Output, completion.Content)
+		attempts = append(attempts, spec.OutputAttempt{
+			Attempt:    attempt,
+			Output:     completion.Content,
+			Violations: violations,
+			Cost:       completion.Cost,
+		})
+		if len(violations) == 0 {
+			if attempt > 1 {
+				e.attempts[node.ID] = attempts
+			}
+			return output, nil
+		}
+
+		e.attempts[node.ID] = attempts
+		e.logger.Info("Attempt %d of node %s broke its output contract: %s", attempt, node.ID, strings.Join(violations, "; "))
+		if attempt > retries {
+			return "", fmt.Errorf("output breaks the node's output contract after %d attempts: %s", attempt, strings.Join(violations, "; "))
+		}
+
+		var err error
+		completion, err = e.complete(ctx, llmConfig, prompt, correctionInput(input, completion.Content, node.Output, violations))
+		if err != nil {
+			return "", err
+		}
+		used.addCompletion(completion)
+	}
+}
+
+// correctionInput asks the model to answer again, showing its rejected
+// answer and what was wrong with it
+func correctionInput(input, answer string, contract *spec.OutputContract, violations []string) string {
+	var b strings.Builder
+	if input != "" {
+		b.WriteString(input)
+		b.WriteString("\n\n")
+	}
+	b.WriteString("Your previous answer was:\n")
+	b.WriteString(answer)
+	b.WriteString("\n\nIt was rejected because:\n")
+	for _, violation := range violations {
+		b.WriteString("- " + violation + "\n")
+	}
+	switch contract.Format {
+	case spec.OutputJSON:
+		b.WriteString("\nAnswer again with only the corrected JSON document")
+		if contract.Schema != nil {
+			if schema, err := json.Marshal(contract.Schema); err == nil {
+				b.WriteString(", matching this JSON Schema:\n")
+				b.Write(schema)
+			}
+		}
+		b.WriteString("\n")
+	default:
+		b.WriteString("\nAnswer again, fixing these problems.\n")
+	}
+	return b.String()
+}
+
+// coerceJSON extracts the JSON document from output, indents it and checks
+// it against schema
+func coerceJSON(schema map[string]interface{}, output string) (string, []string) {
+	text := strings.TrimSpace(output)
+	if body, _, ok := unfence(text); ok {
+		text = body
+	}
+	if !json.Valid([]byte(text)) {
+		text = outermostJSON(text)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return output, []string{fmt.Sprintf("output is not valid JSON: %v", err)}
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, []byte(text), "", "  "); err == nil {
+		text = indented.String()
+	}
+
+	if schema == nil {
+		return text, nil
+	}
+
+	var violations []string
+	if err := jsonschema.Validate(schema, value); err != nil {
+		var schemaErr *jsonschema.Error
+		if !errors.As(err, &schemaErr) {
+			return text, []string{err.Error()}
+		}
+		for _, problem := range schemaErr.Problems {
+			violations = append(violations, problem.String())
+		}
+	}
+	return text, violations
+}
+
+// decodeJSONOutput decodes the JSON document in a node's output, which may
+// be wrapped in a markdown fence or prose
+func decodeJSONOutput(output string) (interface{}, bool) {
+	text := strings.TrimSpace(output)
+	if body, _, ok := unfence(text); ok {
+		text = body
+	}
+	if !json.Valid([]byte(text)) {
+		text = outermostJSON(text)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// unfence returns the body and language of text that is a single fenced
+// code block, such as ```json ... ```
+func unfence(text string) (body, lang string, ok bool) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "```") || !strings.HasSuffix(text, "```") || len(text) < 6 {
+		return "", "", false
+	}
+
+	firstLine, rest, found := strings.Cut(text[3:len(text)-3], "\n")
+	if !found || strings.Contains(rest, "\n```") {
+		return "", "", false
+	}
+	return strings.TrimSpace(rest), strings.ToLower(strings.TrimSpace(firstLine)), true
+}
+
+// outermostJSON returns the span from the first opening brace or bracket to
+// the last matching closer, to drop prose around a JSON document
+func outermostJSON(text string) string {
+	start := strings.IndexAny(text, "{[")
+	if start < 0 {
+		return text
+	}
+	closer := "}"
+	if text[start] == '[' {
+		closer = "]"
+	}
+	end := strings.LastIndex(text, closer)
+	if end < start {
+		return text
+	}
+	return text[start : end+1]
+}
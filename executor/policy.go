@@ -0,0 +1,127 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/not7/core/config"
+	"github.com/not7/core/policy"
+	"github.com/not7/core/spec"
+	"github.com/not7/core/tools"
+)
+
+// webFetchTool is the builtin tool whose URLs the policy's domain rules cover
+const webFetchTool = "WebFetch"
+
+// CheckPolicy reports the first thing an agent would do that the policy of
+// cfg denies: a model or provider a node calls, fallbacks included, a tool
+// it names, or a worst-case estimated cost over the limit. The returned
+// error wraps policy.ErrDenied. Calls are checked again as the agent runs.
+func CheckPolicy(agentSpec *spec.AgentSpec, cfg *config.Config, input string) error {
+	if cfg == nil {
+		return nil
+	}
+	e := &Executor{spec: agentSpec, cfg: cfg, policy: policy.New(cfg.Policy)}
+	return e.checkPolicy(input)
+}
+
+// checkPolicy checks the agent against the executor's policy before it runs
+func (e *Executor) checkPolicy(input string) error {
+	if e.policy == nil {
+		return nil
+	}
+
+	for i := range e.spec.Nodes {
+		node := &e.spec.Nodes[i]
+		if callsLLM(node) {
+			llmConfig, err := e.llmConfigFor(node)
+			if err != nil {
+				// Left for the node to report when it runs
+				continue
+			}
+			if err := e.policy.CheckModel(llmConfig.Provider, llmConfig.Model); err != nil {
+				return fmt.Errorf("node %s: %w", node.ID, err)
+			}
+			for j := range llmConfig.Fallbacks {
+				fallback := fallbackConfig(llmConfig, &llmConfig.Fallbacks[j])
+				if fallback.Model == "" {
+					if provider, err := e.cfg.LLMProvider(fallback.Provider); err == nil {
+						fallback.Model = provider.DefaultModel
+					}
+				}
+				if err := e.policy.CheckModel(fallback.Provider, fallback.Model); err != nil {
+					return fmt.Errorf("node %s fallback: %w", node.ID, err)
+				}
+			}
+		}
+		for _, name := range namedTools(node) {
+			if err := e.policy.CheckTool(name); err != nil {
+				return fmt.Errorf("node %s: %w", node.ID, err)
+			}
+		}
+	}
+
+	if e.spec.Config != nil && e.spec.Config.Tools != nil {
+		for _, name := range e.spec.Config.Tools.Enabled {
+			if err := e.policy.CheckTool(name); err != nil {
+				return fmt.Errorf("config: %w", err)
+			}
+		}
+	}
+
+	// Nodes are priced at the models they run on; those whose model does not
+	// resolve are left for the node to report when it runs
+	estimate := estimateCost(e.spec, input, func(node *spec.Node) *spec.LLMConfig {
+		llmConfig, _ := e.llmConfigFor(node)
+		return llmConfig
+	})
+	if err := e.policy.CheckCost(estimate.TotalCost); err != nil {
+		return fmt.Errorf("%w (worst-case estimate)", err)
+	}
+	return nil
+}
+
+// checkToolCall checks a tool call against the policy: the tool, and for
+// WebFetch the URL it fetches
+func (e *Executor) checkToolCall(toolName string, args map[string]interface{}) error {
+	if err := e.policy.CheckTool(toolName); err != nil {
+		return err
+	}
+	if toolName == webFetchTool {
+		rawURL, _ := args["url"].(string)
+		return e.policy.CheckURL(rawURL)
+	}
+	return nil
+}
+
+// policyContext returns ctx with the policy's URL check, so WebFetch also
+// checks every redirect it follows
+func (e *Executor) policyContext(ctx context.Context) context.Context {
+	if e.policy == nil {
+		return ctx
+	}
+	return tools.WithURLCheck(ctx, e.policy.CheckURL)
+}
+
+// callsLLM reports whether a node of this type calls an LLM
+func callsLLM(node *spec.Node) bool {
+	switch node.Type {
+	case "llm", "react", "conditional":
+		return true
+	}
+	return false
+}
+
+// namedTools returns the tools a node names: its tool, the tools offered to
+// it in ReAct and those its tool config enables
+func namedTools(node *spec.Node) []string {
+	var names []string
+	if node.ToolName != "" {
+		names = append(names, node.ToolName)
+	}
+	names = append(names, node.AvailableTools...)
+	if node.Config != nil && node.Config.Tools != nil {
+		names = append(names, node.Config.Tools.Enabled...)
+	}
+	return names
+}
@@ -0,0 +1,113 @@
+package executor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+
+	"github.com/not7/core/spec"
+)
+
+var (
+	// fencedBlockPattern matches the fenced code blocks of a markdown text
+	fencedBlockPattern = regexp.MustCompile("(?s)```[A-Za-z]*[ \t]*\n(.*?)\n[ \t]*```")
+
+	// reasoningPattern matches the reasoning blocks some models write before
+	// answering
+	reasoningPattern = regexp.MustCompile(`(?is)<(think|thinking|reasoning)>.*?</(think|thinking|reasoning)>`)
+
+	// preamblePattern matches an opening line that only introduces the answer
+	preamblePattern = regexp.MustCompile(`(?i)^((sure|certainly|of course|absolutely|okay|ok|alright|great)\b.*|(here is|here's|here are|below is|below are)\b.*:)$`)
+
+	// answerLabelPattern matches a label put before the answer itself
+	answerLabelPattern = regexp.MustCompile(`(?i)^(final answer|final|answer)\s*:\s*`)
+
+	// markdown renders markdown_html output: CommonMark with GitHub tables,
+	// strikethrough, task lists and autolinks. Raw HTML is left out.
+	markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+)
+
+// postprocess applies the spec's post-processors to the final output, in
+// order. A step that cannot apply leaves the output as it was and is
+// returned as a problem.
+func postprocess(steps []spec.PostProcessor, output string) (string, []string) {
+	var problems []string
+	for _, step := range steps {
+		var err error
+		switch step.Type {
+		case spec.PostExtractJSON:
+			output, err = extractJSON(output)
+		case spec.PostStripPreamble:
+			output = stripPreamble(output)
+		case spec.PostMarkdownHTML:
+			output, err = markdownToHTML(output)
+		case spec.PostMaxLength:
+			output = limitLength(output, step.Length)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", step.Type, err))
+		}
+	}
+	return output, problems
+}
+
+// extractJSON returns the JSON document in text: the first fenced block
+// holding valid JSON, else the span from the first brace or bracket to the
+// last matching closer
+func extractJSON(text string) (string, error) {
+	trimmed := strings.TrimSpace(text)
+	if json.Valid([]byte(trimmed)) {
+		return trimmed, nil
+	}
+	for _, match := range fencedBlockPattern.FindAllStringSubmatch(trimmed, -1) {
+		if body := strings.TrimSpace(match[1]); json.Valid([]byte(body)) {
+			return body, nil
+		}
+	}
+	if span := outermostJSON(trimmed); json.Valid([]byte(span)) {
+		return span, nil
+	}
+	return text, fmt.Errorf("no JSON document found")
+}
+
+// stripPreamble removes reasoning blocks, up to two opening lines that only
+// introduce the answer, and a label such as "Final answer:" before it
+func stripPreamble(text string) string {
+	text = strings.TrimSpace(reasoningPattern.ReplaceAllString(text, ""))
+	for i := 0; i < 2; i++ {
+		first, rest, found := strings.Cut(text, "\n")
+		if !found || strings.TrimSpace(rest) == "" || !preamblePattern.MatchString(strings.TrimSpace(first)) {
+			break
+		}
+		text = strings.TrimSpace(rest)
+	}
+	return answerLabelPattern.ReplaceAllString(text, "")
+}
+
+// markdownToHTML renders markdown text as an HTML fragment
+func markdownToHTML(text string) (string, error) {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(text), &buf); err != nil {
+		return text, err
+	}
+	return buf.String(), nil
+}
+
+// limitLength cuts text to at most n characters, ending with "…". The cut
+// moves back to a space when one is in the last fifth of what is kept.
+func limitLength(text string, n int) string {
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	kept := string([]rune(text)[:n-1])
+	if i := strings.LastIndexAny(kept, " \t\n"); i >= len(kept)*4/5 {
+		kept = kept[:i]
+	}
+	return strings.TrimRight(kept, " \t\n") + "…"
+}
@@ -1,47 +1,39 @@
 package executor
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
 
-	"github.com/not7/core/config"
+	"github.com/not7/core/internal/tmpl"
+	"github.com/not7/core/internal/ui"
 	"github.com/not7/core/spec"
 )
 
 // executeReActNode executes a ReAct (Reasoning + Acting) node with iterative thinking
-func (e *Executor) executeReActNode(node *spec.Node, input string) (string, float64, *spec.ReActTrace, error) {
-	cfg := config.Get()
-
-	// Get LLM config
-	llmConfig := node.LLM
-	if llmConfig == nil && e.spec.Config != nil {
-		llmConfig = e.spec.Config.LLM
-	}
-	if llmConfig == nil {
-		return "", 0, nil, fmt.Errorf("no LLM configuration found")
-	}
-
-	// Set defaults
-	if llmConfig.Model == "" {
-		llmConfig.Model = cfg.OpenAI.DefaultModel
-	}
-	if llmConfig.Temperature == 0 {
-		llmConfig.Temperature = cfg.OpenAI.DefaultTemperature
+func (e *Executor) executeReActNode(ctx context.Context, node *spec.Node, input string) (string, usage, *spec.ReActTrace, error) {
+	llmConfig, err := e.llmConfigFor(node)
+	if err != nil {
+		return "", usage{}, nil, err
 	}
 
 	maxIterations := node.MaxIterations
 	if maxIterations == 0 {
-		maxIterations = 5 // Default to 5 iterations
+		maxIterations = defaultMaxIterations
 	}
 
 	// Build system prompt for ReAct
-	systemPrompt := buildReActSystemPrompt(node.ReActGoal, node.ThinkingPrompt)
+	goal, err := tmpl.Render(node.ReActGoal, input)
+	if err != nil {
+		return "", usage{}, nil, fmt.Errorf("react_goal: %w", err)
+	}
+	systemPrompt := buildReActSystemPrompt(goal, node.ThinkingPrompt)
 
 	e.logger.Info("Starting ReAct reasoning (max iterations: %d)", maxIterations)
 	if e.useCLI {
-		fmt.Printf("   🧠 ReAct Goal: %s\n", node.ReActGoal)
-		fmt.Printf("   🔄 Max iterations: %d\n\n", maxIterations)
+		ui.Infof("   🧠 ReAct Goal: %s\n", goal)
+		ui.Infof("   🔄 Max iterations: %d\n\n", maxIterations)
 	}
 
 	// Initialize trace
@@ -49,7 +41,7 @@ func (e *Executor) executeReActNode(node *spec.Node, input string) (string, floa
 		ThinkingSteps: make([]spec.ThinkingStep, 0),
 	}
 
-	totalCost := 0.0
+	var total usage
 	startTime := time.Now()
 	var finalAnswer string
 
@@ -59,42 +51,50 @@ func (e *Executor) executeReActNode(node *spec.Node, input string) (string, floa
 
 		e.logger.Info("ReAct iteration %d/%d", i, maxIterations)
 		if e.useCLI {
-			fmt.Printf("   💭 Iteration %d/%d: Thinking...\n", i, maxIterations)
+			ui.Infof("   💭 Iteration %d/%d: Thinking...\n", i, maxIterations)
 		}
 
 		// Build prompt for this iteration
 		var iterationPrompt string
 		if i == 1 {
-			iterationPrompt = fmt.Sprintf("Goal: %s\n\nBegin your reasoning. Think step by step.", node.ReActGoal)
+			iterationPrompt = fmt.Sprintf("Goal: %s\n\nBegin your reasoning. Think step by step.", goal)
 		} else {
 			iterationPrompt = "Continue your reasoning. Critique your previous thoughts and refine your answer. If you have a complete answer, start with 'FINAL:'"
 		}
 
 		// Execute LLM call
-		response, cost, err := e.llmClient.Execute(llmConfig, systemPrompt, iterationPrompt)
+		completion, err := e.complete(ctx, llmConfig, systemPrompt, iterationPrompt)
 		if err != nil {
 			e.logger.Error("ReAct iteration %d failed: %v", i, err)
-			return "", totalCost, trace, fmt.Errorf("iteration %d failed: %w", i, err)
+			return "", total, trace, fmt.Errorf("iteration %d failed: %w", i, err)
 		}
+		response, cost := completion.Content, completion.Cost
 
 		iterDuration := time.Since(iterStart).Milliseconds()
-		totalCost += cost
+		total.addCompletion(completion)
 
 		// Record this thinking step
 		step := spec.ThinkingStep{
-			Iteration:  i,
-			Thought:    response,
-			DurationMs: iterDuration,
-			Cost:       cost,
+			Iteration:        i,
+			Thought:          response,
+			DurationMs:       iterDuration,
+			Cost:             cost,
+			PromptTokens:     completion.Usage.PromptTokens,
+			CompletionTokens: completion.Usage.CompletionTokens,
 		}
 		trace.ThinkingSteps = append(trace.ThinkingSteps, step)
 
 		e.logger.Info("Iteration %d completed in %dms (cost: $%.4f)", i, iterDuration, cost)
+		e.logger.Debug("Iteration %d response: %s", i, response)
+		e.emit(Event{Type: EventReActIteration, NodeID: node.ID, NodeType: node.Type, Iteration: i, Message: getThoughtPreview(response), Cost: cost, DurationMs: iterDuration})
 		if e.useCLI {
 			// Show preview of thought
 			preview := getThoughtPreview(response)
-			fmt.Printf("      %s\n", preview)
-			fmt.Printf("      ⏱️  %dms | 💰 $%.4f\n\n", iterDuration, cost)
+			if ui.IsVerbose() {
+				preview = strings.ReplaceAll(strings.TrimSpace(response), "\n", "\n      ")
+			}
+			ui.Infof("      %s\n", preview)
+			ui.Infof("      ⏱️  %dms | 💰 $%.4f\n\n", iterDuration, cost)
 		}
 
 		// Check if final answer
@@ -102,7 +102,7 @@ func (e *Executor) executeReActNode(node *spec.Node, input string) (string, floa
 			finalAnswer = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(response), "FINAL:"))
 			e.logger.Info("ReAct reached conclusion at iteration %d", i)
 			if e.useCLI {
-				fmt.Printf("   ✅ Conclusion reached at iteration %d\n\n", i)
+				ui.Infof("   ✅ Conclusion reached at iteration %d\n\n", i)
 			}
 			break
 		}
@@ -113,12 +113,12 @@ func (e *Executor) executeReActNode(node *spec.Node, input string) (string, floa
 	// Finalize trace
 	trace.Iterations = len(trace.ThinkingSteps)
 	trace.TotalThinkingTimeMs = time.Since(startTime).Milliseconds()
-	trace.IterationsCost = totalCost
+	trace.IterationsCost = total.cost
 
 	e.logger.Info("ReAct complete: %d iterations, %dms total, $%.4f cost",
-		trace.Iterations, trace.TotalThinkingTimeMs, totalCost)
+		trace.Iterations, trace.TotalThinkingTimeMs, total.cost)
 
-	return finalAnswer, totalCost, trace, nil
+	return finalAnswer, total, trace, nil
 }
 
 // buildReActSystemPrompt creates the system prompt for ReAct reasoning
@@ -0,0 +1,170 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/not7/core/tools"
+)
+
+// resultsProviderName is the provider of the ReadMore tool, which every
+// tool manager gets
+const resultsProviderName = "results"
+
+// readMoreTool pages through the full text of shortened tool results
+const readMoreTool = "ReadMore"
+
+// resultIDPrefix starts the IDs of stored results, e.g. result-3
+const resultIDPrefix = "result-"
+
+// resultStore keeps the full text of the run's shortened tool results so
+// that ReadMore can page through them
+type resultStore struct {
+	mu      sync.Mutex
+	results []storedResult
+}
+
+// storedResult is a shortened tool result and the page size to read it in
+type storedResult struct {
+	text []rune
+	page int // Characters per page: the budget of the node that shortened it
+}
+
+// add stores a result and returns its ID
+func (s *resultStore) add(text string, page int) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results = append(s.results, storedResult{text: []rune(text), page: page})
+	return resultIDPrefix + strconv.Itoa(len(s.results))
+}
+
+// get returns the result stored under id
+func (s *resultStore) get(id string) (storedResult, bool) {
+	n, err := strconv.Atoi(strings.TrimPrefix(id, resultIDPrefix))
+	if err != nil || !strings.HasPrefix(id, resultIDPrefix) {
+		return storedResult{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n < 1 || n > len(s.results) {
+		return storedResult{}, false
+	}
+	return s.results[n-1], true
+}
+
+// storeFullResult keeps a shortened tool result for ReadMore and returns a
+// note telling the model how to read it from offset
+func (e *Executor) storeFullResult(text string, limit, offset int) string {
+	id := e.fullResults.add(text, limit)
+	return fmt.Sprintf("\n[full result stored as %s: call %s with {\"id\": %q, \"offset\": %d} to read on]", id, readMoreTool, id, offset)
+}
+
+// resultsProvider offers the ReadMore tool over the run's stored results
+type resultsProvider struct {
+	store *resultStore
+}
+
+func (p *resultsProvider) Initialize(config map[string]string) error {
+	return nil
+}
+
+func (p *resultsProvider) ListTools(ctx context.Context) ([]tools.ToolDefinition, error) {
+	return []tools.ToolDefinition{
+		{
+			Name:        readMoreTool,
+			Description: "Read more of a tool result that was shortened. Returns one page of the full result from offset, or from where find next appears; read again from the offset it gives for the next page.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the stored result, e.g. result-1",
+					},
+					"offset": map[string]interface{}{
+						"type":        "integer",
+						"description": "Character to start reading from (default: 0)",
+					},
+					"find": map[string]interface{}{
+						"type":        "string",
+						"description": "Text to jump to, such as a heading or a name; reading starts at the line where it next appears after offset",
+					},
+				},
+				"required": []string{"id"},
+			},
+			Provider: resultsProviderName,
+		},
+	}, nil
+}
+
+func (p *resultsProvider) ExecuteTool(ctx context.Context, toolName string, arguments map[string]interface{}) (*tools.ToolResult, error) {
+	if toolName != readMoreTool {
+		return &tools.ToolResult{Success: false, Error: fmt.Sprintf("unknown tool: %s", toolName)}, nil
+	}
+
+	id, _ := arguments["id"].(string)
+	result, ok := p.store.get(id)
+	if !ok {
+		return &tools.ToolResult{Success: false, Error: fmt.Sprintf("no stored result %q", id)}, nil
+	}
+	text := result.text
+
+	offset := 0
+	if value, ok := arguments["offset"].(float64); ok && value > 0 {
+		offset = int(value)
+	}
+	if offset > len(text) {
+		offset = len(text)
+	}
+	if find, _ := arguments["find"].(string); find != "" {
+		at := indexFold(text, []rune(find), offset)
+		if at < 0 {
+			return &tools.ToolResult{Success: false, Error: fmt.Sprintf("%q does not appear after offset %d of %s", find, offset, id)}, nil
+		}
+		for at > offset && text[at-1] != '\n' {
+			at--
+		}
+		offset = at
+	}
+	end := offset + result.page
+	if end > len(text) {
+		end = len(text)
+	}
+
+	output := fmt.Sprintf("[%s, characters %d-%d of %d]\n%s", id, offset, end, len(text), string(text[offset:end]))
+	if end < len(text) {
+		output += fmt.Sprintf("\n\n[%d more characters; read from offset %d]", len(text)-end, end)
+	} else {
+		output += "\n\n[end of result]"
+	}
+	return &tools.ToolResult{Success: true, Output: output}, nil
+}
+
+func (p *resultsProvider) GetProviderName() string {
+	return resultsProviderName
+}
+
+func (p *resultsProvider) Close() error {
+	return nil
+}
+
+// indexFold returns the first index of sub in text at or after from,
+// ignoring case, or -1
+func indexFold(text, sub []rune, from int) int {
+	for i := from; i+len(sub) <= len(text); i++ {
+		match := true
+		for j, r := range sub {
+			if unicode.ToLower(text[i+j]) != unicode.ToLower(r) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
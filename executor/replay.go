@@ -0,0 +1,274 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/not7/core/llm"
+	"github.com/not7/core/spec"
+	"github.com/not7/core/tools"
+)
+
+// ErrReplayDiverged is returned when a replayed execution asks for an LLM
+// response or tool result that its recording does not have, e.g. because
+// the executor now calls a node differently
+var ErrReplayDiverged = errors.New("replay diverged from the recording")
+
+// Recording holds the LLM responses and tool results of a past execution,
+// taken from its trace, so it can be re-run without live calls
+type Recording struct {
+	mu    sync.Mutex
+	nodes map[string]*recordedNode
+	tools []string // every tool the spec names or the recording called
+}
+
+// recordedNode is what one node of the original execution received
+type recordedNode struct {
+	completions []*llm.Completion
+	toolCalls   []recordedToolCall
+	output      string // retrieve nodes
+	err         string // the node failed with this error
+}
+
+type recordedToolCall struct {
+	name   string
+	result *tools.ToolResult
+	err    string
+}
+
+// nodeIDKey carries the running node's ID to the recording and to the
+// calls the node makes
+type nodeIDKey struct{}
+
+// NewRecording reads the recorded calls from an execution trace: LLM node
+// outputs, conditional node classifications, ReAct thoughts and tool
+// results, and tool and retrieve node outputs
+func NewRecording(trace *spec.AgentSpec) (*Recording, error) {
+	if trace.Metadata == nil || len(trace.Metadata.NodeResults) == 0 {
+		return nil, fmt.Errorf("trace has no node results to replay")
+	}
+
+	types := make(map[string]string, len(trace.Nodes))
+	for _, node := range trace.Nodes {
+		types[node.ID] = node.Type
+	}
+
+	r := &Recording{nodes: make(map[string]*recordedNode)}
+	for _, result := range trace.Metadata.NodeResults {
+		rec := &recordedNode{err: result.Error}
+		output, _ := result.Output.(string)
+
+		switch types[result.NodeID] {
+		case "llm":
+			if result.Status == "success" {
+				rec.completions = append(rec.completions, &llm.Completion{
+					Content: output,
+					Usage:   llm.Usage{PromptTokens: result.PromptTokens, CompletionTokens: result.CompletionTokens},
+					Cost:    result.Cost,
+				})
+			}
+		case "react":
+			if result.ReActTrace != nil {
+				for _, step := range result.ReActTrace.ThinkingSteps {
+					rec.completions = append(rec.completions, &llm.Completion{
+						Content: step.Thought,
+						Usage:   llm.Usage{PromptTokens: step.PromptTokens, CompletionTokens: step.CompletionTokens},
+						Cost:    step.Cost,
+					})
+					for _, call := range step.ToolCalls {
+						rec.toolCalls = append(rec.toolCalls, recordedToolCall{
+							name:   call.ToolName,
+							result: &tools.ToolResult{Success: call.Error == "", Output: call.Result},
+							err:    call.Error,
+						})
+					}
+				}
+			}
+		case "tool":
+			if node := findNode(trace, result.NodeID); node != nil && result.Status == "success" {
+				rec.toolCalls = append(rec.toolCalls, recordedToolCall{
+					name:   node.ToolName,
+					result: &tools.ToolResult{Success: true, Output: output},
+				})
+			}
+		case "retrieve":
+			rec.output = output
+		case "conditional":
+			if result.Classification != "" {
+				rec.completions = append(rec.completions, &llm.Completion{
+					Content: result.Classification,
+					Usage:   llm.Usage{PromptTokens: result.PromptTokens, CompletionTokens: result.CompletionTokens},
+					Cost:    result.Cost,
+				})
+			}
+		}
+		r.nodes[result.NodeID] = rec
+	}
+	r.tools = toolNames(trace, r.nodes)
+	return r, nil
+}
+
+// Execute returns the running node's next recorded LLM response in place
+// of an LLM call
+func (r *Recording) Execute(ctx context.Context, config *spec.LLMConfig, prompt string, input string) (*llm.Completion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	nodeID, rec, err := r.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(rec.completions) == 0 {
+		if rec.err != "" {
+			return nil, errors.New(rec.err)
+		}
+		return nil, fmt.Errorf("%w: node %s made more LLM calls than were recorded", ErrReplayDiverged, nodeID)
+	}
+	completion := rec.completions[0]
+	rec.completions = rec.completions[1:]
+	return completion, nil
+}
+
+// toolResult returns the running node's next recorded result of toolName
+func (r *Recording) toolResult(ctx context.Context, toolName string) (*tools.ToolResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	nodeID, rec, err := r.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(rec.toolCalls) == 0 {
+		if rec.err != "" {
+			return &tools.ToolResult{Success: false, Error: rec.err}, nil
+		}
+		return nil, fmt.Errorf("%w: node %s made more tool calls than were recorded", ErrReplayDiverged, nodeID)
+	}
+	call := rec.toolCalls[0]
+	if call.name != toolName {
+		return nil, fmt.Errorf("%w: node %s called %s where %s was recorded", ErrReplayDiverged, nodeID, toolName, call.name)
+	}
+	rec.toolCalls = rec.toolCalls[1:]
+	if call.err != "" {
+		return nil, errors.New(call.err)
+	}
+	return call.result, nil
+}
+
+// retrieved returns a retrieve node's recorded output
+func (r *Recording) retrieved(ctx context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, rec, err := r.node(ctx)
+	if err != nil {
+		return "", err
+	}
+	if rec.err != "" {
+		return "", errors.New(rec.err)
+	}
+	return rec.output, nil
+}
+
+// nodeError returns the error a node failed with in the recording
+func (r *Recording) nodeError(nodeID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if rec, ok := r.nodes[nodeID]; ok && rec.err != "" {
+		return errors.New(rec.err)
+	}
+	return nil
+}
+
+// Unused returns the nodes whose recorded calls were not all replayed,
+// sorted; a faithful replay leaves none
+func (r *Recording) Unused() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var unused []string
+	for nodeID, rec := range r.nodes {
+		if len(rec.completions) > 0 || len(rec.toolCalls) > 0 {
+			unused = append(unused, nodeID)
+		}
+	}
+	sort.Strings(unused)
+	return unused
+}
+
+// toolNames returns, sorted, the tools the spec's nodes name and the tools
+// the recording has results for
+func toolNames(trace *spec.AgentSpec, nodes map[string]*recordedNode) []string {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, node := range trace.Nodes {
+		add(node.ToolName)
+		for _, name := range node.AvailableTools {
+			add(name)
+		}
+	}
+	for _, rec := range nodes {
+		for _, call := range rec.toolCalls {
+			add(call.name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *Recording) node(ctx context.Context) (string, *recordedNode, error) {
+	nodeID, _ := ctx.Value(nodeIDKey{}).(string)
+	rec, ok := r.nodes[nodeID]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: node %s did not run in the recording", ErrReplayDiverged, nodeID)
+	}
+	return nodeID, rec, nil
+}
+
+func findNode(s *spec.AgentSpec, id string) *spec.Node {
+	for i := range s.Nodes {
+		if s.Nodes[i].ID == id {
+			return &s.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// replayProvider serves recorded tool results in place of a tool provider
+type replayProvider struct {
+	recording *Recording
+}
+
+func (p *replayProvider) Initialize(config map[string]string) error {
+	return nil
+}
+
+func (p *replayProvider) ListTools(ctx context.Context) ([]tools.ToolDefinition, error) {
+	var defs []tools.ToolDefinition
+	for _, name := range p.recording.tools {
+		defs = append(defs, tools.ToolDefinition{Name: name, Description: "Recorded results of " + name, Provider: p.GetProviderName()})
+	}
+	return defs, nil
+}
+
+func (p *replayProvider) ExecuteTool(ctx context.Context, toolName string, arguments map[string]interface{}) (*tools.ToolResult, error) {
+	return p.recording.toolResult(ctx, toolName)
+}
+
+func (p *replayProvider) GetProviderName() string {
+	return "replay"
+}
+
+func (p *replayProvider) Close() error {
+	return nil
+}
@@ -0,0 +1,100 @@
+package executor_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/not7/core/config"
+	"github.com/not7/core/executor"
+	"github.com/not7/core/internal/vcr"
+	"github.com/not7/core/spec"
+)
+
+// researchSpec is a ReAct node that has to call WebSearch before answering
+const researchSpec = `{
+  "version": "1.1",
+  "goal": "Find the tallest building in the world",
+  "config": {
+    "llm": { "provider": "openai", "model": "gpt-4o-mini", "temperature": 0 },
+    "tools": { "provider": "builtin" }
+  },
+  "nodes": [
+    {
+      "id": "research",
+      "name": "Research",
+      "type": "react",
+      "tools_enabled": true,
+      "available_tools": ["WebSearch"],
+      "react_goal": "Name the tallest building in the world and its height. Use WebSearch.",
+      "max_iterations": 3
+    }
+  ],
+  "routes": [
+    { "from": "start", "to": "research" },
+    { "from": "research", "to": "end" }
+  ]
+}`
+
+// discardLogger drops executor logs
+type discardLogger struct{}
+
+func (discardLogger) Info(string, ...interface{})  {}
+func (discardLogger) Error(string, ...interface{}) {}
+func (discardLogger) Debug(string, ...interface{}) {}
+
+// TestReActToolFlowReplay runs a ReAct node with the builtin tools against a
+// cassette, covering the LLM/tool loop without API keys. Delete the cassette
+// and run with OPENAI_API_KEY and SERP_API_KEY set to record it again.
+func TestReActToolFlowReplay(t *testing.T) {
+	recorder, err := vcr.New("testdata/react_websearch.json", vcr.ModeAuto, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vcr.Install(recorder)()
+
+	cfg := &config.Config{
+		OpenAI:  config.OpenAIConfig{APIKey: "test-key", DefaultModel: "gpt-4o-mini"},
+		Builtin: config.BuiltinConfig{SerpAPIKey: "test-key"},
+	}
+	if recorder.Mode() == vcr.ModeRecord {
+		cfg.OpenAI.APIKey = os.Getenv("OPENAI_API_KEY")
+		cfg.Builtin.SerpAPIKey = os.Getenv("SERP_API_KEY")
+		if cfg.OpenAI.APIKey == "" || cfg.Builtin.SerpAPIKey == "" {
+			t.Skip("recording the cassette needs OPENAI_API_KEY and SERP_API_KEY")
+		}
+	}
+
+	agentSpec, err := spec.Parse([]byte(researchSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec, err := executor.NewExecutorWithLogger(agentSpec, cfg, discardLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := exec.Execute("")
+	if err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	if !strings.Contains(output, "Burj Khalifa") {
+		t.Errorf("output = %q, want the answer from the recorded search", output)
+	}
+
+	results := exec.GetMetadata().NodeResults
+	if len(results) != 1 || results[0].ReActTrace == nil {
+		t.Fatalf("expected one ReAct node result, got %+v", results)
+	}
+	var calls []spec.ToolCallTrace
+	for _, step := range results[0].ReActTrace.ThinkingSteps {
+		calls = append(calls, step.ToolCalls...)
+	}
+	if len(calls) != 1 || calls[0].ToolName != "WebSearch" || calls[0].Error != "" {
+		t.Errorf("tool calls = %+v, want one successful WebSearch", calls)
+	}
+
+	if recorder.Mode() == vcr.ModeReplay && recorder.Unused() != 0 {
+		t.Errorf("%d recorded interactions were not replayed", recorder.Unused())
+	}
+}
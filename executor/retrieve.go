@@ -0,0 +1,67 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/not7/core/corpus"
+	"github.com/not7/core/internal/tmpl"
+	"github.com/not7/core/spec"
+)
+
+// executeRetrieveNode searches the node's corpus and returns the best
+// chunks followed by the input, so the next node sees both. The query
+// embedding's tokens are counted; its cost is negligible and not added.
+func (e *Executor) executeRetrieveNode(ctx context.Context, node *spec.Node, input string) (string, usage, error) {
+	if e.replay != nil {
+		output, err := e.replay.retrieved(ctx)
+		return output, usage{}, err
+	}
+
+	c, err := corpus.NewStore(e.cfg.Server.CorporaDir).Load(node.Corpus)
+	if err != nil {
+		return "", usage{}, err
+	}
+
+	query := input
+	if node.Query != "" {
+		if query, err = tmpl.Render(node.Query, input); err != nil {
+			return "", usage{}, fmt.Errorf("query: %w", err)
+		}
+	}
+
+	var used usage
+	embedder := &countingEmbedder{executor: e, model: c.Model, used: &used}
+	matches, err := c.Search(ctx, embedder, query, node.TopK)
+	if err != nil {
+		return "", used, err
+	}
+	e.logger.Info("Retrieved %d of %d chunks from corpus %s", len(matches), len(c.Chunks), node.Corpus)
+	if len(matches) == 0 {
+		return input, used, nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Relevant excerpts from %s:\n", node.Corpus)
+	for i, m := range matches {
+		e.logger.Debug("Chunk %d: %s #%d (score %.3f)", i+1, m.Source, m.Index, m.Score)
+		fmt.Fprintf(&b, "\n[%d] %s\n%s\n", i+1, m.Source, m.Text)
+	}
+	b.WriteString("\n---\n\n")
+	b.WriteString(input)
+	return b.String(), used, nil
+}
+
+// countingEmbedder embeds with the executor's client, adding the tokens to used
+type countingEmbedder struct {
+	executor *Executor
+	model    string
+	used     *usage
+}
+
+func (c *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	vectors, u, err := c.executor.llmClient.Embed(ctx, c.model, texts)
+	c.used.promptTokens += u.PromptTokens
+	return vectors, err
+}
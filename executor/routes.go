@@ -0,0 +1,158 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/not7/core/internal/jsonpath"
+	"github.com/not7/core/spec"
+)
+
+// nextNodes returns the targets of the routes leaving fromNodeID whose
+// conditions hold, given the output fromNodeID produced. Routes without a
+// condition and success routes are always taken; failure routes are not,
+// since a failed node ends the execution. Default routes are taken when no
+// other route with a condition is. A node whose routes all fail their
+// conditions is an error rather than a silent end.
+func (e *Executor) nextNodes(fromNodeID, output string) ([]string, error) {
+	var nodes, defaults []string
+	routes := 0
+	matched := false
+	for _, route := range e.spec.Routes {
+		if route.From != fromNodeID {
+			continue
+		}
+		routes++
+
+		if route.Condition != nil && route.Condition.Type == spec.ConditionDefault {
+			defaults = append(defaults, route.To)
+			continue
+		}
+		taken, err := e.routeTaken(route, output)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			nodes = append(nodes, route.To)
+			matched = matched || route.Condition != nil
+		}
+	}
+	if !matched {
+		nodes = append(nodes, defaults...)
+	}
+
+	if routes > 0 && len(nodes) == 0 {
+		return nil, fmt.Errorf("no route from %s matched its condition", fromNodeID)
+	}
+	return nodes, nil
+}
+
+// routeTaken evaluates a route's condition
+func (e *Executor) routeTaken(route spec.Route, output string) (bool, error) {
+	if route.Condition == nil {
+		return true, nil
+	}
+
+	switch route.Condition.Type {
+	case spec.ConditionSuccess:
+		return true, nil
+	case spec.ConditionFailure:
+		return false, nil
+	case spec.ConditionExpression:
+		program, err := route.Condition.Compile()
+		if err != nil {
+			return false, fmt.Errorf("route %s -> %s: invalid condition: %w", route.From, route.To, err)
+		}
+		taken, err := program.EvalBool(e.conditionVars(output))
+		if err != nil {
+			return false, fmt.Errorf("route %s -> %s: condition %q failed: %w", route.From, route.To, program, err)
+		}
+		e.logger.Debug("Route %s -> %s: %s is %t", route.From, route.To, program, taken)
+		return taken, nil
+	case spec.ConditionScript:
+		program, err := route.Condition.CompileScript()
+		if err != nil {
+			return false, fmt.Errorf("route %s -> %s: invalid condition: %w", route.From, route.To, err)
+		}
+		taken, err := program.RunBool(context.Background(), e.conditionVars(output))
+		if err != nil {
+			return false, fmt.Errorf("route %s -> %s: condition script failed: %w", route.From, route.To, err)
+		}
+		e.logger.Debug("Route %s -> %s: script is %t", route.From, route.To, taken)
+		return taken, nil
+	case spec.ConditionLabel:
+		label, ok := e.labels[route.From]
+		if !ok {
+			return false, fmt.Errorf("route %s -> %s: node %s chose no label", route.From, route.To, route.From)
+		}
+		return spec.SameLabel(label, route.Condition.Label), nil
+	case spec.ConditionSwitch:
+		path, err := route.Condition.CompilePath()
+		if err != nil {
+			return false, fmt.Errorf("route %s -> %s: invalid condition: %w", route.From, route.To, err)
+		}
+		taken := switchMatches(path, route.Condition.Equals, output)
+		e.logger.Debug("Route %s -> %s: %s == %v is %t", route.From, route.To, path, route.Condition.Equals, taken)
+		return taken, nil
+	default:
+		return false, fmt.Errorf("route %s -> %s: unknown condition type %q", route.From, route.To, route.Condition.Type)
+	}
+}
+
+// switchMatches reports whether any value path selects in the JSON output
+// equals want, or whether there is such a value when want is nil. Output
+// that is not JSON matches nothing.
+func switchMatches(path *jsonpath.Path, want interface{}, output string) bool {
+	doc, ok := decodeJSONOutput(output)
+	if !ok {
+		return false
+	}
+	values := path.Select(doc)
+	if want == nil {
+		return len(values) > 0
+	}
+
+	// Compare as decoded JSON, so 1 and 1.0 are equal whatever decoded the spec
+	if data, err := json.Marshal(want); err == nil {
+		json.Unmarshal(data, &want)
+	}
+	for _, value := range values {
+		if reflect.DeepEqual(value, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// conditionVars builds the variables described by spec.ConditionVariables
+func (e *Executor) conditionVars(output string) map[string]interface{} {
+	nodes := make(map[string]interface{}, len(e.results))
+	var cost float64
+	var tokens int
+	for id, result := range e.results {
+		nodes[id] = map[string]interface{}{
+			"output": result.Output,
+			"status": result.Status,
+			"cost":   result.Cost,
+			"tokens": float64(result.PromptTokens + result.CompletionTokens),
+		}
+		cost += result.Cost
+		tokens += result.PromptTokens + result.CompletionTokens
+	}
+
+	vars := make(map[string]interface{}, len(e.spec.Vars))
+	for name, value := range e.spec.Vars {
+		vars[name] = value
+	}
+
+	return map[string]interface{}{
+		"input":  e.input,
+		"output": output,
+		"nodes":  nodes,
+		"vars":   vars,
+		"cost":   cost,
+		"tokens": tokens,
+	}
+}
@@ -0,0 +1,154 @@
+package executor
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/not7/core/spec"
+)
+
+// minScrubLength is the length below which a secret's value is not scrubbed
+// from outputs, as it would also match ordinary text
+const minScrubLength = 4
+
+// secretValues holds the named secrets an execution has resolved, so each
+// is fetched once and can be scrubbed from what the run records
+type secretValues struct {
+	mu     sync.Mutex
+	values map[string]string // by upper-case name
+}
+
+// checkSecrets fails when the spec references a secret the config does not
+// define, before anything runs
+func (e *Executor) checkSecrets() error {
+	for _, name := range e.spec.SecretNames() {
+		if err := e.cfg.CheckSecret(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// secret returns the value of a named secret, resolving it on first use
+func (e *Executor) secret(ctx context.Context, name string) (string, error) {
+	e.secrets.mu.Lock()
+	defer e.secrets.mu.Unlock()
+	if value, ok := e.secrets.values[name]; ok {
+		return value, nil
+	}
+	value, err := e.cfg.Secret(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	e.secrets.values[name] = value
+	return value, nil
+}
+
+// resolveSecretArgs returns a copy of a tool node's arguments, rendered from
+// the spec's tool_arguments, with their {{secret.NAME}} references replaced
+// by the secrets' values. A secret only resolves in an argument whose spec
+// value references it, so a reference that comes from the node's input, or
+// from anywhere else, stays as written. Replays keep the references, as
+// recorded.
+func (e *Executor) resolveSecretArgs(ctx context.Context, written, args map[string]interface{}) (map[string]interface{}, error) {
+	if e.replay != nil {
+		return args, nil
+	}
+
+	var resolve func(written, value interface{}) (interface{}, error)
+	resolve = func(written, value interface{}) (interface{}, error) {
+		switch v := value.(type) {
+		case string:
+			text, _ := written.(string)
+			allowed := spec.SecretNames(text)
+			return spec.ReplaceSecrets(v, func(name string) (string, error) {
+				if !slices.Contains(allowed, name) {
+					return spec.SecretRef(name), nil
+				}
+				return e.secret(ctx, name)
+			})
+		case map[string]interface{}:
+			writtenMap, _ := written.(map[string]interface{})
+			resolved := make(map[string]interface{}, len(v))
+			for key, item := range v {
+				var err error
+				if resolved[key], err = resolve(writtenMap[key], item); err != nil {
+					return nil, err
+				}
+			}
+			return resolved, nil
+		case []interface{}:
+			writtenList, _ := written.([]interface{})
+			resolved := make([]interface{}, len(v))
+			for i, item := range v {
+				var writtenItem interface{}
+				if i < len(writtenList) {
+					writtenItem = writtenList[i]
+				}
+				var err error
+				if resolved[i], err = resolve(writtenItem, item); err != nil {
+					return nil, err
+				}
+			}
+			return resolved, nil
+		}
+		return value, nil
+	}
+
+	resolved, err := resolve(written, args)
+	if err != nil {
+		return nil, err
+	}
+	return resolved.(map[string]interface{}), nil
+}
+
+// scrubSecrets replaces the values of the secrets resolved so far with their
+// {{secret.NAME}} references, so tools echoing them back do not leak them
+// into the trace or to the LLM
+func (e *Executor) scrubSecrets(text string) string {
+	e.secrets.mu.Lock()
+	defer e.secrets.mu.Unlock()
+	if len(e.secrets.values) == 0 {
+		return text
+	}
+
+	// Longest first, so a secret containing another is replaced whole
+	names := make([]string, 0, len(e.secrets.values))
+	for name, value := range e.secrets.values {
+		if len(value) >= minScrubLength {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return len(e.secrets.values[names[i]]) > len(e.secrets.values[names[j]])
+	})
+	for _, name := range names {
+		text = strings.ReplaceAll(text, e.secrets.values[name], spec.SecretRef(name))
+	}
+	return text
+}
+
+// scrubSecretValue scrubs the strings of a tool output, including those in
+// maps and lists
+func (e *Executor) scrubSecretValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return e.scrubSecrets(v)
+	case map[string]interface{}:
+		scrubbed := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			scrubbed[key] = e.scrubSecretValue(item)
+		}
+		return scrubbed
+	case []interface{}:
+		scrubbed := make([]interface{}, len(v))
+		for i, item := range v {
+			scrubbed[i] = e.scrubSecretValue(item)
+		}
+		return scrubbed
+	}
+	return value
+}
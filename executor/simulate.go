@@ -0,0 +1,96 @@
+package executor
+
+import (
+	"github.com/not7/core/llm"
+	"github.com/not7/core/spec"
+)
+
+// SimulatedModel is a model an execution is re-priced under
+type SimulatedModel struct {
+	Model   string      `json:"model"`
+	Pricing llm.Pricing `json:"pricing"`
+}
+
+// NodeSimulation is the recorded usage of one node and its cost under each
+// simulated model
+type NodeSimulation struct {
+	NodeID           string    `json:"node_id"`
+	NodeType         string    `json:"node_type"`
+	Model            string    `json:"model,omitempty"` // Model the node ran with
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	RecordedCost     float64   `json:"recorded_cost"`
+	Costs            []float64 `json:"costs"` // One per simulated model
+}
+
+// CostSimulation is what a past execution would have cost under other models
+type CostSimulation struct {
+	Models           []SimulatedModel `json:"models"`
+	Nodes            []NodeSimulation `json:"nodes"`
+	PromptTokens     int              `json:"prompt_tokens"`
+	CompletionTokens int              `json:"completion_tokens"`
+	RecordedCost     float64          `json:"recorded_cost"`
+	Costs            []float64        `json:"costs"` // One per simulated model
+}
+
+// SimulateCost re-prices the token counts recorded in an execution trace
+// under each of models. Only llm and react nodes are re-priced; other nodes
+// keep their recorded cost, since the model choice does not change it.
+func SimulateCost(trace *spec.AgentSpec, models []SimulatedModel) *CostSimulation {
+	simulation := &CostSimulation{
+		Models: models,
+		Costs:  make([]float64, len(models)),
+	}
+	if trace.Metadata == nil {
+		return simulation
+	}
+
+	results := make(map[string]spec.NodeResult, len(trace.Metadata.NodeResults))
+	for _, result := range trace.Metadata.NodeResults {
+		results[result.NodeID] = result
+	}
+
+	for _, node := range orderedNodes(trace) {
+		result, ran := results[node.ID]
+		if !ran {
+			continue
+		}
+
+		nodeSimulation := NodeSimulation{
+			NodeID:           node.ID,
+			NodeType:         node.Type,
+			PromptTokens:     result.PromptTokens,
+			CompletionTokens: result.CompletionTokens,
+			RecordedCost:     result.Cost,
+			Costs:            make([]float64, len(models)),
+		}
+
+		repriced := node.Type == "llm" || node.Type == "react" || node.Type == "conditional"
+		if repriced {
+			// The executor writes its default model into the config it used
+			llmConfig := node.LLM
+			if llmConfig == nil && trace.Config != nil {
+				llmConfig = trace.Config.LLM
+			}
+			if llmConfig != nil {
+				nodeSimulation.Model = llmConfig.Model
+			}
+		}
+
+		for i, model := range models {
+			cost := result.Cost
+			if repriced {
+				cost = model.Pricing.Cost(result.PromptTokens, result.CompletionTokens)
+			}
+			nodeSimulation.Costs[i] = cost
+			simulation.Costs[i] += cost
+		}
+
+		simulation.Nodes = append(simulation.Nodes, nodeSimulation)
+		simulation.PromptTokens += result.PromptTokens
+		simulation.CompletionTokens += result.CompletionTokens
+		simulation.RecordedCost += result.Cost
+	}
+
+	return simulation
+}
@@ -0,0 +1,68 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/sink"
+	"github.com/not7/core/spec"
+)
+
+// sinkTimeout bounds the time a sink node takes to write its records
+const sinkTimeout = 60 * time.Second
+
+// executeSinkNode appends the records of its JSON input to the node's sink
+// and passes the input on unchanged, so a sink can sit anywhere in the
+// graph. Replays do not write.
+func (e *Executor) executeSinkNode(ctx context.Context, node *spec.Node, input string) (string, error) {
+	records, err := sinkRecords(input)
+	if err != nil {
+		return "", err
+	}
+	if len(records) == 0 {
+		e.logger.Info("Sink input has no records; nothing appended")
+		return input, nil
+	}
+	if e.replay != nil {
+		e.logger.Info("Replay: %d record(s) not appended to the sink", len(records))
+		return input, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sinkTimeout)
+	defer cancel()
+	location, err := sink.Write(ctx, e.cfg.Sinks, node.Sink, records)
+	if err != nil {
+		return "", err
+	}
+	e.logger.Info("Appended %d record(s) to %s", len(records), location)
+	if e.useCLI {
+		ui.Infof("   📥 Appended %d record(s) to %s\n", len(records), location)
+	}
+	return input, nil
+}
+
+// sinkRecords decodes a sink node's input, a JSON object or an array of
+// objects that may be wrapped in a markdown fence or prose
+func sinkRecords(input string) ([]sink.Record, error) {
+	value, ok := decodeJSONOutput(input)
+	if !ok {
+		return nil, fmt.Errorf("sink input is not JSON; the node before it must output a JSON object or an array of objects")
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return []sink.Record{v}, nil
+	case []interface{}:
+		records := make([]sink.Record, len(v))
+		for i, item := range v {
+			record, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("sink input item %d is not a JSON object", i)
+			}
+			records[i] = record
+		}
+		return records, nil
+	}
+	return nil, fmt.Errorf("sink input must be a JSON object or an array of objects")
+}
@@ -0,0 +1,98 @@
+package executor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/spec"
+)
+
+// Tool output is untrusted: a fetched page or email can carry text written
+// to steer the model. It is sanitized and enclosed in tool_output tags that
+// the ReAct system prompt tells the model to read as data, and can be
+// checked for embedded instructions.
+
+// toolOutputTag is the tag tool results are enclosed in
+const toolOutputTag = "tool_output"
+
+// injectionMarker replaces passages removed by the strip check
+const injectionMarker = "[removed: possible prompt injection]"
+
+// injectionPatterns match passages of tool output that read like
+// instructions to the model rather than content
+var injectionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(?:ignore|disregard|forget|override)\b[^.\n]{0,40}\b(?:previous|prior|above|earlier|preceding|all|any|your)\b[^.\n]{0,40}\b(?:instructions?|prompts?|rules|directions|guidelines)\b[^.\n]*[.!]?`),
+	regexp.MustCompile(`(?i)\b(?:new|updated|real|actual) (?:instructions|task|system prompt)\s*:[^\n]*`),
+	regexp.MustCompile(`(?i)\byou are now\b[^.\n]*[.!]?`),
+	regexp.MustCompile(`(?i)\b(?:do not|don't|never) (?:tell|inform|mention it to|reveal (?:this|it) to) the user\b[^.\n]*[.!]?`),
+	regexp.MustCompile(`(?i)\b(?:reveal|print|repeat|show|output) (?:your|the) (?:system prompt|instructions|hidden prompt)\b[^.\n]*[.!]?`),
+	regexp.MustCompile(`(?im)^\s*(?:system|assistant|TOOL_CALL|FINAL)\s*:[^\n]*`),
+}
+
+// tagPattern matches anything that would open or close the tool output tag
+var tagPattern = regexp.MustCompile(`(?i)<(\s*/?\s*` + toolOutputTag + `)`)
+
+// sanitizeToolOutput removes control characters from tool output and
+// escapes look-alikes of the tag it is enclosed in, so the output cannot
+// end its block early
+func sanitizeToolOutput(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.Map(func(r rune) rune {
+		if (r < 0x20 && r != '\n' && r != '\t') || r == 0x7f {
+			return -1
+		}
+		return r
+	}, text)
+	return tagPattern.ReplaceAllString(text, "&lt;$1")
+}
+
+// findInjections returns the passages of text that read like instructions
+func findInjections(text string) []string {
+	var found []string
+	for _, pattern := range injectionPatterns {
+		for _, match := range pattern.FindAllString(text, -1) {
+			found = append(found, strings.TrimSpace(match))
+		}
+	}
+	return found
+}
+
+// stripInjections replaces the passages of text that read like instructions
+// with a marker
+func stripInjections(text string) string {
+	for _, pattern := range injectionPatterns {
+		text = pattern.ReplaceAllString(text, injectionMarker)
+	}
+	return text
+}
+
+// checkToolOutput sanitizes a tool result for the ReAct context and runs
+// the node's injection check on it. It returns the text to show the model
+// and the passages the check found.
+func (e *Executor) checkToolOutput(node *spec.Node, toolName, text string) (string, []string) {
+	text = sanitizeToolOutput(text)
+	check := spec.InjectionFor(e.spec, node)
+	if check == "" {
+		return text, nil
+	}
+	found := findInjections(text)
+	if len(found) == 0 {
+		return text, nil
+	}
+
+	e.logger.Info("Tool %s output holds %d possible prompt injection(s)", toolName, len(found))
+	if e.useCLI {
+		ui.Infof("         ⚠️  %d possible prompt injection(s) in %s output\n", len(found), toolName)
+	}
+	if check == spec.InjectionStrip {
+		text = stripInjections(text)
+	}
+	return text, found
+}
+
+// delimitToolOutput encloses sanitized tool output in its tag
+func delimitToolOutput(toolName, text string) string {
+	return fmt.Sprintf("<%s tool=%q>\n%s\n</%s>", toolOutputTag, toolName, text, toolOutputTag)
+}
@@ -0,0 +1,65 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/not7/core/spec"
+)
+
+// transformTimeout bounds the time a transform node's expression or script
+// may run
+const transformTimeout = 2 * time.Second
+
+// executeTransformNode computes the node's output from its input with the
+// node's expression or script, without calling a model. A string result is
+// the output as is; any other value is output as JSON.
+func (e *Executor) executeTransformNode(ctx context.Context, node *spec.Node, input string) (string, error) {
+	vars := e.conditionVars(input)
+	vars["data"] = nil
+	if data, ok := decodeJSONOutput(input); ok {
+		vars["data"] = data
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, transformTimeout)
+	defer cancel()
+
+	var value interface{}
+	if node.Script != "" {
+		program, err := node.CompileScript()
+		if err != nil {
+			return "", fmt.Errorf("invalid script: %w", err)
+		}
+		if value, err = program.Run(ctx, vars); err != nil {
+			return "", transformError("script", err)
+		}
+	} else {
+		program, err := node.CompileTransform()
+		if err != nil {
+			return "", fmt.Errorf("invalid expression: %w", err)
+		}
+		if value, err = program.EvalContext(ctx, vars); err != nil {
+			return "", transformError("expression", err)
+		}
+	}
+
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("result is not JSON: %w", err)
+	}
+	return string(data), nil
+}
+
+// transformError describes a failed expression or script
+func transformError(kind string, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%s took longer than %s", kind, transformTimeout)
+	}
+	return fmt.Errorf("%s failed: %w", kind, err)
+}
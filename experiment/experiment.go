@@ -0,0 +1,164 @@
+// Package experiment runs an agent spec in several variants - different
+// prompts, models or vars - over the same inputs and compares their cost,
+// latency and, optionally, quality as judged by a model.
+package experiment
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/not7/core/spec"
+	"gopkg.in/yaml.v3"
+)
+
+// Experiment is a set of variants of one agent spec and the inputs every
+// variant runs on
+type Experiment struct {
+	Name     string            `yaml:"name"`
+	Spec     string            `yaml:"spec"` // Agent spec file, relative to the experiment file
+	Vars     map[string]string `yaml:"vars"` // Variables for every variant
+	Inputs   []string          `yaml:"inputs"`
+	Variants []Variant         `yaml:"variants"`
+	Judge    *Judge            `yaml:"judge"` // Optional quality judging
+
+	dir string
+}
+
+// Variant is one way of running the spec
+type Variant struct {
+	Name    string            `yaml:"name"`
+	Model   string            `yaml:"model"`   // Model of every llm and react node
+	Prompts map[string]string `yaml:"prompts"` // By node ID: an llm node's prompt or a react node's goal
+	Vars    map[string]string `yaml:"vars"`    // Override the experiment's vars
+}
+
+// Judge configures the model that rates each output against a rubric
+type Judge struct {
+	Rubric string `yaml:"rubric"`
+	Model  string `yaml:"model"` // Default: OPENAI_DEFAULT_MODEL
+}
+
+// Load reads an experiment file (YAML or JSON) and validates it
+func Load(path string) (*Experiment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read experiment: %w", err)
+	}
+
+	var x Experiment
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&x); err != nil {
+		return nil, fmt.Errorf("failed to parse experiment: %w", err)
+	}
+	if err := x.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid experiment: %w", err)
+	}
+	x.dir = filepath.Dir(path)
+	return &x, nil
+}
+
+// Validate checks an experiment and names unnamed variants
+func (x *Experiment) Validate() error {
+	if x.Spec == "" {
+		return fmt.Errorf("spec is required")
+	}
+	if len(x.Inputs) == 0 {
+		return fmt.Errorf("at least one input is required")
+	}
+	if len(x.Variants) == 0 {
+		return fmt.Errorf("at least one variant is required")
+	}
+	if x.Judge != nil && x.Judge.Rubric == "" {
+		return fmt.Errorf("judge: rubric is required")
+	}
+
+	names := make(map[string]bool)
+	for i := range x.Variants {
+		v := &x.Variants[i]
+		if v.Name == "" {
+			v.Name = fmt.Sprintf("variant-%d", i+1)
+		}
+		if names[v.Name] {
+			return fmt.Errorf("duplicate variant: %s", v.Name)
+		}
+		names[v.Name] = true
+	}
+	return nil
+}
+
+// SpecPath returns the spec file, resolved against the experiment's directory
+func (x *Experiment) SpecPath() string {
+	if filepath.IsAbs(x.Spec) || x.dir == "" {
+		return x.Spec
+	}
+	return filepath.Join(x.dir, x.Spec)
+}
+
+// vars merges the experiment's vars with a variant's
+func (x *Experiment) vars(v *Variant) map[string]string {
+	if len(x.Vars) == 0 && len(v.Vars) == 0 {
+		return nil
+	}
+	vars := make(map[string]string, len(x.Vars)+len(v.Vars))
+	for k, val := range x.Vars {
+		vars[k] = val
+	}
+	for k, val := range v.Vars {
+		vars[k] = val
+	}
+	return vars
+}
+
+// Apply rewrites agentSpec with the variant's model and prompts
+func (v *Variant) Apply(agentSpec *spec.AgentSpec) error {
+	nodes := make(map[string]*spec.Node, len(agentSpec.Nodes))
+	for i := range agentSpec.Nodes {
+		nodes[agentSpec.Nodes[i].ID] = &agentSpec.Nodes[i]
+	}
+
+	for id, prompt := range v.Prompts {
+		node, ok := nodes[id]
+		if !ok {
+			return fmt.Errorf("variant %s: prompt for unknown node %s", v.Name, id)
+		}
+		switch node.Type {
+		case "llm":
+			node.Prompt = prompt
+			node.PromptRef = ""
+		case "react":
+			node.ReActGoal = prompt
+		default:
+			return fmt.Errorf("variant %s: node %s is a %s node, which has no prompt", v.Name, id, node.Type)
+		}
+	}
+
+	if v.Model == "" {
+		return nil
+	}
+	// Nodes with their own LLM config are changed directly, the others
+	// through the agent's config
+	usesAgentLLM := false
+	for _, node := range nodes {
+		if node.Type != "llm" && node.Type != "react" {
+			continue
+		}
+		if node.LLM != nil {
+			node.LLM.Model = v.Model
+		} else {
+			usesAgentLLM = true
+		}
+	}
+	if usesAgentLLM {
+		if agentSpec.Config == nil {
+			agentSpec.Config = &spec.Config{}
+		}
+		if agentSpec.Config.LLM == nil {
+			agentSpec.Config.LLM = &spec.LLMConfig{Provider: "openai"}
+		}
+		agentSpec.Config.LLM.Model = v.Model
+	}
+	return nil
+}
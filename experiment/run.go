@@ -0,0 +1,196 @@
+package experiment
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/not7/core/eval"
+)
+
+// RunFunc runs variant v on input with vars, labelling the execution with tags
+type RunFunc func(ctx context.Context, v *Variant, input string, vars, tags map[string]string) (*eval.Output, error)
+
+// RunResult is the outcome of one variant on one input
+type RunResult struct {
+	Variant     string  `json:"variant"`
+	InputIndex  int     `json:"input_index"` // Position in the experiment's inputs, from 1
+	ExecutionID string  `json:"execution_id,omitempty"`
+	Output      string  `json:"output,omitempty"`
+	Error       string  `json:"error,omitempty"` // The run failed, so it was not judged
+	Cost        float64 `json:"cost"`
+	Tokens      int     `json:"tokens,omitempty"`
+	DurationMs  int64   `json:"duration_ms"`
+	Judged      bool    `json:"judged,omitempty"`
+	Pass        bool    `json:"pass,omitempty"`
+	Reason      string  `json:"reason,omitempty"` // The judge's reason
+	JudgeCost   float64 `json:"judge_cost,omitempty"`
+}
+
+// VariantSummary compares one variant's runs
+type VariantSummary struct {
+	Name          string   `json:"name"`
+	Runs          int      `json:"runs"`
+	Failed        int      `json:"failed"`
+	Cost          float64  `json:"cost"`
+	AvgCost       float64  `json:"avg_cost"`
+	AvgDurationMs int64    `json:"avg_duration_ms"`
+	P95DurationMs int64    `json:"p95_duration_ms"`
+	AvgTokens     int      `json:"avg_tokens"`
+	Judged        int      `json:"judged,omitempty"`
+	Passed        int      `json:"passed,omitempty"`
+	Quality       *float64 `json:"quality,omitempty"` // Share of judged runs that passed
+}
+
+// Report is the outcome of an experiment
+type Report struct {
+	Experiment string           `json:"experiment,omitempty"`
+	Spec       string           `json:"spec"`
+	Inputs     int              `json:"inputs"`
+	JudgeCost  float64          `json:"judge_cost,omitempty"`
+	StartedAt  time.Time        `json:"started_at"`
+	EndedAt    time.Time        `json:"ended_at"`
+	Variants   []VariantSummary `json:"variants"`
+	Runs       []RunResult      `json:"runs"`
+}
+
+// Run runs every variant on every input with at most parallel in flight.
+// Inputs are taken in turn, each by every variant, so variants see the same
+// conditions. judge may be nil when the experiment has no judge. onRun, if
+// set, sees each run as it finishes.
+func Run(ctx context.Context, x *Experiment, parallel int, run RunFunc, judge eval.GradeFunc, onRun func(RunResult)) *Report {
+	if parallel < 1 {
+		parallel = 1
+	}
+	report := &Report{
+		Experiment: x.Name,
+		Spec:       x.Spec,
+		Inputs:     len(x.Inputs),
+		StartedAt:  time.Now(),
+		Runs:       make([]RunResult, len(x.Inputs)*len(x.Variants)),
+	}
+
+	var mu sync.Mutex
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, input := range x.Inputs {
+		for j := range x.Variants {
+			// Take a slot before starting, so runs start in order
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(slot, i, j int, input string) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				result := runOne(ctx, x, &x.Variants[j], i+1, input, run, judge)
+				mu.Lock()
+				report.Runs[slot] = result
+				if onRun != nil {
+					onRun(result)
+				}
+				mu.Unlock()
+			}(i*len(x.Variants)+j, i, j, input)
+		}
+	}
+	wg.Wait()
+
+	for j := range x.Variants {
+		report.Variants = append(report.Variants, summarize(x.Variants[j].Name, report.Runs))
+	}
+	for _, r := range report.Runs {
+		report.JudgeCost += r.JudgeCost
+	}
+	report.EndedAt = time.Now()
+	return report
+}
+
+func runOne(ctx context.Context, x *Experiment, v *Variant, index int, input string, run RunFunc, judge eval.GradeFunc) (result RunResult) {
+	result = RunResult{Variant: v.Name, InputIndex: index}
+	start := time.Now()
+	defer func() {
+		// Prefer the runtime's own timing over the time spent waiting for it
+		if result.DurationMs == 0 {
+			result.DurationMs = time.Since(start).Milliseconds()
+		}
+	}()
+
+	tags := map[string]string{"experiment": x.Name, "variant": v.Name, "input": strconv.Itoa(index)}
+	if x.Name == "" {
+		delete(tags, "experiment")
+	}
+
+	out, err := run(ctx, v, input, x.vars(v), tags)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.ExecutionID = out.ExecutionID
+	result.Output = out.Output
+	result.Cost = out.Cost
+	result.Tokens = out.Tokens
+	result.DurationMs = out.DurationMs
+	if out.Status != "completed" {
+		result.Error = fmt.Sprintf("execution %s: %s", out.Status, out.Error)
+		return result
+	}
+
+	if judge != nil && x.Judge != nil {
+		verdict, err := judge(ctx, x.Judge.Rubric, input, out.Output)
+		if err != nil {
+			result.Reason = fmt.Sprintf("judging failed: %v", err)
+			return result
+		}
+		result.Judged = true
+		result.Pass = verdict.Pass
+		result.Reason = verdict.Reason
+		result.JudgeCost = verdict.Cost
+	}
+	return result
+}
+
+// summarize aggregates the runs of the named variant
+func summarize(name string, runs []RunResult) VariantSummary {
+	summary := VariantSummary{Name: name}
+	var durations []int64
+	var totalDuration int64
+	var tokens int
+
+	for _, r := range runs {
+		if r.Variant != name {
+			continue
+		}
+		summary.Runs++
+		summary.Cost += r.Cost
+		if r.Error != "" {
+			summary.Failed++
+			continue
+		}
+		durations = append(durations, r.DurationMs)
+		totalDuration += r.DurationMs
+		tokens += r.Tokens
+		if r.Judged {
+			summary.Judged++
+			if r.Pass {
+				summary.Passed++
+			}
+		}
+	}
+
+	if summary.Runs > 0 {
+		summary.AvgCost = summary.Cost / float64(summary.Runs)
+	}
+	if n := len(durations); n > 0 {
+		summary.AvgDurationMs = totalDuration / int64(n)
+		summary.AvgTokens = tokens / n
+		sort.Slice(durations, func(a, b int) bool { return durations[a] < durations[b] })
+		summary.P95DurationMs = durations[(n*95+99)/100-1]
+	}
+	if summary.Judged > 0 {
+		quality := float64(summary.Passed) / float64(summary.Judged)
+		summary.Quality = &quality
+	}
+	return summary
+}
@@ -1,47 +1,526 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/not7/core/client"
+	"github.com/not7/core/config"
+	"github.com/not7/core/eval"
+	"github.com/not7/core/executor"
+	"github.com/not7/core/experiment"
+	"github.com/not7/core/internal/ui"
 	"github.com/not7/core/spec"
+	"github.com/not7/core/team"
+	"github.com/not7/core/tools"
 )
 
 // PrintExecutionResult prints the result of an agent execution
-func PrintExecutionResult(result map[string]interface{}) {
-	if status, ok := result["status"].(string); ok && status == "error" {
-		fmt.Printf("\n❌ Failed: %s\n", result["error"])
+func PrintExecutionResult(result *client.Execution) {
+	if result.Error != "" {
+		ui.Printf("\n❌ Failed: %s\n", result.Error)
 		return
 	}
 
-	fmt.Printf("\n✅ Completed\n")
+	ui.Infof("\n✅ Completed\n")
 
-	if cost, ok := result["cost"].(float64); ok {
-		fmt.Printf("💰 Cost: $%.4f\n", cost)
+	ui.Infof("💰 Cost: $%.4f\n", result.TotalCost)
+	if result.Metadata != nil && result.Metadata.PromptTokens+result.Metadata.CompletionTokens > 0 {
+		ui.Infof("🔢 Tokens: %d prompt + %d completion\n", result.Metadata.PromptTokens, result.Metadata.CompletionTokens)
 	}
+	ui.Infof("⏱️  Time: %.1fs\n", float64(result.DurationMs)/1000)
 
-	if duration, ok := result["duration_ms"].(float64); ok {
-		fmt.Printf("⏱️  Time: %.1fs\n", duration/1000)
+	if result.Metadata != nil && len(result.Metadata.OutputViolations) > 0 {
+		ui.Printf("\n⚠️  Output does not match the spec's output contract:\n")
+		for _, violation := range result.Metadata.OutputViolations {
+			ui.Printf("   - %s\n", violation)
+		}
+	}
+
+	if result.Output != "" {
+		ui.Infoln("\n📄 Output:")
+		ui.Infoln("─────────────────────────────────────")
+		ui.Println(result.Output)
+		ui.Infoln("─────────────────────────────────────")
+	}
+}
+
+// PrintTeamMessage prints a message posted to a team's mailbox
+func PrintTeamMessage(m team.Message) {
+	switch {
+	case m.From == team.User:
+		ui.Infof("💬 %s → %s\n", m.From, m.To)
+	case m.Cost > 0:
+		ui.Infof("💬 [turn %d] %s → %s ($%.4f)\n", m.Turn, m.From, m.To, m.Cost)
+	default:
+		ui.Infof("💬 [turn %d] %s → %s\n", m.Turn, m.From, m.To)
+	}
+	if ui.IsVerbose() {
+		ui.Infof("   %s\n", strings.ReplaceAll(m.Content, "\n", "\n   "))
+	} else {
+		ui.Infof("   %s\n", truncate(strings.ReplaceAll(m.Content, "\n", " "), 100))
+	}
+}
+
+// PrintTeamResult prints the outcome of a team run; errors are left to the
+// caller
+func PrintTeamResult(trace *team.Trace) {
+	if trace.Error == "" {
+		ui.Infof("\n✅ Completed\n")
+	} else {
+		ui.Infoln()
+	}
+
+	ui.Infof("🔄 Turns: %d\n", trace.Turns)
+	ui.Infof("💰 Cost: $%.4f\n", trace.TotalCost)
+	if trace.Tokens > 0 {
+		ui.Infof("🔢 Tokens: %d\n", trace.Tokens)
+	}
+	ui.Infof("⏱️  Time: %.1fs\n", trace.EndedAt.Sub(trace.StartedAt).Seconds())
+
+	if trace.Output != "" {
+		ui.Infoln("\n📄 Output:")
+		ui.Infoln("─────────────────────────────────────")
+		ui.Println(trace.Output)
+		ui.Infoln("─────────────────────────────────────")
+	}
+}
+
+// PrintExecutionTable prints execution summaries as an aligned table
+func PrintExecutionTable(executions []*client.ExecutionInfo) {
+	w := ui.NewTableWriter()
+	fmt.Fprintln(w, "ID\tGOAL\tSTATUS\tDURATION\tCOST")
+
+	for _, exec := range executions {
+		duration := "-"
+		if exec.DurationMs > 0 {
+			duration = fmt.Sprintf("%.1fs", float64(exec.DurationMs)/1000)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t$%.4f\n",
+			exec.ID, truncate(exec.Goal, 40), exec.Status, duration, exec.TotalCost)
+	}
+
+	w.Flush()
+}
+
+// PrintArtifactTable prints an execution's artifacts as an aligned table
+func PrintArtifactTable(artifacts []spec.Artifact) {
+	w := ui.NewTableWriter()
+	fmt.Fprintln(w, "NAME\tNODE\tTYPE\tSIZE")
+
+	for _, artifact := range artifacts {
+		node := artifact.NodeID
+		if artifact.ToolName != "" {
+			node += " (" + artifact.ToolName + ")"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", artifact.Name, node, artifact.ContentType, artifact.Size)
+	}
+
+	w.Flush()
+}
+
+// PrintCostEstimate prints a per-node and total cost estimate table
+func PrintCostEstimate(estimate *executor.CostEstimate) {
+	w := ui.NewTableWriter()
+	fmt.Fprintln(w, "NODE\tTYPE\tMODEL\tCALLS\tIN TOKENS\tOUT TOKENS\tCOST")
+
+	for _, node := range estimate.Nodes {
+		model := node.Model
+		if model == "" {
+			model = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t$%.4f\n",
+			node.NodeID, node.NodeType, model, node.Calls, node.PromptTokens, node.CompletionTokens, node.Cost)
+	}
+
+	fmt.Fprintf(w, "TOTAL\t\t\t\t%d\t%d\t$%.4f\n",
+		estimate.PromptTokens, estimate.CompletionTokens, estimate.TotalCost)
+	w.Flush()
+
+	ui.Infoln("\nToken counts are approximate; ReAct nodes assume every iteration runs.")
+}
+
+// PrintCostSimulation prints each node's recorded cost beside its cost under
+// every simulated model, then the total of each model against the recording
+func PrintCostSimulation(simulation *executor.CostSimulation) {
+	w := ui.NewTableWriter()
+	header := "NODE\tTYPE\tMODEL\tIN TOKENS\tOUT TOKENS\tRECORDED"
+	for _, model := range simulation.Models {
+		header += "\t" + strings.ToUpper(model.Model)
+	}
+	fmt.Fprintln(w, header)
+
+	for _, node := range simulation.Nodes {
+		model := node.Model
+		if model == "" {
+			model = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t$%.4f", node.NodeID, node.NodeType, model, node.PromptTokens, node.CompletionTokens, node.RecordedCost)
+		for _, cost := range node.Costs {
+			fmt.Fprintf(w, "\t$%.4f", cost)
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "TOTAL\t\t\t%d\t%d\t$%.4f", simulation.PromptTokens, simulation.CompletionTokens, simulation.RecordedCost)
+	for _, cost := range simulation.Costs {
+		fmt.Fprintf(w, "\t$%.4f", cost)
+	}
+	fmt.Fprintln(w)
+	w.Flush()
+
+	ui.Println()
+	for i, model := range simulation.Models {
+		cost := simulation.Costs[i]
+		change := "same as recorded"
+		if simulation.RecordedCost > 0 && cost != simulation.RecordedCost {
+			change = fmt.Sprintf("%+.0f%% vs recorded", (cost-simulation.RecordedCost)/simulation.RecordedCost*100)
+		}
+		ui.Printf("💰 %s: $%.4f (%s; $%.5f in, $%.5f out per 1K tokens)\n", model.Model, cost, change, model.Pricing.InputPer1K, model.Pricing.OutputPer1K)
+	}
+}
+
+// BatchResult is the outcome of one spec in a batch run
+type BatchResult struct {
+	Spec      string
+	Execution *client.Execution
+	Err       error
+}
+
+// Succeeded reports whether the spec ran to completion
+func (r BatchResult) Succeeded() bool {
+	return r.Err == nil && r.Execution != nil && r.Execution.Status == "completed"
+}
+
+// PrintBatchSummary prints per-spec statuses and costs with aggregate totals
+func PrintBatchSummary(results []BatchResult) {
+	w := ui.NewTableWriter()
+	fmt.Fprintln(w, "SPEC\tEXECUTION\tSTATUS\tDURATION\tCOST")
+
+	var totalCost float64
+	succeeded := 0
+	for _, result := range results {
+		if result.Succeeded() {
+			succeeded++
+		}
+
+		if result.Execution == nil {
+			fmt.Fprintf(w, "%s\t-\terror\t-\t-\n", result.Spec)
+			continue
+		}
+
+		exec := result.Execution
+		totalCost += exec.TotalCost
+		fmt.Fprintf(w, "%s\t%s\t%s\t%.1fs\t$%.4f\n",
+			result.Spec, exec.ID, exec.Status, float64(exec.DurationMs)/1000, exec.TotalCost)
+	}
+
+	w.Flush()
+
+	ui.Printf("\n📊 %d/%d succeeded · 💰 Total cost: $%.4f\n", succeeded, len(results), totalCost)
+}
+
+// PrintEvalCase prints a finished eval case with its failed assertions
+// (every assertion with --verbose)
+func PrintEvalCase(result eval.CaseResult) {
+	if result.Passed {
+		ui.Infof("✅ %s ($%.4f, %.1fs)\n", result.Name, result.Cost, float64(result.DurationMs)/1000)
+	} else {
+		ui.Printf("❌ %s ($%.4f, %.1fs)\n", result.Name, result.Cost, float64(result.DurationMs)/1000)
+	}
+	if result.Error != "" {
+		ui.Printf("   error: %s\n", result.Error)
+	}
+
+	for _, a := range result.Assertions {
+		if a.Passed && !ui.IsVerbose() {
+			continue
+		}
+		mark := "✗"
+		if a.Passed {
+			mark = "✓"
+		}
+		line := fmt.Sprintf("   %s %s", mark, a.Assertion)
+		if a.Message != "" {
+			line += ": " + a.Message
+		}
+		ui.Println(line)
+	}
+	if !result.Passed && result.Error == "" {
+		ui.Printf("   output: %s\n", truncate(strings.ReplaceAll(result.Output, "\n", " "), 100))
+	}
+}
+
+// PrintEvalSummary prints the pass rate and costs of an eval run
+func PrintEvalSummary(report *eval.Report) {
+	total := report.Passed + report.Failed
+	ui.Printf("\n📊 %d/%d passed · 💰 Agent cost: $%.4f", report.Passed, total, report.Cost)
+	if report.GradingCost > 0 {
+		ui.Printf(" · Grading cost: $%.4f", report.GradingCost)
+	}
+	ui.Printf(" · ⏱️  %.1fs\n", report.EndedAt.Sub(report.StartedAt).Seconds())
+}
+
+// PrintExperimentRun prints a finished experiment run on one line
+func PrintExperimentRun(result experiment.RunResult) {
+	label := fmt.Sprintf("%s #%d ($%.4f, %.1fs)", result.Variant, result.InputIndex, result.Cost, float64(result.DurationMs)/1000)
+	switch {
+	case result.Error != "":
+		ui.Printf("❌ %s\n   error: %s\n", label, result.Error)
+	case result.Judged && !result.Pass:
+		ui.Infof("👎 %s: %s\n", label, result.Reason)
+	case result.Judged:
+		ui.Infof("👍 %s\n", label)
+	default:
+		ui.Infof("✅ %s\n", label)
+	}
+}
+
+// PrintExperimentReport prints a comparison table of the variants
+func PrintExperimentReport(report *experiment.Report) {
+	w := ui.NewTableWriter()
+	fmt.Fprintln(w, "VARIANT\tRUNS\tFAILED\tAVG COST\tTOTAL COST\tAVG TIME\tP95 TIME\tAVG TOKENS\tQUALITY")
+	for _, v := range report.Variants {
+		quality := "-"
+		if v.Quality != nil {
+			quality = fmt.Sprintf("%.0f%% (%d/%d)", *v.Quality*100, v.Passed, v.Judged)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t$%.4f\t$%.4f\t%.1fs\t%.1fs\t%d\t%s\n",
+			v.Name, v.Runs, v.Failed, v.AvgCost, v.Cost, float64(v.AvgDurationMs)/1000, float64(v.P95DurationMs)/1000, v.AvgTokens, quality)
+	}
+	w.Flush()
+
+	if report.JudgeCost > 0 {
+		ui.Infof("\nJudging cost: $%.4f\n", report.JudgeCost)
+	}
+}
+
+// PrintAuthStatus prints the authorization state of a provider's toolkits
+func PrintAuthStatus(statuses []tools.AuthStatus) {
+	w := ui.NewTableWriter()
+	fmt.Fprintln(w, "TOOLKIT\tSTATUS\tTOOLS")
+	for _, status := range statuses {
+		fmt.Fprintf(w, "%s\t%s\t%d\n", status.Toolkit, authStatusLabel(status.Status), status.Tools)
+	}
+	w.Flush()
+}
+
+// authStatusLabel decorates an authorization status for display
+func authStatusLabel(status string) string {
+	switch status {
+	case "active":
+		return "✅ authorized"
+	case "not_required":
+		return "— not required"
+	case "pending":
+		return "⏳ pending"
+	default:
+		return "❌ " + strings.ReplaceAll(status, "_", " ")
+	}
+}
+
+// PrintConfigIssues prints config errors and warnings with their hints
+func PrintConfigIssues(issues []config.Issue) {
+	for _, issue := range issues {
+		icon := "⚠️ "
+		if issue.Severity == config.SeverityError {
+			icon = "❌"
+		}
+		ui.Printf("%s %s\n", icon, issue)
+		if issue.Hint != "" {
+			ui.Printf("   → %s\n", issue.Hint)
+		}
+	}
+}
+
+// PrintSpecIssues prints route graph warnings and errors of an agent spec
+func PrintSpecIssues(issues []spec.Issue) {
+	for _, issue := range issues {
+		icon := "⚠️ "
+		if issue.Severity == spec.SeverityError {
+			icon = "❌"
+		}
+		ui.Printf("%s %s\n", icon, issue)
+	}
+}
+
+// PrintToolList prints tool definitions with their parameters
+func PrintToolList(defs []tools.ToolDefinition) {
+	if len(defs) == 0 {
+		ui.Println("No tools available")
+		return
+	}
+
+	ui.Infof("🔧 %d tools\n", len(defs))
+	for _, def := range defs {
+		ui.Printf("\n%s [%s]\n", def.Name, def.Provider)
+		if def.Description != "" {
+			ui.Printf("  %s\n", def.Description)
+		}
+
+		params := toolParameters(def.InputSchema)
+		if len(params) == 0 {
+			continue
+		}
+		ui.Println("  Parameters:")
+		for _, param := range params {
+			ui.Printf("    - %s\n", param)
+		}
+	}
+}
+
+// toolParameters describes the properties of a JSON input schema as
+// "name (type, required): description" lines, sorted by name
+func toolParameters(schema map[string]interface{}) []string {
+	properties, _ := schema["properties"].(map[string]interface{})
+	if len(properties) == 0 {
+		return nil
+	}
+
+	required := make(map[string]bool)
+	switch names := schema["required"].(type) {
+	case []string:
+		for _, name := range names {
+			required[name] = true
+		}
+	case []interface{}:
+		for _, name := range names {
+			if s, ok := name.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := make([]string, 0, len(names))
+	for _, name := range names {
+		prop, _ := properties[name].(map[string]interface{})
+
+		// Builtin schemas use "type"; Arcade parameters nest it in value_schema
+		paramType, _ := prop["type"].(string)
+		if valueSchema, ok := prop["value_schema"].(map[string]interface{}); ok && paramType == "" {
+			paramType, _ = valueSchema["val_type"].(string)
+		}
+
+		attrs := []string{}
+		if paramType != "" {
+			attrs = append(attrs, paramType)
+		}
+		if required[name] {
+			attrs = append(attrs, "required")
+		}
+
+		line := name
+		if len(attrs) > 0 {
+			line += " (" + strings.Join(attrs, ", ") + ")"
+		}
+		if desc, _ := prop["description"].(string); desc != "" {
+			line += ": " + desc
+		}
+		params = append(params, line)
 	}
 
-	if output, ok := result["output"].(string); ok {
-		fmt.Println("\n📄 Output:")
-		fmt.Println("─────────────────────────────────────")
-		fmt.Println(output)
-		fmt.Println("─────────────────────────────────────")
+	return params
+}
+
+// PrintToolResult prints the outcome of a single tool execution
+func PrintToolResult(result *tools.ToolResult, elapsed time.Duration) {
+	if !result.Success {
+		ui.Printf("❌ Failed: %s\n", result.Error)
+		return
+	}
+
+	ui.Infof("✅ Completed in %.1fs\n", elapsed.Seconds())
+
+	text, ok := result.Output.(string)
+	if !ok {
+		data, err := json.MarshalIndent(result.Output, "", "  ")
+		if err != nil {
+			text = fmt.Sprintf("%v", result.Output)
+		} else {
+			text = string(data)
+		}
+	}
+
+	ui.Infoln("\n📄 Output:")
+	ui.Infoln("─────────────────────────────────────")
+	ui.Println(text)
+	ui.Infoln("─────────────────────────────────────")
+}
+
+// truncate shortens s to at most max runes, adding an ellipsis when cut
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+// PrintEvent prints a single streamed execution event
+func PrintEvent(event client.Event) {
+	switch event.Type {
+	case executor.EventExecutionStarted:
+		ui.Infof("🚀 Started: %s\n", event.Message)
+	case executor.EventNodeStarted:
+		ui.Infof("⚙️  Executing node: %s (%s)\n", event.NodeID, event.NodeType)
+	case executor.EventNodeCompleted:
+		ui.Infof("   ✓ Completed in %dms (cost: $%.4f)\n", event.DurationMs, event.Cost)
+	case executor.EventNodeFailed:
+		ui.Infof("   ❌ Failed: %s\n", event.Error)
+	case executor.EventReActIteration:
+		ui.Infof("   💭 Iteration %d: %s\n", event.Iteration, event.Message)
+	case executor.EventToolCall:
+		if event.Error != "" {
+			ui.Infof("      🔧 %s failed: %s\n", event.ToolName, event.Error)
+		} else {
+			ui.Infof("      🔧 %s (%dms)\n", event.ToolName, event.DurationMs)
+		}
+	case executor.EventNodeSuspended:
+		ui.Infof("   ⏸️  Suspended: %s\n", event.Message)
+	case executor.EventNodeResumed:
+		ui.Infof("   ▶️  Resumed after %s\n", time.Duration(event.DurationMs)*time.Millisecond)
 	}
 }
 
 // DisplayTrace displays a detailed ReAct execution trace
 func DisplayTrace(agent *spec.AgentSpec, showFull bool) {
-	fmt.Printf("\n╔══════════════════════════════════════════════════════════════╗\n")
-	fmt.Printf("║  ReAct Execution Trace                                       ║\n")
-	fmt.Printf("╚══════════════════════════════════════════════════════════════╝\n\n")
+	ui.Printf("\n╔══════════════════════════════════════════════════════════════╗\n")
+	ui.Printf("║  ReAct Execution Trace                                       ║\n")
+	ui.Printf("╚══════════════════════════════════════════════════════════════╝\n\n")
+
+	ui.Printf("🎯 Goal: %s\n", agent.Goal)
+	ui.Printf("📊 Status: %s\n", agent.Metadata.Status)
+	ui.Printf("⏱️  Total Time: %dms\n", agent.Metadata.ExecutionTimeMs)
+	ui.Printf("💰 Total Cost: $%.4f\n", agent.Metadata.TotalCost)
+	ui.Printf("🔢 Tokens: %d prompt + %d completion\n\n", agent.Metadata.PromptTokens, agent.Metadata.CompletionTokens)
+
+	if len(agent.Metadata.OutputViolations) > 0 {
+		ui.Printf("⚠️  Output contract violations:\n")
+		for _, violation := range agent.Metadata.OutputViolations {
+			ui.Printf("   - %s\n", violation)
+		}
+		ui.Println()
+	}
 
-	fmt.Printf("🎯 Goal: %s\n", agent.Goal)
-	fmt.Printf("📊 Status: %s\n", agent.Metadata.Status)
-	fmt.Printf("⏱️  Total Time: %dms\n", agent.Metadata.ExecutionTimeMs)
-	fmt.Printf("💰 Total Cost: $%.4f\n\n", agent.Metadata.TotalCost)
+	if len(agent.Metadata.NodeResults) > 0 {
+		ui.Printf("🧩 Nodes:\n")
+		for _, nodeResult := range agent.Metadata.NodeResults {
+			ui.Printf("   - %s: %s, %dms, $%.4f\n", nodeResult.NodeID, nodeResult.Status, nodeResult.ExecutionTimeMs, nodeResult.Cost)
+			if annotations := formatAnnotations(nodeResult.Annotations); annotations != "" {
+				ui.Printf("     %s\n", annotations)
+			}
+		}
+		ui.Println()
+	}
 
 	// Find ReAct nodes with traces
 	for _, nodeResult := range agent.Metadata.NodeResults {
@@ -50,16 +529,16 @@ func DisplayTrace(agent *spec.AgentSpec, showFull bool) {
 		}
 
 		trace := nodeResult.ReActTrace
-		fmt.Printf("═══════════════════════════════════════════════════════════════\n")
-		fmt.Printf("Node: %s\n", nodeResult.NodeID)
-		fmt.Printf("Iterations: %d | Time: %dms | Cost: $%.4f\n",
+		ui.Printf("═══════════════════════════════════════════════════════════════\n")
+		ui.Printf("Node: %s\n", nodeResult.NodeID)
+		ui.Printf("Iterations: %d | Time: %dms | Cost: $%.4f\n",
 			trace.Iterations, trace.TotalThinkingTimeMs, trace.IterationsCost)
-		fmt.Printf("═══════════════════════════════════════════════════════════════\n\n")
+		ui.Printf("═══════════════════════════════════════════════════════════════\n\n")
 
 		for _, step := range trace.ThinkingSteps {
-			fmt.Printf("┌─ Iteration %d ─────────────────────────────────────────────┐\n", step.Iteration)
-			fmt.Printf("│ Duration: %dms | Cost: $%.4f\n", step.DurationMs, step.Cost)
-			fmt.Printf("└──────────────────────────────────────────────────────────────┘\n\n")
+			ui.Printf("┌─ Iteration %d ─────────────────────────────────────────────┐\n", step.Iteration)
+			ui.Printf("│ Duration: %dms | Cost: $%.4f | Tokens: %d+%d\n", step.DurationMs, step.Cost, step.PromptTokens, step.CompletionTokens)
+			ui.Printf("└──────────────────────────────────────────────────────────────┘\n\n")
 
 			// Show thought
 			thought := step.Thought
@@ -67,72 +546,109 @@ func DisplayTrace(agent *spec.AgentSpec, showFull bool) {
 				thought = thought[:500] + "\n... [truncated, use --full to see all]"
 			}
 
-			fmt.Printf("💭 Thought:\n")
-			fmt.Printf("   %s\n\n", strings.ReplaceAll(thought, "\n", "\n   "))
+			ui.Printf("💭 Thought:\n")
+			ui.Printf("   %s\n\n", strings.ReplaceAll(thought, "\n", "\n   "))
 
 			// Show tool calls
 			if len(step.ToolCalls) > 0 {
 				for _, toolCall := range step.ToolCalls {
-					fmt.Printf("🔧 Tool Call: %s\n", toolCall.ToolName)
+					ui.Printf("🔧 Tool Call: %s\n", toolCall.ToolName)
 
 					// Show arguments
 					if len(toolCall.Arguments) > 0 {
-						fmt.Printf("   Arguments:\n")
+						ui.Printf("   Arguments:\n")
 						for key, val := range toolCall.Arguments {
-							fmt.Printf("     • %s: %v\n", key, val)
+							ui.Printf("     • %s: %v\n", key, val)
 						}
 					}
 
 					// Show result or error
-					fmt.Printf("   Duration: %dms\n", toolCall.DurationMs)
+					ui.Printf("   Duration: %dms\n", toolCall.DurationMs)
 
 					if toolCall.Error != "" {
-						fmt.Printf("   ❌ Error: %s\n", toolCall.Error)
+						ui.Printf("   ❌ Error: %s\n", toolCall.Error)
 					} else {
 						resultStr := fmt.Sprintf("%v", toolCall.Result)
 						if !showFull && len(resultStr) > 300 {
 							resultStr = resultStr[:300] + "... [truncated]"
 						}
-						fmt.Printf("   ✅ Result:\n")
-						fmt.Printf("      %s\n", strings.ReplaceAll(resultStr, "\n", "\n      "))
+						ui.Printf("   ✅ Result:\n")
+						ui.Printf("      %s\n", strings.ReplaceAll(resultStr, "\n", "\n      "))
 					}
-					fmt.Println()
+					ui.Println()
 				}
 			}
 
-			fmt.Println()
+			ui.Println()
 		}
 
 		// Show final output
 		if nodeResult.Output != nil {
-			fmt.Printf("═══════════════════════════════════════════════════════════════\n")
-			fmt.Printf("🎬 Final Output:\n")
-			fmt.Printf("═══════════════════════════════════════════════════════════════\n\n")
+			ui.Printf("═══════════════════════════════════════════════════════════════\n")
+			ui.Printf("🎬 Final Output:\n")
+			ui.Printf("═══════════════════════════════════════════════════════════════\n\n")
 
 			outputStr := fmt.Sprintf("%v", nodeResult.Output)
-			fmt.Printf("%s\n\n", outputStr)
+			ui.Printf("%s\n\n", outputStr)
 		}
 	}
+
+	// Show what happened inside failed nodes (every node with --full)
+	for _, nodeResult := range agent.Metadata.NodeResults {
+		if len(nodeResult.Logs) == 0 || (nodeResult.Status != "failed" && !showFull) {
+			continue
+		}
+
+		ui.Printf("═══════════════════════════════════════════════════════════════\n")
+		ui.Printf("📜 Node Logs: %s (%s)\n", nodeResult.NodeID, nodeResult.Status)
+		if nodeResult.Error != "" {
+			ui.Printf("❌ Error: %s\n", nodeResult.Error)
+		}
+		ui.Printf("═══════════════════════════════════════════════════════════════\n\n")
+
+		if nodeResult.LogsDropped > 0 {
+			ui.Printf("   ... %d earlier lines dropped\n", nodeResult.LogsDropped)
+		}
+		for _, line := range nodeResult.Logs {
+			ui.Printf("   %s\n", line)
+		}
+		ui.Println()
+	}
+}
+
+// formatAnnotations renders node annotations as key: value pairs in key order
+func formatAnnotations(annotations map[string]string) string {
+	keys := make([]string, 0, len(annotations))
+	for key := range annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + ": " + annotations[key]
+	}
+	return strings.Join(pairs, " · ")
 }
 
 // PrintLiveTraceHeader prints the header for live trace mode
 func PrintLiveTraceHeader() {
-	fmt.Printf("\n╔══════════════════════════════════════════════════════════════╗\n")
-	fmt.Printf("║  🔍 ReAct Execution with Live Trace                         ║\n")
-	fmt.Printf("╚══════════════════════════════════════════════════════════════╝\n\n")
+	ui.Infof("\n╔══════════════════════════════════════════════════════════════╗\n")
+	ui.Infof("║  🔍 ReAct Execution with Live Trace                         ║\n")
+	ui.Infof("╚══════════════════════════════════════════════════════════════╝\n\n")
 }
 
 // PrintLiveTraceSummary prints the final summary for live trace mode
-func PrintLiveTraceSummary(metadata *spec.Metadata, output string) {
-	fmt.Printf("\n╔══════════════════════════════════════════════════════════════╗\n")
-	fmt.Printf("║  ✨ Execution Complete                                        ║\n")
-	fmt.Printf("╚══════════════════════════════════════════════════════════════╝\n\n")
-
-	fmt.Printf("⏱️  Total Time: %dms\n", metadata.ExecutionTimeMs)
-	fmt.Printf("💰 Total Cost: $%.4f\n\n", metadata.TotalCost)
-
-	fmt.Printf("📄 Final Output:\n")
-	fmt.Printf("─────────────────────────────────────────────────────────────\n")
-	fmt.Printf("%s\n", output)
-	fmt.Printf("─────────────────────────────────────────────────────────────\n\n")
+func PrintLiveTraceSummary(metadata *spec.Metadata, finalOutput string) {
+	ui.Infof("\n╔══════════════════════════════════════════════════════════════╗\n")
+	ui.Infof("║  ✨ Execution Complete                                        ║\n")
+	ui.Infof("╚══════════════════════════════════════════════════════════════╝\n\n")
+
+	ui.Infof("⏱️  Total Time: %dms\n", metadata.ExecutionTimeMs)
+	ui.Infof("💰 Total Cost: $%.4f\n\n", metadata.TotalCost)
+
+	ui.Infof("📄 Final Output:\n")
+	ui.Infof("─────────────────────────────────────────────────────────────\n")
+	ui.Printf("%s\n", finalOutput)
+	ui.Infof("─────────────────────────────────────────────────────────────\n\n")
 }
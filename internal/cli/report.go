@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/not7/core/client"
+	"github.com/not7/core/spec"
+)
+
+// Report formats supported by WriteReport
+const (
+	ReportMarkdown = "md"
+	ReportHTML     = "html"
+	ReportJSON     = "json"
+)
+
+// Report bundles everything known about a finished execution
+type Report struct {
+	Execution *client.Execution `json:"execution"`
+	Trace     *spec.AgentSpec   `json:"trace,omitempty"`
+	Logs      string            `json:"logs,omitempty"`
+}
+
+// ReportFormatFromPath guesses the report format from a file extension,
+// falling back to Markdown
+func ReportFormatFromPath(path string) string {
+	switch {
+	case strings.HasSuffix(path, ".html"), strings.HasSuffix(path, ".htm"):
+		return ReportHTML
+	case strings.HasSuffix(path, ".json"):
+		return ReportJSON
+	default:
+		return ReportMarkdown
+	}
+}
+
+// WriteReport renders the report in the given format
+func WriteReport(w io.Writer, format string, report *Report) error {
+	switch format {
+	case ReportMarkdown, "markdown":
+		return writeMarkdownReport(w, report)
+	case ReportHTML:
+		return reportTemplate.Execute(w, newReportView(report))
+	case ReportJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	default:
+		return fmt.Errorf("unsupported report format: %s (use md, html or json)", format)
+	}
+}
+
+// reportView is the flattened form of a report shared by the text formats
+type reportView struct {
+	ID        string
+	Goal      string
+	Status    string
+	Input     string
+	Output    string
+	Error     string
+	Cost      string
+	Duration  string
+	StartedAt string
+	Nodes     []nodeView
+	Logs      string
+}
+
+type nodeView struct {
+	ID       string
+	Status   string
+	Duration string
+	Cost     string
+	Error    string
+	Steps    []stepView
+}
+
+type stepView struct {
+	Iteration int
+	Thought   string
+	ToolCalls []string
+}
+
+func newReportView(report *Report) reportView {
+	exec := report.Execution
+	view := reportView{
+		ID:       exec.ID,
+		Goal:     exec.Goal,
+		Status:   exec.Status,
+		Input:    exec.Input,
+		Output:   exec.Output,
+		Error:    exec.Error,
+		Cost:     fmt.Sprintf("$%.4f", exec.TotalCost),
+		Duration: fmt.Sprintf("%.1fs", float64(exec.DurationMs)/1000),
+		Logs:     report.Logs,
+	}
+	if exec.StartedAt != nil {
+		view.StartedAt = exec.StartedAt.Format(time.RFC3339)
+	}
+
+	if report.Trace == nil || report.Trace.Metadata == nil {
+		return view
+	}
+
+	for _, result := range report.Trace.Metadata.NodeResults {
+		node := nodeView{
+			ID:       result.NodeID,
+			Status:   result.Status,
+			Duration: fmt.Sprintf("%dms", result.ExecutionTimeMs),
+			Cost:     fmt.Sprintf("$%.4f", result.Cost),
+			Error:    result.Error,
+		}
+
+		if result.ReActTrace != nil {
+			for _, step := range result.ReActTrace.ThinkingSteps {
+				sv := stepView{Iteration: step.Iteration, Thought: step.Thought}
+				for _, call := range step.ToolCalls {
+					args, _ := json.Marshal(call.Arguments)
+					line := fmt.Sprintf("%s(%s)", call.ToolName, args)
+					if call.Error != "" {
+						line += " → error: " + call.Error
+					}
+					sv.ToolCalls = append(sv.ToolCalls, line)
+				}
+				node.Steps = append(node.Steps, sv)
+			}
+		}
+
+		view.Nodes = append(view.Nodes, node)
+	}
+
+	return view
+}
+
+func writeMarkdownReport(w io.Writer, report *Report) error {
+	view := newReportView(report)
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "# %s\n\n", view.Goal)
+	fmt.Fprintf(&sb, "| | |\n|---|---|\n")
+	fmt.Fprintf(&sb, "| Execution | `%s` |\n", view.ID)
+	fmt.Fprintf(&sb, "| Status | %s |\n", view.Status)
+	if view.StartedAt != "" {
+		fmt.Fprintf(&sb, "| Started | %s |\n", view.StartedAt)
+	}
+	fmt.Fprintf(&sb, "| Duration | %s |\n", view.Duration)
+	fmt.Fprintf(&sb, "| Cost | %s |\n\n", view.Cost)
+
+	if view.Input != "" {
+		fmt.Fprintf(&sb, "## Input\n\n```\n%s\n```\n\n", view.Input)
+	}
+	if view.Error != "" {
+		fmt.Fprintf(&sb, "## Error\n\n```\n%s\n```\n\n", view.Error)
+	}
+	if view.Output != "" {
+		fmt.Fprintf(&sb, "## Output\n\n%s\n\n", view.Output)
+	}
+
+	if len(view.Nodes) > 0 {
+		sb.WriteString("## Trace\n\n")
+		for _, node := range view.Nodes {
+			fmt.Fprintf(&sb, "### %s\n\n%s · %s · %s\n\n", node.ID, node.Status, node.Duration, node.Cost)
+			if node.Error != "" {
+				fmt.Fprintf(&sb, "Error: `%s`\n\n", node.Error)
+			}
+			for _, step := range node.Steps {
+				fmt.Fprintf(&sb, "**Iteration %d**\n\n%s\n\n", step.Iteration, step.Thought)
+				for _, call := range step.ToolCalls {
+					fmt.Fprintf(&sb, "- 🔧 `%s`\n", call)
+				}
+				if len(step.ToolCalls) > 0 {
+					sb.WriteString("\n")
+				}
+			}
+		}
+	}
+
+	if view.Logs != "" {
+		fmt.Fprintf(&sb, "## Logs\n\n```\n%s```\n", view.Logs)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Goal}}</title>
+<style>
+body { font-family: -apple-system, sans-serif; max-width: 900px; margin: 2em auto; padding: 0 1em; color: #222; }
+table { border-collapse: collapse; }
+td { padding: 4px 12px 4px 0; }
+pre { background: #f5f5f5; padding: 1em; overflow-x: auto; white-space: pre-wrap; }
+.failed { color: #b00; }
+.completed { color: #070; }
+</style>
+</head>
+<body>
+<h1>{{.Goal}}</h1>
+<table>
+<tr><td>Execution</td><td><code>{{.ID}}</code></td></tr>
+<tr><td>Status</td><td class="{{.Status}}">{{.Status}}</td></tr>
+{{if .StartedAt}}<tr><td>Started</td><td>{{.StartedAt}}</td></tr>{{end}}
+<tr><td>Duration</td><td>{{.Duration}}</td></tr>
+<tr><td>Cost</td><td>{{.Cost}}</td></tr>
+</table>
+{{if .Input}}<h2>Input</h2>
+<pre>{{.Input}}</pre>{{end}}
+{{if .Error}}<h2>Error</h2>
+<pre class="failed">{{.Error}}</pre>{{end}}
+{{if .Output}}<h2>Output</h2>
+<pre>{{.Output}}</pre>{{end}}
+{{if .Nodes}}<h2>Trace</h2>
+{{range .Nodes}}<h3>{{.ID}}</h3>
+<p class="{{.Status}}">{{.Status}} · {{.Duration}} · {{.Cost}}</p>
+{{if .Error}}<pre class="failed">{{.Error}}</pre>{{end}}
+{{range .Steps}}<h4>Iteration {{.Iteration}}</h4>
+<pre>{{.Thought}}</pre>
+{{if .ToolCalls}}<ul>{{range .ToolCalls}}<li><code>{{.}}</code></li>{{end}}</ul>{{end}}
+{{end}}{{end}}{{end}}
+{{if .Logs}}<h2>Logs</h2>
+<pre>{{.Logs}}</pre>{{end}}
+</body>
+</html>
+`))
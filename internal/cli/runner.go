@@ -2,24 +2,24 @@ package cli
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/not7/core/config"
 	"github.com/not7/core/executor"
+	"github.com/not7/core/internal/ui"
 	"github.com/not7/core/spec"
 )
 
 // RunAgentWithTrace executes an agent locally with live trace output
-func RunAgentWithTrace(specFile string) error {
+// The input is delivered to the agent's first node(s)
+func RunAgentWithTrace(specFile, input string) error {
 	// Load config
-	configFile := "not7.conf"
-	if envConfig := os.Getenv("NOT7_CONFIG"); envConfig != "" {
-		configFile = envConfig
-	}
+	configFile := config.Path()
 
-	if _, err := config.LoadConfig(configFile); err != nil {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
 		return fmt.Errorf("failed to load config from %s: %w", configFile, err)
 	}
+	PrintConfigIssues(cfg.Warnings())
 
 	PrintLiveTraceHeader()
 
@@ -28,16 +28,16 @@ func RunAgentWithTrace(specFile string) error {
 		return fmt.Errorf("failed to load spec: %w", err)
 	}
 
-	fmt.Printf("🎯 Goal: %s\n\n", agentSpec.Goal)
+	ui.Infof("🎯 Goal: %s\n\n", agentSpec.Goal)
 
 	// Create executor with CLI mode (prints to stdout)
-	exec, err := executor.NewExecutor(agentSpec)
+	exec, err := executor.NewCLIExecutor(agentSpec, cfg)
 	if err != nil {
 		return fmt.Errorf("failed to create executor: %w", err)
 	}
 
 	// Execute
-	output, err := exec.Execute("")
+	output, err := exec.Execute(input)
 	if err != nil {
 		return fmt.Errorf("execution failed: %w", err)
 	}
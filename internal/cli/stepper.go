@@ -0,0 +1,445 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/spec"
+)
+
+// errNotTerminal is returned when stdin is not an interactive terminal
+var errNotTerminal = errors.New("--interactive needs a terminal")
+
+const (
+	// stepperTextLength is the characters of a text shown until the stepper
+	// is expanded
+	stepperTextLength = 800
+
+	// maxDiffCells bounds the work of a diff; larger texts are shown whole
+	maxDiffCells = 1000000
+
+	clearScreen = "\033[H\033[2J"
+	stepperRule = "───────────────────────────────────────────────────────────────\n"
+	stepperHelp = "←/→ step · ↑/↓ node · e expand · d diff · g/G first/last · q quit"
+)
+
+// stepperKey is a stepper command read from the keyboard
+type stepperKey int
+
+const (
+	keyNone stepperKey = iota
+	keyNext
+	keyPrev
+	keyNextNode
+	keyPrevNode
+	keyExpand
+	keyDiff
+	keyFirst
+	keyLast
+	keyQuit
+)
+
+// traceFrame is one stop of the stepper: a node, or one iteration of a
+// ReAct node
+type traceFrame struct {
+	node int // index in the node results
+	step int // index in the node's thinking steps; -1 for the node itself
+}
+
+// traceStepper is the state of an interactive trace
+type traceStepper struct {
+	agent    *spec.AgentSpec
+	frames   []traceFrame
+	pos      int
+	expanded bool // whole texts, tool arguments and results, and node logs
+	diff     bool // iterations show the change from the previous thought
+}
+
+// StepTrace shows a trace one node or ReAct iteration at a time, moving
+// between them with the keyboard until q is pressed. Long texts are cut and
+// tool calls summarized until expanded; expanded starts out expanded.
+func StepTrace(agent *spec.AgentSpec, expanded bool) error {
+	if agent.Metadata == nil || len(agent.Metadata.NodeResults) == 0 {
+		return fmt.Errorf("trace has no node results to step through")
+	}
+
+	restore, err := rawInput()
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	stepper := newTraceStepper(agent, expanded)
+	keys := bufio.NewReader(os.Stdin)
+	for {
+		ui.Printf("%s%s", clearScreen, stepper.render())
+		key, err := readKey(keys)
+		if errors.Is(err, io.EOF) || key == keyQuit {
+			ui.Println()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		stepper.handle(key)
+	}
+}
+
+// newTraceStepper lists the frames of a trace: each node, followed by its
+// ReAct iterations
+func newTraceStepper(agent *spec.AgentSpec, expanded bool) *traceStepper {
+	s := &traceStepper{agent: agent, expanded: expanded}
+	for i, result := range agent.Metadata.NodeResults {
+		s.frames = append(s.frames, traceFrame{node: i, step: -1})
+		if result.ReActTrace != nil {
+			for j := range result.ReActTrace.ThinkingSteps {
+				s.frames = append(s.frames, traceFrame{node: i, step: j})
+			}
+		}
+	}
+	return s
+}
+
+// readKey reads one key press, decoding the escape sequences of the arrow,
+// Home and End keys
+func readKey(r *bufio.Reader) (stepperKey, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return keyNone, err
+	}
+	switch b {
+	case 'l', 'n', ' ', '\r', '\n':
+		return keyNext, nil
+	case 'h', 'p', 127, '\b':
+		return keyPrev, nil
+	case 'j':
+		return keyNextNode, nil
+	case 'k':
+		return keyPrevNode, nil
+	case 'e', '\t':
+		return keyExpand, nil
+	case 'd':
+		return keyDiff, nil
+	case 'g':
+		return keyFirst, nil
+	case 'G':
+		return keyLast, nil
+	case 'q', 3, 4: // Ctrl+C and Ctrl+D, as signals are off
+		return keyQuit, nil
+	case 0x1b:
+		return readEscape(r)
+	}
+	return keyNone, nil
+}
+
+// readEscape decodes the rest of an escape sequence: ESC [ or ESC O, then
+// a letter or digits closed by ~
+func readEscape(r *bufio.Reader) (stepperKey, error) {
+	b, err := r.ReadByte()
+	if err != nil || (b != '[' && b != 'O') {
+		return keyNone, err
+	}
+	var digits []byte
+	for {
+		b, err = r.ReadByte()
+		if err != nil {
+			return keyNone, err
+		}
+		if b < '0' || b > '9' {
+			break
+		}
+		digits = append(digits, b)
+	}
+	switch b {
+	case 'A':
+		return keyPrevNode, nil
+	case 'B':
+		return keyNextNode, nil
+	case 'C':
+		return keyNext, nil
+	case 'D':
+		return keyPrev, nil
+	case 'H':
+		return keyFirst, nil
+	case 'F':
+		return keyLast, nil
+	case '~':
+		switch string(digits) {
+		case "1", "7":
+			return keyFirst, nil
+		case "4", "8":
+			return keyLast, nil
+		}
+	}
+	return keyNone, nil
+}
+
+// handle applies a key to the stepper
+func (s *traceStepper) handle(key stepperKey) {
+	current := s.frames[s.pos]
+	switch key {
+	case keyNext:
+		if s.pos < len(s.frames)-1 {
+			s.pos++
+		}
+	case keyPrev:
+		if s.pos > 0 {
+			s.pos--
+		}
+	case keyNextNode:
+		for i := s.pos + 1; i < len(s.frames); i++ {
+			if s.frames[i].node != current.node {
+				s.pos = i
+				break
+			}
+		}
+	case keyPrevNode:
+		target := current.node - 1
+		if current.step >= 0 {
+			target = current.node
+		}
+		for i, frame := range s.frames {
+			if frame.node == target && frame.step < 0 {
+				s.pos = i
+				break
+			}
+		}
+	case keyExpand:
+		s.expanded = !s.expanded
+	case keyDiff:
+		s.diff = !s.diff
+	case keyFirst:
+		s.pos = 0
+	case keyLast:
+		s.pos = len(s.frames) - 1
+	}
+}
+
+// render returns the screen of the current frame
+func (s *traceStepper) render() string {
+	var b strings.Builder
+	frame := s.frames[s.pos]
+	results := s.agent.Metadata.NodeResults
+	result := results[frame.node]
+
+	fmt.Fprintf(&b, "🎯 %s\n", s.agent.Goal)
+	fmt.Fprintf(&b, "Step %d/%d · node %d/%d · execution %s · $%.4f\n", s.pos+1, len(s.frames), frame.node+1, len(results), s.agent.Metadata.Status, s.agent.Metadata.TotalCost)
+	b.WriteString(stepperRule)
+	if frame.step < 0 {
+		s.renderNode(&b, result)
+	} else {
+		s.renderIteration(&b, result, frame.step)
+	}
+	b.WriteString(stepperRule)
+	b.WriteString(stepperHelp + "\n")
+	return b.String()
+}
+
+// renderNode writes the summary, input and output of a node
+func (s *traceStepper) renderNode(b *strings.Builder, result spec.NodeResult) {
+	fmt.Fprintf(b, "🧩 Node: %s (%s) · %dms · $%.4f · %d+%d tokens", result.NodeID, result.Status, result.ExecutionTimeMs, result.Cost, result.PromptTokens, result.CompletionTokens)
+	if result.Model != "" {
+		fmt.Fprintf(b, " · %s", result.Model)
+	}
+	b.WriteString("\n")
+	if annotations := formatAnnotations(result.Annotations); annotations != "" {
+		fmt.Fprintf(b, "   %s\n", annotations)
+	}
+	if result.Classification != "" {
+		fmt.Fprintf(b, "🏷️  Classification: %s\n", result.Classification)
+	}
+	if result.Error != "" {
+		fmt.Fprintf(b, "❌ Error: %s\n", result.Error)
+	}
+	b.WriteString("\n")
+
+	if result.Input != nil {
+		fmt.Fprintf(b, "📥 Input:\n%s\n\n", indent(s.cut(formatValue(result.Input), stepperTextLength), "   "))
+	}
+	if result.Output != nil {
+		fmt.Fprintf(b, "📤 Output:\n%s\n\n", indent(s.cut(formatValue(result.Output), stepperTextLength), "   "))
+	}
+
+	rejected := 0
+	for _, attempt := range result.OutputAttempts {
+		if len(attempt.Violations) > 0 {
+			rejected++
+		}
+	}
+	if rejected > 0 {
+		fmt.Fprintf(b, "⚠️  %d answer(s) rejected by the output contract\n", rejected)
+	}
+	if trace := result.ReActTrace; trace != nil {
+		fmt.Fprintf(b, "🔁 %d ReAct iterations (→ to step through them)\n", len(trace.ThinkingSteps))
+	}
+	if len(result.Logs) > 0 {
+		if !s.expanded {
+			fmt.Fprintf(b, "📜 %d log lines (e to show)\n", len(result.Logs))
+			return
+		}
+		b.WriteString("📜 Logs:\n")
+		if result.LogsDropped > 0 {
+			fmt.Fprintf(b, "   ... %d earlier lines dropped\n", result.LogsDropped)
+		}
+		for _, line := range result.Logs {
+			fmt.Fprintf(b, "   %s\n", line)
+		}
+	}
+}
+
+// renderIteration writes one ReAct iteration: its thought, or the change
+// from the previous one, and its tool calls
+func (s *traceStepper) renderIteration(b *strings.Builder, result spec.NodeResult, index int) {
+	steps := result.ReActTrace.ThinkingSteps
+	step := steps[index]
+	fmt.Fprintf(b, "🔁 Node: %s · iteration %d/%d · %dms · $%.4f · %d+%d tokens\n\n", result.NodeID, index+1, len(steps), step.DurationMs, step.Cost, step.PromptTokens, step.CompletionTokens)
+
+	if s.diff && index > 0 {
+		fmt.Fprintf(b, "💭 Thought, changed since iteration %d:\n", index)
+		for _, line := range diffLines(steps[index-1].Thought, step.Thought) {
+			fmt.Fprintf(b, "   %s\n", line)
+		}
+	} else {
+		fmt.Fprintf(b, "💭 Thought:\n%s\n", indent(s.cut(step.Thought, stepperTextLength), "   "))
+	}
+	b.WriteString("\n")
+
+	for _, call := range step.ToolCalls {
+		status := "✅"
+		if call.Error != "" {
+			status = "❌"
+		}
+		if !s.expanded {
+			fmt.Fprintf(b, "🔧 %s(%s) · %dms %s\n", call.ToolName, truncate(formatArguments(call.Arguments), 80), call.DurationMs, status)
+			continue
+		}
+
+		fmt.Fprintf(b, "🔧 Tool Call: %s · %dms\n", call.ToolName, call.DurationMs)
+		if len(call.Arguments) > 0 {
+			b.WriteString("   Arguments:\n")
+			for _, key := range sortedKeys(call.Arguments) {
+				fmt.Fprintf(b, "     • %s: %v\n", key, call.Arguments[key])
+			}
+		}
+		if call.Error != "" {
+			fmt.Fprintf(b, "   ❌ Error: %s\n", call.Error)
+		} else {
+			fmt.Fprintf(b, "   ✅ Result:\n%s\n", indent(formatValue(call.Result), "      "))
+		}
+		for _, injection := range call.Injections {
+			fmt.Fprintf(b, "   ⚠️  Instruction-like text: %s\n", injection)
+		}
+		b.WriteString("\n")
+	}
+	if len(step.ToolCalls) > 0 && !s.expanded {
+		b.WriteString("   (e to expand the tool calls)\n")
+	}
+}
+
+// cut shortens text to max characters unless the stepper is expanded
+func (s *traceStepper) cut(text string, max int) string {
+	if s.expanded || len([]rune(text)) <= max {
+		return text
+	}
+	return truncate(text, max) + " [e to expand]"
+}
+
+// formatValue renders a trace value: strings as they are, anything else as
+// indented JSON
+func formatValue(value interface{}) string {
+	if text, ok := value.(string); ok {
+		return text
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+// formatArguments renders tool arguments on one line, as key=value pairs in
+// key order
+func formatArguments(args map[string]interface{}) string {
+	pairs := make([]string, 0, len(args))
+	for _, key := range sortedKeys(args) {
+		value, _ := json.Marshal(args[key])
+		pairs = append(pairs, key+"="+string(value))
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// indent prefixes every line of text
+func indent(text, prefix string) string {
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
+
+// diffLines compares two texts line by line, returning the lines of after
+// prefixed with "  " when kept or "+ " when added, and the lines removed
+// from before prefixed with "- "
+func diffLines(before, after string) []string {
+	a, b := strings.Split(before, "\n"), strings.Split(after, "\n")
+	if len(a)*len(b) > maxDiffCells {
+		return prefixLines(b, "+ ")
+	}
+
+	// common[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, "  "+b[j])
+			i++
+			j++
+		case common[i+1][j] >= common[i][j+1]:
+			lines = append(lines, "- "+a[i])
+			i++
+		default:
+			lines = append(lines, "+ "+b[j])
+			j++
+		}
+	}
+	lines = append(lines, prefixLines(a[i:], "- ")...)
+	return append(lines, prefixLines(b[j:], "+ ")...)
+}
+
+// prefixLines returns lines with a prefix added to each
+func prefixLines(lines []string, prefix string) []string {
+	prefixed := make([]string, len(lines))
+	for i, line := range lines {
+		prefixed[i] = prefix + line
+	}
+	return prefixed
+}
@@ -0,0 +1,9 @@
+package cli
+
+import "golang.org/x/sys/unix"
+
+// Requests reading and setting terminal attributes
+const (
+	ioctlGetTermios = unix.TCGETS
+	ioctlSetTermios = unix.TCSETS
+)
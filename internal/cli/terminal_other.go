@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !windows
+
+package cli
+
+// rawInput is not supported on this platform
+func rawInput() (func(), error) {
+	return nil, errNotTerminal
+}
@@ -0,0 +1,37 @@
+//go:build windows
+
+package cli
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// rawInput switches the console to reading keys one at a time, without echo
+// or Ctrl+C handling, with arrow keys sent as escape sequences, and returns
+// a function restoring it
+func rawInput() (func(), error) {
+	in := windows.Handle(os.Stdin.Fd())
+	var inMode uint32
+	if err := windows.GetConsoleMode(in, &inMode); err != nil {
+		return nil, errNotTerminal
+	}
+	raw := inMode&^(windows.ENABLE_ECHO_INPUT|windows.ENABLE_LINE_INPUT|windows.ENABLE_PROCESSED_INPUT) | windows.ENABLE_VIRTUAL_TERMINAL_INPUT
+	if err := windows.SetConsoleMode(in, raw); err != nil {
+		return nil, err
+	}
+
+	// Let the screen be cleared with escape sequences too
+	out := windows.Handle(os.Stdout.Fd())
+	var outMode uint32
+	outSet := windows.GetConsoleMode(out, &outMode) == nil &&
+		windows.SetConsoleMode(out, outMode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING) == nil
+
+	return func() {
+		windows.SetConsoleMode(in, inMode)
+		if outSet {
+			windows.SetConsoleMode(out, outMode)
+		}
+	}, nil
+}
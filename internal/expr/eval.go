@@ -0,0 +1,603 @@
+package expr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type node interface {
+	eval(env *env) (interface{}, error)
+}
+
+// env holds the variables in scope and the budget of an evaluation
+type env struct {
+	vars   map[string]interface{}
+	budget *budget
+}
+
+// budget bounds the work of an evaluation, shared by its nested scopes
+type budget struct {
+	ctx   context.Context
+	steps int // left before the evaluation fails
+}
+
+// charge spends steps, failing once the budget is used up or the context
+// is done
+func (e *env) charge(steps int) error {
+	e.budget.steps -= steps
+	if e.budget.steps < 0 {
+		return fmt.Errorf("%w (more than %d steps)", ErrTooExpensive, MaxSteps)
+	}
+	return e.budget.ctx.Err()
+}
+
+// bind returns a scope in which name is also defined, sharing the budget
+func (e *env) bind(name string) *env {
+	vars := make(map[string]interface{}, len(e.vars)+1)
+	for key, value := range e.vars {
+		vars[key] = value
+	}
+	vars[name] = nil
+	return &env{vars: vars, budget: e.budget}
+}
+
+type (
+	literal struct{ value interface{} }
+	ident   struct{ name string }
+	member  struct {
+		target node
+		name   string
+	}
+	has struct {
+		target node
+		name   string
+	}
+	index struct{ target, key node }
+	unary struct {
+		op      string
+		operand node
+	}
+	binary struct {
+		op          string
+		left, right node
+	}
+	conditional struct{ cond, then, els node }
+	list        struct{ items []node }
+	mapLit      struct{ keys, values []node }
+	call        struct {
+		name   string
+		target node // nil for global functions
+		args   []node
+	}
+
+	// comprehension is a macro call: target.macro(name, body)
+	comprehension struct {
+		macro  string
+		target node
+		name   string
+		body   node
+	}
+)
+
+func (n *literal) eval(*env) (interface{}, error) {
+	return n.value, nil
+}
+
+func (n *ident) eval(env *env) (interface{}, error) {
+	value, ok := env.vars[n.name]
+	if !ok {
+		return nil, fmt.Errorf("undeclared reference to %s", n.name)
+	}
+	return normalize(value), nil
+}
+
+func (n *member) eval(env *env) (interface{}, error) {
+	target, err := n.target.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := target.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot select field %s from %s", n.name, typeName(target))
+	}
+	value, ok := m[n.name]
+	if !ok {
+		return nil, fmt.Errorf("no such key: %s", n.name)
+	}
+	return normalize(value), nil
+}
+
+func (n *has) eval(env *env) (interface{}, error) {
+	target, err := n.target.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := target.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("has() cannot test field %s of %s", n.name, typeName(target))
+	}
+	_, found := m[n.name]
+	return found, nil
+}
+
+func (n *index) eval(env *env) (interface{}, error) {
+	target, err := n.target.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	key, err := n.key.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := target.(type) {
+	case map[string]interface{}:
+		k, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("map keys are strings, got %s", typeName(key))
+		}
+		value, found := t[k]
+		if !found {
+			return nil, fmt.Errorf("no such key: %s", k)
+		}
+		return normalize(value), nil
+	case []interface{}:
+		i, ok := key.(float64)
+		if !ok || i != math.Trunc(i) {
+			return nil, fmt.Errorf("list indexes are integers, got %s", typeName(key))
+		}
+		// Compared as floats, since huge indexes overflow an int
+		if i < 0 || i >= float64(len(t)) {
+			return nil, fmt.Errorf("index %g out of range for a list of %d", i, len(t))
+		}
+		return normalize(t[int(i)]), nil
+	}
+	return nil, fmt.Errorf("cannot index %s", typeName(target))
+}
+
+func (n *unary) eval(env *env) (interface{}, error) {
+	operand, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch v := operand.(type) {
+	case bool:
+		if n.op == "!" {
+			return !v, nil
+		}
+	case float64:
+		if n.op == "-" {
+			return -v, nil
+		}
+	}
+	return nil, fmt.Errorf("operator %s does not apply to %s", n.op, typeName(operand))
+}
+
+func (n *binary) eval(env *env) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	// && and || only evaluate the right side when it decides the result
+	if n.op == "&&" || n.op == "||" {
+		l, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s needs bools, got %s", n.op, typeName(left))
+		}
+		if l == (n.op == "||") {
+			return l, nil
+		}
+		right, err := n.right.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s needs bools, got %s", n.op, typeName(right))
+		}
+		return r, nil
+	}
+
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return reflect.DeepEqual(left, right), nil
+	case "!=":
+		return !reflect.DeepEqual(left, right), nil
+	case "<", "<=", ">", ">=":
+		return compare(n.op, left, right)
+	case "in":
+		return contains(right, left)
+	case "+":
+		switch l := left.(type) {
+		case float64:
+			if r, ok := right.(float64); ok {
+				return l + r, nil
+			}
+		case string:
+			if r, ok := right.(string); ok {
+				if err := env.charge((len(l) + len(r)) / 64); err != nil {
+					return nil, err
+				}
+				return l + r, nil
+			}
+		case []interface{}:
+			if r, ok := right.([]interface{}); ok {
+				if err := env.charge(len(l) + len(r)); err != nil {
+					return nil, err
+				}
+				return append(append([]interface{}{}, l...), r...), nil
+			}
+		}
+	default:
+		l, lok := left.(float64)
+		r, rok := right.(float64)
+		if lok && rok {
+			switch n.op {
+			case "-":
+				return l - r, nil
+			case "*":
+				return l * r, nil
+			case "/", "%":
+				if r == 0 {
+					return nil, fmt.Errorf("division by zero")
+				}
+				if n.op == "/" {
+					return l / r, nil
+				}
+				return math.Mod(l, r), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("operator %s does not apply to %s and %s", n.op, typeName(left), typeName(right))
+}
+
+func compare(op string, left, right interface{}) (bool, error) {
+	var c int
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return false, fmt.Errorf("cannot compare number and %s", typeName(right))
+		}
+		switch {
+		case l < r:
+			c = -1
+		case l > r:
+			c = 1
+		}
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return false, fmt.Errorf("cannot compare string and %s", typeName(right))
+		}
+		c = strings.Compare(l, r)
+	default:
+		return false, fmt.Errorf("cannot compare %s and %s", typeName(left), typeName(right))
+	}
+
+	switch op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+func contains(container, value interface{}) (bool, error) {
+	switch c := container.(type) {
+	case []interface{}:
+		for _, item := range c {
+			if reflect.DeepEqual(normalize(item), value) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case map[string]interface{}:
+		key, ok := value.(string)
+		if !ok {
+			return false, nil
+		}
+		_, found := c[key]
+		return found, nil
+	}
+	return false, fmt.Errorf("operator in needs a list or map, got %s", typeName(container))
+}
+
+func (n *conditional) eval(env *env) (interface{}, error) {
+	cond, err := n.cond.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := cond.(bool)
+	if !ok {
+		return nil, fmt.Errorf("condition of ?: must be a bool, got %s", typeName(cond))
+	}
+	if b {
+		return n.then.eval(env)
+	}
+	return n.els.eval(env)
+}
+
+func (n *list) eval(env *env) (interface{}, error) {
+	if err := env.charge(len(n.items)); err != nil {
+		return nil, err
+	}
+	items := make([]interface{}, len(n.items))
+	for i, item := range n.items {
+		value, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = value
+	}
+	return items, nil
+}
+
+func (n *mapLit) eval(env *env) (interface{}, error) {
+	if err := env.charge(len(n.keys)); err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{}, len(n.keys))
+	for i := range n.keys {
+		key, err := n.keys[i].eval(env)
+		if err != nil {
+			return nil, err
+		}
+		k, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("map keys are strings, got %s", typeName(key))
+		}
+		value, err := n.values[i].eval(env)
+		if err != nil {
+			return nil, err
+		}
+		m[k] = value
+	}
+	return m, nil
+}
+
+// eval applies the macro to the elements of a list, or the keys of a map
+func (n *comprehension) eval(env *env) (interface{}, error) {
+	target, err := n.target.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	var items []interface{}
+	switch t := target.(type) {
+	case []interface{}:
+		items = t
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for key := range t {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			items = append(items, key)
+		}
+	default:
+		return nil, fmt.Errorf("%s() needs a list or map, got %s", n.macro, typeName(target))
+	}
+
+	scope := env.bind(n.name)
+	results := []interface{}{}
+	for _, item := range items {
+		if err := env.charge(1); err != nil {
+			return nil, err
+		}
+		item = normalize(item)
+		scope.vars[n.name] = item
+		value, err := n.body.eval(scope)
+		if err != nil {
+			return nil, err
+		}
+		if n.macro == "map" {
+			results = append(results, value)
+			continue
+		}
+
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s() needs a bool expression, got %s", n.macro, typeName(value))
+		}
+		switch {
+		case n.macro == "filter" && b:
+			results = append(results, item)
+		case n.macro == "exists" && b:
+			return true, nil
+		case n.macro == "all" && !b:
+			return false, nil
+		}
+	}
+
+	switch n.macro {
+	case "exists":
+		return false, nil
+	case "all":
+		return true, nil
+	}
+	return results, nil
+}
+
+func (n *call) eval(env *env) (interface{}, error) {
+	args := make([]interface{}, 0, len(n.args)+1)
+	if n.target != nil {
+		target, err := n.target.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, target)
+	}
+	for _, arg := range n.args {
+		value, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+	}
+
+	if n.name == "size" {
+		return size(args[0])
+	}
+	if n.target == nil {
+		return callFunction(n.name, args[0])
+	}
+	return callMethod(n.name, args)
+}
+
+func size(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return float64(utf8.RuneCountInString(v)), nil
+	case []interface{}:
+		return float64(len(v)), nil
+	case map[string]interface{}:
+		return float64(len(v)), nil
+	}
+	return nil, fmt.Errorf("size() does not apply to %s", typeName(value))
+}
+
+func callFunction(name string, arg interface{}) (interface{}, error) {
+	switch name {
+	case "int", "double":
+		var n float64
+		switch v := arg.(type) {
+		case float64:
+			n = v
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s(): %q is not a number", name, v)
+			}
+			n = parsed
+		default:
+			return nil, fmt.Errorf("%s() does not apply to %s", name, typeName(arg))
+		}
+		if name == "int" {
+			n = math.Trunc(n)
+		}
+		return n, nil
+
+	case "string":
+		switch v := arg.(type) {
+		case string:
+			return v, nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		}
+		return nil, fmt.Errorf("string() does not apply to %s", typeName(arg))
+
+	case "json":
+		s, ok := arg.(string)
+		if !ok {
+			return nil, fmt.Errorf("json() needs a string, got %s", typeName(arg))
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(s), &value); err != nil {
+			return nil, fmt.Errorf("json(): %v", err)
+		}
+		return value, nil
+	}
+	return nil, fmt.Errorf("unknown function %s", name)
+}
+
+func callMethod(name string, args []interface{}) (interface{}, error) {
+	s, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("%s() does not apply to %s", name, typeName(args[0]))
+	}
+
+	switch name {
+	case "lowerAscii":
+		return strings.ToLower(s), nil
+	case "upperAscii":
+		return strings.ToUpper(s), nil
+	case "trim":
+		return strings.TrimSpace(s), nil
+	}
+
+	arg, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("%s() needs a string argument, got %s", name, typeName(args[1]))
+	}
+	switch name {
+	case "contains":
+		return strings.Contains(s, arg), nil
+	case "startsWith":
+		return strings.HasPrefix(s, arg), nil
+	case "endsWith":
+		return strings.HasSuffix(s, arg), nil
+	case "matches":
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return nil, fmt.Errorf("matches(): %v", err)
+		}
+		return re.MatchString(s), nil
+	}
+	return nil, fmt.Errorf("unknown method %s", name)
+}
+
+// normalize converts Go values supplied as variables to the JSON-like
+// types the evaluator works on
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case map[string]string:
+		m := make(map[string]interface{}, len(v))
+		for key, s := range v {
+			m[key] = s
+		}
+		return m
+	case []string:
+		items := make([]interface{}, len(v))
+		for i, s := range v {
+			items[i] = s
+		}
+		return items
+	}
+	return value
+}
+
+// typeName names a value's type in error messages
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "map"
+	}
+	return fmt.Sprintf("%T", value)
+}
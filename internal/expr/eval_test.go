@@ -0,0 +1,55 @@
+package expr_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/not7/core/internal/expr"
+)
+
+// TestListIndexes checks that list indexes out of range, including ones too
+// large for an int, fail instead of panicking
+func TestListIndexes(t *testing.T) {
+	vars := map[string]interface{}{
+		"data": []interface{}{"a", "b", "c"},
+	}
+
+	tests := []struct {
+		source string
+		want   interface{}
+		err    string
+	}{
+		{source: `data[0]`, want: "a"},
+		{source: `data[2]`, want: "c"},
+		{source: `[1, 2, 3][1]`, want: float64(2)},
+		{source: `data[3]`, err: "out of range"},
+		{source: `data[-1]`, err: "out of range"},
+		{source: `data[1e19]`, err: "out of range"},
+		{source: `[1, 2, 3][1e300]`, err: "out of range"},
+		{source: `[1, 2, 3][-1e300]`, err: "out of range"},
+		{source: `data[1.5]`, err: "list indexes are integers"},
+		{source: `data["0"]`, err: "list indexes are integers"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			program, err := expr.Compile(tt.source)
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			got, err := program.Eval(vars)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Eval = %v, %v; want an error containing %q", got, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Eval: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Eval = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
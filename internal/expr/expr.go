@@ -0,0 +1,116 @@
+// Package expr evaluates route condition and transform node expressions.
+// The language is a side-effect free subset of CEL (Common Expression
+// Language) over JSON-like values: null, bool, number (float64), string,
+// list and map.
+//
+//	literals     1  2.5  "text"  'text'  true  false  null  [1, 2]  {"a": 1}
+//	operators    ?:  ||  &&  ==  !=  <  <=  >  >=  in  +  -  *  /  %  !
+//	access       a.b  a["b"]  list[0]  has(a.b)
+//	functions    size(x)  int(x)  double(x)  string(x)  json(s)
+//	methods      s.contains(t)  s.startsWith(t)  s.endsWith(t)  s.matches(re)
+//	             s.lowerAscii()  s.upperAscii()  s.trim()  x.size()
+//	macros       l.filter(x, p)  l.map(x, e)  l.exists(x, p)  l.all(x, p)
+//
+// json(s) is not part of CEL; it decodes a JSON document so conditions can
+// branch on structured model output. Macros run over the elements of a list
+// or the keys of a map. Expressions cannot call out or change their
+// variables; their length and nesting are bounded, and so is the work of
+// an evaluation (see MaxSteps).
+package expr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+const (
+	// MaxLength is the longest expression Compile accepts
+	MaxLength = 4096
+	// maxDepth bounds nesting so hostile input cannot exhaust the stack
+	maxDepth = 64
+	// MaxSteps bounds the work of one evaluation: each element a macro
+	// visits is a step, as is each element or 64 bytes of a list, map or
+	// string the expression builds
+	MaxSteps = 1_000_000
+)
+
+// ErrTooExpensive is returned by evaluations that run out of steps
+var ErrTooExpensive = errors.New("expression is too expensive to evaluate")
+
+// SyntaxError reports an expression that does not parse
+type SyntaxError struct {
+	Pos     int // byte offset in the expression
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at position %d: %s", e.Pos+1, e.Message)
+}
+
+// Program is a compiled expression
+type Program struct {
+	source string
+	root   node
+	idents map[string]bool
+}
+
+// Compile parses an expression
+func Compile(source string) (*Program, error) {
+	if len(source) > MaxLength {
+		return nil, &SyntaxError{Pos: MaxLength, Message: fmt.Sprintf("expression is longer than %d characters", MaxLength)}
+	}
+
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, idents: make(map[string]bool)}
+	root, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	return &Program{source: source, root: root, idents: p.idents}, nil
+}
+
+// String returns the expression's source
+func (p *Program) String() string {
+	return p.source
+}
+
+// Identifiers returns the variables the expression refers to, sorted
+func (p *Program) Identifiers() []string {
+	names := make([]string, 0, len(p.idents))
+	for name := range p.idents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Eval evaluates the expression with the given variables. Values should be
+// JSON-like; Go ints, string maps and string slices are converted.
+func (p *Program) Eval(vars map[string]interface{}) (interface{}, error) {
+	return p.EvalContext(context.Background(), vars)
+}
+
+// EvalContext evaluates the expression like Eval, stopping with ctx's error
+// once ctx is done
+func (p *Program) EvalContext(ctx context.Context, vars map[string]interface{}) (interface{}, error) {
+	return p.root.eval(&env{vars: vars, budget: &budget{ctx: ctx, steps: MaxSteps}})
+}
+
+// EvalBool evaluates an expression that must produce a bool
+func (p *Program) EvalBool(vars map[string]interface{}) (bool, error) {
+	value, err := p.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression must evaluate to a bool, got %s", typeName(value))
+	}
+	return b, nil
+}
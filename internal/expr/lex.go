@@ -0,0 +1,120 @@
+package expr
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenPunct
+)
+
+type token struct {
+	kind  tokenKind
+	text  string      // identifier or punctuation
+	value interface{} // decoded number or string
+	pos   int
+}
+
+// punctuation lists operators longest first so "<=" wins over "<"
+var punctuation = []string{
+	"||", "&&", "==", "!=", "<=", ">=",
+	"<", ">", "+", "-", "*", "/", "%", "!", "?", ":", ".", ",", "(", ")", "[", "]", "{", "}",
+}
+
+func lex(source string) ([]token, error) {
+	var tokens []token
+	for pos := 0; pos < len(source); {
+		c := source[pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			pos++
+
+		case c >= '0' && c <= '9':
+			end := pos
+			for end < len(source) && (isDigit(source[end]) || source[end] == '.' || source[end] == 'e' || source[end] == 'E' ||
+				((source[end] == '+' || source[end] == '-') && (source[end-1] == 'e' || source[end-1] == 'E'))) {
+				end++
+			}
+			n, err := strconv.ParseFloat(source[pos:end], 64)
+			if err != nil {
+				return nil, &SyntaxError{Pos: pos, Message: "invalid number " + source[pos:end]}
+			}
+			tokens = append(tokens, token{kind: tokenNumber, value: n, pos: pos})
+			pos = end
+
+		case c == '"' || c == '\'':
+			s, end, err := lexString(source, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, value: s, pos: pos})
+			pos = end
+
+		case c == '_' || unicode.IsLetter(rune(c)):
+			end := pos
+			for end < len(source) && (source[end] == '_' || isDigit(source[end]) || unicode.IsLetter(rune(source[end]))) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: source[pos:end], pos: pos})
+			pos = end
+
+		default:
+			matched := false
+			for _, p := range punctuation {
+				if strings.HasPrefix(source[pos:], p) {
+					tokens = append(tokens, token{kind: tokenPunct, text: p, pos: pos})
+					pos += len(p)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, &SyntaxError{Pos: pos, Message: "unexpected character " + strconv.QuoteRune(rune(c))}
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(source)}), nil
+}
+
+// lexString decodes the quoted string starting at pos and returns it with
+// the offset just past the closing quote
+func lexString(source string, pos int) (string, int, error) {
+	quote := source[pos]
+	var b strings.Builder
+	for i := pos + 1; i < len(source); i++ {
+		c := source[i]
+		switch {
+		case c == quote:
+			return b.String(), i + 1, nil
+		case c == '\\' && i+1 < len(source):
+			i++
+			switch source[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '\\', '"', '\'':
+				b.WriteByte(source[i])
+			default:
+				return "", 0, &SyntaxError{Pos: i - 1, Message: "unknown escape \\" + string(source[i])}
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, &SyntaxError{Pos: pos, Message: "unterminated string"}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
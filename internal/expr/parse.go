@@ -0,0 +1,366 @@
+package expr
+
+import "fmt"
+
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+	idents map[string]bool
+	bound  map[string]int // variables of the macros being parsed
+}
+
+// functions and methods name the calls Compile accepts, with their arity
+var (
+	functions = map[string]int{"size": 1, "int": 1, "double": 1, "string": 1, "json": 1}
+	methods   = map[string]int{
+		"contains": 1, "startsWith": 1, "endsWith": 1, "matches": 1,
+		"lowerAscii": 0, "upperAscii": 0, "trim": 0, "size": 0,
+	}
+	// macros take a variable and an expression evaluated for each element
+	macros = map[string]bool{"filter": true, "map": true, "exists": true, "all": true}
+)
+
+func (p *parser) parse() (node, error) {
+	n, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.unexpected(tok)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the punctuation or keyword text if it is next
+func (p *parser) accept(text string) bool {
+	tok := p.peek()
+	if (tok.kind == tokenPunct || tok.kind == tokenIdent) && tok.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		tok := p.peek()
+		if tok.kind == tokenEOF {
+			return &SyntaxError{Pos: tok.pos, Message: fmt.Sprintf("expected %q at end of expression", text)}
+		}
+		return &SyntaxError{Pos: tok.pos, Message: fmt.Sprintf("expected %q, found %s", text, describe(tok))}
+	}
+	return nil
+}
+
+func (p *parser) unexpected(tok token) error {
+	if tok.kind == tokenEOF {
+		return &SyntaxError{Pos: tok.pos, Message: "unexpected end of expression"}
+	}
+	return &SyntaxError{Pos: tok.pos, Message: "unexpected " + describe(tok)}
+}
+
+// expr parses a conditional, the lowest precedence level
+func (p *parser) expr() (node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, &SyntaxError{Pos: p.peek().pos, Message: "expression is nested too deeply"}
+	}
+
+	cond, err := p.binary(0)
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept("?") {
+		return cond, nil
+	}
+	then, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	els, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	return &conditional{cond: cond, then: then, els: els}, nil
+}
+
+// precedence lists binary operators from loosest to tightest binding
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">=", "in"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) binary(level int) (node, error) {
+	if level == len(precedence) {
+		return p.unary()
+	}
+
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.acceptAny(precedence[level])
+		if !ok {
+			return left, nil
+		}
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) acceptAny(ops []string) (string, bool) {
+	for _, op := range ops {
+		if p.accept(op) {
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) unary() (node, error) {
+	if op, ok := p.acceptAny([]string{"!", "-"}); ok {
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > maxDepth {
+			return nil, &SyntaxError{Pos: p.peek().pos, Message: "expression is nested too deeply"}
+		}
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unary{op: op, operand: operand}, nil
+	}
+	return p.postfix()
+}
+
+func (p *parser) postfix() (node, error) {
+	n, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.accept("."):
+			tok := p.next()
+			if tok.kind != tokenIdent {
+				return nil, &SyntaxError{Pos: tok.pos, Message: "expected a field or method name after '.'"}
+			}
+			if !p.accept("(") {
+				n = &member{target: n, name: tok.text}
+				continue
+			}
+			if macros[tok.text] {
+				if n, err = p.macro(tok, n); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			args, err := p.args(")")
+			if err != nil {
+				return nil, err
+			}
+			arity, known := methods[tok.text]
+			if !known {
+				return nil, &SyntaxError{Pos: tok.pos, Message: fmt.Sprintf("unknown method %s", tok.text)}
+			}
+			if len(args) != arity {
+				return nil, &SyntaxError{Pos: tok.pos, Message: fmt.Sprintf("%s takes %d argument(s), got %d", tok.text, arity, len(args))}
+			}
+			n = &call{name: tok.text, target: n, args: args}
+		case p.accept("["):
+			key, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			n = &index{target: n, key: key}
+		default:
+			return n, nil
+		}
+	}
+}
+
+func (p *parser) primary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber, tokenString:
+		return &literal{value: tok.value}, nil
+
+	case tokenIdent:
+		switch tok.text {
+		case "true":
+			return &literal{value: true}, nil
+		case "false":
+			return &literal{value: false}, nil
+		case "null":
+			return &literal{value: nil}, nil
+		case "in":
+			return nil, p.unexpected(tok)
+		}
+		if !p.accept("(") {
+			if p.bound[tok.text] == 0 {
+				p.idents[tok.text] = true
+			}
+			return &ident{name: tok.text}, nil
+		}
+		return p.function(tok)
+
+	case tokenPunct:
+		switch tok.text {
+		case "(":
+			n, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		case "[":
+			items, err := p.args("]")
+			if err != nil {
+				return nil, err
+			}
+			return &list{items: items}, nil
+		case "{":
+			return p.mapLiteral()
+		}
+	}
+	return nil, p.unexpected(tok)
+}
+
+// function parses a global call after its opening parenthesis
+func (p *parser) function(name token) (node, error) {
+	args, err := p.args(")")
+	if err != nil {
+		return nil, err
+	}
+
+	if name.text == "has" {
+		if len(args) == 1 {
+			if m, ok := args[0].(*member); ok {
+				return &has{target: m.target, name: m.name}, nil
+			}
+		}
+		return nil, &SyntaxError{Pos: name.pos, Message: "has() takes a single field selection, such as has(a.b)"}
+	}
+
+	arity, known := functions[name.text]
+	if !known {
+		return nil, &SyntaxError{Pos: name.pos, Message: fmt.Sprintf("unknown function %s", name.text)}
+	}
+	if len(args) != arity {
+		return nil, &SyntaxError{Pos: name.pos, Message: fmt.Sprintf("%s takes %d argument(s), got %d", name.text, arity, len(args))}
+	}
+	return &call{name: name.text, args: args}, nil
+}
+
+// macro parses the variable and expression of a macro call, such as
+// items.filter(x, x.price > 10), after its opening parenthesis
+func (p *parser) macro(name token, target node) (node, error) {
+	tok := p.next()
+	if tok.kind != tokenIdent || tok.text == "true" || tok.text == "false" || tok.text == "null" || tok.text == "in" {
+		return nil, &SyntaxError{Pos: tok.pos, Message: fmt.Sprintf("%s() takes a variable name first, such as %s(x, ...)", name.text, name.text)}
+	}
+	if err := p.expect(","); err != nil {
+		return nil, err
+	}
+
+	if p.bound == nil {
+		p.bound = make(map[string]int)
+	}
+	p.bound[tok.text]++
+	body, err := p.expr()
+	p.bound[tok.text]--
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return &comprehension{macro: name.text, target: target, name: tok.text, body: body}, nil
+}
+
+// mapLiteral parses the entries of a map after its opening brace
+func (p *parser) mapLiteral() (node, error) {
+	m := &mapLit{}
+	if p.accept("}") {
+		return m, nil
+	}
+	for {
+		key, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		m.keys = append(m.keys, key)
+		m.values = append(m.values, value)
+		if p.accept("}") {
+			return m, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// args parses a comma separated list up to and including the closing text
+func (p *parser) args(closing string) ([]node, error) {
+	var args []node
+	if p.accept(closing) {
+		return args, nil
+	}
+	for {
+		arg, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.accept(closing) {
+			return args, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func describe(tok token) string {
+	switch tok.kind {
+	case tokenNumber:
+		return "number"
+	case tokenString:
+		return "string"
+	default:
+		return fmt.Sprintf("%q", tok.text)
+	}
+}
@@ -0,0 +1,155 @@
+// Package jsonpath selects values from decoded JSON documents with a
+// subset of JSONPath:
+//
+//	$            the document
+//	.name        a member of an object
+//	['name']     a member whose name is not an identifier
+//	[2]  [-1]    an array element, negative indexes count from the end
+//	[*]  .*      every element of an array or member of an object
+//
+// Filters, slices and recursive descent are not supported.
+package jsonpath
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// step is one selector of a compiled path
+type step struct {
+	name     string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// Path is a compiled JSONPath
+type Path struct {
+	source string
+	steps  []step
+}
+
+// String returns the path as written
+func (p *Path) String() string {
+	return p.source
+}
+
+// Compile parses a path. The leading $ may be omitted.
+func Compile(source string) (*Path, error) {
+	p := &Path{source: source}
+	s := strings.TrimSpace(source)
+	s = strings.TrimPrefix(s, "$")
+
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '.':
+			i++
+			if i < len(s) && s[i] == '*' {
+				p.steps = append(p.steps, step{wildcard: true})
+				i++
+				continue
+			}
+			start := i
+			for i < len(s) && s[i] != '.' && s[i] != '[' {
+				i++
+			}
+			if i == start {
+				return nil, fmt.Errorf("invalid path %q: empty member name at position %d", source, start)
+			}
+			p.steps = append(p.steps, step{name: s[start:i]})
+
+		case '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: unclosed [", source)
+			}
+			inner := strings.TrimSpace(s[i+1 : i+end])
+			i += end + 1
+
+			switch {
+			case inner == "*":
+				p.steps = append(p.steps, step{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				p.steps = append(p.steps, step{name: inner[1 : len(inner)-1]})
+			default:
+				n, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid path %q: [%s] is not an index, a quoted name or *", source, inner)
+				}
+				p.steps = append(p.steps, step{index: n, isIndex: true})
+			}
+
+		default:
+			if i == 0 {
+				// "name.other" without $ or a leading dot
+				s = "." + s
+				continue
+			}
+			return nil, fmt.Errorf("invalid path %q: unexpected %q at position %d", source, s[i], i)
+		}
+	}
+	return p, nil
+}
+
+// Select returns the values the path matches in doc, in document order
+// (object members by name). A path without wildcards matches at most one
+// value; no match is not an error.
+func (p *Path) Select(doc interface{}) []interface{} {
+	values := []interface{}{doc}
+	for _, st := range p.steps {
+		var next []interface{}
+		for _, v := range values {
+			next = append(next, st.apply(v)...)
+		}
+		values = next
+	}
+	return values
+}
+
+// Lookup returns the single value a path without wildcards matches
+func (p *Path) Lookup(doc interface{}) (interface{}, bool) {
+	values := p.Select(doc)
+	if len(values) == 0 {
+		return nil, false
+	}
+	return values[0], true
+}
+
+func (st step) apply(v interface{}) []interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if st.wildcard {
+			keys := make([]string, 0, len(t))
+			for k := range t {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			values := make([]interface{}, len(keys))
+			for i, k := range keys {
+				values[i] = t[k]
+			}
+			return values
+		}
+		if !st.isIndex {
+			if value, ok := t[st.name]; ok {
+				return []interface{}{value}
+			}
+		}
+	case []interface{}:
+		if st.wildcard {
+			return t
+		}
+		if st.isIndex {
+			i := st.index
+			if i < 0 {
+				i += len(t)
+			}
+			if i >= 0 && i < len(t) {
+				return []interface{}{t[i]}
+			}
+		}
+	}
+	return nil
+}
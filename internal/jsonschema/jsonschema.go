@@ -0,0 +1,307 @@
+// Package jsonschema validates decoded JSON values against the commonly used
+// subset of JSON Schema: type, enum, const, properties, required,
+// additionalProperties, items, length and range bounds, and pattern.
+// Other keywords are ignored.
+package jsonschema
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Problem is one way a value fails a schema
+type Problem struct {
+	Path    string // location in the value, e.g. $.items[0].name
+	Message string
+}
+
+func (p Problem) String() string {
+	return p.Path + ": " + p.Message
+}
+
+// Error lists every problem found in a value
+type Error struct {
+	Problems []Problem
+}
+
+func (e *Error) Error() string {
+	messages := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		messages[i] = p.String()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Validate checks a value decoded by encoding/json against a schema and
+// returns an *Error listing every problem, or nil when the value is valid
+func Validate(schema map[string]interface{}, value interface{}) error {
+	var problems []Problem
+	validate(schema, value, "$", &problems)
+	if len(problems) > 0 {
+		return &Error{Problems: problems}
+	}
+	return nil
+}
+
+// Check reports problems in a schema itself that would make Validate
+// misbehave, such as an unknown type or an invalid pattern
+func Check(schema map[string]interface{}) error {
+	var problems []Problem
+	check(schema, "$", &problems)
+	if len(problems) > 0 {
+		return &Error{Problems: problems}
+	}
+	return nil
+}
+
+// Types returns the types a schema allows, from "type" as a string or list
+func Types(schema map[string]interface{}) []string {
+	switch t := schema["type"].(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		var types []string
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// Required returns the required property names of an object schema
+func Required(schema map[string]interface{}) []string {
+	list, _ := schema["required"].([]interface{})
+	var names []string
+	for _, item := range list {
+		if name, ok := item.(string); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+var knownTypes = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "integer": true, "string": true,
+}
+
+func check(schema map[string]interface{}, path string, problems *[]Problem) {
+	add := func(format string, args ...interface{}) {
+		*problems = append(*problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if t, ok := schema["type"]; ok {
+		types := Types(schema)
+		if len(types) == 0 {
+			add("type must be a string or a list of strings, got %v", t)
+		}
+		for _, name := range types {
+			if !knownTypes[name] {
+				add("unknown type %q", name)
+			}
+		}
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		if _, err := regexp.Compile(pattern); err != nil {
+			add("invalid pattern: %v", err)
+		}
+	}
+	if required, ok := schema["required"]; ok {
+		if _, isList := required.([]interface{}); !isList {
+			add("required must be a list of property names")
+		}
+	}
+
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		for _, name := range sortedKeys(properties) {
+			if sub, ok := properties[name].(map[string]interface{}); ok {
+				check(sub, path+"."+name, problems)
+			} else {
+				add("property %s must be a schema object", name)
+			}
+		}
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		check(items, path+"[]", problems)
+	}
+	if additional, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+		check(additional, path+".*", problems)
+	}
+}
+
+func validate(schema map[string]interface{}, value interface{}, path string, problems *[]Problem) {
+	add := func(format string, args ...interface{}) {
+		*problems = append(*problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if types := Types(schema); len(types) > 0 && !hasType(types, value) {
+		add("expected %s, got %s", strings.Join(types, " or "), typeOf(value))
+		return
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && !contains(enum, value) {
+		add("must be one of %s", formatValues(enum))
+	}
+	if want, ok := schema["const"]; ok && !equal(want, value) {
+		add("must be %s", formatValue(want))
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := len([]rune(v))
+		if n, ok := number(schema["minLength"]); ok && float64(length) < n {
+			add("must be at least %g characters", n)
+		}
+		if n, ok := number(schema["maxLength"]); ok && float64(length) > n {
+			add("must be at most %g characters", n)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(v) {
+				add("must match %s", pattern)
+			}
+		}
+
+	case float64:
+		if n, ok := number(schema["minimum"]); ok && v < n {
+			add("must be at least %g", n)
+		}
+		if n, ok := number(schema["maximum"]); ok && v > n {
+			add("must be at most %g", n)
+		}
+		if n, ok := number(schema["exclusiveMinimum"]); ok && v <= n {
+			add("must be greater than %g", n)
+		}
+		if n, ok := number(schema["exclusiveMaximum"]); ok && v >= n {
+			add("must be less than %g", n)
+		}
+
+	case []interface{}:
+		if n, ok := number(schema["minItems"]); ok && float64(len(v)) < n {
+			add("must have at least %g items", n)
+		}
+		if n, ok := number(schema["maxItems"]); ok && float64(len(v)) > n {
+			add("must have at most %g items", n)
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validate(items, item, fmt.Sprintf("%s[%d]", path, i), problems)
+			}
+		}
+
+	case map[string]interface{}:
+		for _, name := range Required(schema) {
+			if _, ok := v[name]; !ok {
+				*problems = append(*problems, Problem{Path: path + "." + name, Message: "is required"})
+			}
+		}
+
+		properties, _ := schema["properties"].(map[string]interface{})
+		for _, name := range sortedKeys(v) {
+			if sub, ok := properties[name].(map[string]interface{}); ok {
+				validate(sub, v[name], path+"."+name, problems)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					*problems = append(*problems, Problem{Path: path + "." + name, Message: "is not allowed"})
+				}
+			case map[string]interface{}:
+				validate(additional, v[name], path+"."+name, problems)
+			}
+		}
+	}
+}
+
+func hasType(types []string, value interface{}) bool {
+	for _, t := range types {
+		switch t {
+		case "integer":
+			if n, ok := value.(float64); ok && n == math.Trunc(n) {
+				return true
+			}
+		case "number":
+			if _, ok := value.(float64); ok {
+				return true
+			}
+		default:
+			if typeOf(value) == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// typeOf names the JSON type of a decoded value
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func number(v interface{}) (float64, bool) {
+	n, ok := v.(float64)
+	return n, ok
+}
+
+func contains(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if equal(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// equal compares decoded JSON scalars; composite values never match
+func equal(a, b interface{}) bool {
+	switch a.(type) {
+	case nil, bool, float64, string:
+		return a == b
+	}
+	return false
+}
+
+func formatValues(values []interface{}) string {
+	formatted := make([]string, len(values))
+	for i, v := range values {
+		formatted[i] = formatValue(v)
+	}
+	return strings.Join(formatted, ", ")
+}
+
+func formatValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprint(v)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
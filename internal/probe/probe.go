@@ -0,0 +1,151 @@
+// Package probe checks that the external providers a config sets up are
+// reachable and accept their keys, for not7 doctor and serve
+// --check-providers
+package probe
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/not7/core/config"
+	"github.com/not7/core/llm"
+	"github.com/not7/core/queue"
+	"github.com/not7/core/tools/arcade"
+	"github.com/not7/core/tools/builtin"
+)
+
+// Timeout bounds each provider check
+const Timeout = 10 * time.Second
+
+// Result is the outcome of one provider check
+type Result struct {
+	Name   string // e.g. "OpenAI"
+	Detail string // what was reached, when the check passed
+	Err    error
+	Hint   string // how to fix a failure
+}
+
+// check is a provider to check: a cheap request that needs a valid key
+type check struct {
+	name string
+	hint string
+	run  func(ctx context.Context) (string, error)
+}
+
+// Providers checks each provider cfg has keys for, at the same time, and
+// returns the results in a fixed order. LLM providers are checked by
+// listing their models, which costs nothing. Ollama has no key and a
+// default URL, so it is not checked.
+func Providers(ctx context.Context, cfg *config.Config) []Result {
+	checks := providerChecks(cfg)
+	results := make([]Result, len(checks))
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c check) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, Timeout)
+			defer cancel()
+
+			detail, err := c.run(ctx)
+			results[i] = Result{Name: c.name, Detail: detail, Err: err}
+			if err != nil {
+				results[i].Hint = c.hint
+			}
+		}(i, c)
+	}
+	wg.Wait()
+	return results
+}
+
+// Failed returns the results whose check failed
+func Failed(results []Result) []Result {
+	var failed []Result
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// providerChecks lists the checks of the providers cfg sets up
+func providerChecks(cfg *config.Config) []check {
+	var checks []check
+
+	if cfg.OpenAI.APIKey != "" {
+		checks = append(checks, llmCheck("OpenAI", config.ProviderOpenAI, cfg, "Check OPENAI_API_KEY and network access to api.openai.com"))
+	}
+	if cfg.Anthropic.APIKey != "" {
+		checks = append(checks, llmCheck("Anthropic", config.ProviderAnthropic, cfg, "Check ANTHROPIC_API_KEY and network access to api.anthropic.com"))
+	}
+	if cfg.Gemini.APIKey != "" {
+		checks = append(checks, llmCheck("Gemini", config.ProviderGemini, cfg, "Check GEMINI_API_KEY and GEMINI_BASE_URL"))
+	}
+
+	if cfg.Builtin.SerpAPIKey != "" {
+		provider := builtin.NewProvider(cfg.Builtin.SerpAPIKey)
+		checks = append(checks, check{
+			name: "SerpAPI",
+			hint: "Check SERP_API_KEY and network access to serpapi.com",
+			run:  reachable(provider.Ping),
+		})
+	}
+
+	if cfg.Arcade.APIKey != "" && cfg.Arcade.UserID != "" {
+		client := arcade.NewClient(cfg.Arcade.APIKey, cfg.Arcade.UserID)
+		checks = append(checks, check{
+			name: "Arcade",
+			hint: "Check ARCADE_API_KEY and network access to api.arcade.dev",
+			run:  reachable(client.Ping),
+		})
+	}
+
+	if cfg.Queue.Backend == queue.BackendRedis && cfg.Queue.URL != "" {
+		checks = append(checks, check{
+			name: "Queue",
+			hint: "Check QUEUE_URL and that Redis is running",
+			run: func(ctx context.Context) (string, error) {
+				q, err := queue.Open(cfg.Queue)
+				if err != nil {
+					return "", err
+				}
+				q.Close()
+				return queue.Describe(cfg.Queue), nil
+			},
+		})
+	}
+
+	return checks
+}
+
+// llmCheck lists the models of an LLM provider
+func llmCheck(name, provider string, cfg *config.Config, hint string) check {
+	return check{
+		name: name,
+		hint: hint,
+		run: func(ctx context.Context) (string, error) {
+			settings, err := cfg.LLMProvider(provider)
+			if err != nil {
+				return "", err
+			}
+			client, err := llm.NewProviderClient(provider, settings)
+			if err != nil {
+				return "", err
+			}
+			return reachable(client.Ping)(ctx)
+		},
+	}
+}
+
+// reachable adapts a Ping method to a check
+func reachable(ping func(context.Context) error) func(context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		if err := ping(ctx); err != nil {
+			return "", err
+		}
+		return "reachable", nil
+	}
+}
@@ -0,0 +1,170 @@
+// Package redact replaces personal data in text sent to LLM providers with
+// placeholders such as [EMAIL_1], and puts the values back into the answers.
+// Values are found by built-in entity patterns and by user-defined regular
+// expressions. A Redactor remembers every value it replaced, so a value gets
+// the same placeholder wherever it appears during one execution.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Built-in entities, in the order they are matched
+var entities = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"email", regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+	{"credit_card", regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)},
+	{"ssn", regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)},
+	{"ip_address", regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`)},
+	{"phone", regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{2,4}\)[ .-]?)?\d{2,4}[ .-]\d{3,4}[ .-]?\d{3,4}\b`)},
+}
+
+// Entities lists the names of the built-in entities
+func Entities() []string {
+	names := make([]string, len(entities))
+	for i, entity := range entities {
+		names[i] = entity.name
+	}
+	return names
+}
+
+// namePattern is what a custom pattern name may look like, since it becomes
+// part of the placeholder
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Rules are the patterns one redaction config matches
+type Rules struct {
+	patterns []rule
+}
+
+type rule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// Compile builds the rules matching the named built-in entities and the
+// custom patterns, keyed by name. Built-in entities are matched first, then
+// the custom patterns in name order.
+func Compile(entityNames []string, patterns map[string]string) (*Rules, error) {
+	rules := &Rules{}
+	wanted := make(map[string]bool, len(entityNames))
+	for _, name := range entityNames {
+		wanted[name] = true
+	}
+	for _, entity := range entities {
+		if wanted[entity.name] {
+			rules.patterns = append(rules.patterns, rule{entity.name, entity.pattern})
+			delete(wanted, entity.name)
+		}
+	}
+	for name := range wanted {
+		return nil, fmt.Errorf("unknown entity %q (supported: %s)", name, strings.Join(Entities(), ", "))
+	}
+
+	names := make([]string, 0, len(patterns))
+	for name := range patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !namePattern.MatchString(name) {
+			return nil, fmt.Errorf("pattern name %q must be lowercase letters, digits and underscores", name)
+		}
+		pattern, err := regexp.Compile(patterns[name])
+		if err != nil {
+			return nil, fmt.Errorf("pattern %s: %w", name, err)
+		}
+		rules.patterns = append(rules.patterns, rule{name, pattern})
+	}
+	return rules, nil
+}
+
+// Redactor replaces matched values with placeholders and restores them. It
+// is safe for concurrent use.
+type Redactor struct {
+	mu           sync.Mutex
+	placeholders map[string]string // value -> placeholder
+	values       map[string]string // placeholder -> value
+	counts       map[string]int    // placeholders issued per rule
+}
+
+// NewRedactor creates a Redactor with no values remembered
+func NewRedactor() *Redactor {
+	return &Redactor{
+		placeholders: make(map[string]string),
+		values:       make(map[string]string),
+		counts:       make(map[string]int),
+	}
+}
+
+// Redact replaces every value rules match in text with its placeholder and
+// returns the result with the number of values replaced
+func (r *Redactor) Redact(rules *Rules, text string) (string, int) {
+	if rules == nil || text == "" {
+		return text, 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	replaced := 0
+	for _, rule := range rules.patterns {
+		text = outsidePlaceholders(text, func(segment string) string {
+			return rule.pattern.ReplaceAllStringFunc(segment, func(value string) string {
+				replaced++
+				return r.placeholder(rule.name, value)
+			})
+		})
+	}
+	return text, replaced
+}
+
+// outsidePlaceholders applies replace to the parts of text between
+// placeholders, so a later rule cannot match inside an earlier placeholder
+func outsidePlaceholders(text string, replace func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range placeholderPattern.FindAllStringIndex(text, -1) {
+		b.WriteString(replace(text[last:loc[0]]))
+		b.WriteString(text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(replace(text[last:]))
+	return b.String()
+}
+
+// placeholder returns the placeholder of value, issuing one on first sight
+func (r *Redactor) placeholder(name, value string) string {
+	if placeholder, ok := r.placeholders[value]; ok {
+		return placeholder
+	}
+	r.counts[name]++
+	placeholder := fmt.Sprintf("[%s_%d]", strings.ToUpper(name), r.counts[name])
+	r.placeholders[value] = placeholder
+	r.values[placeholder] = value
+	return placeholder
+}
+
+// placeholderPattern matches anything shaped like a placeholder
+var placeholderPattern = regexp.MustCompile(`\[[A-Z][A-Z0-9_]*_\d+\]`)
+
+// Restore puts the values back in place of the placeholders in text.
+// Placeholders this Redactor did not issue are left alone.
+func (r *Redactor) Restore(text string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.values) == 0 {
+		return text
+	}
+	return placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		if value, ok := r.values[placeholder]; ok {
+			return value
+		}
+		return placeholder
+	})
+}
@@ -0,0 +1,132 @@
+package script
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/parser"
+)
+
+// insertion is text instrument adds to a script at a byte offset
+type insertion struct {
+	offset int
+	text   string
+	end    bool // Closes what an earlier insertion opened
+	order  int  // Position in the walk, outer nodes first
+}
+
+// instrument adds a call to stepFunc at the start of every function body
+// and loop iteration of src
+func instrument(src string) (string, error) {
+	program, err := parser.ParseFile(nil, "script", src, 0)
+	if err != nil {
+		return "", err
+	}
+
+	var insertions []insertion
+	add := func(idx int, text string, end bool) {
+		insertions = append(insertions, insertion{offset: idx - 1, text: text, end: end, order: len(insertions)})
+	}
+	// wrap makes a loop body a block that starts with a step. A body that is
+	// not a block keeps its semicolon inside, for bodies followed by else or
+	// while.
+	wrap := func(body ast.Statement) {
+		if block, ok := body.(*ast.BlockStatement); ok {
+			add(int(block.LeftBrace)+1, stepFunc+"();", false)
+			return
+		}
+		end := int(body.Idx1()) - 1
+		for end < len(src) && strings.ContainsRune(" \t\r\n", rune(src[end])) {
+			end++
+		}
+		if end < len(src) && src[end] == ';' {
+			end++
+		} else {
+			end = int(body.Idx1()) - 1
+		}
+		add(int(body.Idx0()), "{"+stepFunc+"();", false)
+		add(end+1, "}", true)
+	}
+
+	walk(reflect.ValueOf(program), func(node ast.Node) {
+		switch n := node.(type) {
+		case *ast.FunctionLiteral:
+			add(int(n.Body.LeftBrace)+1, stepFunc+"();", false)
+		case *ast.ArrowFunctionLiteral:
+			switch body := n.Body.(type) {
+			case *ast.BlockStatement:
+				add(int(body.LeftBrace)+1, stepFunc+"();", false)
+			case *ast.ExpressionBody:
+				add(int(body.Idx0()), "("+stepFunc+"(), ", false)
+				add(int(body.Idx1()), ")", true)
+			}
+		case *ast.ForStatement:
+			wrap(n.Body)
+		case *ast.ForInStatement:
+			wrap(n.Body)
+		case *ast.ForOfStatement:
+			wrap(n.Body)
+		case *ast.WhileStatement:
+			wrap(n.Body)
+		case *ast.DoWhileStatement:
+			wrap(n.Body)
+		}
+	})
+
+	// At the same offset, what closes goes before what opens, inner nodes
+	// close first and outer nodes open first
+	sort.SliceStable(insertions, func(i, j int) bool {
+		a, b := insertions[i], insertions[j]
+		switch {
+		case a.offset != b.offset:
+			return a.offset < b.offset
+		case a.end != b.end:
+			return a.end
+		case a.end:
+			return a.order > b.order
+		default:
+			return a.order < b.order
+		}
+	})
+
+	var out strings.Builder
+	last := 0
+	for _, ins := range insertions {
+		out.WriteString(src[last:ins.offset])
+		out.WriteString(ins.text)
+		last = ins.offset
+	}
+	out.WriteString(src[last:])
+	return out.String(), nil
+}
+
+// walk calls visit on every node of the syntax tree under v, parents first.
+// Declaration lists repeat nodes of the tree, so they are skipped.
+func walk(v reflect.Value, visit func(ast.Node)) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if !v.IsNil() {
+			walk(v.Elem(), visit)
+		}
+	case reflect.Pointer:
+		if v.IsNil() {
+			return
+		}
+		if node, ok := v.Interface().(ast.Node); ok {
+			visit(node)
+		}
+		walk(v.Elem(), visit)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Type().Field(i); field.IsExported() && field.Name != "DeclarationList" && field.Name != "File" {
+				walk(v.Field(i), visit)
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			walk(v.Index(i), visit)
+		}
+	}
+}
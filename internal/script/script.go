@@ -0,0 +1,221 @@
+// Package script runs the JavaScript of transform nodes and script route
+// conditions with goja. A script is the body of a function whose
+// parameters are the variables the caller names, and whose return value is
+// the script's result:
+//
+//	return data.items.filter(i => i.price < 10).map(i => i.name)
+//
+// Scripts run in a fresh runtime without eval, Function or any way to call
+// out. They are bounded like expressions: by steps (see MaxSteps), by time
+// (MaxDuration), by call depth, and by the memory they allocate
+// (MaxMemory). Variables are passed in as JSON, so a script cannot change
+// the caller's values.
+package script
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/metrics"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+
+	"github.com/not7/core/internal/expr"
+)
+
+const (
+	// MaxLength is the longest script Compile accepts
+	MaxLength = 16384
+	// MaxSteps bounds the work of one run, as for expressions: each loop
+	// iteration and each function call is a step
+	MaxSteps = expr.MaxSteps
+	// MaxDuration bounds the time of one run
+	MaxDuration = 2 * time.Second
+	// MaxMemory bounds the heap a run may grow by
+	MaxMemory = 64 << 20
+	// maxCallDepth bounds recursion so a script cannot exhaust the stack
+	maxCallDepth = 1024
+	// memoryCheckSteps is how often, in steps, the heap is measured, on top
+	// of the measurements every memoryCheckInterval
+	memoryCheckSteps    = 1024
+	memoryCheckInterval = 10 * time.Millisecond
+)
+
+// Errors returned by runs that exceed a limit
+var (
+	ErrTooExpensive = fmt.Errorf("script is too expensive to run (more than %d steps)", MaxSteps)
+	ErrTooSlow      = fmt.Errorf("script took longer than %s", MaxDuration)
+	ErrTooLarge     = fmt.Errorf("script used more than %d MB of memory", MaxMemory>>20)
+	ErrTooDeep      = fmt.Errorf("script nested more than %d calls", maxCallDepth)
+)
+
+// stepFunc is the function instrumented scripts call on every step. Scripts
+// cannot refer to it themselves.
+const stepFunc = "__step"
+
+// prelude removes the ways a script could compile code at run time, which
+// would not be instrumented
+const prelude = `
+delete globalThis.eval;
+delete globalThis.Function;
+for (const f of [function () {}, function* () {}, async function () {}]) {
+	Object.defineProperty(Object.getPrototypeOf(f), "constructor", { value: undefined });
+}
+`
+
+// Program is a compiled script
+type Program struct {
+	source  string
+	params  []string
+	program *goja.Program
+}
+
+// Compile parses a script whose variables are params
+func Compile(source string, params []string) (*Program, error) {
+	if len(source) > MaxLength {
+		return nil, fmt.Errorf("script is longer than %d characters", MaxLength)
+	}
+	if strings.Contains(source, stepFunc) {
+		return nil, fmt.Errorf("%s is reserved", stepFunc)
+	}
+
+	// The script starts on the wrapper's line, so errors report its lines
+	wrapped := "(function (" + strings.Join(params, ", ") + ") {" + source + "\n})"
+	instrumented, err := instrument(wrapped)
+	if err != nil {
+		return nil, err
+	}
+	program, err := goja.Compile("script", instrumented, false)
+	if err != nil {
+		return nil, err
+	}
+	return &Program{source: source, params: params, program: program}, nil
+}
+
+// String returns the script's source
+func (p *Program) String() string {
+	return p.source
+}
+
+// Run runs the script with vars, which should be JSON values, and returns
+// its result as JSON-like Go values: nil, bool, int64 or float64, string,
+// []interface{} and map[string]interface{}. Run stops with ctx's error once
+// ctx is done.
+func (p *Program) Run(ctx context.Context, vars map[string]interface{}) (interface{}, error) {
+	ctx, cancel := context.WithTimeoutCause(ctx, MaxDuration, ErrTooSlow)
+	defer cancel()
+
+	vm := goja.New()
+	vm.SetMaxCallStackSize(maxCallDepth)
+	if _, err := vm.RunString(prelude); err != nil {
+		return nil, fmt.Errorf("failed to prepare script runtime: %w", err)
+	}
+
+	baseline := heapBytes()
+	overMemory := func() bool { return heapBytes() > baseline+MaxMemory }
+	steps := 0
+	step := func() {
+		steps++
+		switch {
+		case steps > MaxSteps:
+			vm.Interrupt(ErrTooExpensive)
+		case steps%memoryCheckSteps == 0 && overMemory():
+			vm.Interrupt(ErrTooLarge)
+		}
+	}
+	if err := vm.GlobalObject().DefineDataProperty(stepFunc, vm.ToValue(step), goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_FALSE); err != nil {
+		return nil, fmt.Errorf("failed to prepare script runtime: %w", err)
+	}
+
+	// Time and memory are also watched while the script runs built-ins or
+	// loops that are not instrumented
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(memoryCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				vm.Interrupt(context.Cause(ctx))
+				return
+			case <-ticker.C:
+				if overMemory() {
+					vm.Interrupt(ErrTooLarge)
+					return
+				}
+			}
+		}
+	}()
+
+	args, err := p.args(vm, vars)
+	if err != nil {
+		return nil, err
+	}
+	value, err := vm.RunProgram(p.program)
+	if err == nil {
+		fn, _ := goja.AssertFunction(value)
+		value, err = fn(goja.Undefined(), args...)
+	}
+	var interrupted *goja.InterruptedError
+	if errors.As(err, &interrupted) {
+		if cause, ok := interrupted.Value().(error); ok {
+			return nil, cause
+		}
+	}
+	var overflow *goja.StackOverflowError
+	if errors.As(err, &overflow) {
+		return nil, ErrTooDeep
+	}
+	if err != nil {
+		return nil, err
+	}
+	return value.Export(), nil
+}
+
+// RunBool runs a script that must return a boolean
+func (p *Program) RunBool(ctx context.Context, vars map[string]interface{}) (bool, error) {
+	value, err := p.Run(ctx, vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("script must return a boolean, got %T", value)
+	}
+	return b, nil
+}
+
+// args converts vars to the script's arguments, through JSON so the script
+// gets plain objects and arrays of its own
+func (p *Program) args(vm *goja.Runtime, vars map[string]interface{}) ([]goja.Value, error) {
+	parse, ok := goja.AssertFunction(vm.Get("JSON").ToObject(vm).Get("parse"))
+	if !ok {
+		return nil, fmt.Errorf("failed to prepare script runtime: no JSON.parse")
+	}
+
+	args := make([]goja.Value, len(p.params))
+	for i, name := range p.params {
+		data, err := json.Marshal(vars[name])
+		if err != nil {
+			return nil, fmt.Errorf("variable %s is not JSON: %w", name, err)
+		}
+		if args[i], err = parse(goja.Undefined(), vm.ToValue(string(data))); err != nil {
+			return nil, fmt.Errorf("variable %s is not JSON: %w", name, err)
+		}
+	}
+	return args, nil
+}
+
+// heapBytes returns the size of the heap's objects. The heap is shared with
+// the rest of the process, so memory limits are approximate.
+func heapBytes() uint64 {
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	metrics.Read(sample)
+	return sample[0].Value.Uint64()
+}
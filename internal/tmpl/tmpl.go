@@ -0,0 +1,284 @@
+// Package tmpl renders the {{ }} templates in node prompts, ReAct goals,
+// retrieve queries and tool arguments. Templates use Go text/template
+// syntax with the node input and a small function set, so simple data
+// shaping does not need an extra LLM node:
+//
+//	{{input}}                          the node input
+//	{{input | truncate 200}}           at most 200 characters
+//	{{input | slice 10 20}}            characters 10 to 19
+//	{{input | upper}}  {{input | lower}}  {{input | trim}}
+//	{{input | json}}                   JSON-encoded, for embedding in JSON
+//	{{input | regex_extract "\d+"}}    first match, or its first group
+//	{{now}}  {{now "2006-01-02"}}      current UTC time (RFC 3339 by default)
+//
+// Functions take the piped value last. Only actions starting with input or
+// now are templates; any other "{{" text, such as a Mustache placeholder or
+// example JSON, is kept as written.
+package tmpl
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateFuncs are the functions an action must start with to be a template
+var templateFuncs = []string{"input", "now"}
+
+// Check reports whether text is a valid template
+func Check(text string) error {
+	source, ok := templateSource(text)
+	if !ok {
+		return nil
+	}
+	_, err := parse(source, "")
+	return err
+}
+
+// Render executes text with input as the node input
+func Render(text, input string) (string, error) {
+	source, ok := templateSource(text)
+	if !ok {
+		return text, nil
+	}
+
+	t, err := parse(source, input)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, nil); err != nil {
+		return "", fmt.Errorf("template: %s", cleanError(err))
+	}
+	return b.String(), nil
+}
+
+// RenderValue renders the templates in the strings of a decoded JSON
+// value, such as tool arguments. Maps and slices are copied, not changed.
+// Errors name the path of the failing string, e.g. "content.items[0]: ...".
+func RenderValue(value interface{}, input string) (interface{}, error) {
+	return renderValue(value, input, "")
+}
+
+func renderValue(value interface{}, input, path string) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		rendered, err := Render(v, input)
+		if err != nil {
+			return nil, pathError(path, err)
+		}
+		return rendered, nil
+	case map[string]interface{}:
+		rendered := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			r, err := renderValue(item, input, joinKey(path, key))
+			if err != nil {
+				return nil, err
+			}
+			rendered[key] = r
+		}
+		return rendered, nil
+	case []interface{}:
+		rendered := make([]interface{}, len(v))
+		for i, item := range v {
+			r, err := renderValue(item, input, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			rendered[i] = r
+		}
+		return rendered, nil
+	default:
+		return value, nil
+	}
+}
+
+// CheckValue reports the first invalid template in a decoded JSON value
+func CheckValue(value interface{}) error {
+	return checkValue(value, "")
+}
+
+func checkValue(value interface{}, path string) error {
+	switch v := value.(type) {
+	case string:
+		if err := Check(v); err != nil {
+			return pathError(path, err)
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			if err := checkValue(item, joinKey(path, key)); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			if err := checkValue(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// joinKey appends a map key to a value path
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// pathError prefixes err with the path of the value it came from
+func pathError(path string, err error) error {
+	if path == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %w", path, err)
+}
+
+// templateSource returns text as template source in which only the input
+// and now actions are actions, and every other "{{" is literal text. It
+// reports false when text has no such action.
+func templateSource(text string) (string, bool) {
+	var b strings.Builder
+	found := false
+	for {
+		start := strings.Index(text, "{{")
+		if start < 0 {
+			b.WriteString(text)
+			return b.String(), found
+		}
+		b.WriteString(text[:start])
+
+		end := strings.Index(text[start+2:], "}}")
+		if end >= 0 && isTemplateAction(text[start+2:start+2+end]) {
+			b.WriteString(text[start : start+2+end+2])
+			text = text[start+2+end+2:]
+			found = true
+			continue
+		}
+		b.WriteString(`{{"{{"}}`)
+		text = text[start+2:]
+	}
+}
+
+// isTemplateAction reports whether the text between "{{" and "}}" starts
+// with one of the template functions, after an optional trim marker
+func isTemplateAction(action string) bool {
+	action = strings.TrimLeft(strings.TrimPrefix(action, "-"), " \t\r\n")
+	for _, name := range templateFuncs {
+		rest, ok := strings.CutPrefix(action, name)
+		if ok && (rest == "" || strings.ContainsRune(" \t\r\n|)-", rune(rest[0]))) {
+			return true
+		}
+	}
+	return false
+}
+
+// parse parses text with the function set bound to input
+func parse(text, input string) (*template.Template, error) {
+	t, err := template.New("template").Option("missingkey=error").Funcs(funcs(input)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %s", cleanError(err))
+	}
+	return t, nil
+}
+
+// funcs returns the template functions for a node input
+func funcs(input string) template.FuncMap {
+	return template.FuncMap{
+		"input":         func() string { return input },
+		"truncate":      truncate,
+		"slice":         slice,
+		"upper":         strings.ToUpper,
+		"lower":         strings.ToLower,
+		"trim":          strings.TrimSpace,
+		"json":          toJSON,
+		"regex_extract": regexExtract,
+		"now":           now,
+	}
+}
+
+// truncate returns the first n characters of s
+func truncate(n int, s string) string {
+	runes := []rune(s)
+	if n < 0 || n >= len(runes) {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// slice returns the characters of s from start up to end, clamped to s.
+// Negative positions count from the end.
+func slice(start, end int, s string) string {
+	runes := []rune(s)
+	clamp := func(i int) int {
+		if i < 0 {
+			i += len(runes)
+		}
+		if i < 0 {
+			return 0
+		}
+		if i > len(runes) {
+			return len(runes)
+		}
+		return i
+	}
+	start, end = clamp(start), clamp(end)
+	if start >= end {
+		return ""
+	}
+	return string(runes[start:end])
+}
+
+// toJSON encodes v as JSON
+func toJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// regexExtract returns the first match of pattern in s, or the match's
+// first group when the pattern has one. No match gives "".
+func regexExtract(pattern, s string) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", err
+	}
+	match := re.FindStringSubmatch(s)
+	switch {
+	case match == nil:
+		return "", nil
+	case len(match) > 1:
+		return match[1], nil
+	default:
+		return match[0], nil
+	}
+}
+
+// now returns the current UTC time, formatted with the optional Go layout
+func now(layout ...string) string {
+	format := time.RFC3339
+	if len(layout) > 0 {
+		format = layout[0]
+	}
+	return time.Now().UTC().Format(format)
+}
+
+// cleanError drops text/template's location prefix, which names the
+// internal template rather than the spec field, keeping the line number
+func cleanError(err error) string {
+	msg := strings.TrimPrefix(err.Error(), "template: template:")
+	if _, rest, ok := strings.Cut(msg, `executing "template" at `); ok {
+		return rest
+	}
+	if line, rest, ok := strings.Cut(msg, ": "); ok {
+		return "line " + line + ": " + rest
+	}
+	return msg
+}
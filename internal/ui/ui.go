@@ -0,0 +1,195 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"unicode"
+)
+
+// Level selects how much CLI output is printed. Output falls into three
+// tiers: essential results (Printf), progress and decoration (Infof, hidden
+// by --quiet) and extra detail (Verbosef, shown only with --verbose).
+// With --no-emoji every tier is stripped of emoji for CI logs and limited
+// terminals.
+type Level int
+
+const (
+	LevelQuiet Level = iota - 1
+	LevelNormal
+	LevelVerbose
+)
+
+var (
+	mu    sync.RWMutex
+	level = LevelNormal
+	plain bool
+	out   io.Writer = os.Stdout
+)
+
+// SetLevel sets the output level
+func SetLevel(l Level) {
+	mu.Lock()
+	defer mu.Unlock()
+	level = l
+}
+
+// SetPlain enables or disables emoji stripping
+func SetPlain(enabled bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	plain = enabled
+}
+
+// IsQuiet reports whether only essential output is printed
+func IsQuiet() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return level == LevelQuiet
+}
+
+// IsVerbose reports whether extra detail is printed
+func IsVerbose() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return level == LevelVerbose
+}
+
+// Printf prints essential output (results, IDs, tables). Always shown.
+func Printf(format string, a ...interface{}) {
+	write(fmt.Sprintf(format, a...))
+}
+
+// Println prints essential output. Always shown.
+func Println(a ...interface{}) {
+	write(fmt.Sprintln(a...))
+}
+
+// Infof prints progress and decoration. Hidden with --quiet.
+func Infof(format string, a ...interface{}) {
+	if !IsQuiet() {
+		write(fmt.Sprintf(format, a...))
+	}
+}
+
+// Infoln prints progress and decoration. Hidden with --quiet.
+func Infoln(a ...interface{}) {
+	if !IsQuiet() {
+		write(fmt.Sprintln(a...))
+	}
+}
+
+// Verbosef prints extra detail. Shown only with --verbose.
+func Verbosef(format string, a ...interface{}) {
+	if IsVerbose() {
+		write(fmt.Sprintf(format, a...))
+	}
+}
+
+// TableWriter aligns tab-separated columns of essential output. Emoji are
+// stripped before alignment so plain tables stay aligned.
+type TableWriter struct {
+	tw *tabwriter.Writer
+}
+
+// NewTableWriter creates a table writer with the CLI's column layout
+func NewTableWriter() *TableWriter {
+	mu.RLock()
+	w := out
+	mu.RUnlock()
+
+	return &TableWriter{tw: tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)}
+}
+
+// Write buffers a row fragment
+func (t *TableWriter) Write(p []byte) (int, error) {
+	mu.RLock()
+	stripEmoji := plain
+	mu.RUnlock()
+
+	if stripEmoji {
+		if _, err := io.WriteString(t.tw, StripEmoji(string(p))); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	return t.tw.Write(p)
+}
+
+// Flush aligns and prints the buffered rows
+func (t *TableWriter) Flush() error {
+	return t.tw.Flush()
+}
+
+func write(s string) {
+	mu.RLock()
+	w, stripEmoji := out, plain
+	mu.RUnlock()
+
+	if stripEmoji {
+		s = StripEmoji(s)
+	}
+	io.WriteString(w, s)
+}
+
+// statusMarkers keep the meaning of status emoji in plain output
+var statusMarkers = map[rune]string{
+	'✅': "[OK]",
+	'✓': "[OK]",
+	'❌': "[FAIL]",
+	'⚠': "[WARN]",
+}
+
+// StripEmoji removes emoji (and the spacing that follows them) from s.
+// Status emoji are replaced by text markers such as [OK] and [FAIL].
+func StripEmoji(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if !isEmoji(r) {
+			sb.WriteRune(r)
+			continue
+		}
+
+		// Drop modifiers of the emoji and the padding before the text it decorated
+		padded := false
+		for i+1 < len(runes) && (isEmojiModifier(runes[i+1]) || runes[i+1] == ' ') {
+			padded = padded || runes[i+1] == ' '
+			i++
+		}
+
+		if marker, ok := statusMarkers[r]; ok {
+			sb.WriteString(marker)
+			if padded {
+				sb.WriteByte(' ')
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // Pictographs, emoticons, transport, symbols
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // Miscellaneous symbols and dingbats (✅ ❌ ⚠ ✓)
+		return true
+	case r >= 0x2300 && r <= 0x23FF: // Miscellaneous technical (⏱ ⏳ ⏸)
+		return true
+	case r >= 0x2B00 && r <= 0x2BFF: // Miscellaneous symbols and arrows (⭐)
+		return true
+	}
+	return false
+}
+
+func isEmojiModifier(r rune) bool {
+	return r == 0xFE0F || r == 0x200D || unicode.Is(unicode.Variation_Selector, r) ||
+		(r >= 0x1F3FB && r <= 0x1F3FF)
+}
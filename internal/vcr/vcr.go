@@ -0,0 +1,323 @@
+// Package vcr records the HTTP interactions of a process to a fixture file
+// (a cassette) and replays them later, so LLM and tool flows can run in CI
+// without API keys or network access.
+//
+// A Recorder is an http.RoundTripper. Install makes it the transport the
+// OpenAI client and the tool providers send through (see Transport); other
+// HTTP traffic, such as trace exports and error reports, is left alone.
+// Requests are matched by method, URL and body; headers are neither recorded
+// nor compared, and credentials in the query string are redacted, so
+// cassettes can be committed.
+package vcr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrNoInteraction is returned in replay mode for a request the cassette
+// does not hold, or whose recordings were all used
+var ErrNoInteraction = errors.New("no recorded interaction matches the request")
+
+// Mode selects whether a Recorder calls out or answers from its cassette
+type Mode string
+
+const (
+	// ModeAuto replays when the cassette exists and records otherwise
+	ModeAuto Mode = "auto"
+	// ModeRecord calls out and writes every interaction, replacing the cassette
+	ModeRecord Mode = "record"
+	// ModeReplay answers from the cassette and never calls out
+	ModeReplay Mode = "replay"
+)
+
+// ParseMode parses "auto", "record" or "replay"; empty means auto
+func ParseMode(s string) (Mode, error) {
+	switch Mode(strings.ToLower(strings.TrimSpace(s))) {
+	case "", ModeAuto:
+		return ModeAuto, nil
+	case ModeRecord:
+		return ModeRecord, nil
+	case ModeReplay:
+		return ModeReplay, nil
+	default:
+		return "", fmt.Errorf("invalid vcr mode %q: expected auto, record or replay", s)
+	}
+}
+
+// redactedParams are query parameters whose values are never written
+var redactedParams = []string{"api_key", "key", "token", "access_token"}
+
+// Cassette is the fixture file: the recorded interactions in call order
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one request and the response it received
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is the part of a request that is recorded and matched
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// Response is a recorded response
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Recorder records or replays HTTP interactions against one cassette
+type Recorder struct {
+	path string
+	mode Mode // ModeRecord or ModeReplay once resolved
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// New creates a recorder for the cassette at path. In ModeAuto it replays
+// when the file exists. next performs real requests when recording; nil
+// means http.DefaultTransport.
+func New(path string, mode Mode, next http.RoundTripper) (*Recorder, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	r := &Recorder{path: path, mode: mode, next: next}
+
+	if mode == ModeAuto {
+		r.mode = ModeRecord
+		if _, err := os.Stat(path); err == nil {
+			r.mode = ModeReplay
+		}
+	}
+	if r.mode == ModeRecord {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// Mode returns whether the recorder is recording or replaying
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Unused returns how many recorded interactions a replay has not used
+func (r *Recorder) Unused() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, used := range r.used {
+		if !used {
+			n++
+		}
+	}
+	return n
+}
+
+var (
+	activeMu sync.RWMutex
+	active   *Recorder
+)
+
+// Install makes r the transport of the LLM and tool clients, including those
+// created before the call, and returns a function that restores the previous
+// one
+func Install(r *Recorder) (restore func()) {
+	activeMu.Lock()
+	previous := active
+	active = r
+	activeMu.Unlock()
+
+	return func() {
+		activeMu.Lock()
+		active = previous
+		activeMu.Unlock()
+	}
+}
+
+// Transport returns the transport for clients whose calls fixtures should
+// capture: it sends through the installed recorder, if any, and through
+// http.DefaultTransport otherwise
+func Transport() http.RoundTripper {
+	return transport{}
+}
+
+// transport resolves the installed recorder on every request
+type transport struct{}
+
+func (transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	activeMu.RLock()
+	r := active
+	activeMu.RUnlock()
+
+	if r == nil {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	return r.RoundTrip(req)
+}
+
+// RoundTrip answers req from the cassette when replaying, and performs and
+// records it when recording
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	recorded := Request{Method: req.Method, URL: redactURL(req.URL), Body: string(body)}
+
+	if r.mode == ModeReplay {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		for i, interaction := range r.cassette.Interactions {
+			if !r.used[i] && matches(interaction.Request, recorded) {
+				r.used[i] = true
+				return interaction.Response.toHTTP(req), nil
+			}
+		}
+		return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, recorded.Method, recorded.URL)
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	header := resp.Header.Clone()
+	header.Del("Set-Cookie")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request:  recorded,
+		Response: Response{Status: resp.StatusCode, Header: header, Body: string(respBody)},
+	})
+	// Saved after every call so a killed process keeps what it recorded
+	if err := r.save(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// save writes the cassette atomically
+func (r *Recorder) save() error {
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+
+	tempFile := r.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	if err := os.Rename(tempFile, r.path); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to commit cassette: %w", err)
+	}
+	return nil
+}
+
+// readBody returns the request body and leaves req readable again
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// redactURL returns u with credential query parameters replaced
+func redactURL(u *url.URL) string {
+	redacted := *u
+	query := redacted.Query()
+	changed := false
+	for _, name := range redactedParams {
+		if query.Has(name) {
+			query.Set(name, "REDACTED")
+			changed = true
+		}
+	}
+	if changed {
+		redacted.RawQuery = query.Encode()
+	}
+	return redacted.String()
+}
+
+// matches reports whether a recorded request answers req. JSON bodies are
+// compared without insignificant whitespace.
+func matches(recorded, req Request) bool {
+	if recorded.Method != req.Method || recorded.URL != req.URL {
+		return false
+	}
+	return compactJSON(recorded.Body) == compactJSON(req.Body)
+}
+
+func compactJSON(body string) string {
+	var b bytes.Buffer
+	if err := json.Compact(&b, []byte(body)); err != nil {
+		return body
+	}
+	return b.String()
+}
+
+// toHTTP builds the response to req
+func (r Response) toHTTP(req *http.Request) *http.Response {
+	header := r.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
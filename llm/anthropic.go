@@ -0,0 +1,218 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/not7/core/config"
+	"github.com/not7/core/internal/vcr"
+	"github.com/not7/core/spec"
+	"github.com/not7/core/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// anthropicVersion is the version of the Messages API the client speaks
+const anthropicVersion = "2023-06-01"
+
+// anthropicMaxTokens limits the answer of calls without max_tokens, which
+// the Messages API requires
+const anthropicMaxTokens = 4096
+
+// AnthropicClient handles communication with Anthropic's Messages API
+type AnthropicClient struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewAnthropicClient creates a client for Claude models
+func NewAnthropicClient(cfg config.LLMProviderConfig) (*AnthropicClient, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("Anthropic API key not configured (set ANTHROPIC_API_KEY)")
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.anthropic.com/v1"
+	}
+
+	return &AnthropicClient{
+		apiKey:  cfg.APIKey,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{
+			Timeout:   120 * time.Second,
+			Transport: vcr.Transport(),
+		},
+	}, nil
+}
+
+// MessagesRequest represents an Anthropic Messages API request
+type MessagesRequest struct {
+	Model       string    `json:"model"`
+	System      string    `json:"system,omitempty"`
+	Messages    []Message `json:"messages"`
+	Temperature float64   `json:"temperature,omitempty"`
+	MaxTokens   int       `json:"max_tokens"`
+}
+
+// MessagesResponse represents an Anthropic Messages API response
+type MessagesResponse struct {
+	ID         string         `json:"id"`
+	Model      string         `json:"model"`
+	Content    []ContentBlock `json:"content"`
+	StopReason string         `json:"stop_reason"`
+	Usage      MessagesUsage  `json:"usage"`
+}
+
+// ContentBlock is one part of a Messages API answer
+type ContentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+}
+
+// MessagesUsage represents the token usage of a Messages API call
+type MessagesUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// newMessagesRequest builds the request of an LLM call: the prompt as the
+// system prompt and the input as the user message. The API needs a user
+// message, so without input the prompt is sent as one instead.
+func newMessagesRequest(config *spec.LLMConfig, prompt, input string) MessagesRequest {
+	req := MessagesRequest{
+		Model:       config.Model,
+		System:      prompt,
+		Messages:    []Message{{Role: "user", Content: input}},
+		Temperature: config.Temperature,
+		MaxTokens:   config.MaxTokens,
+	}
+	if input == "" {
+		req.System = ""
+		req.Messages[0].Content = prompt
+	}
+	if req.MaxTokens <= 0 {
+		req.MaxTokens = anthropicMaxTokens
+	}
+	// Claude accepts temperatures up to 1, where OpenAI's go up to 2
+	req.Temperature = min(req.Temperature, 1)
+	return req
+}
+
+// Execute runs an LLM completion. The call is traced as a gen_ai chat span
+// carrying the token usage and cost.
+func (c *AnthropicClient) Execute(ctx context.Context, config *spec.LLMConfig, prompt string, input string) (result *Completion, err error) {
+	ctx, span := tracer.Start(ctx, "chat "+config.Model, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("gen_ai.system", "anthropic"),
+		attribute.String("gen_ai.operation.name", "chat"),
+		attribute.String("gen_ai.request.model", config.Model),
+		attribute.Float64("gen_ai.request.temperature", config.Temperature),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	reqBody, err := json.Marshal(newMessagesRequest(config, prompt, input))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/messages", bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	c.authorize(httpReq)
+	if requestID := tracing.RequestIDFromContext(ctx); requestID != "" {
+		httpReq.Header.Set(tracing.RequestIDHeader, requestID)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &ProviderError{Provider: "anthropic", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var message MessagesResponse
+	if err := json.Unmarshal(body, &message); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	var text strings.Builder
+	for _, block := range message.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 && message.StopReason != "end_turn" {
+		return nil, fmt.Errorf("no text returned (stop reason: %s)", message.StopReason)
+	}
+
+	usage := Usage{
+		PromptTokens:     message.Usage.InputTokens,
+		CompletionTokens: message.Usage.OutputTokens,
+		TotalTokens:      message.Usage.InputTokens + message.Usage.OutputTokens,
+	}
+	result = &Completion{
+		Content: text.String(),
+		Model:   message.Model,
+		Usage:   usage,
+		Cost:    calculateCost(config.Model, usage), // approximate
+	}
+
+	span.SetAttributes(
+		attribute.String("gen_ai.response.model", message.Model),
+		attribute.Int("gen_ai.usage.input_tokens", usage.PromptTokens),
+		attribute.Int("gen_ai.usage.output_tokens", usage.CompletionTokens),
+		tracing.CostKey.Float64(result.Cost),
+	)
+
+	return result, nil
+}
+
+// Ping verifies the API key and connectivity by listing available models
+func (c *AnthropicClient) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/models", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	c.authorize(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach anthropic: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("anthropic rejected the API key (status 401)")
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// authorize sets the API key and version headers the API expects
+func (c *AnthropicClient) authorize(req *http.Request) {
+	req.Header.Set("x-api-key", c.apiKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+}
@@ -0,0 +1,343 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/not7/core/spec"
+	"github.com/not7/core/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BatchDiscount is the share of the regular price the Batch API charges
+const BatchDiscount = 0.5
+
+const (
+	// batchEndpoint is the API path batched requests are made against
+	batchEndpoint = "/v1/chat/completions"
+
+	// batchWindow is how long calls are collected before a batch is sent
+	batchWindow = 5 * time.Second
+
+	// batchPollInterval is how often a sent batch's status is checked
+	batchPollInterval = 30 * time.Second
+)
+
+// Batcher sends LLM calls through the OpenAI Batch API, which costs half as
+// much as regular calls but answers within 24 hours rather than seconds.
+// Calls made within a few seconds of each other, from any execution, share
+// one batch. A call blocks until its batch has finished.
+type Batcher struct {
+	client *OpenAIClient
+	window time.Duration
+	poll   time.Duration
+
+	mu      sync.Mutex
+	pending []*batchCall // calls waiting for the window to close
+	nextID  atomic.Int64
+}
+
+// batchCall is one LLM call waiting in a batch
+type batchCall struct {
+	id     string
+	config *spec.LLMConfig
+	req    CompletionRequest
+	done   chan batchAnswer // buffered, so an abandoned call never blocks
+}
+
+// batchAnswer is the outcome of a batched call
+type batchAnswer struct {
+	completion *Completion
+	err        error
+}
+
+// NewBatcher creates a Batcher sending batches with c's credentials
+func (c *OpenAIClient) NewBatcher() *Batcher {
+	return &Batcher{client: c, window: batchWindow, poll: batchPollInterval}
+}
+
+// Execute runs an LLM completion as part of the next batch. It returns
+// once the batch has finished or ctx is done; a call abandoned this way
+// still runs, and is still billed, with its batch.
+func (b *Batcher) Execute(ctx context.Context, config *spec.LLMConfig, prompt string, input string) (result *Completion, err error) {
+	ctx, span := tracer.Start(ctx, "chat "+config.Model, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("gen_ai.system", b.client.provider),
+		attribute.String("gen_ai.operation.name", "chat"),
+		attribute.String("gen_ai.request.model", config.Model),
+		attribute.Bool("not7.llm.batch", true),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	call := &batchCall{
+		id:     "call-" + strconv.FormatInt(b.nextID.Add(1), 10),
+		config: config,
+		req:    newCompletionRequest(config, prompt, input),
+		done:   make(chan batchAnswer, 1),
+	}
+
+	b.mu.Lock()
+	if len(b.pending) == 0 {
+		time.AfterFunc(b.window, b.flush)
+	}
+	b.pending = append(b.pending, call)
+	b.mu.Unlock()
+
+	select {
+	case answer := <-call.done:
+		if answer.err != nil {
+			return nil, answer.err
+		}
+		span.SetAttributes(
+			attribute.String("gen_ai.response.model", answer.completion.Model),
+			attribute.Int("gen_ai.usage.input_tokens", answer.completion.Usage.PromptTokens),
+			attribute.Int("gen_ai.usage.output_tokens", answer.completion.Usage.CompletionTokens),
+			tracing.CostKey.Float64(answer.completion.Cost),
+		)
+		return answer.completion, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// flush sends the calls collected so far as one batch and delivers its
+// answers when it finishes
+func (b *Batcher) flush() {
+	b.mu.Lock()
+	calls := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+	if len(calls) == 0 {
+		return
+	}
+
+	answers, err := b.run(context.Background(), calls)
+	for _, call := range calls {
+		answer, ok := answers[call.id]
+		switch {
+		case ok:
+		case err != nil:
+			answer = batchAnswer{err: err}
+		default:
+			answer = batchAnswer{err: fmt.Errorf("batch finished without answering the call")}
+		}
+		call.done <- answer
+	}
+}
+
+// batchStatus is the part of a batch object Batcher reads
+type batchStatus struct {
+	ID           string `json:"id"`
+	Status       string `json:"status"`
+	OutputFileID string `json:"output_file_id"`
+	ErrorFileID  string `json:"error_file_id"`
+}
+
+// batchLine is one line of a batch's output or error file
+type batchLine struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int             `json:"status_code"`
+		Body       json.RawMessage `json:"body"`
+	} `json:"response"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// run uploads calls as a batch, waits for it to finish and returns the
+// answers by call ID
+func (b *Batcher) run(ctx context.Context, calls []*batchCall) (map[string]batchAnswer, error) {
+	var input bytes.Buffer
+	encoder := json.NewEncoder(&input)
+	for _, call := range calls {
+		line := map[string]interface{}{
+			"custom_id": call.id,
+			"method":    "POST",
+			"url":       batchEndpoint,
+			"body":      call.req,
+		}
+		if err := encoder.Encode(line); err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	fileID, err := b.upload(ctx, input.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	var batch batchStatus
+	create, _ := json.Marshal(map[string]string{
+		"input_file_id":     fileID,
+		"endpoint":          batchEndpoint,
+		"completion_window": "24h",
+	})
+	if err := b.do(ctx, "POST", "/batches", "application/json", bytes.NewReader(create), &batch); err != nil {
+		return nil, fmt.Errorf("failed to create batch: %w", err)
+	}
+
+	for !batchFinished(batch.Status) {
+		time.Sleep(b.poll)
+		if err := b.do(ctx, "GET", "/batches/"+batch.ID, "", nil, &batch); err != nil {
+			// A failed poll is retried at the next interval
+			continue
+		}
+	}
+	if batch.Status != "completed" {
+		return nil, fmt.Errorf("batch %s %s", batch.ID, batch.Status)
+	}
+
+	byID := make(map[string]*batchCall, len(calls))
+	for _, call := range calls {
+		byID[call.id] = call
+	}
+	answers := make(map[string]batchAnswer, len(calls))
+	for _, fileID := range []string{batch.OutputFileID, batch.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		if err := b.readResults(ctx, fileID, byID, answers); err != nil {
+			return answers, fmt.Errorf("batch %s: %w", batch.ID, err)
+		}
+	}
+	return answers, nil
+}
+
+// batchFinished reports whether a batch status is final
+func batchFinished(status string) bool {
+	switch status {
+	case "completed", "failed", "expired", "cancelled":
+		return true
+	}
+	return false
+}
+
+// upload stores a batch input file and returns its ID
+func (b *Batcher) upload(ctx context.Context, data []byte) (string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("purpose", "batch")
+	part, err := form.CreateFormFile("file", "batch.jsonl")
+	if err != nil {
+		return "", fmt.Errorf("failed to build upload: %w", err)
+	}
+	part.Write(data)
+	form.Close()
+
+	var file struct {
+		ID string `json:"id"`
+	}
+	if err := b.do(ctx, "POST", "/files", form.FormDataContentType(), &body, &file); err != nil {
+		return "", fmt.Errorf("failed to upload batch: %w", err)
+	}
+	return file.ID, nil
+}
+
+// readResults reads a batch output or error file into answers
+func (b *Batcher) readResults(ctx context.Context, fileID string, calls map[string]*batchCall, answers map[string]batchAnswer) error {
+	req, err := b.request(ctx, "GET", "/files/"+fileID+"/content", "", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := b.client.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download results: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return &ProviderError{Provider: b.client.provider, StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for scanner.Scan() {
+		var line batchLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			continue
+		}
+		call, ok := calls[line.CustomID]
+		if !ok {
+			continue
+		}
+		answers[call.id] = batchResult(b.client.provider, call, line)
+	}
+	return scanner.Err()
+}
+
+// batchResult turns one line of a batch's results into the call's answer
+func batchResult(provider string, call *batchCall, line batchLine) batchAnswer {
+	if line.Error != nil {
+		return batchAnswer{err: fmt.Errorf("batched call failed: %s: %s", line.Error.Code, line.Error.Message)}
+	}
+	if line.Response == nil {
+		return batchAnswer{err: fmt.Errorf("batched call has no response")}
+	}
+	if line.Response.StatusCode != http.StatusOK {
+		return batchAnswer{err: &ProviderError{Provider: provider, StatusCode: line.Response.StatusCode, Body: string(line.Response.Body)}}
+	}
+
+	var completion CompletionResponse
+	if err := json.Unmarshal(line.Response.Body, &completion); err != nil {
+		return batchAnswer{err: fmt.Errorf("failed to parse response: %w", err)}
+	}
+	if len(completion.Choices) == 0 {
+		return batchAnswer{err: fmt.Errorf("no completion choices returned")}
+	}
+	return batchAnswer{completion: &Completion{
+		Content: completion.Choices[0].Message.Content,
+		Model:   completion.Model,
+		Usage:   completion.Usage,
+		Cost:    calculateCost(call.config.Model, completion.Usage) * BatchDiscount,
+	}}
+}
+
+// do sends an API request and decodes its JSON response into out
+func (b *Batcher) do(ctx context.Context, method, path, contentType string, body io.Reader, out interface{}) error {
+	req, err := b.request(ctx, method, path, contentType, body)
+	if err != nil {
+		return err
+	}
+	resp, err := b.client.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &ProviderError{Provider: b.client.provider, StatusCode: resp.StatusCode, Body: string(data)}
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// request builds an authenticated API request
+func (b *Batcher) request(ctx context.Context, method, path, contentType string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, b.client.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", b.client.apiKey))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req, nil
+}
@@ -0,0 +1,134 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+
+	"github.com/not7/core/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Embedder turns texts into embedding vectors, one per text
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// modelEmbedder embeds texts with one OpenAI embedding model
+type modelEmbedder struct {
+	client *OpenAIClient
+	model  string
+}
+
+// NewEmbedder returns an Embedder using model through c
+func (c *OpenAIClient) NewEmbedder(model string) Embedder {
+	return &modelEmbedder{client: c, model: model}
+}
+
+func (e *modelEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	vectors, _, err := e.client.Embed(ctx, e.model, texts)
+	return vectors, err
+}
+
+// EmbeddingRequest represents an OpenAI embeddings request
+type EmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// EmbeddingResponse represents an OpenAI embeddings response
+type EmbeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+	Model string `json:"model"`
+	Usage Usage  `json:"usage"`
+}
+
+// Embed returns one embedding vector per text, in order. The call is
+// traced as a gen_ai embeddings span.
+func (c *OpenAIClient) Embed(ctx context.Context, model string, texts []string) (vectors [][]float64, usage Usage, err error) {
+	ctx, span := tracer.Start(ctx, "embeddings "+model, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("gen_ai.system", "openai"),
+		attribute.String("gen_ai.operation.name", "embeddings"),
+		attribute.String("gen_ai.request.model", model),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	reqBody, err := json.Marshal(EmbeddingRequest{Model: model, Input: texts})
+	if err != nil {
+		return nil, Usage{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/embeddings", bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, Usage{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+	if requestID := tracing.RequestIDFromContext(ctx); requestID != "" {
+		httpReq.Header.Set(tracing.RequestIDHeader, requestID)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, Usage{}, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, Usage{}, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, Usage{}, &ProviderError{Provider: "openai", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var embeddings EmbeddingResponse
+	if err := json.Unmarshal(body, &embeddings); err != nil {
+		return nil, Usage{}, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	vectors = make([][]float64, len(texts))
+	for _, d := range embeddings.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, Usage{}, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	for i, v := range vectors {
+		if len(v) == 0 {
+			return nil, Usage{}, fmt.Errorf("no embedding returned for input %d", i)
+		}
+	}
+
+	span.SetAttributes(attribute.Int("gen_ai.usage.input_tokens", embeddings.Usage.PromptTokens))
+	return vectors, embeddings.Usage, nil
+}
+
+// CosineSimilarity returns the cosine similarity of a and b (-1 to 1), or 0
+// when they cannot be compared, e.g. embeddings from different models
+func CosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,14 @@
+package llm
+
+import "fmt"
+
+// ProviderError is returned when an LLM provider rejects a request
+type ProviderError struct {
+	Provider   string // e.g. "openai"
+	StatusCode int
+	Body       string // raw response body
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Body)
+}
@@ -0,0 +1,72 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+)
+
+// ModerationModel is the OpenAI model that classifies text for moderation
+const ModerationModel = "omni-moderation-latest"
+
+// Moderation is the verdict of the moderation endpoint on a text
+type Moderation struct {
+	Flagged    bool
+	Categories []string // Categories the text was flagged for, sorted
+}
+
+// Moderate classifies text with the OpenAI moderation endpoint. Moderation
+// calls are free.
+func (c *OpenAIClient) Moderate(ctx context.Context, text string) (*Moderation, error) {
+	reqBody, err := json.Marshal(map[string]string{"model": ModerationModel, "input": text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/moderations", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &ProviderError{Provider: c.provider, StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var result struct {
+		Results []struct {
+			Flagged    bool            `json:"flagged"`
+			Categories map[string]bool `json:"categories"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(result.Results) == 0 {
+		return nil, fmt.Errorf("no moderation results returned")
+	}
+
+	moderation := &Moderation{Flagged: result.Results[0].Flagged}
+	for category, flagged := range result.Results[0].Categories {
+		if flagged {
+			moderation.Categories = append(moderation.Categories, category)
+		}
+	}
+	sort.Strings(moderation.Categories)
+	return moderation, nil
+}
@@ -2,6 +2,7 @@ package llm
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,29 +11,81 @@ import (
 	"time"
 
 	"github.com/not7/core/config"
+	"github.com/not7/core/internal/vcr"
 	"github.com/not7/core/spec"
+	"github.com/not7/core/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// OpenAIClient handles communication with OpenAI API
+var tracer = otel.Tracer("github.com/not7/core/llm")
+
+// OpenAIClient handles communication with OpenAI API, or with a provider
+// that serves the same chat completions API
 type OpenAIClient struct {
+	provider   string // e.g. "openai" or "ollama"
 	apiKey     string
+	baseURL    string
 	httpClient *http.Client
 }
 
 // NewOpenAIClient creates a new OpenAI client
-func NewOpenAIClient() (*OpenAIClient, error) {
-	cfg := config.Get()
-	
-	if cfg.OpenAI.APIKey == "" {
+func NewOpenAIClient(cfg config.OpenAIConfig) (*OpenAIClient, error) {
+	if cfg.APIKey == "" {
 		return nil, fmt.Errorf("OpenAI API key not configured in not7.conf")
 	}
 
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+
+	return newClient(config.ProviderOpenAI, cfg.APIKey, baseURL), nil
+}
+
+// NewProviderClient creates a client for the named LLM provider. Ollama and
+// Gemini are reached through their OpenAI-compatible endpoints, Anthropic
+// through its Messages API.
+func NewProviderClient(name string, cfg config.LLMProviderConfig) (LLMProvider, error) {
+	switch name {
+	case config.ProviderOpenAI:
+		return NewOpenAIClient(config.OpenAIConfig{APIKey: cfg.APIKey, BaseURL: cfg.BaseURL})
+	case config.ProviderAnthropic:
+		return NewAnthropicClient(cfg)
+	case config.ProviderOllama:
+		if cfg.BaseURL == "" {
+			return nil, fmt.Errorf("Ollama base URL not configured (set OLLAMA_BASE_URL)")
+		}
+		// Ollama needs no key but the OpenAI API insists on the header
+		return newClient(name, "ollama", strings.TrimSuffix(cfg.BaseURL, "/")+"/v1"), nil
+	case config.ProviderGemini:
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("Gemini API key not configured (set GEMINI_API_KEY)")
+		}
+		return newClient(name, cfg.APIKey, strings.TrimSuffix(cfg.BaseURL, "/")+"/openai"), nil
+	}
+	return nil, fmt.Errorf("LLM provider %s is not supported for execution yet", name)
+}
+
+// newClient creates a client for an OpenAI-compatible API
+func newClient(provider, apiKey, baseURL string) *OpenAIClient {
 	return &OpenAIClient{
-		apiKey: cfg.OpenAI.APIKey,
+		provider: provider,
+		apiKey:   apiKey,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
 		httpClient: &http.Client{
-			Timeout: 120 * time.Second,
+			Timeout:   120 * time.Second,
+			Transport: vcr.Transport(),
 		},
-	}, nil
+	}
+}
+
+// isLocal reports whether the client talks to a model served locally, whose
+// calls cost nothing
+func (c *OpenAIClient) isLocal() bool {
+	return c.provider == config.ProviderOllama
 }
 
 // CompletionRequest represents OpenAI API request
@@ -73,9 +126,17 @@ type Usage struct {
 	TotalTokens      int `json:"total_tokens"`
 }
 
-// Execute runs an LLM completion
-func (c *OpenAIClient) Execute(config *spec.LLMConfig, prompt string, input string) (string, float64, error) {
-	// Build request
+// Completion is the result of an LLM call
+type Completion struct {
+	Content string
+	Model   string // Model that answered, as reported by the provider
+	Usage   Usage
+	Cost    float64 // Approximate cost in USD
+}
+
+// newCompletionRequest builds the chat request of an LLM call: the prompt as
+// the system message, followed by the input, if any, as the user message
+func newCompletionRequest(config *spec.LLMConfig, prompt, input string) CompletionRequest {
 	req := CompletionRequest{
 		Model: config.Model,
 		Messages: []Message{
@@ -96,78 +157,173 @@ func (c *OpenAIClient) Execute(config *spec.LLMConfig, prompt string, input stri
 	if config.MaxTokens > 0 {
 		req.MaxTokens = config.MaxTokens
 	}
+	return req
+}
+
+// Execute runs an LLM completion. The call is traced as a gen_ai chat span
+// carrying the token usage and cost.
+func (c *OpenAIClient) Execute(ctx context.Context, config *spec.LLMConfig, prompt string, input string) (result *Completion, err error) {
+	ctx, span := tracer.Start(ctx, "chat "+config.Model, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("gen_ai.system", c.provider),
+		attribute.String("gen_ai.operation.name", "chat"),
+		attribute.String("gen_ai.request.model", config.Model),
+		attribute.Float64("gen_ai.request.temperature", config.Temperature),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	req := newCompletionRequest(config, prompt, input)
 
 	// Marshal request
 	reqBody, err := json.Marshal(req)
 	if err != nil {
-		return "", 0, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	// Create HTTP request
-	httpReq, err := http.NewRequest("POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(reqBody))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewBuffer(reqBody))
 	if err != nil {
-		return "", 0, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+	if requestID := tracing.RequestIDFromContext(ctx); requestID != "" {
+		httpReq.Header.Set(tracing.RequestIDHeader, requestID)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
 
 	// Send request
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return "", 0, fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Read response
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", 0, fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", 0, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		return nil, &ProviderError{Provider: c.provider, StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	// Parse response
 	var completion CompletionResponse
 	if err := json.Unmarshal(body, &completion); err != nil {
-		return "", 0, fmt.Errorf("failed to parse response: %w", err)
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	if len(completion.Choices) == 0 {
-		return "", 0, fmt.Errorf("no completion choices returned")
+		return nil, fmt.Errorf("no completion choices returned")
+	}
+
+	result = &Completion{
+		Content: completion.Choices[0].Message.Content,
+		Model:   completion.Model,
+		Usage:   completion.Usage,
+		Cost:    calculateCost(config.Model, completion.Usage), // approximate
+	}
+	if c.isLocal() {
+		result.Cost = 0
+	}
+
+	span.SetAttributes(
+		attribute.String("gen_ai.response.model", completion.Model),
+		attribute.Int("gen_ai.usage.input_tokens", completion.Usage.PromptTokens),
+		attribute.Int("gen_ai.usage.output_tokens", completion.Usage.CompletionTokens),
+		tracing.CostKey.Float64(result.Cost),
+	)
+
+	return result, nil
+}
+
+// Ping verifies the API key and connectivity by listing available models
+func (c *OpenAIClient) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/models", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %w", c.provider, err)
 	}
+	defer resp.Body.Close()
 
-	// Calculate cost (approximate)
-	cost := calculateCost(config.Model, completion.Usage)
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("%s rejected the API key (status 401)", c.provider)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
 
-	return completion.Choices[0].Message.Content, cost, nil
+	return nil
 }
 
 // calculateCost estimates the cost based on token usage
 func calculateCost(model string, usage Usage) float64 {
-	var inputCostPer1k, outputCostPer1k float64
+	return CostForTokens(model, usage.PromptTokens, usage.CompletionTokens)
+}
 
-	// Approximate pricing (as of Oct 2024)
+// CostForTokens estimates the cost of a call with the given token counts
+func CostForTokens(model string, promptTokens, completionTokens int) float64 {
+	return PricingFor(model).Cost(promptTokens, completionTokens)
+}
+
+// Pricing is the USD price per 1K prompt and completion tokens of a model
+type Pricing struct {
+	InputPer1K  float64 `json:"input_per_1k"`
+	OutputPer1K float64 `json:"output_per_1k"`
+}
+
+// Cost returns the price of a call with the given token counts
+func (p Pricing) Cost(promptTokens, completionTokens int) float64 {
+	inputCost := float64(promptTokens) / 1000.0 * p.InputPer1K
+	outputCost := float64(completionTokens) / 1000.0 * p.OutputPer1K
+
+	return inputCost + outputCost
+}
+
+// PricingFor returns the approximate list pricing of model
+func PricingFor(model string) Pricing {
 	switch {
+	case strings.Contains(model, "claude-haiku-4"):
+		return Pricing{InputPer1K: 0.001, OutputPer1K: 0.005}
+	case strings.Contains(model, "claude-3-5-haiku"):
+		return Pricing{InputPer1K: 0.0008, OutputPer1K: 0.004}
+	case strings.Contains(model, "claude-3-haiku"):
+		return Pricing{InputPer1K: 0.00025, OutputPer1K: 0.00125}
+	case strings.Contains(model, "claude-opus-4-5"):
+		return Pricing{InputPer1K: 0.005, OutputPer1K: 0.025}
+	case strings.Contains(model, "claude") && strings.Contains(model, "opus"):
+		return Pricing{InputPer1K: 0.015, OutputPer1K: 0.075}
+	case strings.Contains(model, "claude") && strings.Contains(model, "sonnet"):
+		return Pricing{InputPer1K: 0.003, OutputPer1K: 0.015}
+	case strings.Contains(model, "gpt-4o-mini"):
+		return Pricing{InputPer1K: 0.00015, OutputPer1K: 0.0006}
+	case strings.Contains(model, "gpt-4o"):
+		return Pricing{InputPer1K: 0.0025, OutputPer1K: 0.01}
 	case strings.Contains(model, "gpt-4-turbo"):
-		inputCostPer1k = 0.01
-		outputCostPer1k = 0.03
+		return Pricing{InputPer1K: 0.01, OutputPer1K: 0.03}
 	case strings.Contains(model, "gpt-4"):
-		inputCostPer1k = 0.03
-		outputCostPer1k = 0.06
+		return Pricing{InputPer1K: 0.03, OutputPer1K: 0.06}
 	case strings.Contains(model, "gpt-3.5"):
-		inputCostPer1k = 0.0005
-		outputCostPer1k = 0.0015
+		return Pricing{InputPer1K: 0.0005, OutputPer1K: 0.0015}
 	default:
 		// Conservative estimate
-		inputCostPer1k = 0.01
-		outputCostPer1k = 0.03
+		return Pricing{InputPer1K: 0.01, OutputPer1K: 0.03}
 	}
+}
 
-	inputCost := float64(usage.PromptTokens) / 1000.0 * inputCostPer1k
-	outputCost := float64(usage.CompletionTokens) / 1000.0 * outputCostPer1k
-
-	return inputCost + outputCost
+// CountTokens approximates the token count of text (~4 characters per token
+// for English). It is meant for budgeting, not exact billing.
+func CountTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	return (len(text) + 3) / 4
 }
@@ -0,0 +1,18 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/not7/core/spec"
+)
+
+// LLMProvider is the client of an LLM provider, as NewProviderClient returns
+// it. Clients report usage in the same terms and price their calls with
+// PricingFor, whatever API they speak.
+type LLMProvider interface {
+	// Execute runs a completion of input with prompt as the system prompt
+	Execute(ctx context.Context, config *spec.LLMConfig, prompt string, input string) (*Completion, error)
+
+	// Ping checks that the provider is reachable and accepts the API key
+	Ping(ctx context.Context) error
+}
@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lineHandler is a slog.Handler for the human-readable text format:
+//
+//	[2006-01-02T15:04:05Z07:00] [LEVEL] [request_id=abc] message key=value
+//
+// Attributes added with WithAttrs (logger context such as the request ID)
+// are written in brackets before the message; attributes of the entry
+// itself follow it.
+type lineHandler struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix string // rendered context attributes
+	group  string // key prefix from WithGroup, ending in "."
+}
+
+// NewLineHandler returns a handler writing the text format to w. It accepts
+// every level; filtering is left to the Logger.
+func NewLineHandler(w io.Writer) slog.Handler {
+	return &lineHandler{mu: &sync.Mutex{}, w: w}
+}
+
+func (h *lineHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *lineHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString("[" + r.Time.Format(time.RFC3339) + "] [" + r.Level.String() + "] ")
+	b.WriteString(h.prefix)
+	b.WriteString(r.Message)
+	r.Attrs(func(a slog.Attr) bool {
+		writeAttr(&b, h.group, a, false)
+		return true
+	})
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *lineHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	for _, a := range attrs {
+		writeAttr(&b, h.group, a, true)
+	}
+
+	child := *h
+	child.prefix += b.String()
+	return &child
+}
+
+func (h *lineHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	child := *h
+	child.group += name + "."
+	return &child
+}
+
+// writeAttr renders a, flattening groups into dotted keys. Context
+// attributes are bracketed; entry attributes are space-separated.
+func writeAttr(b *strings.Builder, group string, a slog.Attr, bracketed bool) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			group += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			writeAttr(b, group, ga, bracketed)
+		}
+		return
+	}
+
+	value := a.Value.String()
+	if strings.ContainsAny(value, " \t\n\"=[]") || value == "" {
+		value = strconv.Quote(value)
+	}
+
+	if bracketed {
+		b.WriteString("[" + group + a.Key + "=" + value + "] ")
+	} else {
+		b.WriteString(" " + group + a.Key + "=" + value)
+	}
+}
@@ -1,10 +1,17 @@
+// Package logger writes execution logs on top of log/slog. The printf-style
+// Info/Error/Debug methods used by the executor are kept, while With and
+// LogAttrs attach structured attributes that the JSON handler emits as fields.
 package logger
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,17 +24,128 @@ const (
 	DEBUG Level = "DEBUG"
 )
 
-// Logger handles structured logging
+// Levels lists the log levels from most to least verbose
+var Levels = []Level{DEBUG, INFO, ERROR}
+
+// Format selects how log entries are encoded
+type Format string
+
+const (
+	TextFormat Format = "text" // [timestamp] [LEVEL] message key=value
+	JSONFormat Format = "json" // one JSON object per line
+)
+
+// Formats lists the supported log formats
+var Formats = []Format{TextFormat, JSONFormat}
+
+// defaultLevel is the minimum level of loggers without their own level
+var defaultLevel atomic.Value
+
+// defaultFormat is the encoding of newly created loggers
+var defaultFormat atomic.Value
+
+func init() {
+	defaultLevel.Store(INFO)
+	defaultFormat.Store(TextFormat)
+}
+
+// ParseLevel converts a level name such as "debug" to a Level
+func ParseLevel(name string) (Level, error) {
+	level := Level(strings.ToUpper(strings.TrimSpace(name)))
+	if level.rank() < 0 {
+		return "", fmt.Errorf("unknown log level %q (expected debug, info or error)", name)
+	}
+	return level, nil
+}
+
+// SetDefaultLevel sets the minimum level written by loggers that have no
+// level of their own. It starts at INFO.
+func SetDefaultLevel(level Level) {
+	if level.rank() >= 0 {
+		defaultLevel.Store(level)
+	}
+}
+
+// DefaultLevel returns the global minimum level
+func DefaultLevel() Level {
+	return defaultLevel.Load().(Level)
+}
+
+// ParseFormat converts a format name such as "json" to a Format
+func ParseFormat(name string) (Format, error) {
+	format := Format(strings.ToLower(strings.TrimSpace(name)))
+	for _, f := range Formats {
+		if f == format {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown log format %q (expected text or json)", name)
+}
+
+// SetDefaultFormat sets the encoding used by loggers created afterwards.
+// It starts at TextFormat.
+func SetDefaultFormat(format Format) {
+	if _, err := ParseFormat(string(format)); err == nil {
+		defaultFormat.Store(format)
+	}
+}
+
+// DefaultFormat returns the encoding of new loggers
+func DefaultFormat() Format {
+	return defaultFormat.Load().(Format)
+}
+
+// rank orders levels by severity, -1 for unknown levels
+func (l Level) rank() int {
+	for i, level := range Levels {
+		if level == l {
+			return i
+		}
+	}
+	return -1
+}
+
+// slogLevel maps the level onto its log/slog equivalent
+func (l Level) slogLevel() slog.Level {
+	switch l {
+	case DEBUG:
+		return slog.LevelDebug
+	case ERROR:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// Logger handles structured logging through a slog.Handler
 type Logger struct {
-	writer io.Writer
-	file   *os.File
+	handler slog.Handler
+	file    *os.File
+	level   Level // minimum level; empty means DefaultLevel()
+}
+
+// New creates a logger that writes through handler. Level filtering is done
+// by the logger, so the handler should accept every level.
+func New(handler slog.Handler) *Logger {
+	return &Logger{handler: handler}
+}
+
+// NewHandler returns the handler for format writing to w
+func NewHandler(w io.Writer, format Format) slog.Handler {
+	if format == JSONFormat {
+		return slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})
+	}
+	return NewLineHandler(w)
 }
 
 // NewConsoleLogger creates a logger that writes to stdout
 func NewConsoleLogger() *Logger {
-	return &Logger{
-		writer: os.Stdout,
-	}
+	return New(NewHandler(os.Stdout, DefaultFormat()))
+}
+
+// NewDiscardLogger creates a logger that drops every entry
+func NewDiscardLogger() *Logger {
+	return New(NewHandler(io.Discard, TextFormat))
 }
 
 // NewFileLogger creates a logger that writes to a file in the logs directory
@@ -48,17 +166,56 @@ func NewFileLogger(logDir, executionID string) (*Logger, error) {
 	}
 
 	return &Logger{
-		writer: file,
-		file:   file,
+		handler: NewHandler(file, DefaultFormat()),
+		file:    file,
 	}, nil
 }
 
-// Log writes a log entry with timestamp and level
+// SetLevel sets the minimum level of this logger, overriding the default.
+// An empty level restores the default.
+func (l *Logger) SetLevel(level Level) {
+	l.level = level
+}
+
+// SetRequestID tags every following line with the request ID, so an
+// execution's log can be matched with the API request, events and traces
+func (l *Logger) SetRequestID(id string) {
+	l.handler = l.handler.WithAttrs([]slog.Attr{slog.String("request_id", id)})
+}
+
+// With returns a logger that adds the given key-value pairs to every entry.
+// It shares the file of l, so only one of them should be closed.
+func (l *Logger) With(args ...interface{}) *Logger {
+	child := *l
+	child.handler = slog.New(l.handler).With(args...).Handler()
+	return &child
+}
+
+// Enabled reports whether entries at level are written
+func (l *Logger) Enabled(level Level) bool {
+	min := l.level
+	if min == "" {
+		min = DefaultLevel()
+	}
+	return level.rank() >= min.rank()
+}
+
+// Log writes a log entry with timestamp and level, unless level is below
+// the logger's minimum
 func (l *Logger) Log(level Level, format string, args ...interface{}) {
-	timestamp := time.Now().Format("2006-01-02T15:04:05Z07:00")
-	message := fmt.Sprintf(format, args...)
-	logLine := fmt.Sprintf("[%s] [%s] %s\n", timestamp, level, message)
-	l.writer.Write([]byte(logLine))
+	l.LogAttrs(level, fmt.Sprintf(format, args...))
+}
+
+// LogAttrs writes msg with structured attributes, unless level is below the
+// logger's minimum
+func (l *Logger) LogAttrs(level Level, msg string, attrs ...slog.Attr) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	record := slog.NewRecord(time.Now(), level.slogLevel(), msg, 0)
+	record.AddAttrs(attrs...)
+	l.handler.Handle(context.Background(), record)
 }
 
 // Info logs an informational message
@@ -0,0 +1,190 @@
+// Package memory gives agents long-term memory across executions. Each
+// agent's memories are kept, with their embeddings, in <dir>/<agent-id>.json
+// and recalled by similarity to a query.
+package memory
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/not7/core/llm"
+)
+
+const (
+	// MaxEntries bounds an agent's memory; the oldest entries are dropped first
+	MaxEntries = 1000
+
+	// DefaultRecallLimit is the number of memories Recall returns by default
+	DefaultRecallLimit = 5
+)
+
+// agentIDPattern restricts agent IDs to names that are safe as file names
+var agentIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Entry is one remembered text
+type Entry struct {
+	ID        string    `json:"id"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+	Embedding []float64 `json:"embedding"`
+}
+
+// Match is a recalled entry with its similarity to the query (-1 to 1)
+type Match struct {
+	Entry
+	Score float64
+}
+
+// Store keeps agents' memories in a directory
+type Store struct {
+	dir      string
+	embedder llm.Embedder
+}
+
+// fileLocks serializes access to each memory file. Executions create their
+// own Store, so the locks are shared by every Store in the process.
+var fileLocks sync.Map // path -> *sync.Mutex
+
+// NewStore returns a store keeping memories in dir, created if missing
+func NewStore(dir string, embedder llm.Embedder) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create memory directory: %w", err)
+	}
+	return &Store{dir: dir, embedder: embedder}, nil
+}
+
+// Remember stores text in an agent's memory and returns the new entry
+func (s *Store) Remember(ctx context.Context, agentID, text string) (*Entry, error) {
+	if text == "" {
+		return nil, fmt.Errorf("text is required")
+	}
+	path, err := s.path(agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	vectors, err := s.embedder.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed memory: %w", err)
+	}
+
+	unlock := lock(path)
+	defer unlock()
+
+	entries, err := read(path)
+	if err != nil {
+		return nil, err
+	}
+	entry := Entry{ID: newID(), Text: text, CreatedAt: time.Now().UTC(), Embedding: vectors[0]}
+	entries = append(entries, entry)
+	if len(entries) > MaxEntries {
+		entries = entries[len(entries)-MaxEntries:]
+	}
+	if err := write(path, entries); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Recall returns up to limit of an agent's memories, most similar to query
+// first. An agent with no memories recalls nothing.
+func (s *Store) Recall(ctx context.Context, agentID, query string, limit int) ([]Match, error) {
+	if query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	if limit <= 0 {
+		limit = DefaultRecallLimit
+	}
+	path, err := s.path(agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := lock(path)
+	entries, err := read(path)
+	unlock()
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+
+	vectors, err := s.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	matches := make([]Match, len(entries))
+	for i, entry := range entries {
+		matches[i] = Match{Entry: entry, Score: llm.CosineSimilarity(vectors[0], entry.Embedding)}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+func (s *Store) path(agentID string) (string, error) {
+	if !agentIDPattern.MatchString(agentID) {
+		return "", fmt.Errorf("agent ID %q cannot be used for memory (use letters, digits, '.', '_' and '-')", agentID)
+	}
+	return filepath.Join(s.dir, agentID+".json"), nil
+}
+
+func lock(path string) func() {
+	mu, _ := fileLocks.LoadOrStore(path, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+func read(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read memory: %w", err)
+	}
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse memory file %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+func write(path string, entries []Entry) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal memory: %w", err)
+	}
+
+	// Write atomically: write to temp file, then rename
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write memory: %w", err)
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to rename memory file: %w", err)
+	}
+	return nil
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/not7/core/tools"
+)
+
+// ProviderName is the tool provider name of the memory tools
+const ProviderName = "memory"
+
+// Provider offers the Remember and Recall tools over one agent's memory
+type Provider struct {
+	store   *Store
+	agentID string
+}
+
+// NewProvider returns the memory tools for agentID
+func NewProvider(store *Store, agentID string) *Provider {
+	return &Provider{store: store, agentID: agentID}
+}
+
+// Initialize checks the provider can address the agent's memory
+func (p *Provider) Initialize(config map[string]string) error {
+	if p.agentID == "" {
+		return fmt.Errorf("memory tools need an agent with an id")
+	}
+	_, err := p.store.path(p.agentID)
+	return err
+}
+
+// ListTools returns the memory tools
+func (p *Provider) ListTools(ctx context.Context) ([]tools.ToolDefinition, error) {
+	return []tools.ToolDefinition{
+		{
+			Name:        "Remember",
+			Description: "Save a fact or finding to long-term memory so later runs of this agent can recall it.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"text": map[string]interface{}{
+						"type":        "string",
+						"description": "What to remember, written to make sense on its own",
+					},
+				},
+				"required": []string{"text"},
+			},
+			Provider: ProviderName,
+		},
+		{
+			Name:        "Recall",
+			Description: "Search long-term memory from earlier runs of this agent. Returns the most relevant memories with when they were saved.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "What to look for",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Number of memories to return (default: %d)", DefaultRecallLimit),
+					},
+				},
+				"required": []string{"query"},
+			},
+			Provider: ProviderName,
+		},
+	}, nil
+}
+
+// ExecuteTool executes a memory tool
+func (p *Provider) ExecuteTool(ctx context.Context, toolName string, arguments map[string]interface{}) (*tools.ToolResult, error) {
+	switch toolName {
+	case "Remember":
+		text, _ := arguments["text"].(string)
+		entry, err := p.store.Remember(ctx, p.agentID, text)
+		if err != nil {
+			return &tools.ToolResult{Success: false, Error: err.Error()}, nil
+		}
+		return &tools.ToolResult{
+			Success:  true,
+			Output:   "Remembered.",
+			Metadata: map[string]interface{}{"memory_id": entry.ID},
+		}, nil
+
+	case "Recall":
+		query, _ := arguments["query"].(string)
+		limit := 0
+		if n, ok := arguments["limit"].(float64); ok {
+			limit = int(n)
+		}
+		matches, err := p.store.Recall(ctx, p.agentID, query, limit)
+		if err != nil {
+			return &tools.ToolResult{Success: false, Error: err.Error()}, nil
+		}
+
+		if len(matches) == 0 {
+			return &tools.ToolResult{Success: true, Output: "No memories found."}, nil
+		}
+
+		// One line per memory, dated so the agent can tell old findings from new
+		var b strings.Builder
+		scores := make([]float64, len(matches))
+		for i, m := range matches {
+			fmt.Fprintf(&b, "- [%s] %s\n", m.CreatedAt.Format("2006-01-02 15:04"), m.Text)
+			scores[i] = m.Score
+		}
+		return &tools.ToolResult{
+			Success:  true,
+			Output:   strings.TrimSuffix(b.String(), "\n"),
+			Metadata: map[string]interface{}{"scores": scores},
+		}, nil
+
+	default:
+		return &tools.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("unknown tool: %s", toolName),
+		}, nil
+	}
+}
+
+// GetProviderName returns the provider identifier
+func (p *Provider) GetProviderName() string {
+	return ProviderName
+}
+
+// Close cleans up resources
+func (p *Provider) Close() error {
+	return nil
+}
@@ -0,0 +1,146 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// discordLimit is the longest message Discord accepts
+const discordLimit = 2000
+
+// slackChannel posts announcements to a Slack incoming webhook
+type slackChannel struct {
+	url string
+}
+
+func (c *slackChannel) send(ctx context.Context, msg message) error {
+	return postJSON(ctx, c.url, map[string]string{"text": msg.Text})
+}
+
+// discordChannel posts announcements to a Discord channel webhook
+type discordChannel struct {
+	url string
+}
+
+func (c *discordChannel) send(ctx context.Context, msg message) error {
+	text := msg.Text
+	if runes := []rune(text); len(runes) > discordLimit {
+		text = string(runes[:discordLimit-1]) + "…"
+	}
+	return postJSON(ctx, c.url, map[string]string{"content": text})
+}
+
+// postJSON posts payload as JSON and treats any non-2xx response as a failure
+func postJSON(ctx context.Context, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("notification rejected (status %d): %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// emailChannel sends announcements through an SMTP server. Port 465 uses
+// implicit TLS; on other ports STARTTLS is used when the server offers it.
+type emailChannel struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+}
+
+func (c *emailChannel) send(ctx context.Context, msg message) error {
+	addr := net.JoinHostPort(c.host, strconv.Itoa(c.port))
+	var conn net.Conn
+	var err error
+	if c.port == 465 {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: c.host}}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reach SMTP server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, c.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && c.port != 465 {
+		if err := client.StartTLS(&tls.Config{ServerName: c.host}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if c.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.username, c.password, c.host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+	if err := client.Mail(c.from); err != nil {
+		return fmt.Errorf("SMTP server rejected sender: %w", err)
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("SMTP server rejected recipient %s: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if _, err := w.Write(c.compose(msg)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return client.Quit()
+}
+
+// compose builds the plain-text email of msg
+func (c *emailChannel) compose(msg message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", c.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Text, "\n", "\r\n"))
+	return []byte(b.String())
+}
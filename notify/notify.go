@@ -0,0 +1,211 @@
+// Package notify announces the outcome of executions on Slack, Discord and
+// email, so people hear about finished and failed runs without polling.
+//
+// Like reporting, notify is process-wide: the server and workers call Setup
+// once with the channels in the config, and the execution manager calls
+// Send. An agent's spec can narrow the outcomes, channels and recipients.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/not7/core/config"
+	"github.com/not7/core/spec"
+)
+
+// sendTimeout bounds delivery to a single channel
+const sendTimeout = 10 * time.Second
+
+// excerptLength is how much of the output an announcement quotes
+const excerptLength = 300
+
+// Summary describes a finished execution
+type Summary struct {
+	ExecutionID string
+	AgentID     string
+	Goal        string
+	Status      string // completed or failed
+	Cost        float64
+	DurationMs  int64
+	Output      string
+	Error       string
+}
+
+// message is an announcement as the channels send it
+type message struct {
+	Subject string
+	Text    string
+	To      []string // email recipients
+}
+
+// channel delivers announcements to one destination
+type channel interface {
+	send(ctx context.Context, msg message) error
+}
+
+var (
+	mu       sync.RWMutex
+	on       []string
+	baseURL  string
+	emailTo  []string
+	channels map[string]channel
+)
+
+// Setup configures the channels in cfg. With no channel configured,
+// notifications stay disabled.
+func Setup(cfg config.NotifyConfig) error {
+	configured := make(map[string]channel)
+	if cfg.SlackWebhookURL != "" {
+		configured[spec.ChannelSlack] = &slackChannel{url: cfg.SlackWebhookURL}
+	}
+	if cfg.DiscordWebhookURL != "" {
+		configured[spec.ChannelDiscord] = &discordChannel{url: cfg.DiscordWebhookURL}
+	}
+	if cfg.SMTPHost != "" {
+		if cfg.SMTPFrom == "" {
+			return fmt.Errorf("email notifications need a sender address")
+		}
+		configured[spec.ChannelEmail] = &emailChannel{
+			host:     cfg.SMTPHost,
+			port:     cfg.SMTPPort,
+			username: cfg.SMTPUsername,
+			password: cfg.SMTPPassword,
+			from:     cfg.SMTPFrom,
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	on = splitList(cfg.On)
+	baseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	emailTo = splitList(cfg.EmailTo)
+	channels = configured
+	return nil
+}
+
+// Enabled reports whether any channel is configured
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return len(channels) > 0
+}
+
+// Send announces summary on the channels that policy, an agent's
+// notification settings (possibly nil), selects. Nothing is sent for an
+// outcome the policy or the config does not ask for. It returns the
+// delivery failures, if any.
+func Send(ctx context.Context, summary Summary, policy *spec.NotificationsConfig) error {
+	mu.RLock()
+	outcomes, link, recipients, targets := on, baseURL, emailTo, channels
+	mu.RUnlock()
+
+	var selected []string
+	if policy != nil {
+		if len(policy.On) > 0 {
+			outcomes = policy.On
+		}
+		if len(policy.Email) > 0 {
+			recipients = policy.Email
+		}
+		selected = policy.Channels
+	}
+	if !contains(outcomes, outcome(summary)) {
+		return nil
+	}
+	if link != "" {
+		link += "/executions/" + summary.ExecutionID + "/view"
+	}
+	msg := format(summary, link)
+	msg.To = recipients
+
+	var errs []error
+	for _, name := range []string{spec.ChannelSlack, spec.ChannelDiscord, spec.ChannelEmail} {
+		target, ok := targets[name]
+		if !ok || (len(selected) > 0 && !contains(selected, name)) {
+			continue
+		}
+		if name == spec.ChannelEmail && len(msg.To) == 0 {
+			continue
+		}
+		sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+		if err := target.send(sendCtx, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+		cancel()
+	}
+	return errors.Join(errs...)
+}
+
+// outcome classifies a summary as spec.NotifySuccess or spec.NotifyFailure
+func outcome(summary Summary) string {
+	if summary.Status == "completed" {
+		return spec.NotifySuccess
+	}
+	return spec.NotifyFailure
+}
+
+// format writes the announcement of summary, linking to link when set
+func format(summary Summary, link string) message {
+	agent := summary.AgentID
+	if agent == "" {
+		agent = "Agent"
+	}
+	icon := "✅"
+	if outcome(summary) == spec.NotifyFailure {
+		icon = "❌"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s\n", icon, agent, summary.Status)
+	fmt.Fprintf(&b, "Goal: %s\n", summary.Goal)
+	fmt.Fprintf(&b, "Cost: $%.4f | Duration: %s\n", summary.Cost, time.Duration(summary.DurationMs)*time.Millisecond)
+	if summary.Error != "" {
+		fmt.Fprintf(&b, "Error: %s\n", summary.Error)
+	}
+	if summary.Output != "" {
+		fmt.Fprintf(&b, "Output: %s\n", excerpt(summary.Output))
+	}
+	if link != "" {
+		fmt.Fprintf(&b, "%s\n", link)
+	}
+
+	return message{
+		Subject: fmt.Sprintf("[NOT7] %s %s (%s)", agent, summary.Status, summary.ExecutionID),
+		Text:    b.String(),
+	}
+}
+
+// excerpt shortens text to excerptLength characters
+func excerpt(text string) string {
+	text = strings.TrimSpace(text)
+	if utf8.RuneCountInString(text) <= excerptLength {
+		return text
+	}
+	return string([]rune(text)[:excerptLength]) + "…"
+}
+
+// splitList splits a comma-separated config value
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
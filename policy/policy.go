@@ -0,0 +1,155 @@
+// Package policy holds the rules operators set on what agents may do: the
+// LLM providers and models they call, the tools they use, the domains
+// WebFetch reaches and the cost of an execution. Specs are checked against
+// the policy when an execution is submitted, and every call is checked again
+// while it runs, since models choose tools and URLs at run time.
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/not7/core/config"
+)
+
+// ErrDenied is wrapped by every error reporting something the policy forbids
+var ErrDenied = errors.New("denied by policy")
+
+// Policy is a compiled PolicyConfig. A nil Policy allows everything.
+type Policy struct {
+	models    rules
+	tools     rules
+	providers rules
+	domains   rules
+	maxCost   float64
+}
+
+// rules are the allow and deny lists of one kind of name
+type rules struct {
+	allow []string
+	deny  []string
+}
+
+// New builds the policy of cfg, or returns nil when cfg sets no rule
+func New(cfg config.PolicyConfig) *Policy {
+	p := &Policy{
+		models:    rules{allow: list(cfg.AllowModels), deny: list(cfg.DenyModels)},
+		tools:     rules{allow: list(cfg.AllowTools), deny: list(cfg.DenyTools)},
+		providers: rules{allow: list(cfg.AllowProviders), deny: list(cfg.DenyProviders)},
+		domains:   rules{allow: list(cfg.AllowDomains), deny: list(cfg.DenyDomains)},
+		maxCost:   cfg.MaxCost,
+	}
+	if p.models.empty() && p.tools.empty() && p.providers.empty() && p.domains.empty() && p.maxCost <= 0 {
+		return nil
+	}
+	return p
+}
+
+// CheckModel reports whether an LLM call to the model of a provider is
+// allowed
+func (p *Policy) CheckModel(provider, model string) error {
+	if p == nil {
+		return nil
+	}
+	if err := p.providers.check("provider", strings.ToLower(provider), equal); err != nil {
+		return err
+	}
+	return p.models.check("model", model, matchPattern)
+}
+
+// CheckTool reports whether a tool may be called
+func (p *Policy) CheckTool(name string) error {
+	if p == nil {
+		return nil
+	}
+	return p.tools.check("tool", name, matchPattern)
+}
+
+// CheckURL reports whether WebFetch may fetch a URL, by its host
+func (p *Policy) CheckURL(rawURL string) error {
+	if p == nil || p.domains.empty() {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return fmt.Errorf("%w: cannot tell the domain of %q", ErrDenied, rawURL)
+	}
+	return p.domains.check("domain", strings.ToLower(u.Hostname()), matchDomain)
+}
+
+// CheckCost reports whether an execution may cost cost USD
+func (p *Policy) CheckCost(cost float64) error {
+	if p == nil || p.maxCost <= 0 || cost <= p.maxCost {
+		return nil
+	}
+	return fmt.Errorf("%w: cost $%.4f is over the limit of $%.4f", ErrDenied, cost, p.maxCost)
+}
+
+// check applies the lists to a name: a match on the deny list, or no match
+// on a non-empty allow list, denies it
+func (r rules) check(kind, name string, match func(pattern, name string) bool) error {
+	for _, pattern := range r.deny {
+		if match(pattern, name) {
+			return fmt.Errorf("%w: %s %s is denied", ErrDenied, kind, name)
+		}
+	}
+	if len(r.allow) == 0 {
+		return nil
+	}
+	for _, pattern := range r.allow {
+		if match(pattern, name) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s %s is not allowed", ErrDenied, kind, name)
+}
+
+func (r rules) empty() bool {
+	return len(r.allow) == 0 && len(r.deny) == 0
+}
+
+// list splits a comma-separated config value, dropping empty entries
+func list(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// equal matches names case-insensitively
+func equal(pattern, name string) bool {
+	return strings.EqualFold(pattern, name)
+}
+
+// matchPattern matches a name against a pattern in which * stands for any
+// run of characters, case-insensitively
+func matchPattern(pattern, name string) bool {
+	pattern, name = strings.ToLower(pattern), strings.ToLower(name)
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == name
+	}
+	if !strings.HasPrefix(name, parts[0]) {
+		return false
+	}
+	name = name[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(name, part)
+		if i < 0 {
+			return false
+		}
+		name = name[i+len(part):]
+	}
+	return strings.HasSuffix(name, parts[len(parts)-1])
+}
+
+// matchDomain matches a host against a domain and its subdomains
+func matchDomain(domain, host string) bool {
+	domain = strings.TrimPrefix(strings.ToLower(domain), ".")
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
@@ -0,0 +1,230 @@
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/not7/core/internal/ui"
+)
+
+// errLeaseHeld is returned when another consumer holds a job's lease
+var errLeaseHeld = errors.New("lease held by another consumer")
+
+// Lua scripts that change a key only while this consumer owns it, checking
+// and changing in one step so a lease that lapses in between, and is taken
+// by another consumer, is left alone. Both return 1 when the key was changed.
+const (
+	// releaseScript deletes KEYS[1] if its value is ARGV[1]
+	releaseScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`
+
+	// renewScript sets KEYS[1] to expire in ARGV[2] ms if its value is ARGV[1]
+	renewScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) end return 0`
+)
+
+// ownedBy runs script on key and reports whether it changed it
+func (q *RedisQueue) ownedBy(ctx context.Context, script, key string, args ...string) (bool, error) {
+	reply, err := q.do(ctx, 0, append([]string{"EVAL", script, "1", key, q.id}, args...)...)
+	if err != nil {
+		return false, err
+	}
+	n, _ := reply.(int64)
+	return n == 1, nil
+}
+
+// consumerID identifies this process to the other consumers of a queue
+func consumerID() string {
+	host, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
+
+// leaseKey returns the key of an execution's lease
+func (q *RedisQueue) leaseKey(executionID string) string {
+	return q.prefix + ":lease:" + executionID
+}
+
+// ttlMillis returns the lease length as a PX argument
+func (q *RedisQueue) ttlMillis() string {
+	return strconv.FormatInt(q.leaseTTL.Milliseconds(), 10)
+}
+
+// acquire leases a dequeued job to this consumer and starts its heartbeats
+func (q *RedisQueue) acquire(ctx context.Context, job *Job) error {
+	key := q.leaseKey(job.ExecutionID)
+	if _, err := q.do(ctx, 0, "SET", key, q.id, "NX", "PX", q.ttlMillis()); errors.Is(err, errRedisNil) {
+		return errLeaseHeld
+	} else if err != nil {
+		return fmt.Errorf("failed to lease %s: %w", job.ExecutionID, err)
+	}
+
+	job.lease = &lease{key: key, done: make(chan struct{}), lost: make(chan struct{})}
+	go q.heartbeat(job.lease)
+	return nil
+}
+
+// release stops a job's heartbeats and deletes its lease if still ours
+func (q *RedisQueue) release(ctx context.Context, job *Job) error {
+	if job.lease == nil {
+		return nil
+	}
+	close(job.lease.done)
+
+	if _, err := q.ownedBy(ctx, releaseScript, job.lease.key); err != nil {
+		return fmt.Errorf("failed to release %s: %w", job.ExecutionID, err)
+	}
+	return nil
+}
+
+// heartbeat renews a lease until the job is acknowledged. The lease is lost
+// when another consumer has taken it over, or when it could not be renewed
+// for a full lease length and so may have lapsed.
+func (q *RedisQueue) heartbeat(l *lease) {
+	ticker := time.NewTicker(q.leaseTTL / 3)
+	defer ticker.Stop()
+
+	renewed := time.Now()
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), q.leaseTTL/3)
+		owned, err := q.ownedBy(ctx, renewScript, l.key, q.ttlMillis())
+		cancel()
+
+		switch {
+		case err == nil && owned:
+			renewed = time.Now()
+		case err == nil:
+			close(l.lost)
+			return
+		case time.Since(renewed) >= q.leaseTTL:
+			close(l.lost)
+			return
+		}
+	}
+}
+
+// reap keeps one consumer process of the queue, the leader, queueing again
+// the jobs whose lease lapsed. Every consumer process runs it so a new
+// leader takes over when the old one dies.
+func (q *RedisQueue) reap() {
+	ticker := time.NewTicker(q.leaseTTL / 3)
+	defer ticker.Stop()
+
+	// Jobs seen in the processing list without a lease, and since when. A
+	// job is only leased just after it is taken, so it is given a full lease
+	// length to get one.
+	unleased := make(map[string]time.Time)
+	for {
+		select {
+		case <-q.stop:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), q.leaseTTL)
+		if q.lead(ctx) {
+			if err := q.sweep(ctx, unleased); err != nil && !errors.Is(err, ErrClosed) {
+				ui.Printf("⚠️  Queue: %v\n", err)
+			}
+		} else {
+			clear(unleased)
+		}
+		cancel()
+	}
+}
+
+// lead takes or renews the leader key and reports whether this process holds it
+func (q *RedisQueue) lead(ctx context.Context) bool {
+	key := q.prefix + ":leader"
+	if _, err := q.do(ctx, 0, "SET", key, q.id, "NX", "PX", q.ttlMillis()); err == nil {
+		return true
+	}
+	owned, err := q.ownedBy(ctx, renewScript, key, q.ttlMillis())
+	return err == nil && owned
+}
+
+// sweep queues again the processing jobs that have gone a lease length
+// without a lease
+func (q *RedisQueue) sweep(ctx context.Context, unleased map[string]time.Time) error {
+	reply, err := q.do(ctx, 0, "LRANGE", q.processing, "0", "-1")
+	if err != nil {
+		return fmt.Errorf("failed to list processing jobs: %w", err)
+	}
+	items, _ := reply.([]interface{})
+
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		data, _ := item.(string)
+		seen[data] = true
+
+		var job Job
+		if err := json.Unmarshal([]byte(data), &job); err != nil {
+			continue
+		}
+		held, err := q.do(ctx, 0, "EXISTS", q.leaseKey(job.ExecutionID))
+		if err != nil {
+			return fmt.Errorf("failed to check lease of %s: %w", job.ExecutionID, err)
+		}
+		if n, _ := held.(int64); n > 0 {
+			delete(unleased, data)
+			continue
+		}
+
+		since, ok := unleased[data]
+		if !ok {
+			unleased[data] = time.Now()
+			continue
+		}
+		if time.Since(since) < q.leaseTTL {
+			continue
+		}
+
+		if err := q.requeue(ctx, &job, data); err != nil {
+			return err
+		}
+		delete(unleased, data)
+	}
+
+	for data := range unleased {
+		if !seen[data] {
+			delete(unleased, data)
+		}
+	}
+	return nil
+}
+
+// requeue moves an abandoned job from the processing list to the front of
+// the pending list. Only the consumer whose LREM removes it pushes it back,
+// so it is queued once however many try.
+func (q *RedisQueue) requeue(ctx context.Context, job *Job, data string) error {
+	removed, err := q.do(ctx, 0, "LREM", q.processing, "1", data)
+	if err != nil {
+		return fmt.Errorf("failed to requeue %s: %w", job.ExecutionID, err)
+	}
+	if n, _ := removed.(int64); n == 0 {
+		return nil
+	}
+
+	job.Attempts++
+	encoded, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job: %w", err)
+	}
+	if _, err := q.do(ctx, 0, "RPUSH", q.pending, string(encoded)); err != nil {
+		return fmt.Errorf("failed to requeue %s: %w", job.ExecutionID, err)
+	}
+	ui.Printf("🔁 Requeued %s: its worker stopped renewing the lease\n", job.ExecutionID)
+	return nil
+}
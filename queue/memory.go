@@ -0,0 +1,84 @@
+package queue
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryQueue keeps jobs in-process. Jobs are lost when the process exits.
+type MemoryQueue struct {
+	mu     sync.Mutex
+	jobs   []*Job
+	ready  chan struct{} // signalled when a job is added
+	closed chan struct{}
+	once   sync.Once
+}
+
+// NewMemoryQueue creates an empty in-process queue
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{
+		ready:  make(chan struct{}, 1),
+		closed: make(chan struct{}),
+	}
+}
+
+// Enqueue adds a job to the back of the queue
+func (q *MemoryQueue) Enqueue(ctx context.Context, job *Job) error {
+	select {
+	case <-q.closed:
+		return ErrClosed
+	default:
+	}
+
+	q.mu.Lock()
+	q.jobs = append(q.jobs, job)
+	q.mu.Unlock()
+	q.signal()
+	return nil
+}
+
+// Dequeue blocks until a job is available or ctx is done
+func (q *MemoryQueue) Dequeue(ctx context.Context) (*Job, error) {
+	for {
+		q.mu.Lock()
+		if len(q.jobs) > 0 {
+			job := q.jobs[0]
+			q.jobs = q.jobs[1:]
+			more := len(q.jobs) > 0
+			q.mu.Unlock()
+			// Pass the wakeup on to another waiting consumer
+			if more {
+				q.signal()
+			}
+			return job, nil
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-q.closed:
+			return nil, ErrClosed
+		case <-q.ready:
+		}
+	}
+}
+
+// Ack is a no-op; a dequeued job has already left the queue
+func (q *MemoryQueue) Ack(ctx context.Context, job *Job) error {
+	return nil
+}
+
+// Close wakes blocked consumers, which then return ErrClosed
+func (q *MemoryQueue) Close() error {
+	q.once.Do(func() { close(q.closed) })
+	return nil
+}
+
+// signal wakes one waiting consumer without blocking
+func (q *MemoryQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,118 @@
+// Package queue hands asynchronous executions from the API server to the
+// workers that run them. The memory backend keeps jobs in-process; the
+// Redis backend keeps them in Redis lists, so queued executions survive a
+// restart and any number of servers or workers can consume them.
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/not7/core/config"
+	"github.com/not7/core/executor"
+	"github.com/not7/core/spec"
+)
+
+// Backend names accepted in QUEUE_BACKEND
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
+// MaxAttempts is how many consumers may take a job before it is given up
+// on. A job is taken again only when its consumer stopped renewing its lease.
+const MaxAttempts = 3
+
+var (
+	// ErrClosed is returned by a queue after Close
+	ErrClosed = errors.New("queue closed")
+
+	// ErrLeaseLost is the cause of a job's cancellation when its lease
+	// lapsed and the job may have been given to another consumer
+	ErrLeaseLost = errors.New("queue lease lost")
+)
+
+// Queue is a FIFO of jobs shared between producers and consumers. A
+// dequeued job stays with the queue until it is acknowledged. Backends
+// shared between processes lease each job to its consumer; a job whose
+// lease lapses, because its consumer died, is queued again.
+type Queue interface {
+	// Enqueue adds a job to the back of the queue
+	Enqueue(ctx context.Context, job *Job) error
+
+	// Dequeue blocks until a job is available or ctx is done
+	Dequeue(ctx context.Context) (*Job, error)
+
+	// Ack marks a dequeued job as finished
+	Ack(ctx context.Context, job *Job) error
+
+	// Close releases the queue's connections
+	Close() error
+}
+
+// Job is an execution waiting for a worker. It carries everything needed to
+// run it, so the consumer does not have to share the producer's storage.
+type Job struct {
+	ExecutionID string                `json:"execution_id"`
+	RequestID   string                `json:"request_id,omitempty"`
+	Spec        *spec.AgentSpec       `json:"spec"`
+	Input       string                `json:"input,omitempty"`
+	Tags        map[string]string     `json:"tags,omitempty"`
+	Attachments []executor.Attachment `json:"attachments,omitempty"`
+	Timeout     time.Duration         `json:"timeout,omitempty"`
+	BatchAPI    bool                  `json:"batch_api,omitempty"` // see execution.Options.BatchAPI
+	CreatedAt   time.Time             `json:"created_at"`
+	Attempts    int                   `json:"attempts,omitempty"` // consumers that took the job before
+
+	raw   []byte // encoded form the Redis backend acknowledges by
+	lease *lease
+}
+
+// Lost is closed when the consumer's lease on the job lapses, after which
+// another consumer may run it. It is nil for backends without leases.
+func (j *Job) Lost() <-chan struct{} {
+	if j.lease == nil {
+		return nil
+	}
+	return j.lease.lost
+}
+
+// lease is a consumer's claim on a dequeued job, kept alive by heartbeats
+type lease struct {
+	key  string
+	done chan struct{} // closed on Ack to stop the heartbeats
+	lost chan struct{} // closed when the lease could not be renewed
+}
+
+// Open returns the queue cfg configures, or nil when no backend is set
+func Open(cfg config.QueueConfig) (Queue, error) {
+	switch cfg.Backend {
+	case "":
+		return nil, nil
+	case BackendMemory:
+		return NewMemoryQueue(), nil
+	case BackendRedis:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("the redis queue backend needs QUEUE_URL")
+		}
+		return NewRedisQueue(cfg.URL, cfg.Name, time.Duration(cfg.LeaseSeconds)*time.Second)
+	default:
+		return nil, fmt.Errorf("unknown queue backend %q (expected %s or %s)", cfg.Backend, BackendMemory, BackendRedis)
+	}
+}
+
+// Describe returns the backend and, for Redis, its address without
+// credentials, for startup messages
+func Describe(cfg config.QueueConfig) string {
+	if cfg.Backend != BackendRedis {
+		return cfg.Backend
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return cfg.Backend
+	}
+	return fmt.Sprintf("%s (%s, %s)", cfg.Backend, u.Host, cfg.Name)
+}
@@ -0,0 +1,373 @@
+package queue
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// redisPollTimeout is how long one blocking pop waits, so Dequeue
+	// notices a cancelled context within about this long
+	redisPollTimeout = time.Second
+
+	// redisDialTimeout bounds connecting and authenticating
+	redisDialTimeout = 5 * time.Second
+
+	// redisMaxIdle is the most idle connections kept for reuse
+	redisMaxIdle = 8
+
+	// defaultLeaseTTL is the lease length when none is configured
+	defaultLeaseTTL = 30 * time.Second
+)
+
+// errRedisNil is a nil reply, e.g. a blocking pop that timed out
+var errRedisNil = errors.New("redis: nil reply")
+
+// RedisQueue keeps jobs in Redis lists. Enqueued jobs wait in
+// <name>:pending; a consumer moves a job atomically to <name>:processing
+// when it takes it and removes it from there on Ack, so a job is never lost
+// between the two.
+//
+// A consumer leases each job it takes with a <name>:lease:<execution-id>
+// key that expires unless renewed by heartbeats. One consumer process,
+// elected with the <name>:leader key, watches the processing list and
+// queues again the jobs whose lease has lapsed.
+type RedisQueue struct {
+	addr     string
+	useTLS   bool
+	username string
+	password string
+	db       int
+
+	pending    string
+	processing string
+	prefix     string // of the lease and leader keys
+
+	id       string // identifies this process in lease and leader keys
+	leaseTTL time.Duration
+
+	mu     sync.Mutex
+	idle   []*redisConn
+	closed bool
+
+	reaping sync.Once
+	stop    chan struct{}
+}
+
+// NewRedisQueue connects to the Redis server at rawURL
+// (redis://[user:password@]host:port/db, or rediss:// for TLS) and checks
+// it responds. name prefixes the queue's keys. leaseTTL is how long a
+// consumer that stopped sending heartbeats keeps its jobs.
+func NewRedisQueue(rawURL, name string, leaseTTL time.Duration) (*RedisQueue, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL: %w", err)
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("invalid redis URL %q: scheme must be redis or rediss", rawURL)
+	}
+	if name == "" {
+		name = "not7"
+	}
+	if leaseTTL <= 0 {
+		leaseTTL = defaultLeaseTTL
+	}
+
+	q := &RedisQueue{
+		addr:       u.Host,
+		useTLS:     u.Scheme == "rediss",
+		pending:    name + ":pending",
+		processing: name + ":processing",
+		prefix:     name,
+		id:         consumerID(),
+		leaseTTL:   leaseTTL,
+		stop:       make(chan struct{}),
+	}
+	if u.Port() == "" {
+		q.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		q.password, _ = u.User.Password()
+		q.username = u.User.Username()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if q.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid redis database %q", db)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisDialTimeout)
+	defer cancel()
+	if _, err := q.do(ctx, 0, "PING"); err != nil {
+		return nil, fmt.Errorf("failed to connect to redis at %s: %w", q.addr, err)
+	}
+	return q, nil
+}
+
+// Enqueue pushes a job onto the pending list
+func (q *RedisQueue) Enqueue(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job: %w", err)
+	}
+	if _, err := q.do(ctx, 0, "LPUSH", q.pending, string(data)); err != nil {
+		return fmt.Errorf("failed to enqueue %s: %w", job.ExecutionID, err)
+	}
+	return nil
+}
+
+// Dequeue moves the oldest pending job to the processing list, leases it
+// and returns it. Consumers also look after the jobs of consumers that died.
+func (q *RedisQueue) Dequeue(ctx context.Context) (*Job, error) {
+	q.reaping.Do(func() { go q.reap() })
+
+	timeout := strconv.Itoa(int(redisPollTimeout / time.Second))
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		reply, err := q.do(ctx, redisPollTimeout, "BRPOPLPUSH", q.pending, q.processing, timeout)
+		if errors.Is(err, errRedisNil) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to dequeue: %w", err)
+		}
+
+		data, _ := reply.(string)
+		var job Job
+		if err := json.Unmarshal([]byte(data), &job); err != nil {
+			// An unreadable job would be retried forever; drop it
+			q.do(ctx, 0, "LREM", q.processing, "1", data)
+			return nil, fmt.Errorf("dropped malformed job: %w", err)
+		}
+		job.raw = []byte(data)
+
+		// Another consumer still holding the lease means this is a stale
+		// copy of a job that is already running
+		if err := q.acquire(ctx, &job); errors.Is(err, errLeaseHeld) {
+			q.do(ctx, 0, "LREM", q.processing, "1", data)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		return &job, nil
+	}
+}
+
+// Ack removes a finished job from the processing list
+func (q *RedisQueue) Ack(ctx context.Context, job *Job) error {
+	if job.raw == nil {
+		return fmt.Errorf("job %s was not dequeued from redis", job.ExecutionID)
+	}
+	if _, err := q.do(ctx, 0, "LREM", q.processing, "1", string(job.raw)); err != nil {
+		return fmt.Errorf("failed to ack %s: %w", job.ExecutionID, err)
+	}
+	return q.release(ctx, job)
+}
+
+// Close closes the idle connections; connections in use are closed when
+// their command returns
+func (q *RedisQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.closed {
+		close(q.stop)
+	}
+	q.closed = true
+	for _, conn := range q.idle {
+		conn.Close()
+	}
+	q.idle = nil
+	return nil
+}
+
+// do runs one command on a pooled connection. block is how long the
+// command itself may block the server, on top of the usual I/O deadline.
+func (q *RedisQueue) do(ctx context.Context, block time.Duration, args ...string) (interface{}, error) {
+	conn, err := q.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(redisDialTimeout + block)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) && block == 0 {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	reply, err := conn.command(args...)
+	if err != nil && !errors.Is(err, errRedisNil) && !isRedisError(err) {
+		// The connection's state is unknown after an I/O error
+		conn.Close()
+		return nil, err
+	}
+	q.put(conn)
+	return reply, err
+}
+
+// get returns an idle connection or dials a new one
+func (q *RedisQueue) get(ctx context.Context) (*redisConn, error) {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil, ErrClosed
+	}
+	if n := len(q.idle); n > 0 {
+		conn := q.idle[n-1]
+		q.idle = q.idle[:n-1]
+		q.mu.Unlock()
+		return conn, nil
+	}
+	q.mu.Unlock()
+
+	return q.dial(ctx)
+}
+
+// put returns a connection to the idle pool
+func (q *RedisQueue) put(conn *redisConn) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed || len(q.idle) >= redisMaxIdle {
+		conn.Close()
+		return
+	}
+	q.idle = append(q.idle, conn)
+}
+
+// dial opens a connection, authenticates and selects the database
+func (q *RedisQueue) dial(ctx context.Context) (*redisConn, error) {
+	dialer := &net.Dialer{Timeout: redisDialTimeout}
+	var netConn net.Conn
+	var err error
+	if q.useTLS {
+		host, _, _ := net.SplitHostPort(q.addr)
+		netConn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", q.addr)
+	} else {
+		netConn, err = dialer.DialContext(ctx, "tcp", q.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &redisConn{Conn: netConn, reader: bufio.NewReader(netConn)}
+	conn.SetDeadline(time.Now().Add(redisDialTimeout))
+
+	if q.password != "" {
+		args := []string{"AUTH", q.password}
+		if q.username != "" {
+			args = []string{"AUTH", q.username, q.password}
+		}
+		if _, err := conn.command(args...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+	if q.db != 0 {
+		if _, err := conn.command("SELECT", strconv.Itoa(q.db)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to select database %d: %w", q.db, err)
+		}
+	}
+	return conn, nil
+}
+
+// redisError is an error reply from the server. The connection stays usable.
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// isRedisError reports whether err is an error reply rather than an I/O error
+func isRedisError(err error) bool {
+	var replyErr redisError
+	return errors.As(err, &replyErr)
+}
+
+// redisConn speaks RESP, the Redis protocol, over a connection
+type redisConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// command sends a command and reads its reply: a string for simple and bulk
+// strings, an int64 for integers, a []interface{} for arrays
+func (c *redisConn) command(args ...string) (interface{}, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.Conn, b.String()); err != nil {
+		return nil, err
+	}
+	return c.readReply()
+}
+
+// readReply reads one RESP reply
+func (c *redisConn) readReply() (interface{}, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid bulk length %q", line[1:])
+		}
+		if n < 0 {
+			return nil, errRedisNil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.reader, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid array length %q", line[1:])
+		}
+		if n < 0 {
+			return nil, errRedisNil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			item, err := c.readReply()
+			if err != nil && !errors.Is(err, errRedisNil) {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
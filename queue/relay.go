@@ -0,0 +1,116 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/not7/core/executor"
+	"github.com/not7/core/internal/ui"
+)
+
+// relayRetryDelay is how long a subscriber waits before reconnecting
+const relayRetryDelay = 2 * time.Second
+
+// Relay carries execution events between the processes sharing a queue, so
+// the process that queued an execution hears how it goes on the worker that
+// runs it. Backends whose consumers all share one process do not need it.
+type Relay interface {
+	// Publish sends an event to every subscribed process
+	Publish(ctx context.Context, event executor.Event) error
+
+	// Subscribe calls handle with every published event until ctx is done
+	// or the queue is closed. Events published while it reconnects are lost.
+	Subscribe(ctx context.Context, handle func(executor.Event)) error
+}
+
+// eventsChannel is the Redis channel execution events are published on
+func (q *RedisQueue) eventsChannel() string {
+	return q.prefix + ":events"
+}
+
+// Publish publishes an event on the queue's events channel
+func (q *RedisQueue) Publish(ctx context.Context, event executor.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	if _, err := q.do(ctx, 0, "PUBLISH", q.eventsChannel(), string(data)); err != nil {
+		return fmt.Errorf("failed to publish event of %s: %w", event.ExecutionID, err)
+	}
+	return nil
+}
+
+// Subscribe listens on the queue's events channel over a dedicated
+// connection, reconnecting when it drops
+func (q *RedisQueue) Subscribe(ctx context.Context, handle func(executor.Event)) error {
+	for {
+		err := q.subscribeOnce(ctx, handle)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-q.stop:
+			return ErrClosed
+		default:
+		}
+		ui.Printf("⚠️  Queue: event subscription lost: %v\n", err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-q.stop:
+			return ErrClosed
+		case <-time.After(relayRetryDelay):
+		}
+	}
+}
+
+// subscribeOnce subscribes on a new connection and delivers messages until
+// the connection fails or the subscription is stopped
+func (q *RedisQueue) subscribeOnce(ctx context.Context, handle func(executor.Event)) error {
+	dialCtx, cancel := context.WithTimeout(ctx, redisDialTimeout)
+	conn, err := q.dial(dialCtx)
+	cancel()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.command("SUBSCRIBE", q.eventsChannel()); err != nil {
+		return err
+	}
+	// Messages arrive whenever they are published, so reads wait without a
+	// deadline and closing the connection is what stops them
+	conn.SetDeadline(time.Time{})
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-q.stop:
+		case <-done:
+		}
+		conn.Close()
+	}()
+
+	for {
+		reply, err := conn.readReply()
+		if err != nil {
+			return err
+		}
+		// A message is ["message", channel, payload]
+		items, _ := reply.([]interface{})
+		if len(items) != 3 || items[0] != "message" {
+			continue
+		}
+		payload, _ := items[2].(string)
+
+		var event executor.Event
+		if err := json.Unmarshal([]byte(payload), &event); err != nil {
+			continue
+		}
+		handle(event)
+	}
+}
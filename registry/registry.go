@@ -0,0 +1,293 @@
+// Package registry fetches agent specs from a remote spec registry or an
+// HTTP(S) URL, verifies their SHA-256 checksums and caches them locally.
+//
+// A registry serves each spec version at <base>/<namespace>/<name>/<version>.json
+// with its checksum, as written by sha256sum, next to it at the same path
+// plus ".sha256".
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/not7/core/config"
+)
+
+// Scheme prefixes a registry reference
+const Scheme = "registry://"
+
+// latestVersion is fetched when a reference names no version. It is never
+// read from the cache, since it moves.
+const latestVersion = "latest"
+
+// maxSpecBytes bounds a fetched spec
+const maxSpecBytes = 10 << 20
+
+// refPartPattern matches a namespace, name or version. Parts become cache
+// paths, so separators and ".." are excluded.
+var refPartPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ErrChecksumMismatch is returned when a spec does not match its checksum
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// Ref is a parsed registry://namespace/name@version reference
+type Ref struct {
+	Namespace string
+	Name      string
+	Version   string
+}
+
+// String formats the reference as registry://namespace/name@version
+func (r Ref) String() string {
+	return fmt.Sprintf("%s%s/%s@%s", Scheme, r.Namespace, r.Name, r.Version)
+}
+
+// path returns the reference's path below a registry or cache root
+func (r Ref) path() string {
+	return r.Namespace + "/" + r.Name + "/" + r.Version + ".json"
+}
+
+// IsRemote reports whether source is a registry reference or an HTTP(S)
+// URL rather than a local file
+func IsRemote(source string) bool {
+	return strings.HasPrefix(source, Scheme) || strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://")
+}
+
+// ParseRef parses registry://namespace/name[@version]; the version
+// defaults to latest
+func ParseRef(source string) (Ref, error) {
+	rest, ok := strings.CutPrefix(source, Scheme)
+	if !ok {
+		return Ref{}, fmt.Errorf("%q is not a registry reference", source)
+	}
+
+	ref := Ref{Version: latestVersion}
+	if at := strings.LastIndex(rest, "@"); at >= 0 {
+		rest, ref.Version = rest[:at], rest[at+1:]
+	}
+	namespace, name, ok := strings.Cut(rest, "/")
+	if !ok {
+		return Ref{}, fmt.Errorf("invalid registry reference %q (expected %snamespace/name@version)", source, Scheme)
+	}
+	ref.Namespace, ref.Name = namespace, name
+
+	for _, part := range []string{ref.Namespace, ref.Name, ref.Version} {
+		if !refPartPattern.MatchString(part) {
+			return Ref{}, fmt.Errorf("invalid registry reference %q (expected %snamespace/name@version)", source, Scheme)
+		}
+	}
+	return ref, nil
+}
+
+// Client fetches specs from a registry or URL
+type Client struct {
+	baseURL    string
+	apiKey     string
+	cacheDir   string // "" disables caching
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the registry cfg configures
+func NewClient(cfg config.RegistryConfig) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(cfg.URL, "/"),
+		apiKey:     cfg.APIKey,
+		cacheDir:   cfg.CacheDir,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Fetch returns the verified spec a registry reference or URL points to.
+//
+// Pinned registry versions are immutable, so once fetched they are read
+// from the cache. A URL is verified against a #sha256=<digest> fragment or
+// else the checksum file at the URL plus ".sha256"; one without either is
+// refused. URLs with a digest fragment are cached by that digest.
+func (c *Client) Fetch(ctx context.Context, source string) ([]byte, error) {
+	if strings.HasPrefix(source, Scheme) {
+		ref, err := ParseRef(source)
+		if err != nil {
+			return nil, err
+		}
+		return c.fetchRef(ctx, ref)
+	}
+	return c.fetchURL(ctx, source)
+}
+
+// fetchRef fetches a registry spec through the cache
+func (c *Client) fetchRef(ctx context.Context, ref Ref) ([]byte, error) {
+	if c.baseURL == "" {
+		return nil, fmt.Errorf("no registry configured for %s (set REGISTRY_URL)", ref)
+	}
+
+	cachePath := c.cachePath("registry", filepath.FromSlash(ref.path()))
+	if ref.Version != latestVersion {
+		if data, ok := readCached(cachePath); ok {
+			return data, nil
+		}
+	}
+
+	specURL := c.baseURL + "/" + ref.path()
+	sum, err := c.fetchChecksum(ctx, specURL+".sha256")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch checksum of %s: %w", ref, err)
+	}
+	data, err := c.get(ctx, specURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", ref, err)
+	}
+	if err := verify(data, sum); err != nil {
+		return nil, fmt.Errorf("%s: %w", ref, err)
+	}
+
+	writeCached(cachePath, data, sum)
+	return data, nil
+}
+
+// fetchURL fetches a spec from an HTTP(S) URL
+func (c *Client) fetchURL(ctx context.Context, source string) ([]byte, error) {
+	u, err := url.Parse(source)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid spec URL %q", source)
+	}
+
+	sum, pinned := strings.CutPrefix(u.Fragment, "sha256=")
+	u.Fragment = ""
+	specURL := u.String()
+
+	var cachePath string
+	if pinned {
+		sum = strings.ToLower(sum)
+		if !isDigest(sum) {
+			return nil, fmt.Errorf("invalid sha256 digest in %q", source)
+		}
+		cachePath = c.cachePath("sha256", sum+".json")
+		if data, ok := readCached(cachePath); ok {
+			return data, nil
+		}
+	} else if sum, err = c.fetchChecksum(ctx, specURL+".sha256"); err != nil {
+		return nil, fmt.Errorf("no checksum for %s: add #sha256=<digest> to the URL or publish %s.sha256 (%v)", specURL, specURL, err)
+	}
+
+	data, err := c.get(ctx, specURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", specURL, err)
+	}
+	if err := verify(data, sum); err != nil {
+		return nil, fmt.Errorf("%s: %w", specURL, err)
+	}
+
+	writeCached(cachePath, data, sum)
+	return data, nil
+}
+
+// cachePath returns where a spec is cached, or "" when caching is off
+func (c *Client) cachePath(elem ...string) string {
+	if c.cacheDir == "" {
+		return ""
+	}
+	return filepath.Join(append([]string{c.cacheDir}, elem...)...)
+}
+
+// fetchChecksum reads a sha256sum-style checksum file
+func (c *Client) fetchChecksum(ctx context.Context, checksumURL string) (string, error) {
+	data, err := c.get(ctx, checksumURL)
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 || !isDigest(strings.ToLower(fields[0])) {
+		return "", fmt.Errorf("invalid checksum file %s", checksumURL)
+	}
+	return strings.ToLower(fields[0]), nil
+}
+
+// get downloads a URL, sending the API key to the registry only
+func (c *Client) get(ctx context.Context, target string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.apiKey != "" && c.baseURL != "" && strings.HasPrefix(target, c.baseURL+"/") {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", target, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSpecBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if len(data) > maxSpecBytes {
+		return nil, fmt.Errorf("%s is larger than %d bytes", target, maxSpecBytes)
+	}
+	return data, nil
+}
+
+// verify checks data against a hex SHA-256 digest
+func verify(data []byte, sum string) error {
+	actual := sha256.Sum256(data)
+	if got := hex.EncodeToString(actual[:]); got != sum {
+		return fmt.Errorf("%w: expected sha256 %s, got %s", ErrChecksumMismatch, sum, got)
+	}
+	return nil
+}
+
+// isDigest reports whether s is a lowercase hex SHA-256 digest
+func isDigest(s string) bool {
+	if len(s) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// readCached returns a cached spec if it still matches the checksum stored
+// with it. A corrupt entry is treated as missing and fetched again.
+func readCached(path string) ([]byte, bool) {
+	if path == "" {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	sum, err := os.ReadFile(path + ".sha256")
+	if err != nil || verify(data, strings.TrimSpace(string(sum))) != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// writeCached stores a verified spec and its checksum. Caching is best
+// effort; a spec that could not be cached is fetched again next time.
+func writeCached(path string, data []byte, sum string) {
+	if path == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return
+	}
+	os.WriteFile(path+".sha256", []byte(sum+"\n"), 0644)
+}
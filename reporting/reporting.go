@@ -0,0 +1,146 @@
+// Package reporting sends panics, failed executions and LLM provider errors
+// to Sentry and/or a generic webhook, so operators hear about failures
+// without watching the server output.
+//
+// Like tracing, reporting is process-wide: the server calls Setup once and
+// packages call Send. Until Setup configures a destination, Send does nothing.
+package reporting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/not7/core/config"
+	"github.com/not7/core/webhook"
+)
+
+// sendTimeout bounds delivery to a single destination
+const sendTimeout = 5 * time.Second
+
+// Report kinds
+const (
+	KindPanic           = "panic"
+	KindExecutionFailed = "execution_failed"
+	KindProviderError   = "provider_error"
+)
+
+// Report describes a single failure. It is also the JSON body posted to the
+// error webhook.
+type Report struct {
+	Kind        string    `json:"kind"`
+	Message     string    `json:"message"`
+	ExecutionID string    `json:"execution_id,omitempty"`
+	RequestID   string    `json:"request_id,omitempty"`
+	AgentID     string    `json:"agent_id,omitempty"`
+	NodeID      string    `json:"node_id,omitempty"`
+	Provider    string    `json:"provider,omitempty"`
+	StatusCode  int       `json:"status_code,omitempty"` // provider HTTP status
+	Stack       string    `json:"stack,omitempty"`       // panics only
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// sink delivers reports to one destination
+type sink interface {
+	send(ctx context.Context, report Report) error
+}
+
+var (
+	mu    sync.RWMutex
+	sinks []sink
+)
+
+// Setup configures the destinations in cfg. With neither a Sentry DSN nor a
+// webhook URL, reporting stays disabled.
+func Setup(cfg config.ReportingConfig) error {
+	var configured []sink
+
+	if cfg.SentryDSN != "" {
+		s, err := newSentrySink(cfg.SentryDSN)
+		if err != nil {
+			return err
+		}
+		configured = append(configured, s)
+	}
+	if cfg.WebhookURL != "" {
+		configured = append(configured, &webhookSink{url: cfg.WebhookURL, secret: cfg.WebhookSecret})
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	sinks = configured
+	return nil
+}
+
+// Enabled reports whether any destination is configured
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return len(sinks) > 0
+}
+
+// Send delivers report to every configured destination and returns the
+// delivery failures, if any. Timestamp defaults to now.
+func Send(ctx context.Context, report Report) error {
+	mu.RLock()
+	targets := sinks
+	mu.RUnlock()
+
+	if report.Timestamp.IsZero() {
+		report.Timestamp = time.Now().UTC()
+	}
+
+	var errs []error
+	for _, target := range targets {
+		sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+		if err := target.send(sendCtx, report); err != nil {
+			errs = append(errs, err)
+		}
+		cancel()
+	}
+	return errors.Join(errs...)
+}
+
+// webhookSink posts each report as JSON to a URL, signed with secret when
+// one is set
+type webhookSink struct {
+	url    string
+	secret string
+}
+
+func (s *webhookSink) send(ctx context.Context, report Report) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode error report: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	webhook.Sign(req, s.secret, body)
+
+	return post(req, "error webhook")
+}
+
+// post sends req and treats any non-2xx response as a failure
+func post(req *http.Request, destination string) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %w", destination, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s rejected report (status %d): %s", destination, resp.StatusCode, string(body))
+	}
+	return nil
+}
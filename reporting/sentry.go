@@ -0,0 +1,126 @@
+package reporting
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// sentrySink sends reports as events to Sentry's envelope endpoint. The
+// protocol is small enough that the Sentry SDK is not needed.
+type sentrySink struct {
+	dsn       string
+	endpoint  string // https://host/api/{project}/envelope/
+	publicKey string
+}
+
+// newSentrySink parses a DSN of the form https://{public_key}@{host}/{project_id}
+func newSentrySink(dsn string) (*sentrySink, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Host == "" || u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN")
+	}
+
+	path := strings.Trim(u.Path, "/")
+	prefix, project := "", path
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		prefix, project = "/"+path[:i], path[i+1:]
+	}
+	if project == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN: missing project ID")
+	}
+
+	return &sentrySink{
+		dsn:       dsn,
+		endpoint:  fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, prefix, project),
+		publicKey: u.User.Username(),
+	}, nil
+}
+
+// sentryEvent is the subset of the Sentry event payload NOT7 fills in
+type sentryEvent struct {
+	EventID   string                 `json:"event_id"`
+	Timestamp string                 `json:"timestamp"`
+	Platform  string                 `json:"platform"`
+	Level     string                 `json:"level"`
+	Logger    string                 `json:"logger"`
+	Message   string                 `json:"message"`
+	Tags      map[string]string      `json:"tags"`
+	Extra     map[string]interface{} `json:"extra,omitempty"`
+}
+
+func (s *sentrySink) send(ctx context.Context, report Report) error {
+	eventID, err := newEventID()
+	if err != nil {
+		return err
+	}
+
+	event := sentryEvent{
+		EventID:   eventID,
+		Timestamp: report.Timestamp.Format(time.RFC3339Nano),
+		Platform:  "go",
+		Level:     "error",
+		Logger:    "not7",
+		Message:   report.Message,
+		Tags:      map[string]string{"kind": report.Kind},
+		Extra:     map[string]interface{}{},
+	}
+	if report.Kind == KindPanic {
+		event.Level = "fatal"
+	}
+	for key, value := range map[string]string{
+		"execution_id": report.ExecutionID,
+		"request_id":   report.RequestID,
+		"agent_id":     report.AgentID,
+		"node_id":      report.NodeID,
+		"provider":     report.Provider,
+	} {
+		if value != "" {
+			event.Tags[key] = value
+		}
+	}
+	if report.StatusCode != 0 {
+		event.Extra["status_code"] = report.StatusCode
+	}
+	if report.Stack != "" {
+		event.Extra["stack"] = report.Stack
+	}
+
+	// An envelope is newline-separated JSON: envelope header, item header, item
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, part := range []interface{}{
+		map[string]string{"event_id": eventID, "dsn": s.dsn, "sent_at": time.Now().UTC().Format(time.RFC3339Nano)},
+		map[string]string{"type": "event"},
+		event,
+	} {
+		if err := enc.Encode(part); err != nil {
+			return fmt.Errorf("failed to encode Sentry event: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create Sentry request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=not7, sentry_key=%s", s.publicKey))
+
+	return post(req, "Sentry")
+}
+
+// newEventID returns a random 32-character hex event ID
+func newEventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate event ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,185 @@
+// Package not7 runs NOT7 agents in-process, for programs that embed the
+// runtime as a library instead of calling a server. It lives in the sdk
+// directory, since the module root holds the CLI:
+//
+//	import not7 "github.com/not7/core/sdk"
+//
+//	agentSpec, err := spec.LoadSpec("agent.json")
+//	...
+//	result, err := not7.Run(ctx, agentSpec, not7.Options{Input: "..."})
+//
+// Run reads its settings from Options alone: it prints nothing, writes no
+// files of its own and recovers panics. Node types and hooks are added with
+// executor.RegisterNodeType and executor.RegisterHook. To keep executions,
+// logs and artifacts, use execution.Manager or client.NewEmbeddedClient.
+package not7
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/not7/core/config"
+	"github.com/not7/core/execution"
+	"github.com/not7/core/executor"
+	"github.com/not7/core/spec"
+)
+
+var (
+	// ErrInvalidSpec is wrapped by errors for specs that fail validation
+	ErrInvalidSpec = execution.ErrInvalidSpec
+
+	// ErrInvalidInput is wrapped by errors for input the spec does not accept
+	ErrInvalidInput = execution.ErrInvalidInput
+
+	// ErrPolicyViolation is wrapped by errors for agents that do something
+	// the config's policy denies
+	ErrPolicyViolation = execution.ErrPolicyViolation
+)
+
+// Options configures a run. The zero value runs with the config of the
+// environment and no input.
+type Options struct {
+	// Config supplies the LLM and tool provider settings. When nil, the
+	// config is read from environment variables such as OPENAI_API_KEY
+	// (see config.FromEnv); config.Default is a starting point for
+	// building one in code.
+	Config *config.Config
+
+	// Input is delivered to the agent's first node(s)
+	Input string
+
+	// Vars override the spec's vars (see spec.AgentSpec.Render)
+	Vars map[string]string
+
+	// Attachments are files the agent reads with the ReadAttachment tool
+	Attachments []executor.Attachment
+
+	// PromptsDir is where prompt_ref files are looked up. It defaults to
+	// the config's SERVER_PROMPTS_DIR.
+	PromptsDir string
+
+	// Timeout cancels the run after this long; 0 for no limit beyond ctx
+	Timeout time.Duration
+
+	// Logger receives the run's log lines; nil discards them. The lines of
+	// each node are kept in its result either way.
+	Logger executor.Logger
+
+	// OnEvent receives progress events as nodes start and finish
+	OnEvent executor.EventHandler
+}
+
+// Result is the outcome of a run
+type Result struct {
+	// Output is the agent's final output
+	Output string
+
+	// Metadata holds the status, duration, cost, token counts and the
+	// result of every node
+	Metadata *spec.Metadata
+}
+
+// Run executes an agent and waits for it to finish. agentSpec is not
+// modified. A spec or input that fails validation returns an error
+// wrapping ErrInvalidSpec or ErrInvalidInput and no result; an agent that
+// fails while running returns its result so far along with the error.
+func Run(ctx context.Context, agentSpec *spec.AgentSpec, opts Options) (*Result, error) {
+	if agentSpec == nil {
+		return nil, fmt.Errorf("%w: spec is required", ErrInvalidSpec)
+	}
+	cfg := opts.Config
+	if cfg == nil {
+		var err error
+		if cfg, err = config.FromEnv(); err != nil {
+			return nil, fmt.Errorf("failed to read config from the environment: %w", err)
+		}
+	}
+
+	agentSpec, err := prepare(agentSpec, cfg, &opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var exec *executor.Executor
+	if opts.Logger != nil {
+		exec, err = executor.NewExecutorWithLogger(agentSpec, cfg, opts.Logger)
+	} else {
+		exec, err = executor.NewExecutor(agentSpec, cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create executor: %w", err)
+	}
+	if opts.OnEvent != nil {
+		exec.SetEventHandler(opts.OnEvent)
+	}
+	if err := exec.SetAttachments(opts.Attachments); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	output, err := execute(ctx, exec, opts.Input)
+	return &Result{Output: output, Metadata: exec.GetMetadata()}, err
+}
+
+// prepare returns a copy of the spec with its prompt files read and vars
+// rendered, after validating it and the run's input
+func prepare(agentSpec *spec.AgentSpec, cfg *config.Config, opts *Options) (*spec.AgentSpec, error) {
+	agentSpec, err := clone(agentSpec)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+	}
+
+	promptsDir := opts.PromptsDir
+	if promptsDir == "" {
+		promptsDir = cfg.Server.PromptsDir
+	}
+	if err := agentSpec.ResolvePrompts(promptsDir); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+	}
+	if err := agentSpec.Render(opts.Vars); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+	}
+	if err := spec.ValidateSpec(agentSpec); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+	}
+
+	input, err := spec.ApplyInputDefaults(agentSpec, opts.Input)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	if err := spec.ValidateInput(agentSpec, input); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	opts.Input = input
+	return agentSpec, nil
+}
+
+// execute runs the executor, turning a panic outside the nodes, which
+// recover their own, into a *executor.PanicError
+func execute(ctx context.Context, exec *executor.Executor, input string) (output string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = executor.NewPanicError(r)
+		}
+	}()
+	return exec.ExecuteContext(ctx, input)
+}
+
+// clone deep-copies a spec, so running it leaves the caller's untouched
+func clone(agentSpec *spec.AgentSpec) (*spec.AgentSpec, error) {
+	data, err := json.Marshal(agentSpec)
+	if err != nil {
+		return nil, err
+	}
+	var copied spec.AgentSpec
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil, err
+	}
+	return &copied, nil
+}
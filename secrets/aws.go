@@ -0,0 +1,157 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// awsService is the SigV4 service name of AWS Secrets Manager
+const awsService = "secretsmanager"
+
+// resolveAWS reads a secret from AWS Secrets Manager. The path is a secret
+// name or ARN; credentials come from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY
+// and the optional AWS_SESSION_TOKEN, the region from the ARN or AWS_REGION.
+func resolveAWS(ctx context.Context, secretID string) (string, error) {
+	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return "", fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+
+	region := awsRegion(secretID)
+	if region == "" {
+		return "", fmt.Errorf("AWS region is unknown (set AWS_REGION or use a secret ARN)")
+	}
+
+	body, err := json.Marshal(map[string]string{"SecretId": secretID})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	host := fmt.Sprintf("%s.%s.amazonaws.com", awsService, region)
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://"+host+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+	signAWSRequest(req, host, body, accessKey, secretKey, region, awsService, time.Now())
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach AWS Secrets Manager: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("AWS error (status %d): %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var secret struct {
+		SecretString *string `json:"SecretString"`
+		SecretBinary string  `json:"SecretBinary"`
+	}
+	if err := json.Unmarshal(respBody, &secret); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	if secret.SecretString != nil {
+		return *secret.SecretString, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(secret.SecretBinary)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode binary secret: %w", err)
+	}
+	return string(data), nil
+}
+
+// awsRegion returns the region of an ARN (arn:aws:secretsmanager:<region>:...)
+// or the region configured in the environment
+func awsRegion(secretID string) string {
+	if parts := strings.Split(secretID, ":"); len(parts) > 3 && parts[0] == "arn" {
+		return parts[3]
+	}
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+	return os.Getenv("AWS_DEFAULT_REGION")
+}
+
+// signAWSRequest adds a Signature Version 4 Authorization header to req
+func signAWSRequest(req *http.Request, host string, body []byte, accessKey, secretKey, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		"/",
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(body),
+	}, "\n")
+
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,122 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// Encrypted values are age ciphertexts wrapped sops-style as
+// ENC[age:<base64>], so the key they belong to stays readable in diffs.
+// They are decrypted with the identities in NOT7_AGE_KEY or the file
+// named by NOT7_AGE_KEY_FILE (the format written by age-keygen).
+const (
+	encryptedPrefix = "ENC[age:"
+	encryptedSuffix = "]"
+)
+
+// ErrNoDecryptionKey is returned when an encrypted value is found but no
+// age identity is configured
+var ErrNoDecryptionKey = errors.New("no decryption key (set NOT7_AGE_KEY or NOT7_AGE_KEY_FILE)")
+
+// IsEncrypted reports whether value is an ENC[age:...] value
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix) && strings.HasSuffix(value, encryptedSuffix)
+}
+
+// Encrypt encrypts plaintext to the given age recipients (age1...) and
+// returns it as an ENC[age:...] value
+func Encrypt(plaintext string, recipients []string) (string, error) {
+	if len(recipients) == 0 {
+		return "", fmt.Errorf("at least one age recipient is required")
+	}
+
+	parsed := make([]age.Recipient, 0, len(recipients))
+	for _, recipient := range recipients {
+		r, err := age.ParseX25519Recipient(strings.TrimSpace(recipient))
+		if err != nil {
+			return "", fmt.Errorf("invalid recipient %q: %w", recipient, err)
+		}
+		parsed = append(parsed, r)
+	}
+
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, parsed...)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt: %w", err)
+	}
+	if _, err := io.WriteString(w, plaintext); err != nil {
+		return "", fmt.Errorf("failed to encrypt: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("failed to encrypt: %w", err)
+	}
+
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(buf.Bytes()) + encryptedSuffix, nil
+}
+
+// GenerateKey creates an age key pair, returning the identity (secret key)
+// and its recipient (public key)
+func GenerateKey() (identity, recipient string, err error) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	return id.String(), id.Recipient().String(), nil
+}
+
+// Decrypt decrypts an ENC[age:...] value with the configured identities
+func Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", fmt.Errorf("value is not ENC[age:...] encrypted")
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(
+		strings.TrimSuffix(strings.TrimPrefix(value, encryptedPrefix), encryptedSuffix))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %w", err)
+	}
+
+	identities, err := loadIdentities()
+	if err != nil {
+		return "", err
+	}
+
+	r, err := age.Decrypt(bytes.NewReader(ciphertext), identities...)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt: %w", err)
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// loadIdentities reads the age identities from NOT7_AGE_KEY or NOT7_AGE_KEY_FILE
+func loadIdentities() ([]age.Identity, error) {
+	keys := os.Getenv("NOT7_AGE_KEY")
+	if keys == "" {
+		path := os.Getenv("NOT7_AGE_KEY_FILE")
+		if path == "" {
+			return nil, ErrNoDecryptionKey
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read NOT7_AGE_KEY_FILE: %w", err)
+		}
+		keys = string(data)
+	}
+
+	identities, err := age.ParseIdentities(strings.NewReader(keys))
+	if err != nil {
+		return nil, fmt.Errorf("invalid age identity: %w", err)
+	}
+	return identities, nil
+}
@@ -0,0 +1,106 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	gcpSecretManagerURL = "https://secretmanager.googleapis.com/v1/"
+	gcpMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// resolveGCP reads a secret version from Google Cloud Secret Manager. The
+// path is projects/<project>/secrets/<name>[/versions/<version>], defaulting
+// to the latest version. The access token comes from GOOGLE_OAUTH_ACCESS_TOKEN
+// or, on GCP, from the metadata server.
+func resolveGCP(ctx context.Context, name string) (string, error) {
+	name = strings.Trim(name, "/")
+	if !strings.HasPrefix(name, "projects/") {
+		return "", fmt.Errorf("expected projects/<project>/secrets/<name>")
+	}
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	token, err := gcpAccessToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", gcpSecretManagerURL+name+":access", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	body, err := doGCPRequest(req)
+	if err != nil {
+		return "", err
+	}
+
+	var secret struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(secret.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret payload: %w", err)
+	}
+	return string(data), nil
+}
+
+// gcpAccessToken returns an OAuth token for Secret Manager
+func gcpAccessToken(ctx context.Context) (string, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", gcpMetadataTokenURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	body, err := doGCPRequest(req)
+	if err != nil {
+		return "", fmt.Errorf("no GCP credentials (set GOOGLE_OAUTH_ACCESS_TOKEN or run on GCP): %w", err)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("failed to parse token response: %w", err)
+	}
+	return token.AccessToken, nil
+}
+
+// doGCPRequest sends req and returns the body of a successful response
+func doGCPRequest(req *http.Request) ([]byte, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GCP error (status %d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
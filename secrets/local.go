@@ -0,0 +1,28 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// resolveEnv reads a secret from an environment variable
+func resolveEnv(ctx context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// resolveFile reads a secret from a file, such as a Docker or Kubernetes
+// secret mount. A single trailing newline is dropped.
+func resolveFile(ctx context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	value := strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(value, "\r"), nil
+}
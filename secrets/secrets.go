@@ -0,0 +1,149 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A reference has the form <scheme>://<path>[#<key>], for example:
+//
+//	env://OPENAI_API_KEY
+//	file:///run/secrets/openai_api_key
+//	vault://secret/data/not7#openai_api_key
+//	aws-sm://not7/prod#openai_api_key
+//	gcp-sm://projects/my-project/secrets/openai-api-key
+//
+// The optional key selects a field when the secret holds a JSON object.
+
+// ErrUnknownScheme is returned when a reference names an unregistered backend
+var ErrUnknownScheme = errors.New("unknown secret scheme")
+
+// Resolver fetches a secret from a backend. The path is the part of the
+// reference after "<scheme>://" with any "#key" removed.
+type Resolver interface {
+	Resolve(ctx context.Context, path string) (string, error)
+}
+
+// ResolverFunc adapts a function to the Resolver interface
+type ResolverFunc func(ctx context.Context, path string) (string, error)
+
+// Resolve calls f(ctx, path)
+func (f ResolverFunc) Resolve(ctx context.Context, path string) (string, error) {
+	return f(ctx, path)
+}
+
+// httpClient is shared by the network backends
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+var (
+	resolvers = map[string]Resolver{
+		"env":    ResolverFunc(resolveEnv),
+		"file":   ResolverFunc(resolveFile),
+		"vault":  ResolverFunc(resolveVault),
+		"aws-sm": ResolverFunc(resolveAWS),
+		"gcp-sm": ResolverFunc(resolveGCP),
+	}
+	resolversMu sync.RWMutex
+)
+
+// Register adds or replaces the resolver for a scheme
+func Register(scheme string, resolver Resolver) {
+	resolversMu.Lock()
+	defer resolversMu.Unlock()
+	resolvers[strings.ToLower(scheme)] = resolver
+}
+
+// Schemes returns the registered scheme names, sorted
+func Schemes() []string {
+	resolversMu.RLock()
+	defer resolversMu.RUnlock()
+
+	schemes := make([]string, 0, len(resolvers))
+	for scheme := range resolvers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// IsReference reports whether value is a reference to a registered backend.
+// Plain values, including http:// URLs, are not references.
+func IsReference(value string) bool {
+	scheme, _, ok := strings.Cut(value, "://")
+	if !ok {
+		return false
+	}
+	_, ok = lookup(scheme)
+	return ok
+}
+
+// Resolve returns the secret a reference points to. Values that are not
+// references are returned unchanged.
+func Resolve(ctx context.Context, value string) (string, error) {
+	scheme, rest, ok := strings.Cut(value, "://")
+	if !ok {
+		return value, nil
+	}
+	resolver, ok := lookup(scheme)
+	if !ok {
+		return value, nil
+	}
+
+	path, key, _ := strings.Cut(rest, "#")
+	if path == "" {
+		return "", fmt.Errorf("%s:// reference has no path", scheme)
+	}
+
+	secret, err := resolver.Resolve(ctx, path)
+	if err != nil {
+		return "", fmt.Errorf("%s://%s: %w", scheme, path, err)
+	}
+
+	if key == "" {
+		return secret, nil
+	}
+	field, err := selectKey(secret, key)
+	if err != nil {
+		return "", fmt.Errorf("%s://%s: %w", scheme, path, err)
+	}
+	return field, nil
+}
+
+// lookup finds the resolver registered for scheme
+func lookup(scheme string) (Resolver, bool) {
+	resolversMu.RLock()
+	defer resolversMu.RUnlock()
+	resolver, ok := resolvers[strings.ToLower(scheme)]
+	return resolver, ok
+}
+
+// selectKey extracts a field from a secret holding a JSON object
+func selectKey(secret, key string) (string, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object, cannot select key %q", key)
+	}
+	return fieldString(fields, key)
+}
+
+// fieldString returns fields[key] as a string
+func fieldString(fields map[string]interface{}, key string) (string, error) {
+	value, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("key %q not found in secret", key)
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64, bool:
+		return fmt.Sprint(v), nil
+	}
+	return "", fmt.Errorf("key %q is not a scalar value", key)
+}
@@ -0,0 +1,74 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// defaultVaultAddr matches the Vault CLI default
+const defaultVaultAddr = "http://127.0.0.1:8200"
+
+// resolveVault reads a secret from HashiCorp Vault using VAULT_ADDR,
+// VAULT_TOKEN and the optional VAULT_NAMESPACE. The path is the API path
+// under /v1, e.g. secret/data/not7 for a KV v2 mount. The secret's fields
+// are returned as a JSON object; select one with #key.
+func resolveVault(ctx context.Context, path string) (string, error) {
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		return "", fmt.Errorf("VAULT_TOKEN is not set")
+	}
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		addr = defaultVaultAddr
+	}
+
+	url := strings.TrimSuffix(addr, "/") + "/v1/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach Vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Vault error (status %d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	// KV v2 nests the fields under data.data alongside data.metadata
+	fields := secret.Data
+	if nested, ok := fields["data"].(map[string]interface{}); ok {
+		if _, hasMetadata := fields["metadata"]; hasMetadata {
+			fields = nested
+		}
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode secret: %w", err)
+	}
+	return string(data), nil
+}
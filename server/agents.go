@@ -0,0 +1,258 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/not7/core/execution"
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/spec"
+)
+
+var (
+	errAgentNotFound = errors.New("agent not found")
+	errAgentExists   = errors.New("agent already exists")
+)
+
+// agentIDPattern restricts agent IDs to names that are safe as file names
+var agentIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// NewAgentInfo summarizes a deployed agent for the agents listing
+func NewAgentInfo(agentSpec *spec.AgentSpec, revision int, createdAt, updatedAt time.Time) AgentInfo {
+	return AgentInfo{
+		ID:         agentSpec.ID,
+		Goal:       agentSpec.Goal,
+		Owner:      agentSpec.Owner,
+		Tags:       agentSpec.Tags,
+		Revision:   revision,
+		CreatedAt:  createdAt.Format(time.RFC3339),
+		UpdatedAt:  updatedAt.Format(time.RFC3339),
+		Inputs:     agentSpec.RequiredInputs(),
+		Parameters: agentSpec.InputParameters(),
+	}
+}
+
+// parseAgentFilter builds an agent filter from query parameters
+func parseAgentFilter(query url.Values) AgentFilter {
+	return AgentFilter{
+		Query: strings.TrimSpace(query.Get("q")),
+		Owner: query.Get("owner"),
+		Tags:  query["tag"],
+	}
+}
+
+// handleAgents handles the agent registry:
+//
+//	GET    /api/v1/agents           - list deployed agents, narrowed by q
+//	                                  (text in the ID, goal or description),
+//	                                  owner and tag (repeatable)
+//	POST   /api/v1/agents           - deploy an agent
+//	GET    /api/v1/agents/{id}      - get an agent's spec
+//	PUT    /api/v1/agents/{id}      - replace an agent's spec, or with
+//	                                  ?canary= start a canary of it (see
+//	                                  handleCanary)
+//	DELETE /api/v1/agents/{id}      - delete an agent
+//	POST   /api/v1/agents/{id}/run  - run an agent (see runDeployedAgent)
+func (s *Server) handleAgents(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/agents"), "/")
+	id, sub, _ := strings.Cut(path, "/")
+	if id != "" && !agentIDPattern.MatchString(id) {
+		respondError(w, id, "Agent not found", http.StatusNotFound)
+		return
+	}
+
+	if sub != "" {
+		resource, action, _ := strings.Cut(sub, "/")
+		switch {
+		case resource == "canary":
+			s.handleCanary(w, r, id, action)
+		case resource == "run" && action == "":
+			s.runDeployedAgent(w, r, id)
+		default:
+			respondError(w, id, "Not found", http.StatusNotFound)
+		}
+		return
+	}
+
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		s.listAgents(w, r)
+	case id == "" && r.Method == http.MethodPost:
+		s.saveAgent(w, r, "", false)
+	case id != "" && r.Method == http.MethodGet:
+		s.getAgent(w, id)
+	case id != "" && r.Method == http.MethodPut:
+		s.saveAgent(w, r, id, true)
+	case id != "" && r.Method == http.MethodDelete:
+		s.deleteAgent(w, id)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) listAgents(w http.ResponseWriter, r *http.Request) {
+	agents, err := s.agents.search(parseAgentFilter(r.URL.Query()))
+	if err != nil {
+		respondError(w, "", fmt.Sprintf("Failed to list agents: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(AgentListResponse{Agents: agents, Count: len(agents)})
+}
+
+func (s *Server) getAgent(w http.ResponseWriter, id string) {
+	agentSpec, err := s.agents.get(id)
+	if err != nil {
+		respondAgentError(w, id, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(agentSpec)
+}
+
+// saveAgent deploys (POST) or replaces (PUT) an agent. For PUT the ID comes
+// from the path and must match the spec's, if the spec sets one, and a
+// canary query parameter deploys the spec as a canary instead.
+func (s *Server) saveAgent(w http.ResponseWriter, r *http.Request, id string, replace bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondError(w, id, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	agentSpec, err := spec.Parse(body)
+	if err != nil {
+		respondError(w, id, fmt.Sprintf("Invalid JSON specification: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if id != "" {
+		if agentSpec.ID != "" && agentSpec.ID != id {
+			respondError(w, id, fmt.Sprintf("Spec id %q does not match the agent %q", agentSpec.ID, id), http.StatusBadRequest)
+			return
+		}
+		agentSpec.ID = id
+	}
+	if !agentIDPattern.MatchString(agentSpec.ID) {
+		respondError(w, id, "Agent id is required and may only contain letters, digits, '.', '_' and '-'", http.StatusBadRequest)
+		return
+	}
+	if err := spec.ValidateSpec(agentSpec); err != nil {
+		respondError(w, agentSpec.ID, fmt.Sprintf("Invalid agent specification: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if replace && r.URL.Query().Has("canary") {
+		s.startCanary(w, r, agentSpec)
+		return
+	}
+
+	info, err := s.agents.save(agentSpec, replace)
+	if err != nil {
+		respondAgentError(w, agentSpec.ID, err)
+		return
+	}
+
+	status := http.StatusCreated
+	if replace {
+		status = http.StatusOK
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(info)
+}
+
+// runDeployedAgent runs a deployed agent, or its canary for the canary's
+// share of runs. The body is the run input, checked against the agent's
+// input_schema; a JSON string body is the text it holds. Besides the run
+// options of /api/v1/run, the query takes var and tag as name=value
+// (repeatable).
+func (s *Server) runDeployedAgent(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondError(w, id, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	input := strings.TrimSpace(string(body))
+	var text string
+	if json.Unmarshal(body, &text) == nil {
+		input = text
+	}
+
+	query := r.URL.Query()
+	vars, err := parseNameValues(query["var"])
+	if err != nil {
+		respondError(w, id, fmt.Sprintf("invalid var: %v", err), http.StatusBadRequest)
+		return
+	}
+	tags, err := parseNameValues(query["tag"])
+	if err != nil {
+		respondError(w, id, fmt.Sprintf("invalid tag: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	agentSpec, revision, canaryStarted, err := s.agents.resolve(id)
+	if err != nil {
+		respondAgentError(w, id, err)
+		return
+	}
+	for _, issue := range agentSpec.Warnings() {
+		ui.Infof("[API] ⚠️  %s\n", issue)
+	}
+
+	s.startRun(w, r, agentSpec, id, revision, canaryStarted, execution.Options{Input: input, Vars: vars, Tags: tags})
+}
+
+// parseNameValues parses name=value query parameters into a map
+func parseNameValues(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	parsed := make(map[string]string, len(values))
+	for _, v := range values {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("expected name=value: %s", v)
+		}
+		parsed[name] = value
+	}
+	return parsed, nil
+}
+
+func (s *Server) deleteAgent(w http.ResponseWriter, id string) {
+	if err := s.agents.delete(id); err != nil {
+		respondAgentError(w, id, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respondAgentError maps agent store errors to HTTP statuses
+func respondAgentError(w http.ResponseWriter, id string, err error) {
+	switch {
+	case errors.Is(err, errAgentNotFound):
+		respondError(w, id, "Agent not found", http.StatusNotFound)
+	case errors.Is(err, errNoCanary):
+		respondError(w, id, "Agent has no canary", http.StatusNotFound)
+	case errors.Is(err, errAgentExists):
+		respondError(w, id, "Agent already exists (use PUT /api/v1/agents/{id} to update it)", http.StatusConflict)
+	default:
+		respondError(w, id, fmt.Sprintf("Agent storage failed: %v", err), http.StatusInternalServerError)
+	}
+}
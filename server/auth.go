@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// anonymousClient is the client of every request to a server without API keys
+const anonymousClient = "anonymous"
+
+// clientContextKey stores the name of the request's client in its context
+type clientContextKey struct{}
+
+// withClient identifies the client of an API request by its API key, sent
+// as "Authorization: Bearer <key>". Once any client is configured, requests
+// without a known key are refused; until then every request is anonymous.
+func (s *Server) withClient(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		client := anonymousClient
+		if len(s.clients) > 0 {
+			name, ok := s.clientOf(r)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="not7"`)
+				respondError(w, "", "Missing or invalid API key", http.StatusUnauthorized)
+				return
+			}
+			client = name
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), clientContextKey{}, client)))
+	}
+}
+
+// withViewer is withClient for pages opened in a browser. Browsers cannot
+// send a bearer token from a link, so a refused request asks for basic auth
+// instead, with the API key as the password.
+func (s *Server) withViewer(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		client := anonymousClient
+		if len(s.clients) > 0 {
+			name, ok := s.clientOf(r)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Basic realm="not7"`)
+				http.Error(w, "Missing or invalid API key", http.StatusUnauthorized)
+				return
+			}
+			client = name
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), clientContextKey{}, client)))
+	}
+}
+
+// clientOf returns the client whose API key a request bears, as a bearer
+// token or a basic auth password. Every key is compared in constant time,
+// so response times do not reveal near misses.
+func (s *Server) clientOf(r *http.Request) (string, bool) {
+	key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		_, key, ok = r.BasicAuth()
+	}
+	if !ok || key == "" {
+		return "", false
+	}
+	found := ""
+	for name, client := range s.clients {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(client.APIKey)) == 1 {
+			found = name
+		}
+	}
+	return found, found != ""
+}
+
+// clientFrom returns the client identified by withClient
+func clientFrom(ctx context.Context) string {
+	if client, ok := ctx.Value(clientContextKey{}).(string); ok {
+		return client
+	}
+	return anonymousClient
+}
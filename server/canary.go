@@ -0,0 +1,297 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/spec"
+)
+
+var errNoCanary = errors.New("agent has no canary")
+
+// Defaults for a canary rollout started without canary_runs or
+// canary_max_failure_rate
+const (
+	defaultCanaryRuns           = 20
+	defaultCanaryMaxFailureRate = 0.1
+)
+
+// Outcomes of a canary run, as returned by recordCanary
+const (
+	canaryPending    = ""
+	canaryPromoted   = "promoted"
+	canaryRolledBack = "rolled back"
+)
+
+// canaryRecord is a canary as stored in the registry, and as read from the
+// canary/<id>.json files of older versions
+type canaryRecord struct {
+	CanaryStatus
+	Spec *spec.AgentSpec `json:"spec"`
+}
+
+// startCanary stores agentSpec as a canary of the deployed agent with the
+// same ID, replacing any canary already running. status supplies the
+// rollout settings; its counters are reset. The canary has the agent's next
+// revision, which promoting it stores it as: a deploy in between discards
+// the canary.
+func (s *agentStore) startCanary(agentSpec *spec.AgentSpec, status CanaryStatus) (*CanaryStatus, error) {
+	err := s.db.update(func(tx registryTx) error {
+		agent, err := tx.agent(agentSpec.ID)
+		if err != nil {
+			return err
+		}
+
+		status.AgentID = agentSpec.ID
+		status.Revision = agent.Revision + 1
+		status.Runs, status.Failures = 0, 0
+		status.StartedAt = time.Now().UTC()
+		return tx.putCanary(&canaryRecord{CanaryStatus: status, Spec: agentSpec})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// canary returns the status of an agent's canary
+func (s *agentStore) canary(id string) (status *CanaryStatus, err error) {
+	err = s.db.view(func(tx registryTx) error {
+		c, err := tx.canary(id)
+		if err != nil {
+			return err
+		}
+		status = &c.CanaryStatus
+		return nil
+	})
+	return status, err
+}
+
+// resolve returns the spec that a run of the agent should use, and its
+// revision: the canary's for its share of runs, otherwise the deployed one.
+// started identifies the canary that was picked, for recordCanary, and is
+// zero for the deployed spec.
+func (s *agentStore) resolve(id string) (agentSpec *spec.AgentSpec, revision int, started time.Time, err error) {
+	err = s.db.view(func(tx registryTx) error {
+		agent, err := tx.agent(id)
+		if err != nil {
+			return err
+		}
+		agentSpec, revision = agent.Spec, agent.Revision
+
+		c, err := tx.canary(id)
+		switch {
+		case err == nil:
+			if rand.Float64()*100 < c.Percent {
+				agentSpec, started = c.Spec, c.StartedAt
+				// Canaries started before they had revisions have none
+				revision = max(c.Revision, agent.Revision+1)
+			}
+		case !errors.Is(err, errNoCanary):
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, time.Time{}, err
+	}
+	return agentSpec, revision, started, nil
+}
+
+// recordCanary counts a finished run of the canary started at started. Once
+// the canary has had its runs it is promoted, or rolled back if too many
+// failed; it is rolled back early as soon as that is certain. Runs of a
+// canary that has since been replaced or ended are ignored.
+func (s *agentStore) recordCanary(id string, started time.Time, failed bool) (outcome string, err error) {
+	err = s.db.update(func(tx registryTx) error {
+		c, err := tx.canary(id)
+		if errors.Is(err, errNoCanary) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !c.StartedAt.Equal(started) {
+			return nil
+		}
+
+		c.Runs++
+		if failed {
+			c.Failures++
+		}
+
+		allowed := c.MaxFailureRate * float64(c.MinRuns)
+		switch {
+		case float64(c.Failures) > allowed:
+			outcome = canaryRolledBack
+			return tx.removeCanary(id)
+		case c.Runs >= c.MinRuns:
+			outcome = canaryPromoted
+			return promoteCanary(tx, c)
+		default:
+			return tx.putCanary(c)
+		}
+	})
+	if err != nil {
+		return canaryPending, err
+	}
+	return outcome, nil
+}
+
+// promote replaces the deployed agent with its canary
+func (s *agentStore) promote(id string) error {
+	return s.db.update(func(tx registryTx) error {
+		c, err := tx.canary(id)
+		if err != nil {
+			return err
+		}
+		return promoteCanary(tx, c)
+	})
+}
+
+// rollback discards an agent's canary, leaving the deployed agent as it is
+func (s *agentStore) rollback(id string) error {
+	return s.db.update(func(tx registryTx) error {
+		return tx.removeCanary(id)
+	})
+}
+
+// promoteCanary makes a canary's spec the deployed one and removes the canary
+func promoteCanary(tx registryTx, c *canaryRecord) error {
+	agent, err := tx.agent(c.AgentID)
+	if err != nil {
+		return err
+	}
+	c.Spec.ID = c.AgentID
+	if _, err := tx.putAgent(c.Spec, agent.CreatedAt, time.Now().UTC()); err != nil {
+		return err
+	}
+	return tx.removeCanary(c.AgentID)
+}
+
+// marshalCanary encodes a canary's status and spec for storage
+func marshalCanary(c *canaryRecord) (status, data []byte, err error) {
+	if status, err = json.Marshal(c.CanaryStatus); err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal canary %s: %w", c.AgentID, err)
+	}
+	if data, err = json.Marshal(c.Spec); err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal canary %s: %w", c.AgentID, err)
+	}
+	return status, data, nil
+}
+
+// parseCanary decodes a canary stored by marshalCanary
+func parseCanary(id string, status, data []byte) (*canaryRecord, error) {
+	var c canaryRecord
+	if err := json.Unmarshal(status, &c.CanaryStatus); err != nil {
+		return nil, fmt.Errorf("invalid canary %s: %w", id, err)
+	}
+	var err error
+	if c.Spec, err = spec.Parse(data); err != nil {
+		return nil, fmt.Errorf("invalid canary %s: %w", id, err)
+	}
+	return &c, nil
+}
+
+// parseCanaryStatus reads the rollout settings of PUT /api/v1/agents/{id}
+// from its query: canary (percent of runs, required), canary_runs and
+// canary_max_failure_rate
+func parseCanaryStatus(r *http.Request) (CanaryStatus, error) {
+	query := r.URL.Query()
+	status := CanaryStatus{MinRuns: defaultCanaryRuns, MaxFailureRate: defaultCanaryMaxFailureRate}
+
+	percent, err := strconv.ParseFloat(query.Get("canary"), 64)
+	if err != nil || percent <= 0 || percent > 100 {
+		return status, fmt.Errorf("canary must be a percentage above 0 and at most 100")
+	}
+	status.Percent = percent
+
+	if v := query.Get("canary_runs"); v != "" {
+		runs, err := strconv.Atoi(v)
+		if err != nil || runs < 1 {
+			return status, fmt.Errorf("canary_runs must be a positive integer")
+		}
+		status.MinRuns = runs
+	}
+	if v := query.Get("canary_max_failure_rate"); v != "" {
+		rate, err := strconv.ParseFloat(v, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return status, fmt.Errorf("canary_max_failure_rate must be between 0 and 1")
+		}
+		status.MaxFailureRate = rate
+	}
+	return status, nil
+}
+
+// handleCanary handles an agent's canary:
+//
+//	GET    /api/v1/agents/{id}/canary          - get the canary's status
+//	POST   /api/v1/agents/{id}/canary/promote  - replace the agent with its canary
+//	DELETE /api/v1/agents/{id}/canary          - roll the canary back
+func (s *Server) handleCanary(w http.ResponseWriter, r *http.Request, id, action string) {
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		status, err := s.agents.canary(id)
+		if err != nil {
+			respondAgentError(w, id, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+	case action == "promote" && r.Method == http.MethodPost:
+		if err := s.agents.promote(id); err != nil {
+			respondAgentError(w, id, err)
+			return
+		}
+		ui.Infof("[API] Canary of %s promoted\n", id)
+		w.WriteHeader(http.StatusNoContent)
+	case action == "" && r.Method == http.MethodDelete:
+		if err := s.agents.rollback(id); err != nil {
+			respondAgentError(w, id, err)
+			return
+		}
+		ui.Infof("[API] Canary of %s rolled back\n", id)
+		w.WriteHeader(http.StatusNoContent)
+	case action != "" && action != "promote":
+		respondError(w, id, "Not found", http.StatusNotFound)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// startCanary deploys agentSpec as a canary of its agent (PUT with ?canary=)
+func (s *Server) startCanary(w http.ResponseWriter, r *http.Request, agentSpec *spec.AgentSpec) {
+	settings, err := parseCanaryStatus(r)
+	if err != nil {
+		respondError(w, agentSpec.ID, err.Error(), http.StatusBadRequest)
+		return
+	}
+	status, err := s.agents.startCanary(agentSpec, settings)
+	if err != nil {
+		respondAgentError(w, agentSpec.ID, err)
+		return
+	}
+
+	ui.Infof("[API] Canary of %s started: %g%% of runs, promoted after %d\n", agentSpec.ID, status.Percent, status.MinRuns)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(status)
+}
+
+// recordCanaryRun counts a finished run-by-ID against the canary it used
+func (s *Server) recordCanaryRun(id string, started time.Time, failed bool) {
+	outcome, err := s.agents.recordCanary(id, started, failed)
+	if err != nil {
+		ui.Infof("[API] ⚠️  Failed to record canary run of %s: %v\n", id, err)
+		return
+	}
+	if outcome != canaryPending {
+		ui.Infof("[API] Canary of %s %s\n", id, outcome)
+	}
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/not7/core/executor"
+)
+
+// sseKeepAlive is how often a comment line is sent to keep idle streams open
+const sseKeepAlive = 15 * time.Second
+
+// streamExecutionEvents handles GET /api/v1/executions/{id}/events as Server-Sent Events
+func (s *Server) streamExecutionEvents(w http.ResponseWriter, r *http.Request, execID string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondError(w, execID, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Subscribe before loading so no event is missed between the two
+	events, unsubscribe := s.execMgr.Subscribe(execID)
+	defer unsubscribe()
+
+	exec, ok := s.loadExecution(w, execID)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// Already finished: replay the terminal event and stop
+	if exec.Status.IsTerminal() {
+		writeSSE(w, exec.FinishedEvent())
+		flusher.Flush()
+		return
+	}
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case event, open := <-events:
+			if !open {
+				return
+			}
+			writeSSE(w, event)
+			flusher.Flush()
+			if event.IsTerminal() {
+				return
+			}
+		}
+	}
+}
+
+// writeSSE writes a single event in text/event-stream format
+func writeSSE(w http.ResponseWriter, event executor.Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+}
+
+// followExecutionLogs streams the execution log as text/plain, flushing each
+// chunk as it is written, until the execution finishes or the client disconnects
+func (s *Server) followExecutionLogs(w http.ResponseWriter, r *http.Request, execID string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondError(w, execID, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	if _, ok := s.loadExecution(w, execID); !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// Headers are sent, so a failure can only end the stream early
+	s.execMgr.FollowLogs(r.Context(), execID, flushWriter{w, flusher})
+}
+
+// flushWriter flushes after every write so streamed output reaches the client immediately
+type flushWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+}
+
+func (f flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	f.flusher.Flush()
+	return n, err
+}
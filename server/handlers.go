@@ -1,18 +1,29 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/not7/core/execution"
+	"github.com/not7/core/executor"
+	"github.com/not7/core/internal/ui"
 	"github.com/not7/core/spec"
+	"github.com/not7/core/tracing"
 )
 
-// handleRun handles POST /api/v1/run - Execute agent
+// handleRun handles POST /api/v1/run - Execute agent. The body is an agent
+// spec, or a RunRequest naming the spec or a deployed agent. A multipart body
+// carries that in a "spec" field and attaches its files to the run.
 func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -20,62 +31,249 @@ func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Read request body
-	body, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+	body, attachments, err := readRunBody(w, r)
 	if err != nil {
-		respondError(w, "", "Failed to read request body", http.StatusBadRequest)
+		respondError(w, "", err.Error(), http.StatusBadRequest)
 		return
 	}
-	defer r.Body.Close()
 
-	// Parse agent spec
-	var agentSpec spec.AgentSpec
-	if err := json.Unmarshal(body, &agentSpec); err != nil {
-		respondError(w, "", "Invalid JSON specification", http.StatusBadRequest)
+	// Parse agent spec (bare or wrapped with input and vars)
+	runReq, err := parseRunRequest(body)
+	if err != nil {
+		respondError(w, "", fmt.Sprintf("Invalid JSON specification: %v", err), http.StatusBadRequest)
 		return
 	}
 
-	// Parse options from query parameters
-	opts := execution.Options{
-		Async:  r.URL.Query().Get("async") == "true",
-		Stream: r.URL.Query().Get("stream") == "true",
+	// Runs by ID use the deployed agent, or its canary for the canary's share
+	agentSpec := runReq.Spec
+	var revision int
+	var canaryStarted time.Time
+	if runReq.Agent != "" {
+		if !agentIDPattern.MatchString(runReq.Agent) {
+			respondError(w, runReq.Agent, "Agent not found", http.StatusNotFound)
+			return
+		}
+		if agentSpec, revision, canaryStarted, err = s.agents.resolve(runReq.Agent); err != nil {
+			respondAgentError(w, runReq.Agent, err)
+			return
+		}
 	}
+	for _, issue := range agentSpec.Warnings() {
+		ui.Infof("[API] ⚠️  %s\n", issue)
+	}
+
+	s.startRun(w, r, agentSpec, runReq.Agent, revision, canaryStarted, execution.Options{
+		Input:       runReq.Input,
+		Vars:        runReq.Vars,
+		Tags:        runReq.Tags,
+		Attachments: attachments,
+	})
+}
 
-	fmt.Printf("[API] Executing agent: %s (async=%v, stream=%v)\n", agentSpec.Goal, opts.Async, opts.Stream)
+// startRun executes agentSpec and writes the execution response. The async,
+// stream and batch_api query parameters complete opts. A run by ID is
+// tagged with the agent's revision; agentID and canaryStarted identify one
+// that uses the agent's canary, whose outcome is counted towards it.
+func (s *Server) startRun(w http.ResponseWriter, r *http.Request, agentSpec *spec.AgentSpec, agentID string, revision int, canaryStarted time.Time, opts execution.Options) {
+	query := r.URL.Query()
+	opts.Async = query.Get("async") == "true"
+	opts.Stream = query.Get("stream") == "true"
+	opts.BatchAPI = query.Get("batch_api") == "true"
+	opts.RequestID = tracing.RequestIDFromContext(r.Context())
 
-	// Execute through manager
-	ctx := context.Background()
-	exec, err := s.execMgr.Execute(ctx, &agentSpec, opts)
+	if revision > 0 {
+		opts.Tags = withTag(opts.Tags, "agent_revision", strconv.Itoa(revision))
+	}
+
+	if !canaryStarted.IsZero() {
+		opts.Tags = withTag(opts.Tags, "canary", "true")
+		opts.OnFinish = func(exec *execution.Execution) {
+			if exec.Status != execution.StatusCancelled {
+				s.recordCanaryRun(agentID, canaryStarted, exec.Status != execution.StatusCompleted)
+			}
+		}
+	}
+
+	// Runs count towards the quotas of the client whose key started them.
+	// The execution is counted up front, so concurrent runs cannot all slip
+	// under the quota, and given back if it never starts.
+	client := clientFrom(r.Context())
+	if err := s.reserveRun(client); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errQuotaExceeded) {
+			status = http.StatusTooManyRequests
+		}
+		respondError(w, "", err.Error(), status)
+		return
+	}
+	onFinish := opts.OnFinish
+	opts.OnFinish = func(exec *execution.Execution) {
+		s.recordFinish(client, exec)
+		if onFinish != nil {
+			onFinish(exec)
+		}
+	}
+
+	ui.Infof("[API] Executing agent: %s (async=%v, stream=%v, request_id=%s)\n", agentSpec.Goal, opts.Async, opts.Stream, opts.RequestID)
+
+	// Execute through manager. The execution outlives a disconnecting
+	// client, but keeps the request's trace context.
+	ctx := context.WithoutCancel(r.Context())
+	exec, err := s.execMgr.Execute(ctx, agentSpec, opts)
+	if exec == nil {
+		s.recordUsage(ClientUsage{Client: client, Executions: -1})
+	}
 
 	if err != nil {
-		respondError(w, "", fmt.Sprintf("Execution failed: %v", err), http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		if errors.Is(err, execution.ErrInvalidSpec) || errors.Is(err, execution.ErrInvalidInput) {
+			status = http.StatusBadRequest
+		} else if errors.Is(err, execution.ErrPolicyViolation) {
+			status = http.StatusForbidden
+		}
+		respondError(w, "", fmt.Sprintf("Execution failed: %v", err), status)
 		return
 	}
 
 	// For async, return immediately with execution ID
 	if opts.Async {
+		response := NewExecutionResponse(exec)
+		response.ExecutionID = exec.ID
+		response.Message = "Execution started in background"
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusAccepted)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"execution_id": exec.ID,
-			"status":       exec.Status,
-			"message":      "Execution started in background",
-		})
+		json.NewEncoder(w).Encode(response)
 		return
 	}
 
 	// For sync, return full result
-	response := buildExecutionResponse(exec)
+	response := NewExecutionResponse(exec)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
 
+// maxRunUploadBytes bounds a multipart run body, attachments included
+const maxRunUploadBytes = 32 << 20
+
+// readRunBody returns the run body and any files attached to it. A
+// multipart/form-data body has the run body in its "spec" field and every
+// file part is an attachment, named after its file name.
+func readRunBody(w http.ResponseWriter, r *http.Request) ([]byte, []executor.Attachment, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Failed to read request body")
+		}
+		return body, nil, nil
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRunUploadBytes)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, nil, fmt.Errorf("Invalid multipart body: %v", err)
+	}
+
+	var body []byte
+	var attachments []executor.Attachment
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("Invalid multipart body: %v", err)
+		}
+
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Failed to read request body (uploads are limited to %d MB)", maxRunUploadBytes>>20)
+		}
+		switch {
+		case part.FormName() == "spec":
+			body = data
+		case part.FileName() != "":
+			contentType := part.Header.Get("Content-Type")
+			if contentType == "" || contentType == "application/octet-stream" {
+				contentType = executor.ArtifactContentType(part.FileName(), data)
+			}
+			attachments = append(attachments, executor.Attachment{Name: part.FileName(), ContentType: contentType, Data: data})
+		default:
+			return nil, nil, fmt.Errorf("Unexpected form field %q (send the run body as \"spec\" and attachments as files)", part.FormName())
+		}
+	}
+
+	if body == nil {
+		return nil, nil, fmt.Errorf("Multipart body has no \"spec\" field")
+	}
+	return body, attachments, nil
+}
+
+// withTag returns a copy of tags with key set to value
+func withTag(tags map[string]string, key, value string) map[string]string {
+	tagged := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		tagged[k] = v
+	}
+	tagged[key] = value
+	return tagged
+}
+
+// parseRunRequest decodes a run body that is either a RunRequest envelope
+// (detected by its "spec" or "agent" field) or a bare agent spec
+func parseRunRequest(body []byte) (*RunRequest, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+
+	if _, byID := fields["agent"]; byID {
+		var runReq RunRequest
+		if err := json.Unmarshal(body, &runReq); err != nil {
+			return nil, err
+		}
+		if runReq.Spec != nil {
+			return nil, fmt.Errorf("spec and agent are mutually exclusive")
+		}
+		if runReq.Agent == "" {
+			return nil, fmt.Errorf("agent is required")
+		}
+		return &runReq, nil
+	}
+
+	if rawSpec, wrapped := fields["spec"]; wrapped {
+		var runReq RunRequest
+		if err := json.Unmarshal(body, &runReq); err != nil {
+			return nil, err
+		}
+		if runReq.Spec == nil {
+			return nil, fmt.Errorf("spec is required")
+		}
+
+		// Decode the spec again strictly so misspelled fields are reported
+		agentSpec, err := spec.Parse(rawSpec)
+		if err != nil {
+			return nil, err
+		}
+		runReq.Spec = agentSpec
+		return &runReq, nil
+	}
+
+	agentSpec, err := spec.Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	return &RunRequest{Spec: agentSpec}, nil
+}
+
 // handleExecutions handles execution-related requests
 func (s *Server) handleExecutions(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/api/v1/executions/")
+	path := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/api/v1/executions"), "/")
 
 	// GET /executions - list all
-	if path == "" || path == "/" {
+	if path == "" {
 		if r.Method != http.MethodGet {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -84,38 +282,132 @@ func (s *Server) handleExecutions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// GET /executions/{id} - get specific execution
-	execID := strings.TrimSuffix(path, "/")
-
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	s.getExecution(w, r, execID)
+	// GET /executions/{id}[/status|/result|/trace[/nodes]|/logs|/events|/artifacts[/{name}]] - get specific execution
+	execID, sub, _ := strings.Cut(strings.TrimSuffix(path, "/"), "/")
+	sub, name, _ := strings.Cut(sub, "/")
+	if name != "" && sub != "artifacts" && (sub != "trace" || name != "nodes") {
+		respondError(w, execID, "Not found", http.StatusNotFound)
+		return
+	}
+
+	switch sub {
+	case "":
+		s.getExecution(w, r, execID)
+	case "status":
+		s.getExecutionStatus(w, r, execID)
+	case "result":
+		s.getExecutionResult(w, r, execID)
+	case "trace":
+		if name == "nodes" {
+			s.getExecutionNodeResults(w, r, execID)
+		} else {
+			s.getExecutionTrace(w, r, execID)
+		}
+	case "logs":
+		s.getExecutionLogs(w, r, execID)
+	case "events":
+		s.streamExecutionEvents(w, r, execID)
+	case "artifacts":
+		if name == "" {
+			s.listExecutionArtifacts(w, r, execID)
+		} else {
+			s.getExecutionArtifact(w, r, execID, name)
+		}
+	default:
+		respondError(w, execID, "Not found", http.StatusNotFound)
+	}
 }
 
 // listExecutions handles GET /api/v1/executions
+// Query parameters: status, agent_id, since, until (RFC3339), tag (key=value,
+// repeatable), limit, offset
 func (s *Server) listExecutions(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseListFilter(r.URL.Query())
+	if err != nil {
+		respondError(w, "", err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	ctx := context.Background()
-	executions, err := s.execMgr.ListExecutions(ctx)
+	executions, total, err := s.execMgr.ListExecutions(ctx, filter)
 	if err != nil {
 		respondError(w, "", fmt.Sprintf("Failed to list executions: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"executions": executions,
-		"count":      len(executions),
+	json.NewEncoder(w).Encode(ExecutionListResponse{
+		Executions: executions,
+		Count:      len(executions),
+		Total:      total,
+		Limit:      filter.Limit,
+		Offset:     filter.Offset,
 	})
 }
 
+// parseListFilter builds an execution filter from query parameters
+func parseListFilter(query url.Values) (execution.ListFilter, error) {
+	filter := execution.ListFilter{
+		Status:  execution.Status(query.Get("status")),
+		AgentID: query.Get("agent_id"),
+	}
+
+	var err error
+	if v := query.Get("since"); v != "" {
+		if filter.Since, err = time.Parse(time.RFC3339, v); err != nil {
+			return filter, fmt.Errorf("invalid since (expected RFC3339): %s", v)
+		}
+	}
+	if v := query.Get("until"); v != "" {
+		if filter.Until, err = time.Parse(time.RFC3339, v); err != nil {
+			return filter, fmt.Errorf("invalid until (expected RFC3339): %s", v)
+		}
+	}
+	for _, v := range query["tag"] {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || key == "" {
+			return filter, fmt.Errorf("invalid tag (expected key=value): %s", v)
+		}
+		if filter.Tags == nil {
+			filter.Tags = make(map[string]string)
+		}
+		filter.Tags[key] = value
+	}
+	if v := query.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit < 0 {
+			return filter, fmt.Errorf("invalid limit: %s", v)
+		}
+	}
+	if v := query.Get("offset"); v != "" {
+		if filter.Offset, err = strconv.Atoi(v); err != nil || filter.Offset < 0 {
+			return filter, fmt.Errorf("invalid offset: %s", v)
+		}
+	}
+
+	return filter, nil
+}
+
 // getExecution handles GET /api/v1/executions/{id}
 func (s *Server) getExecution(w http.ResponseWriter, r *http.Request, execID string) {
-	ctx := context.Background()
-	exec, err := s.execMgr.GetExecution(ctx, execID)
+	exec, ok := s.loadExecution(w, execID)
+	if !ok {
+		return
+	}
 
+	response := NewExecutionResponse(exec)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// getExecutionStatus handles GET /api/v1/executions/{id}/status. It reads
+// the execution's index entry, not its trace, so polling stays cheap.
+func (s *Server) getExecutionStatus(w http.ResponseWriter, r *http.Request, execID string) {
+	info, err := s.execMgr.GetInfo(r.Context(), execID)
 	if err != nil {
 		if err == execution.ErrExecutionNotFound {
 			respondError(w, execID, "Execution not found", http.StatusNotFound)
@@ -125,62 +417,272 @@ func (s *Server) getExecution(w http.ResponseWriter, r *http.Request, execID str
 		return
 	}
 
-	response := buildExecutionResponse(exec)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(NewExecutionStatus(info))
+}
+
+// getExecutionResult handles GET /api/v1/executions/{id}/result
+func (s *Server) getExecutionResult(w http.ResponseWriter, r *http.Request, execID string) {
+	exec, ok := s.loadExecution(w, execID)
+	if !ok {
+		return
+	}
+
+	if !exec.Status.IsTerminal() {
+		respondError(w, execID, fmt.Sprintf("Execution is still %s", exec.Status), http.StatusConflict)
+		return
+	}
+
+	response := NewExecutionResponse(exec)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
-// handleHealth handles health check requests
-func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+// getExecutionTrace handles GET /api/v1/executions/{id}/trace
+func (s *Server) getExecutionTrace(w http.ResponseWriter, r *http.Request, execID string) {
+	ctx := context.Background()
+	trace, err := s.execMgr.GetTrace(ctx, execID)
+	if err != nil {
+		if err == execution.ErrExecutionNotFound {
+			respondError(w, execID, "Execution not found", http.StatusNotFound)
+		} else {
+			respondError(w, execID, fmt.Sprintf("Failed to get trace: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"status": "healthy",
-		"server": "NOT7",
+	w.Write(trace)
+}
+
+// DefaultNodeResultLimit is the page size of /trace/nodes without a limit
+const DefaultNodeResultLimit = 50
+
+// getExecutionNodeResults handles GET /api/v1/executions/{id}/trace/nodes
+// Query parameters: limit (default 50), offset. The page is written as the
+// node results are read from the trace, which is never loaded whole.
+func (s *Server) getExecutionNodeResults(w http.ResponseWriter, r *http.Request, execID string) {
+	query := r.URL.Query()
+	limit, offset := DefaultNodeResultLimit, 0
+	var err error
+	if v := query.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			respondError(w, execID, fmt.Sprintf("invalid limit: %s", v), http.StatusBadRequest)
+			return
+		}
+	}
+	if v := query.Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			respondError(w, execID, fmt.Sprintf("invalid offset: %s", v), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// The opening is written with the first node result, so a missing
+	// execution can still get an error response
+	count := 0
+	begin := func() {
+		w.Header().Set("Content-Type", "application/json")
+		id, _ := json.Marshal(execID)
+		fmt.Fprintf(w, `{"id":%s,"limit":%d,"offset":%d,"node_results":[`, id, limit, offset)
+	}
+	var compact bytes.Buffer
+	total, err := s.execMgr.EachNodeResult(r.Context(), execID, offset, limit, func(raw json.RawMessage) error {
+		if count == 0 {
+			begin()
+		} else {
+			w.Write([]byte(","))
+		}
+		count++
+		compact.Reset()
+		if err := json.Compact(&compact, raw); err != nil {
+			return err
+		}
+		_, err := w.Write(compact.Bytes())
+		return err
 	})
+	if err != nil {
+		if count > 0 {
+			// Too late for an error response; the client sees cut-off JSON
+			return
+		}
+		if err == execution.ErrExecutionNotFound {
+			respondError(w, execID, "Execution not found", http.StatusNotFound)
+		} else {
+			respondError(w, execID, fmt.Sprintf("Failed to get trace: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+	if count == 0 {
+		begin()
+	}
+	fmt.Fprintf(w, "],\"count\":%d,\"total\":%d}\n", count, total)
 }
 
-// buildExecutionResponse converts execution domain model to API response
-func buildExecutionResponse(exec *execution.Execution) map[string]interface{} {
-	response := map[string]interface{}{
-		"id":         exec.ID,
-		"status":     exec.Status,
-		"goal":       exec.Spec.Goal,
-		"created_at": exec.CreatedAt,
+// listExecutionArtifacts handles GET /api/v1/executions/{id}/artifacts,
+// listing the artifacts recorded in the execution's trace
+func (s *Server) listExecutionArtifacts(w http.ResponseWriter, r *http.Request, execID string) {
+	data, err := s.execMgr.GetTrace(r.Context(), execID)
+	if err != nil {
+		if err == execution.ErrExecutionNotFound {
+			respondError(w, execID, "Execution not found", http.StatusNotFound)
+		} else {
+			respondError(w, execID, fmt.Sprintf("Failed to get trace: %v", err), http.StatusInternalServerError)
+		}
+		return
 	}
 
-	if exec.StartedAt != nil {
-		response["started_at"] = exec.StartedAt
+	var trace struct {
+		Metadata *spec.Metadata `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &trace); err != nil {
+		respondError(w, execID, fmt.Sprintf("Failed to parse trace: %v", err), http.StatusInternalServerError)
+		return
+	}
+	artifacts := []spec.Artifact{}
+	if trace.Metadata != nil && trace.Metadata.Artifacts != nil {
+		artifacts = trace.Metadata.Artifacts
 	}
 
-	if exec.EndedAt != nil {
-		response["ended_at"] = exec.EndedAt
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ArtifactListResponse{ID: execID, Artifacts: artifacts, Count: len(artifacts)})
+}
+
+// getExecutionArtifact handles GET /api/v1/executions/{id}/artifacts/{name}
+func (s *Server) getExecutionArtifact(w http.ResponseWriter, r *http.Request, execID, name string) {
+	data, err := s.execMgr.GetArtifact(r.Context(), execID, name)
+	if err != nil {
+		switch {
+		case errors.Is(err, execution.ErrExecutionNotFound):
+			respondError(w, execID, "Execution not found", http.StatusNotFound)
+		case errors.Is(err, execution.ErrArtifactNotFound):
+			respondError(w, execID, "Artifact not found", http.StatusNotFound)
+		default:
+			respondError(w, execID, fmt.Sprintf("Failed to get artifact: %v", err), http.StatusInternalServerError)
+		}
+		return
 	}
 
-	if exec.Result != nil {
-		response["output"] = exec.Result.Output
-		response["duration_ms"] = exec.Result.DurationMs
-		response["total_cost"] = exec.Result.TotalCost
+	w.Header().Set("Content-Type", executor.ArtifactContentType(name, data))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	w.Write(data)
+}
+
+// getExecutionLogs handles GET /api/v1/executions/{id}/logs
+// Query parameters: follow=true streams the log as plain text until the execution finishes
+func (s *Server) getExecutionLogs(w http.ResponseWriter, r *http.Request, execID string) {
+	if r.URL.Query().Get("follow") == "true" {
+		s.followExecutionLogs(w, r, execID)
+		return
+	}
 
-		if exec.Result.Error != "" {
-			response["error"] = exec.Result.Error
+	ctx := context.Background()
+	logs, err := s.execMgr.GetLogs(ctx, execID)
+	if err != nil {
+		if err == execution.ErrExecutionNotFound {
+			respondError(w, execID, "Execution not found", http.StatusNotFound)
+		} else {
+			respondError(w, execID, fmt.Sprintf("Failed to get logs: %v", err), http.StatusInternalServerError)
 		}
+		return
+	}
 
-		if exec.Result.Metadata != nil {
-			response["metadata"] = exec.Result.Metadata
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ExecutionLogsResponse{ID: execID, Logs: logs})
+}
+
+// loadExecution fetches an execution, writing an error response if it cannot be loaded
+func (s *Server) loadExecution(w http.ResponseWriter, execID string) (*execution.Execution, bool) {
+	ctx := context.Background()
+	exec, err := s.execMgr.GetExecution(ctx, execID)
+	if err != nil {
+		if err == execution.ErrExecutionNotFound {
+			respondError(w, execID, "Execution not found", http.StatusNotFound)
+		} else {
+			respondError(w, execID, fmt.Sprintf("Failed to get execution: %v", err), http.StatusInternalServerError)
 		}
+		return nil, false
+	}
+	return exec, true
+}
+
+// handleHealth handles health check requests. When the server was started
+// with --check-providers, the results of the checks are included.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	health := map[string]interface{}{
+		"status": "healthy",
+		"server": "NOT7",
+	}
+	if s.providers != nil {
+		health["providers"] = s.providers
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(health)
+}
+
+// NewExecutionResponse converts execution domain model to API response
+func NewExecutionResponse(exec *execution.Execution) *ExecutionResponse {
+	response := &ExecutionResponse{
+		ID:        exec.ID,
+		RequestID: exec.RequestID,
+		Status:    string(exec.Status),
+		Goal:      exec.Spec.Goal,
+		Input:     exec.Input,
+		Tags:      exec.Tags,
+		CreatedAt: exec.CreatedAt,
+		StartedAt: exec.StartedAt,
+		EndedAt:   exec.EndedAt,
+	}
+
+	if exec.Result != nil {
+		response.Output = exec.Result.Output
+		response.DurationMs = exec.Result.DurationMs
+		response.TotalCost = exec.Result.TotalCost
+		response.Error = exec.Result.Error
+		response.Metadata = exec.Result.Metadata
 	}
 
 	return response
 }
 
-// respondError sends a standardized error response
-func respondError(w http.ResponseWriter, id, message string, statusCode int) {
-	response := map[string]interface{}{
-		"status": "error",
-		"error":  message,
+// NewExecutionStatus converts an execution summary to a lightweight status view
+func NewExecutionStatus(info *execution.ExecutionInfo) *ExecutionStatus {
+	status := &ExecutionStatus{
+		ExecutionID: info.ID,
+		Status:      string(info.Status),
+		AgentID:     info.AgentID,
+		Goal:        info.Goal,
+		Progress: &Progress{
+			TotalNodes:     info.TotalNodes,
+			CompletedNodes: info.CompletedNodes,
+		},
+		CostSoFar: info.TotalCost,
+	}
+
+	if info.StartedAt != nil {
+		status.StartedAt = info.StartedAt.Format(time.RFC3339)
+		end := time.Now()
+		if info.EndedAt != nil {
+			end = *info.EndedAt
+		}
+		status.ElapsedMs = end.Sub(*info.StartedAt).Milliseconds()
 	}
-	if id != "" {
-		response["id"] = id
+
+	if info.EndedAt != nil {
+		status.CompletedAt = info.EndedAt.Format(time.RFC3339)
+	}
+
+	return status
+}
+
+// respondError sends a standardized error response, echoing the request ID
+// set by withRequestID
+func respondError(w http.ResponseWriter, id, message string, statusCode int) {
+	response := ErrorResponse{
+		ID:        id,
+		RequestID: w.Header().Get(tracing.RequestIDHeader),
+		Status:    "error",
+		Error:     message,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/not7/core/execution"
+)
+
+// handleMetrics handles GET /metrics in the Prometheus text format
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	writeMetrics(w, s.execMgr.Metrics())
+}
+
+// writeMetrics renders metrics as Prometheus counters
+func writeMetrics(w http.ResponseWriter, metrics execution.Metrics) {
+	statuses := make([]string, 0, len(metrics.Executions))
+	for status := range metrics.Executions {
+		statuses = append(statuses, string(status))
+	}
+	sort.Strings(statuses)
+
+	fmt.Fprintln(w, "# HELP not7_executions_total Finished executions by final status.")
+	fmt.Fprintln(w, "# TYPE not7_executions_total counter")
+	for _, status := range statuses {
+		fmt.Fprintf(w, "not7_executions_total{status=%s} %d\n", labelValue(status), metrics.Executions[execution.Status(status)])
+	}
+
+	fmt.Fprintln(w, "# HELP not7_agent_executions_total Finished executions by agent.")
+	fmt.Fprintln(w, "# TYPE not7_agent_executions_total counter")
+	for _, agent := range metrics.Agents {
+		fmt.Fprintf(w, "not7_agent_executions_total{agent_id=%s} %d\n", labelValue(agent.AgentID), agent.Executions)
+	}
+
+	fmt.Fprintln(w, "# HELP not7_llm_tokens_total LLM tokens consumed by agent and token type.")
+	fmt.Fprintln(w, "# TYPE not7_llm_tokens_total counter")
+	for _, agent := range metrics.Agents {
+		fmt.Fprintf(w, "not7_llm_tokens_total{agent_id=%s,type=\"prompt\"} %d\n", labelValue(agent.AgentID), agent.PromptTokens)
+		fmt.Fprintf(w, "not7_llm_tokens_total{agent_id=%s,type=\"completion\"} %d\n", labelValue(agent.AgentID), agent.CompletionTokens)
+	}
+
+	fmt.Fprintln(w, "# HELP not7_llm_cost_usd_total Approximate LLM spend in USD by agent.")
+	fmt.Fprintln(w, "# TYPE not7_llm_cost_usd_total counter")
+	for _, agent := range metrics.Agents {
+		fmt.Fprintf(w, "not7_llm_cost_usd_total{agent_id=%s} %g\n", labelValue(agent.AgentID), agent.Cost)
+	}
+}
+
+// labelValue quotes a Prometheus label value
+func labelValue(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+	return `"` + value + `"`
+}
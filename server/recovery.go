@@ -0,0 +1,45 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/reporting"
+	"github.com/not7/core/tracing"
+)
+
+// withRecovery turns a panicking handler into a 500 response and sends the
+// panic, with its stack, to the error reporting destinations
+func withRecovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				// Deliberate abort of a streamed response; let net/http handle it
+				panic(rec)
+			}
+
+			requestID := w.Header().Get(tracing.RequestIDHeader)
+			ui.Printf("[API] Panic serving %s %s (request_id=%s): %v\n", r.Method, r.URL.Path, requestID, rec)
+
+			err := reporting.Send(r.Context(), reporting.Report{
+				Kind:      reporting.KindPanic,
+				Message:   fmt.Sprintf("panic serving %s %s: %v", r.Method, r.URL.Path, rec),
+				RequestID: requestID,
+				Stack:     string(debug.Stack()),
+			})
+			if err != nil {
+				ui.Printf("[API] Failed to report panic: %v\n", err)
+			}
+
+			respondError(w, "", "Internal server error", http.StatusInternalServerError)
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
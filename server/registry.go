@@ -0,0 +1,229 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/spec"
+)
+
+// agentRecord is a deployed agent as stored in the registry. An agent's
+// revision counts the specs it has been deployed with.
+type agentRecord struct {
+	Spec      *spec.AgentSpec
+	Revision  int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// registryTx reads and changes the registry within a transaction
+type registryTx interface {
+	// agents returns the deployed agents matching filter, sorted by ID
+	agents(filter AgentFilter) ([]AgentInfo, error)
+	// agent returns a deployed agent, or errAgentNotFound
+	agent(id string) (*agentRecord, error)
+	// putAgent inserts or replaces an agent and returns the revision its
+	// spec was stored as. A replaced agent keeps its creation time.
+	putAgent(agentSpec *spec.AgentSpec, createdAt, updatedAt time.Time) (int, error)
+	// deleteAgent removes an agent and its canary, or returns errAgentNotFound
+	deleteAgent(id string) error
+	// canary returns an agent's canary, or errNoCanary
+	canary(id string) (*canaryRecord, error)
+	putCanary(c *canaryRecord) error
+	// removeCanary discards an agent's canary, or returns errNoCanary
+	removeCanary(id string) error
+	// usage returns the usage of every client that ran executions in a
+	// month, by client name
+	usage(month string) (map[string]ClientUsage, error)
+	// addUsage adds to a client's usage of a month
+	addUsage(month string, used ClientUsage) error
+}
+
+// registryDB is the database behind the registry: SQLite when the binary is
+// built with cgo, otherwise a JSON file
+type registryDB interface {
+	// update runs fn in a transaction, committed if fn returns nil
+	update(fn func(tx registryTx) error) error
+	// view runs fn on the registry without changing it
+	view(fn func(tx registryTx) error) error
+	close() error
+}
+
+// agentStore keeps deployed agents, their canaries and client usage in the
+// registry file of the specs directory
+type agentStore struct {
+	dir string
+	db  registryDB
+}
+
+// newAgentStore opens the registry in dir, creating it if needed. A new
+// registry imports the <id>.json files and canaries that older versions
+// kept in the directory; the files are left in place.
+func newAgentStore(dir string) (*agentStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create specs directory: %w", err)
+	}
+
+	path := filepath.Join(dir, registryFile)
+	_, err := os.Stat(path)
+	created := errors.Is(err, os.ErrNotExist)
+
+	db, err := openRegistry(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open agent registry %s: %w", path, err)
+	}
+
+	s := &agentStore{dir: dir, db: db}
+	if created {
+		imported, err := s.importFiles()
+		if err != nil {
+			db.close()
+			return nil, fmt.Errorf("failed to import agents from %s: %w", dir, err)
+		}
+		if imported > 0 {
+			ui.Infof("📦 Imported %d agent(s) from %s into %s\n", imported, dir, registryFile)
+		}
+	}
+	return s, nil
+}
+
+// search returns the deployed agents matching filter, sorted by ID. Specs
+// that no longer parse are skipped so one bad spec does not hide the others.
+func (s *agentStore) search(filter AgentFilter) (agents []AgentInfo, err error) {
+	err = s.db.view(func(tx registryTx) error {
+		agents, err = tx.agents(filter)
+		return err
+	})
+	return agents, err
+}
+
+// get returns a deployed agent's spec
+func (s *agentStore) get(id string) (agentSpec *spec.AgentSpec, err error) {
+	err = s.db.view(func(tx registryTx) error {
+		agent, err := tx.agent(id)
+		if err != nil {
+			return err
+		}
+		agentSpec = agent.Spec
+		return nil
+	})
+	return agentSpec, err
+}
+
+// save stores an agent's spec as its next revision. With replace false an
+// existing agent is an errAgentExists; with replace true a missing one is
+// an errAgentNotFound, and a running canary is discarded since it was made
+// for the old spec.
+func (s *agentStore) save(agentSpec *spec.AgentSpec, replace bool) (info AgentInfo, err error) {
+	err = s.db.update(func(tx registryTx) error {
+		now := time.Now().UTC()
+		createdAt := now
+		agent, err := tx.agent(agentSpec.ID)
+		switch {
+		case err == nil && !replace:
+			return errAgentExists
+		case errors.Is(err, errAgentNotFound) && replace:
+			return err
+		case err == nil:
+			createdAt = agent.CreatedAt
+		case !errors.Is(err, errAgentNotFound):
+			return err
+		}
+
+		revision, err := tx.putAgent(agentSpec, createdAt, now)
+		if err != nil {
+			return err
+		}
+		if err := discardCanary(tx, agentSpec.ID); err != nil {
+			return err
+		}
+		info = NewAgentInfo(agentSpec, revision, createdAt, now)
+		return nil
+	})
+	return info, err
+}
+
+// delete removes a deployed agent, its tags and its canary
+func (s *agentStore) delete(id string) error {
+	return s.db.update(func(tx registryTx) error {
+		return tx.deleteAgent(id)
+	})
+}
+
+// discardCanary removes an agent's canary, if it has one
+func discardCanary(tx registryTx, id string) error {
+	if err := tx.removeCanary(id); err != nil && !errors.Is(err, errNoCanary) {
+		return err
+	}
+	return nil
+}
+
+// importFiles copies the <id>.json specs and canary/<id>.json canaries of
+// the directory into the registry. A spec's file time becomes its created
+// and updated time.
+func (s *agentStore) importFiles() (int, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil || len(files) == 0 {
+		return 0, err
+	}
+
+	imported := 0
+	err = s.db.update(func(tx registryTx) error {
+		for _, file := range files {
+			info, err := os.Stat(file)
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			agentSpec, err := spec.Parse(data)
+			if err != nil {
+				ui.Infof("⚠️  Skipped %s: %v\n", file, err)
+				continue
+			}
+			if agentSpec.ID == "" {
+				agentSpec.ID = strings.TrimSuffix(filepath.Base(file), ".json")
+			}
+			if !agentIDPattern.MatchString(agentSpec.ID) {
+				ui.Infof("⚠️  Skipped %s: invalid agent id %q\n", file, agentSpec.ID)
+				continue
+			}
+			if _, err := tx.putAgent(agentSpec, info.ModTime().UTC(), info.ModTime().UTC()); err != nil {
+				return err
+			}
+			imported++
+
+			data, err = os.ReadFile(filepath.Join(s.dir, "canary", agentSpec.ID+".json"))
+			if err != nil {
+				continue
+			}
+			var c canaryRecord
+			if err := json.Unmarshal(data, &c); err != nil || c.Spec == nil {
+				ui.Infof("⚠️  Skipped the canary of %s: invalid canary file\n", agentSpec.ID)
+				continue
+			}
+			if err := tx.putCanary(&c); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return imported, err
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
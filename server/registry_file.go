@@ -0,0 +1,271 @@
+//go:build !cgo
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/not7/core/spec"
+)
+
+// registryFile is the agent registry, kept in the specs directory. Without
+// cgo there is no SQLite, so the registry is a JSON file instead.
+const registryFile = "agents.registry"
+
+// fileRegistryState is the content of the registry file
+type fileRegistryState struct {
+	Agents   map[string]fileAgent              `json:"agents"`
+	Canaries map[string]fileCanary             `json:"canaries"`
+	Usage    map[string]map[string]ClientUsage `json:"usage"` // By month, then client
+}
+
+type fileAgent struct {
+	Spec      json.RawMessage `json:"spec"`
+	Revision  int             `json:"revision"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+type fileCanary struct {
+	Status json.RawMessage `json:"status"`
+	Spec   json.RawMessage `json:"spec"`
+}
+
+// fileRegistry keeps the registry in memory and rewrites the file after
+// every change. Unlike the SQLite registry, the file is not meant to be
+// shared by several servers.
+type fileRegistry struct {
+	path  string
+	mu    sync.RWMutex
+	state *fileRegistryState
+}
+
+// openRegistry loads the registry file at path, or starts an empty
+// registry if there is none yet
+func openRegistry(path string) (registryDB, error) {
+	state := &fileRegistryState{}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, state); err != nil {
+			return nil, fmt.Errorf("invalid registry file: %w", err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, err
+	}
+
+	r := &fileRegistry{path: path, state: state.clone()}
+	if errors.Is(err, os.ErrNotExist) {
+		if err := r.write(r.state); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// update runs fn on a copy of the registry, which replaces the registry
+// and is written to the file if fn succeeds
+func (r *fileRegistry) update(fn func(tx registryTx) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx := &fileTx{state: r.state.clone()}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if !tx.changed {
+		return nil
+	}
+	if err := r.write(tx.state); err != nil {
+		return err
+	}
+	r.state = tx.state
+	return nil
+}
+
+func (r *fileRegistry) view(fn func(tx registryTx) error) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return fn(&fileTx{state: r.state})
+}
+
+func (r *fileRegistry) close() error {
+	return nil
+}
+
+// write replaces the registry file with state, through a temporary file so
+// a crash cannot leave it half written
+func (r *fileRegistry) write(state *fileRegistryState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal registry: %w", err)
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write registry: %w", err)
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return fmt.Errorf("failed to write registry: %w", err)
+	}
+	return nil
+}
+
+// clone copies the state's maps, so a transaction can change them without
+// touching the registry. Stored specs are never changed in place.
+func (s *fileRegistryState) clone() *fileRegistryState {
+	c := &fileRegistryState{
+		Agents:   make(map[string]fileAgent, len(s.Agents)),
+		Canaries: make(map[string]fileCanary, len(s.Canaries)),
+		Usage:    make(map[string]map[string]ClientUsage, len(s.Usage)),
+	}
+	for id, agent := range s.Agents {
+		c.Agents[id] = agent
+	}
+	for id, canary := range s.Canaries {
+		c.Canaries[id] = canary
+	}
+	for month, clients := range s.Usage {
+		c.Usage[month] = make(map[string]ClientUsage, len(clients))
+		for client, used := range clients {
+			c.Usage[month][client] = used
+		}
+	}
+	return c
+}
+
+// fileTx reads and changes a copy of the registry
+type fileTx struct {
+	state   *fileRegistryState
+	changed bool
+}
+
+func (t *fileTx) agents(filter AgentFilter) ([]AgentInfo, error) {
+	query := strings.ToLower(filter.Query)
+	agents := []AgentInfo{}
+	for id, stored := range t.state.Agents {
+		agentSpec, err := spec.Parse(stored.Spec)
+		if err != nil {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(id), query) &&
+			!strings.Contains(strings.ToLower(agentSpec.Goal), query) &&
+			!strings.Contains(strings.ToLower(agentSpec.Description), query) {
+			continue
+		}
+		if filter.Owner != "" && agentSpec.Owner != filter.Owner {
+			continue
+		}
+		if slices.ContainsFunc(filter.Tags, func(tag string) bool { return !slices.Contains(agentSpec.Tags, tag) }) {
+			continue
+		}
+
+		info := NewAgentInfo(agentSpec, stored.Revision, stored.CreatedAt, stored.UpdatedAt)
+		if canary, ok := t.state.Canaries[id]; ok {
+			var status CanaryStatus
+			if json.Unmarshal(canary.Status, &status) == nil {
+				info.Canary = &status
+			}
+		}
+		agents = append(agents, info)
+	}
+	sort.Slice(agents, func(i, j int) bool { return agents[i].ID < agents[j].ID })
+	return agents, nil
+}
+
+func (t *fileTx) agent(id string) (*agentRecord, error) {
+	stored, ok := t.state.Agents[id]
+	if !ok {
+		return nil, errAgentNotFound
+	}
+	agentSpec, err := spec.Parse(stored.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid agent %s: %w", id, err)
+	}
+	return &agentRecord{Spec: agentSpec, Revision: stored.Revision, CreatedAt: stored.CreatedAt, UpdatedAt: stored.UpdatedAt}, nil
+}
+
+func (t *fileTx) putAgent(agentSpec *spec.AgentSpec, createdAt, updatedAt time.Time) (int, error) {
+	data, err := json.Marshal(agentSpec)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal agent %s: %w", agentSpec.ID, err)
+	}
+
+	stored := fileAgent{Spec: data, Revision: 1, CreatedAt: createdAt.UTC(), UpdatedAt: updatedAt.UTC()}
+	if old, ok := t.state.Agents[agentSpec.ID]; ok {
+		stored.Revision = old.Revision + 1
+		stored.CreatedAt = old.CreatedAt
+	}
+	t.state.Agents[agentSpec.ID] = stored
+	t.changed = true
+	return stored.Revision, nil
+}
+
+func (t *fileTx) deleteAgent(id string) error {
+	if _, ok := t.state.Agents[id]; !ok {
+		return errAgentNotFound
+	}
+	delete(t.state.Agents, id)
+	delete(t.state.Canaries, id)
+	t.changed = true
+	return nil
+}
+
+func (t *fileTx) canary(id string) (*canaryRecord, error) {
+	stored, ok := t.state.Canaries[id]
+	if !ok {
+		return nil, errNoCanary
+	}
+	return parseCanary(id, stored.Status, stored.Spec)
+}
+
+func (t *fileTx) putCanary(c *canaryRecord) error {
+	status, data, err := marshalCanary(c)
+	if err != nil {
+		return err
+	}
+	t.state.Canaries[c.AgentID] = fileCanary{Status: status, Spec: data}
+	t.changed = true
+	return nil
+}
+
+func (t *fileTx) removeCanary(id string) error {
+	if _, ok := t.state.Canaries[id]; !ok {
+		return errNoCanary
+	}
+	delete(t.state.Canaries, id)
+	t.changed = true
+	return nil
+}
+
+func (t *fileTx) usage(month string) (map[string]ClientUsage, error) {
+	usage := make(map[string]ClientUsage, len(t.state.Usage[month]))
+	for client, used := range t.state.Usage[month] {
+		usage[client] = used
+	}
+	return usage, nil
+}
+
+func (t *fileTx) addUsage(month string, used ClientUsage) error {
+	clients, ok := t.state.Usage[month]
+	if !ok {
+		clients = make(map[string]ClientUsage)
+		t.state.Usage[month] = clients
+	}
+	total := clients[used.Client]
+	total.Client = used.Client
+	total.Executions += used.Executions
+	total.PromptTokens += used.PromptTokens
+	total.CompletionTokens += used.CompletionTokens
+	total.Cost += used.Cost
+	clients[used.Client] = total
+	t.changed = true
+	return nil
+}
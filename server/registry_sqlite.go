@@ -0,0 +1,296 @@
+//go:build cgo
+
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/not7/core/spec"
+)
+
+// registryFile is the agent registry database, kept in the specs directory
+const registryFile = "agents.db"
+
+// registrySchema creates the registry tables. Tags have a table of their
+// own so that a search by tag uses an index instead of parsing every spec.
+// The usage of each API key's client is kept alongside, by month.
+const registrySchema = `
+CREATE TABLE IF NOT EXISTS agents (
+	id          TEXT PRIMARY KEY,
+	goal        TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	owner       TEXT NOT NULL DEFAULT '',
+	spec        TEXT NOT NULL,
+	revision    INTEGER NOT NULL DEFAULT 1,
+	created_at  TEXT NOT NULL,
+	updated_at  TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS agents_owner ON agents (owner);
+
+CREATE TABLE IF NOT EXISTS agent_tags (
+	agent_id TEXT NOT NULL REFERENCES agents (id) ON DELETE CASCADE,
+	tag      TEXT NOT NULL,
+	PRIMARY KEY (agent_id, tag)
+);
+CREATE INDEX IF NOT EXISTS agent_tags_tag ON agent_tags (tag);
+
+CREATE TABLE IF NOT EXISTS canaries (
+	agent_id TEXT PRIMARY KEY REFERENCES agents (id) ON DELETE CASCADE,
+	status   TEXT NOT NULL,
+	spec     TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS usage (
+	client            TEXT NOT NULL,
+	month             TEXT NOT NULL,
+	executions        INTEGER NOT NULL DEFAULT 0,
+	prompt_tokens     INTEGER NOT NULL DEFAULT 0,
+	completion_tokens INTEGER NOT NULL DEFAULT 0,
+	cost              REAL NOT NULL DEFAULT 0,
+	PRIMARY KEY (client, month)
+);
+`
+
+// sqlRunner is the part of *sql.DB and *sql.Tx the registry uses
+type sqlRunner interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// sqliteRegistry keeps the registry in a SQLite database. The database has
+// a single connection, so every transaction runs on its own.
+type sqliteRegistry struct {
+	db *sql.DB
+}
+
+// openRegistry opens the SQLite registry at path, creating its tables
+func openRegistry(path string) (registryDB, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(registrySchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create tables: %w", err)
+	}
+	// Registries created before revisions were kept start counting at 1
+	_, err = db.Exec(`ALTER TABLE agents ADD COLUMN revision INTEGER NOT NULL DEFAULT 1`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column") {
+		db.Close()
+		return nil, fmt.Errorf("failed to upgrade tables: %w", err)
+	}
+	return &sqliteRegistry{db: db}, nil
+}
+
+func (r *sqliteRegistry) update(fn func(tx registryTx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(sqliteTx{tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *sqliteRegistry) view(fn func(tx registryTx) error) error {
+	return fn(sqliteTx{r.db})
+}
+
+func (r *sqliteRegistry) close() error {
+	return r.db.Close()
+}
+
+// sqliteTx runs the registry's statements on a transaction, or on the
+// database for views
+type sqliteTx struct {
+	db sqlRunner
+}
+
+func (t sqliteTx) agents(filter AgentFilter) ([]AgentInfo, error) {
+	query := `SELECT a.spec, a.revision, a.created_at, a.updated_at, c.status
+		FROM agents a LEFT JOIN canaries c ON c.agent_id = a.id WHERE 1 = 1`
+	var args []interface{}
+	if filter.Query != "" {
+		pattern := "%" + escapeLike(filter.Query) + "%"
+		query += ` AND (a.id LIKE ? ESCAPE '\' OR a.goal LIKE ? ESCAPE '\' OR a.description LIKE ? ESCAPE '\')`
+		args = append(args, pattern, pattern, pattern)
+	}
+	if filter.Owner != "" {
+		query += ` AND a.owner = ?`
+		args = append(args, filter.Owner)
+	}
+	for _, tag := range filter.Tags {
+		query += ` AND a.id IN (SELECT agent_id FROM agent_tags WHERE tag = ?)`
+		args = append(args, tag)
+	}
+	query += ` ORDER BY a.id`
+
+	rows, err := t.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	agents := []AgentInfo{}
+	for rows.Next() {
+		var data, createdAt, updatedAt string
+		var revision int
+		var canary sql.NullString
+		if err := rows.Scan(&data, &revision, &createdAt, &updatedAt, &canary); err != nil {
+			return nil, err
+		}
+		agentSpec, err := spec.Parse([]byte(data))
+		if err != nil {
+			continue
+		}
+		info := NewAgentInfo(agentSpec, revision, parseTime(createdAt), parseTime(updatedAt))
+		if canary.Valid {
+			var status CanaryStatus
+			if json.Unmarshal([]byte(canary.String), &status) == nil {
+				info.Canary = &status
+			}
+		}
+		agents = append(agents, info)
+	}
+	return agents, rows.Err()
+}
+
+func (t sqliteTx) agent(id string) (*agentRecord, error) {
+	var data, createdAt, updatedAt string
+	var revision int
+	err := t.db.QueryRow(`SELECT spec, revision, created_at, updated_at FROM agents WHERE id = ?`, id).
+		Scan(&data, &revision, &createdAt, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errAgentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	agentSpec, err := spec.Parse([]byte(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid agent %s: %w", id, err)
+	}
+	return &agentRecord{Spec: agentSpec, Revision: revision, CreatedAt: parseTime(createdAt), UpdatedAt: parseTime(updatedAt)}, nil
+}
+
+func (t sqliteTx) putAgent(agentSpec *spec.AgentSpec, createdAt, updatedAt time.Time) (int, error) {
+	data, err := json.Marshal(agentSpec)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal agent %s: %w", agentSpec.ID, err)
+	}
+
+	var revision int
+	err = t.db.QueryRow(`INSERT INTO agents (id, goal, description, owner, spec, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET goal = excluded.goal, description = excluded.description,
+			owner = excluded.owner, spec = excluded.spec, revision = agents.revision + 1,
+			updated_at = excluded.updated_at
+		RETURNING revision`,
+		agentSpec.ID, agentSpec.Goal, agentSpec.Description, agentSpec.Owner, string(data),
+		formatTime(createdAt), formatTime(updatedAt)).Scan(&revision)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := t.db.Exec(`DELETE FROM agent_tags WHERE agent_id = ?`, agentSpec.ID); err != nil {
+		return 0, err
+	}
+	for _, tag := range agentSpec.Tags {
+		if _, err := t.db.Exec(`INSERT OR IGNORE INTO agent_tags (agent_id, tag) VALUES (?, ?)`, agentSpec.ID, tag); err != nil {
+			return 0, err
+		}
+	}
+	return revision, nil
+}
+
+func (t sqliteTx) deleteAgent(id string) error {
+	result, err := t.db.Exec(`DELETE FROM agents WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return errAgentNotFound
+	}
+	return nil
+}
+
+func (t sqliteTx) canary(id string) (*canaryRecord, error) {
+	var status, data string
+	err := t.db.QueryRow(`SELECT status, spec FROM canaries WHERE agent_id = ?`, id).Scan(&status, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errNoCanary
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseCanary(id, []byte(status), []byte(data))
+}
+
+func (t sqliteTx) putCanary(c *canaryRecord) error {
+	status, data, err := marshalCanary(c)
+	if err != nil {
+		return err
+	}
+	_, err = t.db.Exec(`INSERT OR REPLACE INTO canaries (agent_id, status, spec) VALUES (?, ?, ?)`,
+		c.AgentID, string(status), string(data))
+	return err
+}
+
+func (t sqliteTx) removeCanary(id string) error {
+	result, err := t.db.Exec(`DELETE FROM canaries WHERE agent_id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return errNoCanary
+	}
+	return nil
+}
+
+func (t sqliteTx) usage(month string) (map[string]ClientUsage, error) {
+	rows, err := t.db.Query(`SELECT client, executions, prompt_tokens, completion_tokens, cost
+		FROM usage WHERE month = ?`, month)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := make(map[string]ClientUsage)
+	for rows.Next() {
+		var used ClientUsage
+		if err := rows.Scan(&used.Client, &used.Executions, &used.PromptTokens, &used.CompletionTokens, &used.Cost); err != nil {
+			return nil, err
+		}
+		usage[used.Client] = used
+	}
+	return usage, rows.Err()
+}
+
+func (t sqliteTx) addUsage(month string, used ClientUsage) error {
+	_, err := t.db.Exec(`INSERT INTO usage (client, month, executions, prompt_tokens, completion_tokens, cost)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (client, month) DO UPDATE SET
+			executions = executions + excluded.executions,
+			prompt_tokens = prompt_tokens + excluded.prompt_tokens,
+			completion_tokens = completion_tokens + excluded.completion_tokens,
+			cost = cost + excluded.cost`,
+		used.Client, month, used.Executions, used.PromptTokens, used.CompletionTokens, used.Cost)
+	return err
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern, with \ as the escape
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/spec"
+)
+
+// specWatcher redeploys the <id>.json specs of the specs directory when
+// they change, so agents kept as files pick up edits without a restart
+type specWatcher struct {
+	store   *agentStore
+	started time.Time
+	seen    map[string]time.Time // Modification time of each file when last checked
+}
+
+// watchSpecFiles checks the specs directory for changed spec files every
+// interval, for as long as the server runs
+func (s *Server) watchSpecFiles(interval time.Duration) {
+	watcher := &specWatcher{store: s.agents, started: time.Now(), seen: make(map[string]time.Time)}
+	watcher.check()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		watcher.check()
+	}
+}
+
+// check reloads the files modified since they were last checked
+func (w *specWatcher) check() {
+	files, err := filepath.Glob(filepath.Join(w.store.dir, "*.json"))
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if last, ok := w.seen[file]; ok && last.Equal(info.ModTime()) {
+			continue
+		}
+		w.seen[file] = info.ModTime()
+
+		id, revision, err := w.store.reloadFile(file, info.ModTime(), w.started)
+		switch {
+		case err != nil:
+			ui.Infof("⚠️  Did not reload %s: %v\n", file, err)
+		case revision > 0:
+			ui.Infof("🔄 Reloaded agent %s from %s (revision %d)\n", id, filepath.Base(file), revision)
+		}
+	}
+}
+
+// reloadFile deploys the spec in file, modified at modTime, if it is newer
+// than the agent's deployed spec and differs from it, and returns the new
+// revision. A file for an agent that is not deployed is only deployed if
+// it changed since the watcher started, so agents deleted over the API
+// stay deleted. A running canary is discarded, as with a deploy over the
+// API. The revision is 0 when nothing was deployed.
+func (s *agentStore) reloadFile(file string, modTime, started time.Time) (string, int, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", 0, err
+	}
+	agentSpec, err := spec.Parse(data)
+	if err != nil {
+		return "", 0, err
+	}
+	if agentSpec.ID == "" {
+		agentSpec.ID = strings.TrimSuffix(filepath.Base(file), ".json")
+	}
+	if !agentIDPattern.MatchString(agentSpec.ID) {
+		return agentSpec.ID, 0, fmt.Errorf("invalid agent id %q", agentSpec.ID)
+	}
+	if err := spec.ValidateSpec(agentSpec); err != nil {
+		return agentSpec.ID, 0, fmt.Errorf("invalid agent specification: %w", err)
+	}
+
+	var revision int
+	err = s.db.update(func(tx registryTx) error {
+		now := time.Now().UTC()
+		createdAt := now
+		deployed, err := tx.agent(agentSpec.ID)
+		switch {
+		case errors.Is(err, errAgentNotFound):
+			if modTime.Before(started) {
+				return nil
+			}
+		case err != nil:
+			return err
+		default:
+			if !modTime.After(deployed.UpdatedAt) || sameSpec(deployed.Spec, agentSpec) {
+				return nil
+			}
+			createdAt = deployed.CreatedAt
+		}
+
+		if revision, err = tx.putAgent(agentSpec, createdAt, now); err != nil {
+			return err
+		}
+		return discardCanary(tx, agentSpec.ID)
+	})
+	if err != nil {
+		return agentSpec.ID, 0, err
+	}
+	return agentSpec.ID, revision, nil
+}
+
+// sameSpec reports whether two specs are stored the same
+func sameSpec(a, b *spec.AgentSpec) bool {
+	dataA, errA := json.Marshal(a)
+	dataB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(dataA) == string(dataB)
+}
@@ -0,0 +1,26 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/not7/core/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// withRequestID assigns every request a correlation ID: the client's
+// X-Request-ID when it is valid, a generated one otherwise. The ID is
+// returned in the response header and stored in the request context, along
+// with any W3C trace context sent by the caller.
+func withRequestID(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(tracing.RequestIDHeader)
+		if !tracing.ValidRequestID(requestID) {
+			requestID = tracing.NewRequestID()
+		}
+		w.Header().Set(tracing.RequestIDHeader, requestID)
+
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		next(w, r.WithContext(tracing.WithRequestID(ctx, requestID)))
+	}
+}
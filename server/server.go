@@ -1,23 +1,37 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/not7/core/config"
 	"github.com/not7/core/execution"
+	"github.com/not7/core/internal/probe"
+	"github.com/not7/core/internal/ui"
+	"github.com/not7/core/queue"
 )
 
 // Server represents the NOT7 HTTP server
 type Server struct {
 	port       int
 	execMgr    *execution.Manager
+	agents     *agentStore
 	logDir     string
 	execDir    string
+	specsDir   string
+	queueCfg   config.QueueConfig
+	clients    map[string]config.ClientConfig // API keys and quotas, by client name; none means no auth
+	reload     time.Duration // How often changed spec files are redeployed; 0 never
+	providers  []ProviderStatus // Provider checks run at startup, if any
 }
 
-// NewServer creates a new NOT7 server instance
-func NewServer(port int, execDir, logDir string) *Server {
+// NewServer creates a new NOT7 server instance from the server section of cfg
+func NewServer(cfg *config.Config) (*Server, error) {
+	port, execDir, logDir, specsDir := cfg.Server.Port, cfg.Server.ExecutionsDir, cfg.Server.LogDir, cfg.Server.SpecsDir
 	if port == 0 {
 		port = 8080
 	}
@@ -27,18 +41,45 @@ func NewServer(port int, execDir, logDir string) *Server {
 	if logDir == "" {
 		logDir = "./logs"
 	}
+	if specsDir == "" {
+		specsDir = "./specs"
+	}
 
 	// Create storage
 	storage, err := execution.NewFileSystemStorage(execDir)
 	if err != nil {
-		panic(fmt.Errorf("failed to create storage: %w", err))
+		return nil, fmt.Errorf("failed to create storage: %w", err)
+	}
+	cached := execution.NewCachedStorage(storage, cfg.Server.CachedExecutions)
+	agents, err := newAgentStore(specsDir)
+	if err != nil {
+		return nil, err
 	}
 
 	return &Server{
-		port:    port,
-		execMgr: execution.NewManager(storage, logDir),
-		logDir:  logDir,
-		execDir: execDir,
+		port:     port,
+		execMgr:  execution.NewManager(cached, logDir, cfg),
+		agents:   agents,
+		logDir:   logDir,
+		execDir:  execDir,
+		specsDir: specsDir,
+		queueCfg: cfg.Queue,
+		clients:  cfg.Clients,
+		reload:   time.Duration(cfg.Server.ReloadSeconds) * time.Second,
+	}, nil
+}
+
+// SetProviderChecks records the results of the provider checks run at
+// startup, for /health
+func (s *Server) SetProviderChecks(results []probe.Result) {
+	checkedAt := time.Now().UTC()
+	s.providers = make([]ProviderStatus, 0, len(results))
+	for _, result := range results {
+		status := ProviderStatus{Name: result.Name, OK: result.Err == nil, CheckedAt: checkedAt}
+		if result.Err != nil {
+			status.Error = result.Err.Error()
+		}
+		s.providers = append(s.providers, status)
 	}
 }
 
@@ -52,46 +93,91 @@ func (s *Server) Start() error {
 		return fmt.Errorf("failed to create logs directory: %w", err)
 	}
 
+	// Async runs wait in the queue for this server's workers, or others'
+	q, err := queue.Open(s.queueCfg)
+	if err != nil {
+		return fmt.Errorf("failed to open execution queue: %w", err)
+	}
+	if q != nil {
+		defer q.Close()
+		s.execMgr.SetQueue(q)
+		go s.execMgr.Consume(context.Background(), s.queueCfg.Workers)
+	}
+	if s.reload > 0 {
+		go s.watchSpecFiles(s.reload)
+	}
+
 	// Register HTTP handlers
-	http.HandleFunc("/api/v1/run", s.handleRun)             // Primary execution endpoint
-	http.HandleFunc("/api/v1/executions/", s.handleExecutions) // Execution status/results
-	http.HandleFunc("/health", s.handleHealth)
+	http.HandleFunc("/api/v1/run", withRequestID(s.withClient(s.handleRun)))                // Primary execution endpoint
+	http.HandleFunc("/api/v1/executions", withRequestID(s.withClient(s.handleExecutions)))  // Execution listing
+	http.HandleFunc("/api/v1/executions/", withRequestID(s.withClient(s.handleExecutions))) // Execution status/results
+	http.HandleFunc("/api/v1/agents", withRequestID(s.withClient(s.handleAgents)))          // Agent registry
+	http.HandleFunc("/api/v1/agents/", withRequestID(s.withClient(s.handleAgents)))
+	http.HandleFunc("/api/v1/usage", withRequestID(s.withClient(s.handleUsage)))        // Usage and quotas by client
+	http.HandleFunc("/executions/", withRequestID(s.withViewer(s.handleExecutionView))) // Trace viewer page
+	http.HandleFunc("/health", withRequestID(s.handleHealth))
+	http.HandleFunc("/metrics", withRequestID(s.handleMetrics))
 
 	// Display startup information
 	s.printStartupInfo()
 
 	// Start HTTP server (blocks until error)
 	addr := fmt.Sprintf(":%d", s.port)
-	return http.ListenAndServe(addr, nil)
+	return http.ListenAndServe(addr, withRecovery(http.DefaultServeMux))
 }
 
 // printStartupInfo displays server configuration and available endpoints
 func (s *Server) printStartupInfo() {
-	fmt.Println()
-	fmt.Println("╔═════════════════════════════════════════════════════════════╗")
-	fmt.Println("║                                                             ║")
-	fmt.Println("║            ███╗   ██╗ ██████╗ ████████╗███████╗             ║")
-	fmt.Println("║            ████╗  ██║██╔═══██╗╚══██╔══╝╚════██║             ║")
-	fmt.Println("║            ██╔██╗ ██║██║   ██║   ██║       ██╔╝             ║")
-	fmt.Println("║            ██║╚██╗██║██║   ██║   ██║      ██╔╝              ║")
-	fmt.Println("║            ██║ ╚████║╚██████╔╝   ██║      ██║               ║")
-	fmt.Println("║            ╚═╝  ╚═══╝ ╚═════╝    ╚═╝      ╚═╝               ║")
-	fmt.Println("║                                                             ║")
-	fmt.Println("║                 Declarative Agent Runtime                   ║")
-	fmt.Println("║                     https://not7.ai                         ║")
-	fmt.Println("║                                                             ║")
-	fmt.Println("╚═════════════════════════════════════════════════════════════╝")
-	fmt.Println()
-	fmt.Printf("🚀 Server listening on http://localhost:%d\n", s.port)
-	fmt.Printf("📁 Executions: %s\n", s.execDir)
-	fmt.Printf("📁 Logs: %s\n", s.logDir)
-	fmt.Printf("\n📖 API Endpoints:\n")
-	fmt.Printf("   POST   /api/v1/run                  - Execute agent\n")
-	fmt.Printf("   GET    /api/v1/executions           - List executions\n")
-	fmt.Printf("   GET    /api/v1/executions/{id}      - Get execution status\n")
-	fmt.Printf("   GET    /health                      - Health check\n")
-	fmt.Printf("\n💡 Usage:\n")
-	fmt.Printf("   CLI:  ./not7 run agent.json\n")
-	fmt.Printf("   API:  curl -X POST http://localhost:%d/api/v1/run -d @agent.json\n", s.port)
-	fmt.Println()
+	ui.Infoln()
+	ui.Infoln("╔═════════════════════════════════════════════════════════════╗")
+	ui.Infoln("║                                                             ║")
+	ui.Infoln("║            ███╗   ██╗ ██████╗ ████████╗███████╗             ║")
+	ui.Infoln("║            ████╗  ██║██╔═══██╗╚══██╔══╝╚════██║             ║")
+	ui.Infoln("║            ██╔██╗ ██║██║   ██║   ██║       ██╔╝             ║")
+	ui.Infoln("║            ██║╚██╗██║██║   ██║   ██║      ██╔╝              ║")
+	ui.Infoln("║            ██║ ╚████║╚██████╔╝   ██║      ██║               ║")
+	ui.Infoln("║            ╚═╝  ╚═══╝ ╚═════╝    ╚═╝      ╚═╝               ║")
+	ui.Infoln("║                                                             ║")
+	ui.Infoln("║                 Declarative Agent Runtime                   ║")
+	ui.Infoln("║                     https://not7.ai                         ║")
+	ui.Infoln("║                                                             ║")
+	ui.Infoln("╚═════════════════════════════════════════════════════════════╝")
+	ui.Infoln()
+	ui.Infof("🚀 Server listening on http://localhost:%d\n", s.port)
+	ui.Infof("📁 Executions: %s\n", s.execDir)
+	ui.Infof("📁 Logs: %s\n", s.logDir)
+	ui.Infof("📁 Agents: %s\n", filepath.Join(s.specsDir, registryFile))
+	if len(s.clients) > 0 {
+		ui.Infof("🔑 API keys: %d client(s)\n", len(s.clients))
+	}
+	if s.reload > 0 {
+		ui.Infof("🔄 Reloading changed specs in %s every %s\n", s.specsDir, s.reload)
+	}
+	if s.queueCfg.Backend != "" {
+		ui.Infof("📬 Queue: %s, %d workers\n", queue.Describe(s.queueCfg), s.queueCfg.Workers)
+	}
+	ui.Infof("\n📖 API Endpoints:\n")
+	ui.Infof("   POST   /api/v1/run                  - Execute agent\n")
+	ui.Infof("   GET    /api/v1/executions           - List executions\n")
+	ui.Infof("   GET    /api/v1/executions/{id}      - Get execution details\n")
+	ui.Infof("   GET    /api/v1/executions/{id}/status - Get execution status\n")
+	ui.Infof("   GET    /api/v1/executions/{id}/result - Get execution result\n")
+	ui.Infof("   GET    /api/v1/executions/{id}/trace  - Get execution trace\n")
+	ui.Infof("   GET    /api/v1/executions/{id}/trace/nodes - Page through node results (?offset=, limit=)\n")
+	ui.Infof("   GET    /api/v1/executions/{id}/logs   - Get execution logs (?follow=true streams)\n")
+	ui.Infof("   GET    /api/v1/executions/{id}/events - Stream execution events (SSE)\n")
+	ui.Infof("   GET    /api/v1/executions/{id}/artifacts[/{name}] - List or download artifacts\n")
+	ui.Infof("   GET    /api/v1/agents               - List deployed agents (?q=, owner, tag filter)\n")
+	ui.Infof("   POST   /api/v1/agents               - Deploy agent\n")
+	ui.Infof("   GET    /api/v1/agents/{id}          - Get agent spec (PUT updates, DELETE removes)\n")
+	ui.Infof("   GET    /api/v1/agents/{id}/canary   - Get canary status (POST .../promote, DELETE rolls back)\n")
+	ui.Infof("   POST   /api/v1/agents/{id}/run      - Run a deployed agent with the body as input\n")
+	ui.Infof("   GET    /api/v1/usage                - Usage and quotas by client (?month=YYYY-MM)\n")
+	ui.Infof("   GET    /executions/{id}/view        - Trace viewer (HTML)\n")
+	ui.Infof("   GET    /health                      - Health check\n")
+	ui.Infof("   GET    /metrics                     - Token and cost metrics (Prometheus)\n")
+	ui.Infof("\n💡 Usage:\n")
+	ui.Infof("   CLI:  ./not7 run agent.json\n")
+	ui.Infof("   API:  curl -X POST http://localhost:%d/api/v1/run -d @agent.json\n", s.port)
+	ui.Infoln()
 }
@@ -1,28 +1,128 @@
 package server
 
+import (
+	"time"
+
+	"github.com/not7/core/execution"
+	"github.com/not7/core/spec"
+)
+
+// RunRequest is the envelope form of the run body, used to pass input and
+// variable overrides alongside the spec, or to run a deployed agent by ID.
+// A bare agent spec is also accepted as the body.
+type RunRequest struct {
+	Spec  *spec.AgentSpec   `json:"spec,omitempty"`
+	Agent string            `json:"agent,omitempty"` // Runs a deployed agent by ID instead of spec
+	Input string            `json:"input,omitempty"`
+	Vars  map[string]string `json:"vars,omitempty"`
+	Tags  map[string]string `json:"tags,omitempty"`
+}
+
 // ExecutionResponse represents the API response for agent execution
 type ExecutionResponse struct {
-	ID       string  `json:"id"`
-	Status   string  `json:"status"`
-	Goal     string  `json:"goal,omitempty"`
-	Output   string  `json:"output,omitempty"`
-	Error    string  `json:"error,omitempty"`
-	Cost     float64 `json:"cost,omitempty"`
-	Duration int64   `json:"duration_ms,omitempty"`
-	LogFile  string  `json:"log_file,omitempty"`
+	ID          string            `json:"id"`
+	ExecutionID string            `json:"execution_id,omitempty"` // Same as ID, in async responses, which have always carried it
+	RequestID   string            `json:"request_id,omitempty"`
+	Status      string            `json:"status"`
+	Goal        string            `json:"goal,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	Input       string            `json:"input,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	StartedAt   *time.Time        `json:"started_at,omitempty"`
+	EndedAt     *time.Time        `json:"ended_at,omitempty"`
+	Output      string            `json:"output,omitempty"`
+	Error       string            `json:"error,omitempty"`
+	TotalCost   float64           `json:"total_cost,omitempty"`
+	DurationMs  int64             `json:"duration_ms,omitempty"`
+	Metadata    *spec.Metadata    `json:"metadata,omitempty"`
+}
+
+// ExecutionLogsResponse represents the API response for execution logs
+type ExecutionLogsResponse struct {
+	ID   string `json:"id"`
+	Logs string `json:"logs"`
+}
+
+// ArtifactListResponse represents the API response for listing an
+// execution's artifacts
+type ArtifactListResponse struct {
+	ID        string          `json:"id"`
+	Artifacts []spec.Artifact `json:"artifacts"`
+	Count     int             `json:"count"`
+}
+
+// ErrorResponse represents a standardized API error
+type ErrorResponse struct {
+	ID        string `json:"id,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	Status    string `json:"status"`
+	Error     string `json:"error"`
+}
+
+// ProviderStatus is the outcome of a provider check run at startup, as
+// shown by /health
+type ProviderStatus struct {
+	Name      string    `json:"name"`
+	OK        bool      `json:"ok"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// ExecutionListResponse represents the API response for listing executions
+type ExecutionListResponse struct {
+	Executions []*execution.ExecutionInfo `json:"executions"`
+	Count      int                        `json:"count"` // Executions in this page
+	Total      int                        `json:"total"` // Matches before pagination
+	Limit      int                        `json:"limit,omitempty"`
+	Offset     int                        `json:"offset,omitempty"`
 }
 
 // AgentInfo represents agent metadata
 type AgentInfo struct {
-	ID        string `json:"id"`
-	Goal      string `json:"goal"`
-	CreatedAt string `json:"created_at"`
+	ID         string                `json:"id"`
+	Goal       string                `json:"goal"`
+	Owner      string                `json:"owner,omitempty"`
+	Tags       []string              `json:"tags,omitempty"`
+	Revision   int                   `json:"revision"` // Counts the specs the agent was deployed with, from 1
+	CreatedAt  string                `json:"created_at"`
+	UpdatedAt  string                `json:"updated_at"`
+	Inputs     []string              `json:"inputs,omitempty"`     // Required fields of the agent's input_schema
+	Parameters []spec.InputParameter `json:"parameters,omitempty"` // Fields of the input_schema, with descriptions and defaults
+	Canary     *CanaryStatus         `json:"canary,omitempty"`     // A new version taking a share of runs by ID
+}
+
+// AgentFilter narrows an agent listing; every field that is set must match
+type AgentFilter struct {
+	Query string   // Case-insensitive text in the agent's ID, goal or description
+	Owner string   // Exact owner
+	Tags  []string // Tags the agent has all of
+}
+
+// CanaryStatus represents a canary rollout of a new version of a deployed
+// agent. It is promoted after MinRuns runs, or rolled back as soon as more
+// than MaxFailureRate of them have failed.
+type CanaryStatus struct {
+	AgentID        string    `json:"agent_id"`
+	Percent        float64   `json:"percent"` // Share of runs by ID routed to the canary
+	MinRuns        int       `json:"min_runs"`
+	MaxFailureRate float64   `json:"max_failure_rate"`
+	Runs           int       `json:"runs"`
+	Failures       int       `json:"failures"`
+	Revision       int       `json:"revision"` // Revision the canary's spec is stored as when promoted
+	StartedAt      time.Time `json:"started_at"`
+}
+
+// AgentListResponse represents the API response for listing agents
+type AgentListResponse struct {
+	Agents []AgentInfo `json:"agents"`
+	Count  int         `json:"count"`
 }
 
 // ExecutionStatus represents the current state of an execution
 type ExecutionStatus struct {
 	ExecutionID string    `json:"execution_id"`
-	Status      string    `json:"status"` // queued, running, completed, failed
+	Status      string    `json:"status"` // pending, running, suspended, completed, failed
 	AgentID     string    `json:"agent_id,omitempty"`
 	Goal        string    `json:"goal"`
 	StartedAt   string    `json:"started_at,omitempty"`
@@ -32,6 +132,16 @@ type ExecutionStatus struct {
 	ElapsedMs   int64     `json:"elapsed_ms,omitempty"`
 }
 
+// NodeResultPage is a page of the node results in an execution's trace
+type NodeResultPage struct {
+	ID          string            `json:"id"`
+	NodeResults []spec.NodeResult `json:"node_results"`
+	Count       int               `json:"count"`
+	Total       int               `json:"total"`
+	Limit       int               `json:"limit"`
+	Offset      int               `json:"offset"`
+}
+
 // Progress tracks execution progress
 type Progress struct {
 	TotalNodes      int    `json:"total_nodes"`
@@ -39,3 +149,28 @@ type Progress struct {
 	CurrentNode     string `json:"current_node,omitempty"`
 	CurrentNodeType string `json:"current_node_type,omitempty"`
 }
+
+// UsageResponse represents the API response for a month's usage by client
+type UsageResponse struct {
+	Month   string        `json:"month"` // YYYY-MM, in UTC
+	Clients []ClientUsage `json:"clients"`
+}
+
+// ClientUsage is what the executions started with one client's API key used
+// in a month. Executions are counted when they start, tokens and cost when
+// they finish.
+type ClientUsage struct {
+	Client           string       `json:"client"`
+	Executions       int64        `json:"executions"`
+	PromptTokens     int64        `json:"prompt_tokens"`
+	CompletionTokens int64        `json:"completion_tokens"`
+	Cost             float64      `json:"cost"`
+	Quota            *ClientQuota `json:"quota,omitempty"`
+}
+
+// ClientQuota is a client's monthly quota; limits left out are unlimited
+type ClientQuota struct {
+	Executions int     `json:"executions,omitempty"`
+	Tokens     int     `json:"tokens,omitempty"`
+	Cost       float64 `json:"cost,omitempty"`
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/not7/core/config"
+	"github.com/not7/core/execution"
+	"github.com/not7/core/internal/ui"
+)
+
+// errQuotaExceeded is returned for runs of a client that has used up a
+// monthly quota
+var errQuotaExceeded = errors.New("monthly quota exceeded")
+
+// usageMonthLayout formats the months usage is counted by
+const usageMonthLayout = "2006-01"
+
+// usageMonth returns the month, in UTC, that usage at t counts towards
+func usageMonth(t time.Time) string {
+	return t.UTC().Format(usageMonthLayout)
+}
+
+// handleUsage handles GET /api/v1/usage - the usage of every client in the
+// current month, or in the month given as ?month=YYYY-MM, with their quotas
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	month := r.URL.Query().Get("month")
+	if month == "" {
+		month = usageMonth(time.Now())
+	} else if _, err := time.Parse(usageMonthLayout, month); err != nil {
+		respondError(w, "", fmt.Sprintf("Invalid month %q (expected YYYY-MM)", month), http.StatusBadRequest)
+		return
+	}
+
+	usage, err := s.agents.usage(month)
+	if err != nil {
+		respondError(w, "", fmt.Sprintf("Failed to read usage: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Configured clients are listed even before their first run
+	for name := range s.clients {
+		if _, ok := usage[name]; !ok {
+			usage[name] = ClientUsage{Client: name}
+		}
+	}
+	response := UsageResponse{Month: month, Clients: make([]ClientUsage, 0, len(usage))}
+	for name, used := range usage {
+		if client, ok := s.clients[name]; ok && (client.MonthlyExecutions > 0 || client.MonthlyTokens > 0 || client.MonthlyCost > 0) {
+			used.Quota = &ClientQuota{Executions: client.MonthlyExecutions, Tokens: client.MonthlyTokens, Cost: client.MonthlyCost}
+		}
+		response.Clients = append(response.Clients, used)
+	}
+	sort.Slice(response.Clients, func(i, j int) bool { return response.Clients[i].Client < response.Clients[j].Client })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// reserveRun counts a run towards its client's executions of the month,
+// or returns an errQuotaExceeded when the client has used up any of its
+// monthly quotas. The check and the count are one transaction, so
+// concurrent runs cannot all slip under the quota.
+func (s *Server) reserveRun(client string) error {
+	quota := s.clients[client]
+	limited := quota.MonthlyExecutions > 0 || quota.MonthlyTokens > 0 || quota.MonthlyCost > 0
+	month := usageMonth(time.Now())
+
+	return s.agents.db.update(func(tx registryTx) error {
+		if limited {
+			usage, err := tx.usage(month)
+			if err != nil {
+				return fmt.Errorf("failed to read usage: %w", err)
+			}
+			if err := checkQuota(client, quota, usage[client]); err != nil {
+				return err
+			}
+		}
+		return tx.addUsage(month, ClientUsage{Client: client, Executions: 1})
+	})
+}
+
+// checkQuota returns an errQuotaExceeded when used has reached any of the
+// quotas of a client
+func checkQuota(client string, quota config.ClientConfig, used ClientUsage) error {
+	switch tokens := used.PromptTokens + used.CompletionTokens; {
+	case quota.MonthlyExecutions > 0 && used.Executions >= int64(quota.MonthlyExecutions):
+		return fmt.Errorf("%w: client %s has run %d of %d executions", errQuotaExceeded, client, used.Executions, quota.MonthlyExecutions)
+	case quota.MonthlyTokens > 0 && tokens >= int64(quota.MonthlyTokens):
+		return fmt.Errorf("%w: client %s has used %d of %d tokens", errQuotaExceeded, client, tokens, quota.MonthlyTokens)
+	case quota.MonthlyCost > 0 && used.Cost >= quota.MonthlyCost:
+		return fmt.Errorf("%w: client %s has spent $%.2f of $%.2f", errQuotaExceeded, client, used.Cost, quota.MonthlyCost)
+	}
+	return nil
+}
+
+// recordFinish counts the tokens and cost of a finished execution towards
+// its client's usage
+func (s *Server) recordFinish(client string, exec *execution.Execution) {
+	if exec.Result == nil || exec.Result.Metadata == nil {
+		return
+	}
+	s.recordUsage(ClientUsage{
+		Client:           client,
+		PromptTokens:     int64(exec.Result.Metadata.PromptTokens),
+		CompletionTokens: int64(exec.Result.Metadata.CompletionTokens),
+		Cost:             exec.Result.Metadata.TotalCost,
+	})
+}
+
+// recordUsage adds to a client's usage of the current month
+func (s *Server) recordUsage(used ClientUsage) {
+	if err := s.agents.addUsage(usageMonth(time.Now()), used); err != nil {
+		ui.Infof("[API] ⚠️  Failed to record usage of client %s: %v\n", used.Client, err)
+	}
+}
+
+// addUsage adds to a client's usage of a month
+func (s *agentStore) addUsage(month string, used ClientUsage) error {
+	return s.db.update(func(tx registryTx) error {
+		return tx.addUsage(month, used)
+	})
+}
+
+// usage returns the usage of every client that ran executions in a month,
+// by client name
+func (s *agentStore) usage(month string) (usage map[string]ClientUsage, err error) {
+	err = s.db.view(func(tx registryTx) error {
+		usage, err = tx.usage(month)
+		return err
+	})
+	return usage, err
+}
@@ -0,0 +1,497 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/not7/core/execution"
+	"github.com/not7/core/spec"
+)
+
+// viewTextLimit is how many characters of an input, output or tool result
+// the trace viewer shows
+const viewTextLimit = 4000
+
+// Layout of the node graph, in SVG units
+const (
+	graphNodeWidth  = 160
+	graphNodeHeight = 46
+	graphColumnGap  = 70
+	graphRowGap     = 24
+	graphMargin     = 20
+)
+
+// handleExecutionView handles GET /executions/{id}/view, a self-contained
+// HTML page showing an execution's node graph, the time and cost of every
+// node and its ReAct iterations. The page reloads itself until the
+// execution finishes.
+func (s *Server) handleExecutionView(w http.ResponseWriter, r *http.Request) {
+	execID, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/executions/"), "/view")
+	if !ok || execID == "" || strings.Contains(execID, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := context.Background()
+	exec, err := s.execMgr.GetExecution(ctx, execID)
+	if err != nil {
+		if err == execution.ErrExecutionNotFound {
+			http.Error(w, "Execution not found", http.StatusNotFound)
+		} else {
+			http.Error(w, fmt.Sprintf("Failed to get execution: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// The trace holds the node results; before it is written, the spec the
+	// execution was started with still gives the graph
+	trace := exec.Spec
+	if data, err := s.execMgr.GetTrace(ctx, execID); err == nil {
+		var stored spec.AgentSpec
+		if err := json.Unmarshal(data, &stored); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to read trace: %v", err), http.StatusInternalServerError)
+			return
+		}
+		trace = &stored
+	} else if err != execution.ErrExecutionNotFound {
+		http.Error(w, fmt.Sprintf("Failed to get trace: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	viewTemplate.Execute(w, newTraceView(exec, trace))
+}
+
+// traceView is what the trace viewer page renders
+type traceView struct {
+	ID        string
+	Goal      string
+	Status    string
+	Running   bool
+	StartedAt string
+	Duration  string
+	Cost      string
+	Tokens    int
+	Input     string
+	Output    string
+	Error     string
+	Graph     graphView
+	Runs      []runView
+}
+
+// runView is one run of a node: nodes in a loop run more than once
+type runView struct {
+	NodeID         string
+	Status         string
+	Model          string
+	Classification string
+	Duration       string
+	DurationPct    float64 // Of the longest run
+	Cost           string
+	CostPct        float64 // Of the costliest run
+	Tokens         int
+	Input          string
+	Output         string
+	Error          string
+	Steps          []stepView
+}
+
+// stepView is one ReAct iteration
+type stepView struct {
+	Iteration int
+	Thought   string
+	Duration  string
+	Cost      string
+	ToolCalls []toolCallView
+}
+
+type toolCallView struct {
+	Name      string
+	Arguments string
+	Result    string
+	Error     string
+	Duration  string
+}
+
+// graphView is the node graph, laid out left to right by distance from
+// start
+type graphView struct {
+	Width      int
+	Height     int
+	NodeWidth  int
+	NodeHeight int
+	Nodes      []graphNode
+	Edges      []graphEdge
+}
+
+type graphNode struct {
+	ID     string
+	Label  string
+	Type   string
+	Status string // Of the node's last run; empty when it did not run
+	Runs   int
+	X, Y   int
+}
+
+type graphEdge struct {
+	Path   string // SVG path data
+	Label  string
+	LabelX int
+	LabelY int
+	Taken  bool // Both ends ran
+}
+
+// newTraceView flattens an execution and its trace for the viewer
+func newTraceView(exec *execution.Execution, trace *spec.AgentSpec) traceView {
+	view := traceView{
+		ID:      exec.ID,
+		Status:  string(exec.Status),
+		Running: !exec.Status.IsTerminal(),
+		Input:   truncateView(exec.Input),
+	}
+	if exec.StartedAt != nil {
+		view.StartedAt = exec.StartedAt.UTC().Format("2006-01-02 15:04:05 UTC")
+	}
+	if result := exec.Result; result != nil {
+		view.Duration = formatMs(result.DurationMs)
+		view.Cost = fmt.Sprintf("$%.4f", result.TotalCost)
+		view.Output = truncateView(result.Output)
+		view.Error = result.Error
+	}
+	if trace == nil {
+		return view
+	}
+	view.Goal = trace.Goal
+
+	var results []spec.NodeResult
+	if trace.Metadata != nil {
+		results = trace.Metadata.NodeResults
+		view.Tokens = trace.Metadata.PromptTokens + trace.Metadata.CompletionTokens
+	}
+	view.Runs = newRunViews(results)
+	view.Graph = newGraphView(trace, results)
+	return view
+}
+
+// newRunViews describes the node results in the order they ran
+func newRunViews(results []spec.NodeResult) []runView {
+	var longest int64
+	var costliest float64
+	for _, result := range results {
+		longest = max(longest, result.ExecutionTimeMs)
+		costliest = max(costliest, result.Cost)
+	}
+
+	runs := make([]runView, 0, len(results))
+	for _, result := range results {
+		run := runView{
+			NodeID:         result.NodeID,
+			Status:         result.Status,
+			Model:          result.Model,
+			Classification: result.Classification,
+			Duration:       formatMs(result.ExecutionTimeMs),
+			Cost:           fmt.Sprintf("$%.4f", result.Cost),
+			Tokens:         result.PromptTokens + result.CompletionTokens,
+			Input:          truncateView(viewText(result.Input)),
+			Output:         truncateView(viewText(result.Output)),
+			Error:          result.Error,
+		}
+		if longest > 0 {
+			run.DurationPct = float64(result.ExecutionTimeMs) / float64(longest) * 100
+		}
+		if costliest > 0 {
+			run.CostPct = result.Cost / costliest * 100
+		}
+
+		if result.ReActTrace != nil {
+			for _, step := range result.ReActTrace.ThinkingSteps {
+				sv := stepView{
+					Iteration: step.Iteration,
+					Thought:   step.Thought,
+					Duration:  formatMs(step.DurationMs),
+					Cost:      fmt.Sprintf("$%.4f", step.Cost),
+				}
+				for _, call := range step.ToolCalls {
+					args, _ := json.Marshal(call.Arguments)
+					sv.ToolCalls = append(sv.ToolCalls, toolCallView{
+						Name:      call.ToolName,
+						Arguments: truncateView(string(args)),
+						Result:    truncateView(viewText(call.Result)),
+						Error:     call.Error,
+						Duration:  formatMs(call.DurationMs),
+					})
+				}
+				run.Steps = append(run.Steps, sv)
+			}
+		}
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// newGraphView lays out the spec's nodes in columns by their distance from
+// start, following the routes. Nodes no route reaches go next to start;
+// end comes last.
+func newGraphView(trace *spec.AgentSpec, results []spec.NodeResult) graphView {
+	ids := []string{"start"}
+	for _, node := range trace.Nodes {
+		ids = append(ids, node.ID)
+	}
+	ids = append(ids, "end")
+
+	column := map[string]int{"start": 0}
+	queue := []string{"start"}
+	for len(queue) > 0 {
+		from := queue[0]
+		queue = queue[1:]
+		for _, route := range trace.Routes {
+			if _, seen := column[route.To]; route.From == from && !seen && route.To != "end" {
+				column[route.To] = column[from] + 1
+				queue = append(queue, route.To)
+			}
+		}
+	}
+	last := 0
+	for _, id := range ids[1 : len(ids)-1] {
+		if _, ok := column[id]; !ok {
+			column[id] = 1
+		}
+		last = max(last, column[id])
+	}
+	column["end"] = last + 1
+
+	status := make(map[string]string)
+	runs := make(map[string]int)
+	for _, result := range results {
+		status[result.NodeID] = result.Status
+		runs[result.NodeID]++
+	}
+	if len(results) > 0 {
+		status["start"] = "success"
+		if trace.Metadata != nil && trace.Metadata.Status == "completed" {
+			status["end"] = "success"
+		}
+	}
+
+	graph := graphView{NodeWidth: graphNodeWidth, NodeHeight: graphNodeHeight}
+	rows := make(map[int]int)
+	position := make(map[string]graphNode)
+	for i, id := range ids {
+		node := graphNode{ID: id, Label: id, Status: status[id], Runs: runs[id]}
+		if i > 0 && i < len(ids)-1 {
+			specNode := trace.Nodes[i-1]
+			node.Type = specNode.Type
+			if specNode.Name != "" {
+				node.Label = specNode.Name
+			}
+		}
+		node.X = graphMargin + column[id]*(graphNodeWidth+graphColumnGap)
+		node.Y = graphMargin + rows[column[id]]*(graphNodeHeight+graphRowGap)
+		rows[column[id]]++
+		position[id] = node
+		graph.Nodes = append(graph.Nodes, node)
+		graph.Width = max(graph.Width, node.X+graphNodeWidth+graphMargin)
+		graph.Height = max(graph.Height, node.Y+graphNodeHeight+graphMargin)
+	}
+
+	for _, route := range trace.Routes {
+		from, ok1 := position[route.From]
+		to, ok2 := position[route.To]
+		if !ok1 || !ok2 {
+			continue
+		}
+		x1, y1 := from.X+graphNodeWidth, from.Y+graphNodeHeight/2
+		x2, y2 := to.X, to.Y+graphNodeHeight/2
+		edge := graphEdge{
+			Label: routeLabel(route),
+			Taken: from.Status != "" && to.Status != "",
+		}
+		if x2 > x1 {
+			bend := (x2 - x1) / 2
+			edge.Path = fmt.Sprintf("M%d %d C%d %d %d %d %d %d", x1, y1, x1+bend, y1, x2-bend, y2, x2, y2)
+			edge.LabelX, edge.LabelY = (x1+x2)/2, (y1+y2)/2-4
+		} else {
+			// Loops back to an earlier column: go around below both nodes
+			below := max(from.Y, to.Y) + graphNodeHeight + graphRowGap/2
+			edge.Path = fmt.Sprintf("M%d %d C%d %d %d %d %d %d", x1, y1, x1+graphColumnGap, below, x2-graphColumnGap, below, x2, y2)
+			edge.LabelX, edge.LabelY = (x1+x2)/2, below
+			graph.Height = max(graph.Height, below+graphMargin)
+		}
+		graph.Edges = append(graph.Edges, edge)
+	}
+	return graph
+}
+
+// routeLabel names a route's condition for the graph
+func routeLabel(route spec.Route) string {
+	var label string
+	if c := route.Condition; c != nil {
+		switch c.Type {
+		case "label":
+			label = c.Label
+		case "switch":
+			if c.Equals != nil {
+				label = fmt.Sprintf("%s = %v", c.Path, c.Equals)
+			} else {
+				label = c.Path
+			}
+		case "expression":
+			label = c.Expression
+		default:
+			label = c.Type
+		}
+	}
+	if route.Parallel {
+		label = strings.TrimSpace("∥ " + label)
+	}
+	if utf8.RuneCountInString(label) > 28 {
+		label = string([]rune(label)[:27]) + "…"
+	}
+	return label
+}
+
+// viewText renders a trace value: strings as they are, anything else as
+// indented JSON
+func viewText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// truncateView shortens text to viewTextLimit characters
+func truncateView(text string) string {
+	if utf8.RuneCountInString(text) <= viewTextLimit {
+		return text
+	}
+	return string([]rune(text)[:viewTextLimit]) + "\n… (truncated)"
+}
+
+// formatMs formats a duration in milliseconds for the viewer
+func formatMs(ms int64) string {
+	if ms < 1000 {
+		return fmt.Sprintf("%dms", ms)
+	}
+	return fmt.Sprintf("%.1fs", float64(ms)/1000)
+}
+
+var viewTemplate = template.Must(template.New("view").Funcs(template.FuncMap{
+	"add": func(a, b int) int { return a + b },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+{{if .Running}}<meta http-equiv="refresh" content="2">{{end}}
+<title>{{if .Goal}}{{.Goal}}{{else}}{{.ID}}{{end}} · NOT7</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", sans-serif; max-width: 1100px; margin: 2em auto; padding: 0 1em; color: #222; }
+h1 { font-size: 1.4em; }
+table.summary td { padding: 3px 16px 3px 0; }
+pre { background: #f5f5f5; padding: .8em; overflow-x: auto; white-space: pre-wrap; word-break: break-word; max-height: 30em; }
+code { background: #f0f0f0; padding: 0 3px; }
+.graph { overflow-x: auto; border: 1px solid #ddd; border-radius: 6px; padding: 6px; }
+svg text { font-size: 12px; font-family: inherit; }
+svg .box { fill: #fafafa; stroke: #bbb; }
+svg .success .box { fill: #e7f6e7; stroke: #2a2; }
+svg .failed .box { fill: #fbe4e4; stroke: #c22; }
+svg .blocked .box { fill: #fff1d6; stroke: #d90; }
+svg .running .box { fill: #e4eefb; stroke: #26c; }
+svg .type { fill: #777; font-size: 10px; }
+svg path { fill: none; stroke: #ccc; stroke-width: 1.5; }
+svg path.taken { stroke: #666; }
+svg .route { fill: #666; font-size: 10px; }
+.runs { width: 100%; border-collapse: collapse; }
+.runs > tbody > tr > td { padding: 6px 8px; border-top: 1px solid #eee; vertical-align: top; }
+.runs th { text-align: left; padding: 4px 8px; font-weight: 600; color: #555; }
+.bar { background: #eee; height: 8px; border-radius: 4px; min-width: 120px; margin-top: 4px; }
+.bar div { height: 8px; border-radius: 4px; }
+.time div { background: #58c; }
+.cost div { background: #d93; }
+.success, .completed { color: #070; }
+.failed { color: #b00; }
+.blocked, .cancelled, .suspended { color: #a60; }
+.muted { color: #777; font-size: .9em; }
+details { margin: 4px 0; }
+summary { cursor: pointer; }
+.step { border-left: 3px solid #ccd; padding-left: 10px; margin: 8px 0; }
+.tool { margin: 4px 0 4px 12px; }
+</style>
+</head>
+<body>
+<h1>{{if .Goal}}{{.Goal}}{{else}}Execution {{.ID}}{{end}}</h1>
+<table class="summary">
+<tr><td>Execution</td><td><code>{{.ID}}</code></td></tr>
+<tr><td>Status</td><td class="{{.Status}}">{{.Status}}{{if .Running}} <span class="muted">(refreshing)</span>{{end}}</td></tr>
+{{if .StartedAt}}<tr><td>Started</td><td>{{.StartedAt}}</td></tr>{{end}}
+{{if .Duration}}<tr><td>Duration</td><td>{{.Duration}}</td></tr>{{end}}
+{{if .Cost}}<tr><td>Cost</td><td>{{.Cost}}</td></tr>{{end}}
+{{if .Tokens}}<tr><td>Tokens</td><td>{{.Tokens}}</td></tr>{{end}}
+</table>
+{{if .Error}}<h2>Error</h2>
+<pre class="failed">{{.Error}}</pre>{{end}}
+{{with .Graph}}{{if .Nodes}}<h2>Graph</h2>
+<div class="graph">
+<svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" xmlns="http://www.w3.org/2000/svg">
+<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="7" markerHeight="7" orient="auto-start-reverse"><path d="M0 0 L10 5 L0 10 z" style="fill:#888;stroke:none"/></marker></defs>
+{{range .Edges}}<path d="{{.Path}}" marker-end="url(#arrow)"{{if .Taken}} class="taken"{{end}}/>
+{{if .Label}}<text class="route" x="{{.LabelX}}" y="{{.LabelY}}" text-anchor="middle">{{.Label}}</text>
+{{end}}{{end}}{{range .Nodes}}<g class="{{.Status}}"><title>{{.ID}}{{if .Type}} ({{.Type}}){{end}}{{if .Status}}: {{.Status}}{{end}}{{if gt .Runs 1}}, {{.Runs}} runs{{end}}</title>
+<rect class="box" x="{{.X}}" y="{{.Y}}" width="{{$.Graph.NodeWidth}}" height="{{$.Graph.NodeHeight}}" rx="6"/>
+<text x="{{add .X 10}}" y="{{add .Y 20}}">{{.Label}}{{if gt .Runs 1}} ×{{.Runs}}{{end}}</text>
+{{if .Type}}<text class="type" x="{{add .X 10}}" y="{{add .Y 36}}">{{.Type}}</text>{{end}}
+</g>
+{{end}}</svg>
+</div>{{end}}{{end}}
+{{if .Runs}}<h2>Nodes</h2>
+<table class="runs">
+<thead><tr><th>Node</th><th>Time</th><th>Cost</th><th></th></tr></thead>
+<tbody>
+{{range .Runs}}<tr>
+<td><strong>{{.NodeID}}</strong><br><span class="{{.Status}}">{{.Status}}</span>{{if .Model}}<br><span class="muted">{{.Model}}</span>{{end}}</td>
+<td>{{.Duration}}<div class="bar time"><div style="width: {{printf "%.1f" .DurationPct}}%"></div></div></td>
+<td>{{.Cost}}{{if .Tokens}} <span class="muted">· {{.Tokens}} tokens</span>{{end}}<div class="bar cost"><div style="width: {{printf "%.1f" .CostPct}}%"></div></div></td>
+<td>
+{{if .Error}}<pre class="failed">{{.Error}}</pre>{{end}}
+{{if .Classification}}<div>Label: <code>{{.Classification}}</code></div>{{end}}
+{{if .Input}}<details><summary>Input</summary><pre>{{.Input}}</pre></details>{{end}}
+{{if .Output}}<details><summary>Output</summary><pre>{{.Output}}</pre></details>{{end}}
+{{if .Steps}}<details open><summary>ReAct iterations ({{len .Steps}})</summary>
+{{range .Steps}}<div class="step">
+<div><strong>Iteration {{.Iteration}}</strong> <span class="muted">{{.Duration}} · {{.Cost}}</span></div>
+{{if .Thought}}<pre>{{.Thought}}</pre>{{end}}
+{{range .ToolCalls}}<details class="tool"><summary><code>{{.Name}}</code> <span class="muted">{{.Duration}}</span>{{if .Error}} <span class="failed">error</span>{{end}}</summary>
+<pre>{{.Arguments}}</pre>
+{{if .Error}}<pre class="failed">{{.Error}}</pre>{{end}}
+{{if .Result}}<pre>{{.Result}}</pre>{{end}}
+</details>
+{{end}}</div>
+{{end}}</details>{{end}}
+</td>
+</tr>
+{{end}}</tbody>
+</table>{{end}}
+{{if .Input}}<h2>Input</h2>
+<pre>{{.Input}}</pre>{{end}}
+{{if .Output}}<h2>Output</h2>
+<pre>{{.Output}}</pre>{{end}}
+</body>
+</html>
+`))
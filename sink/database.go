@@ -0,0 +1,87 @@
+package sink
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/not7/core/spec"
+)
+
+// insertRows inserts records into an existing table, in one transaction.
+// Columns left out of the insert take the table's defaults.
+func insertRows(ctx context.Context, databaseURL string, target *spec.SinkConfig, records []Record) (string, error) {
+	if databaseURL == "" {
+		return "", fmt.Errorf("table sinks need SINKS_DATABASE_URL in not7.conf")
+	}
+	driver, dsn := databaseDriver(databaseURL)
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return "", fmt.Errorf("failed to open the sink database: %w", err)
+	}
+	defer db.Close()
+
+	names := columns(target, nil, records)
+	quoted := make([]string, len(names))
+	placeholders := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteName(name)
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		quoteTable(target.Table), strings.Join(quoted, ", "), strings.Join(placeholders, ", "))
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to the sink database: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return "", fmt.Errorf("failed to insert into %s: %w", target.Table, err)
+	}
+	defer stmt.Close()
+
+	args := make([]interface{}, len(names))
+	for _, record := range records {
+		for i, name := range names {
+			args[i] = scalar(record[name])
+		}
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			return "", fmt.Errorf("failed to insert into %s: %w", target.Table, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to insert into %s: %w", target.Table, err)
+	}
+	return "table " + target.Table, nil
+}
+
+// databaseDriver returns the driver and data source of SINKS_DATABASE_URL:
+// postgres for postgres:// URLs, else SQLite
+func databaseDriver(databaseURL string) (string, string) {
+	if strings.HasPrefix(databaseURL, "postgres://") || strings.HasPrefix(databaseURL, "postgresql://") {
+		return "postgres", databaseURL
+	}
+	return "sqlite3", strings.TrimPrefix(databaseURL, "sqlite://")
+}
+
+// quoteTable quotes a table name, keeping a schema prefix apart
+func quoteTable(table string) string {
+	parts := strings.Split(table, ".")
+	for i, part := range parts {
+		parts[i] = quoteName(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// quoteName quotes a table or column name. Column names can come from the
+// records themselves, so quotes in them are escaped.
+func quoteName(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,96 @@
+package sink
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/not7/core/spec"
+)
+
+// fileMu keeps concurrent executions from interleaving their rows
+var fileMu sync.Mutex
+
+// appendFile appends records to a CSV or JSONL file in dir. A new CSV file
+// starts with a header row, and later rows follow the existing header.
+func appendFile(dir string, target *spec.SinkConfig, records []Record) (string, error) {
+	path := filepath.Join(dir, filepath.FromSlash(target.File))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create sink directory: %w", err)
+	}
+
+	fileMu.Lock()
+	defer fileMu.Unlock()
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to open sink file: %w", err)
+	}
+	defer f.Close()
+
+	if target.FileFormat() == spec.SinkJSONL {
+		err = appendJSONL(f, target, records)
+	} else {
+		err = appendCSV(f, target, records)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := f.Sync(); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// appendCSV writes records as CSV rows in the order of the file's header,
+// writing the header first when the file is empty
+func appendCSV(f *os.File, target *spec.SinkConfig, records []Record) error {
+	header, err := csv.NewReader(f).Read()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to read the header: %w", err)
+	}
+
+	names := columns(target, header, records)
+	w := csv.NewWriter(f)
+	if len(header) == 0 {
+		if err := w.Write(names); err != nil {
+			return err
+		}
+	}
+	row := make([]string, len(names))
+	for _, record := range records {
+		for i, name := range names {
+			row[i] = cell(record[name])
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// appendJSONL writes one JSON object per line, narrowed to the sink's
+// columns when it lists any
+func appendJSONL(f *os.File, target *spec.SinkConfig, records []Record) error {
+	enc := json.NewEncoder(f)
+	enc.SetEscapeHTML(false)
+	for _, record := range records {
+		if len(target.Columns) > 0 {
+			narrowed := make(Record, len(target.Columns))
+			for _, name := range target.Columns {
+				narrowed[name] = record[name]
+			}
+			record = narrowed
+		}
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/not7/core/spec"
+)
+
+// sheetsScope is the OAuth scope sheet sinks ask for
+const sheetsScope = "https://www.googleapis.com/auth/spreadsheets"
+
+// sheetsURL is the Google Sheets API, a variable so it can point elsewhere
+var sheetsURL = "https://sheets.googleapis.com/v4/spreadsheets"
+
+// defaultTokenURL is Google's OAuth token endpoint, used when the service
+// account key does not name one
+const defaultTokenURL = "https://oauth2.googleapis.com/token"
+
+// serviceAccount is the part of a Google service account key file that
+// signing in needs
+type serviceAccount struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// appendSheet appends records as rows of a Google Sheet tab. An empty tab
+// gets a header row first, and later rows follow the existing header. The
+// sheet must be shared with the service account.
+func appendSheet(ctx context.Context, credentialsFile string, target *spec.SinkConfig, records []Record) (string, error) {
+	if credentialsFile == "" {
+		return "", fmt.Errorf("sheet sinks need SINKS_GOOGLE_CREDENTIALS in not7.conf")
+	}
+	token, err := googleToken(ctx, credentialsFile)
+	if err != nil {
+		return "", err
+	}
+
+	base := sheetsURL + "/" + url.PathEscape(target.Sheet) + "/values/"
+	var first struct {
+		Values [][]interface{} `json:"values"`
+	}
+	if err := sheetsCall(ctx, http.MethodGet, base+url.PathEscape(sheetRange(target.Tab, "1:1")), token, nil, &first); err != nil {
+		return "", fmt.Errorf("failed to read the sheet's header: %w", err)
+	}
+	var header []string
+	if len(first.Values) > 0 {
+		for _, value := range first.Values[0] {
+			header = append(header, cell(value))
+		}
+	}
+
+	names := columns(target, header, records)
+	var rows [][]interface{}
+	if len(header) == 0 {
+		row := make([]interface{}, len(names))
+		for i, name := range names {
+			row[i] = name
+		}
+		rows = append(rows, row)
+	}
+	for _, record := range records {
+		row := make([]interface{}, len(names))
+		for i, name := range names {
+			row[i] = scalar(record[name])
+			if row[i] == nil {
+				row[i] = ""
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	endpoint := base + url.PathEscape(sheetRange(target.Tab, "A1")) + ":append?valueInputOption=RAW&insertDataOption=INSERT_ROWS"
+	if err := sheetsCall(ctx, http.MethodPost, endpoint, token, map[string]interface{}{"values": rows}, nil); err != nil {
+		return "", fmt.Errorf("failed to append to the sheet: %w", err)
+	}
+	location := "sheet " + target.Sheet
+	if target.Tab != "" {
+		location += " (" + target.Tab + ")"
+	}
+	return location, nil
+}
+
+// sheetRange returns an A1 range, on tab when it is set
+func sheetRange(tab, cells string) string {
+	if tab == "" {
+		return cells
+	}
+	return "'" + strings.ReplaceAll(tab, "'", "''") + "'!" + cells
+}
+
+// sheetsCall sends a Sheets API request and decodes its response into out,
+// if out is not nil
+func sheetsCall(ctx context.Context, method, endpoint, token string, payload, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sheets API returned %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// googleToken signs in as the service account of a key file and returns an
+// access token for the Sheets API
+func googleToken(ctx context.Context, credentialsFile string) (string, error) {
+	data, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Google credentials: %w", err)
+	}
+	var account serviceAccount
+	if err := json.Unmarshal(data, &account); err != nil || account.ClientEmail == "" || account.PrivateKey == "" {
+		return "", fmt.Errorf("%s is not a Google service account key", credentialsFile)
+	}
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return "", fmt.Errorf("%s has no PEM private key", credentialsFile)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("invalid private key in %s: %w", credentialsFile, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", fmt.Errorf("private key in %s is not an RSA key", credentialsFile)
+	}
+	tokenURL := account.TokenURI
+	if tokenURL == "" {
+		tokenURL = defaultTokenURL
+	}
+
+	assertion, err := signJWT(key, map[string]interface{}{
+		"iss":   account.ClientEmail,
+		"scope": sheetsScope,
+		"aud":   tokenURL,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign in to Google: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		AccessToken      string `json:"access_token"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to sign in to Google: %s", resp.Status)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("failed to sign in to Google: %s %s", resp.Status, token.ErrorDescription)
+	}
+	return token.AccessToken, nil
+}
+
+// signJWT returns claims as a JWT signed with RS256
+func signJWT(key *rsa.PrivateKey, claims map[string]interface{}) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	encode := base64.RawURLEncoding.EncodeToString
+	unsigned := encode([]byte(`{"alg":"RS256","typ":"JWT"}`)) + "." + encode(payload)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign Google token request: %w", err)
+	}
+	return unsigned + "." + encode(signature), nil
+}
@@ -0,0 +1,81 @@
+// Package sink appends the records of sink nodes to CSV and JSONL files,
+// Google Sheets and database tables, so recurring agents build up datasets
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/not7/core/config"
+	"github.com/not7/core/spec"
+)
+
+// Record is one row to append: the fields of a JSON object
+type Record = map[string]interface{}
+
+// Write appends records to the sink of target and returns where they went,
+// e.g. "datasets/leads.csv". Fields outside the sink's columns are left
+// out, and missing ones are written empty.
+func Write(ctx context.Context, cfg config.SinksConfig, target *spec.SinkConfig, records []Record) (string, error) {
+	switch {
+	case target.File != "":
+		return appendFile(cfg.Dir, target, records)
+	case target.Sheet != "":
+		return appendSheet(ctx, cfg.GoogleCredentials, target, records)
+	case target.Table != "":
+		return insertRows(ctx, cfg.DatabaseURL, target, records)
+	}
+	return "", fmt.Errorf("sink has no file, sheet or table")
+}
+
+// columns returns the fields to write: the sink's own, else the existing
+// header, else the first record's fields in name order
+func columns(target *spec.SinkConfig, header []string, records []Record) []string {
+	if len(target.Columns) > 0 {
+		return target.Columns
+	}
+	if len(header) > 0 {
+		return header
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(records[0]))
+	for name := range records[0] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// cell formats a field as text. Objects and arrays are written as JSON.
+func cell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// scalar returns a field as a string, number, bool or nil, with objects and
+// arrays as JSON text, for sinks that keep value types
+func scalar(value interface{}) interface{} {
+	switch value.(type) {
+	case nil, string, bool, float64:
+		return value
+	}
+	return cell(value)
+}
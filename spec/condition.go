@@ -0,0 +1,172 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/not7/core/internal/expr"
+	"github.com/not7/core/internal/jsonpath"
+	"github.com/not7/core/internal/script"
+)
+
+// Route condition types
+const (
+	ConditionSuccess    = "success"
+	ConditionFailure    = "failure"
+	ConditionExpression = "expression"
+	ConditionScript     = "script"
+	ConditionLabel      = "label"
+	ConditionSwitch     = "switch"
+	ConditionDefault    = "default"
+)
+
+// ConditionVariables are the names an expression or script condition can
+// refer to:
+//
+//	input   the run input
+//	output  the output of the node the route leaves (the input for start)
+//	nodes   completed nodes by ID, each with output, status, cost and tokens
+//	vars    the spec's variables
+//	cost    the execution's cost so far, in USD
+//	tokens  the execution's prompt and completion tokens so far
+var ConditionVariables = []string{"input", "output", "nodes", "vars", "cost", "tokens"}
+
+// Compile parses an expression condition and checks that it only refers to
+// ConditionVariables
+func (c *Condition) Compile() (*expr.Program, error) {
+	if strings.TrimSpace(c.Expression) == "" {
+		return nil, fmt.Errorf("expression is required")
+	}
+
+	program, err := expr.Compile(c.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range program.Identifiers() {
+		if !isConditionVariable(name) {
+			return nil, fmt.Errorf("unknown variable %s (available: %s)", name, strings.Join(ConditionVariables, ", "))
+		}
+	}
+	return program, nil
+}
+
+// CompileScript parses the JavaScript of a script condition, whose
+// parameters are the ConditionVariables
+func (c *Condition) CompileScript() (*script.Program, error) {
+	if strings.TrimSpace(c.Script) == "" {
+		return nil, fmt.Errorf("script is required")
+	}
+	return script.Compile(c.Script, ConditionVariables)
+}
+
+// CompilePath parses the JSONPath of a switch condition
+func (c *Condition) CompilePath() (*jsonpath.Path, error) {
+	if strings.TrimSpace(c.Path) == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	return jsonpath.Compile(c.Path)
+}
+
+// validateCondition checks a route's condition, compiling expressions
+func validateCondition(route Route) error {
+	c := route.Condition
+	if c == nil {
+		return nil
+	}
+
+	switch c.Type {
+	case ConditionSuccess, ConditionFailure:
+	case ConditionExpression:
+		if _, err := c.Compile(); err != nil {
+			return fmt.Errorf("route %s -> %s: invalid condition: %w", route.From, route.To, err)
+		}
+	case ConditionScript:
+		if _, err := c.CompileScript(); err != nil {
+			return fmt.Errorf("route %s -> %s: invalid condition: %w", route.From, route.To, err)
+		}
+	case ConditionLabel:
+		if strings.TrimSpace(c.Label) == "" {
+			return fmt.Errorf("route %s -> %s: label is required for label conditions", route.From, route.To)
+		}
+	case ConditionSwitch:
+		if _, err := c.CompilePath(); err != nil {
+			return fmt.Errorf("route %s -> %s: invalid condition: %w", route.From, route.To, err)
+		}
+	case ConditionDefault:
+		if route.From == "start" {
+			return fmt.Errorf("route %s -> %s: default conditions need a node to branch on", route.From, route.To)
+		}
+	default:
+		return fmt.Errorf("route %s -> %s: unknown condition type %q (use success, failure, expression, script, label, switch or default)", route.From, route.To, c.Type)
+	}
+	if c.Expression != "" && c.Type != ConditionExpression {
+		return fmt.Errorf("route %s -> %s: expression is only used by expression conditions", route.From, route.To)
+	}
+	if c.Script != "" && c.Type != ConditionScript {
+		return fmt.Errorf("route %s -> %s: script is only used by script conditions", route.From, route.To)
+	}
+	if c.Label != "" && c.Type != ConditionLabel {
+		return fmt.Errorf("route %s -> %s: label is only used by label conditions", route.From, route.To)
+	}
+	if (c.Path != "" || c.Equals != nil) && c.Type != ConditionSwitch {
+		return fmt.Errorf("route %s -> %s: path and equals are only used by switch conditions", route.From, route.To)
+	}
+	return nil
+}
+
+// RouteLabels returns the distinct labels of the label routes leaving a
+// node, in route order
+func RouteLabels(spec *AgentSpec, nodeID string) []string {
+	var labels []string
+	for _, route := range spec.Routes {
+		if route.From != nodeID || route.Condition == nil || route.Condition.Type != ConditionLabel {
+			continue
+		}
+		duplicate := false
+		for _, label := range labels {
+			duplicate = duplicate || SameLabel(label, route.Condition.Label)
+		}
+		if !duplicate {
+			labels = append(labels, route.Condition.Label)
+		}
+	}
+	return labels
+}
+
+// SameLabel reports whether two route labels name the same class; case and
+// surrounding space are ignored
+func SameLabel(a, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+// validateLabels checks that label routes leave conditional nodes only, and
+// that each conditional node has at least two distinct labels to choose
+// from. Several routes may share a label to fan out to all their targets.
+func validateLabels(spec *AgentSpec) error {
+	types := make(map[string]string, len(spec.Nodes))
+	for _, node := range spec.Nodes {
+		types[node.ID] = node.Type
+	}
+	for _, route := range spec.Routes {
+		if route.Condition != nil && route.Condition.Type == ConditionLabel && types[route.From] != "conditional" {
+			return fmt.Errorf("route %s -> %s: label conditions only follow conditional nodes", route.From, route.To)
+		}
+	}
+
+	for _, node := range spec.Nodes {
+		if node.Type == "conditional" && len(RouteLabels(spec, node.ID)) < 2 {
+			return fmt.Errorf("conditional node %s needs label routes to at least two labels", node.ID)
+		}
+	}
+	return nil
+}
+
+func isConditionVariable(name string) bool {
+	for _, v := range ConditionVariables {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
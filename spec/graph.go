@@ -0,0 +1,210 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity classifies a spec issue
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // the spec cannot run correctly
+	SeverityWarning Severity = "warning" // the spec runs but probably not as intended
+)
+
+// Issue is a problem found in the route graph of a spec
+type Issue struct {
+	Severity Severity `json:"severity"`
+	NodeID   string   `json:"node_id,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// String formats the issue as "node ID: message"
+func (i Issue) String() string {
+	if i.NodeID != "" {
+		return fmt.Sprintf("node %s: %s", i.NodeID, i.Message)
+	}
+	return i.Message
+}
+
+// CheckGraph analyses the routes of a spec whose node references are
+// already known to be valid. Cycles and a missing start route are errors,
+// since the executor would loop forever or do nothing. Unreachable nodes,
+// nodes with no path to end and ambiguous unconditional routes are warnings.
+func CheckGraph(spec *AgentSpec) []Issue {
+	var issues []Issue
+
+	next := make(map[string][]Route)
+	prev := make(map[string][]string)
+	for _, route := range spec.Routes {
+		next[route.From] = append(next[route.From], route)
+		prev[route.To] = append(prev[route.To], route.From)
+	}
+
+	if len(next["start"]) == 0 {
+		issues = append(issues, Issue{Severity: SeverityError, Message: "no route from start"})
+	}
+
+	cycles := findCycles(spec, next)
+	for _, cycle := range cycles {
+		issues = append(issues, Issue{
+			Severity: SeverityError,
+			NodeID:   cycle[0],
+			Message:  fmt.Sprintf("routes form a cycle (%s)", strings.Join(cycle, " → ")),
+		})
+	}
+	if len(cycles) == 0 {
+		issues = append(issues, checkJoins(spec, next)...)
+	}
+
+	reachable := walk("start", func(id string) []string {
+		var ids []string
+		for _, route := range next[id] {
+			ids = append(ids, route.To)
+		}
+		return ids
+	})
+	reachesEnd := walk("end", func(id string) []string { return prev[id] })
+
+	for _, node := range spec.Nodes {
+		switch {
+		case !reachable[node.ID]:
+			issues = append(issues, Issue{Severity: SeverityWarning, NodeID: node.ID, Message: "unreachable from start"})
+		case !reachesEnd[node.ID]:
+			issues = append(issues, Issue{Severity: SeverityWarning, NodeID: node.ID, Message: "no path to end"})
+		}
+	}
+
+	for _, from := range append([]string{"start"}, nodeIDs(spec)...) {
+		if issue, ok := checkUnconditionalRoutes(from, next[from]); ok {
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues
+}
+
+// checkJoins reports parallel branches that meet at different join nodes,
+// which is an error, and otherwise join nodes no parallel branches meet at
+func checkJoins(spec *AgentSpec, next map[string][]Route) []Issue {
+	var issues []Issue
+	used := make(map[string]bool)
+	for _, from := range append([]string{"start"}, nodeIDs(spec)...) {
+		if !hasParallelRoute(next[from]) {
+			continue
+		}
+		join, err := spec.JoinOf(from)
+		if err != nil {
+			issues = append(issues, Issue{Severity: SeverityError, NodeID: from, Message: err.Error()})
+		}
+		used[join] = true
+	}
+	if len(issues) > 0 {
+		return issues
+	}
+	for _, node := range spec.Nodes {
+		if node.Join != nil && !used[node.ID] {
+			issues = append(issues, Issue{Severity: SeverityWarning, NodeID: node.ID, Message: "join is set but no parallel branches meet here"})
+		}
+	}
+	return issues
+}
+
+// checkUnconditionalRoutes warns when a node has several routes without a
+// condition, since the executor then runs every target one after another.
+// Parallel routes are meant to be taken together and are left out.
+func checkUnconditionalRoutes(from string, routes []Route) (Issue, bool) {
+	seen := make(map[string]bool)
+	var targets []string
+	for _, route := range routes {
+		if route.Condition != nil || route.Parallel {
+			continue
+		}
+		if seen[route.To] {
+			return Issue{Severity: SeverityWarning, NodeID: from, Message: fmt.Sprintf("duplicate route to %s", route.To)}, true
+		}
+		seen[route.To] = true
+		targets = append(targets, route.To)
+	}
+
+	if len(targets) < 2 {
+		return Issue{}, false
+	}
+	return Issue{
+		Severity: SeverityWarning,
+		NodeID:   from,
+		Message:  fmt.Sprintf("%d routes without conditions (%s) all run, one after another", len(targets), strings.Join(targets, ", ")),
+	}, true
+}
+
+// findCycles returns each cycle found by a depth-first search over the
+// routes, as the node IDs along it with the first repeated at the end
+func findCycles(spec *AgentSpec, next map[string][]Route) [][]string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+	var stack []string
+	var cycles [][]string
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		stack = append(stack, id)
+
+		for _, route := range next[id] {
+			switch state[route.To] {
+			case unvisited:
+				visit(route.To)
+			case visiting:
+				// Back edge: the cycle is the stack from route.To to here
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] == route.To {
+						cycle := append(append([]string(nil), stack[i:]...), route.To)
+						cycles = append(cycles, cycle)
+						break
+					}
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[id] = done
+	}
+
+	for _, id := range append([]string{"start"}, nodeIDs(spec)...) {
+		if state[id] == unvisited {
+			visit(id)
+		}
+	}
+	return cycles
+}
+
+// walk returns the set of IDs reachable from first by following edges
+func walk(first string, edges func(string) []string) map[string]bool {
+	seen := map[string]bool{first: true}
+	queue := []string{first}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, next := range edges(id) {
+			if !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return seen
+}
+
+// nodeIDs lists the node IDs of a spec in declaration order
+func nodeIDs(spec *AgentSpec) []string {
+	ids := make([]string, len(spec.Nodes))
+	for i, node := range spec.Nodes {
+		ids[i] = node.ID
+	}
+	return ids
+}
@@ -0,0 +1,33 @@
+package spec
+
+import "fmt"
+
+// Injection checks on tool output, as set by ToolsConfig.Injection
+const (
+	InjectionFlag  = "flag"  // Record instruction-like passages in the tool call trace
+	InjectionStrip = "strip" // Record them and remove them before the model sees the output
+)
+
+// validateInjection checks a tools config's injection check, if any
+func validateInjection(c *ToolsConfig) error {
+	if c == nil {
+		return nil
+	}
+	switch c.Injection {
+	case "", InjectionFlag, InjectionStrip:
+		return nil
+	}
+	return fmt.Errorf("unknown injection check %q (expected %s or %s)", c.Injection, InjectionFlag, InjectionStrip)
+}
+
+// InjectionFor returns the injection check of a node's tool output: its own
+// tools config's, else the agent's. Empty means no check.
+func InjectionFor(spec *AgentSpec, node *Node) string {
+	if node.Config != nil && node.Config.Tools != nil {
+		return node.Config.Tools.Injection
+	}
+	if spec.Config != nil && spec.Config.Tools != nil {
+		return spec.Config.Tools.Injection
+	}
+	return ""
+}
@@ -0,0 +1,213 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/not7/core/internal/jsonschema"
+)
+
+// ValidateInput checks run input against the spec's input_schema. Input is
+// decoded as JSON unless the schema only allows a string, in which case the
+// raw text is checked. Schema violations are returned together as a
+// *jsonschema.Error. Specs without an input_schema accept any input.
+func ValidateInput(spec *AgentSpec, input string) error {
+	if spec.InputSchema == nil {
+		return nil
+	}
+
+	types := jsonschema.Types(spec.InputSchema)
+	var value interface{}
+	switch {
+	case len(types) == 1 && types[0] == "string":
+		value = input
+	case strings.TrimSpace(input) == "":
+		if !allowsType(types, "null") {
+			return fmt.Errorf("input is required (expected %s)", describeTypes(types))
+		}
+	default:
+		if err := json.Unmarshal([]byte(input), &value); err != nil {
+			if !allowsType(types, "string") {
+				return fmt.Errorf("input must be JSON (expected %s): %v", describeTypes(types), err)
+			}
+			value = input
+		}
+	}
+
+	return jsonschema.Validate(spec.InputSchema, value)
+}
+
+// RequiredInputs lists the required fields of an object input_schema
+func (s *AgentSpec) RequiredInputs() []string {
+	if s.InputSchema == nil {
+		return nil
+	}
+	return jsonschema.Required(s.InputSchema)
+}
+
+// InputParameter describes a field of an object input_schema, from its
+// type, description and default keywords
+type InputParameter struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Required    bool        `json:"required,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+}
+
+// InputParameters lists the fields of an object input_schema: the required
+// ones first, then by name
+func (s *AgentSpec) InputParameters() []InputParameter {
+	properties := inputProperties(s.InputSchema)
+	if len(properties) == 0 {
+		return nil
+	}
+
+	required := map[string]bool{}
+	for _, name := range jsonschema.Required(s.InputSchema) {
+		required[name] = true
+	}
+
+	params := make([]InputParameter, 0, len(properties))
+	for name, property := range properties {
+		description, _ := property["description"].(string)
+		params = append(params, InputParameter{
+			Name:        name,
+			Type:        strings.Join(jsonschema.Types(property), " or "),
+			Description: description,
+			Required:    required[name],
+			Default:     property["default"],
+		})
+	}
+	sort.Slice(params, func(i, j int) bool {
+		if params[i].Required != params[j].Required {
+			return params[i].Required
+		}
+		return params[i].Name < params[j].Name
+	})
+	return params
+}
+
+// ApplyInputDefaults merges the defaults of the spec's input_schema into run
+// input. Fields of a JSON object input that are missing take the default
+// of their property; empty input takes the schema's own default, or an
+// object of the property defaults. Values given at run time always win.
+// Input that is not an object is returned unchanged for ValidateInput to
+// judge.
+func ApplyInputDefaults(spec *AgentSpec, input string) (string, error) {
+	if spec.InputSchema == nil {
+		return input, nil
+	}
+
+	if strings.TrimSpace(input) == "" {
+		if value, ok := spec.InputSchema["default"]; ok {
+			if text, isString := value.(string); isString && allowsOnly(spec.InputSchema, "string") {
+				return text, nil
+			}
+			return marshalInput(value)
+		}
+		if !hasPropertyDefaults(spec.InputSchema) {
+			return input, nil
+		}
+		input = "{}"
+	}
+
+	properties := inputProperties(spec.InputSchema)
+	var fields map[string]interface{}
+	if len(properties) == 0 || json.Unmarshal([]byte(input), &fields) != nil || fields == nil {
+		return input, nil
+	}
+
+	added := false
+	for name, property := range properties {
+		value, ok := property["default"]
+		if _, set := fields[name]; ok && !set {
+			fields[name] = value
+			added = true
+		}
+	}
+	if !added {
+		return input, nil
+	}
+	return marshalInput(fields)
+}
+
+// checkInputDefaults reports defaults that their own schema rejects
+func checkInputDefaults(schema map[string]interface{}) error {
+	if value, ok := schema["default"]; ok {
+		if err := jsonschema.Validate(schema, value); err != nil {
+			return fmt.Errorf("default: %w", err)
+		}
+	}
+	properties := inputProperties(schema)
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if value, ok := properties[name]["default"]; ok {
+			if err := jsonschema.Validate(properties[name], value); err != nil {
+				return fmt.Errorf("default of %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// inputProperties returns the property schemas of an object schema
+func inputProperties(schema map[string]interface{}) map[string]map[string]interface{} {
+	raw, _ := schema["properties"].(map[string]interface{})
+	properties := make(map[string]map[string]interface{}, len(raw))
+	for name, value := range raw {
+		if property, ok := value.(map[string]interface{}); ok {
+			properties[name] = property
+		}
+	}
+	return properties
+}
+
+func hasPropertyDefaults(schema map[string]interface{}) bool {
+	for _, property := range inputProperties(schema) {
+		if _, ok := property["default"]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsOnly reports whether a schema's type is exactly t
+func allowsOnly(schema map[string]interface{}, t string) bool {
+	types := jsonschema.Types(schema)
+	return len(types) == 1 && types[0] == t
+}
+
+func marshalInput(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to apply input defaults: %w", err)
+	}
+	return string(data), nil
+}
+
+// allowsType reports whether a schema's types include t; no types allow all
+func allowsType(types []string, t string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, allowed := range types {
+		if allowed == t {
+			return true
+		}
+	}
+	return false
+}
+
+func describeTypes(types []string) string {
+	if len(types) == 0 {
+		return "a JSON value"
+	}
+	return strings.Join(types, " or ")
+}
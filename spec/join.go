@@ -0,0 +1,142 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Branch error policies, as set by JoinConfig.OnError
+const (
+	JoinFailFast = "fail_fast"      // The first failed branch fails the execution
+	JoinContinue = "continue"       // Failed branches are passed to the join node
+	JoinRequireN = "require_n_of_m" // Failed branches are passed on while Require branches succeed
+)
+
+// JoinConfig makes a node the join of parallel branches. The taken parallel
+// routes of a node each start a branch, which runs until it reaches the join
+// node. The join node then runs once, with the outcome of every branch as
+// its input.
+type JoinConfig struct {
+	OnError string `json:"on_error,omitempty"` // "fail_fast" (default), "continue" or "require_n_of_m"
+	Require int    `json:"require,omitempty"`  // require_n_of_m only: branches that must succeed
+}
+
+// Policy returns the branch error policy, fail_fast when none is set
+func (c *JoinConfig) Policy() string {
+	if c == nil || c.OnError == "" {
+		return JoinFailFast
+	}
+	return c.OnError
+}
+
+// validateJoin checks a node's join settings
+func validateJoin(node Node) error {
+	c := node.Join
+	if c == nil {
+		return nil
+	}
+	switch c.OnError {
+	case "", JoinFailFast, JoinContinue:
+		if c.Require != 0 {
+			return fmt.Errorf("join of node %s: require only applies to %s", node.ID, JoinRequireN)
+		}
+	case JoinRequireN:
+		if c.Require < 1 {
+			return fmt.Errorf("join of node %s: %s needs a positive require", node.ID, JoinRequireN)
+		}
+	default:
+		return fmt.Errorf("join of node %s: unknown on_error %q (expected %s, %s or %s)", node.ID, c.OnError, JoinFailFast, JoinContinue, JoinRequireN)
+	}
+	return nil
+}
+
+// JoinOf returns the join node the branches of a node's parallel routes meet
+// at: the first node with a join config on their paths. Branches of parallel
+// routes inside a branch meet at their own join first. JoinOf returns "" when
+// the branches reach no join node, and an error when they reach several.
+func (s *AgentSpec) JoinOf(from string) (string, error) {
+	next := make(map[string][]Route)
+	for _, route := range s.Routes {
+		next[route.From] = append(next[route.From], route)
+	}
+	joins := make(map[string]bool)
+	for _, node := range s.Nodes {
+		if node.Join != nil {
+			joins[node.ID] = true
+		}
+	}
+	return joinOf(from, next, joins, map[string]bool{})
+}
+
+// joinOf finds the join of from's branches by walking the routes from its
+// parallel targets. forking holds the nodes whose joins are being looked
+// for, so cyclic routes cannot recurse forever.
+func joinOf(from string, next map[string][]Route, joins, forking map[string]bool) (string, error) {
+	forking[from] = true
+	defer delete(forking, from)
+
+	var queue, found []string
+	for _, route := range next[from] {
+		if route.Parallel {
+			queue = append(queue, route.To)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if joins[id] {
+			found = append(found, id)
+			continue
+		}
+
+		// A fork inside the branch: its branches stop at its own join, and
+		// this branch carries on from there
+		routes := next[id]
+		if hasParallelRoute(routes) && !forking[id] {
+			inner, err := joinOf(id, next, joins, forking)
+			if err != nil {
+				return "", err
+			}
+			if inner != "" {
+				seen[inner] = true
+				for _, route := range routes {
+					if !route.Parallel {
+						queue = append(queue, route.To)
+					}
+				}
+				for _, route := range next[inner] {
+					queue = append(queue, route.To)
+				}
+				continue
+			}
+		}
+		for _, route := range routes {
+			queue = append(queue, route.To)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return "", nil
+	case 1:
+		return found[0], nil
+	}
+	return "", fmt.Errorf("parallel branches meet at different join nodes (%s)", strings.Join(found, ", "))
+}
+
+// hasParallelRoute reports whether any of the routes is parallel
+func hasParallelRoute(routes []Route) bool {
+	for _, route := range routes {
+		if route.Parallel {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxSensibleTemperature is the temperature above which output is usually
+// too erratic to be useful, although providers accept up to 2
+const maxSensibleTemperature = 1.5
+
+// Lint looks for spec constructs that are valid but probably mistakes, such
+// as a blank prompt or tools enabled without a tool provider. It returns
+// warnings only; route graph problems are reported by CheckGraph.
+func Lint(spec *AgentSpec) []Issue {
+	var issues []Issue
+	warn := func(nodeID, format string, args ...interface{}) {
+		issues = append(issues, Issue{Severity: SeverityWarning, NodeID: nodeID, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if spec.Config != nil {
+		lintLLMConfig(spec.Config.LLM, "", warn)
+	}
+
+	for _, node := range spec.Nodes {
+		lintLLMConfig(node.LLM, node.ID, warn)
+		if node.Config != nil {
+			lintLLMConfig(node.Config.LLM, node.ID, warn)
+		}
+
+		switch node.Type {
+		case "llm":
+			if node.PromptRef == "" && strings.TrimSpace(node.Prompt) == "" {
+				warn(node.ID, "prompt is blank, so the model gets no instructions")
+			}
+		case "react":
+			if strings.TrimSpace(node.ReActGoal) == "" {
+				warn(node.ID, "react_goal is empty, so the node has nothing to work towards")
+			}
+			if node.ToolsEnabled && toolProvider(spec, &node) == "" {
+				warn(node.ID, "tools_enabled is set but no tool provider is configured (set config.tools.provider)")
+			}
+			if !node.ToolsEnabled && len(node.AvailableTools) > 0 {
+				warn(node.ID, "available_tools is ignored without tools_enabled")
+			}
+		case "tool":
+			if node.ToolName == "" {
+				warn(node.ID, "tool_name is empty, so the node fails when it runs")
+			} else if toolProvider(spec, &node) == "" {
+				warn(node.ID, "no tool provider is configured for tool %s (set config.tools.provider)", node.ToolName)
+			}
+		}
+	}
+
+	hasEnd := false
+	for _, route := range spec.Routes {
+		if route.To == "end" {
+			hasEnd = true
+		}
+		if route.Condition != nil && route.Condition.Type == ConditionFailure {
+			warn(route.From, "the failure route to %s is never taken, since a failed node ends the execution", route.To)
+		}
+	}
+	if !hasEnd {
+		warn("", "no route to end, so the execution has no final output")
+	}
+
+	return issues
+}
+
+// lintLLMConfig warns about temperatures outside the useful range
+func lintLLMConfig(cfg *LLMConfig, nodeID string, warn func(nodeID, format string, args ...interface{})) {
+	if cfg == nil {
+		return
+	}
+	switch {
+	case cfg.Temperature < 0 || cfg.Temperature > 2:
+		warn(nodeID, "temperature %g is outside the range providers accept (0 to 2)", cfg.Temperature)
+	case cfg.Temperature > maxSensibleTemperature:
+		warn(nodeID, "temperature %g is unusually high and tends to produce erratic output", cfg.Temperature)
+	}
+}
+
+// toolProvider returns the tool provider a node uses: its own, else the agent's
+func toolProvider(spec *AgentSpec, node *Node) string {
+	if node.Config != nil && node.Config.Tools != nil {
+		return node.Config.Tools.Provider
+	}
+	if spec.Config != nil && spec.Config.Tools != nil {
+		return spec.Config.Tools.Provider
+	}
+	return ""
+}
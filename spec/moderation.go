@@ -0,0 +1,70 @@
+package spec
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Moderation stages, as set by ModerationConfig.Check
+const (
+	ModerateInput  = "input"
+	ModerateOutput = "output"
+	ModerateBoth   = "both"
+)
+
+// Checks reports whether the policy applies at stage, ModerateInput or
+// ModerateOutput
+func (c *ModerationConfig) Checks(stage string) bool {
+	return c.Check == "" || c.Check == ModerateBoth || c.Check == stage
+}
+
+// BlockedPatterns compiles the policy's blocked expressions
+func (c *ModerationConfig) BlockedPatterns() ([]*regexp.Regexp, error) {
+	patterns := make([]*regexp.Regexp, 0, len(c.Blocked))
+	for _, expr := range c.Blocked {
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("blocked pattern %q: %w", expr, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// validateModeration checks a moderation policy, if any
+func validateModeration(c *ModerationConfig) error {
+	if c == nil {
+		return nil
+	}
+	switch c.Check {
+	case "", ModerateInput, ModerateOutput, ModerateBoth:
+	default:
+		return fmt.Errorf("unknown check %q (expected %s, %s or %s)", c.Check, ModerateInput, ModerateOutput, ModerateBoth)
+	}
+	switch c.Provider {
+	case "":
+		if len(c.Blocked) == 0 {
+			return fmt.Errorf("a policy without a provider needs blocked patterns")
+		}
+		if len(c.Categories) > 0 {
+			return fmt.Errorf("categories require the openai provider")
+		}
+	case "openai":
+	default:
+		return fmt.Errorf("unknown provider %q (expected openai)", c.Provider)
+	}
+	_, err := c.BlockedPatterns()
+	return err
+}
+
+// ModerationFor returns the moderation policy of a node: its own, else the
+// agent's
+func ModerationFor(spec *AgentSpec, node *Node) *ModerationConfig {
+	if node.Config != nil && node.Config.Moderation != nil {
+		return node.Config.Moderation
+	}
+	if spec.Config != nil {
+		return spec.Config.Moderation
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package spec
+
+import "fmt"
+
+// Execution outcomes, as listed in NotificationsConfig.On
+const (
+	NotifySuccess = "success"
+	NotifyFailure = "failure"
+)
+
+// Notification channels, as listed in NotificationsConfig.Channels
+const (
+	ChannelSlack   = "slack"
+	ChannelDiscord = "discord"
+	ChannelEmail   = "email"
+)
+
+// validateNotifications checks an agent's notification settings, if any
+func validateNotifications(c *NotificationsConfig) error {
+	if c == nil {
+		return nil
+	}
+	for _, outcome := range c.On {
+		switch outcome {
+		case NotifySuccess, NotifyFailure:
+		default:
+			return fmt.Errorf("unknown outcome %q (expected %s or %s)", outcome, NotifySuccess, NotifyFailure)
+		}
+	}
+	for _, channel := range c.Channels {
+		switch channel {
+		case ChannelSlack, ChannelDiscord, ChannelEmail:
+		default:
+			return fmt.Errorf("unknown channel %q (expected %s, %s or %s)", channel, ChannelSlack, ChannelDiscord, ChannelEmail)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package spec
+
+import (
+	"fmt"
+
+	"github.com/not7/core/internal/jsonschema"
+)
+
+// Output contract formats
+const (
+	OutputFreeform = "freeform" // any text (the default without a contract)
+	OutputJSON     = "json"     // a JSON document, optionally matching a schema
+	OutputMarkdown = "markdown" // markdown text, not wrapped in a code fence
+)
+
+// DefaultOutputRetries is how many times a node whose output breaks its
+// contract asks the model again, unless the contract says otherwise
+const DefaultOutputRetries = 2
+
+// OutputRetries returns the retries a node contract allows
+func (c *OutputContract) OutputRetries() int {
+	if c.Retries == nil {
+		return DefaultOutputRetries
+	}
+	return *c.Retries
+}
+
+// validateOutputContract checks the output section of a spec, if any
+func validateOutputContract(contract *OutputContract) error {
+	if contract == nil {
+		return nil
+	}
+	if contract.Retries != nil {
+		return fmt.Errorf("output retries only apply to node output contracts")
+	}
+	return validateContract(contract)
+}
+
+// validateNodeOutputContract checks the output section of a node, if any
+func validateNodeOutputContract(node Node) error {
+	if node.Output == nil {
+		return nil
+	}
+	if node.Type != "llm" {
+		return fmt.Errorf("node %s: output contracts are only supported on llm nodes", node.ID)
+	}
+	if node.Output.Retries != nil && *node.Output.Retries < 0 {
+		return fmt.Errorf("node %s: output retries must not be negative", node.ID)
+	}
+	if err := validateContract(node.Output); err != nil {
+		return fmt.Errorf("node %s: %w", node.ID, err)
+	}
+	return nil
+}
+
+// validateContract checks the format and schema of an output contract
+func validateContract(contract *OutputContract) error {
+	switch contract.Format {
+	case OutputFreeform, OutputJSON, OutputMarkdown:
+	case "":
+		return fmt.Errorf("output format is required")
+	default:
+		return fmt.Errorf("unknown output format %q (expected %s, %s or %s)", contract.Format, OutputFreeform, OutputJSON, OutputMarkdown)
+	}
+
+	if contract.Schema != nil {
+		if contract.Format != OutputJSON {
+			return fmt.Errorf("output schema requires the %s format", OutputJSON)
+		}
+		if err := jsonschema.Check(contract.Schema); err != nil {
+			return fmt.Errorf("invalid output schema: %w", err)
+		}
+	}
+	return nil
+}
@@ -2,8 +2,14 @@ package spec
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
+
+	"github.com/not7/core/internal/jsonschema"
+	"github.com/not7/core/internal/tmpl"
 )
 
 // LoadSpec loads and parses a NOT7 agent specification from a JSON file
@@ -13,17 +19,17 @@ func LoadSpec(filepath string) (*AgentSpec, error) {
 		return nil, fmt.Errorf("failed to read spec file: %w", err)
 	}
 
-	var spec AgentSpec
-	if err := json.Unmarshal(data, &spec); err != nil {
+	spec, err := Parse(data)
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse spec JSON: %w", err)
 	}
 
 	// Validate spec
-	if err := ValidateSpec(&spec); err != nil {
+	if err := ValidateSpec(spec); err != nil {
 		return nil, fmt.Errorf("invalid spec: %w", err)
 	}
 
-	return &spec, nil
+	return spec, nil
 }
 
 // ValidateSpec ensures the spec is valid
@@ -40,6 +46,45 @@ func ValidateSpec(spec *AgentSpec) error {
 	if len(spec.Routes) == 0 {
 		return fmt.Errorf("at least one route is required")
 	}
+	for _, tag := range spec.Tags {
+		if strings.TrimSpace(tag) == "" {
+			return fmt.Errorf("tags must not be empty")
+		}
+	}
+	if spec.InputSchema != nil {
+		if err := jsonschema.Check(spec.InputSchema); err != nil {
+			return fmt.Errorf("invalid input_schema: %w", err)
+		}
+		if err := checkInputDefaults(spec.InputSchema); err != nil {
+			return fmt.Errorf("invalid input_schema: %w", err)
+		}
+	}
+	if err := validateOutputContract(spec.Output); err != nil {
+		return err
+	}
+	if err := validatePostprocess(spec.Postprocess); err != nil {
+		return err
+	}
+	if err := validateNotifications(spec.Notifications); err != nil {
+		return fmt.Errorf("notifications: %w", err)
+	}
+	if spec.Config != nil {
+		if err := validateFallbacks(spec.Config.LLM); err != nil {
+			return fmt.Errorf("config.llm: %w", err)
+		}
+		if _, err := spec.Config.Redaction.Rules(); err != nil {
+			return fmt.Errorf("config.redaction: %w", err)
+		}
+		if err := validateModeration(spec.Config.Moderation); err != nil {
+			return fmt.Errorf("config.moderation: %w", err)
+		}
+		if err := validateInjection(spec.Config.Tools); err != nil {
+			return fmt.Errorf("config.tools: %w", err)
+		}
+		if err := validateToolResults(spec.Config.Tools); err != nil {
+			return fmt.Errorf("config.tools: %w", err)
+		}
+	}
 
 	// Validate nodes
 	nodeIDs := make(map[string]bool)
@@ -55,8 +100,57 @@ func ValidateSpec(spec *AgentSpec) error {
 		if node.Type == "" {
 			return fmt.Errorf("node type is required for node %s", node.ID)
 		}
-		if node.Type == "llm" && node.Prompt == "" {
-			return fmt.Errorf("prompt is required for LLM node %s", node.ID)
+		if node.Prompt != "" && node.PromptRef != "" {
+			return fmt.Errorf("node %s sets both prompt and prompt_ref", node.ID)
+		}
+		if node.Type == "llm" && node.Prompt == "" && node.PromptRef == "" {
+			return fmt.Errorf("prompt or prompt_ref is required for LLM node %s", node.ID)
+		}
+		if node.Type == "retrieve" && node.Corpus == "" {
+			return fmt.Errorf("corpus is required for retrieve node %s", node.ID)
+		}
+		if err := validateSink(node); err != nil {
+			return err
+		}
+		if err := validateTransform(node); err != nil {
+			return err
+		}
+		if err := validateJoin(node); err != nil {
+			return err
+		}
+		if node.TopK < 0 {
+			return fmt.Errorf("top_k must not be negative for node %s", node.ID)
+		}
+		if node.Artifact != "" {
+			if err := ValidateArtifactName(node.Artifact); err != nil {
+				return fmt.Errorf("node %s: %w", node.ID, err)
+			}
+		}
+		if err := validateTemplates(node); err != nil {
+			return err
+		}
+		if err := validateNodeOutputContract(node); err != nil {
+			return err
+		}
+		if err := validateFallbacks(node.LLM); err != nil {
+			return fmt.Errorf("node %s: llm: %w", node.ID, err)
+		}
+		if node.Config != nil {
+			if err := validateFallbacks(node.Config.LLM); err != nil {
+				return fmt.Errorf("node %s: config.llm: %w", node.ID, err)
+			}
+			if _, err := node.Config.Redaction.Rules(); err != nil {
+				return fmt.Errorf("node %s: config.redaction: %w", node.ID, err)
+			}
+			if err := validateModeration(node.Config.Moderation); err != nil {
+				return fmt.Errorf("node %s: config.moderation: %w", node.ID, err)
+			}
+			if err := validateInjection(node.Config.Tools); err != nil {
+				return fmt.Errorf("node %s: config.tools: %w", node.ID, err)
+			}
+			if err := validateToolResults(node.Config.Tools); err != nil {
+				return fmt.Errorf("node %s: config.tools: %w", node.ID, err)
+			}
 		}
 	}
 
@@ -72,8 +166,71 @@ func ValidateSpec(spec *AgentSpec) error {
 		if route.To != "end" && !nodeIDs[route.To] {
 			return fmt.Errorf("route references unknown node: %s", route.To)
 		}
+		if err := validateCondition(route); err != nil {
+			return err
+		}
+	}
+	if err := validateLabels(spec); err != nil {
+		return err
+	}
+
+	// Validate the route graph; warnings are left to callers of CheckGraph
+	for _, issue := range CheckGraph(spec) {
+		if issue.Severity == SeverityError {
+			return errors.New(issue.String())
+		}
+	}
+
+	return nil
+}
+
+// validateFallbacks checks that each fallback of an LLM config changes the
+// provider or the model and has no fallbacks of its own
+func validateFallbacks(cfg *LLMConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	for i, fallback := range cfg.Fallbacks {
+		if fallback.Provider == "" && fallback.Model == "" {
+			return fmt.Errorf("fallbacks[%d] needs a provider or a model", i)
+		}
+		if len(fallback.Fallbacks) > 0 {
+			return fmt.Errorf("fallbacks[%d] cannot have fallbacks of its own", i)
+		}
 	}
+	return nil
+}
 
+// validateTemplates checks the {{ }} templates of a node's prompt, ReAct
+// goal, retrieve query and tool arguments
+func validateTemplates(node Node) error {
+	fields := []struct{ name, text string }{
+		{"prompt", node.Prompt},
+		{"react_goal", node.ReActGoal},
+		{"query", node.Query},
+	}
+	for _, field := range fields {
+		if err := tmpl.Check(field.text); err != nil {
+			return fmt.Errorf("node %s: %s: %w", node.ID, field.name, err)
+		}
+	}
+	if err := tmpl.CheckValue(node.ToolArguments); err != nil {
+		return fmt.Errorf("node %s: tool_arguments.%w", node.ID, err)
+	}
+	if err := tmpl.CheckValue(node.Params); err != nil {
+		return fmt.Errorf("node %s: params.%w", node.ID, err)
+	}
+	return nil
+}
+
+// artifactNamePattern restricts artifact names to safe file names
+var artifactNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ValidateArtifactName checks that name can be stored as an artifact file
+func ValidateArtifactName(name string) error {
+	if len(name) > 255 || !artifactNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid artifact name %q: use letters, digits, '.', '_' and '-', starting with a letter or digit", name)
+	}
 	return nil
 }
 
@@ -0,0 +1,40 @@
+package spec
+
+import "fmt"
+
+// Post-processor types, as set by PostProcessor.Type
+const (
+	PostExtractJSON   = "extract_json"   // Keep only the JSON document, dropping markdown fences and prose
+	PostStripPreamble = "strip_preamble" // Drop reasoning blocks and openers like "Sure! Here is the summary:"
+	PostMarkdownHTML  = "markdown_html"  // Render markdown as HTML
+	PostMaxLength     = "max_length"     // Cut the output to Length characters
+)
+
+// PostProcessor is one step applied to an agent's final output before it is
+// stored and returned. Steps run in the order listed, after the output
+// contract is checked.
+type PostProcessor struct {
+	Type   string `json:"type"`             // "extract_json", "strip_preamble", "markdown_html" or "max_length"
+	Length int    `json:"length,omitempty"` // max_length only: characters kept
+}
+
+// validatePostprocess checks the post-processors of a spec
+func validatePostprocess(steps []PostProcessor) error {
+	for i, step := range steps {
+		switch step.Type {
+		case PostExtractJSON, PostStripPreamble, PostMarkdownHTML:
+			if step.Length != 0 {
+				return fmt.Errorf("postprocess[%d]: length only applies to %s", i, PostMaxLength)
+			}
+		case PostMaxLength:
+			if step.Length < 1 {
+				return fmt.Errorf("postprocess[%d]: %s needs a positive length", i, PostMaxLength)
+			}
+		case "":
+			return fmt.Errorf("postprocess[%d]: type is required", i)
+		default:
+			return fmt.Errorf("postprocess[%d]: unknown type %q (expected %s, %s, %s or %s)", i, step.Type, PostExtractJSON, PostStripPreamble, PostMarkdownHTML, PostMaxLength)
+		}
+	}
+	return nil
+}
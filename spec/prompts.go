@@ -0,0 +1,80 @@
+package spec
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ResolvePrompts replaces each node's prompt_ref with the contents of the
+// file it names. A ref is a relative path looked up in dirs in order, so a
+// spec can keep its prompts next to it and share others from a prompts
+// directory; refs may not point outside the directory they are found in.
+func (s *AgentSpec) ResolvePrompts(dirs ...string) error {
+	for i := range s.Nodes {
+		node := &s.Nodes[i]
+		if node.PromptRef == "" {
+			continue
+		}
+		if node.Prompt != "" {
+			return fmt.Errorf("node %s sets both prompt and prompt_ref", node.ID)
+		}
+
+		prompt, err := readPrompt(node.PromptRef, dirs)
+		if err != nil {
+			return fmt.Errorf("node %s: %w", node.ID, err)
+		}
+		node.Prompt = prompt
+		node.PromptRef = ""
+	}
+	return nil
+}
+
+// HasPromptRefs reports whether any node still has an unresolved prompt_ref
+func (s *AgentSpec) HasPromptRefs() bool {
+	for _, node := range s.Nodes {
+		if node.PromptRef != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func readPrompt(ref string, dirs []string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(ref)) {
+		return "", fmt.Errorf("prompt_ref %q must be a relative path inside the prompts directory", ref)
+	}
+
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(ref)))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read prompt_ref %q: %w", ref, err)
+		}
+		prompt := strings.TrimSpace(string(data))
+		if prompt == "" {
+			return "", fmt.Errorf("prompt_ref %q is empty", ref)
+		}
+		return prompt, nil
+	}
+
+	return "", fmt.Errorf("prompt_ref %q not found in %s", ref, strings.Join(nonEmpty(dirs), ", "))
+}
+
+func nonEmpty(values []string) []string {
+	var kept []string
+	for _, v := range values {
+		if v != "" {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,24 @@
+package spec
+
+import "github.com/not7/core/internal/redact"
+
+// Rules compiles the redaction config. A nil config redacts nothing and
+// yields nil rules.
+func (c *RedactionConfig) Rules() (*redact.Rules, error) {
+	if c == nil {
+		return nil, nil
+	}
+	return redact.Compile(c.Entities, c.Patterns)
+}
+
+// RedactionFor returns the redaction config of a node: its own, else the
+// agent's
+func RedactionFor(spec *AgentSpec, node *Node) *RedactionConfig {
+	if node.Config != nil && node.Config.Redaction != nil {
+		return node.Config.Redaction
+	}
+	if spec.Config != nil {
+		return spec.Config.Redaction
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package spec
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// SchemaID is the published location of the agent spec JSON Schema.
+// Specs may reference it with "$schema" for editor completion.
+const SchemaID = "https://github.com/not7/core/raw/main/agent.schema.json"
+
+//go:generate go run .. schema --output ../agent.schema.json
+
+// requiredFields lists the fields ValidateSpec insists on. Required-ness is
+// not derived from omitempty, which many optional fields lack.
+var requiredFields = map[reflect.Type][]string{
+	reflect.TypeOf(AgentSpec{}):      {"version", "goal", "nodes", "routes"},
+	reflect.TypeOf(Node{}):           {"id", "type"},
+	reflect.TypeOf(Route{}):          {"from", "to"},
+	reflect.TypeOf(Condition{}):      {"type"},
+	reflect.TypeOf(OutputContract{}): {"format"},
+	reflect.TypeOf(PostProcessor{}):  {"type"},
+}
+
+// fieldEnums lists the accepted values of string fields, by type and field
+var fieldEnums = map[reflect.Type]map[string][]string{
+	reflect.TypeOf(Node{}):              {"type": {"llm", "react", "tool", "retrieve", "conditional", "transform", "sink"}},
+	reflect.TypeOf(Condition{}):         {"type": {"success", "failure", "expression", "script", "label", "switch", "default"}},
+	reflect.TypeOf(OutputContract{}):    {"format": {OutputFreeform, OutputJSON, OutputMarkdown}},
+	reflect.TypeOf(ModerationConfig{}):  {"check": {ModerateInput, ModerateOutput, ModerateBoth}, "provider": {"openai"}},
+	reflect.TypeOf(ToolsConfig{}):       {"injection": {InjectionFlag, InjectionStrip}},
+	reflect.TypeOf(ToolResultsConfig{}): {"strategy": {ResultsTruncate, ResultsHeuristic, ResultsSummarize}},
+	reflect.TypeOf(PostProcessor{}):     {"type": {PostExtractJSON, PostStripPreamble, PostMarkdownHTML, PostMaxLength}},
+	reflect.TypeOf(JoinConfig{}):        {"on_error": {JoinFailFast, JoinContinue, JoinRequireN}},
+	reflect.TypeOf(NotificationsConfig{}): {
+		"on":       {NotifySuccess, NotifyFailure},
+		"channels": {ChannelSlack, ChannelDiscord, ChannelEmail},
+	},
+}
+
+// Schema returns the JSON Schema (draft 2020-12) of AgentSpec, generated
+// from the Go types so it cannot drift from what the parser accepts
+func Schema() ([]byte, error) {
+	defs := map[string]interface{}{}
+	root := schemaFor(reflect.TypeOf(AgentSpec{}), defs)
+
+	schema := map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"$id":         SchemaID,
+		"title":       "NOT7 agent specification",
+		"description": "Declarative agent graph run by the NOT7 runtime",
+		"$ref":        root["$ref"],
+		"$defs":       defs,
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// schemaFor returns the schema of t, adding struct definitions to defs
+func schemaFor(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem(), defs)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem(), defs)}
+	case reflect.Struct:
+		ref := map[string]interface{}{"$ref": "#/$defs/" + t.Name()}
+		if _, done := defs[t.Name()]; done {
+			return ref
+		}
+		defs[t.Name()] = nil // reserve the name for recursive types
+
+		properties := map[string]interface{}{}
+		for _, field := range jsonFields(t) {
+			property := schemaFor(field.Type, defs)
+			if enum, ok := fieldEnums[t][field.Name]; ok {
+				if items, ok := property["items"].(map[string]interface{}); ok {
+					items["enum"] = enum
+				} else {
+					property["enum"] = enum
+				}
+			}
+			properties[field.Name] = property
+		}
+
+		def := map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+		if required, ok := requiredFields[t]; ok {
+			def["required"] = required
+		}
+		defs[t.Name()] = def
+		return ref
+	default:
+		// interface{} holds arbitrary JSON
+		return map[string]interface{}{}
+	}
+}
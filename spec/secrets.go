@@ -0,0 +1,87 @@
+package spec
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// secretPattern matches {{secret.NAME}} references
+var secretPattern = regexp.MustCompile(`\{\{\s*secret\.([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// SecretRef returns the {{secret.NAME}} reference to a named secret
+func SecretRef(name string) string {
+	return "{{secret." + strings.ToUpper(name) + "}}"
+}
+
+// SecretNames returns the upper-case names of the secrets referenced as
+// {{secret.NAME}} in a text, in order of first use
+func SecretNames(text string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, match := range secretPattern.FindAllStringSubmatch(text, -1) {
+		name := strings.ToUpper(match[1])
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ReplaceSecrets replaces each {{secret.NAME}} in text with the value
+// lookup returns for its upper-case name
+func ReplaceSecrets(text string, lookup func(name string) (string, error)) (string, error) {
+	var err error
+	replaced := secretPattern.ReplaceAllStringFunc(text, func(ref string) string {
+		if err != nil {
+			return ref
+		}
+		var value string
+		value, err = lookup(strings.ToUpper(secretPattern.FindStringSubmatch(ref)[1]))
+		return value
+	})
+	if err != nil {
+		return text, err
+	}
+	return replaced, nil
+}
+
+// SecretNames returns the secrets the spec references in its tool nodes'
+// tool arguments, the only place they resolve, sorted
+func (s *AgentSpec) SecretNames() []string {
+	seen := map[string]bool{}
+	add := func(text string) {
+		for _, name := range SecretNames(text) {
+			seen[name] = true
+		}
+	}
+	var addValue func(value interface{})
+	addValue = func(value interface{}) {
+		switch v := value.(type) {
+		case string:
+			add(v)
+		case map[string]interface{}:
+			for _, item := range v {
+				addValue(item)
+			}
+		case []interface{}:
+			for _, item := range v {
+				addValue(item)
+			}
+		}
+	}
+
+	for _, node := range s.Nodes {
+		for _, value := range node.ToolArguments {
+			addValue(value)
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,97 @@
+package spec
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// File formats of sink nodes, by file extension
+const (
+	SinkCSV   = "csv"
+	SinkJSONL = "jsonl"
+)
+
+// sqlIdentifierPattern matches the table and column names of table sinks
+var sqlIdentifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SinkConfig is where a sink node appends the records of its input, a JSON
+// object or an array of objects. Exactly one of File, Sheet and Table is set.
+type SinkConfig struct {
+	File    string   `json:"file,omitempty"`    // .csv or .jsonl file in SINKS_DIR, e.g. leads/2024.csv
+	Sheet   string   `json:"sheet,omitempty"`   // Google Sheet ID, from the sheet's URL
+	Tab     string   `json:"tab,omitempty"`     // Tab of the sheet (default: the first)
+	Table   string   `json:"table,omitempty"`   // Table in SINKS_DATABASE_URL, e.g. leads or crm.leads
+	Columns []string `json:"columns,omitempty"` // Fields written, in order; default: the existing header, else the first record's fields
+}
+
+// FileFormat returns SinkCSV or SinkJSONL for a file sink, by its extension
+func (c *SinkConfig) FileFormat() string {
+	switch strings.ToLower(path.Ext(c.File)) {
+	case ".csv":
+		return SinkCSV
+	case ".jsonl", ".ndjson":
+		return SinkJSONL
+	}
+	return ""
+}
+
+// validateSink checks a node's sink settings against its type
+func validateSink(node Node) error {
+	if node.Type != "sink" {
+		if node.Sink != nil {
+			return fmt.Errorf("sink is only used by sink nodes, not %s node %s", node.Type, node.ID)
+		}
+		return nil
+	}
+	c := node.Sink
+	if c == nil {
+		return fmt.Errorf("sink is required for sink node %s", node.ID)
+	}
+
+	targets := 0
+	for _, target := range []string{c.File, c.Sheet, c.Table} {
+		if target != "" {
+			targets++
+		}
+	}
+	if targets != 1 {
+		return fmt.Errorf("sink of node %s needs exactly one of file, sheet and table", node.ID)
+	}
+
+	switch {
+	case c.File != "":
+		clean := path.Clean(c.File)
+		if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") || strings.Contains(c.File, `\`) {
+			return fmt.Errorf("sink file of node %s must be a relative path inside SINKS_DIR", node.ID)
+		}
+		if c.FileFormat() == "" {
+			return fmt.Errorf("sink file of node %s must end in .csv or .jsonl", node.ID)
+		}
+	case c.Table != "":
+		for _, part := range strings.Split(c.Table, ".") {
+			if !sqlIdentifierPattern.MatchString(part) {
+				return fmt.Errorf("sink table %q of node %s is not a valid table name", c.Table, node.ID)
+			}
+		}
+	}
+	if c.Tab != "" && c.Sheet == "" {
+		return fmt.Errorf("sink tab of node %s needs a sheet", node.ID)
+	}
+
+	seen := make(map[string]bool)
+	for _, column := range c.Columns {
+		if strings.TrimSpace(column) == "" {
+			return fmt.Errorf("sink columns of node %s must not be empty", node.ID)
+		}
+		if seen[column] {
+			return fmt.Errorf("sink of node %s lists column %q twice", node.ID, column)
+		}
+		seen[column] = true
+		if c.Table != "" && !sqlIdentifierPattern.MatchString(column) {
+			return fmt.Errorf("sink column %q of node %s is not a valid column name", column, node.ID)
+		}
+	}
+	return nil
+}
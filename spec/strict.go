@@ -0,0 +1,216 @@
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// UnknownField is a field in a spec document that AgentSpec does not define
+type UnknownField struct {
+	Path       string // e.g. nodes[0].promt
+	Suggestion string // closest known field name, if any
+}
+
+func (f UnknownField) String() string {
+	if f.Suggestion != "" {
+		return fmt.Sprintf("unknown field %q (did you mean %q?)", f.Path, f.Suggestion)
+	}
+	return fmt.Sprintf("unknown field %q", f.Path)
+}
+
+// UnknownFieldsError lists every unknown field of a spec document, so a
+// misspelled key is reported instead of being silently ignored
+type UnknownFieldsError struct {
+	Fields []UnknownField
+}
+
+func (e *UnknownFieldsError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.String()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Parse decodes a spec document strictly: fields AgentSpec does not define
+// are rejected with an *UnknownFieldsError. Documents written against an
+// older schema version are migrated first, with a warning. The spec is not
+// validated.
+func Parse(data []byte) (*AgentSpec, error) {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	var warnings []Issue
+	if doc, ok := raw.(map[string]interface{}); ok {
+		from, err := migrate(doc)
+		if err != nil {
+			return nil, err
+		}
+		if from != "" && from != CurrentVersion {
+			warnings = append(warnings, Issue{
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("spec version %s is outdated and was migrated to %s (update the file with: not7 migrate -w <file>)", from, CurrentVersion),
+			})
+			if data, err = json.Marshal(doc); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var unknown []UnknownField
+	findUnknownFields(raw, reflect.TypeOf(AgentSpec{}), "", &unknown)
+	if len(unknown) > 0 {
+		return nil, &UnknownFieldsError{Fields: unknown}
+	}
+
+	// Everything is known by now; the decoder enforces it as a backstop
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var spec AgentSpec
+	if err := dec.Decode(&spec); err != nil {
+		return nil, err
+	}
+	spec.warnings = warnings
+	return &spec, nil
+}
+
+// findUnknownFields walks a decoded JSON value alongside the Go type it
+// decodes into and collects the object keys that type does not define.
+// Type mismatches are left for the decoder to report.
+func findUnknownFields(raw interface{}, t reflect.Type, path string, unknown *[]UnknownField) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return
+		}
+		fields := jsonFields(t)
+		for _, key := range sortedKeys(obj) {
+			field, ok := lookupField(fields, key)
+			if !ok {
+				*unknown = append(*unknown, UnknownField{Path: joinPath(path, key), Suggestion: suggestField(fields, key)})
+				continue
+			}
+			findUnknownFields(obj[key], field.Type, joinPath(path, key), unknown)
+		}
+	case reflect.Slice:
+		items, ok := raw.([]interface{})
+		if !ok {
+			return
+		}
+		for i, item := range items {
+			findUnknownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), unknown)
+		}
+	case reflect.Map:
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for _, key := range sortedKeys(obj) {
+			findUnknownFields(obj[key], t.Elem(), joinPath(path, key), unknown)
+		}
+	}
+}
+
+// jsonField is a struct field as seen by encoding/json
+type jsonField struct {
+	Name      string
+	Type      reflect.Type
+	OmitEmpty bool
+}
+
+// jsonFields lists the JSON fields of a struct type in declaration order
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, jsonField{Name: name, Type: f.Type, OmitEmpty: strings.Contains(opts, "omitempty")})
+	}
+	return fields
+}
+
+// lookupField finds the field for a key, ignoring case like encoding/json
+func lookupField(fields []jsonField, key string) (jsonField, bool) {
+	for _, f := range fields {
+		if f.Name == key {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.Name, key) {
+			return f, true
+		}
+	}
+	return jsonField{}, false
+}
+
+// suggestField returns the known field closest to a misspelled key, or ""
+// when nothing is close enough to be a plausible typo
+func suggestField(fields []jsonField, key string) string {
+	best, bestDistance := "", len(key)/3+2 // allow roughly one typo per three letters
+	for _, f := range fields {
+		if d := editDistance(strings.ToLower(key), f.Name); d < bestDistance {
+			best, bestDistance = f.Name, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,62 @@
+package spec
+
+import "fmt"
+
+// Strategies for tool results longer than their budget, as set by
+// ToolResultsConfig.Strategy
+const (
+	ResultsTruncate  = "truncate"  // Cut the result at the budget (the default)
+	ResultsHeuristic = "heuristic" // Keep the start and end, compacted, and save the full result
+	ResultsSummarize = "summarize" // Have the node's model summarize it, and save the full result
+)
+
+// DefaultResultMaxTokens is the budget of a tool result in the ReAct
+// context when none is set, about 500 characters
+const DefaultResultMaxTokens = 125
+
+// ToolResultsConfig sets how tool results that exceed a token budget are
+// shown to a ReAct node's model
+type ToolResultsConfig struct {
+	Strategy  string `json:"strategy,omitempty"`   // "truncate" (default), "heuristic" or "summarize"
+	MaxTokens int    `json:"max_tokens,omitempty"` // Budget per result; DefaultResultMaxTokens when 0
+}
+
+// validateToolResults checks a tools config's result strategy, if any
+func validateToolResults(c *ToolsConfig) error {
+	if c == nil || c.Results == nil {
+		return nil
+	}
+	switch c.Results.Strategy {
+	case "", ResultsTruncate, ResultsHeuristic, ResultsSummarize:
+	default:
+		return fmt.Errorf("results: unknown strategy %q (expected %s, %s or %s)", c.Results.Strategy, ResultsTruncate, ResultsHeuristic, ResultsSummarize)
+	}
+	if c.Results.MaxTokens < 0 {
+		return fmt.Errorf("results: max_tokens must not be negative")
+	}
+	return nil
+}
+
+// ToolResultsFor returns the strategy and token budget of a node's tool
+// results: its own tools config's, else the agent's, else truncation at
+// DefaultResultMaxTokens
+func ToolResultsFor(spec *AgentSpec, node *Node) (string, int) {
+	var c *ToolResultsConfig
+	switch {
+	case node.Config != nil && node.Config.Tools != nil && node.Config.Tools.Results != nil:
+		c = node.Config.Tools.Results
+	case spec.Config != nil && spec.Config.Tools != nil:
+		c = spec.Config.Tools.Results
+	}
+
+	strategy, maxTokens := ResultsTruncate, DefaultResultMaxTokens
+	if c != nil {
+		if c.Strategy != "" {
+			strategy = c.Strategy
+		}
+		if c.MaxTokens > 0 {
+			maxTokens = c.MaxTokens
+		}
+	}
+	return strategy, maxTokens
+}
@@ -0,0 +1,68 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/not7/core/internal/expr"
+	"github.com/not7/core/internal/script"
+)
+
+// TransformVariables are the names a transform node's expression or script
+// can refer to: data, its input decoded as JSON (null when the input is not
+// JSON), and the ConditionVariables, in which output is the node's input as
+// text
+var TransformVariables = append([]string{"data"}, ConditionVariables...)
+
+// CompileTransform parses a transform node's expression and checks that it
+// only refers to TransformVariables
+func (n *Node) CompileTransform() (*expr.Program, error) {
+	if strings.TrimSpace(n.Expression) == "" {
+		return nil, fmt.Errorf("expression is required")
+	}
+
+	program, err := expr.Compile(n.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range program.Identifiers() {
+		if name != "data" && !isConditionVariable(name) {
+			return nil, fmt.Errorf("unknown variable %s (available: %s)", name, strings.Join(TransformVariables, ", "))
+		}
+	}
+	return program, nil
+}
+
+// CompileScript parses a transform node's script, whose parameters are the
+// TransformVariables
+func (n *Node) CompileScript() (*script.Program, error) {
+	if strings.TrimSpace(n.Script) == "" {
+		return nil, fmt.Errorf("script is required")
+	}
+	return script.Compile(n.Script, TransformVariables)
+}
+
+// validateTransform checks a node's expression or script against its type
+func validateTransform(node Node) error {
+	if node.Type != "transform" {
+		if node.Expression != "" || node.Script != "" {
+			return fmt.Errorf("expression and script are only used by transform nodes, not %s node %s", node.Type, node.ID)
+		}
+		return nil
+	}
+
+	var err error
+	switch {
+	case node.Expression != "" && node.Script != "":
+		err = fmt.Errorf("set either expression or script, not both")
+	case node.Script != "":
+		_, err = node.CompileScript()
+	default:
+		_, err = node.CompileTransform()
+	}
+	if err != nil {
+		return fmt.Errorf("transform node %s: %w", node.ID, err)
+	}
+	return nil
+}
@@ -2,28 +2,78 @@ package spec
 
 // AgentSpec represents the complete NOT7 agent specification
 type AgentSpec struct {
-	ID       string         `json:"id,omitempty"`
-	Version  string         `json:"version"`
-	Goal     string         `json:"goal"`
-	Config   *Config        `json:"config,omitempty"`
-	Nodes    []Node         `json:"nodes"`
-	Routes   []Route        `json:"routes"`
-	Metadata *Metadata      `json:"metadata,omitempty"`
+	Schema        string                 `json:"$schema,omitempty"` // JSON Schema reference for editors, ignored at runtime
+	ID            string                 `json:"id,omitempty"`
+	Version       string                 `json:"version"` // Schema version, see CurrentVersion
+	Goal          string                 `json:"goal"`
+	Description   string                 `json:"description,omitempty"`
+	Owner         string                 `json:"owner,omitempty"`         // Team or person responsible, searchable in the agent registry
+	Tags          []string               `json:"tags,omitempty"`          // Labels for finding the agent in the registry
+	Vars          map[string]string      `json:"vars,omitempty"`          // Defaults for ${name} references, overridable with --var
+	InputSchema   map[string]interface{} `json:"input_schema,omitempty"`  // JSON Schema the run input must match
+	Output        *OutputContract        `json:"output,omitempty"`        // Expected format of the final output
+	Postprocess   []PostProcessor        `json:"postprocess,omitempty"`   // Steps applied to the final output, in order
+	Notifications *NotificationsConfig   `json:"notifications,omitempty"` // How the outcome of each execution is announced
+	Config        *Config                `json:"config,omitempty"`
+	Nodes         []Node                 `json:"nodes"`
+	Routes        []Route                `json:"routes"`
+	Metadata      *Metadata              `json:"metadata,omitempty"`
+
+	warnings []Issue // problems that did not stop parsing
+}
+
+// OutputContract declares the expected format of an agent's final output,
+// or of an llm node's output. The executor coerces the output towards it.
+// An agent's violations are recorded in Metadata.OutputViolations instead of
+// failing the execution; a node asks the model again, with the violations,
+// up to Retries times and fails if the output still breaks the contract.
+type OutputContract struct {
+	Format  string                 `json:"format"`            // "freeform", "json" or "markdown"
+	Schema  map[string]interface{} `json:"schema,omitempty"`  // JSON Schema the output must match (json format only)
+	Retries *int                   `json:"retries,omitempty"` // Node contracts only: retries after a violation (default 2)
+}
+
+// NotificationsConfig narrows the announcements of an agent's executions on
+// the channels configured on the server
+type NotificationsConfig struct {
+	On       []string `json:"on,omitempty"`       // Outcomes announced: "success" and/or "failure"; the server's NOTIFY_ON when empty
+	Channels []string `json:"channels,omitempty"` // "slack", "discord" and/or "email"; every configured channel when empty
+	Email    []string `json:"email,omitempty"`    // Recipients instead of the server's NOTIFY_EMAIL_TO
 }
 
 // Config holds global configuration
 type Config struct {
-	LLM         *LLMConfig    `json:"llm,omitempty"`
-	Constraints *Constraints  `json:"constraints,omitempty"`
-	Tools       *ToolsConfig  `json:"tools,omitempty"`
+	LLM         *LLMConfig        `json:"llm,omitempty"`
+	Constraints *Constraints      `json:"constraints,omitempty"`
+	Tools       *ToolsConfig      `json:"tools,omitempty"`
+	Redaction   *RedactionConfig  `json:"redaction,omitempty"`  // Personal data kept from external LLM providers
+	Moderation  *ModerationConfig `json:"moderation,omitempty"` // Policy node inputs and outputs are checked against
 }
 
 // LLMConfig defines language model settings
 type LLMConfig struct {
-	Provider    string  `json:"provider"`
-	Model       string  `json:"model"`
-	Temperature float64 `json:"temperature,omitempty"`
-	MaxTokens   int     `json:"max_tokens,omitempty"`
+	Provider    string      `json:"provider"`
+	Model       string      `json:"model"`
+	Temperature float64     `json:"temperature,omitempty"`
+	MaxTokens   int         `json:"max_tokens,omitempty"`
+	Fallbacks   []LLMConfig `json:"fallbacks,omitempty"` // Tried in order when the provider fails; unset fields are inherited
+}
+
+// RedactionConfig selects the personal data replaced with placeholders
+// before text is sent to an external LLM provider. The values are put back
+// into the model's answers.
+type RedactionConfig struct {
+	Entities []string          `json:"entities,omitempty"` // Built-in entities: email, credit_card, ssn, ip_address, phone
+	Patterns map[string]string `json:"patterns,omitempty"` // Custom regular expressions by name, e.g. employee_id
+}
+
+// ModerationConfig is a policy node inputs and outputs must pass. Text the
+// policy rejects is not run or passed on, and the node is marked blocked.
+type ModerationConfig struct {
+	Check      string   `json:"check,omitempty"`      // "input", "output" or "both" (default)
+	Provider   string   `json:"provider,omitempty"`   // "openai" to use its moderation endpoint; local rules only when empty
+	Categories []string `json:"categories,omitempty"` // Moderation categories that block; any flagged category when empty
+	Blocked    []string `json:"blocked,omitempty"`    // Regular expressions whose matches block, checked locally
 }
 
 // Constraints define execution limits
@@ -35,21 +85,27 @@ type Constraints struct {
 
 // ToolsConfig defines tool provider settings
 type ToolsConfig struct {
-	Provider     string   `json:"provider"`      // "builtin" or "mcp"
-	Enabled      []string `json:"enabled,omitempty"` // List of enabled tool names (optional)
+	Provider  string             `json:"provider"`            // "builtin" or "mcp"
+	Enabled   []string           `json:"enabled,omitempty"`   // List of enabled tool names (optional)
+	Injection string             `json:"injection,omitempty"` // "flag" or "strip" instructions found in tool output; unchecked when empty
+	Results   *ToolResultsConfig `json:"results,omitempty"`   // How results over a token budget are shortened
 }
 
 // Node represents a single execution unit
 type Node struct {
 	ID           string     `json:"id"`
 	Name         string     `json:"name"`
-	Type         string     `json:"type"` // "llm", "react", "tool", "transform", "conditional"
+	Type         string     `json:"type"` // "llm", "react", "tool", "retrieve", "conditional", "transform", "sink", or a type registered with executor.RegisterNodeType
 	Prompt       string     `json:"prompt,omitempty"`
+	PromptRef    string     `json:"prompt_ref,omitempty"` // File holding the prompt, resolved by ResolvePrompts
 	InputFormat  string     `json:"input_format,omitempty"`
 	OutputFormat string     `json:"output_format,omitempty"`
 	LLM          *LLMConfig `json:"llm,omitempty"`
 	Config       *Config    `json:"config,omitempty"` // Node-level config (overrides agent-level)
 
+	// Output is the format the node's output must have (llm nodes only)
+	Output *OutputContract `json:"output,omitempty"`
+
 	// ReAct-specific fields
 	ReActGoal      string `json:"react_goal,omitempty"`
 	MaxIterations  int    `json:"max_iterations,omitempty"`
@@ -60,6 +116,32 @@ type Node struct {
 	AvailableTools []string `json:"available_tools,omitempty"`  // Whitelist of tools for ReAct
 	ToolName       string   `json:"tool_name,omitempty"`        // Tool name for explicit tool nodes
 	ToolArguments  map[string]interface{} `json:"tool_arguments,omitempty"` // Arguments for explicit tool nodes
+
+	// Retrieve-specific fields
+	Corpus string `json:"corpus,omitempty"` // Corpus ingested with not7 ingest
+	TopK   int    `json:"top_k,omitempty"`  // Chunks to retrieve (default 4)
+	Query  string `json:"query,omitempty"`  // Search query template, {{input}} is the node input (default: the input)
+
+	// Transform-specific fields
+	Expression string `json:"expression,omitempty"` // Computes the output from the input, see TransformVariables
+	Script     string `json:"script,omitempty"`     // JavaScript that computes the output instead of an expression
+
+	// Sink-specific fields
+	Sink *SinkConfig `json:"sink,omitempty"` // Where the input's records are appended
+
+	// Join makes the node the join of the parallel branches that reach it
+	Join *JoinConfig `json:"join,omitempty"`
+
+	// Params configure node types registered by embedders. Their strings are
+	// templates rendered against the input, like tool_arguments.
+	Params map[string]interface{} `json:"params,omitempty"`
+
+	// Artifact saves the node's output as an execution artifact with this name
+	Artifact string `json:"artifact,omitempty"`
+
+	// Annotations describe the node to readers, e.g. owner, description and
+	// tags. They do not affect execution and are copied into its NodeResult.
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 // Route defines connection between nodes
@@ -67,35 +149,79 @@ type Route struct {
 	From      string     `json:"from"`
 	To        string     `json:"to"`
 	Condition *Condition `json:"condition,omitempty"`
-	Parallel  bool       `json:"parallel,omitempty"`
+	Parallel  bool       `json:"parallel,omitempty"` // Starts a branch that runs until its join node, see JoinConfig
 }
 
 // Condition defines routing logic
 type Condition struct {
-	Type       string `json:"type"`       // "success", "failure", "expression"
-	Expression string `json:"expression,omitempty"`
+	Type       string      `json:"type"` // "success", "failure", "expression", "script", "label", "switch", "default"
+	Expression string      `json:"expression,omitempty"`
+	Script     string      `json:"script,omitempty"` // JavaScript returning whether the route is taken, for script conditions
+	Label      string      `json:"label,omitempty"`  // Class a conditional node must choose for the route to be taken
+	Path       string      `json:"path,omitempty"`   // JSONPath into the node's JSON output, for switch conditions
+	Equals     interface{} `json:"equals,omitempty"` // Value the path must hold; any value when omitted
 }
 
 // Metadata holds execution results
 type Metadata struct {
-	CreatedAt       string        `json:"created_at,omitempty"`
-	ExecutedAt      string        `json:"executed_at,omitempty"`
-	ExecutionTimeMs int64         `json:"execution_time_ms,omitempty"`
-	TotalCost       float64       `json:"total_cost,omitempty"`
-	Status          string        `json:"status,omitempty"`
-	NodeResults     []NodeResult  `json:"node_results,omitempty"`
+	CreatedAt        string       `json:"created_at,omitempty"`
+	ExecutedAt       string       `json:"executed_at,omitempty"`
+	ExecutionTimeMs  int64        `json:"execution_time_ms,omitempty"`
+	TotalCost        float64      `json:"total_cost,omitempty"`
+	PromptTokens     int          `json:"prompt_tokens,omitempty"`     // Summed over all nodes
+	CompletionTokens int          `json:"completion_tokens,omitempty"` // Summed over all nodes
+	Status           string       `json:"status,omitempty"`
+	OutputViolations []string     `json:"output_violations,omitempty"` // Ways the final output breaks the spec's output contract
+	NodeResults      []NodeResult `json:"node_results,omitempty"`
+	Artifacts        []Artifact   `json:"artifacts,omitempty"` // Files saved by nodes and tools, in order
+	Attachments      []Attachment `json:"attachments,omitempty"` // Files sent with the run
+}
+
+// Attachment describes a file sent with a run as input. Nodes read it with
+// the ReadAttachment tool.
+type Attachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+}
+
+// Artifact describes a file saved by a node or tool during execution. Its
+// content is stored with the execution, not in the trace.
+type Artifact struct {
+	Name        string `json:"name"`
+	NodeID      string `json:"node_id"`
+	ToolName    string `json:"tool_name,omitempty"` // Set when a tool produced it
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+	CreatedAt   string `json:"created_at"`
 }
 
 // NodeResult holds results from a single node execution
 type NodeResult struct {
-	NodeID          string      `json:"node_id"`
-	Status          string      `json:"status"`
-	ExecutionTimeMs int64       `json:"execution_time_ms"`
-	Cost            float64     `json:"cost,omitempty"`
-	Input           interface{} `json:"input,omitempty"`
-	Output          interface{} `json:"output,omitempty"`
-	Error           string      `json:"error,omitempty"`
-	ReActTrace      *ReActTrace `json:"react_trace,omitempty"`
+	NodeID           string            `json:"node_id"`
+	Annotations      map[string]string `json:"annotations,omitempty"` // Copied from the node
+	Status           string            `json:"status"`
+	ExecutionTimeMs  int64             `json:"execution_time_ms"`
+	Cost             float64           `json:"cost,omitempty"`
+	PromptTokens     int               `json:"prompt_tokens,omitempty"`
+	CompletionTokens int               `json:"completion_tokens,omitempty"`
+	Input            interface{}       `json:"input,omitempty"`
+	Output           interface{}       `json:"output,omitempty"`
+	Error            string            `json:"error,omitempty"`
+	ReActTrace       *ReActTrace       `json:"react_trace,omitempty"`
+	Classification   string            `json:"classification,omitempty"` // Label a conditional node chose
+	Model            string            `json:"model,omitempty"`          // Model that produced the output, after any fallback
+	OutputAttempts   []OutputAttempt   `json:"output_attempts,omitempty"` // Answers checked against the node's output contract, when one was rejected
+	Logs             []string          `json:"logs,omitempty"`         // Log lines emitted while the node ran (bounded)
+	LogsDropped      int               `json:"logs_dropped,omitempty"` // Earlier lines dropped from Logs
+}
+
+// OutputAttempt is one answer of a node checked against its output contract
+type OutputAttempt struct {
+	Attempt    int      `json:"attempt"`
+	Output     string   `json:"output"`
+	Violations []string `json:"violations,omitempty"` // Empty when the answer was accepted
+	Cost       float64  `json:"cost,omitempty"`
 }
 
 // ReActTrace holds iteration details for ReAct nodes
@@ -108,11 +234,13 @@ type ReActTrace struct {
 
 // ThinkingStep represents one iteration of ReAct thinking
 type ThinkingStep struct {
-	Iteration  int     `json:"iteration"`
-	Thought    string  `json:"thought"`
-	DurationMs int64   `json:"duration_ms"`
-	Cost       float64 `json:"cost"`
-	ToolCalls  []ToolCallTrace `json:"tool_calls,omitempty"` // Tool calls made in this iteration
+	Iteration        int             `json:"iteration"`
+	Thought          string          `json:"thought"`
+	DurationMs       int64           `json:"duration_ms"`
+	Cost             float64         `json:"cost"`
+	PromptTokens     int             `json:"prompt_tokens,omitempty"`
+	CompletionTokens int             `json:"completion_tokens,omitempty"`
+	ToolCalls        []ToolCallTrace `json:"tool_calls,omitempty"` // Tool calls made in this iteration
 }
 
 // ToolCallTrace represents a tool call during ReAct execution
@@ -122,4 +250,5 @@ type ToolCallTrace struct {
 	Result    interface{}            `json:"result,omitempty"`
 	Error     string                 `json:"error,omitempty"`
 	DurationMs int64                 `json:"duration_ms"`
+	Injections []string              `json:"injections,omitempty"` // Instruction-like passages found in the result, when checked
 }
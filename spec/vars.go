@@ -0,0 +1,109 @@
+package spec
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// varPattern matches ${name} references, and $${name} escapes of them
+var varPattern = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Render substitutes ${name} references in the goal, node prompts, tool
+// arguments and params with the spec's vars, after applying overrides (e.g. from
+// --var). Vars is updated to the values used, so the trace records them.
+// Write $${name} for a literal ${name}.
+//
+// Overriding a variable the spec does not declare, or referencing one it
+// does not define, is an error.
+func (s *AgentSpec) Render(overrides map[string]string) error {
+	var undeclared []string
+	for name := range overrides {
+		if _, ok := s.Vars[name]; !ok {
+			undeclared = append(undeclared, name)
+		}
+	}
+	if len(undeclared) > 0 {
+		sort.Strings(undeclared)
+		return fmt.Errorf("unknown variables: %s (not declared in vars)", strings.Join(undeclared, ", "))
+	}
+
+	vars := make(map[string]string, len(s.Vars))
+	for name, value := range s.Vars {
+		vars[name] = value
+	}
+	for name, value := range overrides {
+		vars[name] = value
+	}
+
+	r := &renderer{vars: vars, undefined: map[string]bool{}}
+	s.Goal = r.render(s.Goal)
+	for i := range s.Nodes {
+		node := &s.Nodes[i]
+		node.Prompt = r.render(node.Prompt)
+		node.ReActGoal = r.render(node.ReActGoal)
+		node.ThinkingPrompt = r.render(node.ThinkingPrompt)
+		for key, value := range node.ToolArguments {
+			node.ToolArguments[key] = r.renderValue(value)
+		}
+		for key, value := range node.Params {
+			node.Params[key] = r.renderValue(value)
+		}
+	}
+
+	if len(r.undefined) > 0 {
+		names := make([]string, 0, len(r.undefined))
+		for name := range r.undefined {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("undefined variables: %s (add them to vars or pass --var)", strings.Join(names, ", "))
+	}
+
+	if len(vars) > 0 {
+		s.Vars = vars
+	}
+	return nil
+}
+
+// renderer substitutes variables and remembers references it cannot resolve
+type renderer struct {
+	vars      map[string]string
+	undefined map[string]bool
+}
+
+func (r *renderer) render(text string) string {
+	return varPattern.ReplaceAllStringFunc(text, func(ref string) string {
+		if strings.HasPrefix(ref, "$$") {
+			return ref[1:]
+		}
+		name := ref[2 : len(ref)-1]
+		value, ok := r.vars[name]
+		if !ok {
+			r.undefined[name] = true
+			return ref
+		}
+		return value
+	})
+}
+
+// renderValue substitutes variables in the strings of a decoded JSON value
+func (r *renderer) renderValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return r.render(v)
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = r.renderValue(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = r.renderValue(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
@@ -0,0 +1,114 @@
+package spec
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CurrentVersion is the spec schema version this runtime reads and writes.
+//
+// AgentSpec.Version names the schema a spec is written against, as
+// MAJOR.MINOR (a trailing .PATCH is accepted and ignored). Minor versions
+// only add fields; a major version may change existing ones. Older specs
+// are migrated on parse, newer ones are rejected.
+const CurrentVersion = "1.1"
+
+// migration upgrades a decoded spec document from one schema version to the
+// next. apply may be nil when the newer version only added fields.
+type migration struct {
+	from, to string
+	apply    func(doc map[string]interface{}) error
+}
+
+// migrations lists every upgrade step, oldest first
+var migrations = []migration{
+	// 1.1 added vars, input_schema, output, prompt_ref, annotations, retrieve nodes, description and $schema
+	{from: "1.0", to: "1.1"},
+}
+
+// Warnings returns the problems found while parsing that did not stop the
+// spec from loading, such as a migration from an older version
+func (s *AgentSpec) Warnings() []Issue {
+	return s.warnings
+}
+
+// migrate upgrades a decoded spec document to CurrentVersion in place and
+// returns the schema version it started from. Documents without a version are left
+// for ValidateSpec to reject.
+func migrate(doc map[string]interface{}) (string, error) {
+	raw, ok := doc["version"].(string)
+	if !ok || raw == "" {
+		return "", nil
+	}
+
+	version, err := schemaVersion(raw)
+	if err != nil {
+		return "", err
+	}
+	if versionNewer(version, CurrentVersion) {
+		return "", fmt.Errorf("spec version %s is newer than this runtime supports (%s)", raw, CurrentVersion)
+	}
+
+	from := version
+	for version != CurrentVersion {
+		step, ok := findMigration(version)
+		if !ok {
+			return "", fmt.Errorf("spec version %s is no longer supported (current: %s)", raw, CurrentVersion)
+		}
+		if step.apply != nil {
+			if err := step.apply(doc); err != nil {
+				return "", fmt.Errorf("failed to migrate spec from %s to %s: %w", step.from, step.to, err)
+			}
+		}
+		version = step.to
+	}
+
+	if from != CurrentVersion {
+		doc["version"] = CurrentVersion
+	}
+	return from, nil
+}
+
+func findMigration(from string) (migration, bool) {
+	for _, m := range migrations {
+		if m.from == from {
+			return m, true
+		}
+	}
+	return migration{}, false
+}
+
+// schemaVersion reduces "1", "1.0" or "1.0.3" to the schema version "1.0"
+func schemaVersion(v string) (string, error) {
+	major, minor, err := parseVersion(v)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d.%d", major, minor), nil
+}
+
+// versionNewer reports whether schema version a is newer than b
+func versionNewer(a, b string) bool {
+	aMajor, aMinor, _ := parseVersion(a)
+	bMajor, bMinor, _ := parseVersion(b)
+	if aMajor != bMajor {
+		return aMajor > bMajor
+	}
+	return aMinor > bMinor
+}
+
+// parseVersion returns the major and minor parts of a version string
+func parseVersion(v string) (major, minor int, err error) {
+	parts := strings.Split(v, ".")
+	numbers := make([]int, len(parts))
+	for i, part := range parts {
+		if numbers[i], err = strconv.Atoi(part); err != nil || numbers[i] < 0 || len(parts) > 3 {
+			return 0, 0, fmt.Errorf("invalid spec version %q: expected MAJOR.MINOR", v)
+		}
+	}
+	if len(numbers) > 1 {
+		minor = numbers[1]
+	}
+	return numbers[0], minor, nil
+}
@@ -0,0 +1,199 @@
+package team
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrTooManyTurns is returned when no role has answered to end within the
+// team's max_turns
+var ErrTooManyTurns = errors.New("team exceeded its turn limit")
+
+// Message is one entry in the shared mailbox
+type Message struct {
+	Turn        int       `json:"turn"` // Turn that produced the message; 0 for the first
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	Content     string    `json:"content"`
+	ExecutionID string    `json:"execution_id,omitempty"` // Execution that produced the message
+	Cost        float64   `json:"cost,omitempty"`
+	Tokens      int       `json:"tokens,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+// Trace is the combined record of a team run
+type Trace struct {
+	TeamID    string    `json:"team_id,omitempty"`
+	Goal      string    `json:"goal"`
+	Status    string    `json:"status"` // completed or failed
+	Output    string    `json:"output,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Turns     int       `json:"turns"`
+	TotalCost float64   `json:"total_cost"`
+	Tokens    int       `json:"tokens"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	Messages  []Message `json:"messages"`
+}
+
+// Reply is the result of one role's agent run
+type Reply struct {
+	ExecutionID string
+	Output      string
+	Cost        float64
+	Tokens      int
+}
+
+// RunFunc runs a role's agent on input. Run calls it once per turn.
+type RunFunc func(ctx context.Context, role *Role, input string) (*Reply, error)
+
+// Run starts the conversation by sending input (or the goal, when input is
+// empty) to the start role and delivers messages one at a time until a role
+// replies to end. A role addresses its reply by starting it with a line
+// such as "TO: critic" or "TO: researcher, critic"; otherwise the reply goes
+// to the role's next. onMessage, if set, sees each message as it is posted.
+// The trace is returned even when Run fails.
+func Run(ctx context.Context, t *Team, input string, run RunFunc, onMessage func(Message)) (*Trace, error) {
+	trace := &Trace{TeamID: t.ID, Goal: t.Goal, StartedAt: time.Now()}
+	post := func(m Message) {
+		m.Time = time.Now()
+		trace.Messages = append(trace.Messages, m)
+		if onMessage != nil {
+			onMessage(m)
+		}
+	}
+
+	err := converse(ctx, t, input, run, trace, post)
+
+	trace.EndedAt = time.Now()
+	trace.Status = "completed"
+	if err != nil {
+		trace.Status = "failed"
+		trace.Error = err.Error()
+	}
+	return trace, err
+}
+
+func converse(ctx context.Context, t *Team, input string, run RunFunc, trace *Trace, post func(Message)) error {
+	if input == "" {
+		input = t.Goal
+	}
+	post(Message{From: User, To: t.Start, Content: input})
+	pending := []int{0}
+
+	for len(pending) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if trace.Turns == t.maxTurns() {
+			return fmt.Errorf("%w: %d turns without a reply to %s", ErrTooManyTurns, trace.Turns, End)
+		}
+
+		message := trace.Messages[pending[0]]
+		pending = pending[1:]
+		role := t.Role(message.To)
+		trace.Turns++
+
+		reply, err := run(ctx, role, transcript(t, role, trace.Messages))
+		if err != nil {
+			return fmt.Errorf("turn %d (%s): %w", trace.Turns, role.Name, err)
+		}
+		trace.TotalCost += reply.Cost
+		trace.Tokens += reply.Tokens
+
+		recipients, content, err := address(t, role, reply.Output)
+		if err != nil {
+			return fmt.Errorf("turn %d (%s): %w", trace.Turns, role.Name, err)
+		}
+
+		for i, to := range recipients {
+			m := Message{Turn: trace.Turns, From: role.Name, To: to, Content: content, ExecutionID: reply.ExecutionID}
+			if i == 0 {
+				// Count the run's usage once, however many recipients it has
+				m.Cost, m.Tokens = reply.Cost, reply.Tokens
+			}
+			post(m)
+			if to == End {
+				trace.Output = content
+				return nil
+			}
+			pending = append(pending, len(trace.Messages)-1)
+		}
+	}
+	return nil
+}
+
+// address splits a reply into its recipients and content. The first
+// non-blank line may name recipients as "TO: a, b".
+func address(t *Team, role *Role, output string) ([]string, string, error) {
+	content := strings.TrimSpace(output)
+	first, rest, _ := strings.Cut(content, "\n")
+
+	var recipients []string
+	if label, names, ok := strings.Cut(first, ":"); ok && strings.EqualFold(strings.TrimSpace(label), "to") {
+		for _, name := range strings.Split(names, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if name != End && t.Role(name) == nil {
+				return nil, "", fmt.Errorf("reply is addressed to unknown role %s", name)
+			}
+			recipients = append(recipients, name)
+		}
+		content = strings.TrimSpace(rest)
+	}
+
+	if len(recipients) == 0 {
+		next := role.Next
+		if next == "" {
+			next = End
+		}
+		recipients = []string{next}
+	}
+
+	// A reply to end finishes the run, so other recipients would never read it
+	for _, to := range recipients {
+		if to == End {
+			return []string{End}, content, nil
+		}
+	}
+	return recipients, content, nil
+}
+
+// transcript builds a role's input: the team's goal, the roles, the
+// conversation so far and how to address the reply
+func transcript(t *Team, role *Role, messages []Message) string {
+	names := make([]string, len(t.Roles))
+	for i, r := range t.Roles {
+		names[i] = r.Name
+	}
+	next := role.Next
+	if next == "" {
+		next = End
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Team goal: %s\n", t.Goal)
+	fmt.Fprintf(&b, "You are %s, in a team of: %s.\n\n", role.Name, strings.Join(names, ", "))
+	b.WriteString("Conversation so far:\n")
+	for _, m := range messages {
+		fmt.Fprintf(&b, "\n[%s -> %s]\n%s\n", m.From, m.To, m.Content)
+	}
+	fmt.Fprintf(&b, "\nReply to the latest message sent to you. To address your reply, start it with a line such as \"TO: %s\"; use \"TO: %s\" to give the team's final answer. Without that line your reply goes to %s.\n",
+		firstOther(names, role.Name), End, next)
+	return b.String()
+}
+
+// firstOther returns a role name other than self, for the addressing example
+func firstOther(names []string, self string) string {
+	for _, name := range names {
+		if name != self {
+			return name
+		}
+	}
+	return End
+}
@@ -0,0 +1,158 @@
+// Package team runs several agent specs as cooperating roles, such as a
+// planner, a researcher and a critic. Roles exchange messages through a
+// shared mailbox until one of them addresses its reply to "end"; the
+// whole conversation is recorded in a combined Trace.
+package team
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/not7/core/spec"
+)
+
+const (
+	// End is the recipient that finishes the conversation
+	End = "end"
+	// User is the sender of the first message
+	User = "user"
+
+	// DefaultMaxTurns bounds the turns of a team without max_turns
+	DefaultMaxTurns = 10
+)
+
+// rolePattern restricts role names so they can be addressed in replies
+var rolePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// Team is a multi-agent specification
+type Team struct {
+	Version  string `json:"version"`
+	ID       string `json:"id,omitempty"`
+	Goal     string `json:"goal"`
+	Roles    []Role `json:"roles"`
+	Start    string `json:"start"`               // Role that receives the first message
+	MaxTurns int    `json:"max_turns,omitempty"` // Agent runs before the team gives up (default 10)
+}
+
+// Role is one agent in a team
+type Role struct {
+	Name string `json:"name"`
+	Spec string `json:"spec"`           // Agent spec file, relative to the team file
+	Next string `json:"next,omitempty"` // Recipient of replies that name none (default end)
+
+	agent *spec.AgentSpec
+}
+
+// Agent returns the role's loaded agent spec
+func (r *Role) Agent() *spec.AgentSpec {
+	return r.agent
+}
+
+// Load reads a team file, validates it and loads each role's agent spec.
+// Prompt references in role specs are resolved against the spec's directory
+// and promptDirs.
+func Load(path string, promptDirs ...string) (*Team, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read team file: %w", err)
+	}
+
+	var t Team
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&t); err != nil {
+		return nil, fmt.Errorf("failed to parse team JSON: %w", err)
+	}
+	if err := t.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid team: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	for i := range t.Roles {
+		role := &t.Roles[i]
+		specFile := role.Spec
+		if !filepath.IsAbs(specFile) {
+			specFile = filepath.Join(dir, specFile)
+		}
+
+		agent, err := spec.LoadSpec(specFile)
+		if err != nil {
+			return nil, fmt.Errorf("role %s: %w", role.Name, err)
+		}
+		dirs := append([]string{filepath.Dir(specFile)}, promptDirs...)
+		if err := agent.ResolvePrompts(dirs...); err != nil {
+			return nil, fmt.Errorf("role %s: %w", role.Name, err)
+		}
+		role.agent = agent
+	}
+
+	return &t, nil
+}
+
+// Validate checks a team's structure; role specs are checked by Load
+func (t *Team) Validate() error {
+	if t.Version == "" {
+		return fmt.Errorf("version is required")
+	}
+	if t.Goal == "" {
+		return fmt.Errorf("goal is required")
+	}
+	if len(t.Roles) == 0 {
+		return fmt.Errorf("at least one role is required")
+	}
+	if t.MaxTurns < 0 {
+		return fmt.Errorf("max_turns must not be negative")
+	}
+
+	names := make(map[string]bool)
+	for _, role := range t.Roles {
+		if !rolePattern.MatchString(role.Name) {
+			return fmt.Errorf("role name %q must start with a letter and contain only letters, digits, '_' and '-'", role.Name)
+		}
+		if role.Name == End || role.Name == User {
+			return fmt.Errorf("role name %q is reserved", role.Name)
+		}
+		if names[role.Name] {
+			return fmt.Errorf("duplicate role: %s", role.Name)
+		}
+		names[role.Name] = true
+		if role.Spec == "" {
+			return fmt.Errorf("spec is required for role %s", role.Name)
+		}
+	}
+
+	for _, role := range t.Roles {
+		if role.Next != "" && role.Next != End && !names[role.Next] {
+			return fmt.Errorf("role %s: next refers to unknown role %s", role.Name, role.Next)
+		}
+	}
+	if t.Start == "" {
+		return fmt.Errorf("start is required")
+	}
+	if !names[t.Start] {
+		return fmt.Errorf("start refers to unknown role %s", t.Start)
+	}
+	return nil
+}
+
+// Role returns the role with the given name
+func (t *Team) Role(name string) *Role {
+	for i := range t.Roles {
+		if t.Roles[i].Name == name {
+			return &t.Roles[i]
+		}
+	}
+	return nil
+}
+
+// maxTurns returns the turn limit, applying the default
+func (t *Team) maxTurns() int {
+	if t.MaxTurns == 0 {
+		return DefaultMaxTurns
+	}
+	return t.MaxTurns
+}
@@ -2,16 +2,23 @@ package arcade
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
 	"time"
+
+	"github.com/not7/core/internal/vcr"
 )
 
 const (
 	baseURL       = "https://api.arcade.dev"
 	cacheDuration = 1 * time.Hour
+	toolsPageSize = 100
 )
 
 // Client handles Arcade API interactions
@@ -20,9 +27,15 @@ type Client struct {
 	userID     string
 	httpClient *http.Client
 
-	// Simple cache for tools
-	cachedTools   []Tool
-	cacheExpiry   time.Time
+	// Simple cache for tools, keyed by toolkit ("" = all toolkits)
+	mu    sync.Mutex
+	cache map[string]cachedTools
+}
+
+// cachedTools is a tool listing with its expiry time
+type cachedTools struct {
+	tools  []Tool
+	expiry time.Time
 }
 
 // NewClient creates a new Arcade API client
@@ -31,20 +44,54 @@ func NewClient(apiKey, userID string) *Client {
 		apiKey: apiKey,
 		userID: userID,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: vcr.Transport(),
 		},
+		cache: make(map[string]cachedTools),
 	}
 }
 
-// ListTools returns all available tools for a given toolkit (with 1-hour cache)
+// ListTools returns all available tools for a given toolkit, or for every
+// toolkit when toolkit is empty (with 1-hour cache)
 func (c *Client) ListTools(toolkit string) ([]Tool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	// Check cache
-	if time.Now().Before(c.cacheExpiry) && len(c.cachedTools) > 0 {
-		return c.cachedTools, nil
+	if cached, ok := c.cache[toolkit]; ok && time.Now().Before(cached.expiry) && len(cached.tools) > 0 {
+		return cached.tools, nil
 	}
 
-	// Fetch from API
-	url := fmt.Sprintf("%s/v1/tools?toolkit=%s&limit=100", baseURL, toolkit)
+	// Fetch from API, one page at a time
+	var items []Tool
+	for {
+		page, err := c.listToolsPage(toolkit, len(items))
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, page.Items...)
+		if len(page.Items) == 0 || len(items) >= page.TotalCount {
+			break
+		}
+	}
+
+	// Update cache
+	c.cache[toolkit] = cachedTools{tools: items, expiry: time.Now().Add(cacheDuration)}
+
+	return items, nil
+}
+
+// listToolsPage fetches a single page of the tools listing
+func (c *Client) listToolsPage(toolkit string, offset int) (*ToolsResponse, error) {
+	params := url.Values{}
+	if toolkit != "" {
+		params.Set("toolkit", toolkit)
+	}
+	params.Set("limit", strconv.Itoa(toolsPageSize))
+	params.Set("offset", strconv.Itoa(offset))
+
+	url := fmt.Sprintf("%s/v1/tools?%s", baseURL, params.Encode())
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -70,11 +117,7 @@ func (c *Client) ListTools(toolkit string) ([]Tool, error) {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	// Update cache
-	c.cachedTools = toolsResp.Items
-	c.cacheExpiry = time.Now().Add(cacheDuration)
-
-	return toolsResp.Items, nil
+	return &toolsResp, nil
 }
 
 // ExecuteTool executes an Arcade tool
@@ -222,3 +265,28 @@ func (c *Client) CheckAuthStatus(authID string, waitSeconds int) (*AuthStatusRes
 
 	return &statusResp, nil
 }
+
+// Ping verifies the API key and connectivity with a minimal tools listing
+func (c *Client) Ping(ctx context.Context) error {
+	url := fmt.Sprintf("%s/v1/tools?limit=1", baseURL)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Arcade: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
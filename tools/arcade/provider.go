@@ -3,8 +3,11 @@ package arcade
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/not7/core/internal/ui"
 	"github.com/not7/core/tools"
 )
 
@@ -15,6 +18,26 @@ type Provider struct {
 	toolNameMap map[string]string        // Maps short name (e.g., "SendEmail") to fully qualified name (e.g., "Gmail.SendEmail@3.2.1")
 }
 
+// DefaultToolkit is used by the plain "arcade" provider name
+const DefaultToolkit = "Gmail"
+
+// ToolkitFromProvider resolves the toolkit of an "arcade" or "arcade-{toolkit}"
+// provider name (e.g., "arcade-spotify" → "Spotify"). ok is false when the
+// name does not refer to Arcade.
+func ToolkitFromProvider(provider string) (toolkit string, ok bool) {
+	if provider == "arcade" {
+		return DefaultToolkit, true
+	}
+
+	name, found := strings.CutPrefix(provider, "arcade-")
+	if !found || name == "" {
+		return "", false
+	}
+
+	// Capitalize first letter for API compatibility
+	return strings.ToUpper(name[:1]) + name[1:], true
+}
+
 // NewProvider creates a new Arcade provider for a specific toolkit
 func NewProvider(apiKey, userID, toolkit string) *Provider {
 	return &Provider{
@@ -58,26 +81,105 @@ func (p *Provider) CheckAndHandleAuthorization(ctx context.Context) error {
 	}
 
 	// Check if already authorized
-	if tools[0].Requirements.Authorization != nil && tools[0].Requirements.Authorization.Status == "active" {
+	if toolAuthStatus(tools[0]) == "active" {
 		return nil // Already authorized
 	}
 
 	// Need authorization - initiate OAuth flow
+	if err := p.interactiveAuthorize(ctx, tools[0].FullyQualifiedName); err != nil {
+		return err
+	}
+
+	ui.Infoln("🚀 Continuing with agent execution...")
+	ui.Infoln()
+	return nil
+}
+
+// Authorize runs the OAuth flow for the provider's toolkit if it is not
+// authorized yet
+func (p *Provider) Authorize(ctx context.Context) error {
+	tools, err := p.client.ListTools(p.toolkit)
+	if err != nil {
+		return fmt.Errorf("failed to check authorization status: %w", err)
+	}
+
+	if len(tools) == 0 {
+		return fmt.Errorf("no %s tools available", p.toolkit)
+	}
+
+	// All tools of a toolkit typically share the same OAuth authorization
+	if toolAuthStatus(tools[0]) == "active" {
+		ui.Infof("✅ %s is already authorized\n", p.toolkit)
+		return nil
+	}
+
 	return p.interactiveAuthorize(ctx, tools[0].FullyQualifiedName)
 }
 
+// AuthorizationStatus reports the authorization state of every Arcade toolkit
+func (p *Provider) AuthorizationStatus(ctx context.Context) ([]tools.AuthStatus, error) {
+	arcadeTools, err := p.client.ListTools("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Arcade tools: %w", err)
+	}
+
+	byToolkit := make(map[string]*tools.AuthStatus)
+	var statuses []*tools.AuthStatus
+	for _, tool := range arcadeTools {
+		toolkit := tool.Toolkit.Name
+		if toolkit == "" {
+			toolkit, _, _ = strings.Cut(tool.QualifiedName, ".")
+		}
+
+		status, ok := byToolkit[toolkit]
+		if !ok {
+			status = &tools.AuthStatus{Toolkit: toolkit, Status: "not_required"}
+			byToolkit[toolkit] = status
+			statuses = append(statuses, status)
+		}
+		status.Tools++
+
+		// A toolkit needs authorization as soon as one of its tools does
+		if toolStatus := toolAuthStatus(tool); toolStatus != "not_required" && status.Status != "active" {
+			status.Status = toolStatus
+		}
+	}
+
+	result := make([]tools.AuthStatus, 0, len(statuses))
+	for _, status := range statuses {
+		result = append(result, *status)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Toolkit < result[j].Toolkit
+	})
+
+	return result, nil
+}
+
+// toolAuthStatus returns the authorization state of a single tool
+func toolAuthStatus(tool Tool) string {
+	auth := tool.Requirements.Authorization
+	if auth == nil {
+		return "not_required"
+	}
+	if auth.Status == "" {
+		return "not_authorized"
+	}
+	return auth.Status
+}
+
 // interactiveAuthorize handles the interactive OAuth authorization flow
 func (p *Provider) interactiveAuthorize(ctx context.Context, toolName string) error {
-	fmt.Println()
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println()
-	fmt.Printf("  🔐 %s Authorization Required\n", p.toolkit)
-	fmt.Println()
-	fmt.Printf("  This agent requires access to %s.\n", p.toolkit)
-	fmt.Println("  Please authorize to continue...")
-	fmt.Println()
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println()
+	ui.Infoln()
+	ui.Infoln("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	ui.Infoln()
+	ui.Infof("  🔐 %s Authorization Required\n", p.toolkit)
+	ui.Infoln()
+	ui.Infof("  This agent requires access to %s.\n", p.toolkit)
+	ui.Infoln("  Please authorize to continue...")
+	ui.Infoln()
+	ui.Infoln("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	ui.Infoln()
 
 	// Initiate authorization
 	authResp, err := p.client.AuthorizeTool(toolName)
@@ -86,7 +188,7 @@ func (p *Provider) interactiveAuthorize(ctx context.Context, toolName string) er
 	}
 
 	if authResp.Status == "completed" {
-		fmt.Println("✅ Already authorized!")
+		ui.Infoln("✅ Already authorized!")
 		return nil
 	}
 
@@ -95,15 +197,15 @@ func (p *Provider) interactiveAuthorize(ctx context.Context, toolName string) er
 	}
 
 	// Display authorization URL
-	fmt.Println("📋 Authorization URL:")
-	fmt.Println()
-	fmt.Println("  " + authResp.AuthorizationURL)
-	fmt.Println()
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println()
-	fmt.Println("⏳ Waiting for authorization (timeout: 5 minutes)...")
-	fmt.Println("   Press Ctrl+C to cancel")
-	fmt.Println()
+	ui.Infoln("📋 Authorization URL:")
+	ui.Infoln()
+	ui.Println("  " + authResp.AuthorizationURL)
+	ui.Infoln()
+	ui.Infoln("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	ui.Infoln()
+	ui.Infoln("⏳ Waiting for authorization (timeout: 5 minutes)...")
+	ui.Infoln("   Press Ctrl+C to cancel")
+	ui.Infoln()
 
 	// Poll for authorization completion
 	timeout := time.After(5 * time.Minute)
@@ -125,12 +227,10 @@ func (p *Provider) interactiveAuthorize(ctx context.Context, toolName string) er
 			}
 
 			if statusResp.Status == "completed" {
-				fmt.Println("✅ Authorization completed!")
-				fmt.Println()
-				fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-				fmt.Println()
-				fmt.Println("🚀 Continuing with agent execution...")
-				fmt.Println()
+				ui.Infoln("✅ Authorization completed!")
+				ui.Infoln()
+				ui.Infoln("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+				ui.Infoln()
 				return nil
 			}
 
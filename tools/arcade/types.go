@@ -6,7 +6,10 @@ type Tool struct {
 	QualifiedName      string `json:"qualified_name"`
 	Name               string `json:"name"`
 	Description        string `json:"description"`
-	Input              struct {
+	Toolkit            struct {
+		Name string `json:"name"`
+	} `json:"toolkit"`
+	Input struct {
 		Parameters []map[string]interface{} `json:"parameters"`
 	} `json:"input"`
 	Requirements struct {
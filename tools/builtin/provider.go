@@ -3,6 +3,7 @@ package builtin
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/not7/core/internal/vcr"
 	"github.com/not7/core/tools"
 )
 
@@ -24,11 +26,25 @@ func NewProvider(serpAPIKey string) *Provider {
 	return &Provider{
 		serpAPIKey: serpAPIKey,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:       30 * time.Second,
+			Transport:     vcr.Transport(),
+			CheckRedirect: checkRedirect,
 		},
 	}
 }
 
+// checkRedirect follows a redirect only to a URL the request's URL check
+// allows, so an allowed host cannot send WebFetch to a denied one
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if err := tools.CheckURL(req.Context(), req.URL.String()); err != nil {
+		return fmt.Errorf("redirect to %s: %w", req.URL.Redacted(), err)
+	}
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	return nil
+}
+
 // Initialize sets up the provider
 func (p *Provider) Initialize(config map[string]string) error {
 	if apiKey, ok := config["serp_api_key"]; ok && apiKey != "" {
@@ -296,6 +312,29 @@ func removeTagContent(html, tagName string) string {
 	return html
 }
 
+// Ping verifies the SerpAPI key and connectivity via the account endpoint
+func (p *Provider) Ping(ctx context.Context) error {
+	apiURL := fmt.Sprintf("https://serpapi.com/account?api_key=%s", url.QueryEscape(p.serpAPIKey))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach SerpAPI: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("SerpAPI error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
 // GetProviderName returns the provider identifier
 func (p *Provider) GetProviderName() string {
 	return "builtin"
@@ -2,11 +2,18 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/not7/core/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/not7/core/tools")
+
 // Manager coordinates tool providers and execution
 type Manager struct {
 	providers map[string]ToolProvider
@@ -55,7 +62,17 @@ func (m *Manager) RegisterProvider(provider ToolProvider) error {
 }
 
 // ExecuteTool executes a tool by name
-func (m *Manager) ExecuteTool(ctx context.Context, toolName string, arguments map[string]interface{}) (*ToolResult, error) {
+func (m *Manager) ExecuteTool(ctx context.Context, toolName string, arguments map[string]interface{}) (result *ToolResult, err error) {
+	ctx, span := tracer.Start(ctx, "tool "+toolName, trace.WithAttributes(tracing.ToolNameKey.String(toolName)))
+	defer func() {
+		// A tool that ran but reported failure still marks the span as failed
+		failure := err
+		if failure == nil && result != nil && !result.Success {
+			failure = errors.New(result.Error)
+		}
+		tracing.End(span, failure)
+	}()
+
 	// Get tool definition
 	toolDef, err := m.registry.Get(toolName)
 	if err != nil {
@@ -72,7 +89,7 @@ func (m *Manager) ExecuteTool(ctx context.Context, toolName string, arguments ma
 	}
 
 	// Execute tool
-	result, err := provider.ExecuteTool(ctx, toolName, arguments)
+	result, err = provider.ExecuteTool(ctx, toolName, arguments)
 	if err != nil {
 		return nil, NewToolError(toolName, "execution failed", err)
 	}
@@ -96,8 +96,14 @@ func (r *Registry) GetToolContext() string {
 
 		if tool.InputSchema != nil && len(tool.InputSchema) > 0 {
 			sb.WriteString("   Parameters:\n")
-			for key, val := range tool.InputSchema {
-				sb.WriteString(fmt.Sprintf("     - %s: %v\n", key, val))
+			// Sorted so the same tools always give the same prompt
+			keys := make([]string, 0, len(tool.InputSchema))
+			for key := range tool.InputSchema {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				sb.WriteString(fmt.Sprintf("     - %s: %v\n", key, tool.InputSchema[key]))
 			}
 		}
 		sb.WriteString("\n")
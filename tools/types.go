@@ -16,6 +16,16 @@ type ToolResult struct {
 	Output  interface{}            `json:"output"`
 	Error   string                 `json:"error,omitempty"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// Artifacts are files the tool produced, saved with the execution
+	Artifacts []Artifact `json:"-"`
+}
+
+// Artifact is a named file produced by a tool
+type Artifact struct {
+	Name        string
+	ContentType string // Detected from the name or content when empty
+	Data        []byte
 }
 
 // ToolCall represents a tool invocation request
@@ -43,9 +53,44 @@ type ToolProvider interface {
 	Close() error
 }
 
+// AuthStatus describes the authorization state of one toolkit of a provider
+type AuthStatus struct {
+	Toolkit string `json:"toolkit"`
+	Status  string `json:"status"` // "active", "pending", "failed", "not_authorized" or "not_required"
+	Tools   int    `json:"tools"`
+}
+
+// Authorizer is implemented by providers whose tools need the user to grant
+// access first (e.g., OAuth for Arcade toolkits)
+type Authorizer interface {
+	// AuthorizationStatus reports the state of every toolkit the provider offers
+	AuthorizationStatus(ctx context.Context) ([]AuthStatus, error)
+
+	// Authorize runs the interactive authorization flow for the provider's
+	// toolkit. It returns nil right away when access is already granted.
+	Authorize(ctx context.Context) error
+}
+
 // ToolConfig represents tool configuration in agent spec
 type ToolConfig struct {
 	Provider string            `json:"provider"` // "builtin" or "mcp"
 	Config   map[string]string `json:"config"`   // Provider-specific config
 	Enabled  []string          `json:"enabled"`  // List of enabled tool names (optional, empty = all)
 }
+
+// urlCheckKey carries the check of the URLs tools fetch in a context
+type urlCheckKey struct{}
+
+// WithURLCheck returns a context in which tools that fetch URLs, such as
+// WebFetch, run check on every URL they request, redirects included
+func WithURLCheck(ctx context.Context, check func(rawURL string) error) context.Context {
+	return context.WithValue(ctx, urlCheckKey{}, check)
+}
+
+// CheckURL runs the URL check of ctx on a URL, if ctx has one
+func CheckURL(ctx context.Context, rawURL string) error {
+	if check, ok := ctx.Value(urlCheckKey{}).(func(rawURL string) error); ok {
+		return check(rawURL)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// RequestIDHeader carries the correlation ID of an API request. The server
+// accepts it from clients, generates one when it is missing, and returns it
+// on every response.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds accepted client-supplied request IDs
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// NewRequestID returns a random 32-character hex request ID
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// ValidRequestID reports whether a client-supplied request ID is safe to
+// echo into logs and headers: at most 128 letters, digits, '-', '_', '.' or ':'
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID in ctx, or "" if there is none
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
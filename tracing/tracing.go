@@ -0,0 +1,111 @@
+// Package tracing exports OpenTelemetry spans for agent executions over
+// OTLP/HTTP, so runs show up in Jaeger, Tempo or any other OTLP backend.
+//
+// Spans form the tree execution → node → LLM call / tool call. Packages
+// create them with otel.Tracer; until Setup installs a provider they are
+// no-ops and cost nothing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/not7/core/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys shared by the NOT7 spans. LLM calls additionally use the
+// OpenTelemetry gen_ai.* conventions.
+const (
+	ExecutionIDKey = attribute.Key("not7.execution.id")
+	RequestIDKey   = attribute.Key("not7.request_id")
+	AgentIDKey     = attribute.Key("not7.agent.id")
+	AgentGoalKey   = attribute.Key("not7.agent.goal")
+	NodeIDKey      = attribute.Key("not7.node.id")
+	NodeTypeKey    = attribute.Key("not7.node.type")
+	ToolNameKey    = attribute.Key("not7.tool.name")
+	CostKey        = attribute.Key("not7.cost_usd")
+
+	PromptTokensKey     = attribute.Key("not7.usage.prompt_tokens")
+	CompletionTokensKey = attribute.Key("not7.usage.completion_tokens")
+)
+
+// Setup installs a global tracer provider that exports to cfg.Endpoint, or
+// to the standard OTEL_EXPORTER_OTLP_* variables when the config leaves it
+// empty. Without either, tracing stays disabled and Setup does nothing.
+// The returned function flushes pending spans and must be called on exit.
+func Setup(ctx context.Context, cfg config.TracingConfig) (func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+
+	var opts []otlptracehttp.Option
+	if cfg.Endpoint != "" {
+		endpoint, err := tracesURL(cfg.Endpoint)
+		if err != nil {
+			return noop, err
+		}
+		opts = append(opts, otlptracehttp.WithEndpointURL(endpoint))
+	} else if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return noop, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return noop, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = "not7"
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return noop, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// End records err on span, if any, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// tracesURL appends the OTLP traces path to a collector base URL, matching
+// how OTEL_EXPORTER_OTLP_ENDPOINT is interpreted
+func tracesURL(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid OTLP endpoint %q", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/traces"
+	} else if !strings.HasSuffix(u.Path, "/v1/traces") {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/v1/traces"
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,89 @@
+// Package webhook signs the HTTP deliveries NOT7 makes to user endpoints,
+// so receivers can check that a payload came from NOT7 and is recent.
+//
+// A signed request carries the Unix time it was sent in TimestampHeader and
+// "sha256=" plus the hex HMAC-SHA256 of "<timestamp>.<body>", keyed with the
+// endpoint's secret, in SignatureHeader. Receivers written in Go can call
+// Verify; others recompute the HMAC and compare it in constant time.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SignatureHeader holds the request's signature
+	SignatureHeader = "X-NOT7-Signature"
+
+	// TimestampHeader holds the Unix time the request was signed
+	TimestampHeader = "X-NOT7-Timestamp"
+
+	// DefaultTolerance is how old a signed request Verify accepts by default
+	DefaultTolerance = 5 * time.Minute
+)
+
+// signaturePrefix names the signature's algorithm
+const signaturePrefix = "sha256="
+
+var (
+	// ErrMissingSignature is returned by Verify for an unsigned request
+	ErrMissingSignature = errors.New("request is not signed")
+
+	// ErrInvalidSignature is returned by Verify when the signature does not
+	// match the body and timestamp
+	ErrInvalidSignature = errors.New("signature does not match")
+
+	// ErrExpired is returned by Verify for a request signed too long ago,
+	// which may be a replay
+	ErrExpired = errors.New("signature timestamp is outside the tolerance")
+)
+
+// Signature returns the signature of body sent at timestamp
+func Signature(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sign sets the timestamp and signature headers of req, whose body is body.
+// With an empty secret, req is left unsigned.
+func Sign(req *http.Request, secret string, body []byte) {
+	if secret == "" {
+		return
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Signature(secret, timestamp, body))
+}
+
+// Verify checks that header signs body with secret, at most tolerance ago
+// (DefaultTolerance when zero)
+func Verify(secret string, header http.Header, body []byte, tolerance time.Duration) error {
+	signature := header.Get(SignatureHeader)
+	if signature == "" || header.Get(TimestampHeader) == "" {
+		return ErrMissingSignature
+	}
+	timestamp, err := strconv.ParseInt(header.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if tolerance == 0 {
+		tolerance = DefaultTolerance
+	}
+	if age := time.Since(time.Unix(timestamp, 0)); age > tolerance || age < -tolerance {
+		return ErrExpired
+	}
+	if !strings.HasPrefix(signature, signaturePrefix) || !hmac.Equal([]byte(signature), []byte(Signature(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}